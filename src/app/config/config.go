@@ -3,38 +3,119 @@ package config
 import (
 	"github.com/joho/godotenv"
 	"github.com/rs/zerolog/log"
+	"net"
 	"os"
 	"strconv"
+	"strings"
 	"sync"
 )
 
 type (
-	// AppConfig type definition
+	// AppConfig type definition, the client IP address is read from X-Forwarded-For only on requests
+	// coming from one of TrustedProxies, it is the address of the peer otherwise
 	AppConfig struct {
-		Debug bool
-		Port  int
-		Addr  string
+		Debug          bool
+		Port           int
+		Addr           string
+		TrustedProxies []*net.IPNet
 	}
-	// DBConfig type definition
+	// DBConfig type definition, every query is cancelled after QueryTimeout seconds, 0 disables the timeout
 	DBConfig struct {
-		Driver      string
-		Name        string
-		User        string
-		Pass        string
-		Host        string
-		Port        string
-		ExposedPort string
-		Path        string
-	}
-	// JwtConfig type definition
+		Driver       string
+		Name         string
+		User         string
+		Pass         string
+		Host         string
+		Port         string
+		ExposedPort  string
+		Path         string
+		QueryTimeout int
+	}
+	// JwtKeyConfig type definition, a PEM key file identified by its kid
+	JwtKeyConfig struct {
+		ID   string
+		Path string
+	}
+	// JwtConfig type definition, Leeway is the allowed clock skew in seconds
 	JwtConfig struct {
-		Secret string
+		Secret    string
+		Algorithm string
+		KeyID     string
+		Keys      []JwtKeyConfig
+		Issuer    string
+		Audience  string
+		Leeway    int
+	}
+	// MailConfig type definition, Driver is either log (mails are only logged) or smtp
+	MailConfig struct {
+		Driver string
+		Host   string
+		Port   int
+		User   string
+		Pass   string
+		From   string
+	}
+	// LoginConfig type definition, login failures are tracked per account and per IP address in Store,
+	// either sql or memory (failures are only known by the instance that received them), an account or
+	// an IP address is locked for LockDuration seconds after MaxAccountFailures or MaxIPFailures failures,
+	// the two factor codes of a user after MaxTwoFactorFailures wrong codes,
+	// doubled on every following lockout up to MaxLockDuration, failures are forgotten after
+	// FailureWindow seconds without failure nor lock
+	LoginConfig struct {
+		Store                string
+		MaxAccountFailures   int
+		MaxIPFailures        int
+		MaxTwoFactorFailures int
+		FailureWindow        int
+		LockDuration         int
+		MaxLockDuration      int
+	}
+	// RateLimitConfig type definition, every client IP address is limited to Requests requests per
+	// sliding Window seconds, counters are kept in Store, either memory (counters are only known by the
+	// instance that received the requests) or redis
+	RateLimitConfig struct {
+		Store    string
+		Requests int
+		Window   int
+	}
+	// RedisConfig type definition
+	RedisConfig struct {
+		Addr     string
+		Password string
+		DB       int
+	}
+	// MFAConfig type definition, Issuer names the application in authenticator apps and EncryptionKey
+	// derives the key encrypting the TOTP secrets at rest
+	MFAConfig struct {
+		Issuer        string
+		EncryptionKey string
+	}
+	// OIDCProviderConfig type definition, an OpenID Connect provider users can sign in with, its
+	// endpoints are discovered from Issuer, RedirectURL is the callback route of the provider
+	OIDCProviderConfig struct {
+		Name         string
+		Issuer       string
+		ClientID     string
+		ClientSecret string
+		RedirectURL  string
+		Scopes       []string
+	}
+	// OIDCConfig type definition, StateKey derives the key encrypting the social login state cookie
+	OIDCConfig struct {
+		Providers []OIDCProviderConfig
+		StateKey  string
 	}
 	// GlobalConfig type definition
 	GlobalConfig struct {
-		App AppConfig
-		DB  DBConfig
-		Jwt JwtConfig
+		App       AppConfig
+		DB        DBConfig
+		Jwt       JwtConfig
+		Mail      MailConfig
+		Login     LoginConfig
+		RateLimit RateLimitConfig
+		Redis     RedisConfig
+		MFA       MFAConfig
+		OIDC      OIDCConfig
 	}
 )
 
@@ -49,16 +130,50 @@ var (
 			Addr:  ":5000",
 		},
 		DB: DBConfig{
-			Driver:      "mysql",
-			Name:        "sherman",
-			User:        "db_user",
-			Pass:        "db_password",
-			Host:        "app-mysql",
-			Port:        "3306",
-			ExposedPort: "5001",
+			Driver:       "mysql",
+			Name:         "sherman",
+			User:         "db_user",
+			Pass:         "db_password",
+			Host:         "app-mysql",
+			Port:         "3306",
+			ExposedPort:  "5001",
+			QueryTimeout: 5,
 		},
 		Jwt: JwtConfig{
-			Secret: "jwt_secret",
+			Secret:    "jwt_secret",
+			Algorithm: "HS256",
+			Issuer:    "sherman",
+			Audience:  "sherman",
+			Leeway:    30,
+		},
+		Mail: MailConfig{
+			Driver: "log",
+			Port:   587,
+			From:   "no-reply@sherman.local",
+		},
+		Login: LoginConfig{
+			Store:                "sql",
+			MaxAccountFailures:   5,
+			MaxIPFailures:        20,
+			MaxTwoFactorFailures: 5,
+			FailureWindow:        900,
+			LockDuration:         60,
+			MaxLockDuration:      3600,
+		},
+		RateLimit: RateLimitConfig{
+			Store:    "memory",
+			Requests: 300,
+			Window:   60,
+		},
+		Redis: RedisConfig{
+			Addr: "localhost:6379",
+		},
+		MFA: MFAConfig{
+			Issuer:        "Sherman",
+			EncryptionKey: "mfa_encryption_key",
+		},
+		OIDC: OIDCConfig{
+			StateKey: "oidc_state_key",
 		},
 	}
 )
@@ -87,21 +202,66 @@ func Get() *GlobalConfig {
 func generateConfig(envMap map[string]string) *GlobalConfig {
 	return &GlobalConfig{
 		App: AppConfig{
-			Debug: getKeyAsBool(envMap, "APP_DEBUG", DefaultConfig.App.Debug),
-			Port:  getKeyAsInt(envMap, "APP_PORT", DefaultConfig.App.Port),
-			Addr:  getKey(envMap, "APP_ADDR", DefaultConfig.App.Addr),
+			Debug:          getKeyAsBool(envMap, "APP_DEBUG", DefaultConfig.App.Debug),
+			Port:           getKeyAsInt(envMap, "APP_PORT", DefaultConfig.App.Port),
+			Addr:           getKey(envMap, "APP_ADDR", DefaultConfig.App.Addr),
+			TrustedProxies: getKeyAsIPNets(envMap, "APP_TRUSTED_PROXIES", DefaultConfig.App.TrustedProxies),
 		},
 		DB: DBConfig{
-			Driver:      getKey(envMap, "DB_DRIVER", DefaultConfig.DB.Driver),
-			Name:        getKey(envMap, "DB_NAME", DefaultConfig.DB.Name),
-			User:        getKey(envMap, "DB_USER", DefaultConfig.DB.User),
-			Pass:        getKey(envMap, "DB_PASS", DefaultConfig.DB.Pass),
-			Host:        getKey(envMap, "DB_HOST", DefaultConfig.DB.Host),
-			Port:        getKey(envMap, "DB_PORT", DefaultConfig.DB.Port),
-			ExposedPort: getKey(envMap, "DB_EXPOSED_PORT", DefaultConfig.DB.ExposedPort),
-			Path:        getKey(envMap, "DB_PATH", ""),
+			Driver:       getKey(envMap, "DB_DRIVER", DefaultConfig.DB.Driver),
+			Name:         getKey(envMap, "DB_NAME", DefaultConfig.DB.Name),
+			User:         getKey(envMap, "DB_USER", DefaultConfig.DB.User),
+			Pass:         getKey(envMap, "DB_PASS", DefaultConfig.DB.Pass),
+			Host:         getKey(envMap, "DB_HOST", DefaultConfig.DB.Host),
+			Port:         getKey(envMap, "DB_PORT", DefaultConfig.DB.Port),
+			ExposedPort:  getKey(envMap, "DB_EXPOSED_PORT", DefaultConfig.DB.ExposedPort),
+			Path:         getKey(envMap, "DB_PATH", ""),
+			QueryTimeout: getKeyAsInt(envMap, "DB_QUERY_TIMEOUT", DefaultConfig.DB.QueryTimeout),
+		},
+		Jwt: JwtConfig{
+			Secret:    getKey(envMap, "JWT_SECRET", DefaultConfig.Jwt.Secret),
+			Algorithm: getKey(envMap, "JWT_ALGORITHM", DefaultConfig.Jwt.Algorithm),
+			KeyID:     getKey(envMap, "JWT_KEY_ID", DefaultConfig.Jwt.KeyID),
+			Keys:      getKeyAsJwtKeys(envMap, "JWT_KEYS", DefaultConfig.Jwt.Keys),
+			Issuer:    getKey(envMap, "JWT_ISSUER", DefaultConfig.Jwt.Issuer),
+			Audience:  getKey(envMap, "JWT_AUDIENCE", DefaultConfig.Jwt.Audience),
+			Leeway:    getKeyAsInt(envMap, "JWT_LEEWAY", DefaultConfig.Jwt.Leeway),
+		},
+		Mail: MailConfig{
+			Driver: getKey(envMap, "MAIL_DRIVER", DefaultConfig.Mail.Driver),
+			Host:   getKey(envMap, "MAIL_HOST", DefaultConfig.Mail.Host),
+			Port:   getKeyAsInt(envMap, "MAIL_PORT", DefaultConfig.Mail.Port),
+			User:   getKey(envMap, "MAIL_USER", DefaultConfig.Mail.User),
+			Pass:   getKey(envMap, "MAIL_PASS", DefaultConfig.Mail.Pass),
+			From:   getKey(envMap, "MAIL_FROM", DefaultConfig.Mail.From),
+		},
+		Login: LoginConfig{
+			Store:                getKey(envMap, "LOGIN_STORE", DefaultConfig.Login.Store),
+			MaxAccountFailures:   getKeyAsInt(envMap, "LOGIN_MAX_ACCOUNT_FAILURES", DefaultConfig.Login.MaxAccountFailures),
+			MaxIPFailures:        getKeyAsInt(envMap, "LOGIN_MAX_IP_FAILURES", DefaultConfig.Login.MaxIPFailures),
+			MaxTwoFactorFailures: getKeyAsInt(envMap, "LOGIN_MAX_TWO_FACTOR_FAILURES", DefaultConfig.Login.MaxTwoFactorFailures),
+			FailureWindow:        getKeyAsInt(envMap, "LOGIN_FAILURE_WINDOW", DefaultConfig.Login.FailureWindow),
+			LockDuration:         getKeyAsInt(envMap, "LOGIN_LOCK_DURATION", DefaultConfig.Login.LockDuration),
+			MaxLockDuration:      getKeyAsInt(envMap, "LOGIN_MAX_LOCK_DURATION", DefaultConfig.Login.MaxLockDuration),
+		},
+		RateLimit: RateLimitConfig{
+			Store:    getKey(envMap, "RATE_LIMIT_STORE", DefaultConfig.RateLimit.Store),
+			Requests: getKeyAsInt(envMap, "RATE_LIMIT_REQUESTS", DefaultConfig.RateLimit.Requests),
+			Window:   getKeyAsInt(envMap, "RATE_LIMIT_WINDOW", DefaultConfig.RateLimit.Window),
+		},
+		Redis: RedisConfig{
+			Addr:     getKey(envMap, "REDIS_ADDR", DefaultConfig.Redis.Addr),
+			Password: getKey(envMap, "REDIS_PASSWORD", DefaultConfig.Redis.Password),
+			DB:       getKeyAsInt(envMap, "REDIS_DB", DefaultConfig.Redis.DB),
+		},
+		MFA: MFAConfig{
+			Issuer:        getKey(envMap, "MFA_ISSUER", DefaultConfig.MFA.Issuer),
+			EncryptionKey: getKey(envMap, "MFA_ENCRYPTION_KEY", DefaultConfig.MFA.EncryptionKey),
+		},
+		OIDC: OIDCConfig{
+			Providers: getKeyAsOIDCProviders(envMap, "OIDC_PROVIDERS", DefaultConfig.OIDC.Providers),
+			StateKey:  getKey(envMap, "OIDC_STATE_KEY", DefaultConfig.OIDC.StateKey),
 		},
-		Jwt: JwtConfig{Secret: getKey(envMap, "JWT_SECRET", DefaultConfig.Jwt.Secret)},
 	}
 }
 
@@ -127,3 +287,70 @@ func getKeyAsInt(env map[string]string, key string, defaultValue int) int {
 	}
 	return defaultValue
 }
+
+// getKeyAsJwtKeys parses a comma separated list of kid:path pairs
+func getKeyAsJwtKeys(env map[string]string, key string, defaultValue []JwtKeyConfig) []JwtKeyConfig {
+	valueStr := getKey(env, key, "")
+	if valueStr == "" {
+		return defaultValue
+	}
+
+	var keys []JwtKeyConfig
+	for _, pair := range strings.Split(valueStr, ",") {
+		idAndPath := strings.SplitN(strings.TrimSpace(pair), ":", 2)
+		if len(idAndPath) != 2 || idAndPath[0] == "" || idAndPath[1] == "" {
+			log.Error().Msg("config error: invalid " + key + " entry " + pair + ", expected kid:path")
+			continue
+		}
+		keys = append(keys, JwtKeyConfig{ID: idAndPath[0], Path: idAndPath[1]})
+	}
+	return keys
+}
+
+// getKeyAsIPNets parses a comma separated list of IP ranges in CIDR notation
+func getKeyAsIPNets(env map[string]string, key string, defaultValue []*net.IPNet) []*net.IPNet {
+	valueStr := getKey(env, key, "")
+	if valueStr == "" {
+		return defaultValue
+	}
+
+	var ipNets []*net.IPNet
+	for _, cidr := range strings.Split(valueStr, ",") {
+		_, ipNet, err := net.ParseCIDR(strings.TrimSpace(cidr))
+		if err != nil {
+			log.Error().Msg("config error: invalid " + key + " entry " + cidr + ", expected an IP range in CIDR notation")
+			continue
+		}
+		ipNets = append(ipNets, ipNet)
+	}
+	return ipNets
+}
+
+// getKeyAsOIDCProviders parses a comma separated list of provider names, each provider is configured
+// by the OIDC_<NAME>_ISSUER, _CLIENT_ID, _CLIENT_SECRET, _REDIRECT_URL and _SCOPES keys
+func getKeyAsOIDCProviders(env map[string]string, key string, defaultValue []OIDCProviderConfig) []OIDCProviderConfig {
+	valueStr := getKey(env, key, "")
+	if valueStr == "" {
+		return defaultValue
+	}
+
+	var providers []OIDCProviderConfig
+	for _, name := range strings.Split(valueStr, ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		prefix := "OIDC_" + strings.ToUpper(name) + "_"
+		provider := OIDCProviderConfig{
+			Name:         name,
+			Issuer:       getKey(env, prefix+"ISSUER", ""),
+			ClientID:     getKey(env, prefix+"CLIENT_ID", ""),
+			ClientSecret: getKey(env, prefix+"CLIENT_SECRET", ""),
+			RedirectURL:  getKey(env, prefix+"REDIRECT_URL", ""),
+			Scopes:       strings.Fields(getKey(env, prefix+"SCOPES", "openid email profile")),
+		}
+		if name == "" || provider.Issuer == "" || provider.ClientID == "" || provider.RedirectURL == "" {
+			log.Error().Msg("config error: invalid " + key + " entry " + name + ", expected " + prefix + "ISSUER, CLIENT_ID and REDIRECT_URL")
+			continue
+		}
+		providers = append(providers, provider)
+	}
+	return providers
+}
@@ -40,3 +40,89 @@ func TestGet(t *testing.T) {
 		}
 	})
 }
+
+func TestGetKeyAsJwtKeys(t *testing.T) {
+	t.Run("it should parse kid:path pairs", func(t *testing.T) {
+		env := map[string]string{"JWT_KEYS": "key-2:./keys/key-2.pem, key-1:./keys/key-1.pem"}
+		expected := []JwtKeyConfig{
+			{ID: "key-2", Path: "./keys/key-2.pem"},
+			{ID: "key-1", Path: "./keys/key-1.pem"},
+		}
+
+		assert.Equal(t, expected, getKeyAsJwtKeys(env, "JWT_KEYS", nil))
+	})
+
+	t.Run("it should skip invalid pairs", func(t *testing.T) {
+		env := map[string]string{"JWT_KEYS": "key-1,:./keys/key-2.pem,key-3:./keys/key-3.pem"}
+		expected := []JwtKeyConfig{
+			{ID: "key-3", Path: "./keys/key-3.pem"},
+		}
+
+		assert.Equal(t, expected, getKeyAsJwtKeys(env, "JWT_KEYS", nil))
+	})
+
+	t.Run("it should return the default value", func(t *testing.T) {
+		assert.Nil(t, getKeyAsJwtKeys(map[string]string{}, "JWT_KEYS", nil))
+	})
+}
+
+func TestGetKeyAsOIDCProviders(t *testing.T) {
+	t.Run("it should parse the configured providers", func(t *testing.T) {
+		env := map[string]string{
+			"OIDC_PROVIDERS":            "Google, okta",
+			"OIDC_GOOGLE_ISSUER":        "https://accounts.google.com",
+			"OIDC_GOOGLE_CLIENT_ID":     "google-client",
+			"OIDC_GOOGLE_CLIENT_SECRET": "google-secret",
+			"OIDC_GOOGLE_REDIRECT_URL":  "http://localhost/api/v1/auth/google/callback",
+			"OIDC_OKTA_ISSUER":          "https://example.okta.com",
+			"OIDC_OKTA_CLIENT_ID":       "okta-client",
+			"OIDC_OKTA_REDIRECT_URL":    "http://localhost/api/v1/auth/okta/callback",
+			"OIDC_OKTA_SCOPES":          "openid email",
+		}
+		expected := []OIDCProviderConfig{
+			{
+				Name:         "google",
+				Issuer:       "https://accounts.google.com",
+				ClientID:     "google-client",
+				ClientSecret: "google-secret",
+				RedirectURL:  "http://localhost/api/v1/auth/google/callback",
+				Scopes:       []string{"openid", "email", "profile"},
+			},
+			{
+				Name:        "okta",
+				Issuer:      "https://example.okta.com",
+				ClientID:    "okta-client",
+				RedirectURL: "http://localhost/api/v1/auth/okta/callback",
+				Scopes:      []string{"openid", "email"},
+			},
+		}
+
+		assert.Equal(t, expected, getKeyAsOIDCProviders(env, "OIDC_PROVIDERS", nil))
+	})
+
+	t.Run("it should skip incomplete providers", func(t *testing.T) {
+		env := map[string]string{
+			"OIDC_PROVIDERS":        "google",
+			"OIDC_GOOGLE_CLIENT_ID": "google-client",
+		}
+
+		assert.Nil(t, getKeyAsOIDCProviders(env, "OIDC_PROVIDERS", nil))
+	})
+}
+
+func TestGetKeyAsIPNets(t *testing.T) {
+	t.Run("it should parse IP ranges and skip invalid ones", func(t *testing.T) {
+		env := map[string]string{"APP_TRUSTED_PROXIES": "10.0.0.0/8, 10.0.0.1,192.168.1.1/32"}
+
+		ipNets := getKeyAsIPNets(env, "APP_TRUSTED_PROXIES", nil)
+
+		if assert.Len(t, ipNets, 2) {
+			assert.Equal(t, "10.0.0.0/8", ipNets[0].String())
+			assert.Equal(t, "192.168.1.1/32", ipNets[1].String())
+		}
+	})
+
+	t.Run("it should return the default value if not set", func(t *testing.T) {
+		assert.Nil(t, getKeyAsIPNets(map[string]string{}, "APP_TRUSTED_PROXIES", nil))
+	})
+}
@@ -6,6 +6,8 @@ import (
 	"fmt"
 	// mysql driver import
 	_ "github.com/go-sql-driver/mysql"
+	// postgres driver
+	_ "github.com/lib/pq"
 	// sqlite3 driver
 	_ "github.com/mattn/go-sqlite3"
 	"sherman/src/app/config"
@@ -25,8 +27,18 @@ func NewConnection(cfg *config.GlobalConfig) (*sql.DB, error) {
 			cfg.DB.Port,
 			cfg.DB.Name,
 		)
+	case "postgres":
+		connectionURL = fmt.Sprintf(
+			"host=%s port=%s user=%s password=%s dbname=%s sslmode=disable",
+			cfg.DB.Host,
+			cfg.DB.Port,
+			cfg.DB.User,
+			cfg.DB.Pass,
+			cfg.DB.Name,
+		)
 	case "sqlite3":
-		connectionURL = cfg.DB.Path
+		// sqlite only enforces foreign keys, and so the cascades, when asked to
+		connectionURL = cfg.DB.Path + "?_foreign_keys=1"
 	default:
 		errorMessage := fmt.Sprintf("DB_DRIVER: %s, not supported", cfg.DB.Driver)
 		return nil, errors.New(errorMessage)
@@ -28,4 +28,12 @@ func TestNewConnection(t *testing.T) {
 		_, err := NewConnection(&cfg)
 		assert.Error(t, err)
 	})
+
+	t.Run("it should return an error", func(t *testing.T) {
+		cfg := config.DefaultConfig
+		cfg.DB.Driver = "postgres"
+		cfg.DB.Host = "some_wrong_host"
+		_, err := NewConnection(&cfg)
+		assert.Error(t, err)
+	})
 }
@@ -0,0 +1,28 @@
+-- +goose Up
+-- SQL in this section is executed when the migration is applied.
+ALTER TABLE security_tokens
+    ADD family_id   char(36)        NULL AFTER type,
+    ADD rotated     tinyint(1)      UNSIGNED NOT NULL DEFAULT '0' AFTER family_id;
+
+UPDATE security_tokens SET family_id = id;
+
+ALTER TABLE security_tokens MODIFY family_id char(36) NOT NULL;
+
+-- rotated tokens share (user_id, type) with the active token of their family
+ALTER TABLE security_tokens ADD INDEX security_tokens_user_id_type_index (user_id, type);
+ALTER TABLE security_tokens DROP INDEX user_id_2;
+ALTER TABLE security_tokens ADD INDEX security_tokens_token_index (token);
+ALTER TABLE security_tokens ADD INDEX security_tokens_family_id_index (family_id);
+
+-- +goose Down
+-- SQL in this section is executed when the migration is rolled back.
+DELETE FROM security_tokens WHERE rotated = 1;
+
+ALTER TABLE security_tokens DROP INDEX security_tokens_family_id_index;
+ALTER TABLE security_tokens DROP INDEX security_tokens_token_index;
+ALTER TABLE security_tokens ADD UNIQUE INDEX user_id_2 (user_id, type);
+ALTER TABLE security_tokens DROP INDEX security_tokens_user_id_type_index;
+
+ALTER TABLE security_tokens
+    DROP COLUMN rotated,
+    DROP COLUMN family_id;
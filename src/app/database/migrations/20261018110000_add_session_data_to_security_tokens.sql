@@ -0,0 +1,17 @@
+-- +goose Up
+-- SQL in this section is executed when the migration is applied.
+ALTER TABLE security_tokens
+    ADD user_agent      varchar(255)    NOT NULL DEFAULT '' AFTER rotated,
+    ADD ip_address      varchar(45)     NOT NULL DEFAULT '' AFTER user_agent,
+    ADD last_used_at    datetime        NULL AFTER ip_address;
+
+UPDATE security_tokens SET last_used_at = updated_at;
+
+ALTER TABLE security_tokens MODIFY last_used_at datetime NOT NULL;
+
+-- +goose Down
+-- SQL in this section is executed when the migration is rolled back.
+ALTER TABLE security_tokens
+    DROP COLUMN last_used_at,
+    DROP COLUMN ip_address,
+    DROP COLUMN user_agent;
@@ -0,0 +1,15 @@
+-- +goose Up
+-- SQL in this section is executed when the migration is applied.
+CREATE TABLE revoked_tokens (
+   id               char(36)        NOT NULL,
+   user_id          char(36)        NOT NULL,
+   expires_at       datetime        NOT NULL,
+   created_at       datetime        NOT NULL,
+   PRIMARY KEY(id),
+   INDEX(expires_at),
+   FOREIGN KEY(user_id) REFERENCES users(id) ON DELETE CASCADE
+) ENGINE = InnoDB DEFAULT CHARSET=utf8mb4;
+
+-- +goose Down
+-- SQL in this section is executed when the migration is rolled back.
+DROP TABLE revoked_tokens;
@@ -0,0 +1,54 @@
+-- +goose Up
+-- SQL in this section is executed when the migration is applied.
+CREATE TABLE roles (
+   id               char(36)        NOT NULL,
+   name             varchar(64)     NOT NULL,
+   created_at       datetime        NOT NULL,
+   updated_at       datetime        NOT NULL,
+   PRIMARY KEY(id),
+   UNIQUE INDEX(name)
+) ENGINE = InnoDB DEFAULT CHARSET=utf8mb4;
+
+CREATE TABLE permissions (
+   id               char(36)        NOT NULL,
+   name             varchar(64)     NOT NULL,
+   created_at       datetime        NOT NULL,
+   updated_at       datetime        NOT NULL,
+   PRIMARY KEY(id),
+   UNIQUE INDEX(name)
+) ENGINE = InnoDB DEFAULT CHARSET=utf8mb4;
+
+CREATE TABLE role_permissions (
+   role_id          char(36)        NOT NULL,
+   permission_id    char(36)        NOT NULL,
+   PRIMARY KEY(role_id, permission_id),
+   FOREIGN KEY(role_id) REFERENCES roles(id) ON DELETE CASCADE,
+   FOREIGN KEY(permission_id) REFERENCES permissions(id) ON DELETE CASCADE
+) ENGINE = InnoDB DEFAULT CHARSET=utf8mb4;
+
+CREATE TABLE user_roles (
+   user_id          char(36)        NOT NULL,
+   role_id          char(36)        NOT NULL,
+   created_at       datetime        NOT NULL,
+   PRIMARY KEY(user_id, role_id),
+   FOREIGN KEY(user_id) REFERENCES users(id) ON DELETE CASCADE,
+   FOREIGN KEY(role_id) REFERENCES roles(id) ON DELETE CASCADE
+) ENGINE = InnoDB DEFAULT CHARSET=utf8mb4;
+
+INSERT INTO roles (id, name, created_at, updated_at) VALUES
+    ('7d3f5b1e-0c4a-4e8e-9a51-2f6b8c0d1a01', 'admin', NOW(), NOW());
+
+INSERT INTO permissions (id, name, created_at, updated_at) VALUES
+    ('b2a6c4d8-3e1f-4a7b-8c9d-0e1f2a3b4c01', 'users:read', NOW(), NOW()),
+    ('b2a6c4d8-3e1f-4a7b-8c9d-0e1f2a3b4c02', 'roles:manage', NOW(), NOW());
+
+INSERT INTO role_permissions (role_id, permission_id) VALUES
+    ('7d3f5b1e-0c4a-4e8e-9a51-2f6b8c0d1a01', 'b2a6c4d8-3e1f-4a7b-8c9d-0e1f2a3b4c01'),
+    ('7d3f5b1e-0c4a-4e8e-9a51-2f6b8c0d1a01', 'b2a6c4d8-3e1f-4a7b-8c9d-0e1f2a3b4c02');
+
+-- +goose Down
+-- SQL in this section is executed when the migration is rolled back.
+DROP TABLE user_roles;
+DROP TABLE role_permissions;
+DROP TABLE permissions;
+DROP TABLE roles;
@@ -0,0 +1,17 @@
+-- +goose Up
+-- SQL in this section is executed when the migration is applied.
+ALTER TABLE users ADD email_verified tinyint(1) UNSIGNED NOT NULL DEFAULT '0' AFTER active;
+
+-- users registered before email verification existed are trusted
+UPDATE users SET email_verified = 1;
+
+-- room for one time token types such as EMAIL_VERIFICATION
+ALTER TABLE security_tokens MODIFY type varchar(32) NOT NULL;
+
+-- +goose Down
+-- SQL in this section is executed when the migration is rolled back.
+DELETE FROM security_tokens WHERE CHAR_LENGTH(type) > 12;
+
+ALTER TABLE security_tokens MODIFY type varchar(12) NOT NULL;
+
+ALTER TABLE users DROP COLUMN email_verified;
@@ -0,0 +1,28 @@
+-- +goose Up
+-- SQL in this section is executed when the migration is applied.
+ALTER TABLE users ADD deleted_at datetime NULL DEFAULT NULL AFTER updated_at;
+
+-- audit logs outlive the users they refer to, user_id has no foreign key on purpose
+CREATE TABLE audit_logs (
+   id               char(36)        NOT NULL,
+   user_id          char(36)        NOT NULL,
+   actor_id         char(36)        NOT NULL,
+   action           varchar(32)     NOT NULL,
+   created_at       datetime        NOT NULL,
+   PRIMARY KEY(id),
+   INDEX(user_id)
+) ENGINE = InnoDB DEFAULT CHARSET=utf8mb4;
+
+INSERT INTO permissions (id, name, created_at, updated_at) VALUES
+    ('b2a6c4d8-3e1f-4a7b-8c9d-0e1f2a3b4c03', 'users:manage', NOW(), NOW());
+
+INSERT INTO role_permissions (role_id, permission_id) VALUES
+    ('7d3f5b1e-0c4a-4e8e-9a51-2f6b8c0d1a01', 'b2a6c4d8-3e1f-4a7b-8c9d-0e1f2a3b4c03');
+
+-- +goose Down
+-- SQL in this section is executed when the migration is rolled back.
+DELETE FROM permissions WHERE id = 'b2a6c4d8-3e1f-4a7b-8c9d-0e1f2a3b4c03';
+
+DROP TABLE audit_logs;
+
+ALTER TABLE users DROP COLUMN deleted_at;
@@ -0,0 +1,15 @@
+-- +goose Up
+-- SQL in this section is executed when the migration is applied.
+CREATE TABLE login_attempts (
+   attempt_key      varchar(255)    NOT NULL,
+   failures         int UNSIGNED    NOT NULL DEFAULT '0',
+   lockouts         int UNSIGNED    NOT NULL DEFAULT '0',
+   locked_until     datetime        NULL DEFAULT NULL,
+   last_failure_at  datetime        NOT NULL,
+   PRIMARY KEY(attempt_key),
+   INDEX(last_failure_at)
+) ENGINE = InnoDB DEFAULT CHARSET=utf8mb4;
+
+-- +goose Down
+-- SQL in this section is executed when the migration is rolled back.
+DROP TABLE login_attempts;
@@ -0,0 +1,27 @@
+-- +goose Up
+-- SQL in this section is executed when the migration is applied.
+CREATE TABLE two_factors (
+   user_id          char(36)        NOT NULL,
+   secret           varchar(255)    NOT NULL,
+   enabled          tinyint(1)      NOT NULL DEFAULT '0',
+   last_used_step   bigint          NOT NULL DEFAULT '0',
+   created_at       datetime        NOT NULL,
+   updated_at       datetime        NOT NULL,
+   PRIMARY KEY(user_id),
+   FOREIGN KEY(user_id) REFERENCES users(id) ON DELETE CASCADE
+) ENGINE = InnoDB DEFAULT CHARSET=utf8mb4;
+
+CREATE TABLE recovery_codes (
+   id               char(36)        NOT NULL,
+   user_id          char(36)        NOT NULL,
+   code_hash        char(64)        NOT NULL,
+   created_at       datetime        NOT NULL,
+   PRIMARY KEY(id),
+   UNIQUE(user_id, code_hash),
+   FOREIGN KEY(user_id) REFERENCES users(id) ON DELETE CASCADE
+) ENGINE = InnoDB DEFAULT CHARSET=utf8mb4;
+
+-- +goose Down
+-- SQL in this section is executed when the migration is rolled back.
+DROP TABLE recovery_codes;
+DROP TABLE two_factors;
@@ -0,0 +1,17 @@
+-- +goose Up
+-- SQL in this section is executed when the migration is applied.
+CREATE TABLE user_identities (
+   id               char(36)        NOT NULL,
+   user_id          char(36)        NOT NULL,
+   provider         varchar(64)     NOT NULL,
+   subject          varchar(255)    NOT NULL,
+   email_address    varchar(255)    NOT NULL,
+   created_at       datetime        NOT NULL,
+   PRIMARY KEY(id),
+   UNIQUE(provider, subject),
+   FOREIGN KEY(user_id) REFERENCES users(id) ON DELETE CASCADE
+) ENGINE = InnoDB DEFAULT CHARSET=utf8mb4;
+
+-- +goose Down
+-- SQL in this section is executed when the migration is rolled back.
+DROP TABLE user_identities;
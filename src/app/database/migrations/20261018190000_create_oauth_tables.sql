@@ -0,0 +1,32 @@
+-- +goose Up
+-- SQL in this section is executed when the migration is applied.
+CREATE TABLE oauth_clients (
+   id               varchar(64)     NOT NULL,
+   name             varchar(255)    NOT NULL,
+   secret_hash      varchar(255)    NOT NULL DEFAULT '',
+   redirect_uris    text            NOT NULL,
+   grant_types      varchar(255)    NOT NULL,
+   scopes           varchar(255)    NOT NULL,
+   created_at       datetime        NOT NULL,
+   updated_at       datetime        NOT NULL,
+   PRIMARY KEY(id)
+) ENGINE = InnoDB DEFAULT CHARSET=utf8mb4;
+
+CREATE TABLE authorization_codes (
+   code_hash        char(64)        NOT NULL,
+   client_id        varchar(64)     NOT NULL,
+   user_id          char(36)        NOT NULL,
+   redirect_uri     varchar(2048)   NOT NULL,
+   scopes           varchar(255)    NOT NULL,
+   code_challenge   varchar(128)    NOT NULL,
+   expires_at       datetime        NOT NULL,
+   created_at       datetime        NOT NULL,
+   PRIMARY KEY(code_hash),
+   FOREIGN KEY(client_id) REFERENCES oauth_clients(id) ON DELETE CASCADE,
+   FOREIGN KEY(user_id) REFERENCES users(id) ON DELETE CASCADE
+) ENGINE = InnoDB DEFAULT CHARSET=utf8mb4;
+
+-- +goose Down
+-- SQL in this section is executed when the migration is rolled back.
+DROP TABLE authorization_codes;
+DROP TABLE oauth_clients;
@@ -0,0 +1,20 @@
+-- +goose Up
+-- SQL in this section is executed when the migration is applied.
+CREATE TABLE api_keys (
+   id               char(36)        NOT NULL,
+   user_id          char(36)        NOT NULL,
+   name             varchar(255)    NOT NULL,
+   prefix           varchar(16)     NOT NULL,
+   key_hash         char(64)        NOT NULL,
+   scopes           varchar(255)    NOT NULL DEFAULT '',
+   expires_at       datetime        NULL,
+   created_at       datetime        NOT NULL,
+   PRIMARY KEY(id),
+   UNIQUE(key_hash),
+   INDEX(user_id),
+   FOREIGN KEY(user_id) REFERENCES users(id) ON DELETE CASCADE
+) ENGINE = InnoDB DEFAULT CHARSET=utf8mb4;
+
+-- +goose Down
+-- SQL in this section is executed when the migration is rolled back.
+DROP TABLE api_keys;
@@ -0,0 +1,22 @@
+-- +goose Up
+-- SQL in this section is executed when the migration is applied.
+-- unique_type is the type of the tokens a user holds a single one of, so that they can be upserted,
+-- it is NULL for refresh tokens which share (user_id, type) with the other sessions of the user
+ALTER TABLE security_tokens ADD unique_type varchar(32) NULL AFTER type;
+
+UPDATE security_tokens SET unique_type = type WHERE type <> 'REFRESH' AND rotated = 0;
+
+-- only the most recent token of a user and unique type is kept
+DELETE t1 FROM security_tokens t1
+    JOIN security_tokens t2
+        ON t1.user_id = t2.user_id
+        AND t1.unique_type = t2.unique_type
+        AND (t1.updated_at < t2.updated_at OR (t1.updated_at = t2.updated_at AND t1.id < t2.id));
+
+ALTER TABLE security_tokens ADD UNIQUE INDEX security_tokens_user_id_unique_type_index (user_id, unique_type);
+
+-- +goose Down
+-- SQL in this section is executed when the migration is rolled back.
+ALTER TABLE security_tokens DROP INDEX security_tokens_user_id_unique_type_index;
+
+ALTER TABLE security_tokens DROP COLUMN unique_type;
@@ -0,0 +1,209 @@
+-- +goose Up
+-- SQL in this section is executed when the migration is applied.
+-- postgres schema of the mysql migrations up to 20261018200000, later mysql migrations need their
+-- postgres counterpart in this directory
+CREATE TABLE users (
+   id               varchar(36)     NOT NULL,
+   first_name       varchar(100)    NOT NULL,
+   last_name        varchar(100)    NOT NULL,
+   email_address    varchar(100)    NOT NULL UNIQUE,
+   password         varchar(100)    NOT NULL,
+   active           boolean         NOT NULL DEFAULT FALSE,
+   email_verified   boolean         NOT NULL DEFAULT FALSE,
+   created_at       timestamptz     NOT NULL,
+   updated_at       timestamptz     NOT NULL,
+   deleted_at       timestamptz     NULL DEFAULT NULL,
+   PRIMARY KEY(id)
+);
+
+CREATE TABLE security_tokens (
+   id               varchar(36)     NOT NULL,
+   user_id          varchar(36)     NOT NULL,
+   token            varchar(255)    NOT NULL,
+   type             varchar(32)     NOT NULL,
+   family_id        varchar(36)     NOT NULL,
+   rotated          boolean         NOT NULL DEFAULT FALSE,
+   user_agent       varchar(255)    NOT NULL DEFAULT '',
+   ip_address       varchar(45)     NOT NULL DEFAULT '',
+   last_used_at     timestamptz     NOT NULL,
+   created_at       timestamptz     NOT NULL,
+   updated_at       timestamptz     NOT NULL,
+   PRIMARY KEY(id),
+   FOREIGN KEY(user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE INDEX security_tokens_user_id_type_index ON security_tokens (user_id, type);
+CREATE INDEX security_tokens_token_index ON security_tokens (token);
+CREATE INDEX security_tokens_family_id_index ON security_tokens (family_id);
+
+CREATE TABLE revoked_tokens (
+   id               varchar(36)     NOT NULL,
+   user_id          varchar(36)     NOT NULL,
+   expires_at       timestamptz     NOT NULL,
+   created_at       timestamptz     NOT NULL,
+   PRIMARY KEY(id),
+   FOREIGN KEY(user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE INDEX revoked_tokens_expires_at_index ON revoked_tokens (expires_at);
+
+CREATE TABLE roles (
+   id               varchar(36)     NOT NULL,
+   name             varchar(64)     NOT NULL UNIQUE,
+   created_at       timestamptz     NOT NULL,
+   updated_at       timestamptz     NOT NULL,
+   PRIMARY KEY(id)
+);
+
+CREATE TABLE permissions (
+   id               varchar(36)     NOT NULL,
+   name             varchar(64)     NOT NULL UNIQUE,
+   created_at       timestamptz     NOT NULL,
+   updated_at       timestamptz     NOT NULL,
+   PRIMARY KEY(id)
+);
+
+CREATE TABLE role_permissions (
+   role_id          varchar(36)     NOT NULL,
+   permission_id    varchar(36)     NOT NULL,
+   PRIMARY KEY(role_id, permission_id),
+   FOREIGN KEY(role_id) REFERENCES roles(id) ON DELETE CASCADE,
+   FOREIGN KEY(permission_id) REFERENCES permissions(id) ON DELETE CASCADE
+);
+
+CREATE TABLE user_roles (
+   user_id          varchar(36)     NOT NULL,
+   role_id          varchar(36)     NOT NULL,
+   created_at       timestamptz     NOT NULL,
+   PRIMARY KEY(user_id, role_id),
+   FOREIGN KEY(user_id) REFERENCES users(id) ON DELETE CASCADE,
+   FOREIGN KEY(role_id) REFERENCES roles(id) ON DELETE CASCADE
+);
+
+INSERT INTO roles (id, name, created_at, updated_at) VALUES
+    ('7d3f5b1e-0c4a-4e8e-9a51-2f6b8c0d1a01', 'admin', NOW(), NOW());
+
+INSERT INTO permissions (id, name, created_at, updated_at) VALUES
+    ('b2a6c4d8-3e1f-4a7b-8c9d-0e1f2a3b4c01', 'users:read', NOW(), NOW()),
+    ('b2a6c4d8-3e1f-4a7b-8c9d-0e1f2a3b4c02', 'roles:manage', NOW(), NOW()),
+    ('b2a6c4d8-3e1f-4a7b-8c9d-0e1f2a3b4c03', 'users:manage', NOW(), NOW());
+
+INSERT INTO role_permissions (role_id, permission_id) VALUES
+    ('7d3f5b1e-0c4a-4e8e-9a51-2f6b8c0d1a01', 'b2a6c4d8-3e1f-4a7b-8c9d-0e1f2a3b4c01'),
+    ('7d3f5b1e-0c4a-4e8e-9a51-2f6b8c0d1a01', 'b2a6c4d8-3e1f-4a7b-8c9d-0e1f2a3b4c02'),
+    ('7d3f5b1e-0c4a-4e8e-9a51-2f6b8c0d1a01', 'b2a6c4d8-3e1f-4a7b-8c9d-0e1f2a3b4c03');
+
+-- audit logs outlive the users they refer to, user_id has no foreign key on purpose
+CREATE TABLE audit_logs (
+   id               varchar(36)     NOT NULL,
+   user_id          varchar(36)     NOT NULL,
+   actor_id         varchar(36)     NOT NULL,
+   action           varchar(32)     NOT NULL,
+   created_at       timestamptz     NOT NULL,
+   PRIMARY KEY(id)
+);
+
+CREATE INDEX audit_logs_user_id_index ON audit_logs (user_id);
+
+CREATE TABLE login_attempts (
+   attempt_key      varchar(255)    NOT NULL,
+   failures         integer         NOT NULL DEFAULT 0,
+   lockouts         integer         NOT NULL DEFAULT 0,
+   locked_until     timestamptz     NULL DEFAULT NULL,
+   last_failure_at  timestamptz     NOT NULL,
+   PRIMARY KEY(attempt_key)
+);
+
+CREATE INDEX login_attempts_last_failure_at_index ON login_attempts (last_failure_at);
+
+CREATE TABLE two_factors (
+   user_id          varchar(36)     NOT NULL,
+   secret           varchar(255)    NOT NULL,
+   enabled          boolean         NOT NULL DEFAULT FALSE,
+   last_used_step   bigint          NOT NULL DEFAULT 0,
+   created_at       timestamptz     NOT NULL,
+   updated_at       timestamptz     NOT NULL,
+   PRIMARY KEY(user_id),
+   FOREIGN KEY(user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE TABLE recovery_codes (
+   id               varchar(36)     NOT NULL,
+   user_id          varchar(36)     NOT NULL,
+   code_hash        varchar(64)     NOT NULL,
+   created_at       timestamptz     NOT NULL,
+   PRIMARY KEY(id),
+   UNIQUE(user_id, code_hash),
+   FOREIGN KEY(user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE TABLE user_identities (
+   id               varchar(36)     NOT NULL,
+   user_id          varchar(36)     NOT NULL,
+   provider         varchar(64)     NOT NULL,
+   subject          varchar(255)    NOT NULL,
+   email_address    varchar(255)    NOT NULL,
+   created_at       timestamptz     NOT NULL,
+   PRIMARY KEY(id),
+   UNIQUE(provider, subject),
+   FOREIGN KEY(user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE TABLE oauth_clients (
+   id               varchar(64)     NOT NULL,
+   name             varchar(255)    NOT NULL,
+   secret_hash      varchar(255)    NOT NULL DEFAULT '',
+   redirect_uris    text            NOT NULL,
+   grant_types      varchar(255)    NOT NULL,
+   scopes           varchar(255)    NOT NULL,
+   created_at       timestamptz     NOT NULL,
+   updated_at       timestamptz     NOT NULL,
+   PRIMARY KEY(id)
+);
+
+CREATE TABLE authorization_codes (
+   code_hash        varchar(64)     NOT NULL,
+   client_id        varchar(64)     NOT NULL,
+   user_id          varchar(36)     NOT NULL,
+   redirect_uri     varchar(2048)   NOT NULL,
+   scopes           varchar(255)    NOT NULL,
+   code_challenge   varchar(128)    NOT NULL,
+   expires_at       timestamptz     NOT NULL,
+   created_at       timestamptz     NOT NULL,
+   PRIMARY KEY(code_hash),
+   FOREIGN KEY(client_id) REFERENCES oauth_clients(id) ON DELETE CASCADE,
+   FOREIGN KEY(user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE TABLE api_keys (
+   id               varchar(36)     NOT NULL,
+   user_id          varchar(36)     NOT NULL,
+   name             varchar(255)    NOT NULL,
+   prefix           varchar(16)     NOT NULL,
+   key_hash         varchar(64)     NOT NULL UNIQUE,
+   scopes           varchar(255)    NOT NULL DEFAULT '',
+   expires_at       timestamptz     NULL,
+   created_at       timestamptz     NOT NULL,
+   PRIMARY KEY(id),
+   FOREIGN KEY(user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE INDEX api_keys_user_id_index ON api_keys (user_id);
+
+-- +goose Down
+-- SQL in this section is executed when the migration is rolled back.
+DROP TABLE api_keys;
+DROP TABLE authorization_codes;
+DROP TABLE oauth_clients;
+DROP TABLE user_identities;
+DROP TABLE recovery_codes;
+DROP TABLE two_factors;
+DROP TABLE login_attempts;
+DROP TABLE audit_logs;
+DROP TABLE user_roles;
+DROP TABLE role_permissions;
+DROP TABLE permissions;
+DROP TABLE roles;
+DROP TABLE revoked_tokens;
+DROP TABLE security_tokens;
+DROP TABLE users;
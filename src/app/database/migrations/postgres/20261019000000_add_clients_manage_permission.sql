@@ -0,0 +1,11 @@
+-- +goose Up
+-- SQL in this section is executed when the migration is applied.
+INSERT INTO permissions (id, name, created_at, updated_at) VALUES
+    ('b2a6c4d8-3e1f-4a7b-8c9d-0e1f2a3b4c04', 'clients:manage', NOW(), NOW());
+
+INSERT INTO role_permissions (role_id, permission_id) VALUES
+    ('7d3f5b1e-0c4a-4e8e-9a51-2f6b8c0d1a01', 'b2a6c4d8-3e1f-4a7b-8c9d-0e1f2a3b4c04');
+
+-- +goose Down
+-- SQL in this section is executed when the migration is rolled back.
+DELETE FROM permissions WHERE id = 'b2a6c4d8-3e1f-4a7b-8c9d-0e1f2a3b4c04';
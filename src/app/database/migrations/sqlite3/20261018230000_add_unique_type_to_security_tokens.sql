@@ -0,0 +1,54 @@
+-- +goose Up
+-- SQL in this section is executed when the migration is applied.
+-- unique_type is the type of the tokens a user holds a single one of, so that they can be upserted,
+-- it is NULL for refresh tokens which share (user_id, type) with the other sessions of the user
+ALTER TABLE security_tokens ADD COLUMN unique_type varchar(32) NULL;
+
+UPDATE security_tokens SET unique_type = type WHERE type <> 'REFRESH' AND rotated = 0;
+
+-- only the most recent token of a user and unique type is kept
+DELETE FROM security_tokens
+    WHERE EXISTS (
+        SELECT 1 FROM security_tokens t2
+        WHERE security_tokens.user_id = t2.user_id
+            AND security_tokens.unique_type = t2.unique_type
+            AND (
+                security_tokens.updated_at < t2.updated_at
+                OR (security_tokens.updated_at = t2.updated_at AND security_tokens.id < t2.id)
+            )
+    );
+
+CREATE UNIQUE INDEX security_tokens_user_id_unique_type_index ON security_tokens (user_id, unique_type);
+
+-- +goose Down
+-- SQL in this section is executed when the migration is rolled back.
+-- the sqlite version of go-sqlite3 can't drop a column, the table is rebuilt without it
+DROP INDEX security_tokens_user_id_unique_type_index;
+
+CREATE TABLE security_tokens_down (
+   id               char(36)        NOT NULL,
+   user_id          char(36)        NOT NULL,
+   token            char(255)       NOT NULL,
+   type             varchar(32)     NOT NULL,
+   family_id        char(36)        NOT NULL,
+   rotated          boolean         NOT NULL DEFAULT 0,
+   user_agent       varchar(255)    NOT NULL DEFAULT '',
+   ip_address       varchar(45)     NOT NULL DEFAULT '',
+   last_used_at     datetime        NOT NULL,
+   created_at       datetime        NOT NULL,
+   updated_at       datetime        NOT NULL,
+   PRIMARY KEY(id),
+   FOREIGN KEY(user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+INSERT INTO security_tokens_down
+    SELECT id, user_id, token, type, family_id, rotated, user_agent, ip_address, last_used_at, created_at, updated_at
+    FROM security_tokens;
+
+DROP TABLE security_tokens;
+
+ALTER TABLE security_tokens_down RENAME TO security_tokens;
+
+CREATE INDEX security_tokens_user_id_type_index ON security_tokens (user_id, type);
+CREATE INDEX security_tokens_token_index ON security_tokens (token);
+CREATE INDEX security_tokens_family_id_index ON security_tokens (family_id);
@@ -0,0 +1,16 @@
+package database
+
+import (
+	"context"
+	"time"
+)
+
+// WithQueryTimeout derives a context cancelled after timeout, a timeout of 0 or less only makes
+// it cancellable
+func WithQueryTimeout(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+
+	return context.WithTimeout(ctx, timeout)
+}
@@ -0,0 +1,40 @@
+package database
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	_ "sherman/src/app/testing"
+	"testing"
+	"time"
+)
+
+func TestWithQueryTimeout(t *testing.T) {
+	t.Run("it should set a deadline", func(t *testing.T) {
+		ctx, cancel := WithQueryTimeout(context.Background(), time.Second)
+		defer cancel()
+
+		deadline, ok := ctx.Deadline()
+		assert.True(t, ok)
+		assert.WithinDuration(t, time.Now().Add(time.Second), deadline, 100*time.Millisecond)
+	})
+
+	t.Run("it should not set a deadline if timeout is disabled", func(t *testing.T) {
+		ctx, cancel := WithQueryTimeout(context.Background(), 0)
+		defer cancel()
+
+		_, ok := ctx.Deadline()
+		assert.False(t, ok)
+
+		cancel()
+		assert.Equal(t, context.Canceled, ctx.Err())
+	})
+
+	t.Run("it should keep the parent cancellation", func(t *testing.T) {
+		parent, cancelParent := context.WithCancel(context.Background())
+		ctx, cancel := WithQueryTimeout(parent, time.Second)
+		defer cancel()
+
+		cancelParent()
+		assert.Equal(t, context.Canceled, ctx.Err())
+	})
+}
@@ -0,0 +1,57 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+)
+
+// Executor runs queries, either a *sql.DB or a *sql.Tx
+type Executor interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+// txKey context key of the transaction begun by RunInTx
+type txKey struct{}
+
+// txValue a transaction and the database it was begun on
+type txValue struct {
+	db *sql.DB
+	tx *sql.Tx
+}
+
+// Conn returns the transaction of ctx begun on db, db itself outside of a transaction
+func Conn(ctx context.Context, db *sql.DB) Executor {
+	if value, ok := ctx.Value(txKey{}).(txValue); ok && value.db == db {
+		return value.tx
+	}
+	return db
+}
+
+// RunInTx runs fn in a transaction begun on db, committed if fn returns nil and rolled back if it
+// returns an error or panics, fn joins the transaction of ctx when one was already begun on db
+func RunInTx(ctx context.Context, db *sql.DB, fn func(ctx context.Context) error) (err error) {
+	if value, ok := ctx.Value(txKey{}).(txValue); ok && value.db == db {
+		return fn(ctx)
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if p := recover(); p != nil {
+			_ = tx.Rollback()
+			panic(p)
+		}
+		if err != nil {
+			_ = tx.Rollback()
+			return
+		}
+		err = tx.Commit()
+	}()
+
+	return fn(context.WithValue(ctx, txKey{}, txValue{db: db, tx: tx}))
+}
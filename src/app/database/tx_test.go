@@ -0,0 +1,143 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	_ "sherman/src/app/testing"
+	"testing"
+)
+
+func TestRunInTx(t *testing.T) {
+	t.Run("it should commit", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		if err != nil {
+			t.Fatalf("an error '%s' was not expected", err)
+		}
+		defer db.Close()
+
+		mock.ExpectBegin()
+		mock.ExpectExec("DELETE FROM users").WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		err = RunInTx(context.Background(), db, func(ctx context.Context) error {
+			_, err := Conn(ctx, db).ExecContext(ctx, "DELETE FROM users")
+			return err
+		})
+
+		assert.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("it should rollback if fn fails", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		if err != nil {
+			t.Fatalf("an error '%s' was not expected", err)
+		}
+		defer db.Close()
+
+		mockError := errors.New("some error")
+		mock.ExpectBegin()
+		mock.ExpectRollback()
+
+		err = RunInTx(context.Background(), db, func(ctx context.Context) error {
+			return mockError
+		})
+
+		assert.Equal(t, mockError, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("it should rollback if fn panics", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		if err != nil {
+			t.Fatalf("an error '%s' was not expected", err)
+		}
+		defer db.Close()
+
+		mock.ExpectBegin()
+		mock.ExpectRollback()
+
+		assert.Panics(t, func() {
+			_ = RunInTx(context.Background(), db, func(ctx context.Context) error {
+				panic("some panic")
+			})
+		})
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("it should return the commit error", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		if err != nil {
+			t.Fatalf("an error '%s' was not expected", err)
+		}
+		defer db.Close()
+
+		mockError := errors.New("some error")
+		mock.ExpectBegin()
+		mock.ExpectCommit().WillReturnError(mockError)
+
+		err = RunInTx(context.Background(), db, func(ctx context.Context) error {
+			return nil
+		})
+
+		assert.Equal(t, mockError, err)
+	})
+
+	t.Run("it should join the transaction of ctx", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		if err != nil {
+			t.Fatalf("an error '%s' was not expected", err)
+		}
+		defer db.Close()
+
+		mock.ExpectBegin()
+		mock.ExpectCommit()
+
+		err = RunInTx(context.Background(), db, func(ctx context.Context) error {
+			return RunInTx(ctx, db, func(innerCtx context.Context) error {
+				assert.Equal(t, Conn(ctx, db), Conn(innerCtx, db))
+				return nil
+			})
+		})
+
+		assert.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestConn(t *testing.T) {
+	t.Run("it should return db outside of a transaction", func(t *testing.T) {
+		db, _, err := sqlmock.New()
+		if err != nil {
+			t.Fatalf("an error '%s' was not expected", err)
+		}
+		defer db.Close()
+
+		assert.Equal(t, db, Conn(context.Background(), db))
+	})
+
+	t.Run("it should not return the transaction of another db", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		if err != nil {
+			t.Fatalf("an error '%s' was not expected", err)
+		}
+		defer db.Close()
+		otherDB, _, err := sqlmock.New()
+		if err != nil {
+			t.Fatalf("an error '%s' was not expected", err)
+		}
+		defer otherDB.Close()
+
+		mock.ExpectBegin()
+		mock.ExpectCommit()
+
+		err = RunInTx(context.Background(), db, func(ctx context.Context) error {
+			assert.Equal(t, otherDB, Conn(ctx, otherDB))
+			return nil
+		})
+
+		assert.NoError(t, err)
+	})
+}
@@ -8,13 +8,21 @@ import (
 	"sherman/src/app/database"
 	"sherman/src/delivery/handler"
 	"sherman/src/domain/auth"
+	"sherman/src/repository/memds"
 	"sherman/src/repository/mysqlds"
+	"sherman/src/repository/pgds"
+	"sherman/src/repository/sqliteds"
+	"sherman/src/service/cache"
+	"sherman/src/service/mailer"
 	"sherman/src/service/middleware"
+	"sherman/src/service/oidc"
 	"sherman/src/service/presenter"
+	"sherman/src/service/ratelimit"
 	"sherman/src/service/security"
 	"sherman/src/service/validator"
 	"sherman/src/usecase"
 	"sync"
+	"time"
 )
 
 var (
@@ -22,10 +30,26 @@ var (
 	once      sync.Once
 )
 
+// repositoryName returns the definition name of a repository for the configured DB_DRIVER
+func repositoryName(cfg *config.GlobalConfig, name string) string {
+	switch cfg.DB.Driver {
+	case "memory":
+		return "memory-" + name
+	case "sqlite3":
+		return "sqlite-" + name
+	case "postgres":
+		return "postgres-" + name
+	default:
+		return "mysql-" + name
+	}
+}
+
 func makeRegistry(cfg *config.GlobalConfig) []di.Def {
+	queryTimeout := time.Duration(cfg.DB.QueryTimeout) * time.Second
+
 	return []di.Def{
 		{
-			Name:  "mysql-db",
+			Name:  "sql-db",
 			Scope: di.App,
 			Build: func(ctn di.Container) (interface{}, error) {
 				db, err := database.NewConnection(cfg)
@@ -38,12 +62,63 @@ func makeRegistry(cfg *config.GlobalConfig) []di.Def {
 				return db.(*sql.DB).Close()
 			},
 		},
+		{
+			Name:  "memory-db",
+			Scope: di.App,
+			Build: func(ctn di.Container) (interface{}, error) {
+				return memds.NewDB(), nil
+			},
+		},
+		{
+			Name:  "cache-service",
+			Scope: di.App,
+			Build: func(ctn di.Container) (interface{}, error) {
+				return cache.New(), nil
+			},
+		},
+		{
+			Name:  "rate-limit-store",
+			Scope: di.App,
+			Build: func(ctn di.Container) (interface{}, error) {
+				if cfg.RateLimit.Store == "redis" {
+					return ratelimit.NewRedisStore(cfg.Redis), nil
+				}
+				return ratelimit.NewMemoryStore(), nil
+			},
+		},
+		{
+			Name:  "mailer-service",
+			Scope: di.App,
+			Build: func(ctn di.Container) (interface{}, error) {
+				return mailer.New(cfg), nil
+			},
+		},
 		{
 			Name:  "middleware-service",
 			Scope: di.App,
 			Build: func(ctn di.Container) (interface{}, error) {
 				securityService := ctn.Get("security-service").(security.Security)
-				return middleware.New(cfg, securityService), nil
+				securityTokenUseCase := ctn.Get("security-token-usecase").(auth.SecurityTokenUseCase)
+				roleUseCase := ctn.Get("role-usecase").(auth.RoleUseCase)
+				userUseCase := ctn.Get("user-usecase").(auth.UserUseCase)
+				apiKeyUseCase := ctn.Get("api-key-usecase").(auth.APIKeyUseCase)
+				rateLimitStore := ctn.Get("rate-limit-store").(ratelimit.Store)
+				return middleware.New(
+					cfg,
+					securityService,
+					securityTokenUseCase,
+					roleUseCase,
+					userUseCase,
+					apiKeyUseCase,
+					rateLimitStore,
+				), nil
+			},
+		},
+		{
+			Name:  "oidc-service",
+			Scope: di.App,
+			Build: func(ctn di.Container) (interface{}, error) {
+				return oidc.New(cfg), nil
 			},
 		},
 		{
@@ -71,34 +146,584 @@ func makeRegistry(cfg *config.GlobalConfig) []di.Def {
 			Name:  "mysql-security-token-repository",
 			Scope: di.App,
 			Build: func(ctn di.Container) (interface{}, error) {
-				db := ctn.Get("mysql-db").(*sql.DB)
-				return mysqlds.NewSecurityTokenRepository(db), nil
+				db := ctn.Get("sql-db").(*sql.DB)
+				return mysqlds.NewSecurityTokenRepository(db, queryTimeout), nil
+			},
+		},
+		{
+			Name:  "mysql-revoked-token-repository",
+			Scope: di.App,
+			Build: func(ctn di.Container) (interface{}, error) {
+				db := ctn.Get("sql-db").(*sql.DB)
+				return mysqlds.NewRevokedTokenRepository(db, queryTimeout), nil
+			},
+		},
+		{
+			Name:  "mysql-audit-log-repository",
+			Scope: di.App,
+			Build: func(ctn di.Container) (interface{}, error) {
+				db := ctn.Get("sql-db").(*sql.DB)
+				return mysqlds.NewAuditLogRepository(db, queryTimeout), nil
+			},
+		},
+		{
+			Name:  "mysql-login-attempt-repository",
+			Scope: di.App,
+			Build: func(ctn di.Container) (interface{}, error) {
+				db := ctn.Get("sql-db").(*sql.DB)
+				return mysqlds.NewLoginAttemptRepository(db, queryTimeout), nil
+			},
+		},
+		{
+			Name:  "memory-login-attempt-repository",
+			Scope: di.App,
+			Build: func(ctn di.Container) (interface{}, error) {
+				return memds.NewLoginAttemptRepository(), nil
+			},
+		},
+		{
+			Name:  "mysql-two-factor-repository",
+			Scope: di.App,
+			Build: func(ctn di.Container) (interface{}, error) {
+				db := ctn.Get("sql-db").(*sql.DB)
+				return mysqlds.NewTwoFactorRepository(db, queryTimeout), nil
+			},
+		},
+		{
+			Name:  "mysql-user-identity-repository",
+			Scope: di.App,
+			Build: func(ctn di.Container) (interface{}, error) {
+				db := ctn.Get("sql-db").(*sql.DB)
+				return mysqlds.NewUserIdentityRepository(db, queryTimeout), nil
+			},
+		},
+		{
+			Name:  "mysql-oauth-client-repository",
+			Scope: di.App,
+			Build: func(ctn di.Container) (interface{}, error) {
+				db := ctn.Get("sql-db").(*sql.DB)
+				return mysqlds.NewOAuthClientRepository(db, queryTimeout), nil
+			},
+		},
+		{
+			Name:  "mysql-authorization-code-repository",
+			Scope: di.App,
+			Build: func(ctn di.Container) (interface{}, error) {
+				db := ctn.Get("sql-db").(*sql.DB)
+				return mysqlds.NewAuthorizationCodeRepository(db, queryTimeout), nil
+			},
+		},
+		{
+			Name:  "mysql-api-key-repository",
+			Scope: di.App,
+			Build: func(ctn di.Container) (interface{}, error) {
+				db := ctn.Get("sql-db").(*sql.DB)
+				return mysqlds.NewAPIKeyRepository(db, queryTimeout), nil
+			},
+		},
+		{
+			Name:  "mysql-role-repository",
+			Scope: di.App,
+			Build: func(ctn di.Container) (interface{}, error) {
+				db := ctn.Get("sql-db").(*sql.DB)
+				return mysqlds.NewRoleRepository(db, queryTimeout), nil
 			},
 		},
 		{
 			Name:  "mysql-user-repository",
 			Scope: di.App,
 			Build: func(ctn di.Container) (interface{}, error) {
-				db := ctn.Get("mysql-db").(*sql.DB)
-				return mysqlds.NewUserRepository(db), nil
+				db := ctn.Get("sql-db").(*sql.DB)
+				return mysqlds.NewUserRepository(db, queryTimeout), nil
+			},
+		},
+		{
+			Name:  "mysql-unit-of-work",
+			Scope: di.App,
+			Build: func(ctn di.Container) (interface{}, error) {
+				db := ctn.Get("sql-db").(*sql.DB)
+				return mysqlds.NewUnitOfWork(db), nil
+			},
+		},
+		{
+			Name:  "sqlite-security-token-repository",
+			Scope: di.App,
+			Build: func(ctn di.Container) (interface{}, error) {
+				db := ctn.Get("sql-db").(*sql.DB)
+				return sqliteds.NewSecurityTokenRepository(db, queryTimeout), nil
+			},
+		},
+		{
+			Name:  "sqlite-revoked-token-repository",
+			Scope: di.App,
+			Build: func(ctn di.Container) (interface{}, error) {
+				db := ctn.Get("sql-db").(*sql.DB)
+				return sqliteds.NewRevokedTokenRepository(db, queryTimeout), nil
+			},
+		},
+		{
+			Name:  "sqlite-audit-log-repository",
+			Scope: di.App,
+			Build: func(ctn di.Container) (interface{}, error) {
+				db := ctn.Get("sql-db").(*sql.DB)
+				return sqliteds.NewAuditLogRepository(db, queryTimeout), nil
+			},
+		},
+		{
+			Name:  "sqlite-login-attempt-repository",
+			Scope: di.App,
+			Build: func(ctn di.Container) (interface{}, error) {
+				db := ctn.Get("sql-db").(*sql.DB)
+				return sqliteds.NewLoginAttemptRepository(db, queryTimeout), nil
+			},
+		},
+		{
+			Name:  "sqlite-two-factor-repository",
+			Scope: di.App,
+			Build: func(ctn di.Container) (interface{}, error) {
+				db := ctn.Get("sql-db").(*sql.DB)
+				return sqliteds.NewTwoFactorRepository(db, queryTimeout), nil
+			},
+		},
+		{
+			Name:  "sqlite-user-identity-repository",
+			Scope: di.App,
+			Build: func(ctn di.Container) (interface{}, error) {
+				db := ctn.Get("sql-db").(*sql.DB)
+				return sqliteds.NewUserIdentityRepository(db, queryTimeout), nil
+			},
+		},
+		{
+			Name:  "sqlite-oauth-client-repository",
+			Scope: di.App,
+			Build: func(ctn di.Container) (interface{}, error) {
+				db := ctn.Get("sql-db").(*sql.DB)
+				return sqliteds.NewOAuthClientRepository(db, queryTimeout), nil
+			},
+		},
+		{
+			Name:  "sqlite-authorization-code-repository",
+			Scope: di.App,
+			Build: func(ctn di.Container) (interface{}, error) {
+				db := ctn.Get("sql-db").(*sql.DB)
+				return sqliteds.NewAuthorizationCodeRepository(db, queryTimeout), nil
+			},
+		},
+		{
+			Name:  "sqlite-api-key-repository",
+			Scope: di.App,
+			Build: func(ctn di.Container) (interface{}, error) {
+				db := ctn.Get("sql-db").(*sql.DB)
+				return sqliteds.NewAPIKeyRepository(db, queryTimeout), nil
+			},
+		},
+		{
+			Name:  "sqlite-role-repository",
+			Scope: di.App,
+			Build: func(ctn di.Container) (interface{}, error) {
+				db := ctn.Get("sql-db").(*sql.DB)
+				return sqliteds.NewRoleRepository(db, queryTimeout), nil
+			},
+		},
+		{
+			Name:  "sqlite-user-repository",
+			Scope: di.App,
+			Build: func(ctn di.Container) (interface{}, error) {
+				db := ctn.Get("sql-db").(*sql.DB)
+				return sqliteds.NewUserRepository(db, queryTimeout), nil
+			},
+		},
+		{
+			Name:  "sqlite-unit-of-work",
+			Scope: di.App,
+			Build: func(ctn di.Container) (interface{}, error) {
+				db := ctn.Get("sql-db").(*sql.DB)
+				return sqliteds.NewUnitOfWork(db), nil
+			},
+		},
+		{
+			Name:  "postgres-security-token-repository",
+			Scope: di.App,
+			Build: func(ctn di.Container) (interface{}, error) {
+				db := ctn.Get("sql-db").(*sql.DB)
+				return pgds.NewSecurityTokenRepository(db, queryTimeout), nil
+			},
+		},
+		{
+			Name:  "postgres-revoked-token-repository",
+			Scope: di.App,
+			Build: func(ctn di.Container) (interface{}, error) {
+				db := ctn.Get("sql-db").(*sql.DB)
+				return pgds.NewRevokedTokenRepository(db, queryTimeout), nil
+			},
+		},
+		{
+			Name:  "postgres-audit-log-repository",
+			Scope: di.App,
+			Build: func(ctn di.Container) (interface{}, error) {
+				db := ctn.Get("sql-db").(*sql.DB)
+				return pgds.NewAuditLogRepository(db, queryTimeout), nil
+			},
+		},
+		{
+			Name:  "postgres-login-attempt-repository",
+			Scope: di.App,
+			Build: func(ctn di.Container) (interface{}, error) {
+				db := ctn.Get("sql-db").(*sql.DB)
+				return pgds.NewLoginAttemptRepository(db, queryTimeout), nil
+			},
+		},
+		{
+			Name:  "postgres-two-factor-repository",
+			Scope: di.App,
+			Build: func(ctn di.Container) (interface{}, error) {
+				db := ctn.Get("sql-db").(*sql.DB)
+				return pgds.NewTwoFactorRepository(db, queryTimeout), nil
+			},
+		},
+		{
+			Name:  "postgres-user-identity-repository",
+			Scope: di.App,
+			Build: func(ctn di.Container) (interface{}, error) {
+				db := ctn.Get("sql-db").(*sql.DB)
+				return pgds.NewUserIdentityRepository(db, queryTimeout), nil
+			},
+		},
+		{
+			Name:  "postgres-oauth-client-repository",
+			Scope: di.App,
+			Build: func(ctn di.Container) (interface{}, error) {
+				db := ctn.Get("sql-db").(*sql.DB)
+				return pgds.NewOAuthClientRepository(db, queryTimeout), nil
+			},
+		},
+		{
+			Name:  "postgres-authorization-code-repository",
+			Scope: di.App,
+			Build: func(ctn di.Container) (interface{}, error) {
+				db := ctn.Get("sql-db").(*sql.DB)
+				return pgds.NewAuthorizationCodeRepository(db, queryTimeout), nil
+			},
+		},
+		{
+			Name:  "postgres-api-key-repository",
+			Scope: di.App,
+			Build: func(ctn di.Container) (interface{}, error) {
+				db := ctn.Get("sql-db").(*sql.DB)
+				return pgds.NewAPIKeyRepository(db, queryTimeout), nil
+			},
+		},
+		{
+			Name:  "postgres-role-repository",
+			Scope: di.App,
+			Build: func(ctn di.Container) (interface{}, error) {
+				db := ctn.Get("sql-db").(*sql.DB)
+				return pgds.NewRoleRepository(db, queryTimeout), nil
+			},
+		},
+		{
+			Name:  "postgres-user-repository",
+			Scope: di.App,
+			Build: func(ctn di.Container) (interface{}, error) {
+				db := ctn.Get("sql-db").(*sql.DB)
+				return pgds.NewUserRepository(db, queryTimeout), nil
+			},
+		},
+		{
+			Name:  "postgres-unit-of-work",
+			Scope: di.App,
+			Build: func(ctn di.Container) (interface{}, error) {
+				db := ctn.Get("sql-db").(*sql.DB)
+				return pgds.NewUnitOfWork(db), nil
+			},
+		},
+		{
+			Name:  "memory-security-token-repository",
+			Scope: di.App,
+			Build: func(ctn di.Container) (interface{}, error) {
+				db := ctn.Get("memory-db").(*memds.DB)
+				return memds.NewSecurityTokenRepository(db), nil
+			},
+		},
+		{
+			Name:  "memory-revoked-token-repository",
+			Scope: di.App,
+			Build: func(ctn di.Container) (interface{}, error) {
+				db := ctn.Get("memory-db").(*memds.DB)
+				return memds.NewRevokedTokenRepository(db), nil
+			},
+		},
+		{
+			Name:  "memory-audit-log-repository",
+			Scope: di.App,
+			Build: func(ctn di.Container) (interface{}, error) {
+				db := ctn.Get("memory-db").(*memds.DB)
+				return memds.NewAuditLogRepository(db), nil
+			},
+		},
+		{
+			Name:  "memory-two-factor-repository",
+			Scope: di.App,
+			Build: func(ctn di.Container) (interface{}, error) {
+				db := ctn.Get("memory-db").(*memds.DB)
+				return memds.NewTwoFactorRepository(db), nil
+			},
+		},
+		{
+			Name:  "memory-user-identity-repository",
+			Scope: di.App,
+			Build: func(ctn di.Container) (interface{}, error) {
+				db := ctn.Get("memory-db").(*memds.DB)
+				return memds.NewUserIdentityRepository(db), nil
+			},
+		},
+		{
+			Name:  "memory-oauth-client-repository",
+			Scope: di.App,
+			Build: func(ctn di.Container) (interface{}, error) {
+				db := ctn.Get("memory-db").(*memds.DB)
+				return memds.NewOAuthClientRepository(db), nil
+			},
+		},
+		{
+			Name:  "memory-authorization-code-repository",
+			Scope: di.App,
+			Build: func(ctn di.Container) (interface{}, error) {
+				db := ctn.Get("memory-db").(*memds.DB)
+				return memds.NewAuthorizationCodeRepository(db), nil
+			},
+		},
+		{
+			Name:  "memory-api-key-repository",
+			Scope: di.App,
+			Build: func(ctn di.Container) (interface{}, error) {
+				db := ctn.Get("memory-db").(*memds.DB)
+				return memds.NewAPIKeyRepository(db), nil
+			},
+		},
+		{
+			Name:  "memory-role-repository",
+			Scope: di.App,
+			Build: func(ctn di.Container) (interface{}, error) {
+				db := ctn.Get("memory-db").(*memds.DB)
+				return memds.NewRoleRepository(db), nil
+			},
+		},
+		{
+			Name:  "memory-user-repository",
+			Scope: di.App,
+			Build: func(ctn di.Container) (interface{}, error) {
+				db := ctn.Get("memory-db").(*memds.DB)
+				return memds.NewUserRepository(db), nil
+			},
+		},
+		{
+			Name:  "memory-unit-of-work",
+			Scope: di.App,
+			Build: func(ctn di.Container) (interface{}, error) {
+				db := ctn.Get("memory-db").(*memds.DB)
+				return memds.NewUnitOfWork(db), nil
 			},
 		},
 		{
 			Name:  "security-token-usecase",
 			Scope: di.App,
 			Build: func(ctn di.Container) (interface{}, error) {
-				securityTokenRepo := ctn.Get("mysql-security-token-repository").(auth.SecurityTokenRepository)
+				securityTokenRepo := ctn.Get(repositoryName(cfg, "security-token-repository")).(auth.SecurityTokenRepository)
+				revokedTokenRepo := ctn.Get(repositoryName(cfg, "revoked-token-repository")).(auth.RevokedTokenRepository)
+				roleRepo := ctn.Get(repositoryName(cfg, "role-repository")).(auth.RoleRepository)
 				securityService := ctn.Get("security-service").(security.Security)
-				return usecase.NewSecurityTokenUseCase(securityTokenRepo, securityService), nil
+				cacheService := ctn.Get("cache-service").(cache.Cache)
+				return usecase.NewSecurityTokenUseCase(
+					securityTokenRepo,
+					revokedTokenRepo,
+					roleRepo,
+					securityService,
+					cacheService,
+				), nil
+			},
+		},
+		{
+			Name:  "role-usecase",
+			Scope: di.App,
+			Build: func(ctn di.Container) (interface{}, error) {
+				roleRepo := ctn.Get(repositoryName(cfg, "role-repository")).(auth.RoleRepository)
+				return usecase.NewRoleUseCase(roleRepo), nil
 			},
 		},
 		{
 			Name:  "user-usecase",
 			Scope: di.App,
 			Build: func(ctn di.Container) (interface{}, error) {
-				userRepo := ctn.Get("mysql-user-repository").(auth.UserRepository)
+				userRepo := ctn.Get(repositoryName(cfg, "user-repository")).(auth.UserRepository)
+				auditLogRepo := ctn.Get(repositoryName(cfg, "audit-log-repository")).(auth.AuditLogRepository)
+				unitOfWork := ctn.Get(repositoryName(cfg, "unit-of-work")).(auth.UnitOfWork)
+				securityTokenUseCase := ctn.Get("security-token-usecase").(auth.SecurityTokenUseCase)
 				securityService := ctn.Get("security-service").(security.Security)
-				return usecase.NewUserUseCase(userRepo, securityService), nil
+				mailerService := ctn.Get("mailer-service").(mailer.Mailer)
+				cacheService := ctn.Get("cache-service").(cache.Cache)
+				return usecase.NewUserUseCase(
+					userRepo,
+					auditLogRepo,
+					unitOfWork,
+					securityTokenUseCase,
+					securityService,
+					mailerService,
+					cacheService,
+				), nil
+			},
+		},
+		{
+			Name:  "login-attempt-usecase",
+			Scope: di.App,
+			Build: func(ctn di.Container) (interface{}, error) {
+				loginAttemptRepoName := repositoryName(cfg, "login-attempt-repository")
+				if cfg.Login.Store == "memory" {
+					loginAttemptRepoName = "memory-login-attempt-repository"
+				}
+				loginAttemptRepo := ctn.Get(loginAttemptRepoName).(auth.LoginAttemptRepository)
+				return usecase.NewLoginAttemptUseCase(loginAttemptRepo, cfg), nil
+			},
+		},
+		{
+			Name:  "two-factor-usecase",
+			Scope: di.App,
+			Build: func(ctn di.Container) (interface{}, error) {
+				twoFactorRepo := ctn.Get(repositoryName(cfg, "two-factor-repository")).(auth.TwoFactorRepository)
+				userRepo := ctn.Get(repositoryName(cfg, "user-repository")).(auth.UserRepository)
+				securityTokenUseCase := ctn.Get("security-token-usecase").(auth.SecurityTokenUseCase)
+				loginAttemptUseCase := ctn.Get("login-attempt-usecase").(auth.LoginAttemptUseCase)
+				securityService := ctn.Get("security-service").(security.Security)
+				return usecase.NewTwoFactorUseCase(
+					twoFactorRepo,
+					userRepo,
+					securityTokenUseCase,
+					loginAttemptUseCase,
+					securityService,
+					cfg,
+				), nil
+			},
+		},
+		{
+			Name:  "social-login-usecase",
+			Scope: di.App,
+			Build: func(ctn di.Container) (interface{}, error) {
+				userIdentityRepo := ctn.Get(repositoryName(cfg, "user-identity-repository")).(auth.UserIdentityRepository)
+				userRepo := ctn.Get(repositoryName(cfg, "user-repository")).(auth.UserRepository)
+				oidcService := ctn.Get("oidc-service").(oidc.OIDC)
+				securityService := ctn.Get("security-service").(security.Security)
+				return usecase.NewSocialLoginUseCase(
+					userIdentityRepo,
+					userRepo,
+					oidcService,
+					securityService,
+				), nil
+			},
+		},
+		{
+			Name:  "oauth-usecase",
+			Scope: di.App,
+			Build: func(ctn di.Container) (interface{}, error) {
+				clientRepo := ctn.Get(repositoryName(cfg, "oauth-client-repository")).(auth.OAuthClientRepository)
+				codeRepo := ctn.Get(repositoryName(cfg, "authorization-code-repository")).(auth.AuthorizationCodeRepository)
+				userUseCase := ctn.Get("user-usecase").(auth.UserUseCase)
+				securityTokenUseCase := ctn.Get("security-token-usecase").(auth.SecurityTokenUseCase)
+				securityService := ctn.Get("security-service").(security.Security)
+				return usecase.NewOAuthUseCase(
+					clientRepo,
+					codeRepo,
+					userUseCase,
+					securityTokenUseCase,
+					securityService,
+				), nil
+			},
+		},
+		{
+			Name:  "api-key-usecase",
+			Scope: di.App,
+			Build: func(ctn di.Container) (interface{}, error) {
+				apiKeyRepo := ctn.Get(repositoryName(cfg, "api-key-repository")).(auth.APIKeyRepository)
+				roleRepo := ctn.Get(repositoryName(cfg, "role-repository")).(auth.RoleRepository)
+				return usecase.NewAPIKeyUseCase(apiKeyRepo, roleRepo), nil
+			},
+		},
+		{
+			Name:  "well-known-handler",
+			Scope: di.App,
+			Build: func(ctn di.Container) (interface{}, error) {
+				securityService := ctn.Get("security-service").(security.Security)
+				return handler.NewWellKnownHandler(securityService), nil
+			},
+		},
+		{
+			Name:  "auth-handler",
+			Scope: di.App,
+			Build: func(ctn di.Container) (interface{}, error) {
+				socialLoginUseCase := ctn.Get("social-login-usecase").(auth.SocialLoginUseCase)
+				securityTokenUseCase := ctn.Get("security-token-usecase").(auth.SecurityTokenUseCase)
+				twoFactorUseCase := ctn.Get("two-factor-usecase").(auth.TwoFactorUseCase)
+				validatorService := ctn.Get("validator-service").(validator.Validator)
+				securityService := ctn.Get("security-service").(security.Security)
+				return handler.NewAuthHandler(
+					socialLoginUseCase,
+					securityTokenUseCase,
+					twoFactorUseCase,
+					validatorService,
+					securityService,
+				), nil
+			},
+		},
+		{
+			Name:  "oauth-handler",
+			Scope: di.App,
+			Build: func(ctn di.Container) (interface{}, error) {
+				oauthUseCase := ctn.Get("oauth-usecase").(auth.OAuthUseCase)
+				securityTokenUseCase := ctn.Get("security-token-usecase").(auth.SecurityTokenUseCase)
+				userUseCase := ctn.Get("user-usecase").(auth.UserUseCase)
+				securityService := ctn.Get("security-service").(security.Security)
+				return handler.NewOAuthHandler(
+					oauthUseCase,
+					securityTokenUseCase,
+					userUseCase,
+					securityService,
+				), nil
+			},
+		},
+		{
+			Name:  "oauth-client-handler",
+			Scope: di.App,
+			Build: func(ctn di.Container) (interface{}, error) {
+				oauthUseCase := ctn.Get("oauth-usecase").(auth.OAuthUseCase)
+				validatorService := ctn.Get("validator-service").(validator.Validator)
+				return handler.NewOAuthClientHandler(oauthUseCase, validatorService), nil
+			},
+		},
+		{
+			Name:  "api-key-handler",
+			Scope: di.App,
+			Build: func(ctn di.Container) (interface{}, error) {
+				apiKeyUseCase := ctn.Get("api-key-usecase").(auth.APIKeyUseCase)
+				validatorService := ctn.Get("validator-service").(validator.Validator)
+				return handler.NewAPIKeyHandler(apiKeyUseCase, validatorService), nil
+			},
+		},
+		{
+			Name:  "admin-handler",
+			Scope: di.App,
+			Build: func(ctn di.Container) (interface{}, error) {
+				userUseCase := ctn.Get("user-usecase").(auth.UserUseCase)
+				validatorService := ctn.Get("validator-service").(validator.Validator)
+				presenterService := ctn.Get("presenter-service").(presenter.Presenter)
+				return handler.NewAdminHandler(userUseCase, validatorService, presenterService), nil
+			},
+		},
+		{
+			Name:  "role-handler",
+			Scope: di.App,
+			Build: func(ctn di.Container) (interface{}, error) {
+				roleUseCase := ctn.Get("role-usecase").(auth.RoleUseCase)
+				validatorService := ctn.Get("validator-service").(validator.Validator)
+				return handler.NewRoleHandler(roleUseCase, validatorService), nil
 			},
 		},
 		{
@@ -107,12 +732,16 @@ func makeRegistry(cfg *config.GlobalConfig) []di.Def {
 			Build: func(ctn di.Container) (interface{}, error) {
 				userUseCase := ctn.Get("user-usecase").(auth.UserUseCase)
 				securityTokenUseCase := ctn.Get("security-token-usecase").(auth.SecurityTokenUseCase)
+				loginAttemptUseCase := ctn.Get("login-attempt-usecase").(auth.LoginAttemptUseCase)
+				twoFactorUseCase := ctn.Get("two-factor-usecase").(auth.TwoFactorUseCase)
 				validatorService := ctn.Get("validator-service").(validator.Validator)
 				securityService := ctn.Get("security-service").(security.Security)
 				presenterService := ctn.Get("presenter-service").(presenter.Presenter)
 				return handler.NewUserHandler(
 					userUseCase,
 					securityTokenUseCase,
+					loginAttemptUseCase,
+					twoFactorUseCase,
 					validatorService,
 					securityService,
 					presenterService,
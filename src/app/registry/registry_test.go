@@ -2,12 +2,20 @@ package registry
 
 import (
 	"database/sql"
+	"github.com/sarulabs/di"
 	"github.com/stretchr/testify/assert"
+	"reflect"
+	"sherman/src/app/config"
 	_ "sherman/src/app/testing"
 	"sherman/src/delivery/handler"
 	"sherman/src/domain/auth"
+	"sherman/src/repository/memds"
+	"sherman/src/service/cache"
+	"sherman/src/service/mailer"
 	"sherman/src/service/middleware"
+	"sherman/src/service/oidc"
 	"sherman/src/service/presenter"
+	"sherman/src/service/ratelimit"
 	"sherman/src/service/security"
 	"sherman/src/service/validator"
 	"testing"
@@ -17,10 +25,20 @@ func TestGet(t *testing.T) {
 	t.Run("it should have all expected definitions", func(t *testing.T) {
 		diContainer, err := Get()
 		if assert.NoError(t, err) {
-			_, ok := diContainer.Get("mysql-db").(*sql.DB)
+			_, ok := diContainer.Get("sql-db").(*sql.DB)
+			assert.True(t, ok)
+			_, ok = diContainer.Get("memory-db").(*memds.DB)
+			assert.True(t, ok)
+			_, ok = diContainer.Get("cache-service").(cache.Cache)
+			assert.True(t, ok)
+			_, ok = diContainer.Get("rate-limit-store").(ratelimit.Store)
+			assert.True(t, ok)
+			_, ok = diContainer.Get("mailer-service").(mailer.Mailer)
 			assert.True(t, ok)
 			_, ok = diContainer.Get("middleware-service").(middleware.Middleware)
 			assert.True(t, ok)
+			_, ok = diContainer.Get("oidc-service").(oidc.OIDC)
+			assert.True(t, ok)
 			_, ok = diContainer.Get("presenter-service").(presenter.Presenter)
 			assert.True(t, ok)
 			_, ok = diContainer.Get("security-service").(security.Security)
@@ -29,12 +47,176 @@ func TestGet(t *testing.T) {
 			assert.True(t, ok)
 			_, ok = diContainer.Get("mysql-security-token-repository").(auth.SecurityTokenRepository)
 			assert.True(t, ok)
+			_, ok = diContainer.Get("mysql-revoked-token-repository").(auth.RevokedTokenRepository)
+			assert.True(t, ok)
+			_, ok = diContainer.Get("mysql-audit-log-repository").(auth.AuditLogRepository)
+			assert.True(t, ok)
+			_, ok = diContainer.Get("mysql-login-attempt-repository").(auth.LoginAttemptRepository)
+			assert.True(t, ok)
+			_, ok = diContainer.Get("memory-login-attempt-repository").(auth.LoginAttemptRepository)
+			assert.True(t, ok)
+			_, ok = diContainer.Get("mysql-two-factor-repository").(auth.TwoFactorRepository)
+			assert.True(t, ok)
+			_, ok = diContainer.Get("mysql-user-identity-repository").(auth.UserIdentityRepository)
+			assert.True(t, ok)
+			_, ok = diContainer.Get("mysql-oauth-client-repository").(auth.OAuthClientRepository)
+			assert.True(t, ok)
+			_, ok = diContainer.Get("mysql-authorization-code-repository").(auth.AuthorizationCodeRepository)
+			assert.True(t, ok)
+			_, ok = diContainer.Get("mysql-api-key-repository").(auth.APIKeyRepository)
+			assert.True(t, ok)
+			_, ok = diContainer.Get("mysql-role-repository").(auth.RoleRepository)
+			assert.True(t, ok)
 			_, ok = diContainer.Get("mysql-user-repository").(auth.UserRepository)
 			assert.True(t, ok)
+			_, ok = diContainer.Get("mysql-unit-of-work").(auth.UnitOfWork)
+			assert.True(t, ok)
+			_, ok = diContainer.Get("sqlite-security-token-repository").(auth.SecurityTokenRepository)
+			assert.True(t, ok)
+			_, ok = diContainer.Get("sqlite-revoked-token-repository").(auth.RevokedTokenRepository)
+			assert.True(t, ok)
+			_, ok = diContainer.Get("sqlite-audit-log-repository").(auth.AuditLogRepository)
+			assert.True(t, ok)
+			_, ok = diContainer.Get("sqlite-login-attempt-repository").(auth.LoginAttemptRepository)
+			assert.True(t, ok)
+			_, ok = diContainer.Get("sqlite-two-factor-repository").(auth.TwoFactorRepository)
+			assert.True(t, ok)
+			_, ok = diContainer.Get("sqlite-user-identity-repository").(auth.UserIdentityRepository)
+			assert.True(t, ok)
+			_, ok = diContainer.Get("sqlite-oauth-client-repository").(auth.OAuthClientRepository)
+			assert.True(t, ok)
+			_, ok = diContainer.Get("sqlite-authorization-code-repository").(auth.AuthorizationCodeRepository)
+			assert.True(t, ok)
+			_, ok = diContainer.Get("sqlite-api-key-repository").(auth.APIKeyRepository)
+			assert.True(t, ok)
+			_, ok = diContainer.Get("sqlite-role-repository").(auth.RoleRepository)
+			assert.True(t, ok)
+			_, ok = diContainer.Get("sqlite-user-repository").(auth.UserRepository)
+			assert.True(t, ok)
+			_, ok = diContainer.Get("sqlite-unit-of-work").(auth.UnitOfWork)
+			assert.True(t, ok)
+			_, ok = diContainer.Get("postgres-security-token-repository").(auth.SecurityTokenRepository)
+			assert.True(t, ok)
+			_, ok = diContainer.Get("postgres-revoked-token-repository").(auth.RevokedTokenRepository)
+			assert.True(t, ok)
+			_, ok = diContainer.Get("postgres-audit-log-repository").(auth.AuditLogRepository)
+			assert.True(t, ok)
+			_, ok = diContainer.Get("postgres-login-attempt-repository").(auth.LoginAttemptRepository)
+			assert.True(t, ok)
+			_, ok = diContainer.Get("postgres-two-factor-repository").(auth.TwoFactorRepository)
+			assert.True(t, ok)
+			_, ok = diContainer.Get("postgres-user-identity-repository").(auth.UserIdentityRepository)
+			assert.True(t, ok)
+			_, ok = diContainer.Get("postgres-oauth-client-repository").(auth.OAuthClientRepository)
+			assert.True(t, ok)
+			_, ok = diContainer.Get("postgres-authorization-code-repository").(auth.AuthorizationCodeRepository)
+			assert.True(t, ok)
+			_, ok = diContainer.Get("postgres-api-key-repository").(auth.APIKeyRepository)
+			assert.True(t, ok)
+			_, ok = diContainer.Get("postgres-role-repository").(auth.RoleRepository)
+			assert.True(t, ok)
+			_, ok = diContainer.Get("postgres-user-repository").(auth.UserRepository)
+			assert.True(t, ok)
+			_, ok = diContainer.Get("postgres-unit-of-work").(auth.UnitOfWork)
+			assert.True(t, ok)
+			_, ok = diContainer.Get("memory-security-token-repository").(auth.SecurityTokenRepository)
+			assert.True(t, ok)
+			_, ok = diContainer.Get("memory-revoked-token-repository").(auth.RevokedTokenRepository)
+			assert.True(t, ok)
+			_, ok = diContainer.Get("memory-audit-log-repository").(auth.AuditLogRepository)
+			assert.True(t, ok)
+			_, ok = diContainer.Get("memory-two-factor-repository").(auth.TwoFactorRepository)
+			assert.True(t, ok)
+			_, ok = diContainer.Get("memory-user-identity-repository").(auth.UserIdentityRepository)
+			assert.True(t, ok)
+			_, ok = diContainer.Get("memory-oauth-client-repository").(auth.OAuthClientRepository)
+			assert.True(t, ok)
+			_, ok = diContainer.Get("memory-authorization-code-repository").(auth.AuthorizationCodeRepository)
+			assert.True(t, ok)
+			_, ok = diContainer.Get("memory-api-key-repository").(auth.APIKeyRepository)
+			assert.True(t, ok)
+			_, ok = diContainer.Get("memory-role-repository").(auth.RoleRepository)
+			assert.True(t, ok)
+			_, ok = diContainer.Get("memory-user-repository").(auth.UserRepository)
+			assert.True(t, ok)
+			_, ok = diContainer.Get("memory-unit-of-work").(auth.UnitOfWork)
+			assert.True(t, ok)
 			_, ok = diContainer.Get("security-token-usecase").(auth.SecurityTokenUseCase)
 			assert.True(t, ok)
+			_, ok = diContainer.Get("role-usecase").(auth.RoleUseCase)
+			assert.True(t, ok)
 			_, ok = diContainer.Get("user-usecase").(auth.UserUseCase)
 			assert.True(t, ok)
+			_, ok = diContainer.Get("login-attempt-usecase").(auth.LoginAttemptUseCase)
+			assert.True(t, ok)
+			_, ok = diContainer.Get("two-factor-usecase").(auth.TwoFactorUseCase)
+			assert.True(t, ok)
+			_, ok = diContainer.Get("social-login-usecase").(auth.SocialLoginUseCase)
+			assert.True(t, ok)
+			_, ok = diContainer.Get("oauth-usecase").(auth.OAuthUseCase)
+			assert.True(t, ok)
+			_, ok = diContainer.Get("api-key-usecase").(auth.APIKeyUseCase)
+			assert.True(t, ok)
+			_, ok = diContainer.Get("well-known-handler").(handler.WellKnownHandler)
+			assert.True(t, ok)
+			_, ok = diContainer.Get("auth-handler").(handler.AuthHandler)
+			assert.True(t, ok)
+			_, ok = diContainer.Get("oauth-handler").(handler.OAuthHandler)
+			assert.True(t, ok)
+			_, ok = diContainer.Get("oauth-client-handler").(handler.OAuthClientHandler)
+			assert.True(t, ok)
+			_, ok = diContainer.Get("api-key-handler").(handler.APIKeyHandler)
+			assert.True(t, ok)
+			_, ok = diContainer.Get("admin-handler").(handler.AdminHandler)
+			assert.True(t, ok)
+			_, ok = diContainer.Get("role-handler").(handler.RoleHandler)
+			assert.True(t, ok)
+			_, ok = diContainer.Get("user-handler").(handler.UserHandler)
+			assert.True(t, ok)
+		}
+	})
+
+	t.Run("it should build the postgres definitions on the sql-db connection", func(t *testing.T) {
+		diContainer, err := Get()
+		if assert.NoError(t, err) {
+			db := diContainer.Get("sql-db").(*sql.DB)
+			for _, name := range []string{
+				"postgres-security-token-repository",
+				"postgres-revoked-token-repository",
+				"postgres-audit-log-repository",
+				"postgres-login-attempt-repository",
+				"postgres-two-factor-repository",
+				"postgres-user-identity-repository",
+				"postgres-oauth-client-repository",
+				"postgres-authorization-code-repository",
+				"postgres-api-key-repository",
+				"postgres-role-repository",
+				"postgres-user-repository",
+				"postgres-unit-of-work",
+			} {
+				definitionDB := reflect.ValueOf(diContainer.Get(name)).Elem().FieldByName("DB").Interface()
+				assert.Same(t, db, definitionDB, name)
+			}
+		}
+	})
+
+	t.Run("it should build the handlers with the memory driver", func(t *testing.T) {
+		cfg := *config.Get()
+		cfg.DB.Driver = "memory"
+
+		builder, err := di.NewBuilder()
+		if assert.NoError(t, err) && assert.NoError(t, builder.Add(makeRegistry(&cfg)...)) {
+			diContainer := builder.Build()
+			defer diContainer.Delete()
+
+			_, ok := diContainer.Get("middleware-service").(middleware.Middleware)
+			assert.True(t, ok)
+			_, ok = diContainer.Get("auth-handler").(handler.AuthHandler)
+			assert.True(t, ok)
+			_, ok = diContainer.Get("oauth-handler").(handler.OAuthHandler)
+			assert.True(t, ok)
+			_, ok = diContainer.Get("api-key-handler").(handler.APIKeyHandler)
+			assert.True(t, ok)
 			_, ok = diContainer.Get("user-handler").(handler.UserHandler)
 			assert.True(t, ok)
 		}
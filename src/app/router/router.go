@@ -4,7 +4,10 @@ import (
 	"github.com/labstack/echo/v4"
 	emw "github.com/labstack/echo/v4/middleware"
 	"github.com/sarulabs/di"
+	"net"
+	"sherman/src/app/config"
 	"sherman/src/delivery/handler"
+	"sherman/src/domain/auth"
 	cmw "sherman/src/service/middleware"
 	cmc "sherman/src/service/middleware/config"
 )
@@ -12,23 +15,135 @@ import (
 // New creates an instance of application router
 func New(ctn di.Container) *echo.Echo {
 	router := echo.New()
+	router.IPExtractor = ipExtractor(config.Get().App.TrustedProxies)
 	router.Use(emw.Recover())
 	router.Use(emw.CORSWithConfig(cmc.CustomCorsConfig))
 	cmws := ctn.Get("middleware-service").(cmw.Middleware)
 	router.Use(cmws.ZeroLog())
+	// the global limiter runs before the auth middlewares of the routes and so counts per client IP
+	// address, authenticated routes are also counted per user by userRateLimit right after them
+	router.Use(cmws.RateLimit(&cmc.DefaultRateLimitConfig))
+	userRateLimit := cmws.RateLimit(&cmc.RateLimitConfig{Name: "user", KeyExtractor: cmw.KeyByUserID})
+	// routes: /.well-known
+	wellKnownRouter := router.Group("/.well-known")
+	{
+		wellKnownHandler := ctn.Get("well-known-handler").(handler.WellKnownHandler)
+
+		wellKnownRouter.GET("/jwks.json", wellKnownHandler.GetJWKS)
+	}
+	// routes: /oauth
+	oauthRouter := router.Group("/oauth")
+	{
+		oauthHandler := ctn.Get("oauth-handler").(handler.OAuthHandler)
+
+		oauthRouter.GET("/authorize", oauthHandler.Authorize)
+		oauthRouter.POST("/token", oauthHandler.Token)
+		oauthRouter.POST("/revoke", oauthHandler.Revoke)
+		oauthRouter.POST("/introspect", oauthHandler.Introspect)
+	}
 	// routes: /api/v1
 	v1Router := router.Group("/api/v1")
+	// routes: /api/v1/auth
+	authRouter := v1Router.Group("/auth")
+	{
+		authHandler := ctn.Get("auth-handler").(handler.AuthHandler)
+
+		authRouter.GET("/:provider/start", authHandler.StartSocialLogin)
+		authRouter.GET("/:provider/callback", authHandler.SocialLoginCallback)
+	}
 	// routes: /api/v1/users
 	userRouter := v1Router.Group("/users")
 	{
 		userHandler := ctn.Get("user-handler").(handler.UserHandler)
+		emailRateLimit := cmws.RateLimit(&cmc.EmailRateLimitConfig)
+		profileScope := cmws.RequireScope(auth.ProfileScope)
+		profileWriteScope := cmws.RequireScope(auth.ProfileWriteScope)
+		accountScope := cmws.RequireScope(auth.AccountScope)
+		apiKey := cmws.APIKey()
 
-		userRouter.POST("/register", userHandler.Register)
+		userRouter.POST("/register", userHandler.Register, emailRateLimit)
 		userRouter.POST("/login", userHandler.Login)
+		userRouter.POST("/login/2fa", userHandler.LoginTwoFactor)
+		userRouter.POST("/verify-email", userHandler.VerifyEmail)
+		userRouter.POST("/verify-email/resend", userHandler.ResendVerificationEmail, emailRateLimit)
+		userRouter.POST("/password/forgot", userHandler.ForgotPassword, emailRateLimit)
+		userRouter.POST("/password/reset", userHandler.ResetPassword)
 		userRouter.PATCH("/refresh-token", userHandler.RefreshAccessToken)
-		userRouter.GET("/:id", userHandler.GetUser, cmws.JWT())
-		userRouter.DELETE("/logout", userHandler.Logout, cmws.JWT())
+		userRouter.GET("/me", userHandler.GetMe, apiKey, cmws.JWT(), userRateLimit, profileScope)
+		userRouter.PATCH("/me", userHandler.UpdateMe, apiKey, cmws.JWT(), userRateLimit, profileWriteScope)
+		userRouter.PUT("/me/password", userHandler.ChangePassword, cmws.JWT(), userRateLimit, accountScope)
+		userRouter.POST("/me/deactivate", userHandler.DeactivateMe, cmws.JWT(), userRateLimit, accountScope)
+		userRouter.DELETE("/me", userHandler.DeleteMe, cmws.JWT(), userRateLimit, accountScope)
+		userRouter.POST("/me/erase", userHandler.EraseMe, cmws.JWT(), userRateLimit, accountScope)
+		userRouter.POST("/me/2fa/setup", userHandler.SetupTwoFactor, cmws.JWT(), userRateLimit, accountScope)
+		userRouter.POST("/me/2fa/confirm", userHandler.ConfirmTwoFactor, cmws.JWT(), userRateLimit, accountScope)
+		userRouter.DELETE("/me/2fa", userHandler.DisableTwoFactor, cmws.JWT(), userRateLimit, accountScope)
+		userRouter.GET("/:id", userHandler.GetUser, apiKey, cmws.JWT(), userRateLimit, profileScope)
+		userRouter.DELETE("/logout", userHandler.Logout, cmws.JWT(), userRateLimit)
+		userRouter.GET("/me/sessions", userHandler.GetSessions, cmws.JWT(), userRateLimit, accountScope)
+		userRouter.DELETE("/me/sessions", userHandler.RemoveSessions, cmws.JWT(), userRateLimit, accountScope)
+		userRouter.DELETE("/me/sessions/:session_id", userHandler.RemoveSession, cmws.JWT(), userRateLimit, accountScope)
+	}
+	// routes: /api/v1/users/me/api-keys
+	apiKeyRouter := userRouter.Group("/me/api-keys")
+	{
+		apiKeyHandler := ctn.Get("api-key-handler").(handler.APIKeyHandler)
+		accountScope := cmws.RequireScope(auth.AccountScope)
+
+		apiKeyRouter.POST("", apiKeyHandler.CreateAPIKey, cmws.JWT(), userRateLimit, accountScope)
+		apiKeyRouter.GET("", apiKeyHandler.GetAPIKeys, cmws.JWT(), userRateLimit, accountScope)
+		apiKeyRouter.DELETE("/:key_id", apiKeyHandler.RevokeAPIKey, cmws.JWT(), userRateLimit, accountScope)
+	}
+	// routes: /api/v1/users/:id/roles
+	roleRouter := userRouter.Group("/:id/roles")
+	{
+		roleHandler := ctn.Get("role-handler").(handler.RoleHandler)
+		canManageRoles := cmws.RequirePermission(auth.ManageRolesPermission)
+		adminScope := cmws.RequireScope(auth.AdminScope)
+		apiKey := cmws.APIKey()
+
+		roleRouter.POST("", roleHandler.AssignRole, apiKey, cmws.JWT(), userRateLimit, adminScope, canManageRoles)
+		roleRouter.DELETE("/:role", roleHandler.RevokeRole, apiKey, cmws.JWT(), userRateLimit, adminScope, canManageRoles)
+	}
+	// routes: /api/v1/admin/users
+	adminUserRouter := v1Router.Group("/admin/users")
+	{
+		adminHandler := ctn.Get("admin-handler").(handler.AdminHandler)
+		adminScope := cmws.RequireScope(auth.AdminScope)
+		canReadUsers := cmws.RequirePermission(auth.ReadUsersPermission)
+		canManageUsers := cmws.RequirePermission(auth.ManageUsersPermission)
+		apiKey := cmws.APIKey()
+
+		adminUserRouter.GET("", adminHandler.ListUsers, apiKey, cmws.JWT(), userRateLimit, adminScope, canReadUsers)
+		adminUserRouter.PATCH("/:id", adminHandler.UpdateUser, apiKey, cmws.JWT(), userRateLimit, adminScope, canManageUsers)
+		adminUserRouter.POST("/:id/activate", adminHandler.ActivateUser, apiKey, cmws.JWT(), userRateLimit, adminScope, canManageUsers)
+		adminUserRouter.POST("/:id/deactivate", adminHandler.DeactivateUser, apiKey, cmws.JWT(), userRateLimit, adminScope, canManageUsers)
+	}
+	// routes: /api/v1/admin/oauth-clients
+	adminOAuthClientRouter := v1Router.Group("/admin/oauth-clients")
+	{
+		oauthClientHandler := ctn.Get("oauth-client-handler").(handler.OAuthClientHandler)
+		adminScope := cmws.RequireScope(auth.AdminScope)
+		canManageClients := cmws.RequirePermission(auth.ManageClientsPermission)
+		apiKey := cmws.APIKey()
+
+		adminOAuthClientRouter.POST("", oauthClientHandler.CreateClient, apiKey, cmws.JWT(), userRateLimit, adminScope, canManageClients)
+		adminOAuthClientRouter.POST("/:id/secret", oauthClientHandler.RotateClientSecret, apiKey, cmws.JWT(), userRateLimit, adminScope, canManageClients)
 	}
 
 	return router
 }
+
+// ipExtractor reads the client IP address from X-Forwarded-For only when the request comes from one of
+// the trusted proxies, a client can't spoof its address to evade the rate limits and the login lockouts
+func ipExtractor(trustedProxies []*net.IPNet) echo.IPExtractor {
+	if len(trustedProxies) == 0 {
+		return echo.ExtractIPDirect()
+	}
+
+	options := []echo.TrustOption{echo.TrustLoopback(false), echo.TrustLinkLocal(false), echo.TrustPrivateNet(false)}
+	for _, ipRange := range trustedProxies {
+		options = append(options, echo.TrustIPRange(ipRange))
+	}
+	return echo.ExtractIPFromXFFHeader(options...)
+}
@@ -3,6 +3,9 @@ package router
 import (
 	"github.com/labstack/echo/v4"
 	"github.com/stretchr/testify/assert"
+	"net"
+	"net/http"
+	"net/http/httptest"
 	"sherman/src/app/registry"
 	_ "sherman/src/app/testing"
 	"testing"
@@ -14,6 +17,34 @@ type Route struct {
 }
 
 var expectedRoutes = []Route{
+	{
+		Method: "GET",
+		Path:   "/.well-known/jwks.json",
+	},
+	{
+		Method: "GET",
+		Path:   "/oauth/authorize",
+	},
+	{
+		Method: "POST",
+		Path:   "/oauth/token",
+	},
+	{
+		Method: "POST",
+		Path:   "/oauth/revoke",
+	},
+	{
+		Method: "POST",
+		Path:   "/oauth/introspect",
+	},
+	{
+		Method: "GET",
+		Path:   "/api/v1/auth/:provider/start",
+	},
+	{
+		Method: "GET",
+		Path:   "/api/v1/auth/:provider/callback",
+	},
 	{
 		Method: "POST",
 		Path:   "/api/v1/users/register",
@@ -22,10 +53,66 @@ var expectedRoutes = []Route{
 		Method: "POST",
 		Path:   "/api/v1/users/login",
 	},
+	{
+		Method: "POST",
+		Path:   "/api/v1/users/login/2fa",
+	},
+	{
+		Method: "POST",
+		Path:   "/api/v1/users/verify-email",
+	},
+	{
+		Method: "POST",
+		Path:   "/api/v1/users/verify-email/resend",
+	},
+	{
+		Method: "POST",
+		Path:   "/api/v1/users/password/forgot",
+	},
+	{
+		Method: "POST",
+		Path:   "/api/v1/users/password/reset",
+	},
 	{
 		Method: "PATCH",
 		Path:   "/api/v1/users/refresh-token",
 	},
+	{
+		Method: "GET",
+		Path:   "/api/v1/users/me",
+	},
+	{
+		Method: "PATCH",
+		Path:   "/api/v1/users/me",
+	},
+	{
+		Method: "PUT",
+		Path:   "/api/v1/users/me/password",
+	},
+	{
+		Method: "POST",
+		Path:   "/api/v1/users/me/deactivate",
+	},
+	{
+		Method: "DELETE",
+		Path:   "/api/v1/users/me",
+	},
+	{
+		Method: "POST",
+		Path:   "/api/v1/users/me/erase",
+	},
+	{
+		Method: "POST",
+		Path:   "/api/v1/users/me/2fa/setup",
+	},
+	{
+		Method: "POST",
+		Path:   "/api/v1/users/me/2fa/confirm",
+	},
+	{
+		Method: "DELETE",
+		Path:   "/api/v1/users/me/2fa",
+	},
 	{
 		Method: "GET",
 		Path:   "/api/v1/users/:id",
@@ -34,6 +121,62 @@ var expectedRoutes = []Route{
 		Method: "DELETE",
 		Path:   "/api/v1/users/logout",
 	},
+	{
+		Method: "GET",
+		Path:   "/api/v1/users/me/sessions",
+	},
+	{
+		Method: "DELETE",
+		Path:   "/api/v1/users/me/sessions",
+	},
+	{
+		Method: "DELETE",
+		Path:   "/api/v1/users/me/sessions/:session_id",
+	},
+	{
+		Method: "POST",
+		Path:   "/api/v1/users/me/api-keys",
+	},
+	{
+		Method: "GET",
+		Path:   "/api/v1/users/me/api-keys",
+	},
+	{
+		Method: "DELETE",
+		Path:   "/api/v1/users/me/api-keys/:key_id",
+	},
+	{
+		Method: "POST",
+		Path:   "/api/v1/users/:id/roles",
+	},
+	{
+		Method: "DELETE",
+		Path:   "/api/v1/users/:id/roles/:role",
+	},
+	{
+		Method: "GET",
+		Path:   "/api/v1/admin/users",
+	},
+	{
+		Method: "PATCH",
+		Path:   "/api/v1/admin/users/:id",
+	},
+	{
+		Method: "POST",
+		Path:   "/api/v1/admin/users/:id/activate",
+	},
+	{
+		Method: "POST",
+		Path:   "/api/v1/admin/users/:id/deactivate",
+	},
+	{
+		Method: "POST",
+		Path:   "/api/v1/admin/oauth-clients",
+	},
+	{
+		Method: "POST",
+		Path:   "/api/v1/admin/oauth-clients/:id/secret",
+	},
 }
 
 func containsRoute(routes []*echo.Route, method, path string) bool {
@@ -62,3 +205,33 @@ func TestNew(t *testing.T) {
 		assert.True(t, containsRoute(routes, eRoute.Method, eRoute.Path))
 	}
 }
+
+func TestIPExtractor(t *testing.T) {
+	t.Run("it should ignore a spoofed X-Forwarded-For header", func(t *testing.T) {
+		diContainer, err := registry.Get()
+		if err != nil {
+			t.Fatalf("an error '%s' was not expected", err)
+		}
+		router := New(diContainer)
+
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.RemoteAddr = "203.0.113.7:54321"
+		req.Header.Set(echo.HeaderXForwardedFor, "10.0.0.1")
+		req.Header.Set(echo.HeaderXRealIP, "10.0.0.2")
+
+		assert.Equal(t, "203.0.113.7", router.NewContext(req, httptest.NewRecorder()).RealIP())
+	})
+
+	t.Run("it should read X-Forwarded-For from a trusted proxy only", func(t *testing.T) {
+		_, trustedProxy, _ := net.ParseCIDR("192.168.0.0/24")
+		extractIP := ipExtractor([]*net.IPNet{trustedProxy})
+
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.RemoteAddr = "192.168.0.10:54321"
+		req.Header.Set(echo.HeaderXForwardedFor, "203.0.113.7")
+		assert.Equal(t, "203.0.113.7", extractIP(req))
+
+		req.RemoteAddr = "198.51.100.3:54321"
+		assert.Equal(t, "198.51.100.3", extractIP(req))
+	})
+}
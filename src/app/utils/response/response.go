@@ -10,12 +10,19 @@ type (
 	// D Response Data type
 	D map[string]interface{}
 
+	// Pagination Response pagination envelope, NextCursor is empty on the last page
+	Pagination struct {
+		NextCursor string `json:"next_cursor"`
+		Total      int    `json:"total"`
+	}
+
 	// Response Response.Response struct definition
 	Response struct {
-		Status int
-		Error  string
-		Errors map[string]string
-		Data   D
+		Status     int
+		Error      string
+		Errors     map[string]string
+		Data       D
+		Pagination *Pagination
 	}
 )
 
@@ -32,7 +39,8 @@ func (res *Response) GetStatus() int {
 	return res.Status
 }
 
-// GetBody returns the body of the Response contains status key, and one of the following keys: error, errors, data
+// GetBody returns the body of the Response contains status key, and one of the following keys: error, errors, data,
+// paginated data also has a pagination key
 func (res *Response) GetBody() map[string]interface{} {
 	body := make(map[string]interface{})
 
@@ -43,6 +51,10 @@ func (res *Response) GetBody() map[string]interface{} {
 		body["errors"] = res.Errors
 	}
 
+	if res.Pagination != nil {
+		body["pagination"] = res.Pagination
+	}
+
 	body["data"] = res.Data
 	return body
 }
@@ -53,6 +65,7 @@ func (res *Response) SetInternalServerError() {
 	res.Error = internalServerError
 	res.Errors = nil
 	res.Data = nil
+	res.Pagination = nil
 }
 
 // SetError sets with an error { Status: [status], Error: [error], Errors: nil, Data: nil }
@@ -61,6 +74,7 @@ func (res *Response) SetError(status int, err string) {
 	res.Error = err
 	res.Errors = nil
 	res.Data = nil
+	res.Pagination = nil
 }
 
 // SetErrors sets a Response with multiple errors { Status: [status], Errors: [errors], Error: "", Data: nil }
@@ -69,6 +83,7 @@ func (res *Response) SetErrors(status int, errs map[string]string) {
 	res.Errors = errs
 	res.Error = ""
 	res.Data = nil
+	res.Pagination = nil
 }
 
 // SetData sets a Response with data { Status: [status], Data: [data], Errors: nil, Error: "" }
@@ -77,4 +92,11 @@ func (res *Response) SetData(status int, data map[string]interface{}) {
 	res.Data = data
 	res.Error = ""
 	res.Errors = nil
+	res.Pagination = nil
+}
+
+// SetPaginatedData sets a Response with a page of data { Status: [status], Data: [data], Pagination: [pagination], Errors: nil, Error: "" }
+func (res *Response) SetPaginatedData(status int, data map[string]interface{}, pagination Pagination) {
+	res.SetData(status, data)
+	res.Pagination = &pagination
 }
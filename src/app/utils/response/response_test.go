@@ -8,10 +8,11 @@ import (
 )
 
 var (
-	mockStatus = 0
-	mockError  = "some-error"
-	mockErrors = map[string]string{"some-error": "some-error", "another-error": "another-error"}
-	mockData   = D{"some-data": "some-data"}
+	mockStatus     = 0
+	mockError      = "some-error"
+	mockErrors     = map[string]string{"some-error": "some-error", "another-error": "another-error"}
+	mockData       = D{"some-data": "some-data"}
+	mockPagination = Pagination{NextCursor: "some-cursor", Total: 1}
 )
 
 func TestResponse(t *testing.T) {
@@ -43,6 +44,10 @@ func TestGetBody(t *testing.T) {
 	assert.Equal(t, mockError, body["error"])
 	assert.Equal(t, mockErrors, body["errors"])
 	assert.Equal(t, mockData, body["data"])
+	assert.NotContains(t, body, "pagination")
+	response.Pagination = &mockPagination
+	body = response.GetBody()
+	assert.Equal(t, &mockPagination, body["pagination"])
 }
 
 func TestSetInternalServerError(t *testing.T) {
@@ -79,3 +84,14 @@ func TestSetData(t *testing.T) {
 	assert.Equal(t, mockStatus, response.Status)
 	assert.Equal(t, mockData, response.Data)
 }
+
+func TestSetPaginatedData(t *testing.T) {
+	response := NewResponse()
+	response.SetPaginatedData(mockStatus, mockData, mockPagination)
+	assert.Equal(t, mockStatus, response.Status)
+	assert.Equal(t, mockData, response.Data)
+	assert.Equal(t, &mockPagination, response.Pagination)
+
+	response.SetError(mockStatus, mockError)
+	assert.Nil(t, response.Pagination)
+}
@@ -29,3 +29,18 @@ func NewDuplicateEntryError(msg string) *DuplicateEntryError {
 func (err *DuplicateEntryError) Error() string {
 	return err.msg
 }
+
+// InvalidQueryError struct error type that should be used to indicate that the error is caused by a query the datastore can't run (e.g. a malformed cursor).
+type InvalidQueryError struct {
+	msg string
+}
+
+// NewInvalidQueryError is the InvalidQueryError constructor.
+func NewInvalidQueryError(msg string) *InvalidQueryError {
+	return &InvalidQueryError{msg: msg}
+}
+
+// Error returns the error message.
+func (err *InvalidQueryError) Error() string {
+	return err.msg
+}
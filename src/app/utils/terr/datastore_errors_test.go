@@ -19,3 +19,10 @@ func TestDuplicateEntryError(t *testing.T) {
 	assert.Equal(t, reflect.TypeOf(err), reflect.TypeOf(&DuplicateEntryError{}))
 	assert.Equal(t, mockErrorMessage, err.Error())
 }
+
+func TestInvalidQueryError(t *testing.T) {
+	mockErrorMessage := "some-error-message"
+	err := NewInvalidQueryError(mockErrorMessage)
+	assert.Equal(t, reflect.TypeOf(err), reflect.TypeOf(&InvalidQueryError{}))
+	assert.Equal(t, mockErrorMessage, err.Error())
+}
@@ -1,5 +1,9 @@
 package terr
 
+import (
+	"time"
+)
+
 // UnAuthorizedError struct error type that should be used to indicate that the error is caused by the nonexistence of the requested resource.
 type UnAuthorizedError struct {
 	msg string
@@ -14,3 +18,156 @@ func NewUnAuthorizedError(msg string) *UnAuthorizedError {
 func (err *UnAuthorizedError) Error() string {
 	return err.msg
 }
+
+// ExpiredTokenError struct error type that should be used to indicate that the error is caused by an expired token.
+type ExpiredTokenError struct {
+	msg string
+}
+
+// NewExpiredTokenError is the ExpiredTokenError constructor.
+func NewExpiredTokenError(msg string) *ExpiredTokenError {
+	return &ExpiredTokenError{msg: msg}
+}
+
+// Error returns the error message.
+func (err *ExpiredTokenError) Error() string {
+	return err.msg
+}
+
+// MalformedTokenError struct error type that should be used to indicate that the error is caused by a token that can't be decoded or lacks required claims.
+type MalformedTokenError struct {
+	msg string
+}
+
+// NewMalformedTokenError is the MalformedTokenError constructor.
+func NewMalformedTokenError(msg string) *MalformedTokenError {
+	return &MalformedTokenError{msg: msg}
+}
+
+// Error returns the error message.
+func (err *MalformedTokenError) Error() string {
+	return err.msg
+}
+
+// TokenTypeError struct error type that should be used to indicate that the error is caused by a token of another type (e.g. a refresh token used as an access token).
+type TokenTypeError struct {
+	msg string
+}
+
+// NewTokenTypeError is the TokenTypeError constructor.
+func NewTokenTypeError(msg string) *TokenTypeError {
+	return &TokenTypeError{msg: msg}
+}
+
+// Error returns the error message.
+func (err *TokenTypeError) Error() string {
+	return err.msg
+}
+
+// TokenSignatureError struct error type that should be used to indicate that the error is caused by a token signature that can't be verified.
+type TokenSignatureError struct {
+	msg string
+}
+
+// NewTokenSignatureError is the TokenSignatureError constructor.
+func NewTokenSignatureError(msg string) *TokenSignatureError {
+	return &TokenSignatureError{msg: msg}
+}
+
+// Error returns the error message.
+func (err *TokenSignatureError) Error() string {
+	return err.msg
+}
+
+// UnverifiedEmailError struct error type that should be used to indicate that the error is caused by a user whose email address is not verified yet.
+type UnverifiedEmailError struct {
+	msg string
+}
+
+// NewUnverifiedEmailError is the UnverifiedEmailError constructor.
+func NewUnverifiedEmailError(msg string) *UnverifiedEmailError {
+	return &UnverifiedEmailError{msg: msg}
+}
+
+// Error returns the error message.
+func (err *UnverifiedEmailError) Error() string {
+	return err.msg
+}
+
+// InactiveUserError struct error type that should be used to indicate that the error is caused by a deactivated user.
+type InactiveUserError struct {
+	msg string
+}
+
+// NewInactiveUserError is the InactiveUserError constructor.
+func NewInactiveUserError(msg string) *InactiveUserError {
+	return &InactiveUserError{msg: msg}
+}
+
+// Error returns the error message.
+func (err *InactiveUserError) Error() string {
+	return err.msg
+}
+
+// AccountLockedError struct error type that should be used to indicate that the error is caused by an account locked after too many failed logins.
+type AccountLockedError struct {
+	msg        string
+	retryAfter time.Duration
+}
+
+// NewAccountLockedError is the AccountLockedError constructor.
+func NewAccountLockedError(msg string, retryAfter time.Duration) *AccountLockedError {
+	return &AccountLockedError{msg: msg, retryAfter: retryAfter}
+}
+
+// Error returns the error message.
+func (err *AccountLockedError) Error() string {
+	return err.msg
+}
+
+// RetryAfter returns how long the account stays locked.
+func (err *AccountLockedError) RetryAfter() time.Duration {
+	return err.retryAfter
+}
+
+// TooManyAttemptsError struct error type that should be used to indicate that the error is caused by an IP address locked after too many failed logins.
+type TooManyAttemptsError struct {
+	msg        string
+	retryAfter time.Duration
+}
+
+// NewTooManyAttemptsError is the TooManyAttemptsError constructor.
+func NewTooManyAttemptsError(msg string, retryAfter time.Duration) *TooManyAttemptsError {
+	return &TooManyAttemptsError{msg: msg, retryAfter: retryAfter}
+}
+
+// Error returns the error message.
+func (err *TooManyAttemptsError) Error() string {
+	return err.msg
+}
+
+// RetryAfter returns how long the IP address stays locked.
+func (err *TooManyAttemptsError) RetryAfter() time.Duration {
+	return err.retryAfter
+}
+
+// OAuthError struct error type that should be used to indicate that an OAuth 2.0 request failed with a RFC 6749 error code.
+type OAuthError struct {
+	code string
+	msg  string
+}
+
+// NewOAuthError is the OAuthError constructor.
+func NewOAuthError(code, msg string) *OAuthError {
+	return &OAuthError{code: code, msg: msg}
+}
+
+// Error returns the error message.
+func (err *OAuthError) Error() string {
+	return err.msg
+}
+
+// Code returns the RFC 6749 error code (e.g. invalid_grant).
+func (err *OAuthError) Code() string {
+	return err.code
+}
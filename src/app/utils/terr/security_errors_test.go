@@ -4,6 +4,7 @@ import (
 	"github.com/stretchr/testify/assert"
 	"reflect"
 	"testing"
+	"time"
 )
 
 func TestNewUnAuthorizedError(t *testing.T) {
@@ -12,3 +13,69 @@ func TestNewUnAuthorizedError(t *testing.T) {
 	assert.Equal(t, reflect.TypeOf(err), reflect.TypeOf(&UnAuthorizedError{}))
 	assert.Equal(t, mockErrorMessage, err.Error())
 }
+
+func TestNewExpiredTokenError(t *testing.T) {
+	mockErrorMessage := "some-error-message"
+	err := NewExpiredTokenError(mockErrorMessage)
+	assert.Equal(t, reflect.TypeOf(err), reflect.TypeOf(&ExpiredTokenError{}))
+	assert.Equal(t, mockErrorMessage, err.Error())
+}
+
+func TestNewMalformedTokenError(t *testing.T) {
+	mockErrorMessage := "some-error-message"
+	err := NewMalformedTokenError(mockErrorMessage)
+	assert.Equal(t, reflect.TypeOf(err), reflect.TypeOf(&MalformedTokenError{}))
+	assert.Equal(t, mockErrorMessage, err.Error())
+}
+
+func TestNewTokenTypeError(t *testing.T) {
+	mockErrorMessage := "some-error-message"
+	err := NewTokenTypeError(mockErrorMessage)
+	assert.Equal(t, reflect.TypeOf(err), reflect.TypeOf(&TokenTypeError{}))
+	assert.Equal(t, mockErrorMessage, err.Error())
+}
+
+func TestNewTokenSignatureError(t *testing.T) {
+	mockErrorMessage := "some-error-message"
+	err := NewTokenSignatureError(mockErrorMessage)
+	assert.Equal(t, reflect.TypeOf(err), reflect.TypeOf(&TokenSignatureError{}))
+	assert.Equal(t, mockErrorMessage, err.Error())
+}
+
+func TestNewUnverifiedEmailError(t *testing.T) {
+	mockErrorMessage := "some-error-message"
+	err := NewUnverifiedEmailError(mockErrorMessage)
+	assert.Equal(t, reflect.TypeOf(err), reflect.TypeOf(&UnverifiedEmailError{}))
+	assert.Equal(t, mockErrorMessage, err.Error())
+}
+
+func TestNewInactiveUserError(t *testing.T) {
+	mockErrorMessage := "some-error-message"
+	err := NewInactiveUserError(mockErrorMessage)
+	assert.Equal(t, reflect.TypeOf(err), reflect.TypeOf(&InactiveUserError{}))
+	assert.Equal(t, mockErrorMessage, err.Error())
+}
+
+func TestNewAccountLockedError(t *testing.T) {
+	mockErrorMessage := "some-error-message"
+	err := NewAccountLockedError(mockErrorMessage, time.Minute)
+	assert.Equal(t, reflect.TypeOf(err), reflect.TypeOf(&AccountLockedError{}))
+	assert.Equal(t, mockErrorMessage, err.Error())
+	assert.Equal(t, time.Minute, err.RetryAfter())
+}
+
+func TestNewTooManyAttemptsError(t *testing.T) {
+	mockErrorMessage := "some-error-message"
+	err := NewTooManyAttemptsError(mockErrorMessage, time.Minute)
+	assert.Equal(t, reflect.TypeOf(err), reflect.TypeOf(&TooManyAttemptsError{}))
+	assert.Equal(t, mockErrorMessage, err.Error())
+	assert.Equal(t, time.Minute, err.RetryAfter())
+}
+
+func TestNewOAuthError(t *testing.T) {
+	mockErrorMessage := "some-error-message"
+	err := NewOAuthError("invalid_grant", mockErrorMessage)
+	assert.Equal(t, reflect.TypeOf(err), reflect.TypeOf(&OAuthError{}))
+	assert.Equal(t, mockErrorMessage, err.Error())
+	assert.Equal(t, "invalid_grant", err.Code())
+}
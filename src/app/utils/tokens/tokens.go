@@ -0,0 +1,24 @@
+package tokens
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"io"
+)
+
+// Random generates an unguessable url safe token, 32 random bytes base64url encoded, it is also a valid
+// PKCE code verifier (RFC 7636)
+func Random() (string, error) {
+	b := make([]byte, 32)
+	if _, err := io.ReadFull(rand.Reader, b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// S256Challenge computes the S256 PKCE code challenge of a code verifier (RFC 7636)
+func S256Challenge(codeVerifier string) string {
+	sum := sha256.Sum256([]byte(codeVerifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
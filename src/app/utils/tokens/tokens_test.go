@@ -0,0 +1,24 @@
+package tokens
+
+import (
+	"encoding/base64"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestRandom(t *testing.T) {
+	token, err := Random()
+	if assert.NoError(t, err) {
+		b, err := base64.RawURLEncoding.DecodeString(token)
+		assert.NoError(t, err)
+		assert.Len(t, b, 32)
+
+		otherToken, _ := Random()
+		assert.NotEqual(t, token, otherToken)
+	}
+}
+
+func TestS256Challenge(t *testing.T) {
+	// RFC 7636 appendix B
+	assert.Equal(t, "E9Melhoa2OwvFrEMTJguCHaoeK1t8URWbuGJSstw-cM", S256Challenge("dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk"))
+}
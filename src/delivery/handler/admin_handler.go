@@ -0,0 +1,134 @@
+package handler
+
+import (
+	"context"
+	"github.com/labstack/echo/v4"
+	"net/http"
+	"sherman/src/app/utils/response"
+	"sherman/src/app/utils/terr"
+	"sherman/src/domain/auth"
+	cmw "sherman/src/service/middleware"
+	"sherman/src/service/presenter"
+	"sherman/src/service/validator"
+)
+
+type (
+	// AdminHandler handler for /admin/users/[routes]
+	AdminHandler interface {
+		ListUsers(ctx echo.Context) error
+		UpdateUser(ctx echo.Context) error
+		ActivateUser(ctx echo.Context) error
+		DeactivateUser(ctx echo.Context) error
+	}
+
+	adminHandler struct {
+		userUseCase auth.UserUseCase
+		validator   validator.Validator
+		presenter   presenter.Presenter
+	}
+)
+
+// NewAdminHandler constructor
+func NewAdminHandler(uuc auth.UserUseCase, vs validator.Validator, ps presenter.Presenter) AdminHandler {
+	return &adminHandler{
+		userUseCase: uuc,
+		validator:   vs,
+		presenter:   ps,
+	}
+}
+
+// ListUsers lists a page of users filtered and sorted by the query params
+func (h *adminHandler) ListUsers(ctx echo.Context) error {
+	res := response.NewResponse()
+
+	query, errors := h.validator.ValidateUserQueryParams(ctx.QueryParams())
+	if len(errors) > 0 {
+		res.SetErrors(http.StatusUnprocessableEntity, errors)
+		return ctx.JSON(res.GetStatus(), res.GetBody())
+	}
+
+	page, err := h.userUseCase.ListUsers(ctx.Request().Context(), query)
+	if err != nil {
+		switch err.(type) {
+		case *terr.InvalidQueryError:
+			res.SetError(http.StatusUnprocessableEntity, err.Error())
+		default:
+			res.SetInternalServerError()
+		}
+		return ctx.JSON(res.GetStatus(), res.GetBody())
+	}
+
+	res.SetPaginatedData(
+		http.StatusOK,
+		response.D{"users": h.presenter.PresentUsers(page.Users)},
+		response.Pagination{NextCursor: page.NextCursor, Total: page.Total},
+	)
+	return ctx.JSON(res.GetStatus(), res.GetBody())
+}
+
+// UpdateUser updates the names and email address of the user of the id route param
+func (h *adminHandler) UpdateUser(ctx echo.Context) error {
+	var user auth.User
+	res := response.NewResponse()
+
+	if err := ctx.Bind(&user); err != nil {
+		res.SetInternalServerError()
+		return ctx.JSON(res.GetStatus(), res.GetBody())
+	}
+
+	if errors := h.validator.ValidateUserParams(&user, "update"); len(errors) > 0 {
+		res.SetErrors(http.StatusUnprocessableEntity, errors)
+		return ctx.JSON(res.GetStatus(), res.GetBody())
+	}
+
+	user.ID = ctx.Param("id")
+	updatedUser, err := h.userUseCase.UpdateUser(ctx.Request().Context(), &user)
+	if err != nil {
+		switch err.(type) {
+		case *terr.NotFoundError:
+			res.SetError(http.StatusNotFound, err.Error())
+		case *terr.DuplicateEntryError:
+			res.SetError(http.StatusForbidden, err.Error())
+		default:
+			res.SetInternalServerError()
+		}
+		return ctx.JSON(res.GetStatus(), res.GetBody())
+	}
+
+	res.SetData(http.StatusOK, response.D{"user": h.presenter.PresentUser(&updatedUser)})
+	return ctx.JSON(res.GetStatus(), res.GetBody())
+}
+
+// ActivateUser activates the user of the id route param
+func (h *adminHandler) ActivateUser(ctx echo.Context) error {
+	return h.setUserActive(ctx, h.userUseCase.ActivateUser)
+}
+
+// DeactivateUser deactivates the user of the id route param and logs out its sessions
+func (h *adminHandler) DeactivateUser(ctx echo.Context) error {
+	return h.setUserActive(ctx, h.userUseCase.DeactivateUser)
+}
+
+// setUserActive activates or deactivates the user of the id route param on behalf of the principal
+func (h *adminHandler) setUserActive(ctx echo.Context, setActive func(ctx context.Context, userID, actorID string) error) error {
+	res := response.NewResponse()
+
+	principal, ok := cmw.GetPrincipal(ctx)
+	if !ok {
+		res.SetError(http.StatusUnauthorized, "invalid token")
+		return ctx.JSON(res.GetStatus(), res.GetBody())
+	}
+
+	if err := setActive(ctx.Request().Context(), ctx.Param("id"), principal.UserID); err != nil {
+		switch err.(type) {
+		case *terr.NotFoundError:
+			res.SetError(http.StatusNotFound, err.Error())
+		default:
+			res.SetInternalServerError()
+		}
+		return ctx.JSON(res.GetStatus(), res.GetBody())
+	}
+
+	res.SetData(http.StatusOK, nil)
+	return ctx.JSON(res.GetStatus(), res.GetBody())
+}
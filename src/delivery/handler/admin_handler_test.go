@@ -0,0 +1,256 @@
+package handler
+
+import (
+	"errors"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"net/http"
+	"net/http/httptest"
+	"sherman/mocks"
+	_ "sherman/src/app/testing"
+	"sherman/src/app/utils/terr"
+	"sherman/src/domain/auth"
+	cmw "sherman/src/service/middleware"
+	"strings"
+	"testing"
+)
+
+type adminHandlerMockDeps struct {
+	userUseCase      *mocks.UserUseCase
+	validatorService *mocks.Validator
+	presenterService *mocks.Presenter
+}
+
+func genMockAdminHandler() (AdminHandler, adminHandlerMockDeps) {
+	ahDeps := adminHandlerMockDeps{
+		userUseCase:      new(mocks.UserUseCase),
+		validatorService: new(mocks.Validator),
+		presenterService: new(mocks.Presenter),
+	}
+
+	ah := NewAdminHandler(ahDeps.userUseCase, ahDeps.validatorService, ahDeps.presenterService)
+
+	return ah, ahDeps
+}
+
+func genAdminRequestContext(method, target, body, userID string) (echo.Context, *httptest.ResponseRecorder) {
+	e := echo.New()
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	rec := httptest.NewRecorder()
+	ctx := e.NewContext(req, rec)
+	ctx.SetParamNames("id")
+	ctx.SetParamValues(userID)
+	cmw.SetPrincipal(ctx, auth.TokenMetadata{UserID: "some-admin-id"})
+	return ctx, rec
+}
+
+func TestListUsers(t *testing.T) {
+	mockUsers := []auth.User{{ID: "some-user-id"}, {ID: "some-other-user-id"}}
+	mockActive := true
+
+	t.Run("it should succeed", func(t *testing.T) {
+		ah, ahDeps := genMockAdminHandler()
+		mockQuery := auth.UserQuery{Active: &mockActive, Limit: 2}
+		ahDeps.validatorService.
+			On("ValidateUserQueryParams", mock.Anything).
+			Return(mockQuery, make(map[string]string))
+		ahDeps.userUseCase.
+			On("ListUsers", mock.Anything, mockQuery).
+			Return(auth.UserPage{Users: mockUsers, NextCursor: "some-cursor", Total: 5}, nil)
+		ahDeps.presenterService.
+			On("PresentUsers", mockUsers).
+			Return([]auth.PresentedUser{{ID: "some-user-id"}, {ID: "some-other-user-id"}})
+
+		ctx, rec := genAdminRequestContext(echo.GET, "/some-url?active=true&limit=2", "", "")
+
+		if assert.NoError(t, ah.ListUsers(ctx)) {
+			assert.Equal(t, http.StatusOK, rec.Code)
+			assert.Contains(t, rec.Body.String(), "\"pagination\":{\"next_cursor\":\"some-cursor\",\"total\":5}")
+			assert.Contains(t, rec.Body.String(), "\"id\":\"some-other-user-id\"")
+			ahDeps.validatorService.AssertCalled(t, "ValidateUserQueryParams", ctx.QueryParams())
+		}
+	})
+
+	t.Run("it should return error", func(t *testing.T) {
+		ah, ahDeps := genMockAdminHandler()
+		ahDeps.validatorService.
+			On("ValidateUserQueryParams", mock.Anything).
+			Return(auth.UserQuery{}, map[string]string{"limit_invalid": "limit must be between 1 and 100"})
+
+		ctx, rec := genAdminRequestContext(echo.GET, "/some-url?limit=1000", "", "")
+
+		if assert.NoError(t, ah.ListUsers(ctx)) {
+			assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+			ahDeps.userUseCase.AssertNotCalled(t, "ListUsers", mock.Anything, mock.Anything)
+		}
+	})
+
+	t.Run("it should return error", func(t *testing.T) {
+		ah, ahDeps := genMockAdminHandler()
+		ahDeps.validatorService.
+			On("ValidateUserQueryParams", mock.Anything).
+			Return(auth.UserQuery{Cursor: "some-cursor"}, make(map[string]string))
+		ahDeps.userUseCase.
+			On("ListUsers", mock.Anything, mock.Anything).
+			Return(auth.UserPage{}, terr.NewInvalidQueryError("invalid cursor"))
+
+		ctx, rec := genAdminRequestContext(echo.GET, "/some-url?cursor=some-cursor", "", "")
+
+		if assert.NoError(t, ah.ListUsers(ctx)) {
+			assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+			assert.Equal(t, "{\"data\":null,\"error\":\"invalid cursor\"}\n", rec.Body.String())
+		}
+	})
+
+	t.Run("it should return error", func(t *testing.T) {
+		ah, ahDeps := genMockAdminHandler()
+		ahDeps.validatorService.
+			On("ValidateUserQueryParams", mock.Anything).
+			Return(auth.UserQuery{}, make(map[string]string))
+		ahDeps.userUseCase.
+			On("ListUsers", mock.Anything, mock.Anything).
+			Return(auth.UserPage{}, errors.New("some error"))
+
+		ctx, rec := genAdminRequestContext(echo.GET, "/some-url", "", "")
+
+		if assert.NoError(t, ah.ListUsers(ctx)) {
+			assert.Equal(t, http.StatusInternalServerError, rec.Code)
+		}
+	})
+}
+
+func TestAdminUpdateUser(t *testing.T) {
+	mockUser := auth.User{ID: "some-user-id", FirstName: "other-first"}
+
+	t.Run("it should succeed", func(t *testing.T) {
+		ah, ahDeps := genMockAdminHandler()
+		ahDeps.validatorService.On("ValidateUserParams", mock.Anything, "update").Return(make(map[string]string))
+		ahDeps.userUseCase.
+			On("UpdateUser", mock.Anything, mock.MatchedBy(func(u *auth.User) bool {
+				return u.ID == "some-user-id" && u.FirstName == "other-first"
+			})).
+			Return(mockUser, nil)
+		ahDeps.presenterService.
+			On("PresentUser", &mockUser).
+			Return(auth.PresentedUser{ID: mockUser.ID, FirstName: mockUser.FirstName})
+
+		ctx, rec := genAdminRequestContext(echo.PATCH, "/some-url", "{\"first_name\":\"other-first\"}", "some-user-id")
+
+		if assert.NoError(t, ah.UpdateUser(ctx)) {
+			assert.Equal(t, http.StatusOK, rec.Code)
+			assert.Contains(t, rec.Body.String(), "\"first_name\":\"other-first\"")
+		}
+	})
+
+	t.Run("it should return error", func(t *testing.T) {
+		ah, ahDeps := genMockAdminHandler()
+		ahDeps.validatorService.
+			On("ValidateUserParams", mock.Anything, "update").
+			Return(map[string]string{"fields_required": "first_name, last_name or email_address is required"})
+
+		ctx, rec := genAdminRequestContext(echo.PATCH, "/some-url", "{}", "some-user-id")
+
+		if assert.NoError(t, ah.UpdateUser(ctx)) {
+			assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+			ahDeps.userUseCase.AssertNotCalled(t, "UpdateUser", mock.Anything, mock.Anything)
+		}
+	})
+
+	t.Run("it should return error", func(t *testing.T) {
+		ah, ahDeps := genMockAdminHandler()
+		ahDeps.validatorService.On("ValidateUserParams", mock.Anything, "update").Return(make(map[string]string))
+		ahDeps.userUseCase.
+			On("UpdateUser", mock.Anything, mock.Anything).
+			Return(auth.User{}, terr.NewNotFoundError("user not found"))
+
+		ctx, rec := genAdminRequestContext(echo.PATCH, "/some-url", "{\"first_name\":\"other-first\"}", "some-user-id")
+
+		if assert.NoError(t, ah.UpdateUser(ctx)) {
+			assert.Equal(t, http.StatusNotFound, rec.Code)
+		}
+	})
+
+	t.Run("it should return error", func(t *testing.T) {
+		ah, ahDeps := genMockAdminHandler()
+		ahDeps.validatorService.On("ValidateUserParams", mock.Anything, "update").Return(make(map[string]string))
+		ahDeps.userUseCase.
+			On("UpdateUser", mock.Anything, mock.Anything).
+			Return(auth.User{}, terr.NewDuplicateEntryError("user already exist"))
+
+		ctx, rec := genAdminRequestContext(echo.PATCH, "/some-url", "{\"email_address\":\"other@email.com\"}", "some-user-id")
+
+		if assert.NoError(t, ah.UpdateUser(ctx)) {
+			assert.Equal(t, http.StatusForbidden, rec.Code)
+		}
+	})
+}
+
+func TestActivateUser(t *testing.T) {
+	t.Run("it should succeed", func(t *testing.T) {
+		ah, ahDeps := genMockAdminHandler()
+		ahDeps.userUseCase.On("ActivateUser", mock.Anything, "some-user-id", "some-admin-id").Return(nil)
+
+		ctx, rec := genAdminRequestContext(echo.POST, "/some-url", "", "some-user-id")
+
+		if assert.NoError(t, ah.ActivateUser(ctx)) {
+			assert.Equal(t, http.StatusOK, rec.Code)
+			ahDeps.userUseCase.AssertExpectations(t)
+		}
+	})
+
+	t.Run("it should return error", func(t *testing.T) {
+		ah, ahDeps := genMockAdminHandler()
+		ahDeps.userUseCase.
+			On("ActivateUser", mock.Anything, "some-user-id", "some-admin-id").
+			Return(terr.NewNotFoundError("user not found"))
+
+		ctx, rec := genAdminRequestContext(echo.POST, "/some-url", "", "some-user-id")
+
+		if assert.NoError(t, ah.ActivateUser(ctx)) {
+			assert.Equal(t, http.StatusNotFound, rec.Code)
+		}
+	})
+}
+
+func TestDeactivateUser(t *testing.T) {
+	t.Run("it should succeed", func(t *testing.T) {
+		ah, ahDeps := genMockAdminHandler()
+		ahDeps.userUseCase.On("DeactivateUser", mock.Anything, "some-user-id", "some-admin-id").Return(nil)
+
+		ctx, rec := genAdminRequestContext(echo.POST, "/some-url", "", "some-user-id")
+
+		if assert.NoError(t, ah.DeactivateUser(ctx)) {
+			assert.Equal(t, http.StatusOK, rec.Code)
+			ahDeps.userUseCase.AssertExpectations(t)
+		}
+	})
+
+	t.Run("it should return error", func(t *testing.T) {
+		ah, ahDeps := genMockAdminHandler()
+		ahDeps.userUseCase.
+			On("DeactivateUser", mock.Anything, "some-user-id", "some-admin-id").
+			Return(errors.New("some error"))
+
+		ctx, rec := genAdminRequestContext(echo.POST, "/some-url", "", "some-user-id")
+
+		if assert.NoError(t, ah.DeactivateUser(ctx)) {
+			assert.Equal(t, http.StatusInternalServerError, rec.Code)
+		}
+	})
+
+	t.Run("it should return error", func(t *testing.T) {
+		ah, _ := genMockAdminHandler()
+
+		e := echo.New()
+		rec := httptest.NewRecorder()
+		ctx := e.NewContext(httptest.NewRequest(echo.POST, "/some-url", nil), rec)
+		ctx.SetParamNames("id")
+		ctx.SetParamValues("some-user-id")
+
+		if assert.NoError(t, ah.DeactivateUser(ctx)) {
+			assert.Equal(t, http.StatusUnauthorized, rec.Code)
+		}
+	})
+}
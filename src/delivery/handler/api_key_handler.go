@@ -0,0 +1,138 @@
+package handler
+
+import (
+	"github.com/labstack/echo/v4"
+	"net/http"
+	"sherman/src/app/utils/response"
+	"sherman/src/app/utils/terr"
+	"sherman/src/domain/auth"
+	cmw "sherman/src/service/middleware"
+	"sherman/src/service/validator"
+)
+
+type (
+	// APIKeyHandler handler for /users/me/api-keys/[routes]
+	APIKeyHandler interface {
+		CreateAPIKey(ctx echo.Context) error
+		GetAPIKeys(ctx echo.Context) error
+		RevokeAPIKey(ctx echo.Context) error
+	}
+
+	apiKeyHandler struct {
+		apiKeyUseCase auth.APIKeyUseCase
+		validator     validator.Validator
+	}
+)
+
+// NewAPIKeyHandler constructor
+func NewAPIKeyHandler(akuc auth.APIKeyUseCase, vs validator.Validator) APIKeyHandler {
+	return &apiKeyHandler{
+		apiKeyUseCase: akuc,
+		validator:     vs,
+	}
+}
+
+// CreateAPIKey creates an API key for the user of the access token, the key is only shown in this response
+func (h *apiKeyHandler) CreateAPIKey(ctx echo.Context) error {
+	var apiKey auth.APIKey
+	res := response.NewResponse()
+
+	principal, ok := cmw.GetPrincipal(ctx)
+	if !ok {
+		res.SetError(http.StatusUnauthorized, "invalid token")
+		return ctx.JSON(res.GetStatus(), res.GetBody())
+	}
+
+	if err := ctx.Bind(&apiKey); err != nil {
+		res.SetInternalServerError()
+		return ctx.JSON(res.GetStatus(), res.GetBody())
+	}
+
+	if errors := h.validator.ValidateAPIKeyParams(&apiKey); len(errors) > 0 {
+		res.SetErrors(http.StatusUnprocessableEntity, errors)
+		return ctx.JSON(res.GetStatus(), res.GetBody())
+	}
+
+	if !canDelegateScopes(&principal, apiKey.Scopes) {
+		res.SetError(http.StatusForbidden, "token can not grant these scopes")
+		return ctx.JSON(res.GetStatus(), res.GetBody())
+	}
+
+	newAPIKey, err := h.apiKeyUseCase.CreateAPIKey(ctx.Request().Context(), &auth.APIKey{
+		UserID:    principal.UserID,
+		Name:      apiKey.Name,
+		Scopes:    apiKey.Scopes,
+		ExpiresAt: apiKey.ExpiresAt,
+	})
+	if err != nil {
+		res.SetInternalServerError()
+		return ctx.JSON(res.GetStatus(), res.GetBody())
+	}
+
+	res.SetData(http.StatusCreated, response.D{"api_key": newAPIKey})
+	return ctx.JSON(res.GetStatus(), res.GetBody())
+}
+
+// GetAPIKeys gets the API keys of the user of the access token, keys themselves are never shown again
+func (h *apiKeyHandler) GetAPIKeys(ctx echo.Context) error {
+	res := response.NewResponse()
+
+	principal, ok := cmw.GetPrincipal(ctx)
+	if !ok {
+		res.SetError(http.StatusUnauthorized, "invalid token")
+		return ctx.JSON(res.GetStatus(), res.GetBody())
+	}
+
+	apiKeys, err := h.apiKeyUseCase.GetAPIKeys(ctx.Request().Context(), principal.UserID)
+	if err != nil {
+		res.SetInternalServerError()
+		return ctx.JSON(res.GetStatus(), res.GetBody())
+	}
+
+	res.SetData(http.StatusOK, response.D{"api_keys": apiKeys})
+	return ctx.JSON(res.GetStatus(), res.GetBody())
+}
+
+// RevokeAPIKey revokes an API key of the user of the access token
+func (h *apiKeyHandler) RevokeAPIKey(ctx echo.Context) error {
+	res := response.NewResponse()
+
+	principal, ok := cmw.GetPrincipal(ctx)
+	if !ok {
+		res.SetError(http.StatusUnauthorized, "invalid token")
+		return ctx.JSON(res.GetStatus(), res.GetBody())
+	}
+
+	if err := h.apiKeyUseCase.RevokeAPIKey(ctx.Request().Context(), principal.UserID, ctx.Param("key_id")); err != nil {
+		switch err.(type) {
+		case *terr.NotFoundError:
+			res.SetError(http.StatusNotFound, err.Error())
+		default:
+			res.SetInternalServerError()
+		}
+		return ctx.JSON(res.GetStatus(), res.GetBody())
+	}
+
+	res.SetData(http.StatusOK, nil)
+	return ctx.JSON(res.GetStatus(), res.GetBody())
+}
+
+// canDelegateScopes checks if a key of scopes grants no more than principal, tokens issued to an OAuth
+// client can't create keys, and only first party sessions can create unrestricted keys
+func canDelegateScopes(principal *auth.TokenMetadata, scopes []string) bool {
+	if principal.ClientID != "" {
+		return false
+	}
+	if len(principal.Scopes) == 0 {
+		return true
+	}
+	if len(scopes) == 0 {
+		return false
+	}
+	for _, scope := range scopes {
+		if !principal.HasScope(scope) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,228 @@
+package handler
+
+import (
+	"errors"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"net/http"
+	"net/http/httptest"
+	"sherman/mocks"
+	_ "sherman/src/app/testing"
+	"sherman/src/app/utils/terr"
+	"sherman/src/domain/auth"
+	cmw "sherman/src/service/middleware"
+	"strings"
+	"testing"
+	"time"
+)
+
+type apiKeyHandlerMockDeps struct {
+	apiKeyUseCase    *mocks.APIKeyUseCase
+	validatorService *mocks.Validator
+}
+
+func genMockAPIKeyHandler() (APIKeyHandler, apiKeyHandlerMockDeps) {
+	akhDeps := apiKeyHandlerMockDeps{
+		apiKeyUseCase:    new(mocks.APIKeyUseCase),
+		validatorService: new(mocks.Validator),
+	}
+
+	akh := NewAPIKeyHandler(akhDeps.apiKeyUseCase, akhDeps.validatorService)
+
+	return akh, akhDeps
+}
+
+func TestCreateAPIKey(t *testing.T) {
+	lo, _ := time.LoadLocation("UTC")
+	mockTokenMeta := auth.TokenMetadata{UserID: "some-user-id", Type: auth.AccessTokenType}
+
+	t.Run("it should succeed", func(t *testing.T) {
+		akh, akhDeps := genMockAPIKeyHandler()
+		akhDeps.validatorService.On("ValidateAPIKeyParams", mock.AnythingOfType("*auth.APIKey")).Return(map[string]string{})
+		akhDeps.apiKeyUseCase.
+			On("CreateAPIKey", mock.Anything, mock.MatchedBy(func(apiKey *auth.APIKey) bool {
+				return apiKey.UserID == "some-user-id" && apiKey.Name == "some key" && apiKey.Scopes[0] == auth.ProfileScope
+			})).
+			Return(auth.NewAPIKey{
+				APIKey: auth.APIKey{
+					ID:        "some-id",
+					UserID:    "some-user-id",
+					Name:      "some key",
+					Prefix:    "shm_abcdefgh",
+					KeyHash:   "some-hash",
+					Scopes:    []string{auth.ProfileScope},
+					CreatedAt: time.Unix(0, 0).In(lo),
+				},
+				Key: "shm_abcdefghijkl",
+			}, nil)
+
+		e := echo.New()
+		req := httptest.NewRequest(echo.POST, "/some-url", strings.NewReader(`{"name":"some key","scopes":["profile"],"user_id":"other-user-id"}`))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+		ctx := e.NewContext(req, rec)
+		cmw.SetPrincipal(ctx, mockTokenMeta)
+
+		if assert.NoError(t, akh.CreateAPIKey(ctx)) {
+			assert.Equal(t, http.StatusCreated, rec.Code)
+			assert.Equal(
+				t,
+				"{\"data\":{\"api_key\":{\"id\":\"some-id\",\"user_id\":\"some-user-id\",\"name\":\"some key\",\"prefix\":\"shm_abcdefgh\",\"scopes\":[\"profile\"],\"expires_at\":null,\"created_at\":\"1970-01-01T00:00:00Z\",\"key\":\"shm_abcdefghijkl\"}}}\n",
+				rec.Body.String(),
+			)
+		}
+	})
+
+	t.Run("it should return validation errors", func(t *testing.T) {
+		akh, akhDeps := genMockAPIKeyHandler()
+		akhDeps.validatorService.
+			On("ValidateAPIKeyParams", mock.AnythingOfType("*auth.APIKey")).
+			Return(map[string]string{"name_required": "name is required"})
+
+		e := echo.New()
+		req := httptest.NewRequest(echo.POST, "/some-url", strings.NewReader(`{}`))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+		ctx := e.NewContext(req, rec)
+		cmw.SetPrincipal(ctx, mockTokenMeta)
+
+		if assert.NoError(t, akh.CreateAPIKey(ctx)) {
+			assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+			akhDeps.apiKeyUseCase.AssertNotCalled(t, "CreateAPIKey", mock.Anything, mock.Anything)
+		}
+	})
+
+	t.Run("it should forbid a token of an OAuth client", func(t *testing.T) {
+		for _, body := range []string{`{"name":"some key"}`, `{"name":"some key","scopes":["admin"]}`} {
+			akh, akhDeps := genMockAPIKeyHandler()
+			akhDeps.validatorService.On("ValidateAPIKeyParams", mock.AnythingOfType("*auth.APIKey")).Return(map[string]string{})
+
+			e := echo.New()
+			req := httptest.NewRequest(echo.POST, "/some-url", strings.NewReader(body))
+			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			rec := httptest.NewRecorder()
+			ctx := e.NewContext(req, rec)
+			cmw.SetPrincipal(ctx, auth.TokenMetadata{
+				UserID:   "some-user-id",
+				Type:     auth.AccessTokenType,
+				ClientID: "some-client-id",
+				Scopes:   []string{auth.AccountScope},
+			})
+
+			if assert.NoError(t, akh.CreateAPIKey(ctx)) {
+				assert.Equal(t, http.StatusForbidden, rec.Code)
+				akhDeps.apiKeyUseCase.AssertNotCalled(t, "CreateAPIKey", mock.Anything, mock.Anything)
+			}
+		}
+	})
+
+	t.Run("it should forbid scopes the token doesn't have", func(t *testing.T) {
+		for _, body := range []string{`{"name":"some key"}`, `{"name":"some key","scopes":["profile","admin"]}`} {
+			akh, akhDeps := genMockAPIKeyHandler()
+			akhDeps.validatorService.On("ValidateAPIKeyParams", mock.AnythingOfType("*auth.APIKey")).Return(map[string]string{})
+
+			e := echo.New()
+			req := httptest.NewRequest(echo.POST, "/some-url", strings.NewReader(body))
+			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			rec := httptest.NewRecorder()
+			ctx := e.NewContext(req, rec)
+			cmw.SetPrincipal(ctx, auth.TokenMetadata{
+				UserID: "some-user-id",
+				Type:   auth.AccessTokenType,
+				Scopes: []string{auth.AccountScope, auth.ProfileScope},
+			})
+
+			if assert.NoError(t, akh.CreateAPIKey(ctx)) {
+				assert.Equal(t, http.StatusForbidden, rec.Code)
+				akhDeps.apiKeyUseCase.AssertNotCalled(t, "CreateAPIKey", mock.Anything, mock.Anything)
+			}
+		}
+	})
+
+	t.Run("it should return error", func(t *testing.T) {
+		akh, akhDeps := genMockAPIKeyHandler()
+		akhDeps.validatorService.On("ValidateAPIKeyParams", mock.AnythingOfType("*auth.APIKey")).Return(map[string]string{})
+		akhDeps.apiKeyUseCase.On("CreateAPIKey", mock.Anything, mock.Anything).Return(auth.NewAPIKey{}, errors.New("some error"))
+
+		e := echo.New()
+		req := httptest.NewRequest(echo.POST, "/some-url", strings.NewReader(`{"name":"some key"}`))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+		ctx := e.NewContext(req, rec)
+		cmw.SetPrincipal(ctx, mockTokenMeta)
+
+		if assert.NoError(t, akh.CreateAPIKey(ctx)) {
+			assert.Equal(t, http.StatusInternalServerError, rec.Code)
+		}
+	})
+}
+
+func TestGetAPIKeys(t *testing.T) {
+	t.Run("it should succeed", func(t *testing.T) {
+		akh, akhDeps := genMockAPIKeyHandler()
+		akhDeps.apiKeyUseCase.
+			On("GetAPIKeys", mock.Anything, "some-user-id").
+			Return([]auth.APIKey{{ID: "some-id", Prefix: "shm_abcdefgh", KeyHash: "some-hash"}}, nil)
+
+		e := echo.New()
+		rec := httptest.NewRecorder()
+		ctx := e.NewContext(httptest.NewRequest(echo.GET, "/some-url", nil), rec)
+		cmw.SetPrincipal(ctx, auth.TokenMetadata{UserID: "some-user-id"})
+
+		if assert.NoError(t, akh.GetAPIKeys(ctx)) {
+			assert.Equal(t, http.StatusOK, rec.Code)
+			assert.Contains(t, rec.Body.String(), "\"prefix\":\"shm_abcdefgh\"")
+			assert.NotContains(t, rec.Body.String(), "some-hash")
+		}
+	})
+
+	t.Run("it should return error", func(t *testing.T) {
+		akh, _ := genMockAPIKeyHandler()
+
+		e := echo.New()
+		rec := httptest.NewRecorder()
+		ctx := e.NewContext(httptest.NewRequest(echo.GET, "/some-url", nil), rec)
+
+		if assert.NoError(t, akh.GetAPIKeys(ctx)) {
+			assert.Equal(t, http.StatusUnauthorized, rec.Code)
+		}
+	})
+}
+
+func TestRevokeAPIKey(t *testing.T) {
+	genContext := func() (echo.Context, *httptest.ResponseRecorder) {
+		e := echo.New()
+		rec := httptest.NewRecorder()
+		ctx := e.NewContext(httptest.NewRequest(echo.DELETE, "/some-url", nil), rec)
+		ctx.SetParamNames("key_id")
+		ctx.SetParamValues("some-id")
+		cmw.SetPrincipal(ctx, auth.TokenMetadata{UserID: "some-user-id"})
+		return ctx, rec
+	}
+
+	t.Run("it should succeed", func(t *testing.T) {
+		akh, akhDeps := genMockAPIKeyHandler()
+		akhDeps.apiKeyUseCase.On("RevokeAPIKey", mock.Anything, "some-user-id", "some-id").Return(nil)
+
+		ctx, rec := genContext()
+
+		if assert.NoError(t, akh.RevokeAPIKey(ctx)) {
+			assert.Equal(t, http.StatusOK, rec.Code)
+		}
+	})
+
+	t.Run("it should return error", func(t *testing.T) {
+		akh, akhDeps := genMockAPIKeyHandler()
+		akhDeps.apiKeyUseCase.
+			On("RevokeAPIKey", mock.Anything, "some-user-id", "some-id").
+			Return(terr.NewNotFoundError("api key not found"))
+
+		ctx, rec := genContext()
+
+		if assert.NoError(t, akh.RevokeAPIKey(ctx)) {
+			assert.Equal(t, http.StatusNotFound, rec.Code)
+			assert.Equal(t, "{\"data\":null,\"error\":\"api key not found\"}\n", rec.Body.String())
+		}
+	})
+}
@@ -0,0 +1,157 @@
+package handler
+
+import (
+	"encoding/json"
+	"github.com/labstack/echo/v4"
+	"net/http"
+	"sherman/src/app/utils/response"
+	"sherman/src/app/utils/terr"
+	"sherman/src/domain/auth"
+	"sherman/src/service/security"
+	"sherman/src/service/validator"
+	"time"
+)
+
+// oidcStateCookie cookie keeping the encrypted auth.OIDCAuthRequest of a social login
+const oidcStateCookie = "OIDC_STATE"
+
+type (
+	// AuthHandler handler for /auth/[routes]
+	AuthHandler interface {
+		StartSocialLogin(ctx echo.Context) error
+		SocialLoginCallback(ctx echo.Context) error
+	}
+
+	authHandler struct {
+		socialLoginUseCase   auth.SocialLoginUseCase
+		securityTokenUseCase auth.SecurityTokenUseCase
+		twoFactorUseCase     auth.TwoFactorUseCase
+		validator            validator.Validator
+		security             security.Security
+	}
+)
+
+// NewAuthHandler constructor
+func NewAuthHandler(
+	sluc auth.SocialLoginUseCase,
+	stuc auth.SecurityTokenUseCase,
+	tfuc auth.TwoFactorUseCase,
+	vs validator.Validator,
+	ss security.Security,
+) AuthHandler {
+	return &authHandler{
+		socialLoginUseCase:   sluc,
+		securityTokenUseCase: stuc,
+		twoFactorUseCase:     tfuc,
+		validator:            vs,
+		security:             ss,
+	}
+}
+
+// StartSocialLogin redirects the user agent to the provider, the request state is kept encrypted in
+// a cookie so that only the user agent that started the login can complete it
+func (h *authHandler) StartSocialLogin(ctx echo.Context) error {
+	res := response.NewResponse()
+
+	authRequest, err := h.socialLoginUseCase.StartLogin(ctx.Request().Context(), ctx.Param("provider"))
+	if err != nil {
+		switch err.(type) {
+		case *terr.NotFoundError:
+			res.SetError(http.StatusNotFound, err.Error())
+		default:
+			res.SetInternalServerError()
+		}
+		return ctx.JSON(res.GetStatus(), res.GetBody())
+	}
+
+	state, err := json.Marshal(authRequest)
+	if err != nil {
+		res.SetInternalServerError()
+		return ctx.JSON(res.GetStatus(), res.GetBody())
+	}
+	encryptedState, err := h.security.EncryptState(string(state))
+	if err != nil {
+		res.SetInternalServerError()
+		return ctx.JSON(res.GetStatus(), res.GetBody())
+	}
+
+	setOIDCStateCookie(ctx, encryptedState, int(time.Until(authRequest.ExpiresAt).Seconds()))
+	return ctx.Redirect(http.StatusFound, authRequest.URL)
+}
+
+// SocialLoginCallback completes the login the provider redirected back, the login state cookie is
+// cleared whatever the outcome so it is used at most once
+func (h *authHandler) SocialLoginCallback(ctx echo.Context) error {
+	res := response.NewResponse()
+	setOIDCStateCookie(ctx, "", 0)
+
+	if providerError := ctx.QueryParam("error"); providerError != "" {
+		res.SetError(http.StatusUnauthorized, "provider error: "+providerError)
+		return ctx.JSON(res.GetStatus(), res.GetBody())
+	}
+
+	state, code := ctx.QueryParam("state"), ctx.QueryParam("code")
+	if errors := h.validator.ValidateSocialLoginCallbackParams(state, code); len(errors) > 0 {
+		res.SetErrors(http.StatusUnprocessableEntity, errors)
+		return ctx.JSON(res.GetStatus(), res.GetBody())
+	}
+
+	authRequest, ok := h.getAuthRequest(ctx)
+	if !ok {
+		res.SetError(http.StatusUnauthorized, "invalid login state")
+		return ctx.JSON(res.GetStatus(), res.GetBody())
+	}
+
+	userID, err := h.socialLoginUseCase.CompleteLogin(ctx.Request().Context(), &authRequest, ctx.Param("provider"), state, code)
+	if err != nil {
+		switch err.(type) {
+		case *terr.UnAuthorizedError:
+			res.SetError(http.StatusUnauthorized, err.Error())
+		case *terr.UnverifiedEmailError, *terr.InactiveUserError:
+			res.SetError(http.StatusForbidden, err.Error())
+		case *terr.NotFoundError:
+			res.SetError(http.StatusNotFound, err.Error())
+		default:
+			res.SetInternalServerError()
+		}
+		return ctx.JSON(res.GetStatus(), res.GetBody())
+	}
+
+	return completeLogin(ctx, h.securityTokenUseCase, h.twoFactorUseCase, userID)
+}
+
+// getAuthRequest gets the auth.OIDCAuthRequest of the login state cookie
+func (h *authHandler) getAuthRequest(ctx echo.Context) (auth.OIDCAuthRequest, bool) {
+	var authRequest auth.OIDCAuthRequest
+
+	cookie, err := ctx.Cookie(oidcStateCookie)
+	if err != nil || cookie.Value == "" {
+		return authRequest, false
+	}
+
+	state, err := h.security.DecryptState(cookie.Value)
+	if err != nil {
+		return authRequest, false
+	}
+
+	if err := json.Unmarshal([]byte(state), &authRequest); err != nil {
+		return authRequest, false
+	}
+	return authRequest, true
+}
+
+// setOIDCStateCookie sets the OIDC_STATE cookie, an empty value with maxAge 0 clears it, the cookie is
+// lax so that it is sent along the top level redirect of the provider
+func setOIDCStateCookie(ctx echo.Context, value string, maxAge int) {
+	// TODO: add secure to cookie when tls is ready
+	ctx.SetCookie(&http.Cookie{
+		Name:     oidcStateCookie,
+		Value:    value,
+		MaxAge:   maxAge,
+		Path:     "/api/v1/auth",
+		Domain:   ctx.Request().Host,
+		Secure:   false,
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	})
+}
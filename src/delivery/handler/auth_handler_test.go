@@ -0,0 +1,282 @@
+package handler
+
+import (
+	"errors"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"net/http"
+	"net/http/httptest"
+	"sherman/mocks"
+	_ "sherman/src/app/testing"
+	"sherman/src/app/utils/terr"
+	"sherman/src/domain/auth"
+	"strings"
+	"testing"
+	"time"
+)
+
+type authHandlerMockDeps struct {
+	socialLoginUseCase   *mocks.SocialLoginUseCase
+	securityTokenUseCase *mocks.SecurityTokenUseCase
+	twoFactorUseCase     *mocks.TwoFactorUseCase
+	validatorService     *mocks.Validator
+	securityService      *mocks.Security
+}
+
+func genMockAuthHandler() (AuthHandler, authHandlerMockDeps) {
+	ahDeps := authHandlerMockDeps{
+		socialLoginUseCase:   new(mocks.SocialLoginUseCase),
+		securityTokenUseCase: new(mocks.SecurityTokenUseCase),
+		twoFactorUseCase:     new(mocks.TwoFactorUseCase),
+		validatorService:     new(mocks.Validator),
+		securityService:      new(mocks.Security),
+	}
+
+	ah := NewAuthHandler(
+		ahDeps.socialLoginUseCase,
+		ahDeps.securityTokenUseCase,
+		ahDeps.twoFactorUseCase,
+		ahDeps.validatorService,
+		ahDeps.securityService,
+	)
+
+	return ah, ahDeps
+}
+
+func genCallbackContext(query, stateCookie string) (echo.Context, *httptest.ResponseRecorder) {
+	e := echo.New()
+	req := httptest.NewRequest(echo.GET, "/some-url?"+query, nil)
+	if stateCookie != "" {
+		req.AddCookie(&http.Cookie{Name: oidcStateCookie, Value: stateCookie})
+	}
+	rec := httptest.NewRecorder()
+	ctx := e.NewContext(req, rec)
+	ctx.SetParamNames("provider")
+	ctx.SetParamValues("google")
+	return ctx, rec
+}
+
+func TestStartSocialLogin(t *testing.T) {
+	t.Run("it should succeed", func(t *testing.T) {
+		ah, ahDeps := genMockAuthHandler()
+		ahDeps.socialLoginUseCase.
+			On("StartLogin", mock.Anything, "google").
+			Return(auth.OIDCAuthRequest{
+				Provider:  "google",
+				State:     "some-state",
+				URL:       "https://idp.example.com/authorize?state=some-state",
+				ExpiresAt: time.Now().Add(time.Minute),
+			}, nil)
+		ahDeps.securityService.
+			On("EncryptState", mock.MatchedBy(func(state string) bool {
+				return strings.Contains(state, "\"state\":\"some-state\"") && !strings.Contains(state, "https://")
+			})).
+			Return("encrypted-state", nil)
+
+		ctx, rec := genCallbackContext("", "")
+
+		if assert.NoError(t, ah.StartSocialLogin(ctx)) {
+			assert.Equal(t, http.StatusFound, rec.Code)
+			assert.Equal(t, "https://idp.example.com/authorize?state=some-state", rec.Header().Get(echo.HeaderLocation))
+			assert.Contains(t, rec.Header().Get("Set-Cookie"), "OIDC_STATE=encrypted-state; Path=/api/v1/auth;")
+			assert.Contains(t, rec.Header().Get("Set-Cookie"), "HttpOnly; SameSite=Lax")
+		}
+	})
+
+	t.Run("it should return error", func(t *testing.T) {
+		ah, ahDeps := genMockAuthHandler()
+		ahDeps.socialLoginUseCase.
+			On("StartLogin", mock.Anything, "google").
+			Return(auth.OIDCAuthRequest{}, terr.NewNotFoundError("oidc provider not found"))
+
+		ctx, rec := genCallbackContext("", "")
+
+		if assert.NoError(t, ah.StartSocialLogin(ctx)) {
+			assert.Equal(t, http.StatusNotFound, rec.Code)
+			assert.Equal(t, "{\"data\":null,\"error\":\"oidc provider not found\"}\n", rec.Body.String())
+		}
+	})
+
+	t.Run("it should return error", func(t *testing.T) {
+		ah, ahDeps := genMockAuthHandler()
+		ahDeps.socialLoginUseCase.
+			On("StartLogin", mock.Anything, "google").
+			Return(auth.OIDCAuthRequest{}, errors.New("oidc provider google discovery failed"))
+
+		ctx, rec := genCallbackContext("", "")
+
+		if assert.NoError(t, ah.StartSocialLogin(ctx)) {
+			assert.Equal(t, http.StatusInternalServerError, rec.Code)
+		}
+	})
+}
+
+func TestSocialLoginCallback(t *testing.T) {
+	mockToken := auth.SecurityToken{Token: "some-token"}
+	mockState := "{\"provider\":\"google\",\"state\":\"some-state\",\"nonce\":\"some-nonce\"," +
+		"\"code_verifier\":\"some-verifier\",\"expires_at\":\"2026-10-18T18:00:00Z\"}"
+	isAuthRequest := mock.MatchedBy(func(authRequest *auth.OIDCAuthRequest) bool {
+		return authRequest.State == "some-state" && authRequest.CodeVerifier == "some-verifier"
+	})
+
+	t.Run("it should succeed", func(t *testing.T) {
+		ah, ahDeps := genMockAuthHandler()
+		ahDeps.validatorService.
+			On("ValidateSocialLoginCallbackParams", "some-state", "some-code").
+			Return(make(map[string]string))
+		ahDeps.securityService.On("DecryptState", "encrypted-state").Return(mockState, nil)
+		ahDeps.socialLoginUseCase.
+			On("CompleteLogin", mock.Anything, isAuthRequest, "google", "some-state", "some-code").
+			Return("some-user-id", nil)
+		ahDeps.twoFactorUseCase.On("IsEnabled", mock.Anything, "some-user-id").Return(false, nil)
+		ahDeps.securityTokenUseCase.On("GenAccessToken", mock.Anything, "some-user-id").Return(mockToken, nil)
+		ahDeps.securityTokenUseCase.
+			On("GenRefreshToken", mock.Anything, "some-user-id", mock.Anything, mock.Anything).
+			Return(mockToken, nil)
+
+		ctx, rec := genCallbackContext("state=some-state&code=some-code", "encrypted-state")
+
+		if assert.NoError(t, ah.SocialLoginCallback(ctx)) {
+			assert.Equal(t, http.StatusOK, rec.Code)
+			assert.Equal(t, "{\"data\":{\"access_token\":\"some-token\"}}\n", rec.Body.String())
+			cookies := rec.Header().Values("Set-Cookie")
+			if assert.Len(t, cookies, 2) {
+				assert.Contains(t, cookies[0], "OIDC_STATE=; Path=/api/v1/auth;")
+				assert.Equal(t, "REFRESH_TOKEN=some-token; Path=/; Domain=example.com; Max-Age=3600; HttpOnly", cookies[1])
+			}
+		}
+	})
+
+	t.Run("it should succeed with two factor authentication", func(t *testing.T) {
+		ah, ahDeps := genMockAuthHandler()
+		ahDeps.validatorService.
+			On("ValidateSocialLoginCallbackParams", "some-state", "some-code").
+			Return(make(map[string]string))
+		ahDeps.securityService.On("DecryptState", "encrypted-state").Return(mockState, nil)
+		ahDeps.socialLoginUseCase.
+			On("CompleteLogin", mock.Anything, isAuthRequest, "google", "some-state", "some-code").
+			Return("some-user-id", nil)
+		ahDeps.twoFactorUseCase.On("IsEnabled", mock.Anything, "some-user-id").Return(true, nil)
+		ahDeps.twoFactorUseCase.
+			On("GenPendingToken", mock.Anything, "some-user-id").
+			Return(auth.SecurityToken{Token: "some-pending-token"}, nil)
+
+		ctx, rec := genCallbackContext("state=some-state&code=some-code", "encrypted-state")
+
+		if assert.NoError(t, ah.SocialLoginCallback(ctx)) {
+			assert.Equal(t, http.StatusOK, rec.Code)
+			assert.Equal(t, "{\"data\":{\"mfa_required\":true,\"mfa_token\":\"some-pending-token\"}}\n", rec.Body.String())
+			ahDeps.securityTokenUseCase.AssertNotCalled(t, "GenAccessToken", mock.Anything, mock.Anything)
+		}
+	})
+
+	t.Run("it should return error", func(t *testing.T) {
+		ah, ahDeps := genMockAuthHandler()
+
+		ctx, rec := genCallbackContext("error=access_denied&state=some-state", "encrypted-state")
+
+		if assert.NoError(t, ah.SocialLoginCallback(ctx)) {
+			assert.Equal(t, http.StatusUnauthorized, rec.Code)
+			assert.Equal(t, "{\"data\":null,\"error\":\"provider error: access_denied\"}\n", rec.Body.String())
+			ahDeps.socialLoginUseCase.AssertNotCalled(t, "CompleteLogin", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+		}
+	})
+
+	t.Run("it should return error", func(t *testing.T) {
+		ah, ahDeps := genMockAuthHandler()
+		ahDeps.validatorService.
+			On("ValidateSocialLoginCallbackParams", "", "").
+			Return(map[string]string{"code_required": "code is required"})
+
+		ctx, rec := genCallbackContext("", "encrypted-state")
+
+		if assert.NoError(t, ah.SocialLoginCallback(ctx)) {
+			assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+		}
+	})
+
+	t.Run("it should return error", func(t *testing.T) {
+		ah, ahDeps := genMockAuthHandler()
+		ahDeps.validatorService.
+			On("ValidateSocialLoginCallbackParams", "some-state", "some-code").
+			Return(make(map[string]string))
+
+		ctx, rec := genCallbackContext("state=some-state&code=some-code", "")
+
+		if assert.NoError(t, ah.SocialLoginCallback(ctx)) {
+			assert.Equal(t, http.StatusUnauthorized, rec.Code)
+			assert.Equal(t, "{\"data\":null,\"error\":\"invalid login state\"}\n", rec.Body.String())
+		}
+	})
+
+	t.Run("it should return error", func(t *testing.T) {
+		ah, ahDeps := genMockAuthHandler()
+		ahDeps.validatorService.
+			On("ValidateSocialLoginCallbackParams", "some-state", "some-code").
+			Return(make(map[string]string))
+		ahDeps.securityService.
+			On("DecryptState", "forged-state").
+			Return("", errors.New("could not decrypt ciphertext"))
+
+		ctx, rec := genCallbackContext("state=some-state&code=some-code", "forged-state")
+
+		if assert.NoError(t, ah.SocialLoginCallback(ctx)) {
+			assert.Equal(t, http.StatusUnauthorized, rec.Code)
+			assert.Equal(t, "{\"data\":null,\"error\":\"invalid login state\"}\n", rec.Body.String())
+		}
+	})
+
+	t.Run("it should return error", func(t *testing.T) {
+		ah, ahDeps := genMockAuthHandler()
+		ahDeps.validatorService.
+			On("ValidateSocialLoginCallbackParams", "other-state", "some-code").
+			Return(make(map[string]string))
+		ahDeps.securityService.On("DecryptState", "encrypted-state").Return(mockState, nil)
+		ahDeps.socialLoginUseCase.
+			On("CompleteLogin", mock.Anything, isAuthRequest, "google", "other-state", "some-code").
+			Return("", terr.NewUnAuthorizedError("invalid login state"))
+
+		ctx, rec := genCallbackContext("state=other-state&code=some-code", "encrypted-state")
+
+		if assert.NoError(t, ah.SocialLoginCallback(ctx)) {
+			assert.Equal(t, http.StatusUnauthorized, rec.Code)
+			assert.Equal(t, "{\"data\":null,\"error\":\"invalid login state\"}\n", rec.Body.String())
+		}
+	})
+
+	t.Run("it should return error", func(t *testing.T) {
+		ah, ahDeps := genMockAuthHandler()
+		ahDeps.validatorService.
+			On("ValidateSocialLoginCallbackParams", "some-state", "some-code").
+			Return(make(map[string]string))
+		ahDeps.securityService.On("DecryptState", "encrypted-state").Return(mockState, nil)
+		ahDeps.socialLoginUseCase.
+			On("CompleteLogin", mock.Anything, isAuthRequest, "google", "some-state", "some-code").
+			Return("", terr.NewUnverifiedEmailError("provider email address not verified"))
+
+		ctx, rec := genCallbackContext("state=some-state&code=some-code", "encrypted-state")
+
+		if assert.NoError(t, ah.SocialLoginCallback(ctx)) {
+			assert.Equal(t, http.StatusForbidden, rec.Code)
+			assert.Equal(t, "{\"data\":null,\"error\":\"provider email address not verified\"}\n", rec.Body.String())
+		}
+	})
+
+	t.Run("it should return error", func(t *testing.T) {
+		ah, ahDeps := genMockAuthHandler()
+		ahDeps.validatorService.
+			On("ValidateSocialLoginCallbackParams", "some-state", "some-code").
+			Return(make(map[string]string))
+		ahDeps.securityService.On("DecryptState", "encrypted-state").Return(mockState, nil)
+		ahDeps.socialLoginUseCase.
+			On("CompleteLogin", mock.Anything, isAuthRequest, "google", "some-state", "some-code").
+			Return("", errors.New("oidc provider google token request failed"))
+
+		ctx, rec := genCallbackContext("state=some-state&code=some-code", "encrypted-state")
+
+		if assert.NoError(t, ah.SocialLoginCallback(ctx)) {
+			assert.Equal(t, http.StatusInternalServerError, rec.Code)
+		}
+	})
+}
@@ -0,0 +1,83 @@
+package handler
+
+import (
+	"github.com/labstack/echo/v4"
+	"net/http"
+	"sherman/src/app/utils/response"
+	"sherman/src/app/utils/terr"
+	"sherman/src/domain/auth"
+	"sherman/src/service/validator"
+)
+
+type (
+	// OAuthClientHandler handler for /admin/oauth-clients/[routes]
+	OAuthClientHandler interface {
+		CreateClient(ctx echo.Context) error
+		RotateClientSecret(ctx echo.Context) error
+	}
+
+	oauthClientHandler struct {
+		oauthUseCase auth.OAuthUseCase
+		validator    validator.Validator
+	}
+
+	// oauthClientParams params of a client registration, public clients get no secret
+	oauthClientParams struct {
+		auth.OAuthClient
+		Public bool `json:"public"`
+	}
+)
+
+// NewOAuthClientHandler constructor
+func NewOAuthClientHandler(ouc auth.OAuthUseCase, vs validator.Validator) OAuthClientHandler {
+	return &oauthClientHandler{
+		oauthUseCase: ouc,
+		validator:    vs,
+	}
+}
+
+// CreateClient registers an OAuth client, the secret of a confidential client is only shown in this response
+func (h *oauthClientHandler) CreateClient(ctx echo.Context) error {
+	var params oauthClientParams
+	res := response.NewResponse()
+
+	if err := ctx.Bind(&params); err != nil {
+		res.SetInternalServerError()
+		return ctx.JSON(res.GetStatus(), res.GetBody())
+	}
+
+	if errors := h.validator.ValidateOAuthClientParams(&params.OAuthClient, params.Public); len(errors) > 0 {
+		res.SetErrors(http.StatusUnprocessableEntity, errors)
+		return ctx.JSON(res.GetStatus(), res.GetBody())
+	}
+
+	client, err := h.oauthUseCase.CreateClient(ctx.Request().Context(), &params.OAuthClient, params.Public)
+	if err != nil {
+		res.SetInternalServerError()
+		return ctx.JSON(res.GetStatus(), res.GetBody())
+	}
+
+	res.SetData(http.StatusCreated, response.D{"client": client})
+	return ctx.JSON(res.GetStatus(), res.GetBody())
+}
+
+// RotateClientSecret replaces the secret of a confidential client, the new secret is only shown in this response
+func (h *oauthClientHandler) RotateClientSecret(ctx echo.Context) error {
+	res := response.NewResponse()
+
+	client, err := h.oauthUseCase.RotateClientSecret(ctx.Request().Context(), ctx.Param("id"))
+	if err != nil {
+		switch err.(type) {
+		case *terr.NotFoundError:
+			res.SetError(http.StatusNotFound, err.Error())
+		case *terr.OAuthError:
+			res.SetError(http.StatusUnprocessableEntity, err.Error())
+		default:
+			res.SetInternalServerError()
+		}
+		return ctx.JSON(res.GetStatus(), res.GetBody())
+	}
+
+	res.SetData(http.StatusOK, response.D{"client": client})
+	return ctx.JSON(res.GetStatus(), res.GetBody())
+}
@@ -0,0 +1,125 @@
+package handler
+
+import (
+	"errors"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"net/http"
+	"sherman/mocks"
+	_ "sherman/src/app/testing"
+	"sherman/src/app/utils/terr"
+	"sherman/src/domain/auth"
+	"testing"
+)
+
+type oauthClientHandlerMockDeps struct {
+	oauthUseCase     *mocks.OAuthUseCase
+	validatorService *mocks.Validator
+}
+
+func genMockOAuthClientHandler() (OAuthClientHandler, oauthClientHandlerMockDeps) {
+	ochDeps := oauthClientHandlerMockDeps{
+		oauthUseCase:     new(mocks.OAuthUseCase),
+		validatorService: new(mocks.Validator),
+	}
+
+	och := NewOAuthClientHandler(ochDeps.oauthUseCase, ochDeps.validatorService)
+
+	return och, ochDeps
+}
+
+func TestCreateClient(t *testing.T) {
+	mockBody := "{\"name\":\"some client\",\"redirect_uris\":[\"https://app.test/callback\"],\"grant_types\":[\"authorization_code\"],\"scopes\":[\"profile\"],\"public\":true}"
+
+	t.Run("it should succeed", func(t *testing.T) {
+		och, ochDeps := genMockOAuthClientHandler()
+		ochDeps.validatorService.
+			On("ValidateOAuthClientParams", mock.Anything, true).
+			Return(make(map[string]string))
+		ochDeps.oauthUseCase.
+			On("CreateClient", mock.Anything, mock.MatchedBy(func(client *auth.OAuthClient) bool {
+				return client.Name == "some client" && client.RedirectURIs[0] == "https://app.test/callback"
+			}), true).
+			Return(auth.NewOAuthClient{OAuthClient: auth.OAuthClient{ID: "some-client-id"}}, nil)
+
+		ctx, rec := genRoleRequestContext(echo.POST, mockBody, nil, nil)
+
+		if assert.NoError(t, och.CreateClient(ctx)) {
+			assert.Equal(t, http.StatusCreated, rec.Code)
+			assert.Contains(t, rec.Body.String(), "\"id\":\"some-client-id\"")
+		}
+	})
+
+	t.Run("it should return error", func(t *testing.T) {
+		och, ochDeps := genMockOAuthClientHandler()
+		ochDeps.validatorService.
+			On("ValidateOAuthClientParams", mock.Anything, false).
+			Return(map[string]string{"name_required": "name is required"})
+
+		ctx, rec := genRoleRequestContext(echo.POST, "{}", nil, nil)
+
+		if assert.NoError(t, och.CreateClient(ctx)) {
+			assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+			ochDeps.oauthUseCase.AssertNotCalled(t, "CreateClient", mock.Anything, mock.Anything, mock.Anything)
+		}
+	})
+
+	t.Run("it should return error", func(t *testing.T) {
+		och, ochDeps := genMockOAuthClientHandler()
+		ochDeps.validatorService.
+			On("ValidateOAuthClientParams", mock.Anything, true).
+			Return(make(map[string]string))
+		ochDeps.oauthUseCase.
+			On("CreateClient", mock.Anything, mock.Anything, true).
+			Return(auth.NewOAuthClient{}, errors.New("some error"))
+
+		ctx, rec := genRoleRequestContext(echo.POST, mockBody, nil, nil)
+
+		if assert.NoError(t, och.CreateClient(ctx)) {
+			assert.Equal(t, http.StatusInternalServerError, rec.Code)
+		}
+	})
+}
+
+func TestRotateClientSecret(t *testing.T) {
+	t.Run("it should succeed", func(t *testing.T) {
+		och, ochDeps := genMockOAuthClientHandler()
+		ochDeps.oauthUseCase.
+			On("RotateClientSecret", mock.Anything, "some-client-id").
+			Return(auth.NewOAuthClient{OAuthClient: auth.OAuthClient{ID: "some-client-id"}, Secret: "some-secret"}, nil)
+
+		ctx, rec := genRoleRequestContext(echo.POST, "", []string{"id"}, []string{"some-client-id"})
+
+		if assert.NoError(t, och.RotateClientSecret(ctx)) {
+			assert.Equal(t, http.StatusOK, rec.Code)
+			assert.Contains(t, rec.Body.String(), "\"secret\":\"some-secret\"")
+		}
+	})
+
+	t.Run("it should return error", func(t *testing.T) {
+		och, ochDeps := genMockOAuthClientHandler()
+		ochDeps.oauthUseCase.
+			On("RotateClientSecret", mock.Anything, "some-client-id").
+			Return(auth.NewOAuthClient{}, terr.NewNotFoundError("oauth client not found"))
+
+		ctx, rec := genRoleRequestContext(echo.POST, "", []string{"id"}, []string{"some-client-id"})
+
+		if assert.NoError(t, och.RotateClientSecret(ctx)) {
+			assert.Equal(t, http.StatusNotFound, rec.Code)
+		}
+	})
+
+	t.Run("it should return error", func(t *testing.T) {
+		och, ochDeps := genMockOAuthClientHandler()
+		ochDeps.oauthUseCase.
+			On("RotateClientSecret", mock.Anything, "some-client-id").
+			Return(auth.NewOAuthClient{}, terr.NewOAuthError("invalid_request", "public clients have no secret"))
+
+		ctx, rec := genRoleRequestContext(echo.POST, "", []string{"id"}, []string{"some-client-id"})
+
+		if assert.NoError(t, och.RotateClientSecret(ctx)) {
+			assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+		}
+	})
+}
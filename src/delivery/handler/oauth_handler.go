@@ -0,0 +1,182 @@
+package handler
+
+import (
+	"github.com/labstack/echo/v4"
+	"net/http"
+	"net/url"
+	"sherman/src/app/utils/terr"
+	"sherman/src/domain/auth"
+	"sherman/src/service/security"
+)
+
+type (
+	// OAuthHandler handler for /oauth/[routes], responses follow RFC 6749 and are not wrapped in a
+	// response.Response so any OAuth client library can consume them
+	OAuthHandler interface {
+		Authorize(ctx echo.Context) error
+		Token(ctx echo.Context) error
+		Revoke(ctx echo.Context) error
+		Introspect(ctx echo.Context) error
+	}
+
+	oauthHandler struct {
+		oauthUseCase         auth.OAuthUseCase
+		securityTokenUseCase auth.SecurityTokenUseCase
+		userUseCase          auth.UserUseCase
+		security             security.Security
+	}
+)
+
+// NewOAuthHandler constructor
+func NewOAuthHandler(
+	ouc auth.OAuthUseCase,
+	stuc auth.SecurityTokenUseCase,
+	uuc auth.UserUseCase,
+	ss security.Security,
+) OAuthHandler {
+	return &oauthHandler{
+		oauthUseCase:         ouc,
+		securityTokenUseCase: stuc,
+		userUseCase:          uuc,
+		security:             ss,
+	}
+}
+
+// Authorize grants an authorization code to a client for the user signed in with the REFRESH_TOKEN
+// cookie and redirects the user agent back to the client, requests without session are redirected
+// back with a login_required error
+func (h *oauthHandler) Authorize(ctx echo.Context) error {
+	redirectURL, err := h.oauthUseCase.Authorize(ctx.Request().Context(), h.getSessionUserID(ctx), &auth.AuthorizationRequest{
+		ResponseType:        ctx.QueryParam("response_type"),
+		ClientID:            ctx.QueryParam("client_id"),
+		RedirectURI:         ctx.QueryParam("redirect_uri"),
+		Scope:               ctx.QueryParam("scope"),
+		State:               ctx.QueryParam("state"),
+		CodeChallenge:       ctx.QueryParam("code_challenge"),
+		CodeChallengeMethod: ctx.QueryParam("code_challenge_method"),
+	})
+	if err != nil {
+		return oauthError(ctx, err)
+	}
+
+	return ctx.Redirect(http.StatusFound, redirectURL)
+}
+
+// Token exchanges an authorization grant for an access token
+func (h *oauthHandler) Token(ctx echo.Context) error {
+	clientID, clientSecret := getClientCredentials(ctx)
+
+	tokenResponse, err := h.oauthUseCase.Token(ctx.Request().Context(), &auth.OAuthTokenRequest{
+		GrantType:    ctx.FormValue("grant_type"),
+		ClientID:     clientID,
+		ClientSecret: clientSecret,
+		Code:         ctx.FormValue("code"),
+		RedirectURI:  ctx.FormValue("redirect_uri"),
+		CodeVerifier: ctx.FormValue("code_verifier"),
+		RefreshToken: ctx.FormValue("refresh_token"),
+		Scope:        ctx.FormValue("scope"),
+		UserAgent:    ctx.Request().UserAgent(),
+		IPAddress:    ctx.RealIP(),
+	})
+	if err != nil {
+		return oauthError(ctx, err)
+	}
+
+	setNoStoreHeaders(ctx)
+	return ctx.JSON(http.StatusOK, tokenResponse)
+}
+
+// Revoke revokes a token of the client (RFC 7009), unknown tokens are revoked too as far as the
+// client is concerned
+func (h *oauthHandler) Revoke(ctx echo.Context) error {
+	token := ctx.FormValue("token")
+	if token == "" {
+		return oauthError(ctx, terr.NewOAuthError("invalid_request", "token required"))
+	}
+
+	clientID, clientSecret := getClientCredentials(ctx)
+	if err := h.oauthUseCase.Revoke(ctx.Request().Context(), clientID, clientSecret, token); err != nil {
+		return oauthError(ctx, err)
+	}
+
+	return ctx.NoContent(http.StatusOK)
+}
+
+// Introspect describes a token to a confidential client (RFC 7662)
+func (h *oauthHandler) Introspect(ctx echo.Context) error {
+	token := ctx.FormValue("token")
+	if token == "" {
+		return oauthError(ctx, terr.NewOAuthError("invalid_request", "token required"))
+	}
+
+	clientID, clientSecret := getClientCredentials(ctx)
+	introspection, err := h.oauthUseCase.Introspect(ctx.Request().Context(), clientID, clientSecret, token)
+	if err != nil {
+		return oauthError(ctx, err)
+	}
+
+	setNoStoreHeaders(ctx)
+	return ctx.JSON(http.StatusOK, introspection)
+}
+
+// getSessionUserID gets the user of a first party session, an empty id means no signed in user
+func (h *oauthHandler) getSessionUserID(ctx echo.Context) string {
+	refreshTokenMetadata, err := h.security.GetAndValidateRefreshToken(ctx)
+	if err != nil || refreshTokenMetadata.ClientID != "" {
+		return ""
+	}
+
+	if !h.securityTokenUseCase.IsRefreshTokenStored(ctx.Request().Context(), &refreshTokenMetadata) ||
+		!h.userUseCase.IsUserActive(ctx.Request().Context(), refreshTokenMetadata.UserID) {
+		return ""
+	}
+	return refreshTokenMetadata.UserID
+}
+
+// getClientCredentials gets the client credentials of the basic authentication header or else of the
+// request body, basic credentials are form url encoded (RFC 6749 section 2.3.1)
+func getClientCredentials(ctx echo.Context) (string, string) {
+	clientID, clientSecret, ok := ctx.Request().BasicAuth()
+	if !ok {
+		return ctx.FormValue("client_id"), ctx.FormValue("client_secret")
+	}
+
+	if id, err := url.QueryUnescape(clientID); err == nil {
+		clientID = id
+	}
+	if secret, err := url.QueryUnescape(clientSecret); err == nil {
+		clientSecret = secret
+	}
+	return clientID, clientSecret
+}
+
+// oauthError responds with a RFC 6749 error, client authentication failures are 401
+func oauthError(ctx echo.Context, err error) error {
+	setNoStoreHeaders(ctx)
+
+	oauthErr, ok := err.(*terr.OAuthError)
+	if !ok {
+		return ctx.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "server_error",
+		})
+	}
+
+	status := http.StatusBadRequest
+	if oauthErr.Code() == "invalid_client" {
+		status = http.StatusUnauthorized
+		if _, _, ok := ctx.Request().BasicAuth(); ok {
+			ctx.Response().Header().Set(echo.HeaderWWWAuthenticate, `Basic realm="oauth"`)
+		}
+	}
+
+	return ctx.JSON(status, map[string]string{
+		"error":             oauthErr.Code(),
+		"error_description": oauthErr.Error(),
+	})
+}
+
+// setNoStoreHeaders forbids caching of responses carrying tokens
+func setNoStoreHeaders(ctx echo.Context) {
+	ctx.Response().Header().Set("Cache-Control", "no-store")
+	ctx.Response().Header().Set("Pragma", "no-cache")
+}
@@ -0,0 +1,278 @@
+package handler
+
+import (
+	"errors"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sherman/mocks"
+	_ "sherman/src/app/testing"
+	"sherman/src/app/utils/terr"
+	"sherman/src/domain/auth"
+	"strings"
+	"testing"
+)
+
+type oauthHandlerMockDeps struct {
+	oauthUseCase         *mocks.OAuthUseCase
+	securityTokenUseCase *mocks.SecurityTokenUseCase
+	userUseCase          *mocks.UserUseCase
+	securityService      *mocks.Security
+}
+
+func genMockOAuthHandler() (OAuthHandler, oauthHandlerMockDeps) {
+	ohDeps := oauthHandlerMockDeps{
+		oauthUseCase:         new(mocks.OAuthUseCase),
+		securityTokenUseCase: new(mocks.SecurityTokenUseCase),
+		userUseCase:          new(mocks.UserUseCase),
+		securityService:      new(mocks.Security),
+	}
+
+	oh := NewOAuthHandler(
+		ohDeps.oauthUseCase,
+		ohDeps.securityTokenUseCase,
+		ohDeps.userUseCase,
+		ohDeps.securityService,
+	)
+
+	return oh, ohDeps
+}
+
+func genFormContext(form url.Values) (echo.Context, *httptest.ResponseRecorder) {
+	e := echo.New()
+	req := httptest.NewRequest(echo.POST, "/some-url", strings.NewReader(form.Encode()))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationForm)
+	rec := httptest.NewRecorder()
+	return e.NewContext(req, rec), rec
+}
+
+func TestAuthorize(t *testing.T) {
+	isRequest := mock.MatchedBy(func(request *auth.AuthorizationRequest) bool {
+		return request.ClientID == "some-app" && request.CodeChallenge == "some-challenge" && request.State == "some-state"
+	})
+	query := "response_type=code&client_id=some-app&state=some-state&code_challenge=some-challenge&code_challenge_method=S256"
+
+	t.Run("it should succeed", func(t *testing.T) {
+		sessionMeta := auth.TokenMetadata{UserID: "some-user-id", Type: auth.RefreshTokenType}
+		oh, ohDeps := genMockOAuthHandler()
+		ohDeps.securityService.On("GetAndValidateRefreshToken", mock.Anything).Return(sessionMeta, nil)
+		ohDeps.securityTokenUseCase.On("IsRefreshTokenStored", mock.Anything, &sessionMeta).Return(true)
+		ohDeps.userUseCase.On("IsUserActive", mock.Anything, "some-user-id").Return(true)
+		ohDeps.oauthUseCase.
+			On("Authorize", mock.Anything, "some-user-id", isRequest).
+			Return("https://app.test/callback?code=some-code&state=some-state", nil)
+
+		e := echo.New()
+		rec := httptest.NewRecorder()
+		ctx := e.NewContext(httptest.NewRequest(echo.GET, "/some-url?"+query, nil), rec)
+
+		if assert.NoError(t, oh.Authorize(ctx)) {
+			assert.Equal(t, http.StatusFound, rec.Code)
+			assert.Equal(t, "https://app.test/callback?code=some-code&state=some-state", rec.Header().Get(echo.HeaderLocation))
+		}
+	})
+
+	t.Run("it should authorize without user on a client session", func(t *testing.T) {
+		oh, ohDeps := genMockOAuthHandler()
+		ohDeps.securityService.
+			On("GetAndValidateRefreshToken", mock.Anything).
+			Return(auth.TokenMetadata{UserID: "some-user-id", ClientID: "some-app"}, nil)
+		ohDeps.oauthUseCase.
+			On("Authorize", mock.Anything, "", isRequest).
+			Return("https://app.test/callback?error=login_required&state=some-state", nil)
+
+		e := echo.New()
+		rec := httptest.NewRecorder()
+		ctx := e.NewContext(httptest.NewRequest(echo.GET, "/some-url?"+query, nil), rec)
+
+		if assert.NoError(t, oh.Authorize(ctx)) {
+			assert.Equal(t, http.StatusFound, rec.Code)
+			ohDeps.securityTokenUseCase.AssertNotCalled(t, "IsRefreshTokenStored", mock.Anything, mock.Anything)
+		}
+	})
+
+	t.Run("it should return error", func(t *testing.T) {
+		oh, ohDeps := genMockOAuthHandler()
+		ohDeps.securityService.
+			On("GetAndValidateRefreshToken", mock.Anything).
+			Return(auth.TokenMetadata{}, terr.NewUnAuthorizedError("refresh token not found"))
+		ohDeps.oauthUseCase.
+			On("Authorize", mock.Anything, "", isRequest).
+			Return("", terr.NewOAuthError("invalid_request", "invalid redirect uri"))
+
+		e := echo.New()
+		rec := httptest.NewRecorder()
+		ctx := e.NewContext(httptest.NewRequest(echo.GET, "/some-url?"+query, nil), rec)
+
+		if assert.NoError(t, oh.Authorize(ctx)) {
+			assert.Equal(t, http.StatusBadRequest, rec.Code)
+			assert.Equal(t, "{\"error\":\"invalid_request\",\"error_description\":\"invalid redirect uri\"}\n", rec.Body.String())
+		}
+	})
+}
+
+func TestToken(t *testing.T) {
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {"some-code"},
+		"redirect_uri":  {"https://app.test/callback"},
+		"code_verifier": {"some-verifier"},
+	}
+
+	t.Run("it should succeed", func(t *testing.T) {
+		oh, ohDeps := genMockOAuthHandler()
+		ohDeps.oauthUseCase.
+			On("Token", mock.Anything, mock.MatchedBy(func(request *auth.OAuthTokenRequest) bool {
+				return request.GrantType == auth.AuthorizationCodeGrant &&
+					request.ClientID == "some-service" &&
+					request.ClientSecret == "some secret" &&
+					request.Code == "some-code" &&
+					request.CodeVerifier == "some-verifier"
+			})).
+			Return(auth.OAuthTokenResponse{AccessToken: "some-token", TokenType: "Bearer", ExpiresIn: 900}, nil)
+
+		ctx, rec := genFormContext(form)
+		ctx.Request().SetBasicAuth("some-service", "some+secret")
+
+		if assert.NoError(t, oh.Token(ctx)) {
+			assert.Equal(t, http.StatusOK, rec.Code)
+			assert.Equal(t, "no-store", rec.Header().Get("Cache-Control"))
+			assert.Equal(t, "{\"access_token\":\"some-token\",\"token_type\":\"Bearer\",\"expires_in\":900}\n", rec.Body.String())
+		}
+	})
+
+	t.Run("it should read client credentials of the body", func(t *testing.T) {
+		oh, ohDeps := genMockOAuthHandler()
+		ohDeps.oauthUseCase.
+			On("Token", mock.Anything, mock.MatchedBy(func(request *auth.OAuthTokenRequest) bool {
+				return request.ClientID == "some-app" && request.ClientSecret == ""
+			})).
+			Return(auth.OAuthTokenResponse{AccessToken: "some-token"}, nil)
+
+		bodyForm := url.Values{"client_id": {"some-app"}}
+		for name, values := range form {
+			bodyForm[name] = values
+		}
+		ctx, rec := genFormContext(bodyForm)
+
+		if assert.NoError(t, oh.Token(ctx)) {
+			assert.Equal(t, http.StatusOK, rec.Code)
+		}
+	})
+
+	t.Run("it should return error on client authentication failure", func(t *testing.T) {
+		oh, ohDeps := genMockOAuthHandler()
+		ohDeps.oauthUseCase.
+			On("Token", mock.Anything, mock.Anything).
+			Return(auth.OAuthTokenResponse{}, terr.NewOAuthError("invalid_client", "client authentication failed"))
+
+		ctx, rec := genFormContext(form)
+		ctx.Request().SetBasicAuth("some-service", "other-secret")
+
+		if assert.NoError(t, oh.Token(ctx)) {
+			assert.Equal(t, http.StatusUnauthorized, rec.Code)
+			assert.Equal(t, `Basic realm="oauth"`, rec.Header().Get(echo.HeaderWWWAuthenticate))
+			assert.Equal(t, "{\"error\":\"invalid_client\",\"error_description\":\"client authentication failed\"}\n", rec.Body.String())
+		}
+	})
+
+	t.Run("it should return error on invalid grant", func(t *testing.T) {
+		oh, ohDeps := genMockOAuthHandler()
+		ohDeps.oauthUseCase.
+			On("Token", mock.Anything, mock.Anything).
+			Return(auth.OAuthTokenResponse{}, terr.NewOAuthError("invalid_grant", "invalid authorization code"))
+
+		ctx, rec := genFormContext(form)
+
+		if assert.NoError(t, oh.Token(ctx)) {
+			assert.Equal(t, http.StatusBadRequest, rec.Code)
+			assert.Equal(t, "{\"error\":\"invalid_grant\",\"error_description\":\"invalid authorization code\"}\n", rec.Body.String())
+		}
+	})
+
+	t.Run("it should return server error", func(t *testing.T) {
+		oh, ohDeps := genMockOAuthHandler()
+		ohDeps.oauthUseCase.On("Token", mock.Anything, mock.Anything).Return(auth.OAuthTokenResponse{}, errors.New("some error"))
+
+		ctx, rec := genFormContext(form)
+
+		if assert.NoError(t, oh.Token(ctx)) {
+			assert.Equal(t, http.StatusInternalServerError, rec.Code)
+			assert.Equal(t, "{\"error\":\"server_error\"}\n", rec.Body.String())
+		}
+	})
+}
+
+func TestRevoke(t *testing.T) {
+	t.Run("it should succeed", func(t *testing.T) {
+		oh, ohDeps := genMockOAuthHandler()
+		ohDeps.oauthUseCase.On("Revoke", mock.Anything, "some-app", "", "some-token").Return(nil)
+
+		ctx, rec := genFormContext(url.Values{"client_id": {"some-app"}, "token": {"some-token"}})
+
+		if assert.NoError(t, oh.Revoke(ctx)) {
+			assert.Equal(t, http.StatusOK, rec.Code)
+			assert.Empty(t, rec.Body.String())
+		}
+	})
+
+	t.Run("it should return error on missing token", func(t *testing.T) {
+		oh, ohDeps := genMockOAuthHandler()
+
+		ctx, rec := genFormContext(url.Values{"client_id": {"some-app"}})
+
+		if assert.NoError(t, oh.Revoke(ctx)) {
+			assert.Equal(t, http.StatusBadRequest, rec.Code)
+			assert.Equal(t, "{\"error\":\"invalid_request\",\"error_description\":\"token required\"}\n", rec.Body.String())
+			ohDeps.oauthUseCase.AssertNotCalled(t, "Revoke", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+		}
+	})
+}
+
+func TestIntrospect(t *testing.T) {
+	t.Run("it should succeed", func(t *testing.T) {
+		oh, ohDeps := genMockOAuthHandler()
+		ohDeps.oauthUseCase.
+			On("Introspect", mock.Anything, "some-service", "some-secret", "some-token").
+			Return(auth.TokenIntrospection{Active: true, Subject: "some-user-id", Scope: "profile"}, nil)
+
+		ctx, rec := genFormContext(url.Values{"token": {"some-token"}})
+		ctx.Request().SetBasicAuth("some-service", "some-secret")
+
+		if assert.NoError(t, oh.Introspect(ctx)) {
+			assert.Equal(t, http.StatusOK, rec.Code)
+			assert.Equal(t, "{\"active\":true,\"scope\":\"profile\",\"sub\":\"some-user-id\"}\n", rec.Body.String())
+		}
+	})
+
+	t.Run("it should describe an inactive token", func(t *testing.T) {
+		oh, ohDeps := genMockOAuthHandler()
+		ohDeps.oauthUseCase.
+			On("Introspect", mock.Anything, "some-service", "some-secret", "some-token").
+			Return(auth.TokenIntrospection{Active: false}, nil)
+
+		ctx, rec := genFormContext(url.Values{"token": {"some-token"}})
+		ctx.Request().SetBasicAuth("some-service", "some-secret")
+
+		if assert.NoError(t, oh.Introspect(ctx)) {
+			assert.Equal(t, http.StatusOK, rec.Code)
+			assert.Equal(t, "{\"active\":false}\n", rec.Body.String())
+		}
+	})
+
+	t.Run("it should return error", func(t *testing.T) {
+		oh, ohDeps := genMockOAuthHandler()
+		ohDeps.oauthUseCase.
+			On("Introspect", mock.Anything, "some-app", "", "some-token").
+			Return(auth.TokenIntrospection{}, terr.NewOAuthError("unauthorized_client", "public clients can't introspect tokens"))
+
+		ctx, rec := genFormContext(url.Values{"client_id": {"some-app"}, "token": {"some-token"}})
+
+		if assert.NoError(t, oh.Introspect(ctx)) {
+			assert.Equal(t, http.StatusBadRequest, rec.Code)
+		}
+	})
+}
@@ -0,0 +1,80 @@
+package handler
+
+import (
+	"github.com/labstack/echo/v4"
+	"net/http"
+	"sherman/src/app/utils/response"
+	"sherman/src/app/utils/terr"
+	"sherman/src/domain/auth"
+	"sherman/src/service/validator"
+)
+
+type (
+	// RoleHandler handler for /users/:id/roles/[routes]
+	RoleHandler interface {
+		AssignRole(ctx echo.Context) error
+		RevokeRole(ctx echo.Context) error
+	}
+
+	roleHandler struct {
+		roleUseCase auth.RoleUseCase
+		validator   validator.Validator
+	}
+)
+
+// NewRoleHandler constructor
+func NewRoleHandler(ruc auth.RoleUseCase, vs validator.Validator) RoleHandler {
+	return &roleHandler{
+		roleUseCase: ruc,
+		validator:   vs,
+	}
+}
+
+// AssignRole assigns a role to the user, it is carried by the access tokens issued from then on
+func (h *roleHandler) AssignRole(ctx echo.Context) error {
+	var role auth.Role
+	res := response.NewResponse()
+
+	if err := ctx.Bind(&role); err != nil {
+		res.SetInternalServerError()
+		return ctx.JSON(res.GetStatus(), res.GetBody())
+	}
+
+	if errors := h.validator.ValidateRoleParams(&role); len(errors) > 0 {
+		res.SetErrors(http.StatusUnprocessableEntity, errors)
+		return ctx.JSON(res.GetStatus(), res.GetBody())
+	}
+
+	if err := h.roleUseCase.AssignRole(ctx.Request().Context(), ctx.Param("id"), role.Name); err != nil {
+		switch err.(type) {
+		case *terr.NotFoundError:
+			res.SetError(http.StatusNotFound, err.Error())
+		case *terr.DuplicateEntryError:
+			res.SetError(http.StatusForbidden, err.Error())
+		default:
+			res.SetInternalServerError()
+		}
+		return ctx.JSON(res.GetStatus(), res.GetBody())
+	}
+
+	res.SetData(http.StatusCreated, nil)
+	return ctx.JSON(res.GetStatus(), res.GetBody())
+}
+
+// RevokeRole revokes a role from the user, access tokens already issued keep it until they expire
+func (h *roleHandler) RevokeRole(ctx echo.Context) error {
+	res := response.NewResponse()
+
+	if err := h.roleUseCase.RevokeRole(ctx.Request().Context(), ctx.Param("id"), ctx.Param("role")); err != nil {
+		switch err.(type) {
+		case *terr.NotFoundError:
+			res.SetError(http.StatusNotFound, err.Error())
+		default:
+			res.SetInternalServerError()
+		}
+		return ctx.JSON(res.GetStatus(), res.GetBody())
+	}
+
+	res.SetData(http.StatusOK, nil)
+	return ctx.JSON(res.GetStatus(), res.GetBody())
+}
@@ -0,0 +1,184 @@
+package handler
+
+import (
+	"errors"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"net/http"
+	"net/http/httptest"
+	"sherman/mocks"
+	_ "sherman/src/app/testing"
+	"sherman/src/app/utils/terr"
+	"sherman/src/domain/auth"
+	"strings"
+	"testing"
+)
+
+type roleHandlerMockDeps struct {
+	roleUseCase      *mocks.RoleUseCase
+	validatorService *mocks.Validator
+}
+
+func genMockRoleHandler() (RoleHandler, roleHandlerMockDeps) {
+	rhDeps := roleHandlerMockDeps{
+		roleUseCase:      new(mocks.RoleUseCase),
+		validatorService: new(mocks.Validator),
+	}
+
+	rh := NewRoleHandler(rhDeps.roleUseCase, rhDeps.validatorService)
+
+	return rh, rhDeps
+}
+
+func genRoleRequestContext(method, body string, paramNames, paramValues []string) (echo.Context, *httptest.ResponseRecorder) {
+	e := echo.New()
+	req := httptest.NewRequest(method, "/some-url", strings.NewReader(body))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	rec := httptest.NewRecorder()
+	ctx := e.NewContext(req, rec)
+	ctx.SetParamNames(paramNames...)
+	ctx.SetParamValues(paramValues...)
+	return ctx, rec
+}
+
+func TestAssignRole(t *testing.T) {
+	mockBody := "{\"name\":\"admin\"}"
+
+	t.Run("it should succeed", func(t *testing.T) {
+		rh, rhDeps := genMockRoleHandler()
+		rhDeps.validatorService.
+			On("ValidateRoleParams", mock.Anything).
+			Return(make(map[string]string))
+		rhDeps.roleUseCase.On("AssignRole", mock.Anything, "some-user-id", auth.AdminRole).Return(nil)
+
+		ctx, rec := genRoleRequestContext(echo.POST, mockBody, []string{"id"}, []string{"some-user-id"})
+
+		if assert.NoError(t, rh.AssignRole(ctx)) {
+			assert.Equal(t, http.StatusCreated, rec.Code)
+			assert.Equal(t, "{\"data\":null}\n", rec.Body.String())
+		}
+	})
+
+	t.Run("it should return error", func(t *testing.T) {
+		rh, rhDeps := genMockRoleHandler()
+		rhDeps.validatorService.
+			On("ValidateRoleParams", mock.Anything).
+			Return(map[string]string{"name_required": "name is required"})
+
+		ctx, rec := genRoleRequestContext(echo.POST, "{}", []string{"id"}, []string{"some-user-id"})
+
+		if assert.NoError(t, rh.AssignRole(ctx)) {
+			assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+			assert.Equal(t, "{\"data\":null,\"errors\":{\"name_required\":\"name is required\"}}\n", rec.Body.String())
+			rhDeps.roleUseCase.AssertNotCalled(t, "AssignRole", mock.Anything, mock.Anything, mock.Anything)
+		}
+	})
+
+	t.Run("it should return error", func(t *testing.T) {
+		rh, _ := genMockRoleHandler()
+
+		ctx, rec := genRoleRequestContext(echo.POST, "\"wrong-params\"", []string{"id"}, []string{"some-user-id"})
+
+		if assert.NoError(t, rh.AssignRole(ctx)) {
+			assert.Equal(t, http.StatusInternalServerError, rec.Code)
+		}
+	})
+
+	t.Run("it should return error", func(t *testing.T) {
+		rh, rhDeps := genMockRoleHandler()
+		rhDeps.validatorService.
+			On("ValidateRoleParams", mock.Anything).
+			Return(make(map[string]string))
+		rhDeps.roleUseCase.
+			On("AssignRole", mock.Anything, "some-user-id", auth.AdminRole).
+			Return(terr.NewNotFoundError("user not found"))
+
+		ctx, rec := genRoleRequestContext(echo.POST, mockBody, []string{"id"}, []string{"some-user-id"})
+
+		if assert.NoError(t, rh.AssignRole(ctx)) {
+			assert.Equal(t, http.StatusNotFound, rec.Code)
+			assert.Equal(t, "{\"data\":null,\"error\":\"user not found\"}\n", rec.Body.String())
+		}
+	})
+
+	t.Run("it should return error", func(t *testing.T) {
+		rh, rhDeps := genMockRoleHandler()
+		rhDeps.validatorService.
+			On("ValidateRoleParams", mock.Anything).
+			Return(make(map[string]string))
+		rhDeps.roleUseCase.
+			On("AssignRole", mock.Anything, "some-user-id", auth.AdminRole).
+			Return(terr.NewDuplicateEntryError("role already assigned"))
+
+		ctx, rec := genRoleRequestContext(echo.POST, mockBody, []string{"id"}, []string{"some-user-id"})
+
+		if assert.NoError(t, rh.AssignRole(ctx)) {
+			assert.Equal(t, http.StatusForbidden, rec.Code)
+			assert.Equal(t, "{\"data\":null,\"error\":\"role already assigned\"}\n", rec.Body.String())
+		}
+	})
+
+	t.Run("it should return error", func(t *testing.T) {
+		rh, rhDeps := genMockRoleHandler()
+		rhDeps.validatorService.
+			On("ValidateRoleParams", mock.Anything).
+			Return(make(map[string]string))
+		rhDeps.roleUseCase.
+			On("AssignRole", mock.Anything, "some-user-id", auth.AdminRole).
+			Return(errors.New("some error"))
+
+		ctx, rec := genRoleRequestContext(echo.POST, mockBody, []string{"id"}, []string{"some-user-id"})
+
+		if assert.NoError(t, rh.AssignRole(ctx)) {
+			assert.Equal(t, http.StatusInternalServerError, rec.Code)
+		}
+	})
+}
+
+func TestRevokeRole(t *testing.T) {
+	t.Run("it should succeed", func(t *testing.T) {
+		rh, rhDeps := genMockRoleHandler()
+		rhDeps.roleUseCase.On("RevokeRole", mock.Anything, "some-user-id", auth.AdminRole).Return(nil)
+
+		ctx, rec := genRoleRequestContext(
+			echo.DELETE, "", []string{"id", "role"}, []string{"some-user-id", auth.AdminRole},
+		)
+
+		if assert.NoError(t, rh.RevokeRole(ctx)) {
+			assert.Equal(t, http.StatusOK, rec.Code)
+			assert.Equal(t, "{\"data\":null}\n", rec.Body.String())
+		}
+	})
+
+	t.Run("it should return error", func(t *testing.T) {
+		rh, rhDeps := genMockRoleHandler()
+		rhDeps.roleUseCase.
+			On("RevokeRole", mock.Anything, "some-user-id", auth.AdminRole).
+			Return(terr.NewNotFoundError("role not assigned"))
+
+		ctx, rec := genRoleRequestContext(
+			echo.DELETE, "", []string{"id", "role"}, []string{"some-user-id", auth.AdminRole},
+		)
+
+		if assert.NoError(t, rh.RevokeRole(ctx)) {
+			assert.Equal(t, http.StatusNotFound, rec.Code)
+			assert.Equal(t, "{\"data\":null,\"error\":\"role not assigned\"}\n", rec.Body.String())
+		}
+	})
+
+	t.Run("it should return error", func(t *testing.T) {
+		rh, rhDeps := genMockRoleHandler()
+		rhDeps.roleUseCase.
+			On("RevokeRole", mock.Anything, "some-user-id", auth.AdminRole).
+			Return(errors.New("some error"))
+
+		ctx, rec := genRoleRequestContext(
+			echo.DELETE, "", []string{"id", "role"}, []string{"some-user-id", auth.AdminRole},
+		)
+
+		if assert.NoError(t, rh.RevokeRole(ctx)) {
+			assert.Equal(t, http.StatusInternalServerError, rec.Code)
+		}
+	})
+}
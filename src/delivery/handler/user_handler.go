@@ -1,14 +1,20 @@
 package handler
 
 import (
+	"context"
 	"github.com/labstack/echo/v4"
+	"github.com/rs/zerolog/log"
+	"math"
 	"net/http"
 	"sherman/src/app/utils/response"
 	"sherman/src/app/utils/terr"
 	"sherman/src/domain/auth"
+	cmw "sherman/src/service/middleware"
 	"sherman/src/service/presenter"
 	"sherman/src/service/security"
 	"sherman/src/service/validator"
+	"strconv"
+	"time"
 )
 
 type (
@@ -18,12 +24,43 @@ type (
 		Login(ctx echo.Context) error
 		RefreshAccessToken(ctx echo.Context) error
 		GetUser(ctx echo.Context) error
+		GetMe(ctx echo.Context) error
+		UpdateMe(ctx echo.Context) error
+		ChangePassword(ctx echo.Context) error
+		DeactivateMe(ctx echo.Context) error
+		DeleteMe(ctx echo.Context) error
+		EraseMe(ctx echo.Context) error
 		Logout(ctx echo.Context) error
+		GetSessions(ctx echo.Context) error
+		RemoveSession(ctx echo.Context) error
+		RemoveSessions(ctx echo.Context) error
+		VerifyEmail(ctx echo.Context) error
+		ResendVerificationEmail(ctx echo.Context) error
+		ForgotPassword(ctx echo.Context) error
+		ResetPassword(ctx echo.Context) error
+		SetupTwoFactor(ctx echo.Context) error
+		ConfirmTwoFactor(ctx echo.Context) error
+		DisableTwoFactor(ctx echo.Context) error
+		LoginTwoFactor(ctx echo.Context) error
+	}
+
+	// tokenParams body of the routes consuming a token received by email
+	tokenParams struct {
+		Token    string `json:"token"`
+		Password string `json:"password"`
+	}
+
+	// twoFactorParams body of the two factor authentication routes
+	twoFactorParams struct {
+		MFAToken string `json:"mfa_token"`
+		Code     string `json:"code"`
 	}
 
 	userHandler struct {
 		userUseCase          auth.UserUseCase
 		securityTokenUseCase auth.SecurityTokenUseCase
+		loginAttemptUseCase  auth.LoginAttemptUseCase
+		twoFactorUseCase     auth.TwoFactorUseCase
 		validator            validator.Validator
 		security             security.Security
 		presenter            presenter.Presenter
@@ -34,6 +71,8 @@ type (
 func NewUserHandler(
 	uuc auth.UserUseCase,
 	stuc auth.SecurityTokenUseCase,
+	lauc auth.LoginAttemptUseCase,
+	tfuc auth.TwoFactorUseCase,
 	vs validator.Validator,
 	ss security.Security,
 	ps presenter.Presenter,
@@ -41,6 +80,8 @@ func NewUserHandler(
 	return &userHandler{
 		userUseCase:          uuc,
 		securityTokenUseCase: stuc,
+		loginAttemptUseCase:  lauc,
+		twoFactorUseCase:     tfuc,
 		validator:            vs,
 		security:             ss,
 		presenter:            ps,
@@ -63,7 +104,7 @@ func (h *userHandler) Register(ctx echo.Context) error {
 		return ctx.JSON(res.GetStatus(), res.GetBody())
 	}
 
-	if err := h.userUseCase.Register(&user); err != nil {
+	if err := h.userUseCase.Register(ctx.Request().Context(), &user); err != nil {
 		switch err.(type) {
 		case *terr.DuplicateEntryError:
 			res.SetError(http.StatusForbidden, err.Error())
@@ -92,13 +133,71 @@ func (h *userHandler) Login(ctx echo.Context) error {
 		return ctx.JSON(res.GetStatus(), res.GetBody())
 	}
 
-	verifiedUser, err := h.userUseCase.VerifyCredentials(&user)
+	if err := h.loginAttemptUseCase.CheckLogin(ctx.Request().Context(), user.EmailAddress, ctx.RealIP()); err != nil {
+		switch err := err.(type) {
+		case *terr.AccountLockedError:
+			setRetryAfterHeader(ctx, err.RetryAfter())
+			res.SetError(http.StatusLocked, err.Error())
+		case *terr.TooManyAttemptsError:
+			setRetryAfterHeader(ctx, err.RetryAfter())
+			res.SetError(http.StatusTooManyRequests, err.Error())
+		default:
+			res.SetInternalServerError()
+		}
+		return ctx.JSON(res.GetStatus(), res.GetBody())
+	}
+
+	verifiedUser, err := h.userUseCase.VerifyCredentials(ctx.Request().Context(), &user)
 
 	if err != nil {
 		switch err.(type) {
 		case *terr.NotFoundError:
+			h.recordFailedLogin(ctx, user.EmailAddress)
 			res.SetError(http.StatusNotFound, err.Error())
 		case *terr.UnAuthorizedError:
+			h.recordFailedLogin(ctx, user.EmailAddress)
+			res.SetError(http.StatusUnauthorized, err.Error())
+		case *terr.UnverifiedEmailError, *terr.InactiveUserError:
+			res.SetError(http.StatusForbidden, err.Error())
+		default:
+			res.SetInternalServerError()
+		}
+		return ctx.JSON(res.GetStatus(), res.GetBody())
+	}
+
+	if err := h.loginAttemptUseCase.RecordSuccessfulLogin(ctx.Request().Context(), user.EmailAddress); err != nil {
+		log.Error().Msg(err.Error())
+	}
+
+	return completeLogin(ctx, h.securityTokenUseCase, h.twoFactorUseCase, verifiedUser.ID)
+}
+
+// LoginTwoFactor exchanges the pending token of a login and a TOTP or recovery code for the user tokens
+func (h *userHandler) LoginTwoFactor(ctx echo.Context) error {
+	var params twoFactorParams
+	res := response.NewResponse()
+
+	if err := ctx.Bind(&params); err != nil {
+		res.SetInternalServerError()
+		return ctx.JSON(res.GetStatus(), res.GetBody())
+	}
+
+	if errors := h.validator.ValidateTwoFactorLoginParams(params.MFAToken, params.Code); len(errors) > 0 {
+		res.SetErrors(http.StatusUnprocessableEntity, errors)
+		return ctx.JSON(res.GetStatus(), res.GetBody())
+	}
+
+	userID, err := h.twoFactorUseCase.VerifyLogin(ctx.Request().Context(), params.MFAToken, params.Code)
+	if err != nil {
+		switch err := err.(type) {
+		case *terr.TooManyAttemptsError:
+			setRetryAfterHeader(ctx, err.RetryAfter())
+			res.SetError(http.StatusTooManyRequests, err.Error())
+		case *terr.UnAuthorizedError,
+			*terr.ExpiredTokenError,
+			*terr.MalformedTokenError,
+			*terr.TokenTypeError,
+			*terr.TokenSignatureError:
 			res.SetError(http.StatusUnauthorized, err.Error())
 		default:
 			res.SetInternalServerError()
@@ -106,63 +205,311 @@ func (h *userHandler) Login(ctx echo.Context) error {
 		return ctx.JSON(res.GetStatus(), res.GetBody())
 	}
 
-	accessToken, err := h.securityTokenUseCase.GenAccessToken(verifiedUser.ID)
+	return startSession(ctx, h.securityTokenUseCase, userID)
+}
+
+// completeLogin completes the login of an authenticated user, users with two factor authentication
+// get a pending token to exchange with a code at /users/login/2fa instead of a session
+func completeLogin(ctx echo.Context, stuc auth.SecurityTokenUseCase, tfuc auth.TwoFactorUseCase, userID string) error {
+	res := response.NewResponse()
+
+	twoFactorEnabled, err := tfuc.IsEnabled(ctx.Request().Context(), userID)
 	if err != nil {
 		res.SetInternalServerError()
 		return ctx.JSON(res.GetStatus(), res.GetBody())
 	}
+	if twoFactorEnabled {
+		pendingToken, err := tfuc.GenPendingToken(ctx.Request().Context(), userID)
+		if err != nil {
+			res.SetInternalServerError()
+			return ctx.JSON(res.GetStatus(), res.GetBody())
+		}
 
-	refreshToken, err := h.securityTokenUseCase.GenRefreshToken(verifiedUser.ID)
+		res.SetData(http.StatusOK, response.D{"mfa_required": true, "mfa_token": pendingToken.Token})
+		return ctx.JSON(res.GetStatus(), res.GetBody())
+	}
+
+	return startSession(ctx, stuc, userID)
+}
+
+// startSession responds the access token of a new session and sets its refresh token cookie
+func startSession(ctx echo.Context, stuc auth.SecurityTokenUseCase, userID string) error {
+	res := response.NewResponse()
+
+	accessToken, err := stuc.GenAccessToken(ctx.Request().Context(), userID)
+	if err != nil {
+		res.SetInternalServerError()
+		return ctx.JSON(res.GetStatus(), res.GetBody())
+	}
+
+	refreshToken, err := stuc.GenRefreshToken(
+		ctx.Request().Context(),
+		userID,
+		ctx.Request().UserAgent(),
+		ctx.RealIP(),
+	)
 	if err != nil {
 		res.SetInternalServerError()
 		return ctx.JSON(res.GetStatus(), res.GetBody())
 	}
 
 	res.SetData(http.StatusOK, response.D{"access_token": accessToken.Token})
-	// TODO: add secure to cookie when tls is ready
-	ctx.SetCookie(&http.Cookie{
-		Name:     "REFRESH_TOKEN",
-		Value:    refreshToken.Token,
-		MaxAge:   3600,
-		Path:     "/",
-		Domain:   ctx.Request().Host,
-		Secure:   false,
-		HttpOnly: true,
-	})
+	setRefreshTokenCookie(ctx, refreshToken.Token, 3600)
 	return ctx.JSON(res.GetStatus(), res.GetBody())
 }
 
-// RefreshAccessToken refreshes user access token
+// recordFailedLogin records a failed login, a tracking failure is logged but must not change the response
+func (h *userHandler) recordFailedLogin(ctx echo.Context, email string) {
+	if err := h.loginAttemptUseCase.RecordFailedLogin(ctx.Request().Context(), email, ctx.RealIP()); err != nil {
+		log.Error().Msg(err.Error())
+	}
+}
+
+// RefreshAccessToken rotates the user refresh token and issues a new access token
 func (h *userHandler) RefreshAccessToken(ctx echo.Context) error {
 	res := response.NewResponse()
 
 	refreshTokenMetadata, err := h.security.GetAndValidateRefreshToken(ctx)
 	if err != nil {
-		res.SetError(http.StatusUnauthorized, "invalid refresh token")
+		switch err.(type) {
+		case *terr.ExpiredTokenError, *terr.MalformedTokenError, *terr.TokenTypeError, *terr.TokenSignatureError:
+			res.SetError(http.StatusUnauthorized, "invalid refresh token: "+err.Error())
+		default:
+			res.SetError(http.StatusUnauthorized, "invalid refresh token")
+		}
 		return ctx.JSON(res.GetStatus(), res.GetBody())
 	}
 
-	if !h.securityTokenUseCase.IsRefreshTokenStored(&refreshTokenMetadata) {
+	// refresh tokens of OAuth clients are exchanged at the token endpoint
+	if refreshTokenMetadata.ClientID != "" {
 		res.SetError(http.StatusUnauthorized, "invalid refresh token")
 		return ctx.JSON(res.GetStatus(), res.GetBody())
 	}
 
-	accessToken, err := h.securityTokenUseCase.GenAccessToken(refreshTokenMetadata.UserID)
+	refreshToken, err := h.securityTokenUseCase.RotateRefreshToken(ctx.Request().Context(), &refreshTokenMetadata)
+	if err != nil {
+		switch err.(type) {
+		case *terr.UnAuthorizedError:
+			setRefreshTokenCookie(ctx, "", 0)
+			res.SetError(http.StatusUnauthorized, err.Error())
+		default:
+			res.SetInternalServerError()
+		}
+		return ctx.JSON(res.GetStatus(), res.GetBody())
+	}
+
+	accessToken, err := h.securityTokenUseCase.GenAccessToken(ctx.Request().Context(), refreshToken.UserID)
 	if err != nil {
 		res.SetError(http.StatusUnauthorized, err.Error())
 		return ctx.JSON(res.GetStatus(), res.GetBody())
 	}
 
 	res.SetData(http.StatusOK, response.D{"access_token": accessToken.Token})
+	setRefreshTokenCookie(ctx, refreshToken.Token, 3600)
 	return ctx.JSON(res.GetStatus(), res.GetBody())
 }
 
-// GetUser gets the user from access token
+// GetUser gets a user by id, only the user itself or an admin can get it
 func (h *userHandler) GetUser(ctx echo.Context) error {
 	res := response.NewResponse()
 	userID := ctx.Param("id")
 
-	user, err := h.userUseCase.GetUserByID(userID)
+	principal, ok := cmw.GetPrincipal(ctx)
+	if !ok {
+		res.SetError(http.StatusUnauthorized, "invalid token")
+		return ctx.JSON(res.GetStatus(), res.GetBody())
+	}
+
+	// checked before the lookup, so the response doesn't reveal which users exist
+	if principal.UserID != userID && !principal.HasRole(auth.AdminRole) {
+		res.SetError(http.StatusForbidden, "forbidden")
+		return ctx.JSON(res.GetStatus(), res.GetBody())
+	}
+
+	return h.presentUserByID(ctx, userID)
+}
+
+// GetMe gets the user of the access token
+func (h *userHandler) GetMe(ctx echo.Context) error {
+	res := response.NewResponse()
+
+	principal, ok := cmw.GetPrincipal(ctx)
+	if !ok {
+		res.SetError(http.StatusUnauthorized, "invalid token")
+		return ctx.JSON(res.GetStatus(), res.GetBody())
+	}
+
+	return h.presentUserByID(ctx, principal.UserID)
+}
+
+// UpdateMe updates the names and email address of the user of the access token
+func (h *userHandler) UpdateMe(ctx echo.Context) error {
+	var user auth.User
+	res := response.NewResponse()
+
+	principal, ok := cmw.GetPrincipal(ctx)
+	if !ok {
+		res.SetError(http.StatusUnauthorized, "invalid token")
+		return ctx.JSON(res.GetStatus(), res.GetBody())
+	}
+
+	if err := ctx.Bind(&user); err != nil {
+		res.SetInternalServerError()
+		return ctx.JSON(res.GetStatus(), res.GetBody())
+	}
+
+	if errors := h.validator.ValidateUserParams(&user, "update"); len(errors) > 0 {
+		res.SetErrors(http.StatusUnprocessableEntity, errors)
+		return ctx.JSON(res.GetStatus(), res.GetBody())
+	}
+
+	user.ID = principal.UserID
+	updatedUser, err := h.userUseCase.UpdateUser(ctx.Request().Context(), &user)
+	if err != nil {
+		switch err.(type) {
+		case *terr.NotFoundError:
+			res.SetError(http.StatusNotFound, err.Error())
+		case *terr.DuplicateEntryError:
+			res.SetError(http.StatusForbidden, err.Error())
+		default:
+			res.SetInternalServerError()
+		}
+		return ctx.JSON(res.GetStatus(), res.GetBody())
+	}
+
+	res.SetData(http.StatusOK, response.D{"user": h.presenter.PresentUser(&updatedUser)})
+	return ctx.JSON(res.GetStatus(), res.GetBody())
+}
+
+// ChangePassword changes the password of the user of the access token, every other session
+// of the user is logged out
+func (h *userHandler) ChangePassword(ctx echo.Context) error {
+	var user auth.User
+	res := response.NewResponse()
+
+	principal, ok := cmw.GetPrincipal(ctx)
+	if !ok {
+		res.SetError(http.StatusUnauthorized, "invalid token")
+		return ctx.JSON(res.GetStatus(), res.GetBody())
+	}
+
+	if err := ctx.Bind(&user); err != nil {
+		res.SetInternalServerError()
+		return ctx.JSON(res.GetStatus(), res.GetBody())
+	}
+
+	if errors := h.validator.ValidateUserParams(&user, "change_password"); len(errors) > 0 {
+		res.SetErrors(http.StatusUnprocessableEntity, errors)
+		return ctx.JSON(res.GetStatus(), res.GetBody())
+	}
+
+	// the session of the request is kept when it presents its refresh token
+	var currentRefreshTokenMetadata *auth.TokenMetadata
+	if refreshTokenMetadata, err := h.security.GetAndValidateRefreshToken(ctx); err == nil {
+		currentRefreshTokenMetadata = &refreshTokenMetadata
+	}
+
+	err := h.userUseCase.ChangePassword(
+		ctx.Request().Context(),
+		principal.UserID,
+		user.Password,
+		user.NewPassword,
+		currentRefreshTokenMetadata,
+	)
+	if err != nil {
+		switch err.(type) {
+		case *terr.NotFoundError:
+			res.SetError(http.StatusNotFound, err.Error())
+		case *terr.UnAuthorizedError:
+			res.SetError(http.StatusForbidden, err.Error())
+		default:
+			res.SetInternalServerError()
+		}
+		return ctx.JSON(res.GetStatus(), res.GetBody())
+	}
+
+	res.SetData(http.StatusOK, nil)
+	return ctx.JSON(res.GetStatus(), res.GetBody())
+}
+
+// DeactivateMe deactivates the account of the user of the access token and logs out its sessions
+func (h *userHandler) DeactivateMe(ctx echo.Context) error {
+	res := response.NewResponse()
+
+	principal, ok := cmw.GetPrincipal(ctx)
+	if !ok {
+		res.SetError(http.StatusUnauthorized, "invalid token")
+		return ctx.JSON(res.GetStatus(), res.GetBody())
+	}
+
+	if err := h.userUseCase.DeactivateUser(ctx.Request().Context(), principal.UserID, principal.UserID); err != nil {
+		switch err.(type) {
+		case *terr.NotFoundError:
+			res.SetError(http.StatusNotFound, err.Error())
+		default:
+			res.SetInternalServerError()
+		}
+		return ctx.JSON(res.GetStatus(), res.GetBody())
+	}
+
+	setRefreshTokenCookie(ctx, "", 0)
+	res.SetData(http.StatusOK, nil)
+	return ctx.JSON(res.GetStatus(), res.GetBody())
+}
+
+// DeleteMe soft deletes the account of the user of the access token once its password is confirmed
+func (h *userHandler) DeleteMe(ctx echo.Context) error {
+	return h.removeMe(ctx, h.userUseCase.DeleteUser)
+}
+
+// EraseMe erases the account and data of the user of the access token once its password is confirmed
+func (h *userHandler) EraseMe(ctx echo.Context) error {
+	return h.removeMe(ctx, h.userUseCase.EraseUser)
+}
+
+// removeMe removes the account of the principal with remove once its password is confirmed
+func (h *userHandler) removeMe(ctx echo.Context, remove func(ctx context.Context, userID, password string) error) error {
+	var user auth.User
+	res := response.NewResponse()
+
+	principal, ok := cmw.GetPrincipal(ctx)
+	if !ok {
+		res.SetError(http.StatusUnauthorized, "invalid token")
+		return ctx.JSON(res.GetStatus(), res.GetBody())
+	}
+
+	if err := ctx.Bind(&user); err != nil {
+		res.SetInternalServerError()
+		return ctx.JSON(res.GetStatus(), res.GetBody())
+	}
+
+	if errors := h.validator.ValidateUserParams(&user, "confirm_password"); len(errors) > 0 {
+		res.SetErrors(http.StatusUnprocessableEntity, errors)
+		return ctx.JSON(res.GetStatus(), res.GetBody())
+	}
+
+	if err := remove(ctx.Request().Context(), principal.UserID, user.Password); err != nil {
+		switch err.(type) {
+		case *terr.NotFoundError:
+			res.SetError(http.StatusNotFound, err.Error())
+		case *terr.UnAuthorizedError:
+			res.SetError(http.StatusForbidden, err.Error())
+		default:
+			res.SetInternalServerError()
+		}
+		return ctx.JSON(res.GetStatus(), res.GetBody())
+	}
+
+	setRefreshTokenCookie(ctx, "", 0)
+	res.SetData(http.StatusOK, nil)
+	return ctx.JSON(res.GetStatus(), res.GetBody())
+}
+
+// presentUserByID responds with the presented user of userID
+func (h *userHandler) presentUserByID(ctx echo.Context, userID string) error {
+	res := response.NewResponse()
+
+	user, err := h.userUseCase.GetUserByID(ctx.Request().Context(), userID)
 	if err != nil {
 		switch err.(type) {
 		case *terr.NotFoundError:
@@ -177,31 +524,353 @@ func (h *userHandler) GetUser(ctx echo.Context) error {
 	return ctx.JSON(res.GetStatus(), res.GetBody())
 }
 
-// Logout logs out the user
+// Logout logs out the current user session and revokes the presented access token
 func (h *userHandler) Logout(ctx echo.Context) error {
 	res := response.NewResponse()
 
+	principal, ok := cmw.GetPrincipal(ctx)
+	if !ok {
+		res.SetError(http.StatusUnauthorized, "invalid token")
+		return ctx.JSON(res.GetStatus(), res.GetBody())
+	}
+
+	if err := h.securityTokenUseCase.RevokeAccessToken(ctx.Request().Context(), &principal); err != nil {
+		res.SetInternalServerError()
+		return ctx.JSON(res.GetStatus(), res.GetBody())
+	}
+
 	refreshTokenMetadata, err := h.security.GetAndValidateRefreshToken(ctx)
 	if err != nil {
 		res.SetError(http.StatusUnauthorized, err.Error())
 		return ctx.JSON(res.GetStatus(), res.GetBody())
 	}
 
-	if err := h.securityTokenUseCase.RemoveRefreshToken(&refreshTokenMetadata); err != nil {
+	if err := h.securityTokenUseCase.RemoveRefreshToken(ctx.Request().Context(), &refreshTokenMetadata); err != nil {
+		switch err.(type) {
+		case *terr.NotFoundError:
+			setRefreshTokenCookie(ctx, "", 0)
+			res.SetError(http.StatusUnauthorized, "invalid refresh token")
+		default:
+			res.SetInternalServerError()
+		}
+		return ctx.JSON(res.GetStatus(), res.GetBody())
+	}
+
+	setRefreshTokenCookie(ctx, "", 0)
+	res.SetData(http.StatusOK, nil)
+	return ctx.JSON(res.GetStatus(), res.GetBody())
+}
+
+// GetSessions gets the active sessions of the user
+func (h *userHandler) GetSessions(ctx echo.Context) error {
+	res := response.NewResponse()
+
+	principal, ok := cmw.GetPrincipal(ctx)
+	if !ok {
+		res.SetError(http.StatusUnauthorized, "invalid token")
+		return ctx.JSON(res.GetStatus(), res.GetBody())
+	}
+
+	sessions, err := h.securityTokenUseCase.GetSessions(ctx.Request().Context(), principal.UserID)
+	if err != nil {
+		res.SetInternalServerError()
+		return ctx.JSON(res.GetStatus(), res.GetBody())
+	}
+
+	res.SetData(http.StatusOK, response.D{"sessions": h.presenter.PresentSessions(sessions)})
+	return ctx.JSON(res.GetStatus(), res.GetBody())
+}
+
+// RemoveSession logs out one of the user sessions
+func (h *userHandler) RemoveSession(ctx echo.Context) error {
+	res := response.NewResponse()
+
+	principal, ok := cmw.GetPrincipal(ctx)
+	if !ok {
+		res.SetError(http.StatusUnauthorized, "invalid token")
+		return ctx.JSON(res.GetStatus(), res.GetBody())
+	}
+
+	if err := h.securityTokenUseCase.RemoveSession(ctx.Request().Context(), principal.UserID, ctx.Param("session_id")); err != nil {
+		switch err.(type) {
+		case *terr.NotFoundError:
+			res.SetError(http.StatusNotFound, "session not found")
+		default:
+			res.SetInternalServerError()
+		}
+		return ctx.JSON(res.GetStatus(), res.GetBody())
+	}
+
+	res.SetData(http.StatusOK, nil)
+	return ctx.JSON(res.GetStatus(), res.GetBody())
+}
+
+// RemoveSessions logs out every session of the user
+func (h *userHandler) RemoveSessions(ctx echo.Context) error {
+	res := response.NewResponse()
+
+	principal, ok := cmw.GetPrincipal(ctx)
+	if !ok {
+		res.SetError(http.StatusUnauthorized, "invalid token")
+		return ctx.JSON(res.GetStatus(), res.GetBody())
+	}
+
+	if err := h.securityTokenUseCase.RemoveSessions(ctx.Request().Context(), principal.UserID); err != nil {
 		res.SetInternalServerError()
 		return ctx.JSON(res.GetStatus(), res.GetBody())
 	}
 
+	setRefreshTokenCookie(ctx, "", 0)
+	res.SetData(http.StatusOK, nil)
+	return ctx.JSON(res.GetStatus(), res.GetBody())
+}
+
+// VerifyEmail verifies the user email address with the token it received by email
+func (h *userHandler) VerifyEmail(ctx echo.Context) error {
+	var params tokenParams
+	res := response.NewResponse()
+
+	if err := ctx.Bind(&params); err != nil {
+		res.SetInternalServerError()
+		return ctx.JSON(res.GetStatus(), res.GetBody())
+	}
+
+	if errors := h.validator.ValidateTokenParams(params.Token); len(errors) > 0 {
+		res.SetErrors(http.StatusUnprocessableEntity, errors)
+		return ctx.JSON(res.GetStatus(), res.GetBody())
+	}
+
+	if err := h.userUseCase.VerifyEmail(ctx.Request().Context(), params.Token); err != nil {
+		switch err.(type) {
+		case *terr.ExpiredTokenError,
+			*terr.MalformedTokenError,
+			*terr.TokenTypeError,
+			*terr.TokenSignatureError,
+			*terr.UnAuthorizedError:
+			res.SetError(http.StatusUnauthorized, "invalid verification token: "+err.Error())
+		case *terr.NotFoundError:
+			res.SetError(http.StatusNotFound, err.Error())
+		default:
+			res.SetInternalServerError()
+		}
+		return ctx.JSON(res.GetStatus(), res.GetBody())
+	}
+
+	res.SetData(http.StatusOK, nil)
+	return ctx.JSON(res.GetStatus(), res.GetBody())
+}
+
+// ResendVerificationEmail sends a new verification token to an unverified email address, the
+// response is the same whether the address is registered or not
+func (h *userHandler) ResendVerificationEmail(ctx echo.Context) error {
+	var user auth.User
+	res := response.NewResponse()
+
+	if err := ctx.Bind(&user); err != nil {
+		res.SetInternalServerError()
+		return ctx.JSON(res.GetStatus(), res.GetBody())
+	}
+
+	if errors := h.validator.ValidateUserParams(&user, "resend_verification"); len(errors) > 0 {
+		res.SetErrors(http.StatusUnprocessableEntity, errors)
+		return ctx.JSON(res.GetStatus(), res.GetBody())
+	}
+
+	if err := h.userUseCase.ResendVerificationEmail(ctx.Request().Context(), user.EmailAddress); err != nil {
+		res.SetInternalServerError()
+		return ctx.JSON(res.GetStatus(), res.GetBody())
+	}
+
+	res.SetData(http.StatusAccepted, nil)
+	return ctx.JSON(res.GetStatus(), res.GetBody())
+}
+
+// ForgotPassword sends a password reset token to the user, the response is the same whether
+// the address is registered or not
+func (h *userHandler) ForgotPassword(ctx echo.Context) error {
+	var user auth.User
+	res := response.NewResponse()
+
+	if err := ctx.Bind(&user); err != nil {
+		res.SetInternalServerError()
+		return ctx.JSON(res.GetStatus(), res.GetBody())
+	}
+
+	if errors := h.validator.ValidateUserParams(&user, "forgot_password"); len(errors) > 0 {
+		res.SetErrors(http.StatusUnprocessableEntity, errors)
+		return ctx.JSON(res.GetStatus(), res.GetBody())
+	}
+
+	if err := h.userUseCase.ForgotPassword(ctx.Request().Context(), user.EmailAddress); err != nil {
+		res.SetInternalServerError()
+		return ctx.JSON(res.GetStatus(), res.GetBody())
+	}
+
+	res.SetData(http.StatusAccepted, nil)
+	return ctx.JSON(res.GetStatus(), res.GetBody())
+}
+
+// ResetPassword sets a new password with the reset token the user received by email, every
+// session of the user is logged out
+func (h *userHandler) ResetPassword(ctx echo.Context) error {
+	var params tokenParams
+	res := response.NewResponse()
+
+	if err := ctx.Bind(&params); err != nil {
+		res.SetInternalServerError()
+		return ctx.JSON(res.GetStatus(), res.GetBody())
+	}
+
+	if errors := h.validator.ValidatePasswordResetParams(params.Token, params.Password); len(errors) > 0 {
+		res.SetErrors(http.StatusUnprocessableEntity, errors)
+		return ctx.JSON(res.GetStatus(), res.GetBody())
+	}
+
+	if err := h.userUseCase.ResetPassword(ctx.Request().Context(), params.Token, params.Password); err != nil {
+		switch err.(type) {
+		case *terr.ExpiredTokenError,
+			*terr.MalformedTokenError,
+			*terr.TokenTypeError,
+			*terr.TokenSignatureError,
+			*terr.UnAuthorizedError:
+			res.SetError(http.StatusUnauthorized, "invalid reset token: "+err.Error())
+		case *terr.NotFoundError:
+			res.SetError(http.StatusNotFound, err.Error())
+		default:
+			res.SetInternalServerError()
+		}
+		return ctx.JSON(res.GetStatus(), res.GetBody())
+	}
+
+	res.SetData(http.StatusOK, nil)
+	return ctx.JSON(res.GetStatus(), res.GetBody())
+}
+
+// SetupTwoFactor generates a new TOTP secret for the user, returned with its otpauth URI
+func (h *userHandler) SetupTwoFactor(ctx echo.Context) error {
+	res := response.NewResponse()
+
+	principal, ok := cmw.GetPrincipal(ctx)
+	if !ok {
+		res.SetError(http.StatusUnauthorized, "invalid token")
+		return ctx.JSON(res.GetStatus(), res.GetBody())
+	}
+
+	setup, err := h.twoFactorUseCase.Setup(ctx.Request().Context(), principal.UserID)
+	if err != nil {
+		switch err.(type) {
+		case *terr.NotFoundError:
+			res.SetError(http.StatusNotFound, err.Error())
+		case *terr.DuplicateEntryError:
+			res.SetError(http.StatusForbidden, err.Error())
+		default:
+			res.SetInternalServerError()
+		}
+		return ctx.JSON(res.GetStatus(), res.GetBody())
+	}
+
+	res.SetData(http.StatusOK, response.D{"secret": setup.Secret, "uri": setup.URI})
+	return ctx.JSON(res.GetStatus(), res.GetBody())
+}
+
+// ConfirmTwoFactor enables two factor authentication with a code of the new authenticator, the
+// recovery codes are only returned by this route
+func (h *userHandler) ConfirmTwoFactor(ctx echo.Context) error {
+	var params twoFactorParams
+	res := response.NewResponse()
+
+	principal, ok := cmw.GetPrincipal(ctx)
+	if !ok {
+		res.SetError(http.StatusUnauthorized, "invalid token")
+		return ctx.JSON(res.GetStatus(), res.GetBody())
+	}
+
+	if err := ctx.Bind(&params); err != nil {
+		res.SetInternalServerError()
+		return ctx.JSON(res.GetStatus(), res.GetBody())
+	}
+
+	if errors := h.validator.ValidateTwoFactorCodeParams(params.Code); len(errors) > 0 {
+		res.SetErrors(http.StatusUnprocessableEntity, errors)
+		return ctx.JSON(res.GetStatus(), res.GetBody())
+	}
+
+	recoveryCodes, err := h.twoFactorUseCase.Confirm(ctx.Request().Context(), principal.UserID, params.Code)
+	if err != nil {
+		switch err := err.(type) {
+		case *terr.TooManyAttemptsError:
+			setRetryAfterHeader(ctx, err.RetryAfter())
+			res.SetError(http.StatusTooManyRequests, err.Error())
+		case *terr.NotFoundError:
+			res.SetError(http.StatusNotFound, err.Error())
+		case *terr.DuplicateEntryError, *terr.UnAuthorizedError:
+			res.SetError(http.StatusForbidden, err.Error())
+		default:
+			res.SetInternalServerError()
+		}
+		return ctx.JSON(res.GetStatus(), res.GetBody())
+	}
+
+	res.SetData(http.StatusOK, response.D{"recovery_codes": recoveryCodes})
+	return ctx.JSON(res.GetStatus(), res.GetBody())
+}
+
+// DisableTwoFactor disables two factor authentication with a TOTP or recovery code
+func (h *userHandler) DisableTwoFactor(ctx echo.Context) error {
+	var params twoFactorParams
+	res := response.NewResponse()
+
+	principal, ok := cmw.GetPrincipal(ctx)
+	if !ok {
+		res.SetError(http.StatusUnauthorized, "invalid token")
+		return ctx.JSON(res.GetStatus(), res.GetBody())
+	}
+
+	if err := ctx.Bind(&params); err != nil {
+		res.SetInternalServerError()
+		return ctx.JSON(res.GetStatus(), res.GetBody())
+	}
+
+	if errors := h.validator.ValidateTwoFactorCodeParams(params.Code); len(errors) > 0 {
+		res.SetErrors(http.StatusUnprocessableEntity, errors)
+		return ctx.JSON(res.GetStatus(), res.GetBody())
+	}
+
+	if err := h.twoFactorUseCase.Disable(ctx.Request().Context(), principal.UserID, params.Code); err != nil {
+		switch err := err.(type) {
+		case *terr.TooManyAttemptsError:
+			setRetryAfterHeader(ctx, err.RetryAfter())
+			res.SetError(http.StatusTooManyRequests, err.Error())
+		case *terr.NotFoundError:
+			res.SetError(http.StatusNotFound, err.Error())
+		case *terr.UnAuthorizedError:
+			res.SetError(http.StatusForbidden, err.Error())
+		default:
+			res.SetInternalServerError()
+		}
+		return ctx.JSON(res.GetStatus(), res.GetBody())
+	}
+
+	res.SetData(http.StatusOK, nil)
+	return ctx.JSON(res.GetStatus(), res.GetBody())
+}
+
+// setRefreshTokenCookie sets the REFRESH_TOKEN cookie, an empty value with maxAge 0 clears it
+func setRefreshTokenCookie(ctx echo.Context, value string, maxAge int) {
 	// TODO: add secure to cookie when tls is ready
 	ctx.SetCookie(&http.Cookie{
 		Name:     "REFRESH_TOKEN",
-		Value:    "",
-		MaxAge:   0,
+		Value:    value,
+		MaxAge:   maxAge,
 		Path:     "/",
 		Domain:   ctx.Request().Host,
 		Secure:   false,
 		HttpOnly: true,
 	})
-	res.SetData(http.StatusOK, nil)
-	return ctx.JSON(res.GetStatus(), res.GetBody())
+}
+
+// setRetryAfterHeader sets the Retry-After header in whole seconds, rounded up
+func setRetryAfterHeader(ctx echo.Context, retryAfter time.Duration) {
+	seconds := int(math.Ceil(retryAfter.Seconds()))
+	ctx.Response().Header().Set("Retry-After", strconv.Itoa(seconds))
 }
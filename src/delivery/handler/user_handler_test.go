@@ -12,6 +12,7 @@ import (
 	_ "sherman/src/app/testing"
 	"sherman/src/app/utils/terr"
 	"sherman/src/domain/auth"
+	cmw "sherman/src/service/middleware"
 	"strings"
 	"testing"
 	"time"
@@ -20,6 +21,8 @@ import (
 type userHandlerMockDeps struct {
 	userUseCase          *mocks.UserUseCase
 	securityTokenUseCase *mocks.SecurityTokenUseCase
+	loginAttemptUseCase  *mocks.LoginAttemptUseCase
+	twoFactorUseCase     *mocks.TwoFactorUseCase
 	validatorService     *mocks.Validator
 	securityService      *mocks.Security
 	presenterService     *mocks.Presenter
@@ -29,6 +32,8 @@ func genMockUserHandler() (UserHandler, userHandlerMockDeps) {
 	uhDeps := userHandlerMockDeps{
 		userUseCase:          new(mocks.UserUseCase),
 		securityTokenUseCase: new(mocks.SecurityTokenUseCase),
+		loginAttemptUseCase:  new(mocks.LoginAttemptUseCase),
+		twoFactorUseCase:     new(mocks.TwoFactorUseCase),
 		validatorService:     new(mocks.Validator),
 		securityService:      new(mocks.Security),
 		presenterService:     new(mocks.Presenter),
@@ -37,6 +42,8 @@ func genMockUserHandler() (UserHandler, userHandlerMockDeps) {
 	uh := NewUserHandler(
 		uhDeps.userUseCase,
 		uhDeps.securityTokenUseCase,
+		uhDeps.loginAttemptUseCase,
+		uhDeps.twoFactorUseCase,
 		uhDeps.validatorService,
 		uhDeps.securityService,
 		uhDeps.presenterService,
@@ -45,6 +52,22 @@ func genMockUserHandler() (UserHandler, userHandlerMockDeps) {
 	return uh, uhDeps
 }
 
+// allowLogin lets the login attempts through and accepts their recording, users have no two factor authentication
+func (d userHandlerMockDeps) allowLogin() {
+	d.loginAttemptUseCase.On("CheckLogin", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	d.loginAttemptUseCase.On("RecordFailedLogin", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	d.loginAttemptUseCase.On("RecordSuccessfulLogin", mock.Anything, mock.Anything).Return(nil)
+	d.twoFactorUseCase.On("IsEnabled", mock.Anything, mock.Anything).Return(false, nil)
+}
+
+func genJSONContext(method, body string) (echo.Context, *httptest.ResponseRecorder) {
+	e := echo.New()
+	req := httptest.NewRequest(method, "/some-url", strings.NewReader(body))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	rec := httptest.NewRecorder()
+	return e.NewContext(req, rec), rec
+}
+
 func TestRegister(t *testing.T) {
 	mockUser := auth.User{
 		FirstName:    "first",
@@ -55,7 +78,7 @@ func TestRegister(t *testing.T) {
 
 	t.Run("it should succeed", func(t *testing.T) {
 		uh, uhDeps := genMockUserHandler()
-		uhDeps.userUseCase.On("Register", mock.Anything).Return(nil)
+		uhDeps.userUseCase.On("Register", mock.Anything, mock.Anything).Return(nil)
 		uhDeps.validatorService.
 			On("ValidateUserParams", mock.Anything, mock.AnythingOfType("string")).
 			Return(make(map[string]string))
@@ -127,7 +150,7 @@ func TestRegister(t *testing.T) {
 		uhDeps.validatorService.
 			On("ValidateUserParams", mock.Anything, mock.AnythingOfType("string")).
 			Return(make(map[string]string))
-		uhDeps.userUseCase.On("Register", mock.Anything).Return(mockError)
+		uhDeps.userUseCase.On("Register", mock.Anything, mock.Anything).Return(mockError)
 
 		userJSON, err := json.Marshal(mockUser)
 		assert.NoError(t, err)
@@ -152,7 +175,7 @@ func TestRegister(t *testing.T) {
 		uhDeps.validatorService.
 			On("ValidateUserParams", mock.Anything, mock.AnythingOfType("string")).
 			Return(make(map[string]string))
-		uhDeps.userUseCase.On("Register", mock.Anything).Return(mockError)
+		uhDeps.userUseCase.On("Register", mock.Anything, mock.Anything).Return(mockError)
 
 		userJSON, err := json.Marshal(mockUser)
 		assert.NoError(t, err)
@@ -191,17 +214,18 @@ func TestLogin(t *testing.T) {
 
 	t.Run("it should succeed", func(t *testing.T) {
 		uh, uhDeps := genMockUserHandler()
+		uhDeps.allowLogin()
 		uhDeps.validatorService.
 			On("ValidateUserParams", mock.Anything, mock.AnythingOfType("string")).
 			Return(make(map[string]string))
 		uhDeps.userUseCase.
-			On("VerifyCredentials", mock.Anything).
+			On("VerifyCredentials", mock.Anything, mock.Anything).
 			Return(mockUser, nil)
 		uhDeps.securityTokenUseCase.
-			On("GenAccessToken", mock.AnythingOfType("string")).
+			On("GenAccessToken", mock.Anything, mock.AnythingOfType("string")).
 			Return(mockToken, nil)
 		uhDeps.securityTokenUseCase.
-			On("GenRefreshToken", mock.AnythingOfType("string")).
+			On("GenRefreshToken", mock.Anything, mock.AnythingOfType("string"), "some-user-agent", "10.0.0.1").
 			Return(mockToken, nil)
 
 		userJSON, err := json.Marshal(mockUser)
@@ -212,6 +236,8 @@ func TestLogin(t *testing.T) {
 		assert.NoError(t, err)
 
 		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		req.Header.Set("User-Agent", "some-user-agent")
+		req.Header.Set(echo.HeaderXRealIP, "10.0.0.1")
 		rec := httptest.NewRecorder()
 		ctx := e.NewContext(req, rec)
 
@@ -219,6 +245,8 @@ func TestLogin(t *testing.T) {
 			assert.Equal(t, http.StatusOK, rec.Code)
 			assert.Equal(t, "REFRESH_TOKEN=some-token; Path=/; Max-Age=3600; HttpOnly", rec.Header().Get("Set-Cookie"))
 			assert.Equal(t, "{\"data\":{\"access_token\":\"some-token\"}}\n", rec.Body.String())
+			uhDeps.loginAttemptUseCase.AssertCalled(t, "CheckLogin", mock.Anything, "some@email.com", "10.0.0.1")
+			uhDeps.loginAttemptUseCase.AssertCalled(t, "RecordSuccessfulLogin", mock.Anything, "some@email.com")
 		}
 	})
 
@@ -268,12 +296,13 @@ func TestLogin(t *testing.T) {
 
 	t.Run("it should return error", func(t *testing.T) {
 		uh, uhDeps := genMockUserHandler()
+		uhDeps.allowLogin()
 		uhDeps.validatorService.
 			On("ValidateUserParams", mock.Anything, mock.AnythingOfType("string")).
 			Return(make(map[string]string))
 		mockError := terr.NewNotFoundError("verify credentials not found error")
 		uhDeps.userUseCase.
-			On("VerifyCredentials", mock.Anything).
+			On("VerifyCredentials", mock.Anything, mock.Anything).
 			Return(auth.User{}, mockError)
 
 		userJSON, err := json.Marshal(mockUser)
@@ -290,17 +319,66 @@ func TestLogin(t *testing.T) {
 		if assert.NoError(t, uh.Login(ctx)) {
 			assert.Equal(t, http.StatusNotFound, rec.Code)
 			assert.Equal(t, "{\"data\":null,\"error\":\"verify credentials not found error\"}\n", rec.Body.String())
+			uhDeps.loginAttemptUseCase.AssertCalled(t, "RecordFailedLogin", mock.Anything, "some@email.com", mock.Anything)
+		}
+	})
+
+	t.Run("it should return error", func(t *testing.T) {
+		uh, uhDeps := genMockUserHandler()
+		uhDeps.allowLogin()
+		uhDeps.validatorService.
+			On("ValidateUserParams", mock.Anything, mock.AnythingOfType("string")).
+			Return(make(map[string]string))
+		uhDeps.userUseCase.
+			On("VerifyCredentials", mock.Anything, mock.Anything).
+			Return(auth.User{}, terr.NewUnverifiedEmailError("email address not verified"))
+
+		userJSON, err := json.Marshal(mockUser)
+		assert.NoError(t, err)
+
+		e := echo.New()
+		req, err := http.NewRequest(echo.POST, "/some-url", strings.NewReader(string(userJSON)))
+		assert.NoError(t, err)
+
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+		ctx := e.NewContext(req, rec)
+
+		if assert.NoError(t, uh.Login(ctx)) {
+			assert.Equal(t, http.StatusForbidden, rec.Code)
+			assert.Equal(t, "{\"data\":null,\"error\":\"email address not verified\"}\n", rec.Body.String())
+			uhDeps.securityTokenUseCase.AssertNotCalled(t, "GenAccessToken", mock.Anything, mock.Anything)
+		}
+	})
+
+	t.Run("it should return error", func(t *testing.T) {
+		uh, uhDeps := genMockUserHandler()
+		uhDeps.allowLogin()
+		uhDeps.validatorService.
+			On("ValidateUserParams", mock.Anything, mock.AnythingOfType("string")).
+			Return(make(map[string]string))
+		uhDeps.userUseCase.
+			On("VerifyCredentials", mock.Anything, mock.Anything).
+			Return(auth.User{}, terr.NewInactiveUserError("user account deactivated"))
+
+		ctx, rec := genJSONContext(echo.POST, "{\"email_address\":\"some@email.com\",\"password\":\"some-password\"}")
+
+		if assert.NoError(t, uh.Login(ctx)) {
+			assert.Equal(t, http.StatusForbidden, rec.Code)
+			assert.Equal(t, "{\"data\":null,\"error\":\"user account deactivated\"}\n", rec.Body.String())
+			uhDeps.securityTokenUseCase.AssertNotCalled(t, "GenAccessToken", mock.Anything, mock.Anything)
 		}
 	})
 
 	t.Run("it should return error", func(t *testing.T) {
 		uh, uhDeps := genMockUserHandler()
+		uhDeps.allowLogin()
 		uhDeps.validatorService.
 			On("ValidateUserParams", mock.Anything, mock.AnythingOfType("string")).
 			Return(make(map[string]string))
 		mockError := terr.NewUnAuthorizedError("verify credentials unauthorized error")
 		uhDeps.userUseCase.
-			On("VerifyCredentials", mock.Anything).
+			On("VerifyCredentials", mock.Anything, mock.Anything).
 			Return(auth.User{}, mockError)
 
 		userJSON, err := json.Marshal(mockUser)
@@ -322,12 +400,13 @@ func TestLogin(t *testing.T) {
 
 	t.Run("it should return error", func(t *testing.T) {
 		uh, uhDeps := genMockUserHandler()
+		uhDeps.allowLogin()
 		uhDeps.validatorService.
 			On("ValidateUserParams", mock.Anything, mock.AnythingOfType("string")).
 			Return(make(map[string]string))
 		mockError := errors.New("any verify credentials error")
 		uhDeps.userUseCase.
-			On("VerifyCredentials", mock.Anything).
+			On("VerifyCredentials", mock.Anything, mock.Anything).
 			Return(auth.User{}, mockError)
 
 		userJSON, err := json.Marshal(mockUser)
@@ -349,15 +428,16 @@ func TestLogin(t *testing.T) {
 
 	t.Run("it should return error", func(t *testing.T) {
 		uh, uhDeps := genMockUserHandler()
+		uhDeps.allowLogin()
 		uhDeps.validatorService.
 			On("ValidateUserParams", mock.Anything, mock.AnythingOfType("string")).
 			Return(make(map[string]string))
 		uhDeps.userUseCase.
-			On("VerifyCredentials", mock.Anything).
+			On("VerifyCredentials", mock.Anything, mock.Anything).
 			Return(mockUser, nil)
 		mockError := errors.New("generate access token error")
 		uhDeps.securityTokenUseCase.
-			On("GenAccessToken", mock.AnythingOfType("string")).
+			On("GenAccessToken", mock.Anything, mock.AnythingOfType("string")).
 			Return(auth.SecurityToken{}, mockError)
 
 		userJSON, err := json.Marshal(mockUser)
@@ -379,18 +459,19 @@ func TestLogin(t *testing.T) {
 
 	t.Run("it should return error", func(t *testing.T) {
 		uh, uhDeps := genMockUserHandler()
+		uhDeps.allowLogin()
 		uhDeps.validatorService.
 			On("ValidateUserParams", mock.Anything, mock.AnythingOfType("string")).
 			Return(make(map[string]string))
 		uhDeps.userUseCase.
-			On("VerifyCredentials", mock.Anything).
+			On("VerifyCredentials", mock.Anything, mock.Anything).
 			Return(mockUser, nil)
 		uhDeps.securityTokenUseCase.
-			On("GenAccessToken", mock.AnythingOfType("string")).
+			On("GenAccessToken", mock.Anything, mock.AnythingOfType("string")).
 			Return(mockToken, nil)
 		mockError := errors.New("generate refresh token error")
 		uhDeps.securityTokenUseCase.
-			On("GenRefreshToken", mock.AnythingOfType("string")).
+			On("GenRefreshToken", mock.Anything, mock.AnythingOfType("string"), mock.AnythingOfType("string"), mock.AnythingOfType("string")).
 			Return(auth.SecurityToken{}, mockError)
 
 		userJSON, err := json.Marshal(mockUser)
@@ -409,6 +490,217 @@ func TestLogin(t *testing.T) {
 			assert.Equal(t, "{\"data\":null,\"error\":\"internal server error\"}\n", rec.Body.String())
 		}
 	})
+
+	t.Run("it should return error", func(t *testing.T) {
+		uh, uhDeps := genMockUserHandler()
+		uhDeps.validatorService.
+			On("ValidateUserParams", mock.Anything, mock.AnythingOfType("string")).
+			Return(make(map[string]string))
+		uhDeps.loginAttemptUseCase.
+			On("CheckLogin", mock.Anything, "some@email.com", mock.Anything).
+			Return(terr.NewAccountLockedError("account locked, too many failed logins", 1500*time.Millisecond))
+
+		ctx, rec := genJSONContext(echo.POST, "{\"email_address\":\"some@email.com\",\"password\":\"some-password\"}")
+
+		if assert.NoError(t, uh.Login(ctx)) {
+			assert.Equal(t, http.StatusLocked, rec.Code)
+			assert.Equal(t, "2", rec.Header().Get("Retry-After"))
+			assert.Equal(t, "{\"data\":null,\"error\":\"account locked, too many failed logins\"}\n", rec.Body.String())
+			uhDeps.userUseCase.AssertNotCalled(t, "VerifyCredentials", mock.Anything, mock.Anything)
+		}
+	})
+
+	t.Run("it should return error", func(t *testing.T) {
+		uh, uhDeps := genMockUserHandler()
+		uhDeps.validatorService.
+			On("ValidateUserParams", mock.Anything, mock.AnythingOfType("string")).
+			Return(make(map[string]string))
+		uhDeps.loginAttemptUseCase.
+			On("CheckLogin", mock.Anything, mock.Anything, mock.Anything).
+			Return(terr.NewTooManyAttemptsError("too many failed logins, try again later", time.Minute))
+
+		ctx, rec := genJSONContext(echo.POST, "{\"email_address\":\"some@email.com\",\"password\":\"some-password\"}")
+
+		if assert.NoError(t, uh.Login(ctx)) {
+			assert.Equal(t, http.StatusTooManyRequests, rec.Code)
+			assert.Equal(t, "60", rec.Header().Get("Retry-After"))
+			assert.Equal(t, "{\"data\":null,\"error\":\"too many failed logins, try again later\"}\n", rec.Body.String())
+		}
+	})
+
+	t.Run("it should return error", func(t *testing.T) {
+		uh, uhDeps := genMockUserHandler()
+		uhDeps.validatorService.
+			On("ValidateUserParams", mock.Anything, mock.AnythingOfType("string")).
+			Return(make(map[string]string))
+		uhDeps.loginAttemptUseCase.
+			On("CheckLogin", mock.Anything, mock.Anything, mock.Anything).
+			Return(errors.New("some error"))
+
+		ctx, rec := genJSONContext(echo.POST, "{\"email_address\":\"some@email.com\",\"password\":\"some-password\"}")
+
+		if assert.NoError(t, uh.Login(ctx)) {
+			assert.Equal(t, http.StatusInternalServerError, rec.Code)
+			assert.Equal(t, "{\"data\":null,\"error\":\"internal server error\"}\n", rec.Body.String())
+		}
+	})
+
+	t.Run("it should return error", func(t *testing.T) {
+		uh, uhDeps := genMockUserHandler()
+		uhDeps.validatorService.
+			On("ValidateUserParams", mock.Anything, mock.AnythingOfType("string")).
+			Return(make(map[string]string))
+		uhDeps.loginAttemptUseCase.
+			On("CheckLogin", mock.Anything, mock.Anything, mock.Anything).
+			Return(nil)
+		uhDeps.loginAttemptUseCase.
+			On("RecordFailedLogin", mock.Anything, mock.Anything, mock.Anything).
+			Return(errors.New("some error"))
+		uhDeps.userUseCase.
+			On("VerifyCredentials", mock.Anything, mock.Anything).
+			Return(auth.User{}, terr.NewUnAuthorizedError("invalid credentials"))
+
+		ctx, rec := genJSONContext(echo.POST, "{\"email_address\":\"some@email.com\",\"password\":\"some-password\"}")
+
+		if assert.NoError(t, uh.Login(ctx)) {
+			assert.Equal(t, http.StatusUnauthorized, rec.Code)
+			assert.Equal(t, "{\"data\":null,\"error\":\"invalid credentials\"}\n", rec.Body.String())
+		}
+	})
+
+	t.Run("it should require a second factor", func(t *testing.T) {
+		uh, uhDeps := genMockUserHandler()
+		uhDeps.validatorService.
+			On("ValidateUserParams", mock.Anything, mock.AnythingOfType("string")).
+			Return(make(map[string]string))
+		uhDeps.loginAttemptUseCase.On("CheckLogin", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+		uhDeps.loginAttemptUseCase.On("RecordSuccessfulLogin", mock.Anything, mock.Anything).Return(nil)
+		uhDeps.userUseCase.
+			On("VerifyCredentials", mock.Anything, mock.Anything).
+			Return(auth.User{ID: "some-user-id"}, nil)
+		uhDeps.twoFactorUseCase.On("IsEnabled", mock.Anything, "some-user-id").Return(true, nil)
+		uhDeps.twoFactorUseCase.
+			On("GenPendingToken", mock.Anything, "some-user-id").
+			Return(auth.SecurityToken{Token: "some-pending-token"}, nil)
+
+		ctx, rec := genJSONContext(echo.POST, "{\"email_address\":\"some@email.com\",\"password\":\"some-password\"}")
+
+		if assert.NoError(t, uh.Login(ctx)) {
+			assert.Equal(t, http.StatusOK, rec.Code)
+			assert.Empty(t, rec.Header().Get(echo.HeaderSetCookie))
+			assert.Equal(t, "{\"data\":{\"mfa_required\":true,\"mfa_token\":\"some-pending-token\"}}\n", rec.Body.String())
+			uhDeps.securityTokenUseCase.AssertNotCalled(t, "GenAccessToken", mock.Anything, mock.Anything)
+		}
+	})
+
+	t.Run("it should return error", func(t *testing.T) {
+		uh, uhDeps := genMockUserHandler()
+		uhDeps.validatorService.
+			On("ValidateUserParams", mock.Anything, mock.AnythingOfType("string")).
+			Return(make(map[string]string))
+		uhDeps.loginAttemptUseCase.On("CheckLogin", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+		uhDeps.loginAttemptUseCase.On("RecordSuccessfulLogin", mock.Anything, mock.Anything).Return(nil)
+		uhDeps.userUseCase.
+			On("VerifyCredentials", mock.Anything, mock.Anything).
+			Return(auth.User{ID: "some-user-id"}, nil)
+		uhDeps.twoFactorUseCase.On("IsEnabled", mock.Anything, "some-user-id").Return(false, errors.New("some error"))
+
+		ctx, rec := genJSONContext(echo.POST, "{\"email_address\":\"some@email.com\",\"password\":\"some-password\"}")
+
+		if assert.NoError(t, uh.Login(ctx)) {
+			assert.Equal(t, http.StatusInternalServerError, rec.Code)
+		}
+	})
+}
+
+func TestLoginTwoFactor(t *testing.T) {
+	mockToken := auth.SecurityToken{Token: "some-token"}
+
+	t.Run("it should succeed", func(t *testing.T) {
+		uh, uhDeps := genMockUserHandler()
+		uhDeps.validatorService.
+			On("ValidateTwoFactorLoginParams", "some-pending-token", "123456").
+			Return(make(map[string]string))
+		uhDeps.twoFactorUseCase.
+			On("VerifyLogin", mock.Anything, "some-pending-token", "123456").
+			Return("some-user-id", nil)
+		uhDeps.securityTokenUseCase.On("GenAccessToken", mock.Anything, "some-user-id").Return(mockToken, nil)
+		uhDeps.securityTokenUseCase.
+			On("GenRefreshToken", mock.Anything, "some-user-id", mock.Anything, mock.Anything).
+			Return(mockToken, nil)
+
+		ctx, rec := genJSONContext(echo.POST, "{\"mfa_token\":\"some-pending-token\",\"code\":\"123456\"}")
+
+		if assert.NoError(t, uh.LoginTwoFactor(ctx)) {
+			assert.Equal(t, http.StatusOK, rec.Code)
+			assert.Equal(t, "REFRESH_TOKEN=some-token; Path=/; Domain=example.com; Max-Age=3600; HttpOnly", rec.Header().Get("Set-Cookie"))
+			assert.Equal(t, "{\"data\":{\"access_token\":\"some-token\"}}\n", rec.Body.String())
+		}
+	})
+
+	t.Run("it should return error", func(t *testing.T) {
+		uh, uhDeps := genMockUserHandler()
+		uhDeps.validatorService.
+			On("ValidateTwoFactorLoginParams", "", "").
+			Return(map[string]string{"code_required": "code is required"})
+
+		ctx, rec := genJSONContext(echo.POST, "{}")
+
+		if assert.NoError(t, uh.LoginTwoFactor(ctx)) {
+			assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+		}
+	})
+
+	t.Run("it should return error", func(t *testing.T) {
+		uh, uhDeps := genMockUserHandler()
+		uhDeps.validatorService.
+			On("ValidateTwoFactorLoginParams", mock.Anything, mock.Anything).
+			Return(make(map[string]string))
+		uhDeps.twoFactorUseCase.
+			On("VerifyLogin", mock.Anything, mock.Anything, mock.Anything).
+			Return("", terr.NewUnAuthorizedError("invalid code"))
+
+		ctx, rec := genJSONContext(echo.POST, "{\"mfa_token\":\"some-pending-token\",\"code\":\"000000\"}")
+
+		if assert.NoError(t, uh.LoginTwoFactor(ctx)) {
+			assert.Equal(t, http.StatusUnauthorized, rec.Code)
+			assert.Equal(t, "{\"data\":null,\"error\":\"invalid code\"}\n", rec.Body.String())
+			uhDeps.securityTokenUseCase.AssertNotCalled(t, "GenAccessToken", mock.Anything, mock.Anything)
+		}
+	})
+
+	t.Run("it should return error", func(t *testing.T) {
+		uh, uhDeps := genMockUserHandler()
+		uhDeps.validatorService.
+			On("ValidateTwoFactorLoginParams", mock.Anything, mock.Anything).
+			Return(make(map[string]string))
+		uhDeps.twoFactorUseCase.
+			On("VerifyLogin", mock.Anything, mock.Anything, mock.Anything).
+			Return("", terr.NewTooManyAttemptsError("too many failed codes, try again later", time.Minute))
+
+		ctx, rec := genJSONContext(echo.POST, "{\"mfa_token\":\"some-pending-token\",\"code\":\"000000\"}")
+
+		if assert.NoError(t, uh.LoginTwoFactor(ctx)) {
+			assert.Equal(t, http.StatusTooManyRequests, rec.Code)
+			assert.Equal(t, "60", rec.Header().Get("Retry-After"))
+		}
+	})
+
+	t.Run("it should return error", func(t *testing.T) {
+		uh, uhDeps := genMockUserHandler()
+		uhDeps.validatorService.
+			On("ValidateTwoFactorLoginParams", mock.Anything, mock.Anything).
+			Return(make(map[string]string))
+		uhDeps.twoFactorUseCase.
+			On("VerifyLogin", mock.Anything, mock.Anything, mock.Anything).
+			Return("", errors.New("some error"))
+
+		ctx, rec := genJSONContext(echo.POST, "{\"mfa_token\":\"some-pending-token\",\"code\":\"000000\"}")
+
+		if assert.NoError(t, uh.LoginTwoFactor(ctx)) {
+			assert.Equal(t, http.StatusInternalServerError, rec.Code)
+		}
+	})
 }
 
 func TestRefreshAccessToken(t *testing.T) {
@@ -427,16 +719,26 @@ func TestRefreshAccessToken(t *testing.T) {
 		UpdatedAt: time.Time{},
 	}
 
+	mockRefreshToken := auth.SecurityToken{
+		ID:        "some-id",
+		UserID:    "some-user-id",
+		Token:     "some-new-refresh-token",
+		Type:      auth.RefreshTokenType,
+		FamilyID:  "some-id",
+		CreatedAt: time.Time{},
+		UpdatedAt: time.Time{},
+	}
+
 	t.Run("it should succeed", func(t *testing.T) {
 		uh, uhDeps := genMockUserHandler()
 		uhDeps.securityService.
 			On("GetAndValidateRefreshToken", mock.Anything).
 			Return(mockTokenMeta, nil)
 		uhDeps.securityTokenUseCase.
-			On("IsRefreshTokenStored", mock.Anything).
-			Return(true)
+			On("RotateRefreshToken", mock.Anything, mock.Anything).
+			Return(mockRefreshToken, nil)
 		uhDeps.securityTokenUseCase.
-			On("GenAccessToken", mock.AnythingOfType("string")).
+			On("GenAccessToken", mock.Anything, mock.AnythingOfType("string")).
 			Return(mockToken, nil)
 
 		e := echo.New()
@@ -448,6 +750,7 @@ func TestRefreshAccessToken(t *testing.T) {
 
 		if assert.NoError(t, uh.RefreshAccessToken(ctx)) {
 			assert.Equal(t, http.StatusOK, rec.Code)
+			assert.Equal(t, "REFRESH_TOKEN=some-new-refresh-token; Path=/; Max-Age=3600; HttpOnly", rec.Header().Get("Set-Cookie"))
 			assert.Equal(t, "{\"data\":{\"access_token\":\"some-token\"}}\n", rec.Body.String())
 		}
 	})
@@ -472,14 +775,55 @@ func TestRefreshAccessToken(t *testing.T) {
 		}
 	})
 
+	t.Run("it should return error", func(t *testing.T) {
+		uh, uhDeps := genMockUserHandler()
+		uhDeps.securityService.
+			On("GetAndValidateRefreshToken", mock.Anything).
+			Return(auth.TokenMetadata{}, terr.NewExpiredTokenError("token is expired"))
+
+		e := echo.New()
+		req, err := http.NewRequest(echo.PATCH, "/some-url", strings.NewReader(""))
+		assert.NoError(t, err)
+
+		rec := httptest.NewRecorder()
+		ctx := e.NewContext(req, rec)
+
+		if assert.NoError(t, uh.RefreshAccessToken(ctx)) {
+			assert.Equal(t, http.StatusUnauthorized, rec.Code)
+			assert.Equal(t, "{\"data\":null,\"error\":\"invalid refresh token: token is expired\"}\n", rec.Body.String())
+		}
+	})
+
+	t.Run("it should return error on refresh token of an oauth client", func(t *testing.T) {
+		uh, uhDeps := genMockUserHandler()
+		clientTokenMeta := mockTokenMeta
+		clientTokenMeta.ClientID = "some-client-id"
+		uhDeps.securityService.
+			On("GetAndValidateRefreshToken", mock.Anything).
+			Return(clientTokenMeta, nil)
+
+		e := echo.New()
+		req, err := http.NewRequest(echo.PATCH, "/some-url", strings.NewReader(""))
+		assert.NoError(t, err)
+
+		rec := httptest.NewRecorder()
+		ctx := e.NewContext(req, rec)
+
+		if assert.NoError(t, uh.RefreshAccessToken(ctx)) {
+			assert.Equal(t, http.StatusUnauthorized, rec.Code)
+			assert.Equal(t, "{\"data\":null,\"error\":\"invalid refresh token\"}\n", rec.Body.String())
+			uhDeps.securityTokenUseCase.AssertNotCalled(t, "RotateRefreshToken", mock.Anything, mock.Anything)
+		}
+	})
+
 	t.Run("it should return error", func(t *testing.T) {
 		uh, uhDeps := genMockUserHandler()
 		uhDeps.securityService.
 			On("GetAndValidateRefreshToken", mock.Anything).
 			Return(mockTokenMeta, nil)
 		uhDeps.securityTokenUseCase.
-			On("IsRefreshTokenStored", mock.Anything).
-			Return(false)
+			On("RotateRefreshToken", mock.Anything, mock.Anything).
+			Return(auth.SecurityToken{}, terr.NewUnAuthorizedError("invalid refresh token"))
 
 		e := echo.New()
 		req, err := http.NewRequest(echo.PATCH, "/some-url", strings.NewReader(""))
@@ -490,21 +834,68 @@ func TestRefreshAccessToken(t *testing.T) {
 
 		if assert.NoError(t, uh.RefreshAccessToken(ctx)) {
 			assert.Equal(t, http.StatusUnauthorized, rec.Code)
+			assert.Equal(t, "REFRESH_TOKEN=; Path=/; HttpOnly", rec.Header().Get("Set-Cookie"))
 			assert.Equal(t, "{\"data\":null,\"error\":\"invalid refresh token\"}\n", rec.Body.String())
 		}
 	})
 
+	t.Run("it should reject a replayed refresh token", func(t *testing.T) {
+		uh, uhDeps := genMockUserHandler()
+		uhDeps.securityService.
+			On("GetAndValidateRefreshToken", mock.Anything).
+			Return(mockTokenMeta, nil)
+		uhDeps.securityTokenUseCase.
+			On("RotateRefreshToken", mock.Anything, mock.Anything).
+			Return(auth.SecurityToken{}, terr.NewUnAuthorizedError("refresh token reuse detected"))
+
+		e := echo.New()
+		req, err := http.NewRequest(echo.PATCH, "/some-url", strings.NewReader(""))
+		assert.NoError(t, err)
+
+		rec := httptest.NewRecorder()
+		ctx := e.NewContext(req, rec)
+
+		if assert.NoError(t, uh.RefreshAccessToken(ctx)) {
+			assert.Equal(t, http.StatusUnauthorized, rec.Code)
+			assert.Equal(t, "REFRESH_TOKEN=; Path=/; HttpOnly", rec.Header().Get("Set-Cookie"))
+			assert.Equal(t, "{\"data\":null,\"error\":\"refresh token reuse detected\"}\n", rec.Body.String())
+			uhDeps.securityTokenUseCase.AssertNotCalled(t, "GenAccessToken", mock.Anything, mock.Anything)
+		}
+	})
+
 	t.Run("it should return error", func(t *testing.T) {
 		uh, uhDeps := genMockUserHandler()
 		uhDeps.securityService.
 			On("GetAndValidateRefreshToken", mock.Anything).
 			Return(mockTokenMeta, nil)
 		uhDeps.securityTokenUseCase.
-			On("IsRefreshTokenStored", mock.Anything).
-			Return(true)
+			On("RotateRefreshToken", mock.Anything, mock.Anything).
+			Return(auth.SecurityToken{}, errors.New("rotate refresh token error"))
+
+		e := echo.New()
+		req, err := http.NewRequest(echo.PATCH, "/some-url", strings.NewReader(""))
+		assert.NoError(t, err)
+
+		rec := httptest.NewRecorder()
+		ctx := e.NewContext(req, rec)
+
+		if assert.NoError(t, uh.RefreshAccessToken(ctx)) {
+			assert.Equal(t, http.StatusInternalServerError, rec.Code)
+			assert.Equal(t, "{\"data\":null,\"error\":\"internal server error\"}\n", rec.Body.String())
+		}
+	})
+
+	t.Run("it should return error", func(t *testing.T) {
+		uh, uhDeps := genMockUserHandler()
+		uhDeps.securityService.
+			On("GetAndValidateRefreshToken", mock.Anything).
+			Return(mockTokenMeta, nil)
+		uhDeps.securityTokenUseCase.
+			On("RotateRefreshToken", mock.Anything, mock.Anything).
+			Return(mockRefreshToken, nil)
 		mockError := errors.New("gen access token error")
 		uhDeps.securityTokenUseCase.
-			On("GenAccessToken", mock.AnythingOfType("string")).
+			On("GenAccessToken", mock.Anything, mock.AnythingOfType("string")).
 			Return(auth.SecurityToken{}, mockError)
 
 		e := echo.New()
@@ -537,7 +928,7 @@ func TestGetUser(t *testing.T) {
 	t.Run("it should succeed", func(t *testing.T) {
 		uh, uhDeps := genMockUserHandler()
 		uhDeps.userUseCase.
-			On("GetUserByID", mock.Anything).
+			On("GetUserByID", mock.Anything, mock.Anything).
 			Return(mockUser, nil)
 		uhDeps.presenterService.
 			On("PresentUser", mock.Anything).
@@ -563,12 +954,13 @@ func TestGetUser(t *testing.T) {
 		ctx.SetPath("some-url/:id")
 		ctx.SetParamNames("id")
 		ctx.SetParamValues(mockUser.ID)
+		cmw.SetPrincipal(ctx, auth.TokenMetadata{UserID: mockUser.ID})
 
 		if assert.NoError(t, uh.GetUser(ctx)) {
 			assert.Equal(t, http.StatusOK, rec.Code)
 			assert.Equal(
 				t,
-				"{\"data\":{\"user\":{\"id\":\"some-id\",\"first_name\":\"first\",\"last_name\":\"last\",\"email_address\":\"some@email.com\",\"active\":true,\"created_at\":\"1970-01-01T00:00:00Z\",\"updated_at\":\"1970-01-01T00:00:00Z\"}}}\n",
+				"{\"data\":{\"user\":{\"id\":\"some-id\",\"first_name\":\"first\",\"last_name\":\"last\",\"email_address\":\"some@email.com\",\"active\":true,\"email_verified\":false,\"created_at\":\"1970-01-01T00:00:00Z\",\"updated_at\":\"1970-01-01T00:00:00Z\"}}}\n",
 				rec.Body.String(),
 			)
 		}
@@ -578,7 +970,7 @@ func TestGetUser(t *testing.T) {
 		uh, uhDeps := genMockUserHandler()
 		mockError := terr.NewNotFoundError("get user by id not found error")
 		uhDeps.userUseCase.
-			On("GetUserByID", mock.Anything).
+			On("GetUserByID", mock.Anything, mock.Anything).
 			Return(auth.User{}, mockError)
 
 		e := echo.New()
@@ -593,6 +985,7 @@ func TestGetUser(t *testing.T) {
 		ctx.SetPath("some-url/:id")
 		ctx.SetParamNames("id")
 		ctx.SetParamValues(mockUser.ID)
+		cmw.SetPrincipal(ctx, auth.TokenMetadata{UserID: mockUser.ID})
 
 		if assert.NoError(t, uh.GetUser(ctx)) {
 			assert.Equal(t, http.StatusNotFound, rec.Code)
@@ -604,7 +997,7 @@ func TestGetUser(t *testing.T) {
 		uh, uhDeps := genMockUserHandler()
 		mockError := errors.New("any get user by id error")
 		uhDeps.userUseCase.
-			On("GetUserByID", mock.Anything).
+			On("GetUserByID", mock.Anything, mock.Anything).
 			Return(auth.User{}, mockError)
 
 		e := echo.New()
@@ -619,28 +1012,188 @@ func TestGetUser(t *testing.T) {
 		ctx.SetPath("some-url/:id")
 		ctx.SetParamNames("id")
 		ctx.SetParamValues(mockUser.ID)
+		cmw.SetPrincipal(ctx, auth.TokenMetadata{UserID: mockUser.ID})
 
 		if assert.NoError(t, uh.GetUser(ctx)) {
 			assert.Equal(t, http.StatusInternalServerError, rec.Code)
 			assert.Equal(t, "{\"data\":null,\"error\":\"internal server error\"}\n", rec.Body.String())
 		}
 	})
-}
-
-func TestLogout(t *testing.T) {
-	mockTokenMeta := auth.TokenMetadata{
-		UserID: "some-user-id",
-		Type:   "some-token-type",
-		Token:  "some-token",
-	}
 
-	t.Run("it should succeed", func(t *testing.T) {
+	t.Run("it should succeed for an admin", func(t *testing.T) {
 		uh, uhDeps := genMockUserHandler()
-		uhDeps.securityService.
-			On("GetAndValidateRefreshToken", mock.Anything).
-			Return(mockTokenMeta, nil)
+		uhDeps.userUseCase.
+			On("GetUserByID", mock.Anything, mockUser.ID).
+			Return(mockUser, nil)
+		uhDeps.presenterService.
+			On("PresentUser", mock.Anything).
+			Return(auth.PresentedUser{ID: mockUser.ID})
+
+		e := echo.New()
+		req, err := http.NewRequest(echo.GET, "/some-url/"+mockUser.ID, strings.NewReader(""))
+		assert.NoError(t, err)
+
+		rec := httptest.NewRecorder()
+		ctx := e.NewContext(req, rec)
+		ctx.SetPath("some-url/:id")
+		ctx.SetParamNames("id")
+		ctx.SetParamValues(mockUser.ID)
+		cmw.SetPrincipal(ctx, auth.TokenMetadata{UserID: "some-admin-id", Roles: []string{auth.AdminRole}})
+
+		if assert.NoError(t, uh.GetUser(ctx)) {
+			assert.Equal(t, http.StatusOK, rec.Code)
+		}
+	})
+
+	t.Run("it should return error", func(t *testing.T) {
+		uh, uhDeps := genMockUserHandler()
+
+		e := echo.New()
+		req, err := http.NewRequest(echo.GET, "/some-url/"+mockUser.ID, strings.NewReader(""))
+		assert.NoError(t, err)
+
+		rec := httptest.NewRecorder()
+		ctx := e.NewContext(req, rec)
+		ctx.SetPath("some-url/:id")
+		ctx.SetParamNames("id")
+		ctx.SetParamValues(mockUser.ID)
+		cmw.SetPrincipal(ctx, auth.TokenMetadata{UserID: "some-other-user-id"})
+
+		if assert.NoError(t, uh.GetUser(ctx)) {
+			assert.Equal(t, http.StatusForbidden, rec.Code)
+			assert.Equal(t, "{\"data\":null,\"error\":\"forbidden\"}\n", rec.Body.String())
+			uhDeps.userUseCase.AssertNotCalled(t, "GetUserByID", mock.Anything, mock.Anything)
+		}
+	})
+
+	t.Run("it should return error", func(t *testing.T) {
+		uh, _ := genMockUserHandler()
+
+		e := echo.New()
+		req, err := http.NewRequest(echo.GET, "/some-url/"+mockUser.ID, strings.NewReader(""))
+		assert.NoError(t, err)
+
+		rec := httptest.NewRecorder()
+		ctx := e.NewContext(req, rec)
+		ctx.SetPath("some-url/:id")
+		ctx.SetParamNames("id")
+		ctx.SetParamValues(mockUser.ID)
+
+		if assert.NoError(t, uh.GetUser(ctx)) {
+			assert.Equal(t, http.StatusUnauthorized, rec.Code)
+			assert.Equal(t, "{\"data\":null,\"error\":\"invalid token\"}\n", rec.Body.String())
+		}
+	})
+}
+
+func TestGetMe(t *testing.T) {
+	lo, _ := time.LoadLocation("UTC")
+	mockUser := auth.User{
+		ID:           "some-id",
+		FirstName:    "first",
+		LastName:     "last",
+		EmailAddress: "some@email.com",
+		Password:     "some-password",
+		Active:       true,
+		CreatedAt:    time.Unix(0, 0).In(lo),
+		UpdatedAt:    time.Unix(0, 0).In(lo),
+	}
+
+	t.Run("it should succeed", func(t *testing.T) {
+		uh, uhDeps := genMockUserHandler()
+		uhDeps.userUseCase.
+			On("GetUserByID", mock.Anything, mockUser.ID).
+			Return(mockUser, nil)
+		uhDeps.presenterService.
+			On("PresentUser", mock.Anything).
+			Return(auth.PresentedUser{
+				ID:           mockUser.ID,
+				FirstName:    mockUser.FirstName,
+				LastName:     mockUser.LastName,
+				EmailAddress: mockUser.EmailAddress,
+				Active:       mockUser.Active,
+				CreatedAt:    mockUser.CreatedAt,
+				UpdatedAt:    mockUser.UpdatedAt,
+			})
+
+		e := echo.New()
+		req, err := http.NewRequest(echo.GET, "/some-url", strings.NewReader(""))
+		assert.NoError(t, err)
+
+		rec := httptest.NewRecorder()
+		ctx := e.NewContext(req, rec)
+		cmw.SetPrincipal(ctx, auth.TokenMetadata{UserID: mockUser.ID})
+
+		if assert.NoError(t, uh.GetMe(ctx)) {
+			assert.Equal(t, http.StatusOK, rec.Code)
+			assert.Equal(
+				t,
+				"{\"data\":{\"user\":{\"id\":\"some-id\",\"first_name\":\"first\",\"last_name\":\"last\",\"email_address\":\"some@email.com\",\"active\":true,\"email_verified\":false,\"created_at\":\"1970-01-01T00:00:00Z\",\"updated_at\":\"1970-01-01T00:00:00Z\"}}}\n",
+				rec.Body.String(),
+			)
+		}
+	})
+
+	t.Run("it should return error", func(t *testing.T) {
+		uh, _ := genMockUserHandler()
+
+		e := echo.New()
+		req, err := http.NewRequest(echo.GET, "/some-url", strings.NewReader(""))
+		assert.NoError(t, err)
+
+		rec := httptest.NewRecorder()
+		ctx := e.NewContext(req, rec)
+
+		if assert.NoError(t, uh.GetMe(ctx)) {
+			assert.Equal(t, http.StatusUnauthorized, rec.Code)
+			assert.Equal(t, "{\"data\":null,\"error\":\"invalid token\"}\n", rec.Body.String())
+		}
+	})
+
+	t.Run("it should return error", func(t *testing.T) {
+		uh, uhDeps := genMockUserHandler()
+		uhDeps.userUseCase.
+			On("GetUserByID", mock.Anything, mockUser.ID).
+			Return(auth.User{}, terr.NewNotFoundError("user not found"))
+
+		e := echo.New()
+		req, err := http.NewRequest(echo.GET, "/some-url", strings.NewReader(""))
+		assert.NoError(t, err)
+
+		rec := httptest.NewRecorder()
+		ctx := e.NewContext(req, rec)
+		cmw.SetPrincipal(ctx, auth.TokenMetadata{UserID: mockUser.ID})
+
+		if assert.NoError(t, uh.GetMe(ctx)) {
+			assert.Equal(t, http.StatusNotFound, rec.Code)
+			assert.Equal(t, "{\"data\":null,\"error\":\"user not found\"}\n", rec.Body.String())
+		}
+	})
+}
+
+func TestLogout(t *testing.T) {
+	mockAccessTokenMeta := auth.TokenMetadata{
+		ID:     "some-token-id",
+		UserID: "some-user-id",
+		Type:   auth.AccessTokenType,
+		Token:  "some-access-token",
+	}
+	mockTokenMeta := auth.TokenMetadata{
+		UserID: "some-user-id",
+		Type:   "some-token-type",
+		Token:  "some-token",
+	}
+
+	t.Run("it should succeed", func(t *testing.T) {
+		uh, uhDeps := genMockUserHandler()
+		uhDeps.securityTokenUseCase.
+			On("RevokeAccessToken", mock.Anything, &mockAccessTokenMeta).
+			Return(nil)
+		uhDeps.securityService.
+			On("GetAndValidateRefreshToken", mock.Anything).
+			Return(mockTokenMeta, nil)
 		uhDeps.securityTokenUseCase.
-			On("RemoveRefreshToken", mock.Anything).
+			On("RemoveRefreshToken", mock.Anything, mock.Anything).
 			Return(nil)
 
 		e := echo.New()
@@ -649,6 +1202,7 @@ func TestLogout(t *testing.T) {
 
 		rec := httptest.NewRecorder()
 		ctx := e.NewContext(req, rec)
+		cmw.SetPrincipal(ctx, mockAccessTokenMeta)
 
 		if assert.NoError(t, uh.Logout(ctx)) {
 			assert.Equal(t, http.StatusOK, rec.Code)
@@ -659,6 +1213,9 @@ func TestLogout(t *testing.T) {
 
 	t.Run("it should return error", func(t *testing.T) {
 		uh, uhDeps := genMockUserHandler()
+		uhDeps.securityTokenUseCase.
+			On("RevokeAccessToken", mock.Anything, &mockAccessTokenMeta).
+			Return(nil)
 		mockError := errors.New("get and validate refresh token error")
 		uhDeps.securityService.
 			On("GetAndValidateRefreshToken", mock.Anything).
@@ -670,6 +1227,7 @@ func TestLogout(t *testing.T) {
 
 		rec := httptest.NewRecorder()
 		ctx := e.NewContext(req, rec)
+		cmw.SetPrincipal(ctx, mockAccessTokenMeta)
 
 		if assert.NoError(t, uh.Logout(ctx)) {
 			assert.Equal(t, http.StatusUnauthorized, rec.Code)
@@ -679,11 +1237,41 @@ func TestLogout(t *testing.T) {
 
 	t.Run("it should return error", func(t *testing.T) {
 		uh, uhDeps := genMockUserHandler()
+		uhDeps.securityTokenUseCase.
+			On("RevokeAccessToken", mock.Anything, &mockAccessTokenMeta).
+			Return(nil)
+		uhDeps.securityService.
+			On("GetAndValidateRefreshToken", mock.Anything).
+			Return(mockTokenMeta, nil)
+		uhDeps.securityTokenUseCase.
+			On("RemoveRefreshToken", mock.Anything, mock.Anything).
+			Return(terr.NewNotFoundError("token not found"))
+
+		e := echo.New()
+		req, err := http.NewRequest(echo.DELETE, "/some-url", strings.NewReader(""))
+		assert.NoError(t, err)
+
+		rec := httptest.NewRecorder()
+		ctx := e.NewContext(req, rec)
+		cmw.SetPrincipal(ctx, mockAccessTokenMeta)
+
+		if assert.NoError(t, uh.Logout(ctx)) {
+			assert.Equal(t, http.StatusUnauthorized, rec.Code)
+			assert.Equal(t, "REFRESH_TOKEN=; Path=/; HttpOnly", rec.Header().Get("Set-Cookie"))
+			assert.Equal(t, "{\"data\":null,\"error\":\"invalid refresh token\"}\n", rec.Body.String())
+		}
+	})
+
+	t.Run("it should return error", func(t *testing.T) {
+		uh, uhDeps := genMockUserHandler()
+		uhDeps.securityTokenUseCase.
+			On("RevokeAccessToken", mock.Anything, &mockAccessTokenMeta).
+			Return(nil)
 		uhDeps.securityService.
 			On("GetAndValidateRefreshToken", mock.Anything).
 			Return(mockTokenMeta, nil)
 		uhDeps.securityTokenUseCase.
-			On("RemoveRefreshToken", mock.Anything).
+			On("RemoveRefreshToken", mock.Anything, mock.Anything).
 			Return(errors.New("remove refresh token error"))
 
 		e := echo.New()
@@ -692,10 +1280,1055 @@ func TestLogout(t *testing.T) {
 
 		rec := httptest.NewRecorder()
 		ctx := e.NewContext(req, rec)
+		cmw.SetPrincipal(ctx, mockAccessTokenMeta)
+
+		if assert.NoError(t, uh.Logout(ctx)) {
+			assert.Equal(t, http.StatusInternalServerError, rec.Code)
+			assert.Equal(t, "{\"data\":null,\"error\":\"internal server error\"}\n", rec.Body.String())
+		}
+	})
+
+	t.Run("it should return error", func(t *testing.T) {
+		uh, _ := genMockUserHandler()
+
+		e := echo.New()
+		req, err := http.NewRequest(echo.DELETE, "/some-url", strings.NewReader(""))
+		assert.NoError(t, err)
+
+		rec := httptest.NewRecorder()
+		ctx := e.NewContext(req, rec)
+
+		if assert.NoError(t, uh.Logout(ctx)) {
+			assert.Equal(t, http.StatusUnauthorized, rec.Code)
+			assert.Equal(t, "{\"data\":null,\"error\":\"invalid token\"}\n", rec.Body.String())
+		}
+	})
+
+	t.Run("it should return error", func(t *testing.T) {
+		uh, uhDeps := genMockUserHandler()
+		uhDeps.securityTokenUseCase.
+			On("RevokeAccessToken", mock.Anything, mock.Anything).
+			Return(errors.New("could not revoke access token"))
+
+		e := echo.New()
+		req, err := http.NewRequest(echo.DELETE, "/some-url", strings.NewReader(""))
+		assert.NoError(t, err)
+
+		rec := httptest.NewRecorder()
+		ctx := e.NewContext(req, rec)
+		cmw.SetPrincipal(ctx, mockAccessTokenMeta)
 
 		if assert.NoError(t, uh.Logout(ctx)) {
 			assert.Equal(t, http.StatusInternalServerError, rec.Code)
 			assert.Equal(t, "{\"data\":null,\"error\":\"internal server error\"}\n", rec.Body.String())
+			uhDeps.securityTokenUseCase.AssertNotCalled(t, "RemoveRefreshToken", mock.Anything, mock.Anything)
+		}
+	})
+}
+
+func TestGetSessions(t *testing.T) {
+	lo, _ := time.LoadLocation("UTC")
+	mockTokenMeta := auth.TokenMetadata{
+		UserID: "some-user-id",
+		Type:   auth.AccessTokenType,
+		Token:  "some-token",
+	}
+	mockSessions := []auth.SecurityToken{
+		{
+			ID:         "some-id",
+			UserID:     "some-user-id",
+			Token:      "some-hashed-token",
+			Type:       auth.RefreshTokenType,
+			FamilyID:   "some-id",
+			UserAgent:  "some-user-agent",
+			IPAddress:  "127.0.0.1",
+			LastUsedAt: time.Unix(0, 0).In(lo),
+			CreatedAt:  time.Unix(0, 0).In(lo),
+			UpdatedAt:  time.Unix(0, 0).In(lo),
+		},
+	}
+
+	t.Run("it should succeed", func(t *testing.T) {
+		uh, uhDeps := genMockUserHandler()
+		uhDeps.securityTokenUseCase.
+			On("GetSessions", mock.Anything, mockTokenMeta.UserID).
+			Return(mockSessions, nil)
+		uhDeps.presenterService.
+			On("PresentSessions", mockSessions).
+			Return([]auth.PresentedSession{
+				{
+					ID:         mockSessions[0].FamilyID,
+					UserAgent:  mockSessions[0].UserAgent,
+					IPAddress:  mockSessions[0].IPAddress,
+					LastUsedAt: mockSessions[0].LastUsedAt,
+					CreatedAt:  mockSessions[0].CreatedAt,
+				},
+			})
+
+		e := echo.New()
+		req, err := http.NewRequest(echo.GET, "/some-url", strings.NewReader(""))
+		assert.NoError(t, err)
+
+		rec := httptest.NewRecorder()
+		ctx := e.NewContext(req, rec)
+		cmw.SetPrincipal(ctx, mockTokenMeta)
+
+		if assert.NoError(t, uh.GetSessions(ctx)) {
+			assert.Equal(t, http.StatusOK, rec.Code)
+			assert.Equal(
+				t,
+				"{\"data\":{\"sessions\":[{\"id\":\"some-id\",\"user_agent\":\"some-user-agent\",\"ip_address\":\"127.0.0.1\",\"last_used_at\":\"1970-01-01T00:00:00Z\",\"created_at\":\"1970-01-01T00:00:00Z\"}]}}\n",
+				rec.Body.String(),
+			)
+			assert.NotContains(t, rec.Body.String(), "some-hashed-token")
+		}
+	})
+
+	t.Run("it should return error", func(t *testing.T) {
+		uh, _ := genMockUserHandler()
+
+		e := echo.New()
+		req, err := http.NewRequest(echo.GET, "/some-url", strings.NewReader(""))
+		assert.NoError(t, err)
+
+		rec := httptest.NewRecorder()
+		ctx := e.NewContext(req, rec)
+
+		if assert.NoError(t, uh.GetSessions(ctx)) {
+			assert.Equal(t, http.StatusUnauthorized, rec.Code)
+			assert.Equal(t, "{\"data\":null,\"error\":\"invalid token\"}\n", rec.Body.String())
+		}
+	})
+
+	t.Run("it should return error", func(t *testing.T) {
+		uh, uhDeps := genMockUserHandler()
+		uhDeps.securityTokenUseCase.
+			On("GetSessions", mock.Anything, mock.Anything).
+			Return(nil, errors.New("get sessions error"))
+
+		e := echo.New()
+		req, err := http.NewRequest(echo.GET, "/some-url", strings.NewReader(""))
+		assert.NoError(t, err)
+
+		rec := httptest.NewRecorder()
+		ctx := e.NewContext(req, rec)
+		cmw.SetPrincipal(ctx, mockTokenMeta)
+
+		if assert.NoError(t, uh.GetSessions(ctx)) {
+			assert.Equal(t, http.StatusInternalServerError, rec.Code)
+			assert.Equal(t, "{\"data\":null,\"error\":\"internal server error\"}\n", rec.Body.String())
+		}
+	})
+}
+
+func TestRemoveSession(t *testing.T) {
+	mockTokenMeta := auth.TokenMetadata{
+		UserID: "some-user-id",
+		Type:   auth.AccessTokenType,
+		Token:  "some-token",
+	}
+
+	t.Run("it should succeed", func(t *testing.T) {
+		uh, uhDeps := genMockUserHandler()
+		uhDeps.securityTokenUseCase.
+			On("RemoveSession", mock.Anything, mockTokenMeta.UserID, "some-session-id").
+			Return(nil)
+
+		e := echo.New()
+		req, err := http.NewRequest(echo.DELETE, "/some-url/some-session-id", strings.NewReader(""))
+		assert.NoError(t, err)
+
+		rec := httptest.NewRecorder()
+		ctx := e.NewContext(req, rec)
+		cmw.SetPrincipal(ctx, mockTokenMeta)
+		ctx.SetPath("some-url/:session_id")
+		ctx.SetParamNames("session_id")
+		ctx.SetParamValues("some-session-id")
+
+		if assert.NoError(t, uh.RemoveSession(ctx)) {
+			assert.Equal(t, http.StatusOK, rec.Code)
+			assert.Equal(t, "{\"data\":null}\n", rec.Body.String())
+		}
+	})
+
+	t.Run("it should return error", func(t *testing.T) {
+		uh, _ := genMockUserHandler()
+
+		e := echo.New()
+		req, err := http.NewRequest(echo.DELETE, "/some-url/some-session-id", strings.NewReader(""))
+		assert.NoError(t, err)
+
+		rec := httptest.NewRecorder()
+		ctx := e.NewContext(req, rec)
+
+		if assert.NoError(t, uh.RemoveSession(ctx)) {
+			assert.Equal(t, http.StatusUnauthorized, rec.Code)
+			assert.Equal(t, "{\"data\":null,\"error\":\"invalid token\"}\n", rec.Body.String())
+		}
+	})
+
+	t.Run("it should return error", func(t *testing.T) {
+		uh, uhDeps := genMockUserHandler()
+		uhDeps.securityTokenUseCase.
+			On("RemoveSession", mock.Anything, mock.Anything, mock.Anything).
+			Return(terr.NewNotFoundError("token not found"))
+
+		e := echo.New()
+		req, err := http.NewRequest(echo.DELETE, "/some-url/some-session-id", strings.NewReader(""))
+		assert.NoError(t, err)
+
+		rec := httptest.NewRecorder()
+		ctx := e.NewContext(req, rec)
+		cmw.SetPrincipal(ctx, mockTokenMeta)
+		ctx.SetPath("some-url/:session_id")
+		ctx.SetParamNames("session_id")
+		ctx.SetParamValues("some-session-id")
+
+		if assert.NoError(t, uh.RemoveSession(ctx)) {
+			assert.Equal(t, http.StatusNotFound, rec.Code)
+			assert.Equal(t, "{\"data\":null,\"error\":\"session not found\"}\n", rec.Body.String())
+		}
+	})
+
+	t.Run("it should return error", func(t *testing.T) {
+		uh, uhDeps := genMockUserHandler()
+		uhDeps.securityTokenUseCase.
+			On("RemoveSession", mock.Anything, mock.Anything, mock.Anything).
+			Return(errors.New("remove session error"))
+
+		e := echo.New()
+		req, err := http.NewRequest(echo.DELETE, "/some-url/some-session-id", strings.NewReader(""))
+		assert.NoError(t, err)
+
+		rec := httptest.NewRecorder()
+		ctx := e.NewContext(req, rec)
+		cmw.SetPrincipal(ctx, mockTokenMeta)
+
+		if assert.NoError(t, uh.RemoveSession(ctx)) {
+			assert.Equal(t, http.StatusInternalServerError, rec.Code)
+			assert.Equal(t, "{\"data\":null,\"error\":\"internal server error\"}\n", rec.Body.String())
+		}
+	})
+}
+
+func TestRemoveSessions(t *testing.T) {
+	mockTokenMeta := auth.TokenMetadata{
+		UserID: "some-user-id",
+		Type:   auth.AccessTokenType,
+		Token:  "some-token",
+	}
+
+	t.Run("it should succeed", func(t *testing.T) {
+		uh, uhDeps := genMockUserHandler()
+		uhDeps.securityTokenUseCase.
+			On("RemoveSessions", mock.Anything, mockTokenMeta.UserID).
+			Return(nil)
+
+		e := echo.New()
+		req, err := http.NewRequest(echo.DELETE, "/some-url", strings.NewReader(""))
+		assert.NoError(t, err)
+
+		rec := httptest.NewRecorder()
+		ctx := e.NewContext(req, rec)
+		cmw.SetPrincipal(ctx, mockTokenMeta)
+
+		if assert.NoError(t, uh.RemoveSessions(ctx)) {
+			assert.Equal(t, http.StatusOK, rec.Code)
+			assert.Equal(t, "REFRESH_TOKEN=; Path=/; HttpOnly", rec.Header().Get("Set-Cookie"))
+			assert.Equal(t, "{\"data\":null}\n", rec.Body.String())
+		}
+	})
+
+	t.Run("it should return error", func(t *testing.T) {
+		uh, _ := genMockUserHandler()
+
+		e := echo.New()
+		req, err := http.NewRequest(echo.DELETE, "/some-url", strings.NewReader(""))
+		assert.NoError(t, err)
+
+		rec := httptest.NewRecorder()
+		ctx := e.NewContext(req, rec)
+
+		if assert.NoError(t, uh.RemoveSessions(ctx)) {
+			assert.Equal(t, http.StatusUnauthorized, rec.Code)
+			assert.Equal(t, "{\"data\":null,\"error\":\"invalid token\"}\n", rec.Body.String())
+		}
+	})
+
+	t.Run("it should return error", func(t *testing.T) {
+		uh, uhDeps := genMockUserHandler()
+		uhDeps.securityTokenUseCase.
+			On("RemoveSessions", mock.Anything, mock.Anything).
+			Return(errors.New("remove sessions error"))
+
+		e := echo.New()
+		req, err := http.NewRequest(echo.DELETE, "/some-url", strings.NewReader(""))
+		assert.NoError(t, err)
+
+		rec := httptest.NewRecorder()
+		ctx := e.NewContext(req, rec)
+		cmw.SetPrincipal(ctx, mockTokenMeta)
+
+		if assert.NoError(t, uh.RemoveSessions(ctx)) {
+			assert.Equal(t, http.StatusInternalServerError, rec.Code)
+			assert.Equal(t, "{\"data\":null,\"error\":\"internal server error\"}\n", rec.Body.String())
+		}
+	})
+}
+
+func TestVerifyEmail(t *testing.T) {
+	t.Run("it should succeed", func(t *testing.T) {
+		uh, uhDeps := genMockUserHandler()
+		uhDeps.validatorService.On("ValidateTokenParams", "some-token").Return(make(map[string]string))
+		uhDeps.userUseCase.On("VerifyEmail", mock.Anything, "some-token").Return(nil)
+
+		ctx, rec := genJSONContext(echo.POST, "{\"token\":\"some-token\"}")
+
+		if assert.NoError(t, uh.VerifyEmail(ctx)) {
+			assert.Equal(t, http.StatusOK, rec.Code)
+			assert.Equal(t, "{\"data\":null}\n", rec.Body.String())
+		}
+	})
+
+	t.Run("it should return error", func(t *testing.T) {
+		uh, uhDeps := genMockUserHandler()
+		uhDeps.validatorService.
+			On("ValidateTokenParams", "").
+			Return(map[string]string{"token_required": "token is required"})
+
+		ctx, rec := genJSONContext(echo.POST, "{}")
+
+		if assert.NoError(t, uh.VerifyEmail(ctx)) {
+			assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+			assert.Equal(t, "{\"data\":null,\"errors\":{\"token_required\":\"token is required\"}}\n", rec.Body.String())
+		}
+	})
+
+	t.Run("it should return error", func(t *testing.T) {
+		uh, uhDeps := genMockUserHandler()
+		uhDeps.validatorService.On("ValidateTokenParams", "some-token").Return(make(map[string]string))
+		uhDeps.userUseCase.On("VerifyEmail", mock.Anything, "some-token").Return(terr.NewExpiredTokenError("token is expired"))
+
+		ctx, rec := genJSONContext(echo.POST, "{\"token\":\"some-token\"}")
+
+		if assert.NoError(t, uh.VerifyEmail(ctx)) {
+			assert.Equal(t, http.StatusUnauthorized, rec.Code)
+			assert.Equal(t, "{\"data\":null,\"error\":\"invalid verification token: token is expired\"}\n", rec.Body.String())
+		}
+	})
+
+	t.Run("it should return error", func(t *testing.T) {
+		uh, uhDeps := genMockUserHandler()
+		uhDeps.validatorService.On("ValidateTokenParams", "some-token").Return(make(map[string]string))
+		uhDeps.userUseCase.On("VerifyEmail", mock.Anything, "some-token").Return(terr.NewNotFoundError("user not found"))
+
+		ctx, rec := genJSONContext(echo.POST, "{\"token\":\"some-token\"}")
+
+		if assert.NoError(t, uh.VerifyEmail(ctx)) {
+			assert.Equal(t, http.StatusNotFound, rec.Code)
+		}
+	})
+
+	t.Run("it should return error", func(t *testing.T) {
+		uh, uhDeps := genMockUserHandler()
+		uhDeps.validatorService.On("ValidateTokenParams", "some-token").Return(make(map[string]string))
+		uhDeps.userUseCase.On("VerifyEmail", mock.Anything, "some-token").Return(errors.New("some error"))
+
+		ctx, rec := genJSONContext(echo.POST, "{\"token\":\"some-token\"}")
+
+		if assert.NoError(t, uh.VerifyEmail(ctx)) {
+			assert.Equal(t, http.StatusInternalServerError, rec.Code)
+		}
+	})
+}
+
+func TestResendVerificationEmail(t *testing.T) {
+	t.Run("it should succeed", func(t *testing.T) {
+		uh, uhDeps := genMockUserHandler()
+		uhDeps.validatorService.
+			On("ValidateUserParams", mock.Anything, "resend_verification").
+			Return(make(map[string]string))
+		uhDeps.userUseCase.On("ResendVerificationEmail", mock.Anything, "some@email.com").Return(nil)
+
+		ctx, rec := genJSONContext(echo.POST, "{\"email_address\":\"some@email.com\"}")
+
+		if assert.NoError(t, uh.ResendVerificationEmail(ctx)) {
+			assert.Equal(t, http.StatusAccepted, rec.Code)
+			assert.Equal(t, "{\"data\":null}\n", rec.Body.String())
+		}
+	})
+
+	t.Run("it should return error", func(t *testing.T) {
+		uh, uhDeps := genMockUserHandler()
+		uhDeps.validatorService.
+			On("ValidateUserParams", mock.Anything, "resend_verification").
+			Return(map[string]string{"email_address_required": "email_address is required"})
+
+		ctx, rec := genJSONContext(echo.POST, "{}")
+
+		if assert.NoError(t, uh.ResendVerificationEmail(ctx)) {
+			assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+			uhDeps.userUseCase.AssertNotCalled(t, "ResendVerificationEmail", mock.Anything, mock.Anything)
+		}
+	})
+
+	t.Run("it should return error", func(t *testing.T) {
+		uh, uhDeps := genMockUserHandler()
+		uhDeps.validatorService.
+			On("ValidateUserParams", mock.Anything, "resend_verification").
+			Return(make(map[string]string))
+		uhDeps.userUseCase.On("ResendVerificationEmail", mock.Anything, "some@email.com").Return(errors.New("some error"))
+
+		ctx, rec := genJSONContext(echo.POST, "{\"email_address\":\"some@email.com\"}")
+
+		if assert.NoError(t, uh.ResendVerificationEmail(ctx)) {
+			assert.Equal(t, http.StatusInternalServerError, rec.Code)
+		}
+	})
+}
+
+func TestForgotPassword(t *testing.T) {
+	t.Run("it should succeed", func(t *testing.T) {
+		uh, uhDeps := genMockUserHandler()
+		uhDeps.validatorService.
+			On("ValidateUserParams", mock.Anything, "forgot_password").
+			Return(make(map[string]string))
+		uhDeps.userUseCase.On("ForgotPassword", mock.Anything, "some@email.com").Return(nil)
+
+		ctx, rec := genJSONContext(echo.POST, "{\"email_address\":\"some@email.com\"}")
+
+		if assert.NoError(t, uh.ForgotPassword(ctx)) {
+			assert.Equal(t, http.StatusAccepted, rec.Code)
+			assert.Equal(t, "{\"data\":null}\n", rec.Body.String())
+		}
+	})
+
+	t.Run("it should return error", func(t *testing.T) {
+		uh, uhDeps := genMockUserHandler()
+		uhDeps.validatorService.
+			On("ValidateUserParams", mock.Anything, "forgot_password").
+			Return(map[string]string{"email_address_required": "email_address is required"})
+
+		ctx, rec := genJSONContext(echo.POST, "{}")
+
+		if assert.NoError(t, uh.ForgotPassword(ctx)) {
+			assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+			assert.Equal(t, "{\"data\":null,\"errors\":{\"email_address_required\":\"email_address is required\"}}\n", rec.Body.String())
+			uhDeps.userUseCase.AssertNotCalled(t, "ForgotPassword", mock.Anything, mock.Anything)
+		}
+	})
+
+	t.Run("it should return error", func(t *testing.T) {
+		uh, _ := genMockUserHandler()
+
+		ctx, rec := genJSONContext(echo.POST, "\"wrong-params\"")
+
+		if assert.NoError(t, uh.ForgotPassword(ctx)) {
+			assert.Equal(t, http.StatusInternalServerError, rec.Code)
+		}
+	})
+
+	t.Run("it should return error", func(t *testing.T) {
+		uh, uhDeps := genMockUserHandler()
+		uhDeps.validatorService.
+			On("ValidateUserParams", mock.Anything, "forgot_password").
+			Return(make(map[string]string))
+		uhDeps.userUseCase.On("ForgotPassword", mock.Anything, "some@email.com").Return(errors.New("some error"))
+
+		ctx, rec := genJSONContext(echo.POST, "{\"email_address\":\"some@email.com\"}")
+
+		if assert.NoError(t, uh.ForgotPassword(ctx)) {
+			assert.Equal(t, http.StatusInternalServerError, rec.Code)
+		}
+	})
+}
+
+func TestResetPassword(t *testing.T) {
+	mockBody := "{\"token\":\"some-token\",\"password\":\"some-new-password\"}"
+
+	t.Run("it should succeed", func(t *testing.T) {
+		uh, uhDeps := genMockUserHandler()
+		uhDeps.validatorService.
+			On("ValidatePasswordResetParams", "some-token", "some-new-password").
+			Return(make(map[string]string))
+		uhDeps.userUseCase.On("ResetPassword", mock.Anything, "some-token", "some-new-password").Return(nil)
+
+		ctx, rec := genJSONContext(echo.POST, mockBody)
+
+		if assert.NoError(t, uh.ResetPassword(ctx)) {
+			assert.Equal(t, http.StatusOK, rec.Code)
+			assert.Equal(t, "{\"data\":null}\n", rec.Body.String())
+		}
+	})
+
+	t.Run("it should return error", func(t *testing.T) {
+		uh, uhDeps := genMockUserHandler()
+		uhDeps.validatorService.
+			On("ValidatePasswordResetParams", "", "").
+			Return(map[string]string{"token_required": "token is required", "password_required": "password is required"})
+
+		ctx, rec := genJSONContext(echo.POST, "{}")
+
+		if assert.NoError(t, uh.ResetPassword(ctx)) {
+			assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+			assert.Equal(
+				t,
+				"{\"data\":null,\"errors\":{\"password_required\":\"password is required\",\"token_required\":\"token is required\"}}\n",
+				rec.Body.String(),
+			)
+			uhDeps.userUseCase.AssertNotCalled(t, "ResetPassword", mock.Anything, mock.Anything, mock.Anything)
+		}
+	})
+
+	t.Run("it should return error", func(t *testing.T) {
+		uh, uhDeps := genMockUserHandler()
+		uhDeps.validatorService.
+			On("ValidatePasswordResetParams", "some-token", "some-new-password").
+			Return(make(map[string]string))
+		uhDeps.userUseCase.
+			On("ResetPassword", mock.Anything, "some-token", "some-new-password").
+			Return(terr.NewUnAuthorizedError("invalid token"))
+
+		ctx, rec := genJSONContext(echo.POST, mockBody)
+
+		if assert.NoError(t, uh.ResetPassword(ctx)) {
+			assert.Equal(t, http.StatusUnauthorized, rec.Code)
+			assert.Equal(t, "{\"data\":null,\"error\":\"invalid reset token: invalid token\"}\n", rec.Body.String())
+		}
+	})
+
+	t.Run("it should return error", func(t *testing.T) {
+		uh, uhDeps := genMockUserHandler()
+		uhDeps.validatorService.
+			On("ValidatePasswordResetParams", "some-token", "some-new-password").
+			Return(make(map[string]string))
+		uhDeps.userUseCase.
+			On("ResetPassword", mock.Anything, "some-token", "some-new-password").
+			Return(terr.NewNotFoundError("user not found"))
+
+		ctx, rec := genJSONContext(echo.POST, mockBody)
+
+		if assert.NoError(t, uh.ResetPassword(ctx)) {
+			assert.Equal(t, http.StatusNotFound, rec.Code)
+		}
+	})
+
+	t.Run("it should return error", func(t *testing.T) {
+		uh, uhDeps := genMockUserHandler()
+		uhDeps.validatorService.
+			On("ValidatePasswordResetParams", "some-token", "some-new-password").
+			Return(make(map[string]string))
+		uhDeps.userUseCase.
+			On("ResetPassword", mock.Anything, "some-token", "some-new-password").
+			Return(errors.New("some error"))
+
+		ctx, rec := genJSONContext(echo.POST, mockBody)
+
+		if assert.NoError(t, uh.ResetPassword(ctx)) {
+			assert.Equal(t, http.StatusInternalServerError, rec.Code)
+		}
+	})
+}
+
+func TestUpdateMe(t *testing.T) {
+	mockUser := auth.User{ID: "some-user-id", FirstName: "other-first", LastName: "last", EmailAddress: "some@email.com"}
+
+	t.Run("it should succeed", func(t *testing.T) {
+		uh, uhDeps := genMockUserHandler()
+		uhDeps.validatorService.On("ValidateUserParams", mock.Anything, "update").Return(make(map[string]string))
+		uhDeps.userUseCase.
+			On("UpdateUser", mock.Anything, mock.MatchedBy(func(u *auth.User) bool {
+				return u.ID == "some-user-id" && u.FirstName == "other-first"
+			})).
+			Return(mockUser, nil)
+		uhDeps.presenterService.
+			On("PresentUser", &mockUser).
+			Return(auth.PresentedUser{ID: mockUser.ID, FirstName: mockUser.FirstName})
+
+		ctx, rec := genJSONContext(echo.PATCH, "{\"id\":\"some-other-user-id\",\"first_name\":\"other-first\"}")
+		cmw.SetPrincipal(ctx, auth.TokenMetadata{UserID: "some-user-id"})
+
+		if assert.NoError(t, uh.UpdateMe(ctx)) {
+			assert.Equal(t, http.StatusOK, rec.Code)
+			assert.Contains(t, rec.Body.String(), "\"first_name\":\"other-first\"")
+		}
+	})
+
+	t.Run("it should return error", func(t *testing.T) {
+		uh, uhDeps := genMockUserHandler()
+		uhDeps.validatorService.
+			On("ValidateUserParams", mock.Anything, "update").
+			Return(map[string]string{"fields_required": "first_name, last_name or email_address is required"})
+
+		ctx, rec := genJSONContext(echo.PATCH, "{}")
+		cmw.SetPrincipal(ctx, auth.TokenMetadata{UserID: "some-user-id"})
+
+		if assert.NoError(t, uh.UpdateMe(ctx)) {
+			assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+			uhDeps.userUseCase.AssertNotCalled(t, "UpdateUser", mock.Anything, mock.Anything)
+		}
+	})
+
+	t.Run("it should return error", func(t *testing.T) {
+		uh, uhDeps := genMockUserHandler()
+		uhDeps.validatorService.On("ValidateUserParams", mock.Anything, "update").Return(make(map[string]string))
+		uhDeps.userUseCase.
+			On("UpdateUser", mock.Anything, mock.Anything).
+			Return(auth.User{}, terr.NewDuplicateEntryError("user already exist"))
+
+		ctx, rec := genJSONContext(echo.PATCH, "{\"email_address\":\"other@email.com\"}")
+		cmw.SetPrincipal(ctx, auth.TokenMetadata{UserID: "some-user-id"})
+
+		if assert.NoError(t, uh.UpdateMe(ctx)) {
+			assert.Equal(t, http.StatusForbidden, rec.Code)
+			assert.Equal(t, "{\"data\":null,\"error\":\"user already exist\"}\n", rec.Body.String())
+		}
+	})
+
+	t.Run("it should return error", func(t *testing.T) {
+		uh, uhDeps := genMockUserHandler()
+		uhDeps.validatorService.On("ValidateUserParams", mock.Anything, "update").Return(make(map[string]string))
+		uhDeps.userUseCase.
+			On("UpdateUser", mock.Anything, mock.Anything).
+			Return(auth.User{}, terr.NewNotFoundError("user not found"))
+
+		ctx, rec := genJSONContext(echo.PATCH, "{\"first_name\":\"other-first\"}")
+		cmw.SetPrincipal(ctx, auth.TokenMetadata{UserID: "some-user-id"})
+
+		if assert.NoError(t, uh.UpdateMe(ctx)) {
+			assert.Equal(t, http.StatusNotFound, rec.Code)
+		}
+	})
+
+	t.Run("it should return error", func(t *testing.T) {
+		uh, _ := genMockUserHandler()
+
+		ctx, rec := genJSONContext(echo.PATCH, "{\"first_name\":\"other-first\"}")
+
+		if assert.NoError(t, uh.UpdateMe(ctx)) {
+			assert.Equal(t, http.StatusUnauthorized, rec.Code)
+		}
+	})
+}
+
+func TestChangePassword(t *testing.T) {
+	mockBody := "{\"password\":\"some-password\",\"new_password\":\"some-new-password\"}"
+	mockRefreshTokenMeta := auth.TokenMetadata{UserID: "some-user-id", Type: auth.RefreshTokenType, Token: "some-token"}
+
+	t.Run("it should succeed", func(t *testing.T) {
+		uh, uhDeps := genMockUserHandler()
+		uhDeps.validatorService.
+			On("ValidateUserParams", mock.Anything, "change_password").
+			Return(make(map[string]string))
+		uhDeps.securityService.On("GetAndValidateRefreshToken", mock.Anything).Return(mockRefreshTokenMeta, nil)
+		uhDeps.userUseCase.
+			On("ChangePassword", mock.Anything, "some-user-id", "some-password", "some-new-password", &mockRefreshTokenMeta).
+			Return(nil)
+
+		ctx, rec := genJSONContext(echo.PUT, mockBody)
+		cmw.SetPrincipal(ctx, auth.TokenMetadata{UserID: "some-user-id"})
+
+		if assert.NoError(t, uh.ChangePassword(ctx)) {
+			assert.Equal(t, http.StatusOK, rec.Code)
+			assert.Equal(t, "{\"data\":null}\n", rec.Body.String())
+			uhDeps.userUseCase.AssertExpectations(t)
+		}
+	})
+
+	t.Run("it should keep no session without a refresh token", func(t *testing.T) {
+		uh, uhDeps := genMockUserHandler()
+		uhDeps.validatorService.
+			On("ValidateUserParams", mock.Anything, "change_password").
+			Return(make(map[string]string))
+		uhDeps.securityService.
+			On("GetAndValidateRefreshToken", mock.Anything).
+			Return(auth.TokenMetadata{}, terr.NewUnAuthorizedError("refresh token not found"))
+		uhDeps.userUseCase.
+			On("ChangePassword", mock.Anything, "some-user-id", "some-password", "some-new-password", (*auth.TokenMetadata)(nil)).
+			Return(nil)
+
+		ctx, rec := genJSONContext(echo.PUT, mockBody)
+		cmw.SetPrincipal(ctx, auth.TokenMetadata{UserID: "some-user-id"})
+
+		if assert.NoError(t, uh.ChangePassword(ctx)) {
+			assert.Equal(t, http.StatusOK, rec.Code)
+			uhDeps.userUseCase.AssertExpectations(t)
+		}
+	})
+
+	t.Run("it should return error", func(t *testing.T) {
+		uh, uhDeps := genMockUserHandler()
+		uhDeps.validatorService.
+			On("ValidateUserParams", mock.Anything, "change_password").
+			Return(map[string]string{"new_password_required": "new_password is required"})
+
+		ctx, rec := genJSONContext(echo.PUT, "{\"password\":\"some-password\"}")
+		cmw.SetPrincipal(ctx, auth.TokenMetadata{UserID: "some-user-id"})
+
+		if assert.NoError(t, uh.ChangePassword(ctx)) {
+			assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+			uhDeps.userUseCase.AssertNotCalled(t, "ChangePassword", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+		}
+	})
+
+	t.Run("it should return error", func(t *testing.T) {
+		uh, uhDeps := genMockUserHandler()
+		uhDeps.validatorService.
+			On("ValidateUserParams", mock.Anything, "change_password").
+			Return(make(map[string]string))
+		uhDeps.securityService.On("GetAndValidateRefreshToken", mock.Anything).Return(mockRefreshTokenMeta, nil)
+		uhDeps.userUseCase.
+			On("ChangePassword", mock.Anything, "some-user-id", "some-password", "some-new-password", mock.Anything).
+			Return(terr.NewUnAuthorizedError("password doesn't match"))
+
+		ctx, rec := genJSONContext(echo.PUT, mockBody)
+		cmw.SetPrincipal(ctx, auth.TokenMetadata{UserID: "some-user-id"})
+
+		if assert.NoError(t, uh.ChangePassword(ctx)) {
+			assert.Equal(t, http.StatusForbidden, rec.Code)
+			assert.Equal(t, "{\"data\":null,\"error\":\"password doesn't match\"}\n", rec.Body.String())
+		}
+	})
+
+	t.Run("it should return error", func(t *testing.T) {
+		uh, uhDeps := genMockUserHandler()
+		uhDeps.validatorService.
+			On("ValidateUserParams", mock.Anything, "change_password").
+			Return(make(map[string]string))
+		uhDeps.securityService.On("GetAndValidateRefreshToken", mock.Anything).Return(mockRefreshTokenMeta, nil)
+		uhDeps.userUseCase.
+			On("ChangePassword", mock.Anything, "some-user-id", "some-password", "some-new-password", mock.Anything).
+			Return(errors.New("some error"))
+
+		ctx, rec := genJSONContext(echo.PUT, mockBody)
+		cmw.SetPrincipal(ctx, auth.TokenMetadata{UserID: "some-user-id"})
+
+		if assert.NoError(t, uh.ChangePassword(ctx)) {
+			assert.Equal(t, http.StatusInternalServerError, rec.Code)
+		}
+	})
+
+	t.Run("it should return error", func(t *testing.T) {
+		uh, _ := genMockUserHandler()
+
+		ctx, rec := genJSONContext(echo.PUT, mockBody)
+
+		if assert.NoError(t, uh.ChangePassword(ctx)) {
+			assert.Equal(t, http.StatusUnauthorized, rec.Code)
+		}
+	})
+}
+
+func TestDeactivateMe(t *testing.T) {
+	t.Run("it should succeed", func(t *testing.T) {
+		uh, uhDeps := genMockUserHandler()
+		uhDeps.userUseCase.On("DeactivateUser", mock.Anything, "some-user-id", "some-user-id").Return(nil)
+
+		ctx, rec := genJSONContext(echo.POST, "")
+		cmw.SetPrincipal(ctx, auth.TokenMetadata{UserID: "some-user-id"})
+
+		if assert.NoError(t, uh.DeactivateMe(ctx)) {
+			assert.Equal(t, http.StatusOK, rec.Code)
+			assert.Contains(t, rec.Header().Get(echo.HeaderSetCookie), "REFRESH_TOKEN=;")
+			uhDeps.userUseCase.AssertExpectations(t)
+		}
+	})
+
+	t.Run("it should return error", func(t *testing.T) {
+		uh, uhDeps := genMockUserHandler()
+		uhDeps.userUseCase.On("DeactivateUser", mock.Anything, "some-user-id", "some-user-id").Return(errors.New("some error"))
+
+		ctx, rec := genJSONContext(echo.POST, "")
+		cmw.SetPrincipal(ctx, auth.TokenMetadata{UserID: "some-user-id"})
+
+		if assert.NoError(t, uh.DeactivateMe(ctx)) {
+			assert.Equal(t, http.StatusInternalServerError, rec.Code)
+		}
+	})
+
+	t.Run("it should return error", func(t *testing.T) {
+		uh, _ := genMockUserHandler()
+
+		ctx, rec := genJSONContext(echo.POST, "")
+
+		if assert.NoError(t, uh.DeactivateMe(ctx)) {
+			assert.Equal(t, http.StatusUnauthorized, rec.Code)
+		}
+	})
+}
+
+func TestDeleteMe(t *testing.T) {
+	t.Run("it should succeed", func(t *testing.T) {
+		uh, uhDeps := genMockUserHandler()
+		uhDeps.validatorService.
+			On("ValidateUserParams", mock.Anything, "confirm_password").
+			Return(make(map[string]string))
+		uhDeps.userUseCase.On("DeleteUser", mock.Anything, "some-user-id", "some-password").Return(nil)
+
+		ctx, rec := genJSONContext(echo.DELETE, "{\"password\":\"some-password\"}")
+		cmw.SetPrincipal(ctx, auth.TokenMetadata{UserID: "some-user-id"})
+
+		if assert.NoError(t, uh.DeleteMe(ctx)) {
+			assert.Equal(t, http.StatusOK, rec.Code)
+			assert.Contains(t, rec.Header().Get(echo.HeaderSetCookie), "REFRESH_TOKEN=;")
+			uhDeps.userUseCase.AssertExpectations(t)
+		}
+	})
+
+	t.Run("it should return error", func(t *testing.T) {
+		uh, uhDeps := genMockUserHandler()
+		uhDeps.validatorService.
+			On("ValidateUserParams", mock.Anything, "confirm_password").
+			Return(map[string]string{"password_required": "password is required"})
+
+		ctx, rec := genJSONContext(echo.DELETE, "{}")
+		cmw.SetPrincipal(ctx, auth.TokenMetadata{UserID: "some-user-id"})
+
+		if assert.NoError(t, uh.DeleteMe(ctx)) {
+			assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+			uhDeps.userUseCase.AssertNotCalled(t, "DeleteUser", mock.Anything, mock.Anything, mock.Anything)
+		}
+	})
+
+	t.Run("it should return error", func(t *testing.T) {
+		uh, uhDeps := genMockUserHandler()
+		uhDeps.validatorService.
+			On("ValidateUserParams", mock.Anything, "confirm_password").
+			Return(make(map[string]string))
+		uhDeps.userUseCase.
+			On("DeleteUser", mock.Anything, "some-user-id", "some-password").
+			Return(terr.NewUnAuthorizedError("password doesn't match"))
+
+		ctx, rec := genJSONContext(echo.DELETE, "{\"password\":\"some-password\"}")
+		cmw.SetPrincipal(ctx, auth.TokenMetadata{UserID: "some-user-id"})
+
+		if assert.NoError(t, uh.DeleteMe(ctx)) {
+			assert.Equal(t, http.StatusForbidden, rec.Code)
+			assert.Equal(t, "{\"data\":null,\"error\":\"password doesn't match\"}\n", rec.Body.String())
+		}
+	})
+}
+
+func TestEraseMe(t *testing.T) {
+	t.Run("it should succeed", func(t *testing.T) {
+		uh, uhDeps := genMockUserHandler()
+		uhDeps.validatorService.
+			On("ValidateUserParams", mock.Anything, "confirm_password").
+			Return(make(map[string]string))
+		uhDeps.userUseCase.On("EraseUser", mock.Anything, "some-user-id", "some-password").Return(nil)
+
+		ctx, rec := genJSONContext(echo.POST, "{\"password\":\"some-password\"}")
+		cmw.SetPrincipal(ctx, auth.TokenMetadata{UserID: "some-user-id"})
+
+		if assert.NoError(t, uh.EraseMe(ctx)) {
+			assert.Equal(t, http.StatusOK, rec.Code)
+			assert.Equal(t, "{\"data\":null}\n", rec.Body.String())
+			uhDeps.userUseCase.AssertExpectations(t)
+		}
+	})
+
+	t.Run("it should return error", func(t *testing.T) {
+		uh, uhDeps := genMockUserHandler()
+		uhDeps.validatorService.
+			On("ValidateUserParams", mock.Anything, "confirm_password").
+			Return(make(map[string]string))
+		uhDeps.userUseCase.
+			On("EraseUser", mock.Anything, "some-user-id", "some-password").
+			Return(terr.NewNotFoundError("user not found"))
+
+		ctx, rec := genJSONContext(echo.POST, "{\"password\":\"some-password\"}")
+		cmw.SetPrincipal(ctx, auth.TokenMetadata{UserID: "some-user-id"})
+
+		if assert.NoError(t, uh.EraseMe(ctx)) {
+			assert.Equal(t, http.StatusNotFound, rec.Code)
+		}
+	})
+
+	t.Run("it should return error", func(t *testing.T) {
+		uh, uhDeps := genMockUserHandler()
+		uhDeps.validatorService.
+			On("ValidateUserParams", mock.Anything, "confirm_password").
+			Return(make(map[string]string))
+		uhDeps.userUseCase.
+			On("EraseUser", mock.Anything, "some-user-id", "some-password").
+			Return(errors.New("could not write audit log"))
+
+		ctx, rec := genJSONContext(echo.POST, "{\"password\":\"some-password\"}")
+		cmw.SetPrincipal(ctx, auth.TokenMetadata{UserID: "some-user-id"})
+
+		if assert.NoError(t, uh.EraseMe(ctx)) {
+			assert.Equal(t, http.StatusInternalServerError, rec.Code)
+		}
+	})
+
+	t.Run("it should return error", func(t *testing.T) {
+		uh, _ := genMockUserHandler()
+
+		ctx, rec := genJSONContext(echo.POST, "{\"password\":\"some-password\"}")
+
+		if assert.NoError(t, uh.EraseMe(ctx)) {
+			assert.Equal(t, http.StatusUnauthorized, rec.Code)
+		}
+	})
+}
+
+func TestSetupTwoFactor(t *testing.T) {
+	t.Run("it should succeed", func(t *testing.T) {
+		uh, uhDeps := genMockUserHandler()
+		uhDeps.twoFactorUseCase.
+			On("Setup", mock.Anything, "some-user-id").
+			Return(auth.TwoFactorSetup{Secret: "SOMESECRET", URI: "otpauth://totp/some"}, nil)
+
+		ctx, rec := genJSONContext(echo.POST, "")
+		cmw.SetPrincipal(ctx, auth.TokenMetadata{UserID: "some-user-id"})
+
+		if assert.NoError(t, uh.SetupTwoFactor(ctx)) {
+			assert.Equal(t, http.StatusOK, rec.Code)
+			assert.Equal(t, "{\"data\":{\"secret\":\"SOMESECRET\",\"uri\":\"otpauth://totp/some\"}}\n", rec.Body.String())
+		}
+	})
+
+	t.Run("it should return error", func(t *testing.T) {
+		uh, uhDeps := genMockUserHandler()
+		uhDeps.twoFactorUseCase.
+			On("Setup", mock.Anything, "some-user-id").
+			Return(auth.TwoFactorSetup{}, terr.NewDuplicateEntryError("two factor authentication already enabled"))
+
+		ctx, rec := genJSONContext(echo.POST, "")
+		cmw.SetPrincipal(ctx, auth.TokenMetadata{UserID: "some-user-id"})
+
+		if assert.NoError(t, uh.SetupTwoFactor(ctx)) {
+			assert.Equal(t, http.StatusForbidden, rec.Code)
+		}
+	})
+
+	t.Run("it should return error", func(t *testing.T) {
+		uh, _ := genMockUserHandler()
+
+		ctx, rec := genJSONContext(echo.POST, "")
+
+		if assert.NoError(t, uh.SetupTwoFactor(ctx)) {
+			assert.Equal(t, http.StatusUnauthorized, rec.Code)
+		}
+	})
+}
+
+func TestConfirmTwoFactor(t *testing.T) {
+	t.Run("it should succeed", func(t *testing.T) {
+		uh, uhDeps := genMockUserHandler()
+		uhDeps.validatorService.On("ValidateTwoFactorCodeParams", "123456").Return(make(map[string]string))
+		uhDeps.twoFactorUseCase.
+			On("Confirm", mock.Anything, "some-user-id", "123456").
+			Return([]string{"abcde-fghij"}, nil)
+
+		ctx, rec := genJSONContext(echo.POST, "{\"code\":\"123456\"}")
+		cmw.SetPrincipal(ctx, auth.TokenMetadata{UserID: "some-user-id"})
+
+		if assert.NoError(t, uh.ConfirmTwoFactor(ctx)) {
+			assert.Equal(t, http.StatusOK, rec.Code)
+			assert.Equal(t, "{\"data\":{\"recovery_codes\":[\"abcde-fghij\"]}}\n", rec.Body.String())
+		}
+	})
+
+	t.Run("it should return error", func(t *testing.T) {
+		uh, uhDeps := genMockUserHandler()
+		uhDeps.validatorService.
+			On("ValidateTwoFactorCodeParams", "").
+			Return(map[string]string{"code_required": "code is required"})
+
+		ctx, rec := genJSONContext(echo.POST, "{}")
+		cmw.SetPrincipal(ctx, auth.TokenMetadata{UserID: "some-user-id"})
+
+		if assert.NoError(t, uh.ConfirmTwoFactor(ctx)) {
+			assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+		}
+	})
+
+	t.Run("it should return error", func(t *testing.T) {
+		uh, uhDeps := genMockUserHandler()
+		uhDeps.validatorService.On("ValidateTwoFactorCodeParams", mock.Anything).Return(make(map[string]string))
+		uhDeps.twoFactorUseCase.
+			On("Confirm", mock.Anything, "some-user-id", "000000").
+			Return(nil, terr.NewUnAuthorizedError("invalid code"))
+
+		ctx, rec := genJSONContext(echo.POST, "{\"code\":\"000000\"}")
+		cmw.SetPrincipal(ctx, auth.TokenMetadata{UserID: "some-user-id"})
+
+		if assert.NoError(t, uh.ConfirmTwoFactor(ctx)) {
+			assert.Equal(t, http.StatusForbidden, rec.Code)
+			assert.Equal(t, "{\"data\":null,\"error\":\"invalid code\"}\n", rec.Body.String())
+		}
+	})
+
+	t.Run("it should return error", func(t *testing.T) {
+		uh, uhDeps := genMockUserHandler()
+		uhDeps.validatorService.On("ValidateTwoFactorCodeParams", mock.Anything).Return(make(map[string]string))
+		uhDeps.twoFactorUseCase.
+			On("Confirm", mock.Anything, "some-user-id", "000000").
+			Return(nil, terr.NewTooManyAttemptsError("too many failed codes, try again later", time.Minute))
+
+		ctx, rec := genJSONContext(echo.POST, "{\"code\":\"000000\"}")
+		cmw.SetPrincipal(ctx, auth.TokenMetadata{UserID: "some-user-id"})
+
+		if assert.NoError(t, uh.ConfirmTwoFactor(ctx)) {
+			assert.Equal(t, http.StatusTooManyRequests, rec.Code)
+			assert.Equal(t, "60", rec.Header().Get("Retry-After"))
+		}
+	})
+
+	t.Run("it should return error", func(t *testing.T) {
+		uh, uhDeps := genMockUserHandler()
+		uhDeps.validatorService.On("ValidateTwoFactorCodeParams", mock.Anything).Return(make(map[string]string))
+		uhDeps.twoFactorUseCase.
+			On("Confirm", mock.Anything, "some-user-id", "123456").
+			Return(nil, terr.NewNotFoundError("two factor authentication not found"))
+
+		ctx, rec := genJSONContext(echo.POST, "{\"code\":\"123456\"}")
+		cmw.SetPrincipal(ctx, auth.TokenMetadata{UserID: "some-user-id"})
+
+		if assert.NoError(t, uh.ConfirmTwoFactor(ctx)) {
+			assert.Equal(t, http.StatusNotFound, rec.Code)
+		}
+	})
+}
+
+func TestDisableTwoFactor(t *testing.T) {
+	t.Run("it should succeed", func(t *testing.T) {
+		uh, uhDeps := genMockUserHandler()
+		uhDeps.validatorService.On("ValidateTwoFactorCodeParams", "abcde-fghij").Return(make(map[string]string))
+		uhDeps.twoFactorUseCase.On("Disable", mock.Anything, "some-user-id", "abcde-fghij").Return(nil)
+
+		ctx, rec := genJSONContext(echo.DELETE, "{\"code\":\"abcde-fghij\"}")
+		cmw.SetPrincipal(ctx, auth.TokenMetadata{UserID: "some-user-id"})
+
+		if assert.NoError(t, uh.DisableTwoFactor(ctx)) {
+			assert.Equal(t, http.StatusOK, rec.Code)
+			uhDeps.twoFactorUseCase.AssertExpectations(t)
+		}
+	})
+
+	t.Run("it should return error", func(t *testing.T) {
+		uh, uhDeps := genMockUserHandler()
+		uhDeps.validatorService.On("ValidateTwoFactorCodeParams", mock.Anything).Return(make(map[string]string))
+		uhDeps.twoFactorUseCase.
+			On("Disable", mock.Anything, "some-user-id", "000000").
+			Return(terr.NewUnAuthorizedError("invalid code"))
+
+		ctx, rec := genJSONContext(echo.DELETE, "{\"code\":\"000000\"}")
+		cmw.SetPrincipal(ctx, auth.TokenMetadata{UserID: "some-user-id"})
+
+		if assert.NoError(t, uh.DisableTwoFactor(ctx)) {
+			assert.Equal(t, http.StatusForbidden, rec.Code)
+		}
+	})
+
+	t.Run("it should return error", func(t *testing.T) {
+		uh, _ := genMockUserHandler()
+
+		ctx, rec := genJSONContext(echo.DELETE, "{\"code\":\"000000\"}")
+
+		if assert.NoError(t, uh.DisableTwoFactor(ctx)) {
+			assert.Equal(t, http.StatusUnauthorized, rec.Code)
 		}
 	})
 }
@@ -0,0 +1,32 @@
+package handler
+
+import (
+	"github.com/labstack/echo/v4"
+	"net/http"
+	"sherman/src/service/security"
+)
+
+type (
+	// WellKnownHandler handler for /.well-known/[routes]
+	WellKnownHandler interface {
+		GetJWKS(ctx echo.Context) error
+	}
+
+	wellKnownHandler struct {
+		security security.Security
+	}
+)
+
+// NewWellKnownHandler constructor
+func NewWellKnownHandler(ss security.Security) WellKnownHandler {
+	return &wellKnownHandler{
+		security: ss,
+	}
+}
+
+// GetJWKS gets the public json web key set that verifies issued tokens, the set is
+// served as is (not wrapped in a response.Response) so any jwt library can consume it
+func (h *wellKnownHandler) GetJWKS(ctx echo.Context) error {
+	ctx.Response().Header().Set("Cache-Control", "public, max-age=300")
+	return ctx.JSON(http.StatusOK, h.security.GetJWKS())
+}
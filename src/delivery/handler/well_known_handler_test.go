@@ -0,0 +1,61 @@
+package handler
+
+import (
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"sherman/mocks"
+	_ "sherman/src/app/testing"
+	"sherman/src/service/security"
+	"testing"
+)
+
+type wellKnownHandlerMockDeps struct {
+	securityService *mocks.Security
+}
+
+func genMockWellKnownHandler() (WellKnownHandler, wellKnownHandlerMockDeps) {
+	wkhDeps := wellKnownHandlerMockDeps{
+		securityService: new(mocks.Security),
+	}
+
+	wkh := NewWellKnownHandler(wkhDeps.securityService)
+
+	return wkh, wkhDeps
+}
+
+func TestGetJWKS(t *testing.T) {
+	t.Run("it should succeed", func(t *testing.T) {
+		wkh, wkhDeps := genMockWellKnownHandler()
+		wkhDeps.securityService.
+			On("GetJWKS").
+			Return(security.JWKS{
+				Keys: []security.JWK{
+					{
+						Kty: "OKP",
+						Use: "sig",
+						Alg: "EdDSA",
+						Kid: "some-kid",
+						Crv: "Ed25519",
+						X:   "some-x",
+					},
+				},
+			})
+
+		e := echo.New()
+		req := httptest.NewRequest(echo.GET, "/some-url", nil)
+		rec := httptest.NewRecorder()
+		ctx := e.NewContext(req, rec)
+
+		if assert.NoError(t, wkh.GetJWKS(ctx)) {
+			assert.Equal(t, http.StatusOK, rec.Code)
+			assert.Equal(t, "public, max-age=300", rec.Header().Get("Cache-Control"))
+			assert.Equal(
+				t,
+				"{\"keys\":[{\"kty\":\"OKP\",\"use\":\"sig\",\"alg\":\"EdDSA\",\"kid\":\"some-kid\",\"crv\":\"Ed25519\",\"x\":\"some-x\"}]}\n",
+				rec.Body.String(),
+			)
+		}
+	})
+}
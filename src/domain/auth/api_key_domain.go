@@ -0,0 +1,43 @@
+package auth
+
+import (
+	"context"
+	"time"
+)
+
+// APIKeyTokenType constant type of the principal of a request authenticated with an API key
+const APIKeyTokenType = "API_KEY"
+
+type (
+	// APIKey entity struct, a long lived credential of a user for machine to machine access, only its
+	// hash and a display prefix are persisted, keys without scopes grant what the user session grants
+	APIKey struct {
+		ID        string     `json:"id"`
+		UserID    string     `json:"user_id"`
+		Name      string     `json:"name"`
+		Prefix    string     `json:"prefix"`
+		KeyHash   string     `json:"-"`
+		Scopes    []string   `json:"scopes"`
+		ExpiresAt *time.Time `json:"expires_at"`
+		CreatedAt time.Time  `json:"created_at"`
+	}
+	// NewAPIKey struct definition, a created API key along with the key itself, shown this once
+	NewAPIKey struct {
+		APIKey
+		Key string `json:"key"`
+	}
+	// APIKeyRepository interface
+	APIKeyRepository interface {
+		CreateAPIKey(ctx context.Context, apiKey *APIKey) error
+		GetAPIKeysByUserID(ctx context.Context, userID string) ([]APIKey, error)
+		GetAPIKeyByHash(ctx context.Context, keyHash string) (APIKey, error)
+		RemoveAPIKey(ctx context.Context, userID, id string) error
+	}
+	// APIKeyUseCase interface
+	APIKeyUseCase interface {
+		CreateAPIKey(ctx context.Context, apiKey *APIKey) (NewAPIKey, error)
+		GetAPIKeys(ctx context.Context, userID string) ([]APIKey, error)
+		RevokeAPIKey(ctx context.Context, userID, id string) error
+		Authenticate(ctx context.Context, key string) (TokenMetadata, error)
+	}
+)
@@ -0,0 +1,33 @@
+package auth
+
+import (
+	"context"
+	"time"
+)
+
+const (
+	// UserActivatedAction constant audit action of an account activated by an admin
+	UserActivatedAction = "user.activated"
+	// UserDeactivatedAction constant audit action of a deactivated account
+	UserDeactivatedAction = "user.deactivated"
+	// UserDeletedAction constant audit action of a soft deleted account
+	UserDeletedAction = "user.deleted"
+	// UserErasedAction constant audit action of an account erased with its data
+	UserErasedAction = "user.erased"
+)
+
+type (
+	// AuditLog entity struct, records an action of ActorID on the account of UserID, it only
+	// holds ids so it can be kept after the account data is erased
+	AuditLog struct {
+		ID        string    `json:"id"`
+		UserID    string    `json:"user_id"`
+		ActorID   string    `json:"actor_id"`
+		Action    string    `json:"action"`
+		CreatedAt time.Time `json:"created_at"`
+	}
+	// AuditLogRepository interface
+	AuditLogRepository interface {
+		CreateAuditLog(ctx context.Context, auditLog *AuditLog) error
+	}
+)
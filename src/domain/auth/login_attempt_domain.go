@@ -0,0 +1,37 @@
+package auth
+
+import (
+	"context"
+	"time"
+)
+
+type (
+	// LoginAttempt entity struct, the failed logins of an account or an IP address, or the
+	// wrong two factor codes of a user, identified by Key,
+	// Lockouts counts the lockouts of the current failure window and doubles every next lock duration
+	LoginAttempt struct {
+		Key           string    `json:"key"`
+		Failures      int       `json:"failures"`
+		Lockouts      int       `json:"lockouts"`
+		LockedUntil   time.Time `json:"locked_until"`
+		LastFailureAt time.Time `json:"last_failure_at"`
+	}
+	// LoginAttemptRepository interface, UpdateLoginAttempt reads and saves the login attempt of a key
+	// atomically, concurrent updates of the same key are applied one after the other
+	LoginAttemptRepository interface {
+		GetLoginAttempt(ctx context.Context, key string) (LoginAttempt, error)
+		SaveLoginAttempt(ctx context.Context, loginAttempt *LoginAttempt) error
+		UpdateLoginAttempt(ctx context.Context, key string, update func(loginAttempt *LoginAttempt)) error
+		RemoveLoginAttempt(ctx context.Context, key string) error
+		RemoveStaleLoginAttempts(ctx context.Context, before time.Time) error
+	}
+	// LoginAttemptUseCase interface
+	LoginAttemptUseCase interface {
+		CheckLogin(ctx context.Context, email, ipAddress string) error
+		RecordFailedLogin(ctx context.Context, email, ipAddress string) error
+		RecordSuccessfulLogin(ctx context.Context, email string) error
+		CheckTwoFactor(ctx context.Context, userID string) error
+		RecordFailedTwoFactor(ctx context.Context, userID string) error
+		RecordSuccessfulTwoFactor(ctx context.Context, userID string) error
+	}
+)
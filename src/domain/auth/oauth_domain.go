@@ -0,0 +1,121 @@
+package auth
+
+import (
+	"context"
+	"time"
+)
+
+const (
+	// AuthorizationCodeGrant constant OAuth grant type exchanging an authorization code
+	AuthorizationCodeGrant = "authorization_code"
+	// RefreshTokenGrant constant OAuth grant type exchanging a refresh token
+	RefreshTokenGrant = "refresh_token"
+	// ClientCredentialsGrant constant OAuth grant type of clients acting on their own behalf
+	ClientCredentialsGrant = "client_credentials"
+	// ProfileScope constant scope to read the user profile
+	ProfileScope = "profile"
+	// ProfileWriteScope constant scope to update the user profile
+	ProfileWriteScope = "profile:write"
+	// AccountScope constant scope to manage the user account: password, sessions, two factor authentication and removal
+	AccountScope = "account"
+	// AdminScope constant scope to use the admin routes the user roles grant
+	AdminScope = "admin"
+)
+
+type (
+	// OAuthClient entity struct, a registered OAuth client, clients without secret are public clients
+	// (e.g. mobile apps and SPAs) that can't keep a secret and must authenticate with PKCE alone
+	OAuthClient struct {
+		ID           string    `json:"id"`
+		Name         string    `json:"name"`
+		SecretHash   string    `json:"-"`
+		RedirectURIs []string  `json:"redirect_uris"`
+		GrantTypes   []string  `json:"grant_types"`
+		Scopes       []string  `json:"scopes"`
+		CreatedAt    time.Time `json:"created_at"`
+		UpdatedAt    time.Time `json:"updated_at"`
+	}
+	// NewOAuthClient a registered OAuth client with its secret, the secret is only returned when it is
+	// generated and public clients have none
+	NewOAuthClient struct {
+		OAuthClient
+		Secret string `json:"secret,omitempty"`
+	}
+	// AuthorizationCode entity struct, a single use code granted to a client for a user, only its hash is persisted
+	AuthorizationCode struct {
+		CodeHash      string    `json:"code_hash"`
+		ClientID      string    `json:"client_id"`
+		UserID        string    `json:"user_id"`
+		RedirectURI   string    `json:"redirect_uri"`
+		Scopes        []string  `json:"scopes"`
+		CodeChallenge string    `json:"code_challenge"`
+		ExpiresAt     time.Time `json:"expires_at"`
+		CreatedAt     time.Time `json:"created_at"`
+	}
+	// AuthorizationRequest params of a RFC 6749 authorization request, PKCE is required with the S256 method
+	AuthorizationRequest struct {
+		ResponseType        string
+		ClientID            string
+		RedirectURI         string
+		Scope               string
+		State               string
+		CodeChallenge       string
+		CodeChallengeMethod string
+	}
+	// OAuthTokenRequest params of a RFC 6749 token request, client credentials are either sent with
+	// basic authentication or in the request body
+	OAuthTokenRequest struct {
+		GrantType    string
+		ClientID     string
+		ClientSecret string
+		Code         string
+		RedirectURI  string
+		CodeVerifier string
+		RefreshToken string
+		Scope        string
+		UserAgent    string
+		IPAddress    string
+	}
+	// OAuthTokenResponse RFC 6749 successful token response
+	OAuthTokenResponse struct {
+		AccessToken  string `json:"access_token"`
+		TokenType    string `json:"token_type"`
+		ExpiresIn    int64  `json:"expires_in"`
+		RefreshToken string `json:"refresh_token,omitempty"`
+		Scope        string `json:"scope,omitempty"`
+	}
+	// TokenIntrospection RFC 7662 introspection response, inactive tokens only report active false
+	TokenIntrospection struct {
+		Active    bool     `json:"active"`
+		Scope     string   `json:"scope,omitempty"`
+		ClientID  string   `json:"client_id,omitempty"`
+		TokenType string   `json:"token_type,omitempty"`
+		Subject   string   `json:"sub,omitempty"`
+		Issuer    string   `json:"iss,omitempty"`
+		Audience  []string `json:"aud,omitempty"`
+		JwtID     string   `json:"jti,omitempty"`
+		IssuedAt  int64    `json:"iat,omitempty"`
+		NotBefore int64    `json:"nbf,omitempty"`
+		ExpiresAt int64    `json:"exp,omitempty"`
+	}
+	// OAuthClientRepository interface
+	OAuthClientRepository interface {
+		CreateClient(ctx context.Context, client *OAuthClient) error
+		UpdateClientSecret(ctx context.Context, id, secretHash string, updatedAt time.Time) error
+		GetClientByID(ctx context.Context, id string) (OAuthClient, error)
+	}
+	// AuthorizationCodeRepository interface
+	AuthorizationCodeRepository interface {
+		CreateAuthorizationCode(ctx context.Context, code *AuthorizationCode) error
+		ConsumeAuthorizationCode(ctx context.Context, codeHash string) (AuthorizationCode, error)
+	}
+	// OAuthUseCase interface
+	OAuthUseCase interface {
+		Authorize(ctx context.Context, userID string, request *AuthorizationRequest) (string, error)
+		Token(ctx context.Context, request *OAuthTokenRequest) (OAuthTokenResponse, error)
+		Revoke(ctx context.Context, clientID, clientSecret, token string) error
+		Introspect(ctx context.Context, clientID, clientSecret, token string) (TokenIntrospection, error)
+		CreateClient(ctx context.Context, client *OAuthClient, public bool) (NewOAuthClient, error)
+		RotateClientSecret(ctx context.Context, id string) (NewOAuthClient, error)
+	}
+)
@@ -0,0 +1,48 @@
+package auth
+
+import (
+	"context"
+	"time"
+)
+
+const (
+	// ReadUsersPermission constant permission to read any user
+	ReadUsersPermission = "users:read"
+	// ManageRolesPermission constant permission to assign and revoke roles
+	ManageRolesPermission = "roles:manage"
+	// ManageUsersPermission constant permission to update, activate and deactivate any user
+	ManageUsersPermission = "users:manage"
+	// ManageClientsPermission constant permission to register OAuth clients and rotate their secrets
+	ManageClientsPermission = "clients:manage"
+)
+
+type (
+	// Role entity struct, a named set of permissions
+	Role struct {
+		ID        string    `json:"id"`
+		Name      string    `json:"name"`
+		CreatedAt time.Time `json:"created_at"`
+		UpdatedAt time.Time `json:"updated_at"`
+	}
+	// UserRole entity struct, a role assigned to a user
+	UserRole struct {
+		UserID    string    `json:"user_id"`
+		RoleID    string    `json:"role_id"`
+		CreatedAt time.Time `json:"created_at"`
+	}
+	// RoleRepository interface
+	RoleRepository interface {
+		GetRoleByName(ctx context.Context, name string) (Role, error)
+		GetRoleNamesByUserID(ctx context.Context, userID string) ([]string, error)
+		GetPermissionsByRoleNames(ctx context.Context, roleNames []string) ([]string, error)
+		CreateUserRole(ctx context.Context, userRole *UserRole) error
+		RemoveUserRole(ctx context.Context, userID, roleID string) error
+	}
+	// RoleUseCase interface
+	RoleUseCase interface {
+		GetRoleNamesByUserID(ctx context.Context, userID string) ([]string, error)
+		HasPermission(ctx context.Context, roleNames []string, permission string) (bool, error)
+		AssignRole(ctx context.Context, userID, roleName string) error
+		RevokeRole(ctx context.Context, userID, roleName string) error
+	}
+)
@@ -1,6 +1,7 @@
 package auth
 
 import (
+	"context"
 	"time"
 )
 
@@ -9,35 +10,120 @@ const (
 	RefreshTokenType = "REFRESH"
 	// AccessTokenType constant security token type for access tokens
 	AccessTokenType = "ACCESS"
+	// EmailVerificationTokenType constant security token type for one time email verification tokens
+	EmailVerificationTokenType = "EMAIL_VERIFICATION"
+	// PasswordResetTokenType constant security token type for one time password reset tokens
+	PasswordResetTokenType = "PASSWORD_RESET"
+	// MFAPendingTokenType constant security token type for one time tokens of logins waiting for a second factor
+	MFAPendingTokenType = "MFA_PENDING"
+	// ClientAccessTokenType constant security token type for access tokens of OAuth clients acting on their own behalf
+	ClientAccessTokenType = "CLIENT_ACCESS"
 )
 
 type (
-	// SecurityToken entity struct
+	// SecurityToken entity struct, the active refresh token of a family represents a user session
 	SecurityToken struct {
+		ID         string    `json:"id"`
+		UserID     string    `json:"user_id"`
+		Token      string    `json:"token"`
+		Type       string    `json:"type"`
+		FamilyID   string    `json:"family_id"`
+		Rotated    bool      `json:"rotated"`
+		UserAgent  string    `json:"user_agent"`
+		IPAddress  string    `json:"ip_address"`
+		LastUsedAt time.Time `json:"last_used_at"`
+		CreatedAt  time.Time `json:"created_at"`
+		UpdatedAt  time.Time `json:"updated_at"`
+	}
+	// PresentedSession defines struct with public keys of a user session
+	PresentedSession struct {
+		ID         string    `json:"id"`
+		UserAgent  string    `json:"user_agent"`
+		IPAddress  string    `json:"ip_address"`
+		LastUsedAt time.Time `json:"last_used_at"`
+		CreatedAt  time.Time `json:"created_at"`
+	}
+	// RevokedToken entity struct, a revoked token is rejected until it expires
+	RevokedToken struct {
 		ID        string    `json:"id"`
 		UserID    string    `json:"user_id"`
-		Token     string    `json:"token"`
-		Type      string    `json:"type"`
+		ExpiresAt time.Time `json:"expires_at"`
 		CreatedAt time.Time `json:"created_at"`
-		UpdatedAt time.Time `json:"updated_at"`
 	}
-	// TokenMetadata struct definition
+	// TokenMetadata struct definition, ID is the jti claim and UserID the sub claim, ClientID and Scopes
+	// are only set on tokens issued to an OAuth client
 	TokenMetadata struct {
-		UserID string
-		Type   string
-		Token  string
+		ID        string
+		UserID    string
+		Type      string
+		Token     string
+		Roles     []string
+		ClientID  string
+		Scopes    []string
+		Issuer    string
+		Audience  []string
+		IssuedAt  int64
+		NotBefore int64
+		ExpiresAt int64
 	}
 	// SecurityTokenRepository interface
 	SecurityTokenRepository interface {
-		CreateOrUpdateToken(token *SecurityToken) error
-		GetTokenByMetadata(tokenMetadata *TokenMetadata) (SecurityToken, error)
-		RemoveTokenByMetadata(tokenMetadata *TokenMetadata) error
+		CreateToken(ctx context.Context, token *SecurityToken) error
+		CreateOrUpdateToken(ctx context.Context, token *SecurityToken) error
+		GetTokenByMetadata(ctx context.Context, tokenMetadata *TokenMetadata) (SecurityToken, error)
+		GetTokensByUserID(ctx context.Context, userID, tokenType string) ([]SecurityToken, error)
+		RotateToken(ctx context.Context, token, rotatedToken *SecurityToken) error
+		RemoveTokenByMetadata(ctx context.Context, tokenMetadata *TokenMetadata) error
+		RemoveTokenFamily(ctx context.Context, userID, familyID string) error
+	}
+	// RevokedTokenRepository interface
+	RevokedTokenRepository interface {
+		CreateRevokedToken(ctx context.Context, revokedToken *RevokedToken) error
+		IsTokenRevoked(ctx context.Context, tokenID string) (bool, error)
+		RemoveExpiredRevokedTokens(ctx context.Context, now time.Time) error
 	}
 	// SecurityTokenUseCase interface
 	SecurityTokenUseCase interface {
-		GenRefreshToken(userID string) (SecurityToken, error)
-		GenAccessToken(userID string) (SecurityToken, error)
-		IsRefreshTokenStored(refreshTokenMetadata *TokenMetadata) bool
-		RemoveRefreshToken(refreshTokenMetadata *TokenMetadata) error
+		GenRefreshToken(ctx context.Context, userID, userAgent, ipAddress string) (SecurityToken, error)
+		GenAccessToken(ctx context.Context, userID string) (SecurityToken, error)
+		GenScopedRefreshToken(ctx context.Context, userID, clientID string, scopes []string, userAgent, ipAddress string) (SecurityToken, error)
+		GenScopedAccessToken(ctx context.Context, userID, clientID string, scopes []string) (SecurityToken, error)
+		GenClientAccessToken(ctx context.Context, clientID string, scopes []string) (SecurityToken, error)
+		IsRefreshTokenStored(ctx context.Context, refreshTokenMetadata *TokenMetadata) bool
+		RotateRefreshToken(ctx context.Context, refreshTokenMetadata *TokenMetadata) (SecurityToken, error)
+		RemoveRefreshToken(ctx context.Context, refreshTokenMetadata *TokenMetadata) error
+		RevokeAccessToken(ctx context.Context, accessTokenMetadata *TokenMetadata) error
+		IsAccessTokenRevoked(ctx context.Context, accessTokenMetadata *TokenMetadata) bool
+		GetSessions(ctx context.Context, userID string) ([]SecurityToken, error)
+		RemoveSession(ctx context.Context, userID, sessionID string) error
+		RemoveSessions(ctx context.Context, userID string) error
+		RemoveOtherSessions(ctx context.Context, userID string, refreshTokenMetadata *TokenMetadata) error
+		GenOneTimeToken(ctx context.Context, userID, tokenType string, duration time.Duration) (SecurityToken, error)
+		ConsumeOneTimeToken(ctx context.Context, token, tokenType string) (TokenMetadata, error)
+		RemoveOneTimeTokens(ctx context.Context, userID, tokenType string) error
 	}
 )
+
+// HasScope checks if the token grants scope, tokens neither issued to an OAuth client nor scoped are
+// first party session tokens or unrestricted API keys and grant every scope
+func (tm *TokenMetadata) HasScope(scope string) bool {
+	if tm.ClientID == "" && len(tm.Scopes) == 0 {
+		return true
+	}
+	for _, s := range tm.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// HasRole checks if the token grants role
+func (tm *TokenMetadata) HasRole(role string) bool {
+	for _, r := range tm.Roles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,50 @@
+package auth
+
+import (
+	"context"
+	"time"
+)
+
+type (
+	// TwoFactor entity struct, the TOTP authenticator of a user, Secret is encrypted at rest and the
+	// authenticator is only enforced once Enabled, LastUsedStep is the TOTP period of the last accepted
+	// code so that a code can't be replayed
+	TwoFactor struct {
+		UserID       string    `json:"user_id"`
+		Secret       string    `json:"secret"`
+		Enabled      bool      `json:"enabled"`
+		LastUsedStep int64     `json:"last_used_step"`
+		CreatedAt    time.Time `json:"created_at"`
+		UpdatedAt    time.Time `json:"updated_at"`
+	}
+	// RecoveryCode entity struct, a one time code replacing a TOTP code, only its hash is persisted
+	RecoveryCode struct {
+		ID        string    `json:"id"`
+		UserID    string    `json:"user_id"`
+		CodeHash  string    `json:"code_hash"`
+		CreatedAt time.Time `json:"created_at"`
+	}
+	// TwoFactorSetup struct definition, the secret of an authenticator being enrolled and its otpauth URI
+	TwoFactorSetup struct {
+		Secret string `json:"secret"`
+		URI    string `json:"uri"`
+	}
+	// TwoFactorRepository interface
+	TwoFactorRepository interface {
+		GetTwoFactor(ctx context.Context, userID string) (TwoFactor, error)
+		CreateOrUpdateTwoFactor(ctx context.Context, twoFactor *TwoFactor) error
+		UpdateTwoFactorLastUsedStep(ctx context.Context, userID string, lastUsedStep int64, updatedAt time.Time) error
+		RemoveTwoFactor(ctx context.Context, userID string) error
+		ReplaceRecoveryCodes(ctx context.Context, userID string, recoveryCodes []RecoveryCode) error
+		RemoveRecoveryCode(ctx context.Context, userID, codeHash string) error
+	}
+	// TwoFactorUseCase interface
+	TwoFactorUseCase interface {
+		Setup(ctx context.Context, userID string) (TwoFactorSetup, error)
+		Confirm(ctx context.Context, userID, code string) ([]string, error)
+		Disable(ctx context.Context, userID, code string) error
+		IsEnabled(ctx context.Context, userID string) (bool, error)
+		GenPendingToken(ctx context.Context, userID string) (SecurityToken, error)
+		VerifyLogin(ctx context.Context, pendingToken, code string) (string, error)
+	}
+)
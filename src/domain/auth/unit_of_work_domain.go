@@ -0,0 +1,12 @@
+package auth
+
+import "context"
+
+type (
+	// UnitOfWork runs datastore operations atomically, Do commits the operations of fn when fn
+	// returns nil and rolls them back otherwise, the repositories called with the context given to
+	// fn are scoped to the transaction, a Do called with that context joins the transaction
+	UnitOfWork interface {
+		Do(ctx context.Context, fn func(ctx context.Context) error) error
+	}
+)
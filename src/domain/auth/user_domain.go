@@ -1,43 +1,97 @@
 package auth
 
 import (
+	"context"
 	"time"
 )
 
+const (
+	// AdminRole constant role of users allowed to manage other users
+	AdminRole = "admin"
+	// DefaultUserQueryLimit constant page size of a UserQuery without limit
+	DefaultUserQueryLimit = 20
+	// MaxUserQueryLimit constant max page size of a UserQuery
+	MaxUserQueryLimit = 100
+)
+
+// UserSortKeys sort keys of a UserQuery
+var UserSortKeys = []string{"created_at", "email_address", "first_name", "last_name"}
+
 type (
-	// User entity struct
+	// User entity struct, NewPassword is only bound from change password requests and never persisted
 	User struct {
-		ID           string    `json:"id"`
-		FirstName    string    `json:"first_name"`
-		LastName     string    `json:"last_name"`
-		EmailAddress string    `json:"email_address"`
-		Password     string    `json:"password"`
-		Active       bool      `json:"active"`
-		CreatedAt    time.Time `json:"created_at"`
-		UpdatedAt    time.Time `json:"updated_at"`
+		ID            string    `json:"id"`
+		FirstName     string    `json:"first_name"`
+		LastName      string    `json:"last_name"`
+		EmailAddress  string    `json:"email_address"`
+		Password      string    `json:"password"`
+		NewPassword   string    `json:"new_password"`
+		Active        bool      `json:"active"`
+		EmailVerified bool      `json:"email_verified"`
+		CreatedAt     time.Time `json:"created_at"`
+		UpdatedAt     time.Time `json:"updated_at"`
 	}
 
 	// PresentedUser defines struct with public auth.User keys
 	PresentedUser struct {
-		ID           string    `json:"id"`
-		FirstName    string    `json:"first_name"`
-		LastName     string    `json:"last_name"`
-		EmailAddress string    `json:"email_address"`
-		Active       bool      `json:"active"`
-		CreatedAt    time.Time `json:"created_at"`
-		UpdatedAt    time.Time `json:"updated_at"`
+		ID            string    `json:"id"`
+		FirstName     string    `json:"first_name"`
+		LastName      string    `json:"last_name"`
+		EmailAddress  string    `json:"email_address"`
+		Active        bool      `json:"active"`
+		EmailVerified bool      `json:"email_verified"`
+		CreatedAt     time.Time `json:"created_at"`
+		UpdatedAt     time.Time `json:"updated_at"`
+	}
+
+	// UserQuery filters, sort and page of a users listing, zero filters are ignored, CreatedFrom
+	// is inclusive and CreatedTo exclusive, Cursor is the NextCursor of the previous page
+	UserQuery struct {
+		Active      *bool
+		CreatedFrom time.Time
+		CreatedTo   time.Time
+		EmailPrefix string
+		SortKey     string
+		SortDesc    bool
+		Cursor      string
+		Limit       int
+	}
+
+	// UserPage a page of a users listing, Total counts the users matching the filters of every page
+	UserPage struct {
+		Users      []User
+		NextCursor string
+		Total      int
 	}
 
 	// UserRepository interface
 	UserRepository interface {
-		CreateUser(user *User) error
-		GetUserByID(id string) (User, error)
-		GetUserByEmail(email string) (User, error)
+		CreateUser(ctx context.Context, user *User) error
+		GetUserByID(ctx context.Context, id string) (User, error)
+		GetUserByEmail(ctx context.Context, email string) (User, error)
+		ListUsers(ctx context.Context, query UserQuery) (UserPage, error)
+		UpdateUser(ctx context.Context, user *User) error
+		UpdateUserPassword(ctx context.Context, id, password string, updatedAt time.Time) error
+		UpdateUserActive(ctx context.Context, id string, active bool, updatedAt time.Time) error
+		SoftDeleteUser(ctx context.Context, id string, deletedAt time.Time) error
+		DeleteUser(ctx context.Context, id string) error
 	}
 	// UserUseCase interface
 	UserUseCase interface {
-		Register(user *User) error
-		GetUserByID(id string) (User, error)
-		VerifyCredentials(user *User) (User, error)
+		Register(ctx context.Context, user *User) error
+		GetUserByID(ctx context.Context, id string) (User, error)
+		ListUsers(ctx context.Context, query UserQuery) (UserPage, error)
+		VerifyCredentials(ctx context.Context, user *User) (User, error)
+		VerifyEmail(ctx context.Context, token string) error
+		ResendVerificationEmail(ctx context.Context, email string) error
+		ForgotPassword(ctx context.Context, email string) error
+		ResetPassword(ctx context.Context, token, password string) error
+		UpdateUser(ctx context.Context, user *User) (User, error)
+		ChangePassword(ctx context.Context, userID, password, newPassword string, refreshTokenMetadata *TokenMetadata) error
+		IsUserActive(ctx context.Context, userID string) bool
+		ActivateUser(ctx context.Context, userID, actorID string) error
+		DeactivateUser(ctx context.Context, userID, actorID string) error
+		DeleteUser(ctx context.Context, userID, password string) error
+		EraseUser(ctx context.Context, userID, password string) error
 	}
 )
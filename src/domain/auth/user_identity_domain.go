@@ -0,0 +1,47 @@
+package auth
+
+import (
+	"context"
+	"time"
+)
+
+type (
+	// UserIdentity entity struct, links the subject of an OpenID Connect provider to a user
+	UserIdentity struct {
+		ID           string    `json:"id"`
+		UserID       string    `json:"user_id"`
+		Provider     string    `json:"provider"`
+		Subject      string    `json:"subject"`
+		EmailAddress string    `json:"email_address"`
+		CreatedAt    time.Time `json:"created_at"`
+	}
+	// OIDCAuthRequest an authorization code request sent to an OpenID Connect provider, kept by the
+	// user agent until the provider redirects it back to the callback route, URL is never kept
+	OIDCAuthRequest struct {
+		Provider     string    `json:"provider"`
+		State        string    `json:"state"`
+		Nonce        string    `json:"nonce"`
+		CodeVerifier string    `json:"code_verifier"`
+		URL          string    `json:"-"`
+		ExpiresAt    time.Time `json:"expires_at"`
+	}
+	// OIDCClaims the verified claims of an OpenID Connect ID token
+	OIDCClaims struct {
+		Subject       string
+		Email         string
+		EmailVerified bool
+		GivenName     string
+		FamilyName    string
+		Name          string
+	}
+	// UserIdentityRepository interface
+	UserIdentityRepository interface {
+		GetIdentity(ctx context.Context, provider, subject string) (UserIdentity, error)
+		CreateIdentity(ctx context.Context, identity *UserIdentity) error
+	}
+	// SocialLoginUseCase interface
+	SocialLoginUseCase interface {
+		StartLogin(ctx context.Context, provider string) (OIDCAuthRequest, error)
+		CompleteLogin(ctx context.Context, authRequest *OIDCAuthRequest, provider, state, code string) (string, error)
+	}
+)
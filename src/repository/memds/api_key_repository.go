@@ -0,0 +1,88 @@
+package memds
+
+import (
+	"context"
+	"sherman/src/app/utils/terr"
+	"sherman/src/domain/auth"
+	"sort"
+)
+
+// apiKeyRepository in memory implementation of auth.APIKeyRepository, keys belong to a user of the
+// same DB and are removed with it
+type apiKeyRepository struct {
+	DB *DB
+}
+
+// NewAPIKeyRepository constructor
+func NewAPIKeyRepository(db *DB) auth.APIKeyRepository {
+	return &apiKeyRepository{
+		DB: db,
+	}
+}
+
+// CreateAPIKey persist a auth.APIKey in the datastore, key hashes are unique
+func (r *apiKeyRepository) CreateAPIKey(ctx context.Context, apiKey *auth.APIKey) error {
+	db := r.DB.scope(ctx)
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	if _, ok := db.users[apiKey.UserID]; !ok {
+		return terr.NewNotFoundError("user not found")
+	}
+	for id, storedAPIKey := range db.apiKeys {
+		if id == apiKey.ID || storedAPIKey.KeyHash == apiKey.KeyHash {
+			return terr.NewDuplicateEntryError("api key already exist")
+		}
+	}
+
+	db.apiKeys[apiKey.ID] = *apiKey
+	return nil
+}
+
+// GetAPIKeysByUserID gets the auth.APIKey(s) of a user from the datastore, most recent first
+func (r *apiKeyRepository) GetAPIKeysByUserID(ctx context.Context, userID string) ([]auth.APIKey, error) {
+	db := r.DB.scope(ctx)
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+
+	apiKeys := make([]auth.APIKey, 0)
+	for _, apiKey := range db.apiKeys {
+		if apiKey.UserID == userID {
+			apiKeys = append(apiKeys, apiKey)
+		}
+	}
+
+	sort.Slice(apiKeys, func(i, j int) bool {
+		return apiKeys[i].CreatedAt.After(apiKeys[j].CreatedAt)
+	})
+	return apiKeys, nil
+}
+
+// GetAPIKeyByHash gets the auth.APIKey of a key hash from the datastore
+func (r *apiKeyRepository) GetAPIKeyByHash(ctx context.Context, keyHash string) (auth.APIKey, error) {
+	db := r.DB.scope(ctx)
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+
+	for _, apiKey := range db.apiKeys {
+		if apiKey.KeyHash == keyHash {
+			return apiKey, nil
+		}
+	}
+	return auth.APIKey{}, terr.NewNotFoundError("api key not found")
+}
+
+// RemoveAPIKey removes an auth.APIKey of a user from the datastore
+func (r *apiKeyRepository) RemoveAPIKey(ctx context.Context, userID, id string) error {
+	db := r.DB.scope(ctx)
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	apiKey, ok := db.apiKeys[id]
+	if !ok || apiKey.UserID != userID {
+		return terr.NewNotFoundError("api key not found")
+	}
+
+	delete(db.apiKeys, id)
+	return nil
+}
@@ -0,0 +1,59 @@
+package memds
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	_ "sherman/src/app/testing"
+	"sherman/src/app/utils/terr"
+	"sherman/src/domain/auth"
+	"testing"
+	"time"
+)
+
+func TestCreateAPIKey(t *testing.T) {
+	t.Run("should return the keys of the user most recent first", func(t *testing.T) {
+		apiKeyRepo := NewAPIKeyRepository(genUserDB(t))
+		now := time.Now()
+		apiKey := auth.APIKey{ID: "some-id", UserID: "some-user-id", KeyHash: "some-hash", CreatedAt: now}
+		otherAPIKey := auth.APIKey{ID: "other-id", UserID: "some-user-id", KeyHash: "other-hash", CreatedAt: now.Add(time.Second)}
+		assert.NoError(t, apiKeyRepo.CreateAPIKey(context.Background(), &apiKey))
+		assert.NoError(t, apiKeyRepo.CreateAPIKey(context.Background(), &otherAPIKey))
+
+		apiKeys, err := apiKeyRepo.GetAPIKeysByUserID(context.Background(), "some-user-id")
+
+		if assert.NoError(t, err) {
+			assert.Equal(t, []auth.APIKey{otherAPIKey, apiKey}, apiKeys)
+		}
+	})
+
+	t.Run("should return a duplicate entry error", func(t *testing.T) {
+		apiKeyRepo := NewAPIKeyRepository(genUserDB(t))
+		assert.NoError(t, apiKeyRepo.CreateAPIKey(context.Background(), &auth.APIKey{ID: "some-id", UserID: "some-user-id", KeyHash: "some-hash"}))
+
+		err := apiKeyRepo.CreateAPIKey(context.Background(), &auth.APIKey{ID: "other-id", UserID: "some-user-id", KeyHash: "some-hash"})
+
+		assert.Equal(t, terr.NewDuplicateEntryError("api key already exist"), err)
+	})
+}
+
+func TestRemoveAPIKey(t *testing.T) {
+	t.Run("should not remove the key of another user", func(t *testing.T) {
+		apiKeyRepo := NewAPIKeyRepository(genUserDB(t))
+		assert.NoError(t, apiKeyRepo.CreateAPIKey(context.Background(), &auth.APIKey{ID: "some-id", UserID: "some-user-id", KeyHash: "some-hash"}))
+
+		err := apiKeyRepo.RemoveAPIKey(context.Background(), "other-user-id", "some-id")
+
+		assert.Equal(t, terr.NewNotFoundError("api key not found"), err)
+	})
+
+	t.Run("should be removed with the user", func(t *testing.T) {
+		db := genUserDB(t)
+		apiKeyRepo := NewAPIKeyRepository(db)
+		assert.NoError(t, apiKeyRepo.CreateAPIKey(context.Background(), &auth.APIKey{ID: "some-id", UserID: "some-user-id", KeyHash: "some-hash"}))
+		assert.NoError(t, NewUserRepository(db).DeleteUser(context.Background(), "some-user-id"))
+
+		_, err := apiKeyRepo.GetAPIKeyByHash(context.Background(), "some-hash")
+
+		assert.Equal(t, terr.NewNotFoundError("api key not found"), err)
+	})
+}
@@ -0,0 +1,28 @@
+package memds
+
+import (
+	"context"
+	"sherman/src/domain/auth"
+)
+
+// auditLogRepository in memory implementation of auth.AuditLogRepository
+type auditLogRepository struct {
+	DB *DB
+}
+
+// NewAuditLogRepository constructor
+func NewAuditLogRepository(db *DB) auth.AuditLogRepository {
+	return &auditLogRepository{
+		DB: db,
+	}
+}
+
+// CreateAuditLog persist a auth.AuditLog in the datastore
+func (r *auditLogRepository) CreateAuditLog(ctx context.Context, auditLog *auth.AuditLog) error {
+	db := r.DB.scope(ctx)
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	db.auditLogs[auditLog.ID] = *auditLog
+	return nil
+}
@@ -0,0 +1,56 @@
+package memds
+
+import (
+	"context"
+	"sherman/src/app/utils/terr"
+	"sherman/src/domain/auth"
+)
+
+// authorizationCodeRepository in memory implementation of auth.AuthorizationCodeRepository, codes
+// belong to a user of the same DB and are removed with it
+type authorizationCodeRepository struct {
+	DB *DB
+}
+
+// NewAuthorizationCodeRepository constructor
+func NewAuthorizationCodeRepository(db *DB) auth.AuthorizationCodeRepository {
+	return &authorizationCodeRepository{
+		DB: db,
+	}
+}
+
+// CreateAuthorizationCode persist a auth.AuthorizationCode in the datastore
+func (r *authorizationCodeRepository) CreateAuthorizationCode(ctx context.Context, code *auth.AuthorizationCode) error {
+	db := r.DB.scope(ctx)
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	if _, ok := db.users[code.UserID]; !ok {
+		return terr.NewNotFoundError("user not found")
+	}
+	if _, ok := db.oauthClients[code.ClientID]; !ok {
+		return terr.NewNotFoundError("oauth client not found")
+	}
+	if _, ok := db.authorizationCodes[code.CodeHash]; ok {
+		return terr.NewDuplicateEntryError("authorization code already exist")
+	}
+
+	db.authorizationCodes[code.CodeHash] = *code
+	return nil
+}
+
+// ConsumeAuthorizationCode gets and removes a auth.AuthorizationCode from the datastore, the removal
+// is the use of the code so concurrent uses of the same code get a not found error
+func (r *authorizationCodeRepository) ConsumeAuthorizationCode(ctx context.Context, codeHash string) (auth.AuthorizationCode, error) {
+	db := r.DB.scope(ctx)
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	code, ok := db.authorizationCodes[codeHash]
+	if !ok {
+		return auth.AuthorizationCode{}, terr.NewNotFoundError("authorization code not found")
+	}
+
+	delete(db.authorizationCodes, codeHash)
+	return code, nil
+}
@@ -0,0 +1,15 @@
+package memds
+
+import (
+	_ "sherman/src/app/testing"
+	"sherman/src/domain/auth"
+	"sherman/src/repository/repositorytest"
+	"testing"
+)
+
+func TestConformance(t *testing.T) {
+	repositorytest.Run(t, func(t *testing.T) (auth.UserRepository, auth.SecurityTokenRepository, auth.UnitOfWork) {
+		db := NewDB()
+		return NewUserRepository(db), NewSecurityTokenRepository(db), NewUnitOfWork(db)
+	})
+}
@@ -0,0 +1,164 @@
+package memds
+
+import (
+	"context"
+	"sherman/src/domain/auth"
+	"sync"
+	"time"
+)
+
+// DB in memory tables shared by the repositories built on it, as a *sql.DB is for the sql
+// repositories, its data is lost when the process stops
+type DB struct {
+	mu             sync.RWMutex
+	users          map[string]userRecord
+	securityTokens map[string]auth.SecurityToken
+	// uniqueTypes the unique_type column of the security tokens, by token id, only set on the
+	// tokens persisted by CreateOrUpdateToken
+	uniqueTypes   map[string]string
+	revokedTokens map[string]auth.RevokedToken
+	auditLogs     map[string]auth.AuditLog
+	roles         map[string]auth.Role
+	// rolePermissions the names of the permissions granted by a role, by role id
+	rolePermissions    map[string][]string
+	userRoles          map[userRoleKey]auth.UserRole
+	twoFactors         map[string]auth.TwoFactor
+	recoveryCodes      map[string]auth.RecoveryCode
+	userIdentities     map[string]auth.UserIdentity
+	oauthClients       map[string]auth.OAuthClient
+	authorizationCodes map[string]auth.AuthorizationCode
+	apiKeys            map[string]auth.APIKey
+}
+
+// userRoleKey primary key of a stored auth.UserRole
+type userRoleKey struct {
+	userID string
+	roleID string
+}
+
+// userRecord a stored auth.User, DeletedAt is zero while the user is not soft deleted
+type userRecord struct {
+	User      auth.User
+	DeletedAt time.Time
+}
+
+// txKey context key of the transaction begun by a unitOfWork
+type txKey struct{}
+
+// txValue a DB and the copy of its tables a transaction works on
+type txValue struct {
+	db      *DB
+	staging *DB
+}
+
+// adminRoleID id of the admin role, the one seeded by the migrations
+const adminRoleID = "7d3f5b1e-0c4a-4e8e-9a51-2f6b8c0d1a01"
+
+// NewDB constructor, the admin role is seeded with its permissions as the migrations do
+func NewDB() *DB {
+	db := newDB()
+
+	now := time.Now()
+	db.roles[adminRoleID] = auth.Role{ID: adminRoleID, Name: auth.AdminRole, CreatedAt: now, UpdatedAt: now}
+	db.rolePermissions[adminRoleID] = []string{
+		auth.ReadUsersPermission,
+		auth.ManageRolesPermission,
+		auth.ManageUsersPermission,
+		auth.ManageClientsPermission,
+	}
+	return db
+}
+
+// newDB makes a DB without any row
+func newDB() *DB {
+	return &DB{
+		users:              make(map[string]userRecord),
+		securityTokens:     make(map[string]auth.SecurityToken),
+		uniqueTypes:        make(map[string]string),
+		revokedTokens:      make(map[string]auth.RevokedToken),
+		auditLogs:          make(map[string]auth.AuditLog),
+		roles:              make(map[string]auth.Role),
+		rolePermissions:    make(map[string][]string),
+		userRoles:          make(map[userRoleKey]auth.UserRole),
+		twoFactors:         make(map[string]auth.TwoFactor),
+		recoveryCodes:      make(map[string]auth.RecoveryCode),
+		userIdentities:     make(map[string]auth.UserIdentity),
+		oauthClients:       make(map[string]auth.OAuthClient),
+		authorizationCodes: make(map[string]auth.AuthorizationCode),
+		apiKeys:            make(map[string]auth.APIKey),
+	}
+}
+
+// scope returns the copy of db the transaction of ctx works on, db itself outside of a transaction
+func (db *DB) scope(ctx context.Context) *DB {
+	if value, ok := ctx.Value(txKey{}).(txValue); ok && value.db == db {
+		return value.staging
+	}
+	return db
+}
+
+// clone copies the tables of db, callers hold the lock
+func (db *DB) clone() *DB {
+	clone := newDB()
+	for id, record := range db.users {
+		clone.users[id] = record
+	}
+	for id, token := range db.securityTokens {
+		clone.securityTokens[id] = token
+	}
+	for id, uniqueType := range db.uniqueTypes {
+		clone.uniqueTypes[id] = uniqueType
+	}
+	for id, revokedToken := range db.revokedTokens {
+		clone.revokedTokens[id] = revokedToken
+	}
+	for id, auditLog := range db.auditLogs {
+		clone.auditLogs[id] = auditLog
+	}
+	for id, role := range db.roles {
+		clone.roles[id] = role
+	}
+	for id, permissions := range db.rolePermissions {
+		clone.rolePermissions[id] = permissions
+	}
+	for key, userRole := range db.userRoles {
+		clone.userRoles[key] = userRole
+	}
+	for userID, twoFactor := range db.twoFactors {
+		clone.twoFactors[userID] = twoFactor
+	}
+	for id, recoveryCode := range db.recoveryCodes {
+		clone.recoveryCodes[id] = recoveryCode
+	}
+	for id, identity := range db.userIdentities {
+		clone.userIdentities[id] = identity
+	}
+	for id, client := range db.oauthClients {
+		clone.oauthClients[id] = client
+	}
+	for codeHash, code := range db.authorizationCodes {
+		clone.authorizationCodes[codeHash] = code
+	}
+	for id, apiKey := range db.apiKeys {
+		clone.apiKeys[id] = apiKey
+	}
+	return clone
+}
+
+// commit replaces the tables of db by the ones of staging, callers hold the lock
+func (db *DB) commit(staging *DB) {
+	db.users = staging.users
+	db.securityTokens = staging.securityTokens
+	db.uniqueTypes = staging.uniqueTypes
+	db.revokedTokens = staging.revokedTokens
+	db.auditLogs = staging.auditLogs
+	db.roles = staging.roles
+	db.rolePermissions = staging.rolePermissions
+	db.userRoles = staging.userRoles
+	db.twoFactors = staging.twoFactors
+	db.recoveryCodes = staging.recoveryCodes
+	db.userIdentities = staging.userIdentities
+	db.oauthClients = staging.oauthClients
+	db.authorizationCodes = staging.authorizationCodes
+	db.apiKeys = staging.apiKeys
+}
@@ -0,0 +1,81 @@
+package memds
+
+import (
+	"context"
+	"sherman/src/app/utils/terr"
+	"sherman/src/domain/auth"
+	"sync"
+	"time"
+)
+
+// loginAttemptRepository in memory implementation of auth.LoginAttemptRepository, login attempts
+// are only known by the process that saved them
+type loginAttemptRepository struct {
+	mu            sync.RWMutex
+	loginAttempts map[string]auth.LoginAttempt
+}
+
+// NewLoginAttemptRepository constructor
+func NewLoginAttemptRepository() auth.LoginAttemptRepository {
+	return &loginAttemptRepository{
+		loginAttempts: make(map[string]auth.LoginAttempt),
+	}
+}
+
+// GetLoginAttempt gets the auth.LoginAttempt of a key
+func (r *loginAttemptRepository) GetLoginAttempt(ctx context.Context, key string) (auth.LoginAttempt, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	loginAttempt, ok := r.loginAttempts[key]
+	if !ok {
+		return auth.LoginAttempt{}, terr.NewNotFoundError("login attempt not found")
+	}
+	return loginAttempt, nil
+}
+
+// SaveLoginAttempt stores a auth.LoginAttempt, replacing the one of the same key
+func (r *loginAttemptRepository) SaveLoginAttempt(ctx context.Context, loginAttempt *auth.LoginAttempt) error {
+	r.mu.Lock()
+	r.loginAttempts[loginAttempt.Key] = *loginAttempt
+	r.mu.Unlock()
+	return nil
+}
+
+// UpdateLoginAttempt applies update to the auth.LoginAttempt of a key, or to a new one, and stores it
+// while holding the lock, a concurrent update of the key waits for it
+func (r *loginAttemptRepository) UpdateLoginAttempt(ctx context.Context, key string, update func(loginAttempt *auth.LoginAttempt)) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	loginAttempt, ok := r.loginAttempts[key]
+	if !ok {
+		loginAttempt = auth.LoginAttempt{Key: key}
+	}
+
+	update(&loginAttempt)
+	loginAttempt.Key = key
+	r.loginAttempts[key] = loginAttempt
+	return nil
+}
+
+// RemoveLoginAttempt removes the auth.LoginAttempt of a key, a missing key is a no-op
+func (r *loginAttemptRepository) RemoveLoginAttempt(ctx context.Context, key string) error {
+	r.mu.Lock()
+	delete(r.loginAttempts, key)
+	r.mu.Unlock()
+	return nil
+}
+
+// RemoveStaleLoginAttempts removes the login attempts without failure nor lock since before
+func (r *loginAttemptRepository) RemoveStaleLoginAttempts(ctx context.Context, before time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for key, loginAttempt := range r.loginAttempts {
+		if loginAttempt.LastFailureAt.Before(before) && loginAttempt.LockedUntil.Before(before) {
+			delete(r.loginAttempts, key)
+		}
+	}
+	return nil
+}
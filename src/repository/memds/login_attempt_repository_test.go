@@ -0,0 +1,116 @@
+package memds
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	_ "sherman/src/app/testing"
+	"sherman/src/app/utils/terr"
+	"sherman/src/domain/auth"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestGetLoginAttempt(t *testing.T) {
+	t.Run("should return a login attempt", func(t *testing.T) {
+		loginAttemptRepo := NewLoginAttemptRepository()
+		mockLoginAttempt := auth.LoginAttempt{Key: "ip:10.0.0.1", Failures: 2, LastFailureAt: time.Now()}
+		assert.NoError(t, loginAttemptRepo.SaveLoginAttempt(context.Background(), &mockLoginAttempt))
+
+		loginAttempt, err := loginAttemptRepo.GetLoginAttempt(context.Background(), "ip:10.0.0.1")
+
+		if assert.NoError(t, err) {
+			assert.Equal(t, mockLoginAttempt, loginAttempt)
+		}
+	})
+
+	t.Run("should return a not found error", func(t *testing.T) {
+		loginAttemptRepo := NewLoginAttemptRepository()
+
+		_, err := loginAttemptRepo.GetLoginAttempt(context.Background(), "ip:10.0.0.1")
+
+		assert.Equal(t, terr.NewNotFoundError("login attempt not found"), err)
+	})
+}
+
+func TestSaveLoginAttempt(t *testing.T) {
+	loginAttemptRepo := NewLoginAttemptRepository()
+	assert.NoError(t, loginAttemptRepo.SaveLoginAttempt(context.Background(), &auth.LoginAttempt{Key: "ip:10.0.0.1", Failures: 1}))
+	assert.NoError(t, loginAttemptRepo.SaveLoginAttempt(context.Background(), &auth.LoginAttempt{Key: "ip:10.0.0.1", Failures: 2}))
+
+	loginAttempt, err := loginAttemptRepo.GetLoginAttempt(context.Background(), "ip:10.0.0.1")
+
+	if assert.NoError(t, err) {
+		assert.Equal(t, 2, loginAttempt.Failures)
+	}
+}
+
+func TestUpdateLoginAttempt(t *testing.T) {
+	t.Run("should update a new login attempt", func(t *testing.T) {
+		loginAttemptRepo := NewLoginAttemptRepository()
+
+		err := loginAttemptRepo.UpdateLoginAttempt(context.Background(), "ip:10.0.0.1", func(loginAttempt *auth.LoginAttempt) {
+			loginAttempt.Failures++
+		})
+
+		if assert.NoError(t, err) {
+			loginAttempt, err := loginAttemptRepo.GetLoginAttempt(context.Background(), "ip:10.0.0.1")
+			if assert.NoError(t, err) {
+				assert.Equal(t, auth.LoginAttempt{Key: "ip:10.0.0.1", Failures: 1}, loginAttempt)
+			}
+		}
+	})
+
+	t.Run("should apply concurrent updates one after the other", func(t *testing.T) {
+		loginAttemptRepo := NewLoginAttemptRepository()
+
+		var wg sync.WaitGroup
+		for i := 0; i < 50; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				_ = loginAttemptRepo.UpdateLoginAttempt(context.Background(), "ip:10.0.0.1", func(loginAttempt *auth.LoginAttempt) {
+					loginAttempt.Failures++
+				})
+			}()
+		}
+		wg.Wait()
+
+		loginAttempt, err := loginAttemptRepo.GetLoginAttempt(context.Background(), "ip:10.0.0.1")
+		if assert.NoError(t, err) {
+			assert.Equal(t, 50, loginAttempt.Failures)
+		}
+	})
+}
+
+func TestRemoveLoginAttempt(t *testing.T) {
+	loginAttemptRepo := NewLoginAttemptRepository()
+	assert.NoError(t, loginAttemptRepo.SaveLoginAttempt(context.Background(), &auth.LoginAttempt{Key: "ip:10.0.0.1", Failures: 1}))
+
+	assert.NoError(t, loginAttemptRepo.RemoveLoginAttempt(context.Background(), "ip:10.0.0.1"))
+	assert.NoError(t, loginAttemptRepo.RemoveLoginAttempt(context.Background(), "ip:10.0.0.1"))
+
+	_, err := loginAttemptRepo.GetLoginAttempt(context.Background(), "ip:10.0.0.1")
+	assert.Error(t, err)
+}
+
+func TestRemoveStaleLoginAttempts(t *testing.T) {
+	loginAttemptRepo := NewLoginAttemptRepository()
+	now := time.Now()
+	_ = loginAttemptRepo.SaveLoginAttempt(context.Background(), &auth.LoginAttempt{Key: "stale", LastFailureAt: now.Add(-time.Hour)})
+	_ = loginAttemptRepo.SaveLoginAttempt(context.Background(), &auth.LoginAttempt{Key: "recent", LastFailureAt: now})
+	_ = loginAttemptRepo.SaveLoginAttempt(context.Background(), &auth.LoginAttempt{
+		Key:           "locked",
+		LastFailureAt: now.Add(-time.Hour),
+		LockedUntil:   now.Add(time.Hour),
+	})
+
+	assert.NoError(t, loginAttemptRepo.RemoveStaleLoginAttempts(context.Background(), now.Add(-time.Minute)))
+
+	_, err := loginAttemptRepo.GetLoginAttempt(context.Background(), "stale")
+	assert.Error(t, err)
+	_, err = loginAttemptRepo.GetLoginAttempt(context.Background(), "recent")
+	assert.NoError(t, err)
+	_, err = loginAttemptRepo.GetLoginAttempt(context.Background(), "locked")
+	assert.NoError(t, err)
+}
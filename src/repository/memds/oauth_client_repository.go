@@ -0,0 +1,64 @@
+package memds
+
+import (
+	"context"
+	"sherman/src/app/utils/terr"
+	"sherman/src/domain/auth"
+	"time"
+)
+
+// oauthClientRepository in memory implementation of auth.OAuthClientRepository
+type oauthClientRepository struct {
+	DB *DB
+}
+
+// NewOAuthClientRepository constructor
+func NewOAuthClientRepository(db *DB) auth.OAuthClientRepository {
+	return &oauthClientRepository{
+		DB: db,
+	}
+}
+
+// CreateClient persist a auth.OAuthClient in the datastore
+func (r *oauthClientRepository) CreateClient(ctx context.Context, client *auth.OAuthClient) error {
+	db := r.DB.scope(ctx)
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	if _, ok := db.oauthClients[client.ID]; ok {
+		return terr.NewDuplicateEntryError("oauth client already exist")
+	}
+
+	db.oauthClients[client.ID] = *client
+	return nil
+}
+
+// UpdateClientSecret replaces the secret hash of an auth.OAuthClient in the datastore
+func (r *oauthClientRepository) UpdateClientSecret(ctx context.Context, id, secretHash string, updatedAt time.Time) error {
+	db := r.DB.scope(ctx)
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	client, ok := db.oauthClients[id]
+	if !ok {
+		return terr.NewNotFoundError("oauth client not found")
+	}
+
+	client.SecretHash = secretHash
+	client.UpdatedAt = updatedAt
+	db.oauthClients[id] = client
+	return nil
+}
+
+// GetClientByID gets a auth.OAuthClient from the datastore
+func (r *oauthClientRepository) GetClientByID(ctx context.Context, id string) (auth.OAuthClient, error) {
+	db := r.DB.scope(ctx)
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+
+	client, ok := db.oauthClients[id]
+	if !ok {
+		return auth.OAuthClient{}, terr.NewNotFoundError("oauth client not found")
+	}
+	return client, nil
+}
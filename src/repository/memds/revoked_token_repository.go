@@ -0,0 +1,55 @@
+package memds
+
+import (
+	"context"
+	"sherman/src/domain/auth"
+	"time"
+)
+
+// revokedTokenRepository in memory implementation of auth.RevokedTokenRepository
+type revokedTokenRepository struct {
+	DB *DB
+}
+
+// NewRevokedTokenRepository constructor
+func NewRevokedTokenRepository(db *DB) auth.RevokedTokenRepository {
+	return &revokedTokenRepository{
+		DB: db,
+	}
+}
+
+// CreateRevokedToken persist a auth.RevokedToken in the datastore, revoking an already revoked token is a no-op
+func (r *revokedTokenRepository) CreateRevokedToken(ctx context.Context, revokedToken *auth.RevokedToken) error {
+	db := r.DB.scope(ctx)
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	if _, ok := db.revokedTokens[revokedToken.ID]; !ok {
+		db.revokedTokens[revokedToken.ID] = *revokedToken
+	}
+	return nil
+}
+
+// IsTokenRevoked checks if a token id is persisted in the datastore
+func (r *revokedTokenRepository) IsTokenRevoked(ctx context.Context, tokenID string) (bool, error) {
+	db := r.DB.scope(ctx)
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+
+	_, ok := db.revokedTokens[tokenID]
+	return ok, nil
+}
+
+// RemoveExpiredRevokedTokens removes the revoked tokens expired before now from the datastore
+func (r *revokedTokenRepository) RemoveExpiredRevokedTokens(ctx context.Context, now time.Time) error {
+	db := r.DB.scope(ctx)
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	for id, revokedToken := range db.revokedTokens {
+		if revokedToken.ExpiresAt.Before(now) {
+			delete(db.revokedTokens, id)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,116 @@
+package memds
+
+import (
+	"context"
+	"sherman/src/app/utils/terr"
+	"sherman/src/domain/auth"
+	"sort"
+)
+
+// roleRepository in memory implementation of auth.RoleRepository, the roles and their permissions
+// are the ones seeded by NewDB
+type roleRepository struct {
+	DB *DB
+}
+
+// NewRoleRepository constructor
+func NewRoleRepository(db *DB) auth.RoleRepository {
+	return &roleRepository{
+		DB: db,
+	}
+}
+
+// GetRoleByName gets a auth.Role by name from the datastore
+func (r *roleRepository) GetRoleByName(ctx context.Context, name string) (auth.Role, error) {
+	db := r.DB.scope(ctx)
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+
+	for _, role := range db.roles {
+		if role.Name == name {
+			return role, nil
+		}
+	}
+	return auth.Role{}, terr.NewNotFoundError("role not found")
+}
+
+// GetRoleNamesByUserID gets the names of the roles assigned to a user from the datastore
+func (r *roleRepository) GetRoleNamesByUserID(ctx context.Context, userID string) ([]string, error) {
+	db := r.DB.scope(ctx)
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+
+	roleNames := make([]string, 0)
+	for key := range db.userRoles {
+		if key.userID == userID {
+			roleNames = append(roleNames, db.roles[key.roleID].Name)
+		}
+	}
+
+	sort.Strings(roleNames)
+	return roleNames, nil
+}
+
+// GetPermissionsByRoleNames gets the names of the permissions granted by a set of roles from the datastore
+func (r *roleRepository) GetPermissionsByRoleNames(ctx context.Context, roleNames []string) ([]string, error) {
+	db := r.DB.scope(ctx)
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+
+	granted := make(map[string]bool)
+	for id, role := range db.roles {
+		for _, roleName := range roleNames {
+			if role.Name != roleName {
+				continue
+			}
+			for _, permission := range db.rolePermissions[id] {
+				granted[permission] = true
+			}
+		}
+	}
+
+	permissions := make([]string, 0, len(granted))
+	for permission := range granted {
+		permissions = append(permissions, permission)
+	}
+
+	sort.Strings(permissions)
+	return permissions, nil
+}
+
+// CreateUserRole persist a auth.UserRole in the datastore
+func (r *roleRepository) CreateUserRole(ctx context.Context, userRole *auth.UserRole) error {
+	db := r.DB.scope(ctx)
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	if _, ok := db.users[userRole.UserID]; !ok {
+		return terr.NewNotFoundError("user not found")
+	}
+	if _, ok := db.roles[userRole.RoleID]; !ok {
+		return terr.NewNotFoundError("role not found")
+	}
+
+	key := userRoleKey{userID: userRole.UserID, roleID: userRole.RoleID}
+	if _, ok := db.userRoles[key]; ok {
+		return terr.NewDuplicateEntryError("role already assigned")
+	}
+
+	db.userRoles[key] = *userRole
+	return nil
+}
+
+// RemoveUserRole removes a auth.UserRole from the datastore
+func (r *roleRepository) RemoveUserRole(ctx context.Context, userID, roleID string) error {
+	db := r.DB.scope(ctx)
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	key := userRoleKey{userID: userID, roleID: roleID}
+	if _, ok := db.userRoles[key]; !ok {
+		return terr.NewNotFoundError("role not assigned")
+	}
+
+	delete(db.userRoles, key)
+	return nil
+}
@@ -0,0 +1,69 @@
+package memds
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	_ "sherman/src/app/testing"
+	"sherman/src/app/utils/terr"
+	"sherman/src/domain/auth"
+	"testing"
+	"time"
+)
+
+// genUserDB makes a DB with the user "some-user-id"
+func genUserDB(t *testing.T) *DB {
+	db := NewDB()
+	user := &auth.User{ID: "some-user-id", EmailAddress: "some@email.com", CreatedAt: time.Now(), UpdatedAt: time.Now()}
+	assert.NoError(t, NewUserRepository(db).CreateUser(context.Background(), user))
+	return db
+}
+
+func TestCreateUserRole(t *testing.T) {
+	t.Run("should assign the seeded admin role and its permissions", func(t *testing.T) {
+		roleRepo := NewRoleRepository(genUserDB(t))
+
+		role, err := roleRepo.GetRoleByName(context.Background(), auth.AdminRole)
+		if assert.NoError(t, err) {
+			userRole := &auth.UserRole{UserID: "some-user-id", RoleID: role.ID, CreatedAt: time.Now()}
+			assert.NoError(t, roleRepo.CreateUserRole(context.Background(), userRole))
+		}
+
+		roleNames, err := roleRepo.GetRoleNamesByUserID(context.Background(), "some-user-id")
+		if assert.NoError(t, err) {
+			assert.Equal(t, []string{auth.AdminRole}, roleNames)
+		}
+
+		permissions, err := roleRepo.GetPermissionsByRoleNames(context.Background(), roleNames)
+		if assert.NoError(t, err) {
+			assert.Equal(t, []string{"clients:manage", "roles:manage", "users:manage", "users:read"}, permissions)
+		}
+	})
+
+	t.Run("should return a duplicate entry error", func(t *testing.T) {
+		roleRepo := NewRoleRepository(genUserDB(t))
+		userRole := &auth.UserRole{UserID: "some-user-id", RoleID: adminRoleID, CreatedAt: time.Now()}
+		assert.NoError(t, roleRepo.CreateUserRole(context.Background(), userRole))
+
+		err := roleRepo.CreateUserRole(context.Background(), userRole)
+
+		assert.Equal(t, terr.NewDuplicateEntryError("role already assigned"), err)
+	})
+
+	t.Run("should return a not found error for an unknown user", func(t *testing.T) {
+		roleRepo := NewRoleRepository(NewDB())
+
+		err := roleRepo.CreateUserRole(context.Background(), &auth.UserRole{UserID: "some-user-id", RoleID: adminRoleID})
+
+		assert.Equal(t, terr.NewNotFoundError("user not found"), err)
+	})
+}
+
+func TestRemoveUserRole(t *testing.T) {
+	t.Run("should return a not found error", func(t *testing.T) {
+		roleRepo := NewRoleRepository(genUserDB(t))
+
+		err := roleRepo.RemoveUserRole(context.Background(), "some-user-id", adminRoleID)
+
+		assert.Equal(t, terr.NewNotFoundError("role not assigned"), err)
+	})
+}
@@ -0,0 +1,184 @@
+package memds
+
+import (
+	"context"
+	"sherman/src/app/utils/terr"
+	"sherman/src/domain/auth"
+	"sort"
+)
+
+// securityTokenRepository in memory implementation of auth.SecurityTokenRepository, tokens belong to
+// a user of the same DB and are removed with it
+type securityTokenRepository struct {
+	DB *DB
+}
+
+// NewSecurityTokenRepository constructor
+func NewSecurityTokenRepository(db *DB) auth.SecurityTokenRepository {
+	return &securityTokenRepository{
+		DB: db,
+	}
+}
+
+// createToken stores a new token, callers hold the lock
+func (db *DB) createToken(token *auth.SecurityToken) error {
+	if _, ok := db.users[token.UserID]; !ok {
+		return terr.NewNotFoundError("user not found")
+	}
+	if _, ok := db.securityTokens[token.ID]; ok {
+		return terr.NewDuplicateEntryError("token already exist")
+	}
+
+	db.securityTokens[token.ID] = *token
+	return nil
+}
+
+// updateToken stores the new value of an existing token, callers hold the lock
+func (db *DB) updateToken(id string, token *auth.SecurityToken) {
+	storedToken, ok := db.securityTokens[id]
+	if !ok {
+		return
+	}
+
+	storedToken.Token = token.Token
+	storedToken.LastUsedAt = token.LastUsedAt
+	storedToken.UpdatedAt = token.UpdatedAt
+	db.securityTokens[id] = storedToken
+}
+
+// removeToken removes a stored token, callers hold the lock
+func (db *DB) removeToken(id string) {
+	delete(db.securityTokens, id)
+	delete(db.uniqueTypes, id)
+}
+
+// CreateToken persist a new auth.SecurityToken in the datastore
+func (r *securityTokenRepository) CreateToken(ctx context.Context, token *auth.SecurityToken) error {
+	db := r.DB.scope(ctx)
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	return db.createToken(token)
+}
+
+// CreateOrUpdateToken persist a auth.SecurityToken in the datastore, replacing the token of the same user and type
+// persisted by a previous CreateOrUpdateToken
+func (r *securityTokenRepository) CreateOrUpdateToken(ctx context.Context, token *auth.SecurityToken) error {
+	db := r.DB.scope(ctx)
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	for id, storedToken := range db.securityTokens {
+		if storedToken.UserID == token.UserID && db.uniqueTypes[id] == token.Type {
+			db.updateToken(id, token)
+			return nil
+		}
+	}
+
+	if err := db.createToken(token); err != nil {
+		return err
+	}
+	db.uniqueTypes[token.ID] = token.Type
+	return nil
+}
+
+// GetTokenByMetadata finds the exact auth.SecurityToken in the datastore, the active token of a family takes precedence
+func (r *securityTokenRepository) GetTokenByMetadata(ctx context.Context, tokenMetadata *auth.TokenMetadata) (auth.SecurityToken, error) {
+	db := r.DB.scope(ctx)
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+
+	var token *auth.SecurityToken
+	for _, storedToken := range db.securityTokens {
+		if storedToken.UserID != tokenMetadata.UserID ||
+			storedToken.Type != tokenMetadata.Type ||
+			storedToken.Token != tokenMetadata.Token {
+			continue
+		}
+		if token == nil || (token.Rotated && !storedToken.Rotated) {
+			foundToken := storedToken
+			token = &foundToken
+		}
+	}
+
+	if token == nil {
+		return auth.SecurityToken{}, terr.NewNotFoundError("token not found")
+	}
+	return *token, nil
+}
+
+// GetTokensByUserID gets the active auth.SecurityToken(s) of a user and type from the datastore, most recently used first
+func (r *securityTokenRepository) GetTokensByUserID(ctx context.Context, userID, tokenType string) ([]auth.SecurityToken, error) {
+	db := r.DB.scope(ctx)
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+
+	tokens := make([]auth.SecurityToken, 0)
+	for _, storedToken := range db.securityTokens {
+		if storedToken.UserID == userID && storedToken.Type == tokenType && !storedToken.Rotated {
+			tokens = append(tokens, storedToken)
+		}
+	}
+
+	sort.Slice(tokens, func(i, j int) bool {
+		return tokens[i].LastUsedAt.After(tokens[j].LastUsedAt)
+	})
+	return tokens, nil
+}
+
+// RotateToken stores the new value of a token and keeps its previous value as a rotated token of the same family,
+// both or neither, a token that no longer has the previous value was rotated concurrently and returns a
+// terr.UnAuthorizedError
+func (r *securityTokenRepository) RotateToken(ctx context.Context, token, rotatedToken *auth.SecurityToken) error {
+	db := r.DB.scope(ctx)
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	storedToken, ok := db.securityTokens[token.ID]
+	if !ok || storedToken.Token != rotatedToken.Token || storedToken.Rotated {
+		return terr.NewUnAuthorizedError("refresh token already rotated")
+	}
+
+	previousToken := *rotatedToken
+	previousToken.Rotated = true
+	if err := db.createToken(&previousToken); err != nil {
+		return err
+	}
+
+	db.updateToken(token.ID, token)
+	return nil
+}
+
+// RemoveTokenByMetadata removes every token of the user and type from the datastore
+func (r *securityTokenRepository) RemoveTokenByMetadata(ctx context.Context, tokenMetadata *auth.TokenMetadata) error {
+	db := r.DB.scope(ctx)
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	for id, storedToken := range db.securityTokens {
+		if storedToken.UserID == tokenMetadata.UserID && storedToken.Type == tokenMetadata.Type {
+			db.removeToken(id)
+		}
+	}
+	return nil
+}
+
+// RemoveTokenFamily removes every token of a family from the datastore
+func (r *securityTokenRepository) RemoveTokenFamily(ctx context.Context, userID, familyID string) error {
+	db := r.DB.scope(ctx)
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	removed := false
+	for id, storedToken := range db.securityTokens {
+		if storedToken.UserID == userID && storedToken.FamilyID == familyID {
+			db.removeToken(id)
+			removed = true
+		}
+	}
+
+	if !removed {
+		return terr.NewNotFoundError("token not found")
+	}
+	return nil
+}
@@ -0,0 +1,124 @@
+package memds
+
+import (
+	"context"
+	"sherman/src/app/utils/terr"
+	"sherman/src/domain/auth"
+	"time"
+)
+
+// twoFactorRepository in memory implementation of auth.TwoFactorRepository, two factor authentications
+// and recovery codes belong to a user of the same DB and are removed with it
+type twoFactorRepository struct {
+	DB *DB
+}
+
+// NewTwoFactorRepository constructor
+func NewTwoFactorRepository(db *DB) auth.TwoFactorRepository {
+	return &twoFactorRepository{
+		DB: db,
+	}
+}
+
+// removeRecoveryCodes removes the recovery codes of a user, callers hold the lock
+func (db *DB) removeRecoveryCodes(userID string) {
+	for id, recoveryCode := range db.recoveryCodes {
+		if recoveryCode.UserID == userID {
+			delete(db.recoveryCodes, id)
+		}
+	}
+}
+
+// GetTwoFactor gets the auth.TwoFactor of a user from the datastore
+func (r *twoFactorRepository) GetTwoFactor(ctx context.Context, userID string) (auth.TwoFactor, error) {
+	db := r.DB.scope(ctx)
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+
+	twoFactor, ok := db.twoFactors[userID]
+	if !ok {
+		return auth.TwoFactor{}, terr.NewNotFoundError("two factor authentication not found")
+	}
+	return twoFactor, nil
+}
+
+// CreateOrUpdateTwoFactor persist a auth.TwoFactor in the datastore, replacing the one of the same user
+func (r *twoFactorRepository) CreateOrUpdateTwoFactor(ctx context.Context, twoFactor *auth.TwoFactor) error {
+	db := r.DB.scope(ctx)
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	if _, ok := db.users[twoFactor.UserID]; !ok {
+		return terr.NewNotFoundError("user not found")
+	}
+
+	storedTwoFactor := *twoFactor
+	if previous, ok := db.twoFactors[twoFactor.UserID]; ok {
+		storedTwoFactor.CreatedAt = previous.CreatedAt
+	}
+	db.twoFactors[twoFactor.UserID] = storedTwoFactor
+	return nil
+}
+
+// UpdateTwoFactorLastUsedStep moves the last used TOTP period of a user forward in the datastore,
+// an older or equal period is a replayed code and gets a unauthorized error
+func (r *twoFactorRepository) UpdateTwoFactorLastUsedStep(ctx context.Context, userID string, lastUsedStep int64, updatedAt time.Time) error {
+	db := r.DB.scope(ctx)
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	twoFactor, ok := db.twoFactors[userID]
+	if !ok || twoFactor.LastUsedStep >= lastUsedStep {
+		return terr.NewUnAuthorizedError("code already used")
+	}
+
+	twoFactor.LastUsedStep = lastUsedStep
+	twoFactor.UpdatedAt = updatedAt
+	db.twoFactors[userID] = twoFactor
+	return nil
+}
+
+// RemoveTwoFactor removes the auth.TwoFactor and the recovery codes of a user from the datastore
+func (r *twoFactorRepository) RemoveTwoFactor(ctx context.Context, userID string) error {
+	db := r.DB.scope(ctx)
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	db.removeRecoveryCodes(userID)
+	if _, ok := db.twoFactors[userID]; !ok {
+		return terr.NewNotFoundError("two factor authentication not found")
+	}
+
+	delete(db.twoFactors, userID)
+	return nil
+}
+
+// ReplaceRecoveryCodes replaces the recovery codes of a user in the datastore
+func (r *twoFactorRepository) ReplaceRecoveryCodes(ctx context.Context, userID string, recoveryCodes []auth.RecoveryCode) error {
+	db := r.DB.scope(ctx)
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	db.removeRecoveryCodes(userID)
+	for _, recoveryCode := range recoveryCodes {
+		recoveryCode.UserID = userID
+		db.recoveryCodes[recoveryCode.ID] = recoveryCode
+	}
+	return nil
+}
+
+// RemoveRecoveryCode removes a recovery code of a user from the datastore, the removal is the use
+// of the code so concurrent uses of the same code find nothing to remove
+func (r *twoFactorRepository) RemoveRecoveryCode(ctx context.Context, userID, codeHash string) error {
+	db := r.DB.scope(ctx)
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	for id, recoveryCode := range db.recoveryCodes {
+		if recoveryCode.UserID == userID && recoveryCode.CodeHash == codeHash {
+			delete(db.recoveryCodes, id)
+			return nil
+		}
+	}
+	return terr.NewNotFoundError("recovery code not found")
+}
@@ -0,0 +1,58 @@
+package memds
+
+import (
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	_ "sherman/src/app/testing"
+	"sherman/src/app/utils/terr"
+	"sherman/src/domain/auth"
+	"testing"
+	"time"
+)
+
+func TestUpdateTwoFactorLastUsedStep(t *testing.T) {
+	t.Run("should reject a replayed step", func(t *testing.T) {
+		twoFactorRepo := NewTwoFactorRepository(genUserDB(t))
+		assert.NoError(t, twoFactorRepo.CreateOrUpdateTwoFactor(context.Background(), &auth.TwoFactor{UserID: "some-user-id", LastUsedStep: 5}))
+
+		assert.NoError(t, twoFactorRepo.UpdateTwoFactorLastUsedStep(context.Background(), "some-user-id", 6, time.Now()))
+		err := twoFactorRepo.UpdateTwoFactorLastUsedStep(context.Background(), "some-user-id", 6, time.Now())
+
+		assert.Equal(t, terr.NewUnAuthorizedError("code already used"), err)
+	})
+}
+
+func TestRecoveryCodes(t *testing.T) {
+	t.Run("should replace the codes and use each once", func(t *testing.T) {
+		twoFactorRepo := NewTwoFactorRepository(genUserDB(t))
+		assert.NoError(t, twoFactorRepo.ReplaceRecoveryCodes(context.Background(), "some-user-id", []auth.RecoveryCode{
+			{ID: "old-id", CodeHash: "old-hash"},
+		}))
+		assert.NoError(t, twoFactorRepo.ReplaceRecoveryCodes(context.Background(), "some-user-id", []auth.RecoveryCode{
+			{ID: "some-id", CodeHash: "some-hash"},
+		}))
+
+		assert.Equal(t, terr.NewNotFoundError("recovery code not found"),
+			twoFactorRepo.RemoveRecoveryCode(context.Background(), "some-user-id", "old-hash"))
+		assert.NoError(t, twoFactorRepo.RemoveRecoveryCode(context.Background(), "some-user-id", "some-hash"))
+		assert.Equal(t, terr.NewNotFoundError("recovery code not found"),
+			twoFactorRepo.RemoveRecoveryCode(context.Background(), "some-user-id", "some-hash"))
+	})
+
+	t.Run("should roll back the codes replaced in a failed unit of work", func(t *testing.T) {
+		db := genUserDB(t)
+		twoFactorRepo := NewTwoFactorRepository(db)
+		assert.NoError(t, twoFactorRepo.ReplaceRecoveryCodes(context.Background(), "some-user-id", []auth.RecoveryCode{
+			{ID: "some-id", CodeHash: "some-hash"},
+		}))
+
+		err := NewUnitOfWork(db).Do(context.Background(), func(ctx context.Context) error {
+			assert.NoError(t, twoFactorRepo.ReplaceRecoveryCodes(ctx, "some-user-id", nil))
+			return errors.New("some error")
+		})
+
+		assert.Error(t, err)
+		assert.NoError(t, twoFactorRepo.RemoveRecoveryCode(context.Background(), "some-user-id", "some-hash"))
+	})
+}
@@ -0,0 +1,38 @@
+package memds
+
+import (
+	"context"
+	"sherman/src/domain/auth"
+)
+
+// unitOfWork in memory implementation of auth.UnitOfWork, a transaction works on a copy of the
+// tables which replaces them on commit, the tables stay locked until the transaction ends so that
+// operations outside of it wait for it
+type unitOfWork struct {
+	DB *DB
+}
+
+// NewUnitOfWork constructor
+func NewUnitOfWork(db *DB) auth.UnitOfWork {
+	return &unitOfWork{
+		DB: db,
+	}
+}
+
+// Do runs fn in a transaction, committed if fn returns nil and rolled back otherwise
+func (u *unitOfWork) Do(ctx context.Context, fn func(ctx context.Context) error) error {
+	if value, ok := ctx.Value(txKey{}).(txValue); ok && value.db == u.DB {
+		return fn(ctx)
+	}
+
+	u.DB.mu.Lock()
+	defer u.DB.mu.Unlock()
+
+	staging := u.DB.clone()
+	if err := fn(context.WithValue(ctx, txKey{}, txValue{db: u.DB, staging: staging})); err != nil {
+		return err
+	}
+
+	u.DB.commit(staging)
+	return nil
+}
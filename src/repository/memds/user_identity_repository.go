@@ -0,0 +1,56 @@
+package memds
+
+import (
+	"context"
+	"sherman/src/app/utils/terr"
+	"sherman/src/domain/auth"
+)
+
+// userIdentityRepository in memory implementation of auth.UserIdentityRepository, identities belong
+// to a user of the same DB and are removed with it
+type userIdentityRepository struct {
+	DB *DB
+}
+
+// NewUserIdentityRepository constructor
+func NewUserIdentityRepository(db *DB) auth.UserIdentityRepository {
+	return &userIdentityRepository{
+		DB: db,
+	}
+}
+
+// GetIdentity gets the auth.UserIdentity of a provider subject from the datastore
+func (r *userIdentityRepository) GetIdentity(ctx context.Context, provider, subject string) (auth.UserIdentity, error) {
+	db := r.DB.scope(ctx)
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+
+	for _, identity := range db.userIdentities {
+		if identity.Provider == provider && identity.Subject == subject {
+			return identity, nil
+		}
+	}
+	return auth.UserIdentity{}, terr.NewNotFoundError("user identity not found")
+}
+
+// CreateIdentity persist a auth.UserIdentity in the datastore, a provider subject is linked to a single user
+func (r *userIdentityRepository) CreateIdentity(ctx context.Context, identity *auth.UserIdentity) error {
+	db := r.DB.scope(ctx)
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	if _, ok := db.users[identity.UserID]; !ok {
+		return terr.NewNotFoundError("user not found")
+	}
+	if _, ok := db.userIdentities[identity.ID]; ok {
+		return terr.NewDuplicateEntryError("user identity already exist")
+	}
+	for _, storedIdentity := range db.userIdentities {
+		if storedIdentity.Provider == identity.Provider && storedIdentity.Subject == identity.Subject {
+			return terr.NewDuplicateEntryError("user identity already exist")
+		}
+	}
+
+	db.userIdentities[identity.ID] = *identity
+	return nil
+}
@@ -0,0 +1,211 @@
+package memds
+
+import (
+	"context"
+	"sherman/src/app/utils/terr"
+	"sherman/src/domain/auth"
+	"time"
+)
+
+// userRepository in memory implementation of auth.UserRepository, email addresses are unique
+// among every stored user, soft deleted or not
+type userRepository struct {
+	DB *DB
+}
+
+// NewUserRepository constructor
+func NewUserRepository(db *DB) auth.UserRepository {
+	return &userRepository{
+		DB: db,
+	}
+}
+
+// emailTaken tells whether another user than id has the email address, callers hold the lock
+func (db *DB) emailTaken(email, id string) bool {
+	for _, record := range db.users {
+		if record.User.EmailAddress == email && record.User.ID != id {
+			return true
+		}
+	}
+	return false
+}
+
+// activeRecord gets the record of a non deleted user, callers hold the lock
+func (db *DB) activeRecord(id string) (userRecord, bool) {
+	record, ok := db.users[id]
+	if !ok || !record.DeletedAt.IsZero() {
+		return userRecord{}, false
+	}
+	return record, true
+}
+
+// CreateUser persist a auth.User from the datastore
+func (r *userRepository) CreateUser(ctx context.Context, user *auth.User) error {
+	db := r.DB.scope(ctx)
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	if _, ok := db.users[user.ID]; ok || db.emailTaken(user.EmailAddress, user.ID) {
+		return terr.NewDuplicateEntryError("user already exist")
+	}
+
+	storedUser := *user
+	storedUser.NewPassword = ""
+	db.users[user.ID] = userRecord{User: storedUser}
+	return nil
+}
+
+// UpdateUser updates a auth.User in the datastore
+func (r *userRepository) UpdateUser(ctx context.Context, user *auth.User) error {
+	db := r.DB.scope(ctx)
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	record, ok := db.activeRecord(user.ID)
+	if !ok {
+		return terr.NewNotFoundError("user not found")
+	}
+	if db.emailTaken(user.EmailAddress, user.ID) {
+		return terr.NewDuplicateEntryError("user already exist")
+	}
+
+	record.User.FirstName = user.FirstName
+	record.User.LastName = user.LastName
+	record.User.EmailAddress = user.EmailAddress
+	record.User.Password = user.Password
+	record.User.Active = user.Active
+	record.User.EmailVerified = user.EmailVerified
+	record.User.UpdatedAt = user.UpdatedAt
+	db.users[user.ID] = record
+	return nil
+}
+
+// UpdateUserPassword updates the password hash of a auth.User in the datastore
+func (r *userRepository) UpdateUserPassword(ctx context.Context, id, password string, updatedAt time.Time) error {
+	db := r.DB.scope(ctx)
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	record, ok := db.activeRecord(id)
+	if !ok {
+		return terr.NewNotFoundError("user not found")
+	}
+
+	record.User.Password = password
+	record.User.UpdatedAt = updatedAt
+	db.users[id] = record
+	return nil
+}
+
+// UpdateUserActive activates or deactivates a auth.User in the datastore
+func (r *userRepository) UpdateUserActive(ctx context.Context, id string, active bool, updatedAt time.Time) error {
+	db := r.DB.scope(ctx)
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	record, ok := db.activeRecord(id)
+	if !ok {
+		return terr.NewNotFoundError("user not found")
+	}
+
+	record.User.Active = active
+	record.User.UpdatedAt = updatedAt
+	db.users[id] = record
+	return nil
+}
+
+// SoftDeleteUser deactivates a auth.User and flags it as deleted in the datastore, deleted users
+// are no longer found but their data is kept
+func (r *userRepository) SoftDeleteUser(ctx context.Context, id string, deletedAt time.Time) error {
+	db := r.DB.scope(ctx)
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	record, ok := db.activeRecord(id)
+	if !ok {
+		return terr.NewNotFoundError("user not found")
+	}
+
+	record.User.Active = false
+	record.User.UpdatedAt = deletedAt
+	record.DeletedAt = deletedAt
+	db.users[id] = record
+	return nil
+}
+
+// DeleteUser removes a auth.User from the datastore, soft deleted or not, with the rows that belong to it
+func (r *userRepository) DeleteUser(ctx context.Context, id string) error {
+	db := r.DB.scope(ctx)
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	if _, ok := db.users[id]; !ok {
+		return terr.NewNotFoundError("user not found")
+	}
+
+	delete(db.users, id)
+	for tokenID, token := range db.securityTokens {
+		if token.UserID == id {
+			db.removeToken(tokenID)
+		}
+	}
+	for tokenID, revokedToken := range db.revokedTokens {
+		if revokedToken.UserID == id {
+			delete(db.revokedTokens, tokenID)
+		}
+	}
+	for key := range db.userRoles {
+		if key.userID == id {
+			delete(db.userRoles, key)
+		}
+	}
+	delete(db.twoFactors, id)
+	for codeID, recoveryCode := range db.recoveryCodes {
+		if recoveryCode.UserID == id {
+			delete(db.recoveryCodes, codeID)
+		}
+	}
+	for identityID, identity := range db.userIdentities {
+		if identity.UserID == id {
+			delete(db.userIdentities, identityID)
+		}
+	}
+	for codeHash, code := range db.authorizationCodes {
+		if code.UserID == id {
+			delete(db.authorizationCodes, codeHash)
+		}
+	}
+	for apiKeyID, apiKey := range db.apiKeys {
+		if apiKey.UserID == id {
+			delete(db.apiKeys, apiKeyID)
+		}
+	}
+	return nil
+}
+
+// GetUserByID gets a non deleted auth.User by id in the datastore
+func (r *userRepository) GetUserByID(ctx context.Context, id string) (auth.User, error) {
+	db := r.DB.scope(ctx)
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+
+	record, ok := db.activeRecord(id)
+	if !ok {
+		return auth.User{}, terr.NewNotFoundError("user not found")
+	}
+	return record.User, nil
+}
+
+// GetUserByEmail gets a non deleted auth.User by email from the datastore
+func (r *userRepository) GetUserByEmail(ctx context.Context, email string) (auth.User, error) {
+	db := r.DB.scope(ctx)
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+
+	for _, record := range db.users {
+		if record.User.EmailAddress == email && record.DeletedAt.IsZero() {
+			return record.User, nil
+		}
+	}
+	return auth.User{}, terr.NewNotFoundError("user not found")
+}
@@ -0,0 +1,178 @@
+package memds
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"sherman/src/app/utils/terr"
+	"sherman/src/domain/auth"
+	"sort"
+	"strings"
+	"time"
+)
+
+// userCursor position of the last user of a page, it is only valid with the sort key it was made for
+type userCursor struct {
+	SortKey string `json:"k"`
+	Value   string `json:"v"`
+	ID      string `json:"id"`
+}
+
+// ListUsers gets a page of non deleted auth.User(s) from the datastore, pages are keyed on the sort
+// field and the id so that they stay consistent while users are added
+func (r *userRepository) ListUsers(ctx context.Context, query auth.UserQuery) (auth.UserPage, error) {
+	db := r.DB.scope(ctx)
+	if !isUserSortKey(query.SortKey) {
+		return auth.UserPage{}, terr.NewInvalidQueryError("invalid sort key")
+	}
+	if query.Limit < 1 {
+		return auth.UserPage{}, terr.NewInvalidQueryError("invalid limit")
+	}
+
+	var cursorUser *auth.User
+	if query.Cursor != "" {
+		user, err := decodeUserCursor(query.Cursor, query.SortKey)
+		if err != nil {
+			return auth.UserPage{}, err
+		}
+		cursorUser = &user
+	}
+
+	db.mu.RLock()
+	users := make([]auth.User, 0)
+	for _, record := range db.users {
+		if record.DeletedAt.IsZero() && matchesUserQuery(&record.User, &query) {
+			users = append(users, record.User)
+		}
+	}
+	db.mu.RUnlock()
+
+	total := len(users)
+	sort.Slice(users, func(i, j int) bool {
+		order := compareUsers(&users[i], &users[j], query.SortKey)
+		if query.SortDesc {
+			return order > 0
+		}
+		return order < 0
+	})
+
+	if cursorUser != nil {
+		users = users[sort.Search(len(users), func(i int) bool {
+			order := compareUsers(&users[i], cursorUser, query.SortKey)
+			if query.SortDesc {
+				return order < 0
+			}
+			return order > 0
+		}):]
+	}
+
+	page := auth.UserPage{Users: users, Total: total}
+	if len(users) > query.Limit {
+		page.Users = users[:query.Limit]
+		page.NextCursor = encodeUserCursor(&page.Users[query.Limit-1], query.SortKey)
+	}
+	return page, nil
+}
+
+// isUserSortKey tells whether users can be sorted on sortKey
+func isUserSortKey(sortKey string) bool {
+	for _, userSortKey := range auth.UserSortKeys {
+		if sortKey == userSortKey {
+			return true
+		}
+	}
+	return false
+}
+
+// matchesUserQuery tells whether user matches the filters of query, email prefixes are case
+// insensitive as the LIKE of the sql datastores
+func matchesUserQuery(user *auth.User, query *auth.UserQuery) bool {
+	if query.Active != nil && user.Active != *query.Active {
+		return false
+	}
+	if !query.CreatedFrom.IsZero() && user.CreatedAt.Before(query.CreatedFrom) {
+		return false
+	}
+	if !query.CreatedTo.IsZero() && !user.CreatedAt.Before(query.CreatedTo) {
+		return false
+	}
+	if query.EmailPrefix != "" && !strings.HasPrefix(strings.ToLower(user.EmailAddress), strings.ToLower(query.EmailPrefix)) {
+		return false
+	}
+	return true
+}
+
+// compareUsers orders a and b on sortKey then on their id
+func compareUsers(a, b *auth.User, sortKey string) int {
+	var order int
+	switch sortKey {
+	case "created_at":
+		switch {
+		case a.CreatedAt.Before(b.CreatedAt):
+			order = -1
+		case a.CreatedAt.After(b.CreatedAt):
+			order = 1
+		}
+	case "email_address":
+		order = strings.Compare(a.EmailAddress, b.EmailAddress)
+	case "first_name":
+		order = strings.Compare(a.FirstName, b.FirstName)
+	case "last_name":
+		order = strings.Compare(a.LastName, b.LastName)
+	}
+
+	if order == 0 {
+		return strings.Compare(a.ID, b.ID)
+	}
+	return order
+}
+
+// encodeUserCursor returns the opaque cursor of the position of user in a listing sorted by sortKey
+func encodeUserCursor(user *auth.User, sortKey string) string {
+	cursor := userCursor{SortKey: sortKey, ID: user.ID}
+	switch sortKey {
+	case "created_at":
+		cursor.Value = user.CreatedAt.UTC().Format(time.RFC3339Nano)
+	case "email_address":
+		cursor.Value = user.EmailAddress
+	case "first_name":
+		cursor.Value = user.FirstName
+	case "last_name":
+		cursor.Value = user.LastName
+	}
+
+	data, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// decodeUserCursor returns a user holding the id and the sort field value of a cursor made for sortKey
+func decodeUserCursor(encodedCursor, sortKey string) (auth.User, error) {
+	invalidCursorErr := terr.NewInvalidQueryError("invalid cursor")
+
+	data, err := base64.RawURLEncoding.DecodeString(encodedCursor)
+	if err != nil {
+		return auth.User{}, invalidCursorErr
+	}
+
+	var cursor userCursor
+	if err := json.Unmarshal(data, &cursor); err != nil || cursor.SortKey != sortKey || cursor.ID == "" {
+		return auth.User{}, invalidCursorErr
+	}
+
+	user := auth.User{ID: cursor.ID}
+	switch sortKey {
+	case "created_at":
+		createdAt, err := time.Parse(time.RFC3339Nano, cursor.Value)
+		if err != nil {
+			return auth.User{}, invalidCursorErr
+		}
+		user.CreatedAt = createdAt
+	case "email_address":
+		user.EmailAddress = cursor.Value
+	case "first_name":
+		user.FirstName = cursor.Value
+	case "last_name":
+		user.LastName = cursor.Value
+	}
+	return user, nil
+}
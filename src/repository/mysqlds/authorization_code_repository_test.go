@@ -0,0 +1,153 @@
+package mysqlds
+
+import (
+	"context"
+	"database/sql"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	_ "sherman/src/app/testing"
+	"sherman/src/app/utils/terr"
+	"sherman/src/domain/auth"
+	"testing"
+	"time"
+)
+
+func TestCreateAuthorizationCode(t *testing.T) {
+	code := &auth.AuthorizationCode{
+		CodeHash:      "some-hash",
+		ClientID:      "some-client-id",
+		UserID:        "some-user-id",
+		RedirectURI:   "https://app.test/callback",
+		Scopes:        []string{auth.ProfileScope, auth.AccountScope},
+		CodeChallenge: "some-challenge",
+		ExpiresAt:     time.Now().Add(time.Minute),
+		CreatedAt:     time.Now(),
+	}
+
+	t.Run("should insert", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		if err != nil {
+			t.Fatalf("an error '%s' was not expected", err)
+		}
+		defer db.Close()
+
+		codeRepo := NewAuthorizationCodeRepository(db, time.Second)
+
+		mock.
+			ExpectExec("INSERT authorization_codes SET").
+			WithArgs(
+				code.CodeHash,
+				code.ClientID,
+				code.UserID,
+				code.RedirectURI,
+				"profile account",
+				code.CodeChallenge,
+				code.ExpiresAt,
+				code.CreatedAt,
+			).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+
+		assert.NoError(t, codeRepo.CreateAuthorizationCode(context.Background(), code))
+	})
+}
+
+func TestConsumeAuthorizationCode(t *testing.T) {
+	now := time.Now()
+	columns := []string{
+		"code_hash",
+		"client_id",
+		"user_id",
+		"redirect_uri",
+		"scopes",
+		"code_challenge",
+		"expires_at",
+		"created_at",
+	}
+
+	t.Run("should return and remove a code", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		if err != nil {
+			t.Fatalf("an error '%s' was not expected", err)
+		}
+		defer db.Close()
+
+		codeRepo := NewAuthorizationCodeRepository(db, time.Second)
+
+		mock.
+			ExpectQuery("SELECT code_hash, client_id, user_id, redirect_uri, scopes, code_challenge, expires_at, created_at FROM authorization_codes").
+			WithArgs("some-hash").
+			WillReturnRows(sqlmock.NewRows(columns).AddRow(
+				"some-hash",
+				"some-client-id",
+				"some-user-id",
+				"https://app.test/callback",
+				"profile",
+				"some-challenge",
+				now,
+				now,
+			))
+		mock.
+			ExpectExec("DELETE FROM authorization_codes").
+			WithArgs("some-hash").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		code, err := codeRepo.ConsumeAuthorizationCode(context.Background(), "some-hash")
+
+		if assert.NoError(t, err) {
+			assert.Equal(t, auth.AuthorizationCode{
+				CodeHash:      "some-hash",
+				ClientID:      "some-client-id",
+				UserID:        "some-user-id",
+				RedirectURI:   "https://app.test/callback",
+				Scopes:        []string{auth.ProfileScope},
+				CodeChallenge: "some-challenge",
+				ExpiresAt:     now,
+				CreatedAt:     now,
+			}, code)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		}
+	})
+
+	t.Run("should return a not found error", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		if err != nil {
+			t.Fatalf("an error '%s' was not expected", err)
+		}
+		defer db.Close()
+
+		codeRepo := NewAuthorizationCodeRepository(db, time.Second)
+
+		mock.
+			ExpectQuery("SELECT code_hash").
+			WithArgs("some-hash").
+			WillReturnError(sql.ErrNoRows)
+
+		_, err = codeRepo.ConsumeAuthorizationCode(context.Background(), "some-hash")
+
+		assert.Equal(t, terr.NewNotFoundError("authorization code not found"), err)
+	})
+
+	t.Run("should return a not found error when already consumed", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		if err != nil {
+			t.Fatalf("an error '%s' was not expected", err)
+		}
+		defer db.Close()
+
+		codeRepo := NewAuthorizationCodeRepository(db, time.Second)
+
+		mock.
+			ExpectQuery("SELECT code_hash").
+			WithArgs("some-hash").
+			WillReturnRows(sqlmock.NewRows(columns).
+				AddRow("some-hash", "some-client-id", "some-user-id", "", "", "", now, now))
+		mock.
+			ExpectExec("DELETE FROM authorization_codes").
+			WithArgs("some-hash").
+			WillReturnResult(sqlmock.NewResult(0, 0))
+
+		_, err = codeRepo.ConsumeAuthorizationCode(context.Background(), "some-hash")
+
+		assert.Equal(t, terr.NewNotFoundError("authorization code not found"), err)
+	})
+}
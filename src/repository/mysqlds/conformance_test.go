@@ -0,0 +1,36 @@
+package mysqlds
+
+import (
+	"database/sql"
+	"time"
+	// mysql driver import
+	_ "github.com/go-sql-driver/mysql"
+	"os"
+	_ "sherman/src/app/testing"
+	"sherman/src/domain/auth"
+	"sherman/src/repository/repositorytest"
+	"testing"
+)
+
+// TestConformance runs against the migrated mysql database of MYSQL_TEST_DSN (with parseTime=true),
+// its users and security tokens are removed, it is skipped without MYSQL_TEST_DSN
+func TestConformance(t *testing.T) {
+	dsn := os.Getenv("MYSQL_TEST_DSN")
+	if dsn == "" {
+		t.Skip("MYSQL_TEST_DSN is not set")
+	}
+
+	db, err := sql.Open("mysql", dsn)
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected", err)
+	}
+	defer db.Close()
+
+	repositorytest.Run(t, func(t *testing.T) (auth.UserRepository, auth.SecurityTokenRepository, auth.UnitOfWork) {
+		// security tokens are removed by cascade
+		if _, err := db.Exec(`DELETE FROM users`); err != nil {
+			t.Fatalf("an error '%s' was not expected", err)
+		}
+		return NewUserRepository(db, time.Second), NewSecurityTokenRepository(db, time.Second), NewUnitOfWork(db)
+	})
+}
@@ -0,0 +1,237 @@
+package mysqlds
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	_ "sherman/src/app/testing"
+	"sherman/src/app/utils/terr"
+	"sherman/src/domain/auth"
+	"testing"
+	"time"
+)
+
+func TestGetLoginAttempt(t *testing.T) {
+	now := time.Now()
+	columns := []string{"attempt_key", "failures", "lockouts", "locked_until", "last_failure_at"}
+
+	t.Run("should return a login attempt", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		if err != nil {
+			t.Fatalf("an error '%s' was not expected", err)
+		}
+		defer db.Close()
+
+		loginAttemptRepo := NewLoginAttemptRepository(db, time.Second)
+
+		mock.
+			ExpectQuery("SELECT attempt_key, failures, lockouts, locked_until, last_failure_at FROM login_attempts").
+			WithArgs("account:some@email.com").
+			WillReturnRows(sqlmock.NewRows(columns).AddRow("account:some@email.com", 0, 1, now, now))
+
+		loginAttempt, err := loginAttemptRepo.GetLoginAttempt(context.Background(), "account:some@email.com")
+
+		if assert.NoError(t, err) {
+			assert.Equal(t, auth.LoginAttempt{
+				Key:           "account:some@email.com",
+				Lockouts:      1,
+				LockedUntil:   now,
+				LastFailureAt: now,
+			}, loginAttempt)
+		}
+	})
+
+	t.Run("should return a login attempt without lock", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		if err != nil {
+			t.Fatalf("an error '%s' was not expected", err)
+		}
+		defer db.Close()
+
+		loginAttemptRepo := NewLoginAttemptRepository(db, time.Second)
+
+		mock.
+			ExpectQuery("SELECT attempt_key, failures, lockouts, locked_until, last_failure_at FROM login_attempts").
+			WithArgs("ip:10.0.0.1").
+			WillReturnRows(sqlmock.NewRows(columns).AddRow("ip:10.0.0.1", 2, 0, nil, now))
+
+		loginAttempt, err := loginAttemptRepo.GetLoginAttempt(context.Background(), "ip:10.0.0.1")
+
+		if assert.NoError(t, err) {
+			assert.Equal(t, 2, loginAttempt.Failures)
+			assert.True(t, loginAttempt.LockedUntil.IsZero())
+		}
+	})
+
+	t.Run("should return a not found error", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		if err != nil {
+			t.Fatalf("an error '%s' was not expected", err)
+		}
+		defer db.Close()
+
+		loginAttemptRepo := NewLoginAttemptRepository(db, time.Second)
+
+		mock.
+			ExpectQuery("SELECT attempt_key").
+			WithArgs("ip:10.0.0.1").
+			WillReturnError(sql.ErrNoRows)
+
+		_, err = loginAttemptRepo.GetLoginAttempt(context.Background(), "ip:10.0.0.1")
+
+		if assert.Error(t, err) {
+			assert.Equal(t, terr.NewNotFoundError("login attempt not found"), err)
+		}
+	})
+}
+
+func TestSaveLoginAttempt(t *testing.T) {
+	now := time.Now()
+
+	t.Run("should insert or update", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		if err != nil {
+			t.Fatalf("an error '%s' was not expected", err)
+		}
+		defer db.Close()
+
+		loginAttemptRepo := NewLoginAttemptRepository(db, time.Second)
+
+		mock.
+			ExpectExec("INSERT login_attempts SET .* ON DUPLICATE KEY UPDATE").
+			WithArgs("ip:10.0.0.1", 1, 0, sql.NullTime{}, now).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		err = loginAttemptRepo.SaveLoginAttempt(context.Background(), &auth.LoginAttempt{Key: "ip:10.0.0.1", Failures: 1, LastFailureAt: now})
+
+		assert.NoError(t, err)
+	})
+
+	t.Run("should return an error", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		if err != nil {
+			t.Fatalf("an error '%s' was not expected", err)
+		}
+		defer db.Close()
+
+		loginAttemptRepo := NewLoginAttemptRepository(db, time.Second)
+
+		mockError := errors.New("some error")
+		mock.
+			ExpectExec("INSERT login_attempts SET").
+			WithArgs("ip:10.0.0.1", 0, 1, sql.NullTime{Time: now, Valid: true}, now).
+			WillReturnError(mockError)
+
+		err = loginAttemptRepo.SaveLoginAttempt(context.Background(), &auth.LoginAttempt{
+			Key:           "ip:10.0.0.1",
+			Lockouts:      1,
+			LockedUntil:   now,
+			LastFailureAt: now,
+		})
+
+		assert.Equal(t, mockError, err)
+	})
+}
+
+func TestUpdateLoginAttempt(t *testing.T) {
+	now := time.Now()
+	columns := []string{"attempt_key", "failures", "lockouts", "locked_until", "last_failure_at"}
+
+	t.Run("should update the locked login attempt in a transaction", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		if err != nil {
+			t.Fatalf("an error '%s' was not expected", err)
+		}
+		defer db.Close()
+
+		loginAttemptRepo := NewLoginAttemptRepository(db, time.Second)
+
+		mock.ExpectBegin()
+		mock.
+			ExpectExec("INSERT login_attempts SET attempt_key=\\?, last_failure_at=\\? ON DUPLICATE KEY UPDATE attempt_key=attempt_key").
+			WithArgs("ip:10.0.0.1", sqlmock.AnyArg()).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.
+			ExpectQuery("SELECT attempt_key, failures, lockouts, locked_until, last_failure_at FROM login_attempts WHERE attempt_key = \\? LIMIT 1 FOR UPDATE").
+			WithArgs("ip:10.0.0.1").
+			WillReturnRows(sqlmock.NewRows(columns).AddRow("ip:10.0.0.1", 2, 0, nil, now))
+		mock.
+			ExpectExec("INSERT login_attempts SET .* ON DUPLICATE KEY UPDATE failures").
+			WithArgs("ip:10.0.0.1", 3, 0, sql.NullTime{}, now).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		err = loginAttemptRepo.UpdateLoginAttempt(context.Background(), "ip:10.0.0.1", func(loginAttempt *auth.LoginAttempt) {
+			loginAttempt.Failures++
+		})
+
+		assert.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("should rollback on error", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		if err != nil {
+			t.Fatalf("an error '%s' was not expected", err)
+		}
+		defer db.Close()
+
+		loginAttemptRepo := NewLoginAttemptRepository(db, time.Second)
+
+		mockError := errors.New("some error")
+		mock.ExpectBegin()
+		mock.
+			ExpectExec("INSERT").
+			WithArgs("ip:10.0.0.1", sqlmock.AnyArg()).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.
+			ExpectQuery("SELECT attempt_key").
+			WithArgs("ip:10.0.0.1").
+			WillReturnError(mockError)
+		mock.ExpectRollback()
+
+		err = loginAttemptRepo.UpdateLoginAttempt(context.Background(), "ip:10.0.0.1", func(loginAttempt *auth.LoginAttempt) {
+			loginAttempt.Failures++
+		})
+
+		assert.Equal(t, mockError, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestRemoveLoginAttempt(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected", err)
+	}
+	defer db.Close()
+
+	loginAttemptRepo := NewLoginAttemptRepository(db, time.Second)
+
+	mock.
+		ExpectExec("DELETE FROM login_attempts WHERE attempt_key = \\?").
+		WithArgs("account:some@email.com").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	assert.NoError(t, loginAttemptRepo.RemoveLoginAttempt(context.Background(), "account:some@email.com"))
+}
+
+func TestRemoveStaleLoginAttempts(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected", err)
+	}
+	defer db.Close()
+
+	loginAttemptRepo := NewLoginAttemptRepository(db, time.Second)
+
+	before := time.Now()
+	mock.
+		ExpectExec("DELETE FROM login_attempts WHERE last_failure_at < \\? AND \\(locked_until IS NULL OR locked_until < \\?\\)").
+		WithArgs(before, before).
+		WillReturnResult(sqlmock.NewResult(0, 3))
+
+	assert.NoError(t, loginAttemptRepo.RemoveStaleLoginAttempts(context.Background(), before))
+}
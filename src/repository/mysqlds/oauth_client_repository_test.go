@@ -0,0 +1,176 @@
+package mysqlds
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	_ "sherman/src/app/testing"
+	"sherman/src/app/utils/terr"
+	"sherman/src/domain/auth"
+	"testing"
+	"time"
+)
+
+func TestGetClientByID(t *testing.T) {
+	now := time.Now()
+	columns := []string{"id", "name", "secret_hash", "redirect_uris", "grant_types", "scopes", "created_at", "updated_at"}
+
+	t.Run("should return a client", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		if err != nil {
+			t.Fatalf("an error '%s' was not expected", err)
+		}
+		defer db.Close()
+
+		clientRepo := NewOAuthClientRepository(db, time.Second)
+
+		mock.
+			ExpectQuery("SELECT id, name, secret_hash, redirect_uris, grant_types, scopes, created_at, updated_at FROM oauth_clients").
+			WithArgs("some-client-id").
+			WillReturnRows(sqlmock.NewRows(columns).AddRow(
+				"some-client-id",
+				"some client",
+				"some-hash",
+				"https://app.test/callback com.app:/callback",
+				"authorization_code refresh_token",
+				"profile account",
+				now,
+				now,
+			))
+
+		client, err := clientRepo.GetClientByID(context.Background(), "some-client-id")
+
+		if assert.NoError(t, err) {
+			assert.Equal(t, auth.OAuthClient{
+				ID:           "some-client-id",
+				Name:         "some client",
+				SecretHash:   "some-hash",
+				RedirectURIs: []string{"https://app.test/callback", "com.app:/callback"},
+				GrantTypes:   []string{auth.AuthorizationCodeGrant, auth.RefreshTokenGrant},
+				Scopes:       []string{auth.ProfileScope, auth.AccountScope},
+				CreatedAt:    now,
+				UpdatedAt:    now,
+			}, client)
+		}
+	})
+
+	t.Run("should return a not found error", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		if err != nil {
+			t.Fatalf("an error '%s' was not expected", err)
+		}
+		defer db.Close()
+
+		clientRepo := NewOAuthClientRepository(db, time.Second)
+
+		mock.
+			ExpectQuery("SELECT id").
+			WithArgs("some-client-id").
+			WillReturnError(sql.ErrNoRows)
+
+		_, err = clientRepo.GetClientByID(context.Background(), "some-client-id")
+
+		assert.Equal(t, terr.NewNotFoundError("oauth client not found"), err)
+	})
+}
+
+func TestCreateClient(t *testing.T) {
+	now := time.Now()
+	mockClient := auth.OAuthClient{
+		ID:           "some-client-id",
+		Name:         "some client",
+		SecretHash:   "some-hash",
+		RedirectURIs: []string{"https://app.test/callback", "com.app:/callback"},
+		GrantTypes:   []string{auth.AuthorizationCodeGrant, auth.RefreshTokenGrant},
+		Scopes:       []string{auth.ProfileScope, auth.AccountScope},
+		CreatedAt:    now,
+		UpdatedAt:    now,
+	}
+
+	t.Run("should insert", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		if err != nil {
+			t.Fatalf("an error '%s' was not expected", err)
+		}
+		defer db.Close()
+
+		clientRepo := NewOAuthClientRepository(db, time.Second)
+
+		mock.
+			ExpectExec("INSERT oauth_clients").
+			WithArgs(
+				"some-client-id",
+				"some client",
+				"some-hash",
+				"https://app.test/callback com.app:/callback",
+				"authorization_code refresh_token",
+				"profile account",
+				now,
+				now,
+			).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+
+		assert.NoError(t, clientRepo.CreateClient(context.Background(), &mockClient))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("should return a duplicate entry error", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		if err != nil {
+			t.Fatalf("an error '%s' was not expected", err)
+		}
+		defer db.Close()
+
+		clientRepo := NewOAuthClientRepository(db, time.Second)
+
+		mock.
+			ExpectExec("INSERT oauth_clients").
+			WillReturnError(errors.New("Error 1062: Duplicate entry 'some-client-id' for key 'PRIMARY'"))
+
+		err = clientRepo.CreateClient(context.Background(), &mockClient)
+
+		assert.Equal(t, terr.NewDuplicateEntryError("oauth client already exist"), err)
+	})
+}
+
+func TestUpdateClientSecret(t *testing.T) {
+	now := time.Now()
+
+	t.Run("should update", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		if err != nil {
+			t.Fatalf("an error '%s' was not expected", err)
+		}
+		defer db.Close()
+
+		clientRepo := NewOAuthClientRepository(db, time.Second)
+
+		mock.
+			ExpectExec("UPDATE oauth_clients SET secret_hash").
+			WithArgs("other-hash", now, "some-client-id").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		assert.NoError(t, clientRepo.UpdateClientSecret(context.Background(), "some-client-id", "other-hash", now))
+	})
+
+	t.Run("should return a not found error", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		if err != nil {
+			t.Fatalf("an error '%s' was not expected", err)
+		}
+		defer db.Close()
+
+		clientRepo := NewOAuthClientRepository(db, time.Second)
+
+		mock.
+			ExpectExec("UPDATE oauth_clients SET secret_hash").
+			WithArgs("other-hash", now, "some-client-id").
+			WillReturnResult(sqlmock.NewResult(0, 0))
+
+		err = clientRepo.UpdateClientSecret(context.Background(), "some-client-id", "other-hash", now)
+
+		assert.Equal(t, terr.NewNotFoundError("oauth client not found"), err)
+	})
+}
@@ -0,0 +1,167 @@
+package mysqlds
+
+import (
+	"context"
+	"database/sql"
+	"sherman/src/app/database"
+	"sherman/src/app/utils/terr"
+	"sherman/src/domain/auth"
+	"strings"
+	"time"
+)
+
+// roleRepository sql implementation of auth.RoleRepository
+type roleRepository struct {
+	DB           *sql.DB
+	QueryTimeout time.Duration
+}
+
+// NewRoleRepository constructor
+func NewRoleRepository(db *sql.DB, queryTimeout time.Duration) auth.RoleRepository {
+	return &roleRepository{
+		DB:           db,
+		QueryTimeout: queryTimeout,
+	}
+}
+
+// GetRoleByName gets a auth.Role by name from the datastore
+func (r *roleRepository) GetRoleByName(ctx context.Context, name string) (auth.Role, error) {
+	ctx, cancel := database.WithQueryTimeout(ctx, r.QueryTimeout)
+	defer cancel()
+
+	var role auth.Role
+
+	query := `SELECT id, name, created_at, updated_at FROM roles WHERE name = ? LIMIT 1`
+	err := database.Conn(ctx, r.DB).QueryRowContext(ctx, query, name).Scan(
+		&role.ID,
+		&role.Name,
+		&role.CreatedAt,
+		&role.UpdatedAt,
+	)
+	if err != nil {
+		if strings.Contains(strings.ToLower(err.Error()), "no rows") {
+			err = terr.NewNotFoundError("role not found")
+		}
+		return auth.Role{}, err
+	}
+
+	return role, nil
+}
+
+// GetRoleNamesByUserID gets the names of the roles assigned to a user from the datastore
+func (r *roleRepository) GetRoleNamesByUserID(ctx context.Context, userID string) ([]string, error) {
+	ctx, cancel := database.WithQueryTimeout(ctx, r.QueryTimeout)
+	defer cancel()
+
+	query := `
+		SELECT roles.name
+		FROM roles
+		INNER JOIN user_roles ON user_roles.role_id = roles.id
+		WHERE user_roles.user_id = ?
+		ORDER BY roles.name
+	`
+
+	rows, err := database.Conn(ctx, r.DB).QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return scanStrings(rows)
+}
+
+// GetPermissionsByRoleNames gets the names of the permissions granted by a set of roles from the datastore
+func (r *roleRepository) GetPermissionsByRoleNames(ctx context.Context, roleNames []string) ([]string, error) {
+	ctx, cancel := database.WithQueryTimeout(ctx, r.QueryTimeout)
+	defer cancel()
+
+	if len(roleNames) == 0 {
+		return make([]string, 0), nil
+	}
+
+	args := make([]interface{}, len(roleNames))
+	for i, roleName := range roleNames {
+		args[i] = roleName
+	}
+
+	query := `
+		SELECT DISTINCT permissions.name
+		FROM permissions
+		INNER JOIN role_permissions ON role_permissions.permission_id = permissions.id
+		INNER JOIN roles ON roles.id = role_permissions.role_id
+		WHERE roles.name IN (?` + strings.Repeat(",?", len(roleNames)-1) + `)
+		ORDER BY permissions.name
+	`
+
+	rows, err := database.Conn(ctx, r.DB).QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	return scanStrings(rows)
+}
+
+// CreateUserRole persist a auth.UserRole in the datastore
+func (r *roleRepository) CreateUserRole(ctx context.Context, userRole *auth.UserRole) error {
+	ctx, cancel := database.WithQueryTimeout(ctx, r.QueryTimeout)
+	defer cancel()
+
+	query := `
+		INSERT user_roles
+		SET
+			user_id=?,
+			role_id=?,
+			created_at=?
+	`
+
+	_, err := database.Conn(ctx, r.DB).ExecContext(ctx, query,
+		userRole.UserID,
+		userRole.RoleID,
+		userRole.CreatedAt,
+	)
+
+	if err != nil {
+		switch errMessage := strings.ToLower(err.Error()); {
+		case strings.Contains(errMessage, "duplicate"):
+			err = terr.NewDuplicateEntryError("role already assigned")
+		case strings.Contains(errMessage, "foreign key"):
+			err = terr.NewNotFoundError("user not found")
+		}
+		return err
+	}
+
+	return nil
+}
+
+// RemoveUserRole removes a auth.UserRole from the datastore
+func (r *roleRepository) RemoveUserRole(ctx context.Context, userID, roleID string) error {
+	ctx, cancel := database.WithQueryTimeout(ctx, r.QueryTimeout)
+	defer cancel()
+
+	query := `DELETE FROM user_roles WHERE user_id = ? AND role_id = ?`
+	result, err := database.Conn(ctx, r.DB).ExecContext(ctx, query, userID, roleID)
+	if err != nil {
+		return err
+	}
+
+	if affected, err := result.RowsAffected(); err == nil && affected == 0 {
+		return terr.NewNotFoundError("role not assigned")
+	}
+
+	return nil
+}
+
+// scanStrings scans and closes rows of a single string column
+func scanStrings(rows *sql.Rows) ([]string, error) {
+	defer rows.Close()
+
+	values := make([]string, 0)
+	for rows.Next() {
+		var value string
+		if err := rows.Scan(&value); err != nil {
+			return nil, err
+		}
+		values = append(values, value)
+	}
+
+	return values, rows.Err()
+}
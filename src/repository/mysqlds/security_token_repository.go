@@ -1,77 +1,114 @@
 package mysqlds
 
 import (
+	"context"
 	"database/sql"
+	"sherman/src/app/database"
 	"sherman/src/app/utils/terr"
 	"sherman/src/domain/auth"
+	"time"
 )
 
 // securityTokenRepository sql implementation of auth.SecurityTokenRepository
 type securityTokenRepository struct {
-	DB *sql.DB
+	DB           *sql.DB
+	QueryTimeout time.Duration
 }
 
 // NewSecurityTokenRepository constructor
-func NewSecurityTokenRepository(db *sql.DB) auth.SecurityTokenRepository {
+func NewSecurityTokenRepository(db *sql.DB, queryTimeout time.Duration) auth.SecurityTokenRepository {
 	return &securityTokenRepository{
-		DB: db,
+		DB:           db,
+		QueryTimeout: queryTimeout,
 	}
 }
 
-// CreateOrUpdateToken persist a auth.SecurityToken in the datastore
-func (r *securityTokenRepository) CreateOrUpdateToken(token *auth.SecurityToken) error {
-	var err error
-	var query string
-	var existingToken auth.SecurityToken
-
-	// find token id if it exist
-	query = `SELECT id FROM security_tokens WHERE user_id = ? AND type = ? LIMIT 1`
-	row := r.DB.QueryRow(query, token.UserID, token.Type)
-	_ = row.Scan(&existingToken.ID)
-
-	switch existingToken.ID {
-	case "":
-		// no existing token -> insert
-		query = `
-			INSERT security_tokens
-			SET
-				id=?,
-				user_id=?,
-				token=?,
-			    type=?,
-				created_at=?,
-				updated_at=?
-		`
+// CreateToken persist a new auth.SecurityToken in the datastore
+func (r *securityTokenRepository) CreateToken(ctx context.Context, token *auth.SecurityToken) error {
+	ctx, cancel := database.WithQueryTimeout(ctx, r.QueryTimeout)
+	defer cancel()
 
-		_, err = r.DB.Exec(query,
-			token.ID,
-			token.UserID,
-			token.Token,
-			token.Type,
-			token.CreatedAt,
-			token.UpdatedAt,
-		)
-	default:
-		// existing token -> update
-		query = `
-			UPDATE security_tokens
-			SET
-				token=?,
-				updated_at=?
-			WHERE id = ?
-		`
-		_, err = r.DB.Exec(query,
-			token.Token,
-			token.UpdatedAt,
-			existingToken.ID,
-		)
-	}
+	query := `
+		INSERT security_tokens
+		SET
+			id=?,
+			user_id=?,
+			token=?,
+			type=?,
+			family_id=?,
+			rotated=?,
+			user_agent=?,
+			ip_address=?,
+			last_used_at=?,
+			created_at=?,
+			updated_at=?
+	`
 
+	_, err := database.Conn(ctx, r.DB).ExecContext(ctx, query,
+		token.ID,
+		token.UserID,
+		token.Token,
+		token.Type,
+		token.FamilyID,
+		token.Rotated,
+		token.UserAgent,
+		token.IPAddress,
+		token.LastUsedAt,
+		token.CreatedAt,
+		token.UpdatedAt,
+	)
 	return err
 }
 
-// GetTokenByMetadata finds a auth.SecurityToken in the datastore
-func (r *securityTokenRepository) GetTokenByMetadata(tokenMetadata *auth.TokenMetadata) (auth.SecurityToken, error) {
+// CreateOrUpdateToken persist a auth.SecurityToken in the datastore, replacing the token of the same user and type
+// persisted by a previous CreateOrUpdateToken, the insert or update is a single atomic upsert
+func (r *securityTokenRepository) CreateOrUpdateToken(ctx context.Context, token *auth.SecurityToken) error {
+	ctx, cancel := database.WithQueryTimeout(ctx, r.QueryTimeout)
+	defer cancel()
+
+	query := `
+		INSERT security_tokens
+		SET
+			id=?,
+			user_id=?,
+			token=?,
+			type=?,
+			unique_type=?,
+			family_id=?,
+			rotated=?,
+			user_agent=?,
+			ip_address=?,
+			last_used_at=?,
+			created_at=?,
+			updated_at=?
+		ON DUPLICATE KEY UPDATE
+			token=VALUES(token),
+			last_used_at=VALUES(last_used_at),
+			updated_at=VALUES(updated_at)
+	`
+
+	_, err := database.Conn(ctx, r.DB).ExecContext(ctx, query,
+		token.ID,
+		token.UserID,
+		token.Token,
+		token.Type,
+		token.Type,
+		token.FamilyID,
+		token.Rotated,
+		token.UserAgent,
+		token.IPAddress,
+		token.LastUsedAt,
+		token.CreatedAt,
+		token.UpdatedAt,
+	)
+	return err
+}
+
+// GetTokenByMetadata finds the exact auth.SecurityToken in the datastore, the active token of a family takes precedence
+func (r *securityTokenRepository) GetTokenByMetadata(ctx context.Context, tokenMetadata *auth.TokenMetadata) (auth.SecurityToken, error) {
+	ctx, cancel := database.WithQueryTimeout(ctx, r.QueryTimeout)
+	defer cancel()
+
 	var token auth.SecurityToken
 	query := `
 		SELECT 
@@ -79,17 +116,28 @@ func (r *securityTokenRepository) GetTokenByMetadata(tokenMetadata *auth.TokenMe
 			user_id,
 			token,
 			type,
+			family_id,
+			rotated,
+			user_agent,
+			ip_address,
+			last_used_at,
 			created_at,
 			updated_at
 		FROM security_tokens 
-		WHERE user_id = ? AND type = ? LIMIT 1
+		WHERE user_id = ? AND type = ? AND token = ?
+		ORDER BY rotated ASC LIMIT 1
 	`
-	row := r.DB.QueryRow(query, tokenMetadata.UserID, tokenMetadata.Type)
+	row := database.Conn(ctx, r.DB).QueryRowContext(ctx, query, tokenMetadata.UserID, tokenMetadata.Type, tokenMetadata.Token)
 	err := row.Scan(
 		&token.ID,
 		&token.UserID,
 		&token.Token,
 		&token.Type,
+		&token.FamilyID,
+		&token.Rotated,
+		&token.UserAgent,
+		&token.IPAddress,
+		&token.LastUsedAt,
 		&token.CreatedAt,
 		&token.UpdatedAt)
 
@@ -100,12 +148,122 @@ func (r *securityTokenRepository) GetTokenByMetadata(tokenMetadata *auth.TokenMe
 	return token, nil
 }
 
-// RemoveTokenByMetadata removes a token from the datastore
-func (r *securityTokenRepository) RemoveTokenByMetadata(tokenMetadata *auth.TokenMetadata) error {
+// GetTokensByUserID gets the active auth.SecurityToken(s) of a user and type from the datastore, most recently used first
+func (r *securityTokenRepository) GetTokensByUserID(ctx context.Context, userID, tokenType string) ([]auth.SecurityToken, error) {
+	ctx, cancel := database.WithQueryTimeout(ctx, r.QueryTimeout)
+	defer cancel()
+
+	query := `
+		SELECT
+			id,
+			user_id,
+			token,
+			type,
+			family_id,
+			rotated,
+			user_agent,
+			ip_address,
+			last_used_at,
+			created_at,
+			updated_at
+		FROM security_tokens
+		WHERE user_id = ? AND type = ? AND rotated = 0
+		ORDER BY last_used_at DESC
+	`
+	rows, err := database.Conn(ctx, r.DB).QueryContext(ctx, query, userID, tokenType)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tokens := make([]auth.SecurityToken, 0)
+	for rows.Next() {
+		var token auth.SecurityToken
+		err := rows.Scan(
+			&token.ID,
+			&token.UserID,
+			&token.Token,
+			&token.Type,
+			&token.FamilyID,
+			&token.Rotated,
+			&token.UserAgent,
+			&token.IPAddress,
+			&token.LastUsedAt,
+			&token.CreatedAt,
+			&token.UpdatedAt)
+		if err != nil {
+			return nil, err
+		}
+		tokens = append(tokens, token)
+	}
+
+	return tokens, rows.Err()
+}
+
+// RotateToken stores the new value of a token and keeps its previous value as a rotated token of the same family,
+// both or neither, a token that no longer has the previous value was rotated concurrently and returns a
+// terr.UnAuthorizedError
+func (r *securityTokenRepository) RotateToken(ctx context.Context, token, rotatedToken *auth.SecurityToken) error {
+	ctx, cancel := database.WithQueryTimeout(ctx, r.QueryTimeout)
+	defer cancel()
+
+	return database.RunInTx(ctx, r.DB, func(ctx context.Context) error {
+		query := `
+			UPDATE security_tokens
+			SET
+				token=?,
+				last_used_at=?,
+				updated_at=?
+			WHERE id = ? AND token = ? AND rotated = 0
+		`
+		result, err := database.Conn(ctx, r.DB).ExecContext(ctx, query,
+			token.Token,
+			token.LastUsedAt,
+			token.UpdatedAt,
+			token.ID,
+			rotatedToken.Token,
+		)
+		if err != nil {
+			return err
+		}
+
+		if affected, err := result.RowsAffected(); err == nil && affected == 0 {
+			return terr.NewUnAuthorizedError("refresh token already rotated")
+		}
+
+		previousToken := *rotatedToken
+		previousToken.Rotated = true
+		return r.CreateToken(ctx, &previousToken)
+	})
+}
+
+// RemoveTokenByMetadata removes every token of the user and type from the datastore
+func (r *securityTokenRepository) RemoveTokenByMetadata(ctx context.Context, tokenMetadata *auth.TokenMetadata) error {
+	ctx, cancel := database.WithQueryTimeout(ctx, r.QueryTimeout)
+	defer cancel()
+
 	query := `DELETE FROM security_tokens WHERE user_id = ? AND type = ?`
-	_, err := r.DB.Exec(query,
+	_, err := database.Conn(ctx, r.DB).ExecContext(ctx, query,
 		tokenMetadata.UserID,
 		tokenMetadata.Type,
 	)
 	return err
 }
+
+// RemoveTokenFamily removes every token of a family from the datastore
+func (r *securityTokenRepository) RemoveTokenFamily(ctx context.Context, userID, familyID string) error {
+	ctx, cancel := database.WithQueryTimeout(ctx, r.QueryTimeout)
+	defer cancel()
+
+	query := `DELETE FROM security_tokens WHERE user_id = ? AND family_id = ?`
+	result, err := database.Conn(ctx, r.DB).ExecContext(ctx, query, userID, familyID)
+	if err != nil {
+		return err
+	}
+
+	if affected, err := result.RowsAffected(); err == nil && affected == 0 {
+		return terr.NewNotFoundError("token not found")
+	}
+
+	return nil
+}
@@ -1,6 +1,7 @@
 package mysqlds
 
 import (
+	"context"
 	"errors"
 	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/google/uuid"
@@ -12,14 +13,18 @@ import (
 	"time"
 )
 
-func TestCreateOrUpdateToken(t *testing.T) {
+func TestCreateToken(t *testing.T) {
 	st := &auth.SecurityToken{
-		ID:        uuid.New().String(),
-		UserID:    "some-user-id",
-		Token:     "some-user-token",
-		Type:      "some-token-type",
-		CreatedAt: time.Now(),
-		UpdatedAt: time.Now(),
+		ID:         uuid.New().String(),
+		UserID:     "some-user-id",
+		Token:      "some-user-token",
+		Type:       "some-token-type",
+		FamilyID:   "some-family-id",
+		UserAgent:  "some-user-agent",
+		IPAddress:  "127.0.0.1",
+		LastUsedAt: time.Now(),
+		CreatedAt:  time.Now(),
+		UpdatedAt:  time.Now(),
 	}
 
 	t.Run("should insert", func(t *testing.T) {
@@ -29,47 +34,72 @@ func TestCreateOrUpdateToken(t *testing.T) {
 		}
 		defer db.Close()
 
-		securityTokenRepo := NewSecurityTokenRepository(db)
-
-		mock.
-			ExpectQuery("SELECT id FROM security_tokens").
-			WithArgs(st.UserID, st.Type).
-			WillReturnRows(sqlmock.NewRows([]string{"id"}))
+		securityTokenRepo := NewSecurityTokenRepository(db, time.Second)
 
 		mock.
 			ExpectExec("INSERT security_tokens SET").
-			WithArgs(st.ID, st.UserID, st.Token, st.Type, st.CreatedAt, st.UpdatedAt).
+			WithArgs(st.ID, st.UserID, st.Token, st.Type, st.FamilyID, st.Rotated, st.UserAgent, st.IPAddress, st.LastUsedAt, st.CreatedAt, st.UpdatedAt).
 			WillReturnResult(sqlmock.NewResult(1, 1))
 
-		err = securityTokenRepo.CreateOrUpdateToken(st)
+		err = securityTokenRepo.CreateToken(context.Background(), st)
 
 		assert.NoError(t, err)
 	})
 
-	t.Run("should update", func(t *testing.T) {
+	t.Run("should return an error", func(t *testing.T) {
 		db, mock, err := sqlmock.New()
 		if err != nil {
 			t.Fatalf("an error '%s' was not expected", err)
 		}
 		defer db.Close()
 
-		securityTokenRepo := NewSecurityTokenRepository(db)
-
-		rows := sqlmock.NewRows([]string{"id"}).AddRow(st.ID)
+		securityTokenRepo := NewSecurityTokenRepository(db, time.Second)
 
+		mockError := errors.New("any error")
 		mock.
-			ExpectQuery("SELECT id FROM security_tokens").
-			WithArgs(st.UserID, st.Type).
-			WillReturnRows(rows)
+			ExpectExec("INSERT security_tokens SET").
+			WillReturnError(mockError)
+
+		err = securityTokenRepo.CreateToken(context.Background(), st)
+
+		if assert.Error(t, err) {
+			assert.Equal(t, mockError, err)
+		}
+	})
+}
+
+func TestCreateOrUpdateToken(t *testing.T) {
+	st := &auth.SecurityToken{
+		ID:         uuid.New().String(),
+		UserID:     "some-user-id",
+		Token:      "some-user-token",
+		Type:       "some-token-type",
+		FamilyID:   "some-family-id",
+		UserAgent:  "some-user-agent",
+		IPAddress:  "127.0.0.1",
+		LastUsedAt: time.Now(),
+		CreatedAt:  time.Now(),
+		UpdatedAt:  time.Now(),
+	}
+
+	t.Run("should upsert", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		if err != nil {
+			t.Fatalf("an error '%s' was not expected", err)
+		}
+		defer db.Close()
+
+		securityTokenRepo := NewSecurityTokenRepository(db, time.Second)
 
 		mock.
-			ExpectExec("UPDATE security_tokens SET").
-			WithArgs(st.Token, st.UpdatedAt, st.ID).
+			ExpectExec("INSERT security_tokens SET (.+) ON DUPLICATE KEY UPDATE").
+			WithArgs(st.ID, st.UserID, st.Token, st.Type, st.Type, st.FamilyID, st.Rotated, st.UserAgent, st.IPAddress, st.LastUsedAt, st.CreatedAt, st.UpdatedAt).
 			WillReturnResult(sqlmock.NewResult(1, 1))
 
-		err = securityTokenRepo.CreateOrUpdateToken(st)
+		err = securityTokenRepo.CreateOrUpdateToken(context.Background(), st)
 
 		assert.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
 	})
 
 	t.Run("should return an error", func(t *testing.T) {
@@ -81,9 +111,9 @@ func TestCreateOrUpdateToken(t *testing.T) {
 
 		db.Close()
 
-		securityTokenRepo := NewSecurityTokenRepository(db)
+		securityTokenRepo := NewSecurityTokenRepository(db, time.Second)
 
-		err = securityTokenRepo.CreateOrUpdateToken(st)
+		err = securityTokenRepo.CreateOrUpdateToken(context.Background(), st)
 
 		assert.Error(t, err)
 	})
@@ -92,12 +122,16 @@ func TestCreateOrUpdateToken(t *testing.T) {
 
 func TestGetTokenByMetadata(t *testing.T) {
 	st := &auth.SecurityToken{
-		ID:        uuid.New().String(),
-		UserID:    "some-user-id",
-		Token:     "some-user-token",
-		Type:      "some-token-type",
-		CreatedAt: time.Now(),
-		UpdatedAt: time.Now(),
+		ID:         uuid.New().String(),
+		UserID:     "some-user-id",
+		Token:      "some-user-token",
+		Type:       "some-token-type",
+		FamilyID:   "some-family-id",
+		UserAgent:  "some-user-agent",
+		IPAddress:  "127.0.0.1",
+		LastUsedAt: time.Now(),
+		CreatedAt:  time.Now(),
+		UpdatedAt:  time.Now(),
 	}
 
 	tmd := &auth.TokenMetadata{
@@ -113,17 +147,19 @@ func TestGetTokenByMetadata(t *testing.T) {
 		}
 		defer db.Close()
 
-		securityTokenRepo := NewSecurityTokenRepository(db)
+		securityTokenRepo := NewSecurityTokenRepository(db, time.Second)
 
 		rows := sqlmock.
-			NewRows([]string{"id", "user_id", "token", "type", "created_at", "updated_at"}).
-			AddRow(st.ID, st.UserID, st.Token, st.Type, st.CreatedAt, st.UpdatedAt)
+			NewRows([]string{
+				"id", "user_id", "token", "type", "family_id", "rotated", "user_agent", "ip_address", "last_used_at", "created_at", "updated_at",
+			}).
+			AddRow(st.ID, st.UserID, st.Token, st.Type, st.FamilyID, st.Rotated, st.UserAgent, st.IPAddress, st.LastUsedAt, st.CreatedAt, st.UpdatedAt)
 		mock.
-			ExpectQuery("SELECT id, user_id, token, type, created_at, updated_at FROM security_tokens").
-			WithArgs(st.UserID, st.Type).
+			ExpectQuery("SELECT id, user_id, token, type, family_id, rotated, user_agent, ip_address, last_used_at, created_at, updated_at FROM security_tokens").
+			WithArgs(st.UserID, st.Type, st.Token).
 			WillReturnRows(rows)
 
-		token, err := securityTokenRepo.GetTokenByMetadata(tmd)
+		token, err := securityTokenRepo.GetTokenByMetadata(context.Background(), tmd)
 
 		assert.EqualValues(t, st, &token)
 		assert.NoError(t, err)
@@ -136,14 +172,14 @@ func TestGetTokenByMetadata(t *testing.T) {
 		}
 		defer db.Close()
 
-		securityTokenRepo := NewSecurityTokenRepository(db)
+		securityTokenRepo := NewSecurityTokenRepository(db, time.Second)
 
 		mock.
-			ExpectQuery("SELECT id, user_id, token, type, created_at, updated_at FROM security_tokens").
-			WithArgs(st.UserID, st.Type).
+			ExpectQuery("SELECT id, user_id, token, type, family_id, rotated, user_agent, ip_address, last_used_at, created_at, updated_at FROM security_tokens").
+			WithArgs(st.UserID, st.Type, st.Token).
 			WillReturnError(errors.New("any error"))
 
-		_, err = securityTokenRepo.GetTokenByMetadata(tmd)
+		_, err = securityTokenRepo.GetTokenByMetadata(context.Background(), tmd)
 
 		expectedError := terr.NewNotFoundError("token not found")
 		if assert.Error(t, err) {
@@ -152,6 +188,194 @@ func TestGetTokenByMetadata(t *testing.T) {
 	})
 }
 
+func TestGetTokensByUserID(t *testing.T) {
+	st := &auth.SecurityToken{
+		ID:         uuid.New().String(),
+		UserID:     "some-user-id",
+		Token:      "some-user-token",
+		Type:       auth.RefreshTokenType,
+		FamilyID:   "some-family-id",
+		UserAgent:  "some-user-agent",
+		IPAddress:  "127.0.0.1",
+		LastUsedAt: time.Now(),
+		CreatedAt:  time.Now(),
+		UpdatedAt:  time.Now(),
+	}
+
+	t.Run("should return tokens", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		if err != nil {
+			t.Fatalf("an error '%s' was not expected", err)
+		}
+		defer db.Close()
+
+		securityTokenRepo := NewSecurityTokenRepository(db, time.Second)
+
+		rows := sqlmock.
+			NewRows([]string{
+				"id", "user_id", "token", "type", "family_id", "rotated", "user_agent", "ip_address", "last_used_at", "created_at", "updated_at",
+			}).
+			AddRow(st.ID, st.UserID, st.Token, st.Type, st.FamilyID, st.Rotated, st.UserAgent, st.IPAddress, st.LastUsedAt, st.CreatedAt, st.UpdatedAt)
+		mock.
+			ExpectQuery("SELECT id, user_id, token, type, family_id, rotated, user_agent, ip_address, last_used_at, created_at, updated_at FROM security_tokens").
+			WithArgs(st.UserID, st.Type).
+			WillReturnRows(rows)
+
+		tokens, err := securityTokenRepo.GetTokensByUserID(context.Background(), st.UserID, st.Type)
+
+		assert.NoError(t, err)
+		assert.EqualValues(t, []auth.SecurityToken{*st}, tokens)
+	})
+
+	t.Run("should return an empty list", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		if err != nil {
+			t.Fatalf("an error '%s' was not expected", err)
+		}
+		defer db.Close()
+
+		securityTokenRepo := NewSecurityTokenRepository(db, time.Second)
+
+		mock.
+			ExpectQuery("SELECT (.+) FROM security_tokens").
+			WithArgs(st.UserID, st.Type).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+		tokens, err := securityTokenRepo.GetTokensByUserID(context.Background(), st.UserID, st.Type)
+
+		assert.NoError(t, err)
+		assert.Empty(t, tokens)
+		assert.NotNil(t, tokens)
+	})
+
+	t.Run("should return an error", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		if err != nil {
+			t.Fatalf("an error '%s' was not expected", err)
+		}
+		defer db.Close()
+
+		securityTokenRepo := NewSecurityTokenRepository(db, time.Second)
+
+		mockError := errors.New("any error")
+		mock.
+			ExpectQuery("SELECT (.+) FROM security_tokens").
+			WithArgs(st.UserID, st.Type).
+			WillReturnError(mockError)
+
+		_, err = securityTokenRepo.GetTokensByUserID(context.Background(), st.UserID, st.Type)
+
+		if assert.Error(t, err) {
+			assert.Equal(t, mockError, err)
+		}
+	})
+}
+
+func TestRotateToken(t *testing.T) {
+	st := &auth.SecurityToken{
+		ID:         uuid.New().String(),
+		UserID:     "some-user-id",
+		Token:      "some-new-user-token",
+		Type:       "some-token-type",
+		FamilyID:   "some-family-id",
+		UserAgent:  "some-user-agent",
+		IPAddress:  "127.0.0.1",
+		LastUsedAt: time.Now(),
+		CreatedAt:  time.Now(),
+		UpdatedAt:  time.Now(),
+	}
+	rst := &auth.SecurityToken{
+		ID:         uuid.New().String(),
+		UserID:     st.UserID,
+		Token:      "some-user-token",
+		Type:       st.Type,
+		FamilyID:   st.FamilyID,
+		Rotated:    true,
+		UserAgent:  st.UserAgent,
+		IPAddress:  st.IPAddress,
+		LastUsedAt: time.Now(),
+		CreatedAt:  time.Now(),
+		UpdatedAt:  time.Now(),
+	}
+
+	t.Run("should rotate", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		if err != nil {
+			t.Fatalf("an error '%s' was not expected", err)
+		}
+		defer db.Close()
+
+		securityTokenRepo := NewSecurityTokenRepository(db, time.Second)
+
+		mock.ExpectBegin()
+		mock.
+			ExpectExec("UPDATE security_tokens SET").
+			WithArgs(st.Token, st.LastUsedAt, st.UpdatedAt, st.ID, rst.Token).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.
+			ExpectExec("INSERT security_tokens SET").
+			WithArgs(rst.ID, rst.UserID, rst.Token, rst.Type, rst.FamilyID, true, rst.UserAgent, rst.IPAddress, rst.LastUsedAt, rst.CreatedAt, rst.UpdatedAt).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectCommit()
+
+		err = securityTokenRepo.RotateToken(context.Background(), st, rst)
+
+		assert.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("should return an un-authorized error", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		if err != nil {
+			t.Fatalf("an error '%s' was not expected", err)
+		}
+		defer db.Close()
+
+		securityTokenRepo := NewSecurityTokenRepository(db, time.Second)
+
+		mock.ExpectBegin()
+		mock.
+			ExpectExec("UPDATE security_tokens SET").
+			WithArgs(st.Token, st.LastUsedAt, st.UpdatedAt, st.ID, rst.Token).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectRollback()
+
+		err = securityTokenRepo.RotateToken(context.Background(), st, rst)
+
+		assert.Equal(t, terr.NewUnAuthorizedError("refresh token already rotated"), err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("should return an error", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		if err != nil {
+			t.Fatalf("an error '%s' was not expected", err)
+		}
+		defer db.Close()
+
+		securityTokenRepo := NewSecurityTokenRepository(db, time.Second)
+
+		mockError := errors.New("any error")
+		mock.ExpectBegin()
+		mock.
+			ExpectExec("UPDATE security_tokens SET").
+			WithArgs(st.Token, st.LastUsedAt, st.UpdatedAt, st.ID, rst.Token).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.
+			ExpectExec("INSERT security_tokens SET").
+			WithArgs(rst.ID, rst.UserID, rst.Token, rst.Type, rst.FamilyID, true, rst.UserAgent, rst.IPAddress, rst.LastUsedAt, rst.CreatedAt, rst.UpdatedAt).
+			WillReturnError(mockError)
+		mock.ExpectRollback()
+
+		err = securityTokenRepo.RotateToken(context.Background(), st, rst)
+
+		if assert.Error(t, err) {
+			assert.Equal(t, mockError, err)
+		}
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
 func TestRemoveTokenMetadata(t *testing.T) {
 	tmd := &auth.TokenMetadata{
 		UserID: "some-user-id",
@@ -165,14 +389,57 @@ func TestRemoveTokenMetadata(t *testing.T) {
 	}
 	defer db.Close()
 
-	securityTokenRepo := NewSecurityTokenRepository(db)
+	securityTokenRepo := NewSecurityTokenRepository(db, time.Second)
 
 	mock.
 		ExpectExec("DELETE FROM security_tokens WHERE").
 		WithArgs(tmd.UserID, tmd.Type).
 		WillReturnResult(sqlmock.NewResult(0, 0))
 
-	err = securityTokenRepo.RemoveTokenByMetadata(tmd)
+	err = securityTokenRepo.RemoveTokenByMetadata(context.Background(), tmd)
 
 	assert.NoError(t, err)
 }
+
+func TestRemoveTokenFamily(t *testing.T) {
+	t.Run("should remove", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		if err != nil {
+			t.Fatalf("an error '%s' was not expected", err)
+		}
+		defer db.Close()
+
+		securityTokenRepo := NewSecurityTokenRepository(db, time.Second)
+
+		mock.
+			ExpectExec("DELETE FROM security_tokens WHERE").
+			WithArgs("some-user-id", "some-family-id").
+			WillReturnResult(sqlmock.NewResult(0, 2))
+
+		err = securityTokenRepo.RemoveTokenFamily(context.Background(), "some-user-id", "some-family-id")
+
+		assert.NoError(t, err)
+	})
+
+	t.Run("should return a not found error", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		if err != nil {
+			t.Fatalf("an error '%s' was not expected", err)
+		}
+		defer db.Close()
+
+		securityTokenRepo := NewSecurityTokenRepository(db, time.Second)
+
+		mock.
+			ExpectExec("DELETE FROM security_tokens WHERE").
+			WithArgs("some-user-id", "some-family-id").
+			WillReturnResult(sqlmock.NewResult(0, 0))
+
+		err = securityTokenRepo.RemoveTokenFamily(context.Background(), "some-user-id", "some-family-id")
+
+		expectedError := terr.NewNotFoundError("token not found")
+		if assert.Error(t, err) {
+			assert.Equal(t, expectedError, err)
+		}
+	})
+}
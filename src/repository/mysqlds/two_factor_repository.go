@@ -0,0 +1,177 @@
+package mysqlds
+
+import (
+	"context"
+	"database/sql"
+	"sherman/src/app/database"
+	"sherman/src/app/utils/terr"
+	"sherman/src/domain/auth"
+	"strings"
+	"time"
+)
+
+// twoFactorRepository sql implementation of auth.TwoFactorRepository
+type twoFactorRepository struct {
+	DB           *sql.DB
+	QueryTimeout time.Duration
+}
+
+// NewTwoFactorRepository constructor
+func NewTwoFactorRepository(db *sql.DB, queryTimeout time.Duration) auth.TwoFactorRepository {
+	return &twoFactorRepository{
+		DB:           db,
+		QueryTimeout: queryTimeout,
+	}
+}
+
+// GetTwoFactor gets the auth.TwoFactor of a user from the datastore
+func (r *twoFactorRepository) GetTwoFactor(ctx context.Context, userID string) (auth.TwoFactor, error) {
+	ctx, cancel := database.WithQueryTimeout(ctx, r.QueryTimeout)
+	defer cancel()
+
+	var twoFactor auth.TwoFactor
+	query := `
+		SELECT
+			user_id,
+			secret,
+			enabled,
+			last_used_step,
+			created_at,
+			updated_at
+		FROM two_factors
+		WHERE user_id = ? LIMIT 1
+	`
+	err := database.Conn(ctx, r.DB).QueryRowContext(ctx, query, userID).Scan(
+		&twoFactor.UserID,
+		&twoFactor.Secret,
+		&twoFactor.Enabled,
+		&twoFactor.LastUsedStep,
+		&twoFactor.CreatedAt,
+		&twoFactor.UpdatedAt)
+
+	if err != nil {
+		if strings.Contains(strings.ToLower(err.Error()), "no rows") {
+			err = terr.NewNotFoundError("two factor authentication not found")
+		}
+		return auth.TwoFactor{}, err
+	}
+
+	return twoFactor, nil
+}
+
+// CreateOrUpdateTwoFactor persist a auth.TwoFactor in the datastore, replacing the one of the same user
+func (r *twoFactorRepository) CreateOrUpdateTwoFactor(ctx context.Context, twoFactor *auth.TwoFactor) error {
+	ctx, cancel := database.WithQueryTimeout(ctx, r.QueryTimeout)
+	defer cancel()
+
+	query := `
+		INSERT two_factors
+		SET
+			user_id=?,
+			secret=?,
+			enabled=?,
+			last_used_step=?,
+			created_at=?,
+			updated_at=?
+		ON DUPLICATE KEY UPDATE
+			secret=VALUES(secret),
+			enabled=VALUES(enabled),
+			last_used_step=VALUES(last_used_step),
+			updated_at=VALUES(updated_at)
+	`
+	_, err := database.Conn(ctx, r.DB).ExecContext(ctx, query,
+		twoFactor.UserID,
+		twoFactor.Secret,
+		twoFactor.Enabled,
+		twoFactor.LastUsedStep,
+		twoFactor.CreatedAt,
+		twoFactor.UpdatedAt,
+	)
+	return err
+}
+
+// UpdateTwoFactorLastUsedStep moves the last used TOTP period of a user forward in the datastore,
+// an older or equal period is a replayed code and gets a unauthorized error
+func (r *twoFactorRepository) UpdateTwoFactorLastUsedStep(ctx context.Context, userID string, lastUsedStep int64, updatedAt time.Time) error {
+	ctx, cancel := database.WithQueryTimeout(ctx, r.QueryTimeout)
+	defer cancel()
+
+	query := `
+		UPDATE two_factors
+		SET
+			last_used_step=?,
+			updated_at=?
+		WHERE user_id = ? AND last_used_step < ?
+	`
+	result, err := database.Conn(ctx, r.DB).ExecContext(ctx, query, lastUsedStep, updatedAt, userID, lastUsedStep)
+	if err != nil {
+		return err
+	}
+
+	if affected, err := result.RowsAffected(); err == nil && affected == 0 {
+		return terr.NewUnAuthorizedError("code already used")
+	}
+	return nil
+}
+
+// RemoveTwoFactor removes the auth.TwoFactor and the recovery codes of a user from the datastore
+func (r *twoFactorRepository) RemoveTwoFactor(ctx context.Context, userID string) error {
+	ctx, cancel := database.WithQueryTimeout(ctx, r.QueryTimeout)
+	defer cancel()
+
+	if _, err := database.Conn(ctx, r.DB).ExecContext(ctx, `DELETE FROM recovery_codes WHERE user_id = ?`, userID); err != nil {
+		return err
+	}
+
+	result, err := database.Conn(ctx, r.DB).ExecContext(ctx, `DELETE FROM two_factors WHERE user_id = ?`, userID)
+	if err != nil {
+		return err
+	}
+
+	if affected, err := result.RowsAffected(); err == nil && affected == 0 {
+		return terr.NewNotFoundError("two factor authentication not found")
+	}
+	return nil
+}
+
+// ReplaceRecoveryCodes replaces the recovery codes of a user in the datastore
+func (r *twoFactorRepository) ReplaceRecoveryCodes(ctx context.Context, userID string, recoveryCodes []auth.RecoveryCode) error {
+	ctx, cancel := database.WithQueryTimeout(ctx, r.QueryTimeout)
+	defer cancel()
+
+	if _, err := database.Conn(ctx, r.DB).ExecContext(ctx, `DELETE FROM recovery_codes WHERE user_id = ?`, userID); err != nil {
+		return err
+	}
+	if len(recoveryCodes) == 0 {
+		return nil
+	}
+
+	placeholders := make([]string, len(recoveryCodes))
+	args := make([]interface{}, 0, 4*len(recoveryCodes))
+	for i, recoveryCode := range recoveryCodes {
+		placeholders[i] = "(?, ?, ?, ?)"
+		args = append(args, recoveryCode.ID, userID, recoveryCode.CodeHash, recoveryCode.CreatedAt)
+	}
+
+	query := `INSERT INTO recovery_codes (id, user_id, code_hash, created_at) VALUES ` + strings.Join(placeholders, ", ")
+	_, err := database.Conn(ctx, r.DB).ExecContext(ctx, query, args...)
+	return err
+}
+
+// RemoveRecoveryCode removes a recovery code of a user from the datastore, the removal is the use
+// of the code so concurrent uses of the same code find nothing to remove
+func (r *twoFactorRepository) RemoveRecoveryCode(ctx context.Context, userID, codeHash string) error {
+	ctx, cancel := database.WithQueryTimeout(ctx, r.QueryTimeout)
+	defer cancel()
+
+	query := `DELETE FROM recovery_codes WHERE user_id = ? AND code_hash = ?`
+	result, err := database.Conn(ctx, r.DB).ExecContext(ctx, query, userID, codeHash)
+	if err != nil {
+		return err
+	}
+
+	if affected, err := result.RowsAffected(); err == nil && affected == 0 {
+		return terr.NewNotFoundError("recovery code not found")
+	}
+	return nil
+}
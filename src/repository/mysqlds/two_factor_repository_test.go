@@ -0,0 +1,271 @@
+package mysqlds
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	_ "sherman/src/app/testing"
+	"sherman/src/app/utils/terr"
+	"sherman/src/domain/auth"
+	"testing"
+	"time"
+)
+
+func TestGetTwoFactor(t *testing.T) {
+	now := time.Now()
+	columns := []string{"user_id", "secret", "enabled", "last_used_step", "created_at", "updated_at"}
+
+	t.Run("should return a two factor", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		if err != nil {
+			t.Fatalf("an error '%s' was not expected", err)
+		}
+		defer db.Close()
+
+		twoFactorRepo := NewTwoFactorRepository(db, time.Second)
+
+		mock.
+			ExpectQuery("SELECT user_id, secret, enabled, last_used_step, created_at, updated_at FROM two_factors").
+			WithArgs("some-user-id").
+			WillReturnRows(sqlmock.NewRows(columns).AddRow("some-user-id", "some-secret", true, 42, now, now))
+
+		twoFactor, err := twoFactorRepo.GetTwoFactor(context.Background(), "some-user-id")
+
+		if assert.NoError(t, err) {
+			assert.Equal(t, auth.TwoFactor{
+				UserID:       "some-user-id",
+				Secret:       "some-secret",
+				Enabled:      true,
+				LastUsedStep: 42,
+				CreatedAt:    now,
+				UpdatedAt:    now,
+			}, twoFactor)
+		}
+	})
+
+	t.Run("should return a not found error", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		if err != nil {
+			t.Fatalf("an error '%s' was not expected", err)
+		}
+		defer db.Close()
+
+		twoFactorRepo := NewTwoFactorRepository(db, time.Second)
+
+		mock.
+			ExpectQuery("SELECT user_id").
+			WithArgs("some-user-id").
+			WillReturnError(sql.ErrNoRows)
+
+		_, err = twoFactorRepo.GetTwoFactor(context.Background(), "some-user-id")
+
+		assert.Equal(t, terr.NewNotFoundError("two factor authentication not found"), err)
+	})
+}
+
+func TestCreateOrUpdateTwoFactor(t *testing.T) {
+	now := time.Now()
+	twoFactor := &auth.TwoFactor{UserID: "some-user-id", Secret: "some-secret", CreatedAt: now, UpdatedAt: now}
+
+	t.Run("should insert or update", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		if err != nil {
+			t.Fatalf("an error '%s' was not expected", err)
+		}
+		defer db.Close()
+
+		twoFactorRepo := NewTwoFactorRepository(db, time.Second)
+
+		mock.
+			ExpectExec("INSERT two_factors SET .* ON DUPLICATE KEY UPDATE").
+			WithArgs("some-user-id", "some-secret", false, int64(0), now, now).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		assert.NoError(t, twoFactorRepo.CreateOrUpdateTwoFactor(context.Background(), twoFactor))
+	})
+
+	t.Run("should return an error", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		if err != nil {
+			t.Fatalf("an error '%s' was not expected", err)
+		}
+		defer db.Close()
+
+		twoFactorRepo := NewTwoFactorRepository(db, time.Second)
+
+		mockError := errors.New("some error")
+		mock.
+			ExpectExec("INSERT two_factors SET").
+			WillReturnError(mockError)
+
+		assert.Equal(t, mockError, twoFactorRepo.CreateOrUpdateTwoFactor(context.Background(), twoFactor))
+	})
+}
+
+func TestUpdateTwoFactorLastUsedStep(t *testing.T) {
+	now := time.Now()
+
+	t.Run("should update", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		if err != nil {
+			t.Fatalf("an error '%s' was not expected", err)
+		}
+		defer db.Close()
+
+		twoFactorRepo := NewTwoFactorRepository(db, time.Second)
+
+		mock.
+			ExpectExec("UPDATE two_factors SET last_used_step=\\?, updated_at=\\? WHERE user_id = \\? AND last_used_step < \\?").
+			WithArgs(int64(42), now, "some-user-id", int64(42)).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		assert.NoError(t, twoFactorRepo.UpdateTwoFactorLastUsedStep(context.Background(), "some-user-id", 42, now))
+	})
+
+	t.Run("should return an unauthorized error", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		if err != nil {
+			t.Fatalf("an error '%s' was not expected", err)
+		}
+		defer db.Close()
+
+		twoFactorRepo := NewTwoFactorRepository(db, time.Second)
+
+		mock.
+			ExpectExec("UPDATE two_factors").
+			WillReturnResult(sqlmock.NewResult(0, 0))
+
+		err = twoFactorRepo.UpdateTwoFactorLastUsedStep(context.Background(), "some-user-id", 42, now)
+
+		assert.Equal(t, terr.NewUnAuthorizedError("code already used"), err)
+	})
+}
+
+func TestRemoveTwoFactor(t *testing.T) {
+	t.Run("should remove", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		if err != nil {
+			t.Fatalf("an error '%s' was not expected", err)
+		}
+		defer db.Close()
+
+		twoFactorRepo := NewTwoFactorRepository(db, time.Second)
+
+		mock.
+			ExpectExec("DELETE FROM recovery_codes WHERE user_id = \\?").
+			WithArgs("some-user-id").
+			WillReturnResult(sqlmock.NewResult(0, 10))
+		mock.
+			ExpectExec("DELETE FROM two_factors WHERE user_id = \\?").
+			WithArgs("some-user-id").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		assert.NoError(t, twoFactorRepo.RemoveTwoFactor(context.Background(), "some-user-id"))
+	})
+
+	t.Run("should return a not found error", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		if err != nil {
+			t.Fatalf("an error '%s' was not expected", err)
+		}
+		defer db.Close()
+
+		twoFactorRepo := NewTwoFactorRepository(db, time.Second)
+
+		mock.
+			ExpectExec("DELETE FROM recovery_codes").
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.
+			ExpectExec("DELETE FROM two_factors").
+			WillReturnResult(sqlmock.NewResult(0, 0))
+
+		err = twoFactorRepo.RemoveTwoFactor(context.Background(), "some-user-id")
+
+		assert.Equal(t, terr.NewNotFoundError("two factor authentication not found"), err)
+	})
+}
+
+func TestReplaceRecoveryCodes(t *testing.T) {
+	now := time.Now()
+	recoveryCodes := []auth.RecoveryCode{
+		{ID: "some-id", CodeHash: "some-hash", CreatedAt: now},
+		{ID: "other-id", CodeHash: "other-hash", CreatedAt: now},
+	}
+
+	t.Run("should insert", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		if err != nil {
+			t.Fatalf("an error '%s' was not expected", err)
+		}
+		defer db.Close()
+
+		twoFactorRepo := NewTwoFactorRepository(db, time.Second)
+
+		mock.
+			ExpectExec("DELETE FROM recovery_codes WHERE user_id = \\?").
+			WithArgs("some-user-id").
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.
+			ExpectExec("INSERT INTO recovery_codes \\(id, user_id, code_hash, created_at\\) VALUES \\(\\?, \\?, \\?, \\?\\), \\(\\?, \\?, \\?, \\?\\)").
+			WithArgs("some-id", "some-user-id", "some-hash", now, "other-id", "some-user-id", "other-hash", now).
+			WillReturnResult(sqlmock.NewResult(0, 2))
+
+		assert.NoError(t, twoFactorRepo.ReplaceRecoveryCodes(context.Background(), "some-user-id", recoveryCodes))
+	})
+
+	t.Run("should return an error", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		if err != nil {
+			t.Fatalf("an error '%s' was not expected", err)
+		}
+		defer db.Close()
+
+		twoFactorRepo := NewTwoFactorRepository(db, time.Second)
+
+		mockError := errors.New("some error")
+		mock.
+			ExpectExec("DELETE FROM recovery_codes").
+			WillReturnError(mockError)
+
+		assert.Equal(t, mockError, twoFactorRepo.ReplaceRecoveryCodes(context.Background(), "some-user-id", recoveryCodes))
+	})
+}
+
+func TestRemoveRecoveryCode(t *testing.T) {
+	t.Run("should remove", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		if err != nil {
+			t.Fatalf("an error '%s' was not expected", err)
+		}
+		defer db.Close()
+
+		twoFactorRepo := NewTwoFactorRepository(db, time.Second)
+
+		mock.
+			ExpectExec("DELETE FROM recovery_codes WHERE user_id = \\? AND code_hash = \\?").
+			WithArgs("some-user-id", "some-hash").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		assert.NoError(t, twoFactorRepo.RemoveRecoveryCode(context.Background(), "some-user-id", "some-hash"))
+	})
+
+	t.Run("should return a not found error", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		if err != nil {
+			t.Fatalf("an error '%s' was not expected", err)
+		}
+		defer db.Close()
+
+		twoFactorRepo := NewTwoFactorRepository(db, time.Second)
+
+		mock.
+			ExpectExec("DELETE FROM recovery_codes").
+			WillReturnResult(sqlmock.NewResult(0, 0))
+
+		err = twoFactorRepo.RemoveRecoveryCode(context.Background(), "some-user-id", "some-hash")
+
+		assert.Equal(t, terr.NewNotFoundError("recovery code not found"), err)
+	})
+}
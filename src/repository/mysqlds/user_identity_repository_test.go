@@ -0,0 +1,113 @@
+package mysqlds
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	_ "sherman/src/app/testing"
+	"sherman/src/app/utils/terr"
+	"sherman/src/domain/auth"
+	"testing"
+	"time"
+)
+
+func TestGetIdentity(t *testing.T) {
+	now := time.Now()
+	columns := []string{"id", "user_id", "provider", "subject", "email_address", "created_at"}
+
+	t.Run("should return a user identity", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		if err != nil {
+			t.Fatalf("an error '%s' was not expected", err)
+		}
+		defer db.Close()
+
+		identityRepo := NewUserIdentityRepository(db, time.Second)
+
+		mock.
+			ExpectQuery("SELECT id, user_id, provider, subject, email_address, created_at FROM user_identities").
+			WithArgs("google", "some-subject").
+			WillReturnRows(sqlmock.NewRows(columns).
+				AddRow("some-id", "some-user-id", "google", "some-subject", "some@email.com", now))
+
+		identity, err := identityRepo.GetIdentity(context.Background(), "google", "some-subject")
+
+		if assert.NoError(t, err) {
+			assert.Equal(t, auth.UserIdentity{
+				ID:           "some-id",
+				UserID:       "some-user-id",
+				Provider:     "google",
+				Subject:      "some-subject",
+				EmailAddress: "some@email.com",
+				CreatedAt:    now,
+			}, identity)
+		}
+	})
+
+	t.Run("should return a not found error", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		if err != nil {
+			t.Fatalf("an error '%s' was not expected", err)
+		}
+		defer db.Close()
+
+		identityRepo := NewUserIdentityRepository(db, time.Second)
+
+		mock.
+			ExpectQuery("SELECT id").
+			WithArgs("google", "some-subject").
+			WillReturnError(sql.ErrNoRows)
+
+		_, err = identityRepo.GetIdentity(context.Background(), "google", "some-subject")
+
+		assert.Equal(t, terr.NewNotFoundError("user identity not found"), err)
+	})
+}
+
+func TestCreateIdentity(t *testing.T) {
+	identity := &auth.UserIdentity{
+		ID:           "some-id",
+		UserID:       "some-user-id",
+		Provider:     "google",
+		Subject:      "some-subject",
+		EmailAddress: "some@email.com",
+		CreatedAt:    time.Now(),
+	}
+
+	t.Run("should insert", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		if err != nil {
+			t.Fatalf("an error '%s' was not expected", err)
+		}
+		defer db.Close()
+
+		identityRepo := NewUserIdentityRepository(db, time.Second)
+
+		mock.
+			ExpectExec("INSERT user_identities SET").
+			WithArgs(identity.ID, identity.UserID, identity.Provider, identity.Subject, identity.EmailAddress, identity.CreatedAt).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+
+		assert.NoError(t, identityRepo.CreateIdentity(context.Background(), identity))
+	})
+
+	t.Run("should return a duplicate entry error", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		if err != nil {
+			t.Fatalf("an error '%s' was not expected", err)
+		}
+		defer db.Close()
+
+		identityRepo := NewUserIdentityRepository(db, time.Second)
+
+		mock.
+			ExpectExec("INSERT user_identities SET").
+			WillReturnError(errors.New("Error 1062: Duplicate entry"))
+
+		err = identityRepo.CreateIdentity(context.Background(), identity)
+
+		assert.Equal(t, terr.NewDuplicateEntryError("user identity already exist"), err)
+	})
+}
@@ -1,25 +1,35 @@
 package mysqlds
 
 import (
+	"context"
 	"database/sql"
+	"sherman/src/app/database"
 	"sherman/src/app/utils/terr"
 	"sherman/src/domain/auth"
 	"strings"
+	"time"
 )
 
 // userRepository sql implementation of auth.UserRepository
 type userRepository struct {
-	DB *sql.DB
+	DB           *sql.DB
+	QueryTimeout time.Duration
 }
 
 // NewUserRepository constructor
-func NewUserRepository(db *sql.DB) auth.UserRepository {
+func NewUserRepository(db *sql.DB, queryTimeout time.Duration) auth.UserRepository {
 	return &userRepository{
-		DB: db,
+		DB:           db,
+		QueryTimeout: queryTimeout,
 	}
 }
 
-func (r *userRepository) scanUserRow(row *sql.Row) (auth.User, error) {
+// rowScanner a *sql.Row or *sql.Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func (r *userRepository) scanUserRow(row rowScanner) (auth.User, error) {
 	var user auth.User
 
 	err := row.Scan(
@@ -29,6 +39,7 @@ func (r *userRepository) scanUserRow(row *sql.Row) (auth.User, error) {
 		&user.EmailAddress,
 		&user.Password,
 		&user.Active,
+		&user.EmailVerified,
 		&user.CreatedAt,
 		&user.UpdatedAt)
 
@@ -43,7 +54,10 @@ func (r *userRepository) scanUserRow(row *sql.Row) (auth.User, error) {
 }
 
 // CreateUser persist a auth.User from the datastore
-func (r *userRepository) CreateUser(user *auth.User) error {
+func (r *userRepository) CreateUser(ctx context.Context, user *auth.User) error {
+	ctx, cancel := database.WithQueryTimeout(ctx, r.QueryTimeout)
+	defer cancel()
+
 	query := `
 		INSERT users
 		SET
@@ -53,17 +67,19 @@ func (r *userRepository) CreateUser(user *auth.User) error {
 			email_address=?,
 			password=?,
 			active=?,
+			email_verified=?,
 			created_at=?,
 			updated_at=?
 	`
 
-	_, err := r.DB.Exec(query,
+	_, err := database.Conn(ctx, r.DB).ExecContext(ctx, query,
 		user.ID,
 		user.FirstName,
 		user.LastName,
 		user.EmailAddress,
 		user.Password,
 		user.Active,
+		user.EmailVerified,
 		user.CreatedAt,
 		user.UpdatedAt,
 	)
@@ -78,8 +94,126 @@ func (r *userRepository) CreateUser(user *auth.User) error {
 	return nil
 }
 
-// GetUserByID gets a auth.User by id in the datastore
-func (r *userRepository) GetUserByID(id string) (auth.User, error) {
+// UpdateUser updates a auth.User in the datastore
+func (r *userRepository) UpdateUser(ctx context.Context, user *auth.User) error {
+	ctx, cancel := database.WithQueryTimeout(ctx, r.QueryTimeout)
+	defer cancel()
+
+	query := `
+		UPDATE users
+		SET
+			first_name=?,
+			last_name=?,
+			email_address=?,
+			password=?,
+			active=?,
+			email_verified=?,
+			updated_at=?
+		WHERE id = ? AND deleted_at IS NULL
+	`
+
+	result, err := database.Conn(ctx, r.DB).ExecContext(ctx, query,
+		user.FirstName,
+		user.LastName,
+		user.EmailAddress,
+		user.Password,
+		user.Active,
+		user.EmailVerified,
+		user.UpdatedAt,
+		user.ID,
+	)
+	if err != nil {
+		if strings.Contains(strings.ToLower(err.Error()), "duplicate") {
+			err = terr.NewDuplicateEntryError("user already exist")
+		}
+		return err
+	}
+
+	if affected, err := result.RowsAffected(); err == nil && affected == 0 {
+		return terr.NewNotFoundError("user not found")
+	}
+	return nil
+}
+
+// UpdateUserPassword updates the password hash of a auth.User in the datastore
+func (r *userRepository) UpdateUserPassword(ctx context.Context, id, password string, updatedAt time.Time) error {
+	ctx, cancel := database.WithQueryTimeout(ctx, r.QueryTimeout)
+	defer cancel()
+
+	query := `UPDATE users SET password=?, updated_at=? WHERE id = ? AND deleted_at IS NULL`
+
+	result, err := database.Conn(ctx, r.DB).ExecContext(ctx, query, password, updatedAt, id)
+	if err != nil {
+		return err
+	}
+
+	if affected, err := result.RowsAffected(); err == nil && affected == 0 {
+		return terr.NewNotFoundError("user not found")
+	}
+	return nil
+}
+
+// UpdateUserActive activates or deactivates a auth.User in the datastore
+func (r *userRepository) UpdateUserActive(ctx context.Context, id string, active bool, updatedAt time.Time) error {
+	ctx, cancel := database.WithQueryTimeout(ctx, r.QueryTimeout)
+	defer cancel()
+
+	query := `UPDATE users SET active=?, updated_at=? WHERE id = ? AND deleted_at IS NULL`
+
+	result, err := database.Conn(ctx, r.DB).ExecContext(ctx, query, active, updatedAt, id)
+	if err != nil {
+		return err
+	}
+
+	if affected, err := result.RowsAffected(); err == nil && affected == 0 {
+		return terr.NewNotFoundError("user not found")
+	}
+	return nil
+}
+
+// SoftDeleteUser deactivates a auth.User and flags it as deleted in the datastore, deleted users
+// are no longer found but their data is kept
+func (r *userRepository) SoftDeleteUser(ctx context.Context, id string, deletedAt time.Time) error {
+	ctx, cancel := database.WithQueryTimeout(ctx, r.QueryTimeout)
+	defer cancel()
+
+	query := `UPDATE users SET active=0, updated_at=?, deleted_at=? WHERE id = ? AND deleted_at IS NULL`
+
+	result, err := database.Conn(ctx, r.DB).ExecContext(ctx, query, deletedAt, deletedAt, id)
+	if err != nil {
+		return err
+	}
+
+	if affected, err := result.RowsAffected(); err == nil && affected == 0 {
+		return terr.NewNotFoundError("user not found")
+	}
+	return nil
+}
+
+// DeleteUser removes a auth.User from the datastore, soft deleted or not, its security tokens
+// and roles are removed by cascade
+func (r *userRepository) DeleteUser(ctx context.Context, id string) error {
+	ctx, cancel := database.WithQueryTimeout(ctx, r.QueryTimeout)
+	defer cancel()
+
+	query := `DELETE FROM users WHERE id = ?`
+
+	result, err := database.Conn(ctx, r.DB).ExecContext(ctx, query, id)
+	if err != nil {
+		return err
+	}
+
+	if affected, err := result.RowsAffected(); err == nil && affected == 0 {
+		return terr.NewNotFoundError("user not found")
+	}
+	return nil
+}
+
+// GetUserByID gets a non deleted auth.User by id in the datastore
+func (r *userRepository) GetUserByID(ctx context.Context, id string) (auth.User, error) {
+	ctx, cancel := database.WithQueryTimeout(ctx, r.QueryTimeout)
+	defer cancel()
+
 	query := `
 		SELECT
 			id,
@@ -88,17 +222,21 @@ func (r *userRepository) GetUserByID(id string) (auth.User, error) {
 			email_address,
 			password,
 			active,
+			email_verified,
 			created_at,
 			updated_at
-		FROM users 
-		WHERE id = ? LIMIT 1
+		FROM users
+		WHERE id = ? AND deleted_at IS NULL LIMIT 1
 	`
-	row := r.DB.QueryRow(query, id)
+	row := database.Conn(ctx, r.DB).QueryRowContext(ctx, query, id)
 	return r.scanUserRow(row)
 }
 
-// GetUserByEmail gets a auth.User by email from the datastore
-func (r *userRepository) GetUserByEmail(email string) (auth.User, error) {
+// GetUserByEmail gets a non deleted auth.User by email from the datastore
+func (r *userRepository) GetUserByEmail(ctx context.Context, email string) (auth.User, error) {
+	ctx, cancel := database.WithQueryTimeout(ctx, r.QueryTimeout)
+	defer cancel()
+
 	query := `
 		SELECT
 			id,
@@ -107,11 +245,12 @@ func (r *userRepository) GetUserByEmail(email string) (auth.User, error) {
 			email_address,
 			password,
 			active,
+			email_verified,
 			created_at,
 			updated_at
 		FROM users
-		WHERE email_address = ? LIMIT 1
+		WHERE email_address = ? AND deleted_at IS NULL LIMIT 1
 	`
-	row := r.DB.QueryRow(query, email)
+	row := database.Conn(ctx, r.DB).QueryRowContext(ctx, query, email)
 	return r.scanUserRow(row)
 }
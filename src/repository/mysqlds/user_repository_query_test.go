@@ -0,0 +1,164 @@
+package mysqlds
+
+import (
+	"context"
+	"errors"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	_ "sherman/src/app/testing"
+	"sherman/src/app/utils/terr"
+	"sherman/src/domain/auth"
+	"testing"
+	"time"
+)
+
+func TestListUsers(t *testing.T) {
+	columns := []string{"id", "first_name", "last_name", "email_address", "password", "active", "email_verified", "created_at", "updated_at"}
+	createdAt := time.Date(2020, 5, 5, 9, 55, 33, 0, time.UTC)
+
+	t.Run("should return a page", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		if err != nil {
+			t.Fatalf("an error '%s' was not expected", err)
+		}
+		defer db.Close()
+
+		userRepo := NewUserRepository(db, time.Second)
+
+		mock.
+			ExpectQuery("SELECT COUNT\\(\\*\\) FROM users WHERE deleted_at IS NULL").
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(3))
+		mock.
+			ExpectQuery("SELECT id, .* FROM users WHERE deleted_at IS NULL ORDER BY created_at DESC, id DESC LIMIT \\?").
+			WithArgs(3).
+			WillReturnRows(sqlmock.NewRows(columns).
+				AddRow("some-id-3", "first", "last", "c@email.com", "pwd", true, true, createdAt.Add(time.Hour*2), createdAt).
+				AddRow("some-id-2", "first", "last", "b@email.com", "pwd", true, true, createdAt.Add(time.Hour), createdAt).
+				AddRow("some-id-1", "first", "last", "a@email.com", "pwd", true, true, createdAt, createdAt))
+
+		page, err := userRepo.ListUsers(context.Background(), auth.UserQuery{SortKey: "created_at", SortDesc: true, Limit: 2})
+
+		if assert.NoError(t, err) {
+			assert.Equal(t, 3, page.Total)
+			if assert.Len(t, page.Users, 2) {
+				assert.Equal(t, "some-id-3", page.Users[0].ID)
+				assert.Equal(t, "some-id-2", page.Users[1].ID)
+			}
+			assert.Equal(t, encodeUserCursor(&page.Users[1], "created_at"), page.NextCursor)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		}
+	})
+
+	t.Run("should return the last page", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		if err != nil {
+			t.Fatalf("an error '%s' was not expected", err)
+		}
+		defer db.Close()
+
+		userRepo := NewUserRepository(db, time.Second)
+
+		active := true
+		cursor := encodeUserCursor(&auth.User{ID: "some-id-2", EmailAddress: "so_me@email.com"}, "email_address")
+
+		mock.
+			ExpectQuery("SELECT COUNT\\(\\*\\) FROM users WHERE deleted_at IS NULL AND active = \\? AND created_at >= \\? AND created_at < \\? AND email_address LIKE \\?").
+			WithArgs(true, createdAt, createdAt.Add(time.Hour), "so\\_me%").
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(2))
+		mock.
+			ExpectQuery("FROM users WHERE .* AND email_address LIKE \\? AND \\(email_address > \\? OR \\(email_address = \\? AND id > \\?\\)\\) ORDER BY email_address ASC, id ASC LIMIT \\?").
+			WithArgs(true, createdAt, createdAt.Add(time.Hour), "so\\_me%", "so_me@email.com", "so_me@email.com", "some-id-2", 2).
+			WillReturnRows(sqlmock.NewRows(columns).
+				AddRow("some-id-1", "first", "last", "so_me@email.com", "pwd", true, true, createdAt, createdAt))
+
+		page, err := userRepo.ListUsers(context.Background(), auth.UserQuery{
+			Active:      &active,
+			CreatedFrom: createdAt,
+			CreatedTo:   createdAt.Add(time.Hour),
+			EmailPrefix: "so_me",
+			SortKey:     "email_address",
+			Cursor:      cursor,
+			Limit:       1,
+		})
+
+		if assert.NoError(t, err) {
+			assert.Equal(t, 2, page.Total)
+			assert.Len(t, page.Users, 1)
+			assert.Empty(t, page.NextCursor)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		}
+	})
+
+	t.Run("should return an invalid query error", func(t *testing.T) {
+		db, _, err := sqlmock.New()
+		if err != nil {
+			t.Fatalf("an error '%s' was not expected", err)
+		}
+		defer db.Close()
+
+		userRepo := NewUserRepository(db, time.Second)
+
+		_, err = userRepo.ListUsers(context.Background(), auth.UserQuery{SortKey: "password", Limit: 1})
+
+		if assert.Error(t, err) {
+			assert.Equal(t, terr.NewInvalidQueryError("invalid sort key"), err)
+		}
+	})
+
+	t.Run("should return an invalid query error", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		if err != nil {
+			t.Fatalf("an error '%s' was not expected", err)
+		}
+		defer db.Close()
+
+		userRepo := NewUserRepository(db, time.Second)
+
+		mock.
+			ExpectQuery("SELECT COUNT").
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(2))
+
+		// a cursor of a listing sorted on another key
+		cursor := encodeUserCursor(&auth.User{ID: "some-id", EmailAddress: "some@email.com"}, "email_address")
+		_, err = userRepo.ListUsers(context.Background(), auth.UserQuery{SortKey: "created_at", Cursor: cursor, Limit: 1})
+
+		if assert.Error(t, err) {
+			assert.Equal(t, terr.NewInvalidQueryError("invalid cursor"), err)
+		}
+	})
+
+	t.Run("should return an error", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		if err != nil {
+			t.Fatalf("an error '%s' was not expected", err)
+		}
+		defer db.Close()
+
+		userRepo := NewUserRepository(db, time.Second)
+
+		mockError := errors.New("some error")
+		mock.
+			ExpectQuery("SELECT COUNT").
+			WillReturnError(mockError)
+
+		_, err = userRepo.ListUsers(context.Background(), auth.UserQuery{SortKey: "created_at", Limit: 1})
+
+		if assert.Error(t, err) {
+			assert.Equal(t, mockError, err)
+		}
+	})
+}
+
+func TestUserCursor(t *testing.T) {
+	createdAt := time.Date(2020, 5, 5, 9, 55, 33, 123, time.UTC)
+	cursor := encodeUserCursor(&auth.User{ID: "some-id", CreatedAt: createdAt}, "created_at")
+
+	value, id, err := decodeUserCursor(cursor, "created_at")
+	if assert.NoError(t, err) {
+		assert.Equal(t, createdAt, value)
+		assert.Equal(t, "some-id", id)
+	}
+
+	_, _, err = decodeUserCursor("not a cursor", "created_at")
+	assert.Equal(t, terr.NewInvalidQueryError("invalid cursor"), err)
+}
@@ -1,6 +1,7 @@
 package mysqlds
 
 import (
+	"context"
 	"errors"
 	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/google/uuid"
@@ -14,14 +15,15 @@ import (
 
 func TestCreateUser(t *testing.T) {
 	u := &auth.User{
-		ID:           uuid.New().String(),
-		FirstName:    "first",
-		LastName:     "last",
-		EmailAddress: "some@email.com",
-		Password:     "some-password",
-		Active:       true,
-		CreatedAt:    time.Now(),
-		UpdatedAt:    time.Now(),
+		ID:            uuid.New().String(),
+		FirstName:     "first",
+		LastName:      "last",
+		EmailAddress:  "some@email.com",
+		Password:      "some-password",
+		Active:        true,
+		EmailVerified: true,
+		CreatedAt:     time.Now(),
+		UpdatedAt:     time.Now(),
 	}
 
 	t.Run("should insert", func(t *testing.T) {
@@ -31,14 +33,14 @@ func TestCreateUser(t *testing.T) {
 		}
 		defer db.Close()
 
-		userRepo := NewUserRepository(db)
+		userRepo := NewUserRepository(db, time.Second)
 
 		mock.
 			ExpectExec("INSERT users SET").
-			WithArgs(u.ID, u.FirstName, u.LastName, u.EmailAddress, u.Password, u.Active, u.CreatedAt, u.UpdatedAt).
+			WithArgs(u.ID, u.FirstName, u.LastName, u.EmailAddress, u.Password, u.Active, u.EmailVerified, u.CreatedAt, u.UpdatedAt).
 			WillReturnResult(sqlmock.NewResult(1, 1))
 
-		err = userRepo.CreateUser(u)
+		err = userRepo.CreateUser(context.Background(), u)
 
 		assert.NoError(t, err)
 	})
@@ -50,15 +52,15 @@ func TestCreateUser(t *testing.T) {
 		}
 		defer db.Close()
 
-		userRepo := NewUserRepository(db)
+		userRepo := NewUserRepository(db, time.Second)
 
 		returnError := terr.NewDuplicateEntryError("duplicate")
 		mock.
 			ExpectExec("INSERT users SET").
-			WithArgs(u.ID, u.FirstName, u.LastName, u.EmailAddress, u.Password, u.Active, u.CreatedAt, u.UpdatedAt).
+			WithArgs(u.ID, u.FirstName, u.LastName, u.EmailAddress, u.Password, u.Active, u.EmailVerified, u.CreatedAt, u.UpdatedAt).
 			WillReturnError(returnError)
 
-		err = userRepo.CreateUser(u)
+		err = userRepo.CreateUser(context.Background(), u)
 
 		expectedError := terr.NewDuplicateEntryError("user already exist")
 		if assert.Error(t, err) {
@@ -69,14 +71,15 @@ func TestCreateUser(t *testing.T) {
 
 func TestGetUserByID(t *testing.T) {
 	u := &auth.User{
-		ID:           uuid.New().String(),
-		FirstName:    "first",
-		LastName:     "last",
-		EmailAddress: "some@email.com",
-		Password:     "some-password",
-		Active:       true,
-		CreatedAt:    time.Now(),
-		UpdatedAt:    time.Now(),
+		ID:            uuid.New().String(),
+		FirstName:     "first",
+		LastName:      "last",
+		EmailAddress:  "some@email.com",
+		Password:      "some-password",
+		Active:        true,
+		EmailVerified: true,
+		CreatedAt:     time.Now(),
+		UpdatedAt:     time.Now(),
 	}
 
 	t.Run("should return a user", func(t *testing.T) {
@@ -86,18 +89,18 @@ func TestGetUserByID(t *testing.T) {
 		}
 		defer db.Close()
 
-		userRepo := NewUserRepository(db)
+		userRepo := NewUserRepository(db, time.Second)
 
 		rows := sqlmock.
-			NewRows([]string{"id", "first_name", "last_name", "email_address", "password", "active", "created_at", "updated_at"}).
-			AddRow(u.ID, u.FirstName, u.LastName, u.EmailAddress, u.Password, u.Active, u.CreatedAt, u.UpdatedAt)
+			NewRows([]string{"id", "first_name", "last_name", "email_address", "password", "active", "email_verified", "created_at", "updated_at"}).
+			AddRow(u.ID, u.FirstName, u.LastName, u.EmailAddress, u.Password, u.Active, u.EmailVerified, u.CreatedAt, u.UpdatedAt)
 
 		mock.
-			ExpectQuery("SELECT id, first_name, last_name, email_address, password, active, created_at, updated_at FROM users").
+			ExpectQuery("SELECT id, first_name, last_name, email_address, password, active, email_verified, created_at, updated_at FROM users").
 			WithArgs(u.ID).
 			WillReturnRows(rows)
 
-		user, err := userRepo.GetUserByID(u.ID)
+		user, err := userRepo.GetUserByID(context.Background(), u.ID)
 
 		assert.EqualValues(t, u, &user)
 		assert.NoError(t, err)
@@ -110,16 +113,16 @@ func TestGetUserByID(t *testing.T) {
 		}
 		defer db.Close()
 
-		userRepo := NewUserRepository(db)
+		userRepo := NewUserRepository(db, time.Second)
 		wrongID := "some-wrong-user-id"
 
 		returnError := errors.New("no rows")
 		mock.
-			ExpectQuery("SELECT id, first_name, last_name, email_address, password, active, created_at, updated_at FROM users").
+			ExpectQuery("SELECT id, first_name, last_name, email_address, password, active, email_verified, created_at, updated_at FROM users").
 			WithArgs(wrongID).
 			WillReturnError(returnError)
 
-		_, err = userRepo.GetUserByID(wrongID)
+		_, err = userRepo.GetUserByID(context.Background(), wrongID)
 
 		expectedError := terr.NewNotFoundError("user not found")
 		if assert.Error(t, err) {
@@ -130,14 +133,15 @@ func TestGetUserByID(t *testing.T) {
 
 func TestGetUserByEmail(t *testing.T) {
 	u := &auth.User{
-		ID:           uuid.New().String(),
-		FirstName:    "first",
-		LastName:     "last",
-		EmailAddress: "some@email.com",
-		Password:     "some-password",
-		Active:       true,
-		CreatedAt:    time.Now(),
-		UpdatedAt:    time.Now(),
+		ID:            uuid.New().String(),
+		FirstName:     "first",
+		LastName:      "last",
+		EmailAddress:  "some@email.com",
+		Password:      "some-password",
+		Active:        true,
+		EmailVerified: true,
+		CreatedAt:     time.Now(),
+		UpdatedAt:     time.Now(),
 	}
 
 	t.Run("should return a user", func(t *testing.T) {
@@ -147,18 +151,18 @@ func TestGetUserByEmail(t *testing.T) {
 		}
 		defer db.Close()
 
-		userRepo := NewUserRepository(db)
+		userRepo := NewUserRepository(db, time.Second)
 
 		rows := sqlmock.
-			NewRows([]string{"id", "first_name", "last_name", "email_address", "password", "active", "created_at", "updated_at"}).
-			AddRow(u.ID, u.FirstName, u.LastName, u.EmailAddress, u.Password, u.Active, u.CreatedAt, u.UpdatedAt)
+			NewRows([]string{"id", "first_name", "last_name", "email_address", "password", "active", "email_verified", "created_at", "updated_at"}).
+			AddRow(u.ID, u.FirstName, u.LastName, u.EmailAddress, u.Password, u.Active, u.EmailVerified, u.CreatedAt, u.UpdatedAt)
 
 		mock.
-			ExpectQuery("SELECT id, first_name, last_name, email_address, password, active, created_at, updated_at FROM users").
+			ExpectQuery("SELECT id, first_name, last_name, email_address, password, active, email_verified, created_at, updated_at FROM users").
 			WithArgs(u.EmailAddress).
 			WillReturnRows(rows)
 
-		user, err := userRepo.GetUserByEmail(u.EmailAddress)
+		user, err := userRepo.GetUserByEmail(context.Background(), u.EmailAddress)
 
 		assert.EqualValues(t, u, &user)
 		assert.NoError(t, err)
@@ -171,17 +175,17 @@ func TestGetUserByEmail(t *testing.T) {
 		}
 		defer db.Close()
 
-		userRepo := NewUserRepository(db)
+		userRepo := NewUserRepository(db, time.Second)
 
 		wrongEmail := "wrongg@email.com"
 
 		returnError := errors.New("no rows")
 		mock.
-			ExpectQuery("SELECT id, first_name, last_name, email_address, password, active, created_at, updated_at FROM users").
+			ExpectQuery("SELECT id, first_name, last_name, email_address, password, active, email_verified, created_at, updated_at FROM users").
 			WithArgs(wrongEmail).
 			WillReturnError(returnError)
 
-		_, err = userRepo.GetUserByEmail(wrongEmail)
+		_, err = userRepo.GetUserByEmail(context.Background(), wrongEmail)
 
 		expectedError := terr.NewNotFoundError("user not found")
 		if assert.Error(t, err) {
@@ -189,3 +193,339 @@ func TestGetUserByEmail(t *testing.T) {
 		}
 	})
 }
+
+func TestUpdateUser(t *testing.T) {
+	u := &auth.User{
+		ID:            uuid.New().String(),
+		FirstName:     "first",
+		LastName:      "last",
+		EmailAddress:  "some@email.com",
+		Password:      "some-password",
+		Active:        true,
+		EmailVerified: true,
+		UpdatedAt:     time.Now(),
+	}
+
+	t.Run("should update", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		if err != nil {
+			t.Fatalf("an error '%s' was not expected", err)
+		}
+		defer db.Close()
+
+		userRepo := NewUserRepository(db, time.Second)
+
+		mock.
+			ExpectExec("UPDATE users SET").
+			WithArgs(u.FirstName, u.LastName, u.EmailAddress, u.Password, u.Active, u.EmailVerified, u.UpdatedAt, u.ID).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		err = userRepo.UpdateUser(context.Background(), u)
+
+		assert.NoError(t, err)
+	})
+
+	t.Run("should return a not found error", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		if err != nil {
+			t.Fatalf("an error '%s' was not expected", err)
+		}
+		defer db.Close()
+
+		userRepo := NewUserRepository(db, time.Second)
+
+		mock.
+			ExpectExec("UPDATE users SET").
+			WithArgs(u.FirstName, u.LastName, u.EmailAddress, u.Password, u.Active, u.EmailVerified, u.UpdatedAt, u.ID).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+
+		err = userRepo.UpdateUser(context.Background(), u)
+
+		if assert.Error(t, err) {
+			assert.Equal(t, terr.NewNotFoundError("user not found"), err)
+		}
+	})
+
+	t.Run("should return a duplicate entry error", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		if err != nil {
+			t.Fatalf("an error '%s' was not expected", err)
+		}
+		defer db.Close()
+
+		userRepo := NewUserRepository(db, time.Second)
+
+		mock.
+			ExpectExec("UPDATE users SET").
+			WithArgs(u.FirstName, u.LastName, u.EmailAddress, u.Password, u.Active, u.EmailVerified, u.UpdatedAt, u.ID).
+			WillReturnError(errors.New("Error 1062: Duplicate entry"))
+
+		err = userRepo.UpdateUser(context.Background(), u)
+
+		if assert.Error(t, err) {
+			assert.Equal(t, terr.NewDuplicateEntryError("user already exist"), err)
+		}
+	})
+}
+
+func TestUpdateUserPassword(t *testing.T) {
+	mockUpdatedAt := time.Now()
+
+	t.Run("should update", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		if err != nil {
+			t.Fatalf("an error '%s' was not expected", err)
+		}
+		defer db.Close()
+
+		userRepo := NewUserRepository(db, time.Second)
+
+		mock.
+			ExpectExec("UPDATE users SET password=\\?, updated_at=\\? WHERE id = \\?").
+			WithArgs("some-hashed-password", mockUpdatedAt, "some-user-id").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		err = userRepo.UpdateUserPassword(context.Background(), "some-user-id", "some-hashed-password", mockUpdatedAt)
+
+		assert.NoError(t, err)
+	})
+
+	t.Run("should return a not found error", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		if err != nil {
+			t.Fatalf("an error '%s' was not expected", err)
+		}
+		defer db.Close()
+
+		userRepo := NewUserRepository(db, time.Second)
+
+		mock.
+			ExpectExec("UPDATE users SET password").
+			WithArgs("some-hashed-password", mockUpdatedAt, "some-user-id").
+			WillReturnResult(sqlmock.NewResult(0, 0))
+
+		err = userRepo.UpdateUserPassword(context.Background(), "some-user-id", "some-hashed-password", mockUpdatedAt)
+
+		if assert.Error(t, err) {
+			assert.Equal(t, terr.NewNotFoundError("user not found"), err)
+		}
+	})
+
+	t.Run("should return an error", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		if err != nil {
+			t.Fatalf("an error '%s' was not expected", err)
+		}
+		defer db.Close()
+
+		userRepo := NewUserRepository(db, time.Second)
+
+		mockError := errors.New("some error")
+		mock.
+			ExpectExec("UPDATE users SET password").
+			WithArgs("some-hashed-password", mockUpdatedAt, "some-user-id").
+			WillReturnError(mockError)
+
+		err = userRepo.UpdateUserPassword(context.Background(), "some-user-id", "some-hashed-password", mockUpdatedAt)
+
+		if assert.Error(t, err) {
+			assert.Equal(t, mockError, err)
+		}
+	})
+}
+
+func TestUpdateUserActive(t *testing.T) {
+	mockUpdatedAt := time.Now()
+
+	t.Run("should update", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		if err != nil {
+			t.Fatalf("an error '%s' was not expected", err)
+		}
+		defer db.Close()
+
+		userRepo := NewUserRepository(db, time.Second)
+
+		mock.
+			ExpectExec("UPDATE users SET active=\\?, updated_at=\\? WHERE id = \\? AND deleted_at IS NULL").
+			WithArgs(false, mockUpdatedAt, "some-user-id").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		err = userRepo.UpdateUserActive(context.Background(), "some-user-id", false, mockUpdatedAt)
+
+		assert.NoError(t, err)
+	})
+
+	t.Run("should return a not found error", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		if err != nil {
+			t.Fatalf("an error '%s' was not expected", err)
+		}
+		defer db.Close()
+
+		userRepo := NewUserRepository(db, time.Second)
+
+		mock.
+			ExpectExec("UPDATE users SET active").
+			WithArgs(false, mockUpdatedAt, "some-user-id").
+			WillReturnResult(sqlmock.NewResult(0, 0))
+
+		err = userRepo.UpdateUserActive(context.Background(), "some-user-id", false, mockUpdatedAt)
+
+		if assert.Error(t, err) {
+			assert.Equal(t, terr.NewNotFoundError("user not found"), err)
+		}
+	})
+
+	t.Run("should return an error", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		if err != nil {
+			t.Fatalf("an error '%s' was not expected", err)
+		}
+		defer db.Close()
+
+		userRepo := NewUserRepository(db, time.Second)
+
+		mockError := errors.New("some error")
+		mock.
+			ExpectExec("UPDATE users SET active").
+			WithArgs(false, mockUpdatedAt, "some-user-id").
+			WillReturnError(mockError)
+
+		err = userRepo.UpdateUserActive(context.Background(), "some-user-id", false, mockUpdatedAt)
+
+		if assert.Error(t, err) {
+			assert.Equal(t, mockError, err)
+		}
+	})
+}
+
+func TestSoftDeleteUser(t *testing.T) {
+	mockDeletedAt := time.Now()
+
+	t.Run("should update", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		if err != nil {
+			t.Fatalf("an error '%s' was not expected", err)
+		}
+		defer db.Close()
+
+		userRepo := NewUserRepository(db, time.Second)
+
+		mock.
+			ExpectExec("UPDATE users SET active=0, updated_at=\\?, deleted_at=\\? WHERE id = \\? AND deleted_at IS NULL").
+			WithArgs(mockDeletedAt, mockDeletedAt, "some-user-id").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		err = userRepo.SoftDeleteUser(context.Background(), "some-user-id", mockDeletedAt)
+
+		assert.NoError(t, err)
+	})
+
+	t.Run("should return a not found error", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		if err != nil {
+			t.Fatalf("an error '%s' was not expected", err)
+		}
+		defer db.Close()
+
+		userRepo := NewUserRepository(db, time.Second)
+
+		mock.
+			ExpectExec("UPDATE users SET active=0").
+			WithArgs(mockDeletedAt, mockDeletedAt, "some-user-id").
+			WillReturnResult(sqlmock.NewResult(0, 0))
+
+		err = userRepo.SoftDeleteUser(context.Background(), "some-user-id", mockDeletedAt)
+
+		if assert.Error(t, err) {
+			assert.Equal(t, terr.NewNotFoundError("user not found"), err)
+		}
+	})
+
+	t.Run("should return an error", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		if err != nil {
+			t.Fatalf("an error '%s' was not expected", err)
+		}
+		defer db.Close()
+
+		userRepo := NewUserRepository(db, time.Second)
+
+		mockError := errors.New("some error")
+		mock.
+			ExpectExec("UPDATE users SET active=0").
+			WithArgs(mockDeletedAt, mockDeletedAt, "some-user-id").
+			WillReturnError(mockError)
+
+		err = userRepo.SoftDeleteUser(context.Background(), "some-user-id", mockDeletedAt)
+
+		if assert.Error(t, err) {
+			assert.Equal(t, mockError, err)
+		}
+	})
+}
+
+func TestDeleteUser(t *testing.T) {
+	t.Run("should delete", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		if err != nil {
+			t.Fatalf("an error '%s' was not expected", err)
+		}
+		defer db.Close()
+
+		userRepo := NewUserRepository(db, time.Second)
+
+		mock.
+			ExpectExec("DELETE FROM users WHERE id = \\?").
+			WithArgs("some-user-id").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		err = userRepo.DeleteUser(context.Background(), "some-user-id")
+
+		assert.NoError(t, err)
+	})
+
+	t.Run("should return a not found error", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		if err != nil {
+			t.Fatalf("an error '%s' was not expected", err)
+		}
+		defer db.Close()
+
+		userRepo := NewUserRepository(db, time.Second)
+
+		mock.
+			ExpectExec("DELETE FROM users").
+			WithArgs("some-user-id").
+			WillReturnResult(sqlmock.NewResult(0, 0))
+
+		err = userRepo.DeleteUser(context.Background(), "some-user-id")
+
+		if assert.Error(t, err) {
+			assert.Equal(t, terr.NewNotFoundError("user not found"), err)
+		}
+	})
+
+	t.Run("should return an error", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		if err != nil {
+			t.Fatalf("an error '%s' was not expected", err)
+		}
+		defer db.Close()
+
+		userRepo := NewUserRepository(db, time.Second)
+
+		mockError := errors.New("some error")
+		mock.
+			ExpectExec("DELETE FROM users").
+			WithArgs("some-user-id").
+			WillReturnError(mockError)
+
+		err = userRepo.DeleteUser(context.Background(), "some-user-id")
+
+		if assert.Error(t, err) {
+			assert.Equal(t, mockError, err)
+		}
+	})
+}
@@ -0,0 +1,170 @@
+package pgds
+
+import (
+	"context"
+	"database/sql"
+	"sherman/src/app/database"
+	"sherman/src/app/utils/terr"
+	"sherman/src/domain/auth"
+	"strings"
+	"time"
+)
+
+// apiKeyRepository postgres implementation of auth.APIKeyRepository
+type apiKeyRepository struct {
+	DB           *sql.DB
+	QueryTimeout time.Duration
+}
+
+// NewAPIKeyRepository constructor
+func NewAPIKeyRepository(db *sql.DB, queryTimeout time.Duration) auth.APIKeyRepository {
+	return &apiKeyRepository{
+		DB:           db,
+		QueryTimeout: queryTimeout,
+	}
+}
+
+// CreateAPIKey persist a auth.APIKey in the datastore, scopes are stored space separated
+func (r *apiKeyRepository) CreateAPIKey(ctx context.Context, apiKey *auth.APIKey) error {
+	ctx, cancel := database.WithQueryTimeout(ctx, r.QueryTimeout)
+	defer cancel()
+
+	var expiresAt sql.NullTime
+	if apiKey.ExpiresAt != nil {
+		expiresAt = sql.NullTime{Time: *apiKey.ExpiresAt, Valid: true}
+	}
+
+	query := `
+		INSERT INTO api_keys (
+			id,
+			user_id,
+			name,
+			prefix,
+			key_hash,
+			scopes,
+			expires_at,
+			created_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+	`
+	_, err := database.Conn(ctx, r.DB).ExecContext(ctx, query,
+		apiKey.ID,
+		apiKey.UserID,
+		apiKey.Name,
+		apiKey.Prefix,
+		apiKey.KeyHash,
+		strings.Join(apiKey.Scopes, " "),
+		expiresAt,
+		apiKey.CreatedAt,
+	)
+	return err
+}
+
+// GetAPIKeysByUserID gets the auth.APIKey(s) of a user from the datastore, most recent first
+func (r *apiKeyRepository) GetAPIKeysByUserID(ctx context.Context, userID string) ([]auth.APIKey, error) {
+	ctx, cancel := database.WithQueryTimeout(ctx, r.QueryTimeout)
+	defer cancel()
+
+	query := `
+		SELECT
+			id,
+			user_id,
+			name,
+			prefix,
+			key_hash,
+			scopes,
+			expires_at,
+			created_at
+		FROM api_keys
+		WHERE user_id = $1
+		ORDER BY created_at DESC
+	`
+	rows, err := database.Conn(ctx, r.DB).QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	apiKeys := make([]auth.APIKey, 0)
+	for rows.Next() {
+		apiKey, err := r.scanAPIKeyRow(rows)
+		if err != nil {
+			return nil, err
+		}
+		apiKeys = append(apiKeys, apiKey)
+	}
+
+	return apiKeys, rows.Err()
+}
+
+// GetAPIKeyByHash gets the auth.APIKey of a key hash from the datastore
+func (r *apiKeyRepository) GetAPIKeyByHash(ctx context.Context, keyHash string) (auth.APIKey, error) {
+	ctx, cancel := database.WithQueryTimeout(ctx, r.QueryTimeout)
+	defer cancel()
+
+	query := `
+		SELECT
+			id,
+			user_id,
+			name,
+			prefix,
+			key_hash,
+			scopes,
+			expires_at,
+			created_at
+		FROM api_keys
+		WHERE key_hash = $1 LIMIT 1
+	`
+	apiKey, err := r.scanAPIKeyRow(database.Conn(ctx, r.DB).QueryRowContext(ctx, query, keyHash))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			err = terr.NewNotFoundError("api key not found")
+		}
+		return auth.APIKey{}, err
+	}
+
+	return apiKey, nil
+}
+
+// RemoveAPIKey removes an auth.APIKey of a user from the datastore
+func (r *apiKeyRepository) RemoveAPIKey(ctx context.Context, userID, id string) error {
+	ctx, cancel := database.WithQueryTimeout(ctx, r.QueryTimeout)
+	defer cancel()
+
+	result, err := database.Conn(ctx, r.DB).ExecContext(ctx, `DELETE FROM api_keys WHERE id = $1 AND user_id = $2`, id, userID)
+	if err != nil {
+		return err
+	}
+
+	if affected, err := result.RowsAffected(); err == nil && affected == 0 {
+		return terr.NewNotFoundError("api key not found")
+	}
+	return nil
+}
+
+// scanAPIKeyRow scans a row of api_keys, keys without scopes get nil scopes
+func (r *apiKeyRepository) scanAPIKeyRow(row rowScanner) (auth.APIKey, error) {
+	var apiKey auth.APIKey
+	var scopes string
+	var expiresAt sql.NullTime
+
+	err := row.Scan(
+		&apiKey.ID,
+		&apiKey.UserID,
+		&apiKey.Name,
+		&apiKey.Prefix,
+		&apiKey.KeyHash,
+		&scopes,
+		&expiresAt,
+		&apiKey.CreatedAt)
+	if err != nil {
+		return auth.APIKey{}, err
+	}
+
+	if scopes != "" {
+		apiKey.Scopes = strings.Fields(scopes)
+	}
+	if expiresAt.Valid {
+		apiKey.ExpiresAt = &expiresAt.Time
+	}
+	return apiKey, nil
+}
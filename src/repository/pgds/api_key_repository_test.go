@@ -0,0 +1,215 @@
+package pgds
+
+import (
+	"context"
+	"database/sql"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	_ "sherman/src/app/testing"
+	"sherman/src/app/utils/terr"
+	"sherman/src/domain/auth"
+	"testing"
+	"time"
+)
+
+func TestCreateAPIKey(t *testing.T) {
+	expiresAt := time.Now().Add(time.Hour)
+	apiKey := &auth.APIKey{
+		ID:        "some-id",
+		UserID:    "some-user-id",
+		Name:      "some key",
+		Prefix:    "shm_abcdefgh",
+		KeyHash:   "some-hash",
+		Scopes:    []string{auth.ProfileScope, auth.AdminScope},
+		ExpiresAt: &expiresAt,
+		CreatedAt: time.Now(),
+	}
+
+	t.Run("should insert", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		if err != nil {
+			t.Fatalf("an error '%s' was not expected", err)
+		}
+		defer db.Close()
+
+		apiKeyRepo := NewAPIKeyRepository(db, time.Second)
+
+		mock.
+			ExpectExec("INSERT INTO api_keys").
+			WithArgs(
+				apiKey.ID,
+				apiKey.UserID,
+				apiKey.Name,
+				apiKey.Prefix,
+				apiKey.KeyHash,
+				"profile admin",
+				sql.NullTime{Time: expiresAt, Valid: true},
+				apiKey.CreatedAt,
+			).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+
+		assert.NoError(t, apiKeyRepo.CreateAPIKey(context.Background(), apiKey))
+	})
+
+	t.Run("should insert without expiry", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		if err != nil {
+			t.Fatalf("an error '%s' was not expected", err)
+		}
+		defer db.Close()
+
+		apiKeyRepo := NewAPIKeyRepository(db, time.Second)
+		unexpiringKey := *apiKey
+		unexpiringKey.ExpiresAt = nil
+		unexpiringKey.Scopes = nil
+
+		mock.
+			ExpectExec("INSERT INTO api_keys").
+			WithArgs(
+				apiKey.ID,
+				apiKey.UserID,
+				apiKey.Name,
+				apiKey.Prefix,
+				apiKey.KeyHash,
+				"",
+				sql.NullTime{},
+				apiKey.CreatedAt,
+			).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+
+		assert.NoError(t, apiKeyRepo.CreateAPIKey(context.Background(), &unexpiringKey))
+	})
+}
+
+func TestGetAPIKeysByUserID(t *testing.T) {
+	now := time.Now()
+	columns := []string{"id", "user_id", "name", "prefix", "key_hash", "scopes", "expires_at", "created_at"}
+
+	t.Run("should return the api keys of a user", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		if err != nil {
+			t.Fatalf("an error '%s' was not expected", err)
+		}
+		defer db.Close()
+
+		apiKeyRepo := NewAPIKeyRepository(db, time.Second)
+
+		mock.
+			ExpectQuery("SELECT id, user_id, name, prefix, key_hash, scopes, expires_at, created_at FROM api_keys").
+			WithArgs("some-user-id").
+			WillReturnRows(sqlmock.NewRows(columns).
+				AddRow("some-id", "some-user-id", "some key", "shm_abcdefgh", "some-hash", "profile", now, now).
+				AddRow("other-id", "some-user-id", "other key", "shm_ijklmnop", "other-hash", "", nil, now))
+
+		apiKeys, err := apiKeyRepo.GetAPIKeysByUserID(context.Background(), "some-user-id")
+
+		if assert.NoError(t, err) {
+			assert.Equal(t, []auth.APIKey{
+				{
+					ID:        "some-id",
+					UserID:    "some-user-id",
+					Name:      "some key",
+					Prefix:    "shm_abcdefgh",
+					KeyHash:   "some-hash",
+					Scopes:    []string{auth.ProfileScope},
+					ExpiresAt: &now,
+					CreatedAt: now,
+				},
+				{
+					ID:        "other-id",
+					UserID:    "some-user-id",
+					Name:      "other key",
+					Prefix:    "shm_ijklmnop",
+					KeyHash:   "other-hash",
+					CreatedAt: now,
+				},
+			}, apiKeys)
+		}
+	})
+}
+
+func TestGetAPIKeyByHash(t *testing.T) {
+	now := time.Now()
+	columns := []string{"id", "user_id", "name", "prefix", "key_hash", "scopes", "expires_at", "created_at"}
+
+	t.Run("should return an api key", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		if err != nil {
+			t.Fatalf("an error '%s' was not expected", err)
+		}
+		defer db.Close()
+
+		apiKeyRepo := NewAPIKeyRepository(db, time.Second)
+
+		mock.
+			ExpectQuery("SELECT id, user_id, name, prefix, key_hash, scopes, expires_at, created_at FROM api_keys").
+			WithArgs("some-hash").
+			WillReturnRows(sqlmock.NewRows(columns).
+				AddRow("some-id", "some-user-id", "some key", "shm_abcdefgh", "some-hash", "", nil, now))
+
+		apiKey, err := apiKeyRepo.GetAPIKeyByHash(context.Background(), "some-hash")
+
+		if assert.NoError(t, err) {
+			assert.Equal(t, "some-id", apiKey.ID)
+			assert.Nil(t, apiKey.Scopes)
+			assert.Nil(t, apiKey.ExpiresAt)
+		}
+	})
+
+	t.Run("should return a not found error", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		if err != nil {
+			t.Fatalf("an error '%s' was not expected", err)
+		}
+		defer db.Close()
+
+		apiKeyRepo := NewAPIKeyRepository(db, time.Second)
+
+		mock.
+			ExpectQuery("SELECT id").
+			WithArgs("some-hash").
+			WillReturnError(sql.ErrNoRows)
+
+		_, err = apiKeyRepo.GetAPIKeyByHash(context.Background(), "some-hash")
+
+		assert.Equal(t, terr.NewNotFoundError("api key not found"), err)
+	})
+}
+
+func TestRemoveAPIKey(t *testing.T) {
+	t.Run("should remove", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		if err != nil {
+			t.Fatalf("an error '%s' was not expected", err)
+		}
+		defer db.Close()
+
+		apiKeyRepo := NewAPIKeyRepository(db, time.Second)
+
+		mock.
+			ExpectExec("DELETE FROM api_keys").
+			WithArgs("some-id", "some-user-id").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		assert.NoError(t, apiKeyRepo.RemoveAPIKey(context.Background(), "some-user-id", "some-id"))
+	})
+
+	t.Run("should return a not found error", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		if err != nil {
+			t.Fatalf("an error '%s' was not expected", err)
+		}
+		defer db.Close()
+
+		apiKeyRepo := NewAPIKeyRepository(db, time.Second)
+
+		mock.
+			ExpectExec("DELETE FROM api_keys").
+			WithArgs("some-id", "some-user-id").
+			WillReturnResult(sqlmock.NewResult(0, 0))
+
+		err = apiKeyRepo.RemoveAPIKey(context.Background(), "some-user-id", "some-id")
+
+		assert.Equal(t, terr.NewNotFoundError("api key not found"), err)
+	})
+}
@@ -0,0 +1,61 @@
+package pgds
+
+import (
+	"context"
+	"errors"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	_ "sherman/src/app/testing"
+	"sherman/src/domain/auth"
+	"testing"
+	"time"
+)
+
+func TestCreateAuditLog(t *testing.T) {
+	al := &auth.AuditLog{
+		ID:        uuid.New().String(),
+		UserID:    "some-user-id",
+		ActorID:   "some-actor-id",
+		Action:    auth.UserErasedAction,
+		CreatedAt: time.Now(),
+	}
+
+	t.Run("should insert", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		if err != nil {
+			t.Fatalf("an error '%s' was not expected", err)
+		}
+		defer db.Close()
+
+		auditLogRepo := NewAuditLogRepository(db, time.Second)
+
+		mock.
+			ExpectExec("INSERT INTO audit_logs").
+			WithArgs(al.ID, al.UserID, al.ActorID, al.Action, al.CreatedAt).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+
+		err = auditLogRepo.CreateAuditLog(context.Background(), al)
+
+		assert.NoError(t, err)
+	})
+
+	t.Run("should return error", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		if err != nil {
+			t.Fatalf("an error '%s' was not expected", err)
+		}
+		defer db.Close()
+
+		auditLogRepo := NewAuditLogRepository(db, time.Second)
+
+		mock.
+			ExpectExec("INSERT INTO audit_logs").
+			WithArgs(al.ID, al.UserID, al.ActorID, al.Action, al.CreatedAt).
+			WillReturnError(errors.New("some error"))
+
+		err = auditLogRepo.CreateAuditLog(context.Background(), al)
+
+		assert.Error(t, err)
+	})
+}
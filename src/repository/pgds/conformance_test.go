@@ -0,0 +1,33 @@
+package pgds
+
+import (
+	"database/sql"
+	"os"
+	_ "sherman/src/app/testing"
+	"sherman/src/domain/auth"
+	"sherman/src/repository/repositorytest"
+	"testing"
+	"time"
+)
+
+// TestConformance runs against the migrated postgres database of PG_TEST_DSN, its users and
+// security tokens are removed, it is skipped without PG_TEST_DSN
+func TestConformance(t *testing.T) {
+	dsn := os.Getenv("PG_TEST_DSN")
+	if dsn == "" {
+		t.Skip("PG_TEST_DSN is not set")
+	}
+
+	db, err := sql.Open("postgres", dsn)
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected", err)
+	}
+	defer db.Close()
+
+	repositorytest.Run(t, func(t *testing.T) (auth.UserRepository, auth.SecurityTokenRepository, auth.UnitOfWork) {
+		if _, err := db.Exec(`TRUNCATE users CASCADE`); err != nil {
+			t.Fatalf("an error '%s' was not expected", err)
+		}
+		return NewUserRepository(db, time.Second), NewSecurityTokenRepository(db, time.Second), NewUnitOfWork(db)
+	})
+}
@@ -0,0 +1,187 @@
+package pgds
+
+import (
+	"context"
+	"errors"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"regexp"
+	_ "sherman/src/app/testing"
+	"sherman/src/domain/auth"
+	"testing"
+	"time"
+)
+
+func TestCreateRevokedToken(t *testing.T) {
+	rt := &auth.RevokedToken{
+		ID:        uuid.New().String(),
+		UserID:    "some-user-id",
+		ExpiresAt: time.Now().Add(time.Minute * time.Duration(15)),
+		CreatedAt: time.Now(),
+	}
+
+	t.Run("should insert", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		if err != nil {
+			t.Fatalf("an error '%s' was not expected", err)
+		}
+		defer db.Close()
+
+		revokedTokenRepo := NewRevokedTokenRepository(db, time.Second)
+
+		mock.
+			ExpectExec("INSERT INTO revoked_tokens").
+			WithArgs(rt.ID, rt.UserID, rt.ExpiresAt, rt.CreatedAt).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+
+		err = revokedTokenRepo.CreateRevokedToken(context.Background(), rt)
+
+		assert.NoError(t, err)
+	})
+
+	t.Run("should ignore an already revoked token", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		if err != nil {
+			t.Fatalf("an error '%s' was not expected", err)
+		}
+		defer db.Close()
+
+		revokedTokenRepo := NewRevokedTokenRepository(db, time.Second)
+
+		mock.
+			ExpectExec(regexp.QuoteMeta("ON CONFLICT (id) DO NOTHING")).
+			WithArgs(rt.ID, rt.UserID, rt.ExpiresAt, rt.CreatedAt).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+
+		err = revokedTokenRepo.CreateRevokedToken(context.Background(), rt)
+
+		assert.NoError(t, err)
+	})
+
+	t.Run("should return error", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		if err != nil {
+			t.Fatalf("an error '%s' was not expected", err)
+		}
+		defer db.Close()
+
+		revokedTokenRepo := NewRevokedTokenRepository(db, time.Second)
+
+		mock.
+			ExpectExec("INSERT INTO revoked_tokens").
+			WithArgs(rt.ID, rt.UserID, rt.ExpiresAt, rt.CreatedAt).
+			WillReturnError(errors.New("some error"))
+
+		err = revokedTokenRepo.CreateRevokedToken(context.Background(), rt)
+
+		assert.Error(t, err)
+	})
+}
+
+func TestIsTokenRevoked(t *testing.T) {
+	t.Run("should return true", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		if err != nil {
+			t.Fatalf("an error '%s' was not expected", err)
+		}
+		defer db.Close()
+
+		revokedTokenRepo := NewRevokedTokenRepository(db, time.Second)
+
+		mock.
+			ExpectQuery("SELECT COUNT(.+) FROM revoked_tokens WHERE").
+			WithArgs("some-token-id").
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+
+		revoked, err := revokedTokenRepo.IsTokenRevoked(context.Background(), "some-token-id")
+
+		if assert.NoError(t, err) {
+			assert.True(t, revoked)
+		}
+	})
+
+	t.Run("should return false", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		if err != nil {
+			t.Fatalf("an error '%s' was not expected", err)
+		}
+		defer db.Close()
+
+		revokedTokenRepo := NewRevokedTokenRepository(db, time.Second)
+
+		mock.
+			ExpectQuery("SELECT COUNT(.+) FROM revoked_tokens WHERE").
+			WithArgs("some-token-id").
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+
+		revoked, err := revokedTokenRepo.IsTokenRevoked(context.Background(), "some-token-id")
+
+		if assert.NoError(t, err) {
+			assert.False(t, revoked)
+		}
+	})
+
+	t.Run("should return error", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		if err != nil {
+			t.Fatalf("an error '%s' was not expected", err)
+		}
+		defer db.Close()
+
+		revokedTokenRepo := NewRevokedTokenRepository(db, time.Second)
+
+		mock.
+			ExpectQuery("SELECT COUNT(.+) FROM revoked_tokens WHERE").
+			WithArgs("some-token-id").
+			WillReturnError(errors.New("some error"))
+
+		revoked, err := revokedTokenRepo.IsTokenRevoked(context.Background(), "some-token-id")
+
+		if assert.Error(t, err) {
+			assert.False(t, revoked)
+		}
+	})
+}
+
+func TestRemoveExpiredRevokedTokens(t *testing.T) {
+	now := time.Now()
+
+	t.Run("should remove", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		if err != nil {
+			t.Fatalf("an error '%s' was not expected", err)
+		}
+		defer db.Close()
+
+		revokedTokenRepo := NewRevokedTokenRepository(db, time.Second)
+
+		mock.
+			ExpectExec("DELETE FROM revoked_tokens WHERE").
+			WithArgs(now).
+			WillReturnResult(sqlmock.NewResult(0, 2))
+
+		err = revokedTokenRepo.RemoveExpiredRevokedTokens(context.Background(), now)
+
+		assert.NoError(t, err)
+	})
+
+	t.Run("should return error", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		if err != nil {
+			t.Fatalf("an error '%s' was not expected", err)
+		}
+		defer db.Close()
+
+		revokedTokenRepo := NewRevokedTokenRepository(db, time.Second)
+
+		mock.
+			ExpectExec("DELETE FROM revoked_tokens WHERE").
+			WithArgs(now).
+			WillReturnError(errors.New("some error"))
+
+		err = revokedTokenRepo.RemoveExpiredRevokedTokens(context.Background(), now)
+
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,304 @@
+package pgds
+
+import (
+	"context"
+	"errors"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	_ "sherman/src/app/testing"
+	"sherman/src/app/utils/terr"
+	"sherman/src/domain/auth"
+	"testing"
+	"time"
+)
+
+func TestGetRoleByName(t *testing.T) {
+	mockRole := auth.Role{
+		ID:        "some-role-id",
+		Name:      auth.AdminRole,
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	}
+
+	t.Run("should get a role", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		if err != nil {
+			t.Fatalf("an error '%s' was not expected", err)
+		}
+		defer db.Close()
+
+		roleRepo := NewRoleRepository(db, time.Second)
+
+		rows := sqlmock.NewRows([]string{"id", "name", "created_at", "updated_at"}).
+			AddRow(mockRole.ID, mockRole.Name, mockRole.CreatedAt, mockRole.UpdatedAt)
+		mock.
+			ExpectQuery("SELECT (.+) FROM roles WHERE").
+			WithArgs(auth.AdminRole).
+			WillReturnRows(rows)
+
+		role, err := roleRepo.GetRoleByName(context.Background(), auth.AdminRole)
+
+		if assert.NoError(t, err) {
+			assert.Equal(t, mockRole, role)
+		}
+	})
+
+	t.Run("should return a not found error", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		if err != nil {
+			t.Fatalf("an error '%s' was not expected", err)
+		}
+		defer db.Close()
+
+		roleRepo := NewRoleRepository(db, time.Second)
+
+		mock.
+			ExpectQuery("SELECT (.+) FROM roles WHERE").
+			WithArgs("some-role").
+			WillReturnRows(sqlmock.NewRows([]string{"id", "name", "created_at", "updated_at"}))
+
+		role, err := roleRepo.GetRoleByName(context.Background(), "some-role")
+
+		if assert.Error(t, err) {
+			assert.Equal(t, terr.NewNotFoundError("role not found"), err)
+			assert.Equal(t, auth.Role{}, role)
+		}
+	})
+}
+
+func TestGetRoleNamesByUserID(t *testing.T) {
+	t.Run("should get the role names", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		if err != nil {
+			t.Fatalf("an error '%s' was not expected", err)
+		}
+		defer db.Close()
+
+		roleRepo := NewRoleRepository(db, time.Second)
+
+		mock.
+			ExpectQuery("SELECT roles.name FROM roles INNER JOIN user_roles").
+			WithArgs("some-user-id").
+			WillReturnRows(sqlmock.NewRows([]string{"name"}).AddRow(auth.AdminRole).AddRow("some-role"))
+
+		roleNames, err := roleRepo.GetRoleNamesByUserID(context.Background(), "some-user-id")
+
+		if assert.NoError(t, err) {
+			assert.Equal(t, []string{auth.AdminRole, "some-role"}, roleNames)
+		}
+	})
+
+	t.Run("should get an empty list", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		if err != nil {
+			t.Fatalf("an error '%s' was not expected", err)
+		}
+		defer db.Close()
+
+		roleRepo := NewRoleRepository(db, time.Second)
+
+		mock.
+			ExpectQuery("SELECT roles.name FROM roles INNER JOIN user_roles").
+			WithArgs("some-user-id").
+			WillReturnRows(sqlmock.NewRows([]string{"name"}))
+
+		roleNames, err := roleRepo.GetRoleNamesByUserID(context.Background(), "some-user-id")
+
+		if assert.NoError(t, err) {
+			assert.NotNil(t, roleNames)
+			assert.Empty(t, roleNames)
+		}
+	})
+
+	t.Run("should return error", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		if err != nil {
+			t.Fatalf("an error '%s' was not expected", err)
+		}
+		defer db.Close()
+
+		roleRepo := NewRoleRepository(db, time.Second)
+
+		mock.
+			ExpectQuery("SELECT roles.name FROM roles INNER JOIN user_roles").
+			WithArgs("some-user-id").
+			WillReturnError(errors.New("some error"))
+
+		_, err = roleRepo.GetRoleNamesByUserID(context.Background(), "some-user-id")
+
+		assert.Error(t, err)
+	})
+}
+
+func TestGetPermissionsByRoleNames(t *testing.T) {
+	t.Run("should get the permissions", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		if err != nil {
+			t.Fatalf("an error '%s' was not expected", err)
+		}
+		defer db.Close()
+
+		roleRepo := NewRoleRepository(db, time.Second)
+
+		mock.
+			ExpectQuery(`SELECT DISTINCT permissions.name FROM permissions (.+) WHERE roles.name IN \(\$1, \$2\)`).
+			WithArgs(auth.AdminRole, "some-role").
+			WillReturnRows(sqlmock.NewRows([]string{"name"}).
+				AddRow(auth.ManageRolesPermission).
+				AddRow(auth.ReadUsersPermission))
+
+		permissions, err := roleRepo.GetPermissionsByRoleNames(context.Background(), []string{auth.AdminRole, "some-role"})
+
+		if assert.NoError(t, err) {
+			assert.Equal(t, []string{auth.ManageRolesPermission, auth.ReadUsersPermission}, permissions)
+		}
+	})
+
+	t.Run("should not query without roles", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		if err != nil {
+			t.Fatalf("an error '%s' was not expected", err)
+		}
+		defer db.Close()
+
+		roleRepo := NewRoleRepository(db, time.Second)
+
+		permissions, err := roleRepo.GetPermissionsByRoleNames(context.Background(), nil)
+
+		if assert.NoError(t, err) {
+			assert.Empty(t, permissions)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		}
+	})
+
+	t.Run("should return error", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		if err != nil {
+			t.Fatalf("an error '%s' was not expected", err)
+		}
+		defer db.Close()
+
+		roleRepo := NewRoleRepository(db, time.Second)
+
+		mock.
+			ExpectQuery("SELECT DISTINCT permissions.name FROM permissions").
+			WithArgs(auth.AdminRole).
+			WillReturnError(errors.New("some error"))
+
+		_, err = roleRepo.GetPermissionsByRoleNames(context.Background(), []string{auth.AdminRole})
+
+		assert.Error(t, err)
+	})
+}
+
+func TestCreateUserRole(t *testing.T) {
+	ur := &auth.UserRole{
+		UserID:    "some-user-id",
+		RoleID:    "some-role-id",
+		CreatedAt: time.Now(),
+	}
+
+	t.Run("should insert", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		if err != nil {
+			t.Fatalf("an error '%s' was not expected", err)
+		}
+		defer db.Close()
+
+		roleRepo := NewRoleRepository(db, time.Second)
+
+		mock.
+			ExpectExec("INSERT INTO user_roles").
+			WithArgs(ur.UserID, ur.RoleID, ur.CreatedAt).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+
+		err = roleRepo.CreateUserRole(context.Background(), ur)
+
+		assert.NoError(t, err)
+	})
+
+	t.Run("should return a duplicate entry error", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		if err != nil {
+			t.Fatalf("an error '%s' was not expected", err)
+		}
+		defer db.Close()
+
+		roleRepo := NewRoleRepository(db, time.Second)
+
+		mock.
+			ExpectExec("INSERT INTO user_roles").
+			WithArgs(ur.UserID, ur.RoleID, ur.CreatedAt).
+			WillReturnError(&pq.Error{Code: uniqueViolation})
+
+		err = roleRepo.CreateUserRole(context.Background(), ur)
+
+		if assert.Error(t, err) {
+			assert.Equal(t, terr.NewDuplicateEntryError("role already assigned"), err)
+		}
+	})
+
+	t.Run("should return a not found error", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		if err != nil {
+			t.Fatalf("an error '%s' was not expected", err)
+		}
+		defer db.Close()
+
+		roleRepo := NewRoleRepository(db, time.Second)
+
+		mock.
+			ExpectExec("INSERT INTO user_roles").
+			WithArgs(ur.UserID, ur.RoleID, ur.CreatedAt).
+			WillReturnError(&pq.Error{Code: foreignKeyViolation})
+
+		err = roleRepo.CreateUserRole(context.Background(), ur)
+
+		if assert.Error(t, err) {
+			assert.Equal(t, terr.NewNotFoundError("user not found"), err)
+		}
+	})
+}
+
+func TestRemoveUserRole(t *testing.T) {
+	t.Run("should remove", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		if err != nil {
+			t.Fatalf("an error '%s' was not expected", err)
+		}
+		defer db.Close()
+
+		roleRepo := NewRoleRepository(db, time.Second)
+
+		mock.
+			ExpectExec("DELETE FROM user_roles WHERE").
+			WithArgs("some-user-id", "some-role-id").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		err = roleRepo.RemoveUserRole(context.Background(), "some-user-id", "some-role-id")
+
+		assert.NoError(t, err)
+	})
+
+	t.Run("should return a not found error", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		if err != nil {
+			t.Fatalf("an error '%s' was not expected", err)
+		}
+		defer db.Close()
+
+		roleRepo := NewRoleRepository(db, time.Second)
+
+		mock.
+			ExpectExec("DELETE FROM user_roles WHERE").
+			WithArgs("some-user-id", "some-role-id").
+			WillReturnResult(sqlmock.NewResult(0, 0))
+
+		err = roleRepo.RemoveUserRole(context.Background(), "some-user-id", "some-role-id")
+
+		if assert.Error(t, err) {
+			assert.Equal(t, terr.NewNotFoundError("role not assigned"), err)
+		}
+	})
+}
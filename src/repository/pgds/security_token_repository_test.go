@@ -0,0 +1,300 @@
+package pgds
+
+import (
+	"context"
+	"database/sql"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"regexp"
+	_ "sherman/src/app/testing"
+	"sherman/src/app/utils/terr"
+	"sherman/src/domain/auth"
+	"testing"
+	"time"
+)
+
+var tokenColumns = []string{
+	"id",
+	"user_id",
+	"token",
+	"type",
+	"family_id",
+	"rotated",
+	"user_agent",
+	"ip_address",
+	"last_used_at",
+	"created_at",
+	"updated_at",
+}
+
+func genToken() *auth.SecurityToken {
+	now := time.Now()
+	return &auth.SecurityToken{
+		ID:         "some-id",
+		UserID:     "some-user-id",
+		Token:      "some-token",
+		Type:       auth.RefreshTokenType,
+		FamilyID:   "some-family-id",
+		UserAgent:  "some-user-agent",
+		IPAddress:  "127.0.0.1",
+		LastUsedAt: now,
+		CreatedAt:  now,
+		UpdatedAt:  now,
+	}
+}
+
+func TestCreateToken(t *testing.T) {
+	st := genToken()
+
+	t.Run("should insert", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		if err != nil {
+			t.Fatalf("an error '%s' was not expected", err)
+		}
+		defer db.Close()
+
+		securityTokenRepo := NewSecurityTokenRepository(db, time.Second)
+
+		mock.
+			ExpectExec(regexp.QuoteMeta("VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)")).
+			WithArgs(st.ID, st.UserID, st.Token, st.Type, st.FamilyID, st.Rotated, st.UserAgent, st.IPAddress, st.LastUsedAt, st.CreatedAt, st.UpdatedAt).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		assert.NoError(t, securityTokenRepo.CreateToken(context.Background(), st))
+	})
+
+	t.Run("should return a not found error on unknown user", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		if err != nil {
+			t.Fatalf("an error '%s' was not expected", err)
+		}
+		defer db.Close()
+
+		securityTokenRepo := NewSecurityTokenRepository(db, time.Second)
+
+		mock.
+			ExpectExec("INSERT INTO security_tokens").
+			WillReturnError(&pq.Error{Code: foreignKeyViolation})
+
+		assert.Equal(t, terr.NewNotFoundError("user not found"), securityTokenRepo.CreateToken(context.Background(), st))
+	})
+}
+
+func TestCreateOrUpdateToken(t *testing.T) {
+	st := genToken()
+
+	t.Run("should upsert", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		if err != nil {
+			t.Fatalf("an error '%s' was not expected", err)
+		}
+		defer db.Close()
+
+		securityTokenRepo := NewSecurityTokenRepository(db, time.Second)
+
+		mock.
+			ExpectExec(regexp.QuoteMeta("ON CONFLICT (user_id, unique_type) DO UPDATE")).
+			WithArgs(st.ID, st.UserID, st.Token, st.Type, st.Type, st.FamilyID, st.Rotated, st.UserAgent, st.IPAddress, st.LastUsedAt, st.CreatedAt, st.UpdatedAt).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		assert.NoError(t, securityTokenRepo.CreateOrUpdateToken(context.Background(), st))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("should return a not found error on an unknown user", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		if err != nil {
+			t.Fatalf("an error '%s' was not expected", err)
+		}
+		defer db.Close()
+
+		securityTokenRepo := NewSecurityTokenRepository(db, time.Second)
+
+		mock.
+			ExpectExec("INSERT INTO security_tokens").
+			WillReturnError(&pq.Error{Code: foreignKeyViolation})
+
+		assert.Equal(t, terr.NewNotFoundError("user not found"), securityTokenRepo.CreateOrUpdateToken(context.Background(), st))
+	})
+}
+
+func TestGetTokenByMetadata(t *testing.T) {
+	st := genToken()
+	tokenMetadata := &auth.TokenMetadata{UserID: st.UserID, Type: st.Type, Token: st.Token}
+
+	t.Run("should return a token", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		if err != nil {
+			t.Fatalf("an error '%s' was not expected", err)
+		}
+		defer db.Close()
+
+		securityTokenRepo := NewSecurityTokenRepository(db, time.Second)
+
+		mock.
+			ExpectQuery(regexp.QuoteMeta("WHERE user_id = $1 AND type = $2 AND token = $3")).
+			WithArgs(st.UserID, st.Type, st.Token).
+			WillReturnRows(sqlmock.NewRows(tokenColumns).AddRow(
+				st.ID, st.UserID, st.Token, st.Type, st.FamilyID, st.Rotated, st.UserAgent, st.IPAddress, st.LastUsedAt, st.CreatedAt, st.UpdatedAt))
+
+		token, err := securityTokenRepo.GetTokenByMetadata(context.Background(), tokenMetadata)
+
+		if assert.NoError(t, err) {
+			assert.Equal(t, *st, token)
+		}
+	})
+
+	t.Run("should return a not found error", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		if err != nil {
+			t.Fatalf("an error '%s' was not expected", err)
+		}
+		defer db.Close()
+
+		securityTokenRepo := NewSecurityTokenRepository(db, time.Second)
+
+		mock.
+			ExpectQuery("SELECT").
+			WillReturnError(sql.ErrNoRows)
+
+		_, err = securityTokenRepo.GetTokenByMetadata(context.Background(), tokenMetadata)
+
+		assert.Equal(t, terr.NewNotFoundError("token not found"), err)
+	})
+}
+
+func TestGetTokensByUserID(t *testing.T) {
+	st := genToken()
+
+	t.Run("should return tokens", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		if err != nil {
+			t.Fatalf("an error '%s' was not expected", err)
+		}
+		defer db.Close()
+
+		securityTokenRepo := NewSecurityTokenRepository(db, time.Second)
+
+		mock.
+			ExpectQuery(regexp.QuoteMeta("WHERE user_id = $1 AND type = $2 AND rotated = FALSE ORDER BY last_used_at DESC")).
+			WithArgs(st.UserID, st.Type).
+			WillReturnRows(sqlmock.NewRows(tokenColumns).AddRow(
+				st.ID, st.UserID, st.Token, st.Type, st.FamilyID, st.Rotated, st.UserAgent, st.IPAddress, st.LastUsedAt, st.CreatedAt, st.UpdatedAt))
+
+		tokens, err := securityTokenRepo.GetTokensByUserID(context.Background(), st.UserID, st.Type)
+
+		if assert.NoError(t, err) {
+			assert.Equal(t, []auth.SecurityToken{*st}, tokens)
+		}
+	})
+}
+
+func TestRotateToken(t *testing.T) {
+	st := genToken()
+	rotatedToken := genToken()
+	rotatedToken.ID = "rotated-id"
+
+	t.Run("should rotate", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		if err != nil {
+			t.Fatalf("an error '%s' was not expected", err)
+		}
+		defer db.Close()
+
+		securityTokenRepo := NewSecurityTokenRepository(db, time.Second)
+
+		mock.ExpectBegin()
+		mock.
+			ExpectExec(regexp.QuoteMeta("WHERE id = $4 AND token = $5 AND rotated = FALSE")).
+			WithArgs(st.Token, st.LastUsedAt, st.UpdatedAt, st.ID, rotatedToken.Token).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.
+			ExpectExec("INSERT INTO security_tokens").
+			WithArgs(rotatedToken.ID, rotatedToken.UserID, rotatedToken.Token, rotatedToken.Type, rotatedToken.FamilyID, true,
+				rotatedToken.UserAgent, rotatedToken.IPAddress, rotatedToken.LastUsedAt, rotatedToken.CreatedAt, rotatedToken.UpdatedAt).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		assert.NoError(t, securityTokenRepo.RotateToken(context.Background(), st, rotatedToken))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("should return an un-authorized error", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		if err != nil {
+			t.Fatalf("an error '%s' was not expected", err)
+		}
+		defer db.Close()
+
+		securityTokenRepo := NewSecurityTokenRepository(db, time.Second)
+
+		mock.ExpectBegin()
+		mock.
+			ExpectExec(regexp.QuoteMeta("WHERE id = $4 AND token = $5 AND rotated = FALSE")).
+			WithArgs(st.Token, st.LastUsedAt, st.UpdatedAt, st.ID, rotatedToken.Token).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectRollback()
+
+		err = securityTokenRepo.RotateToken(context.Background(), st, rotatedToken)
+
+		assert.Equal(t, terr.NewUnAuthorizedError("refresh token already rotated"), err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestRemoveTokenByMetadata(t *testing.T) {
+	t.Run("should remove", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		if err != nil {
+			t.Fatalf("an error '%s' was not expected", err)
+		}
+		defer db.Close()
+
+		securityTokenRepo := NewSecurityTokenRepository(db, time.Second)
+
+		mock.
+			ExpectExec(regexp.QuoteMeta("DELETE FROM security_tokens WHERE user_id = $1 AND type = $2")).
+			WithArgs("some-user-id", auth.RefreshTokenType).
+			WillReturnResult(sqlmock.NewResult(0, 2))
+
+		err = securityTokenRepo.RemoveTokenByMetadata(context.Background(), &auth.TokenMetadata{UserID: "some-user-id", Type: auth.RefreshTokenType})
+
+		assert.NoError(t, err)
+	})
+}
+
+func TestRemoveTokenFamily(t *testing.T) {
+	t.Run("should remove", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		if err != nil {
+			t.Fatalf("an error '%s' was not expected", err)
+		}
+		defer db.Close()
+
+		securityTokenRepo := NewSecurityTokenRepository(db, time.Second)
+
+		mock.
+			ExpectExec(regexp.QuoteMeta("DELETE FROM security_tokens WHERE user_id = $1 AND family_id = $2")).
+			WithArgs("some-user-id", "some-family-id").
+			WillReturnResult(sqlmock.NewResult(0, 2))
+
+		assert.NoError(t, securityTokenRepo.RemoveTokenFamily(context.Background(), "some-user-id", "some-family-id"))
+	})
+
+	t.Run("should return a not found error", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		if err != nil {
+			t.Fatalf("an error '%s' was not expected", err)
+		}
+		defer db.Close()
+
+		securityTokenRepo := NewSecurityTokenRepository(db, time.Second)
+
+		mock.
+			ExpectExec("DELETE FROM security_tokens").
+			WillReturnResult(sqlmock.NewResult(0, 0))
+
+		assert.Equal(t, terr.NewNotFoundError("token not found"), securityTokenRepo.RemoveTokenFamily(context.Background(), "some-user-id", "some-family-id"))
+	})
+}
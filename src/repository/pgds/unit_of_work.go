@@ -0,0 +1,26 @@
+package pgds
+
+import (
+	"context"
+	"database/sql"
+	"sherman/src/app/database"
+	"sherman/src/domain/auth"
+)
+
+// unitOfWork postgres implementation of auth.UnitOfWork, the repositories built on the same *sql.DB
+// take part in its transactions
+type unitOfWork struct {
+	DB *sql.DB
+}
+
+// NewUnitOfWork constructor
+func NewUnitOfWork(db *sql.DB) auth.UnitOfWork {
+	return &unitOfWork{
+		DB: db,
+	}
+}
+
+// Do runs fn in a transaction, committed if fn returns nil and rolled back otherwise
+func (u *unitOfWork) Do(ctx context.Context, fn func(ctx context.Context) error) error {
+	return database.RunInTx(ctx, u.DB, fn)
+}
@@ -0,0 +1,269 @@
+package pgds
+
+import (
+	"context"
+	"database/sql"
+	"github.com/lib/pq"
+	"sherman/src/app/database"
+	"sherman/src/app/utils/terr"
+	"sherman/src/domain/auth"
+	"time"
+)
+
+// userRepository postgres implementation of auth.UserRepository
+type userRepository struct {
+	DB           *sql.DB
+	QueryTimeout time.Duration
+}
+
+// NewUserRepository constructor
+func NewUserRepository(db *sql.DB, queryTimeout time.Duration) auth.UserRepository {
+	return &userRepository{
+		DB:           db,
+		QueryTimeout: queryTimeout,
+	}
+}
+
+// rowScanner a *sql.Row or *sql.Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+const (
+	// uniqueViolation SQLSTATE of a unique or primary key constraint violation
+	uniqueViolation = "23505"
+	// foreignKeyViolation SQLSTATE of a foreign key constraint violation
+	foreignKeyViolation = "23503"
+)
+
+// hasSQLState tells whether err is a postgres error of the SQLSTATE code
+func hasSQLState(err error, code pq.ErrorCode) bool {
+	pqErr, ok := err.(*pq.Error)
+	return ok && pqErr.Code == code
+}
+
+func (r *userRepository) scanUserRow(row rowScanner) (auth.User, error) {
+	var user auth.User
+
+	err := row.Scan(
+		&user.ID,
+		&user.FirstName,
+		&user.LastName,
+		&user.EmailAddress,
+		&user.Password,
+		&user.Active,
+		&user.EmailVerified,
+		&user.CreatedAt,
+		&user.UpdatedAt)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			err = terr.NewNotFoundError("user not found")
+		}
+		return auth.User{}, err
+	}
+
+	return user, nil
+}
+
+// CreateUser persist a auth.User from the datastore
+func (r *userRepository) CreateUser(ctx context.Context, user *auth.User) error {
+	ctx, cancel := database.WithQueryTimeout(ctx, r.QueryTimeout)
+	defer cancel()
+
+	query := `
+		INSERT INTO users (
+			id,
+			first_name,
+			last_name,
+			email_address,
+			password,
+			active,
+			email_verified,
+			created_at,
+			updated_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+	`
+
+	_, err := database.Conn(ctx, r.DB).ExecContext(ctx, query,
+		user.ID,
+		user.FirstName,
+		user.LastName,
+		user.EmailAddress,
+		user.Password,
+		user.Active,
+		user.EmailVerified,
+		user.CreatedAt,
+		user.UpdatedAt,
+	)
+
+	if err != nil {
+		if hasSQLState(err, uniqueViolation) {
+			err = terr.NewDuplicateEntryError("user already exist")
+		}
+		return err
+	}
+
+	return nil
+}
+
+// UpdateUser updates a auth.User in the datastore
+func (r *userRepository) UpdateUser(ctx context.Context, user *auth.User) error {
+	ctx, cancel := database.WithQueryTimeout(ctx, r.QueryTimeout)
+	defer cancel()
+
+	query := `
+		UPDATE users
+		SET
+			first_name=$1,
+			last_name=$2,
+			email_address=$3,
+			password=$4,
+			active=$5,
+			email_verified=$6,
+			updated_at=$7
+		WHERE id = $8 AND deleted_at IS NULL
+	`
+
+	result, err := database.Conn(ctx, r.DB).ExecContext(ctx, query,
+		user.FirstName,
+		user.LastName,
+		user.EmailAddress,
+		user.Password,
+		user.Active,
+		user.EmailVerified,
+		user.UpdatedAt,
+		user.ID,
+	)
+	if err != nil {
+		if hasSQLState(err, uniqueViolation) {
+			err = terr.NewDuplicateEntryError("user already exist")
+		}
+		return err
+	}
+
+	if affected, err := result.RowsAffected(); err == nil && affected == 0 {
+		return terr.NewNotFoundError("user not found")
+	}
+	return nil
+}
+
+// UpdateUserPassword updates the password hash of a auth.User in the datastore
+func (r *userRepository) UpdateUserPassword(ctx context.Context, id, password string, updatedAt time.Time) error {
+	ctx, cancel := database.WithQueryTimeout(ctx, r.QueryTimeout)
+	defer cancel()
+
+	query := `UPDATE users SET password=$1, updated_at=$2 WHERE id = $3 AND deleted_at IS NULL`
+
+	result, err := database.Conn(ctx, r.DB).ExecContext(ctx, query, password, updatedAt, id)
+	if err != nil {
+		return err
+	}
+
+	if affected, err := result.RowsAffected(); err == nil && affected == 0 {
+		return terr.NewNotFoundError("user not found")
+	}
+	return nil
+}
+
+// UpdateUserActive activates or deactivates a auth.User in the datastore
+func (r *userRepository) UpdateUserActive(ctx context.Context, id string, active bool, updatedAt time.Time) error {
+	ctx, cancel := database.WithQueryTimeout(ctx, r.QueryTimeout)
+	defer cancel()
+
+	query := `UPDATE users SET active=$1, updated_at=$2 WHERE id = $3 AND deleted_at IS NULL`
+
+	result, err := database.Conn(ctx, r.DB).ExecContext(ctx, query, active, updatedAt, id)
+	if err != nil {
+		return err
+	}
+
+	if affected, err := result.RowsAffected(); err == nil && affected == 0 {
+		return terr.NewNotFoundError("user not found")
+	}
+	return nil
+}
+
+// SoftDeleteUser deactivates a auth.User and flags it as deleted in the datastore, deleted users
+// are no longer found but their data is kept
+func (r *userRepository) SoftDeleteUser(ctx context.Context, id string, deletedAt time.Time) error {
+	ctx, cancel := database.WithQueryTimeout(ctx, r.QueryTimeout)
+	defer cancel()
+
+	query := `UPDATE users SET active=FALSE, updated_at=$1, deleted_at=$1 WHERE id = $2 AND deleted_at IS NULL`
+
+	result, err := database.Conn(ctx, r.DB).ExecContext(ctx, query, deletedAt, id)
+	if err != nil {
+		return err
+	}
+
+	if affected, err := result.RowsAffected(); err == nil && affected == 0 {
+		return terr.NewNotFoundError("user not found")
+	}
+	return nil
+}
+
+// DeleteUser removes a auth.User from the datastore, soft deleted or not, its security tokens
+// and roles are removed by cascade
+func (r *userRepository) DeleteUser(ctx context.Context, id string) error {
+	ctx, cancel := database.WithQueryTimeout(ctx, r.QueryTimeout)
+	defer cancel()
+
+	query := `DELETE FROM users WHERE id = $1`
+
+	result, err := database.Conn(ctx, r.DB).ExecContext(ctx, query, id)
+	if err != nil {
+		return err
+	}
+
+	if affected, err := result.RowsAffected(); err == nil && affected == 0 {
+		return terr.NewNotFoundError("user not found")
+	}
+	return nil
+}
+
+// GetUserByID gets a non deleted auth.User by id in the datastore
+func (r *userRepository) GetUserByID(ctx context.Context, id string) (auth.User, error) {
+	ctx, cancel := database.WithQueryTimeout(ctx, r.QueryTimeout)
+	defer cancel()
+
+	query := `
+		SELECT
+			id,
+			first_name,
+			last_name,
+			email_address,
+			password,
+			active,
+			email_verified,
+			created_at,
+			updated_at
+		FROM users
+		WHERE id = $1 AND deleted_at IS NULL LIMIT 1
+	`
+	row := database.Conn(ctx, r.DB).QueryRowContext(ctx, query, id)
+	return r.scanUserRow(row)
+}
+
+// GetUserByEmail gets a non deleted auth.User by email from the datastore
+func (r *userRepository) GetUserByEmail(ctx context.Context, email string) (auth.User, error) {
+	ctx, cancel := database.WithQueryTimeout(ctx, r.QueryTimeout)
+	defer cancel()
+
+	query := `
+		SELECT
+			id,
+			first_name,
+			last_name,
+			email_address,
+			password,
+			active,
+			email_verified,
+			created_at,
+			updated_at
+		FROM users
+		WHERE email_address = $1 AND deleted_at IS NULL LIMIT 1
+	`
+	row := database.Conn(ctx, r.DB).QueryRowContext(ctx, query, email)
+	return r.scanUserRow(row)
+}
@@ -0,0 +1,182 @@
+package pgds
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"sherman/src/app/database"
+	"sherman/src/app/utils/terr"
+	"sherman/src/domain/auth"
+	"strings"
+	"time"
+)
+
+// userSortColumns maps the auth.UserQuery sort keys to their column, no other column can be sorted on
+var userSortColumns = map[string]string{
+	"created_at":    "created_at",
+	"email_address": "email_address",
+	"first_name":    "first_name",
+	"last_name":     "last_name",
+}
+
+// likeEscaper escapes the LIKE wildcards of a user provided pattern
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// userCursor position of the last user of a page, it is only valid with the sort key it was made for
+type userCursor struct {
+	SortKey string `json:"k"`
+	Value   string `json:"v"`
+	ID      string `json:"id"`
+}
+
+// ListUsers gets a page of non deleted auth.User(s) from the datastore, pages are keyed on the sort
+// column and the id so that they stay consistent while users are added
+func (r *userRepository) ListUsers(ctx context.Context, query auth.UserQuery) (auth.UserPage, error) {
+	ctx, cancel := database.WithQueryTimeout(ctx, r.QueryTimeout)
+	defer cancel()
+
+	column, ok := userSortColumns[query.SortKey]
+	if !ok {
+		return auth.UserPage{}, terr.NewInvalidQueryError("invalid sort key")
+	}
+	if query.Limit < 1 {
+		return auth.UserPage{}, terr.NewInvalidQueryError("invalid limit")
+	}
+
+	conditions, args := userQueryConditions(&query)
+
+	var total int
+	countQuery := `SELECT COUNT(*) FROM users WHERE ` + strings.Join(conditions, " AND ")
+	if err := database.Conn(ctx, r.DB).QueryRowContext(ctx, countQuery, args...).Scan(&total); err != nil {
+		return auth.UserPage{}, err
+	}
+
+	direction, comparison := "ASC", ">"
+	if query.SortDesc {
+		direction, comparison = "DESC", "<"
+	}
+
+	if query.Cursor != "" {
+		cursorValue, cursorID, err := decodeUserCursor(query.Cursor, query.SortKey)
+		if err != nil {
+			return auth.UserPage{}, err
+		}
+		args = append(args, cursorValue, cursorID)
+		conditions = append(conditions, fmt.Sprintf("(%s, id) %s ($%d, $%d)", column, comparison, len(args)-1, len(args)))
+	}
+
+	// one more user than the limit tells whether there is a next page
+	listQuery := fmt.Sprintf(`
+		SELECT
+			id,
+			first_name,
+			last_name,
+			email_address,
+			password,
+			active,
+			email_verified,
+			created_at,
+			updated_at
+		FROM users
+		WHERE %s
+		ORDER BY %s %s, id %s
+		LIMIT $%d
+	`, strings.Join(conditions, " AND "), column, direction, direction, len(args)+1)
+	args = append(args, query.Limit+1)
+
+	rows, err := database.Conn(ctx, r.DB).QueryContext(ctx, listQuery, args...)
+	if err != nil {
+		return auth.UserPage{}, err
+	}
+	defer rows.Close()
+
+	users := make([]auth.User, 0)
+	for rows.Next() {
+		user, err := r.scanUserRow(rows)
+		if err != nil {
+			return auth.UserPage{}, err
+		}
+		users = append(users, user)
+	}
+	if err := rows.Err(); err != nil {
+		return auth.UserPage{}, err
+	}
+
+	page := auth.UserPage{Users: users, Total: total}
+	if len(users) > query.Limit {
+		page.Users = users[:query.Limit]
+		page.NextCursor = encodeUserCursor(&page.Users[query.Limit-1], query.SortKey)
+	}
+	return page, nil
+}
+
+// userQueryConditions returns the where conditions of the filters of a auth.UserQuery and their
+// args, user input is only ever passed as args, numbered in the order of the conditions
+func userQueryConditions(query *auth.UserQuery) ([]string, []interface{}) {
+	conditions := []string{"deleted_at IS NULL"}
+	args := make([]interface{}, 0)
+
+	addCondition := func(condition string, arg interface{}) {
+		args = append(args, arg)
+		conditions = append(conditions, fmt.Sprintf(condition, len(args)))
+	}
+
+	if query.Active != nil {
+		addCondition("active = $%d", *query.Active)
+	}
+	if !query.CreatedFrom.IsZero() {
+		addCondition("created_at >= $%d", query.CreatedFrom)
+	}
+	if !query.CreatedTo.IsZero() {
+		addCondition("created_at < $%d", query.CreatedTo)
+	}
+	if query.EmailPrefix != "" {
+		// ILIKE keeps the case insensitive matching of the mysql collation
+		addCondition("email_address ILIKE $%d", likeEscaper.Replace(query.EmailPrefix)+"%")
+	}
+
+	return conditions, args
+}
+
+// encodeUserCursor returns the opaque cursor of the position of user in a listing sorted by sortKey
+func encodeUserCursor(user *auth.User, sortKey string) string {
+	cursor := userCursor{SortKey: sortKey, ID: user.ID}
+	switch sortKey {
+	case "created_at":
+		cursor.Value = user.CreatedAt.UTC().Format(time.RFC3339Nano)
+	case "email_address":
+		cursor.Value = user.EmailAddress
+	case "first_name":
+		cursor.Value = user.FirstName
+	case "last_name":
+		cursor.Value = user.LastName
+	}
+
+	data, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// decodeUserCursor returns the sort column value and the user id of a cursor made for sortKey
+func decodeUserCursor(encodedCursor, sortKey string) (interface{}, string, error) {
+	invalidCursorErr := terr.NewInvalidQueryError("invalid cursor")
+
+	data, err := base64.RawURLEncoding.DecodeString(encodedCursor)
+	if err != nil {
+		return nil, "", invalidCursorErr
+	}
+
+	var cursor userCursor
+	if err := json.Unmarshal(data, &cursor); err != nil || cursor.SortKey != sortKey || cursor.ID == "" {
+		return nil, "", invalidCursorErr
+	}
+
+	if sortKey == "created_at" {
+		createdAt, err := time.Parse(time.RFC3339Nano, cursor.Value)
+		if err != nil {
+			return nil, "", invalidCursorErr
+		}
+		return createdAt, cursor.ID, nil
+	}
+	return cursor.Value, cursor.ID, nil
+}
@@ -0,0 +1,101 @@
+package pgds
+
+import (
+	"context"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	_ "sherman/src/app/testing"
+	"sherman/src/app/utils/terr"
+	"sherman/src/domain/auth"
+	"testing"
+	"time"
+)
+
+func TestListUsers(t *testing.T) {
+	createdAt := time.Date(2020, 5, 5, 9, 55, 33, 0, time.UTC)
+
+	t.Run("should return a page", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		if err != nil {
+			t.Fatalf("an error '%s' was not expected", err)
+		}
+		defer db.Close()
+
+		userRepo := NewUserRepository(db, time.Second)
+
+		mock.
+			ExpectQuery("SELECT COUNT\\(\\*\\) FROM users WHERE deleted_at IS NULL").
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(3))
+		mock.
+			ExpectQuery("SELECT id, .* FROM users WHERE deleted_at IS NULL ORDER BY created_at DESC, id DESC LIMIT \\$1").
+			WithArgs(3).
+			WillReturnRows(sqlmock.NewRows(userColumns).
+				AddRow("some-id-3", "first", "last", "c@email.com", "pwd", true, true, createdAt.Add(time.Hour*2), createdAt).
+				AddRow("some-id-2", "first", "last", "b@email.com", "pwd", true, true, createdAt.Add(time.Hour), createdAt).
+				AddRow("some-id-1", "first", "last", "a@email.com", "pwd", true, true, createdAt, createdAt))
+
+		page, err := userRepo.ListUsers(context.Background(), auth.UserQuery{SortKey: "created_at", SortDesc: true, Limit: 2})
+
+		if assert.NoError(t, err) {
+			assert.Equal(t, 3, page.Total)
+			if assert.Len(t, page.Users, 2) {
+				assert.Equal(t, "some-id-3", page.Users[0].ID)
+				assert.Equal(t, "some-id-2", page.Users[1].ID)
+			}
+			assert.Equal(t, encodeUserCursor(&page.Users[1], "created_at"), page.NextCursor)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		}
+	})
+
+	t.Run("should number the filter and cursor args", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		if err != nil {
+			t.Fatalf("an error '%s' was not expected", err)
+		}
+		defer db.Close()
+
+		userRepo := NewUserRepository(db, time.Second)
+
+		active := true
+		cursor := encodeUserCursor(&auth.User{ID: "some-id-1", EmailAddress: "a@email.com"}, "email_address")
+
+		mock.
+			ExpectQuery("SELECT COUNT\\(\\*\\) FROM users WHERE deleted_at IS NULL AND active = \\$1 AND email_address ILIKE \\$2").
+			WithArgs(true, "a\\_%").
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(2))
+		mock.
+			ExpectQuery("AND email_address ILIKE \\$2 AND \\(email_address, id\\) > \\(\\$3, \\$4\\) ORDER BY email_address ASC, id ASC LIMIT \\$5").
+			WithArgs(true, "a\\_%", "a@email.com", "some-id-1", 11).
+			WillReturnRows(sqlmock.NewRows(userColumns).
+				AddRow("some-id-2", "first", "last", "a_b@email.com", "pwd", true, true, createdAt, createdAt))
+
+		page, err := userRepo.ListUsers(context.Background(), auth.UserQuery{
+			SortKey:     "email_address",
+			Limit:       10,
+			Active:      &active,
+			EmailPrefix: "a_",
+			Cursor:      cursor,
+		})
+
+		if assert.NoError(t, err) {
+			assert.Equal(t, 2, page.Total)
+			assert.Len(t, page.Users, 1)
+			assert.Empty(t, page.NextCursor)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		}
+	})
+
+	t.Run("should return an invalid query error", func(t *testing.T) {
+		db, _, err := sqlmock.New()
+		if err != nil {
+			t.Fatalf("an error '%s' was not expected", err)
+		}
+		defer db.Close()
+
+		userRepo := NewUserRepository(db, time.Second)
+
+		_, err = userRepo.ListUsers(context.Background(), auth.UserQuery{SortKey: "password", Limit: 10})
+
+		assert.Equal(t, terr.NewInvalidQueryError("invalid sort key"), err)
+	})
+}
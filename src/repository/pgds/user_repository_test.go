@@ -0,0 +1,344 @@
+package pgds
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"regexp"
+	_ "sherman/src/app/testing"
+	"sherman/src/app/utils/terr"
+	"sherman/src/domain/auth"
+	"testing"
+	"time"
+)
+
+var userColumns = []string{
+	"id",
+	"first_name",
+	"last_name",
+	"email_address",
+	"password",
+	"active",
+	"email_verified",
+	"created_at",
+	"updated_at",
+}
+
+func TestCreateUser(t *testing.T) {
+	u := &auth.User{
+		ID:            "some-id",
+		FirstName:     "first",
+		LastName:      "last",
+		EmailAddress:  "some@email.com",
+		Password:      "some-password",
+		Active:        true,
+		EmailVerified: true,
+		CreatedAt:     time.Now(),
+		UpdatedAt:     time.Now(),
+	}
+
+	t.Run("should insert", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		if err != nil {
+			t.Fatalf("an error '%s' was not expected", err)
+		}
+		defer db.Close()
+
+		userRepo := NewUserRepository(db, time.Second)
+
+		mock.
+			ExpectExec(regexp.QuoteMeta("VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)")).
+			WithArgs(u.ID, u.FirstName, u.LastName, u.EmailAddress, u.Password, u.Active, u.EmailVerified, u.CreatedAt, u.UpdatedAt).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		assert.NoError(t, userRepo.CreateUser(context.Background(), u))
+	})
+
+	t.Run("should return a duplicate entry error", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		if err != nil {
+			t.Fatalf("an error '%s' was not expected", err)
+		}
+		defer db.Close()
+
+		userRepo := NewUserRepository(db, time.Second)
+
+		mock.
+			ExpectExec("INSERT INTO users").
+			WillReturnError(&pq.Error{Code: uniqueViolation})
+
+		err = userRepo.CreateUser(context.Background(), u)
+
+		assert.Equal(t, terr.NewDuplicateEntryError("user already exist"), err)
+	})
+
+	t.Run("should return an error", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		if err != nil {
+			t.Fatalf("an error '%s' was not expected", err)
+		}
+		defer db.Close()
+
+		userRepo := NewUserRepository(db, time.Second)
+
+		mock.
+			ExpectExec("INSERT INTO users").
+			WillReturnError(errors.New("some error"))
+
+		assert.EqualError(t, userRepo.CreateUser(context.Background(), u), "some error")
+	})
+}
+
+func TestGetUserByID(t *testing.T) {
+	now := time.Now()
+
+	t.Run("should return a user", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		if err != nil {
+			t.Fatalf("an error '%s' was not expected", err)
+		}
+		defer db.Close()
+
+		userRepo := NewUserRepository(db, time.Second)
+
+		mock.
+			ExpectQuery(regexp.QuoteMeta("WHERE id = $1 AND deleted_at IS NULL")).
+			WithArgs("some-id").
+			WillReturnRows(sqlmock.NewRows(userColumns).
+				AddRow("some-id", "first", "last", "some@email.com", "some-password", true, true, now, now))
+
+		user, err := userRepo.GetUserByID(context.Background(), "some-id")
+
+		if assert.NoError(t, err) {
+			assert.Equal(t, "some-id", user.ID)
+			assert.True(t, user.EmailVerified)
+		}
+	})
+
+	t.Run("should return a not found error", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		if err != nil {
+			t.Fatalf("an error '%s' was not expected", err)
+		}
+		defer db.Close()
+
+		userRepo := NewUserRepository(db, time.Second)
+
+		mock.
+			ExpectQuery("SELECT").
+			WithArgs("some-id").
+			WillReturnError(sql.ErrNoRows)
+
+		_, err = userRepo.GetUserByID(context.Background(), "some-id")
+
+		assert.Equal(t, terr.NewNotFoundError("user not found"), err)
+	})
+}
+
+func TestGetUserByEmail(t *testing.T) {
+	t.Run("should return a not found error", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		if err != nil {
+			t.Fatalf("an error '%s' was not expected", err)
+		}
+		defer db.Close()
+
+		userRepo := NewUserRepository(db, time.Second)
+
+		mock.
+			ExpectQuery(regexp.QuoteMeta("WHERE email_address = $1 AND deleted_at IS NULL")).
+			WithArgs("some@email.com").
+			WillReturnError(sql.ErrNoRows)
+
+		_, err = userRepo.GetUserByEmail(context.Background(), "some@email.com")
+
+		assert.Equal(t, terr.NewNotFoundError("user not found"), err)
+	})
+}
+
+func TestUpdateUser(t *testing.T) {
+	u := &auth.User{ID: "some-id", EmailAddress: "some@email.com", UpdatedAt: time.Now()}
+
+	t.Run("should update", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		if err != nil {
+			t.Fatalf("an error '%s' was not expected", err)
+		}
+		defer db.Close()
+
+		userRepo := NewUserRepository(db, time.Second)
+
+		mock.
+			ExpectExec(regexp.QuoteMeta("WHERE id = $8 AND deleted_at IS NULL")).
+			WithArgs(u.FirstName, u.LastName, u.EmailAddress, u.Password, u.Active, u.EmailVerified, u.UpdatedAt, u.ID).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		assert.NoError(t, userRepo.UpdateUser(context.Background(), u))
+	})
+
+	t.Run("should return a not found error", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		if err != nil {
+			t.Fatalf("an error '%s' was not expected", err)
+		}
+		defer db.Close()
+
+		userRepo := NewUserRepository(db, time.Second)
+
+		mock.
+			ExpectExec("UPDATE users").
+			WillReturnResult(sqlmock.NewResult(0, 0))
+
+		assert.Equal(t, terr.NewNotFoundError("user not found"), userRepo.UpdateUser(context.Background(), u))
+	})
+
+	t.Run("should return a duplicate entry error", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		if err != nil {
+			t.Fatalf("an error '%s' was not expected", err)
+		}
+		defer db.Close()
+
+		userRepo := NewUserRepository(db, time.Second)
+
+		mock.
+			ExpectExec("UPDATE users").
+			WillReturnError(&pq.Error{Code: uniqueViolation})
+
+		assert.Equal(t, terr.NewDuplicateEntryError("user already exist"), userRepo.UpdateUser(context.Background(), u))
+	})
+}
+
+func TestUpdateUserPassword(t *testing.T) {
+	now := time.Now()
+
+	t.Run("should update", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		if err != nil {
+			t.Fatalf("an error '%s' was not expected", err)
+		}
+		defer db.Close()
+
+		userRepo := NewUserRepository(db, time.Second)
+
+		mock.
+			ExpectExec(regexp.QuoteMeta("UPDATE users SET password=$1, updated_at=$2 WHERE id = $3")).
+			WithArgs("some-password", now, "some-id").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		assert.NoError(t, userRepo.UpdateUserPassword(context.Background(), "some-id", "some-password", now))
+	})
+
+	t.Run("should return a not found error", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		if err != nil {
+			t.Fatalf("an error '%s' was not expected", err)
+		}
+		defer db.Close()
+
+		userRepo := NewUserRepository(db, time.Second)
+
+		mock.
+			ExpectExec("UPDATE users").
+			WillReturnResult(sqlmock.NewResult(0, 0))
+
+		assert.Equal(t, terr.NewNotFoundError("user not found"), userRepo.UpdateUserPassword(context.Background(), "some-id", "some-password", now))
+	})
+}
+
+func TestUpdateUserActive(t *testing.T) {
+	now := time.Now()
+
+	t.Run("should update", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		if err != nil {
+			t.Fatalf("an error '%s' was not expected", err)
+		}
+		defer db.Close()
+
+		userRepo := NewUserRepository(db, time.Second)
+
+		mock.
+			ExpectExec(regexp.QuoteMeta("UPDATE users SET active=$1, updated_at=$2 WHERE id = $3")).
+			WithArgs(false, now, "some-id").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		assert.NoError(t, userRepo.UpdateUserActive(context.Background(), "some-id", false, now))
+	})
+}
+
+func TestSoftDeleteUser(t *testing.T) {
+	now := time.Now()
+
+	t.Run("should update", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		if err != nil {
+			t.Fatalf("an error '%s' was not expected", err)
+		}
+		defer db.Close()
+
+		userRepo := NewUserRepository(db, time.Second)
+
+		mock.
+			ExpectExec(regexp.QuoteMeta("SET active=FALSE, updated_at=$1, deleted_at=$1 WHERE id = $2")).
+			WithArgs(now, "some-id").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		assert.NoError(t, userRepo.SoftDeleteUser(context.Background(), "some-id", now))
+	})
+
+	t.Run("should return a not found error", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		if err != nil {
+			t.Fatalf("an error '%s' was not expected", err)
+		}
+		defer db.Close()
+
+		userRepo := NewUserRepository(db, time.Second)
+
+		mock.
+			ExpectExec("UPDATE users").
+			WillReturnResult(sqlmock.NewResult(0, 0))
+
+		assert.Equal(t, terr.NewNotFoundError("user not found"), userRepo.SoftDeleteUser(context.Background(), "some-id", now))
+	})
+}
+
+func TestDeleteUser(t *testing.T) {
+	t.Run("should delete", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		if err != nil {
+			t.Fatalf("an error '%s' was not expected", err)
+		}
+		defer db.Close()
+
+		userRepo := NewUserRepository(db, time.Second)
+
+		mock.
+			ExpectExec(regexp.QuoteMeta("DELETE FROM users WHERE id = $1")).
+			WithArgs("some-id").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		assert.NoError(t, userRepo.DeleteUser(context.Background(), "some-id"))
+	})
+
+	t.Run("should return a not found error", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		if err != nil {
+			t.Fatalf("an error '%s' was not expected", err)
+		}
+		defer db.Close()
+
+		userRepo := NewUserRepository(db, time.Second)
+
+		mock.
+			ExpectExec("DELETE FROM users").
+			WithArgs("some-id").
+			WillReturnResult(sqlmock.NewResult(0, 0))
+
+		assert.Equal(t, terr.NewNotFoundError("user not found"), userRepo.DeleteUser(context.Background(), "some-id"))
+	})
+}
@@ -0,0 +1,557 @@
+// Package repositorytest is the conformance suite of the auth.UserRepository,
+// auth.SecurityTokenRepository and auth.UnitOfWork implementations, every datastore runs it from its
+// own tests so that they keep the same behaviour, errors included
+package repositorytest
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"sherman/src/app/utils/terr"
+	"sherman/src/domain/auth"
+	"testing"
+	"time"
+)
+
+// Factory returns the repositories and the unit of work of an empty datastore, they share it so that
+// security tokens belong to the users of the user repository and both take part in transactions
+type Factory func(t *testing.T) (auth.UserRepository, auth.SecurityTokenRepository, auth.UnitOfWork)
+
+// now is truncated to the second, the precision of the mysql datetime columns
+func now() time.Time {
+	return time.Now().UTC().Truncate(time.Second)
+}
+
+func genUser(id, email string, createdAt time.Time) *auth.User {
+	return &auth.User{
+		ID:            id,
+		FirstName:     "first",
+		LastName:      "last",
+		EmailAddress:  email,
+		Password:      "some-password",
+		Active:        true,
+		EmailVerified: true,
+		CreatedAt:     createdAt,
+		UpdatedAt:     createdAt,
+	}
+}
+
+func genToken(id, userID, token string, lastUsedAt time.Time) *auth.SecurityToken {
+	return &auth.SecurityToken{
+		ID:         id,
+		UserID:     userID,
+		Token:      token,
+		Type:       auth.RefreshTokenType,
+		FamilyID:   id,
+		UserAgent:  "some-user-agent",
+		IPAddress:  "127.0.0.1",
+		LastUsedAt: lastUsedAt,
+		CreatedAt:  lastUsedAt,
+		UpdatedAt:  lastUsedAt,
+	}
+}
+
+// assertUser compares users field by field, datastores may return times in another location
+func assertUser(t *testing.T, expected *auth.User, actual auth.User) {
+	assert.Equal(t, expected.ID, actual.ID)
+	assert.Equal(t, expected.FirstName, actual.FirstName)
+	assert.Equal(t, expected.LastName, actual.LastName)
+	assert.Equal(t, expected.EmailAddress, actual.EmailAddress)
+	assert.Equal(t, expected.Password, actual.Password)
+	assert.Equal(t, expected.Active, actual.Active)
+	assert.Equal(t, expected.EmailVerified, actual.EmailVerified)
+	assert.True(t, expected.CreatedAt.Equal(actual.CreatedAt), "created_at %s != %s", expected.CreatedAt, actual.CreatedAt)
+	assert.True(t, expected.UpdatedAt.Equal(actual.UpdatedAt), "updated_at %s != %s", expected.UpdatedAt, actual.UpdatedAt)
+}
+
+// assertToken compares security tokens field by field, datastores may return times in another location
+func assertToken(t *testing.T, expected *auth.SecurityToken, actual auth.SecurityToken) {
+	assert.Equal(t, expected.ID, actual.ID)
+	assert.Equal(t, expected.UserID, actual.UserID)
+	assert.Equal(t, expected.Token, actual.Token)
+	assert.Equal(t, expected.Type, actual.Type)
+	assert.Equal(t, expected.FamilyID, actual.FamilyID)
+	assert.Equal(t, expected.Rotated, actual.Rotated)
+	assert.Equal(t, expected.UserAgent, actual.UserAgent)
+	assert.Equal(t, expected.IPAddress, actual.IPAddress)
+	assert.True(t, expected.LastUsedAt.Equal(actual.LastUsedAt), "last_used_at %s != %s", expected.LastUsedAt, actual.LastUsedAt)
+	assert.True(t, expected.CreatedAt.Equal(actual.CreatedAt), "created_at %s != %s", expected.CreatedAt, actual.CreatedAt)
+	assert.True(t, expected.UpdatedAt.Equal(actual.UpdatedAt), "updated_at %s != %s", expected.UpdatedAt, actual.UpdatedAt)
+}
+
+// Run runs the conformance suite on the repositories of newRepositories
+func Run(t *testing.T, newRepositories Factory) {
+	t.Run("UserRepository", func(t *testing.T) {
+		runUserRepository(t, newRepositories)
+	})
+	t.Run("UserRepository ListUsers", func(t *testing.T) {
+		runListUsers(t, newRepositories)
+	})
+	t.Run("SecurityTokenRepository", func(t *testing.T) {
+		runSecurityTokenRepository(t, newRepositories)
+	})
+	t.Run("UnitOfWork", func(t *testing.T) {
+		runUnitOfWork(t, newRepositories)
+	})
+}
+
+func runUserRepository(t *testing.T, newRepositories Factory) {
+	ctx := context.Background()
+
+	t.Run("it should create and get a user", func(t *testing.T) {
+		userRepo, _, _ := newRepositories(t)
+		u := genUser("some-id", "some@email.com", now())
+
+		if assert.NoError(t, userRepo.CreateUser(ctx, u)) {
+			user, err := userRepo.GetUserByID(ctx, "some-id")
+			if assert.NoError(t, err) {
+				assertUser(t, u, user)
+			}
+			user, err = userRepo.GetUserByEmail(ctx, "some@email.com")
+			if assert.NoError(t, err) {
+				assertUser(t, u, user)
+			}
+		}
+	})
+
+	t.Run("it should return a duplicate entry error on a taken email or id", func(t *testing.T) {
+		userRepo, _, _ := newRepositories(t)
+		assert.NoError(t, userRepo.CreateUser(ctx, genUser("some-id", "some@email.com", now())))
+
+		duplicateErr := terr.NewDuplicateEntryError("user already exist")
+		assert.Equal(t, duplicateErr, userRepo.CreateUser(ctx, genUser("other-id", "some@email.com", now())))
+		assert.Equal(t, duplicateErr, userRepo.CreateUser(ctx, genUser("some-id", "other@email.com", now())))
+	})
+
+	t.Run("it should return a not found error on an unknown user", func(t *testing.T) {
+		userRepo, _, _ := newRepositories(t)
+		notFoundErr := terr.NewNotFoundError("user not found")
+
+		_, err := userRepo.GetUserByID(ctx, "some-id")
+		assert.Equal(t, notFoundErr, err)
+		_, err = userRepo.GetUserByEmail(ctx, "some@email.com")
+		assert.Equal(t, notFoundErr, err)
+		assert.Equal(t, notFoundErr, userRepo.UpdateUser(ctx, genUser("some-id", "some@email.com", now())))
+		assert.Equal(t, notFoundErr, userRepo.UpdateUserPassword(ctx, "some-id", "other-password", now()))
+		assert.Equal(t, notFoundErr, userRepo.UpdateUserActive(ctx, "some-id", false, now()))
+		assert.Equal(t, notFoundErr, userRepo.SoftDeleteUser(ctx, "some-id", now()))
+		assert.Equal(t, notFoundErr, userRepo.DeleteUser(ctx, "some-id"))
+	})
+
+	t.Run("it should update a user", func(t *testing.T) {
+		userRepo, _, _ := newRepositories(t)
+		u := genUser("some-id", "some@email.com", now())
+		assert.NoError(t, userRepo.CreateUser(ctx, u))
+
+		u.FirstName = "other-first"
+		u.LastName = "other-last"
+		u.EmailAddress = "other@email.com"
+		u.EmailVerified = false
+		u.UpdatedAt = u.UpdatedAt.Add(time.Minute)
+
+		if assert.NoError(t, userRepo.UpdateUser(ctx, u)) {
+			user, err := userRepo.GetUserByID(ctx, "some-id")
+			if assert.NoError(t, err) {
+				assertUser(t, u, user)
+			}
+		}
+	})
+
+	t.Run("it should return a duplicate entry error on an update to a taken email", func(t *testing.T) {
+		userRepo, _, _ := newRepositories(t)
+		assert.NoError(t, userRepo.CreateUser(ctx, genUser("some-id", "some@email.com", now())))
+		u := genUser("other-id", "other@email.com", now())
+		assert.NoError(t, userRepo.CreateUser(ctx, u))
+
+		u.EmailAddress = "some@email.com"
+		u.UpdatedAt = u.UpdatedAt.Add(time.Minute)
+
+		assert.Equal(t, terr.NewDuplicateEntryError("user already exist"), userRepo.UpdateUser(ctx, u))
+	})
+
+	t.Run("it should update the password and the activation of a user", func(t *testing.T) {
+		userRepo, _, _ := newRepositories(t)
+		createdAt := now()
+		assert.NoError(t, userRepo.CreateUser(ctx, genUser("some-id", "some@email.com", createdAt)))
+
+		assert.NoError(t, userRepo.UpdateUserPassword(ctx, "some-id", "other-password", createdAt.Add(time.Minute)))
+		assert.NoError(t, userRepo.UpdateUserActive(ctx, "some-id", false, createdAt.Add(time.Hour)))
+
+		user, err := userRepo.GetUserByID(ctx, "some-id")
+		if assert.NoError(t, err) {
+			assert.Equal(t, "other-password", user.Password)
+			assert.False(t, user.Active)
+			assert.True(t, createdAt.Add(time.Hour).Equal(user.UpdatedAt))
+		}
+	})
+
+	t.Run("it should soft delete a user", func(t *testing.T) {
+		userRepo, _, _ := newRepositories(t)
+		assert.NoError(t, userRepo.CreateUser(ctx, genUser("some-id", "some@email.com", now())))
+
+		if assert.NoError(t, userRepo.SoftDeleteUser(ctx, "some-id", now())) {
+			_, err := userRepo.GetUserByID(ctx, "some-id")
+			assert.Equal(t, terr.NewNotFoundError("user not found"), err)
+			_, err = userRepo.GetUserByEmail(ctx, "some@email.com")
+			assert.Equal(t, terr.NewNotFoundError("user not found"), err)
+			assert.Equal(t, terr.NewNotFoundError("user not found"), userRepo.SoftDeleteUser(ctx, "some-id", now()))
+			// the data of soft deleted users is kept, so is their email
+			assert.Equal(
+				t,
+				terr.NewDuplicateEntryError("user already exist"),
+				userRepo.CreateUser(ctx, genUser("other-id", "some@email.com", now())),
+			)
+		}
+	})
+
+	t.Run("it should delete a user with its security tokens", func(t *testing.T) {
+		userRepo, securityTokenRepo, _ := newRepositories(t)
+		assert.NoError(t, userRepo.CreateUser(ctx, genUser("some-id", "some@email.com", now())))
+		assert.NoError(t, userRepo.SoftDeleteUser(ctx, "some-id", now()))
+		assert.NoError(t, securityTokenRepo.CreateToken(ctx, genToken("some-token-id", "some-id", "some-token", now())))
+
+		if assert.NoError(t, userRepo.DeleteUser(ctx, "some-id")) {
+			tokens, err := securityTokenRepo.GetTokensByUserID(ctx, "some-id", auth.RefreshTokenType)
+			assert.NoError(t, err)
+			assert.Empty(t, tokens)
+			assert.NoError(t, userRepo.CreateUser(ctx, genUser("some-id", "some@email.com", now())))
+		}
+	})
+}
+
+func runListUsers(t *testing.T, newRepositories Factory) {
+	ctx := context.Background()
+
+	createdAt := now()
+	genUsers := func(t *testing.T, userRepo auth.UserRepository) {
+		assert.NoError(t, userRepo.CreateUser(ctx, genUser("id-1", "a_1@email.com", createdAt)))
+		assert.NoError(t, userRepo.CreateUser(ctx, genUser("id-2", "ab2@email.com", createdAt.Add(time.Second))))
+		assert.NoError(t, userRepo.CreateUser(ctx, genUser("id-3", "b3@email.com", createdAt.Add(time.Second))))
+		assert.NoError(t, userRepo.CreateUser(ctx, genUser("id-4", "c4@email.com", createdAt.Add(2*time.Second))))
+		assert.NoError(t, userRepo.UpdateUserActive(ctx, "id-4", false, createdAt))
+		assert.NoError(t, userRepo.CreateUser(ctx, genUser("id-5", "d5@email.com", createdAt.Add(3*time.Second))))
+		assert.NoError(t, userRepo.SoftDeleteUser(ctx, "id-5", createdAt))
+	}
+	listIDs := func(t *testing.T, userRepo auth.UserRepository, query auth.UserQuery) ([]string, int) {
+		ids := make([]string, 0)
+		total := 0
+		for {
+			page, err := userRepo.ListUsers(ctx, query)
+			if !assert.NoError(t, err) {
+				return ids, total
+			}
+			total = page.Total
+			for _, user := range page.Users {
+				ids = append(ids, user.ID)
+			}
+			if page.NextCursor == "" {
+				return ids, total
+			}
+			assert.Len(t, page.Users, query.Limit)
+			query.Cursor = page.NextCursor
+		}
+	}
+
+	t.Run("it should page through the users in order", func(t *testing.T) {
+		userRepo, _, _ := newRepositories(t)
+		genUsers(t, userRepo)
+
+		ids, total := listIDs(t, userRepo, auth.UserQuery{SortKey: "created_at", Limit: 2})
+		assert.Equal(t, []string{"id-1", "id-2", "id-3", "id-4"}, ids)
+		assert.Equal(t, 4, total)
+
+		ids, _ = listIDs(t, userRepo, auth.UserQuery{SortKey: "created_at", SortDesc: true, Limit: 3})
+		assert.Equal(t, []string{"id-4", "id-3", "id-2", "id-1"}, ids)
+
+		ids, _ = listIDs(t, userRepo, auth.UserQuery{SortKey: "email_address", SortDesc: true, Limit: 1})
+		assert.Equal(t, []string{"id-4", "id-3", "id-2", "id-1"}, ids)
+	})
+
+	t.Run("it should filter the users", func(t *testing.T) {
+		userRepo, _, _ := newRepositories(t)
+		genUsers(t, userRepo)
+		active := true
+
+		ids, total := listIDs(t, userRepo, auth.UserQuery{SortKey: "created_at", Limit: 10, Active: &active})
+		assert.Equal(t, []string{"id-1", "id-2", "id-3"}, ids)
+		assert.Equal(t, 3, total)
+
+		ids, _ = listIDs(t, userRepo, auth.UserQuery{
+			SortKey:     "created_at",
+			Limit:       10,
+			CreatedFrom: createdAt.Add(time.Second),
+			CreatedTo:   createdAt.Add(2 * time.Second),
+		})
+		assert.Equal(t, []string{"id-2", "id-3"}, ids)
+
+		// LIKE wildcards of the prefix are matched literally
+		ids, _ = listIDs(t, userRepo, auth.UserQuery{SortKey: "created_at", Limit: 10, EmailPrefix: "a_"})
+		assert.Equal(t, []string{"id-1"}, ids)
+	})
+
+	t.Run("it should return an invalid query error", func(t *testing.T) {
+		userRepo, _, _ := newRepositories(t)
+
+		_, err := userRepo.ListUsers(ctx, auth.UserQuery{SortKey: "password", Limit: 10})
+		assert.Equal(t, terr.NewInvalidQueryError("invalid sort key"), err)
+		_, err = userRepo.ListUsers(ctx, auth.UserQuery{SortKey: "created_at"})
+		assert.Equal(t, terr.NewInvalidQueryError("invalid limit"), err)
+		_, err = userRepo.ListUsers(ctx, auth.UserQuery{SortKey: "created_at", Limit: 10, Cursor: "some-cursor"})
+		assert.Equal(t, terr.NewInvalidQueryError("invalid cursor"), err)
+	})
+}
+
+func runSecurityTokenRepository(t *testing.T, newRepositories Factory) {
+	ctx := context.Background()
+
+	newTokenRepository := func(t *testing.T) auth.SecurityTokenRepository {
+		userRepo, securityTokenRepo, _ := newRepositories(t)
+		assert.NoError(t, userRepo.CreateUser(ctx, genUser("some-user-id", "some@email.com", now())))
+		return securityTokenRepo
+	}
+	metadata := func(token string) *auth.TokenMetadata {
+		return &auth.TokenMetadata{UserID: "some-user-id", Type: auth.RefreshTokenType, Token: token}
+	}
+
+	t.Run("it should create and get a token", func(t *testing.T) {
+		securityTokenRepo := newTokenRepository(t)
+		st := genToken("some-id", "some-user-id", "some-token", now())
+
+		if assert.NoError(t, securityTokenRepo.CreateToken(ctx, st)) {
+			token, err := securityTokenRepo.GetTokenByMetadata(ctx, metadata("some-token"))
+			if assert.NoError(t, err) {
+				assertToken(t, st, token)
+			}
+		}
+	})
+
+	t.Run("it should return an error on a token of an unknown user", func(t *testing.T) {
+		securityTokenRepo := newTokenRepository(t)
+
+		assert.Error(t, securityTokenRepo.CreateToken(ctx, genToken("some-id", "other-user-id", "some-token", now())))
+	})
+
+	t.Run("it should return a not found error on an unknown token", func(t *testing.T) {
+		securityTokenRepo := newTokenRepository(t)
+		assert.NoError(t, securityTokenRepo.CreateToken(ctx, genToken("some-id", "some-user-id", "some-token", now())))
+
+		_, err := securityTokenRepo.GetTokenByMetadata(ctx, metadata("other-token"))
+		assert.Equal(t, terr.NewNotFoundError("token not found"), err)
+		_, err = securityTokenRepo.GetTokenByMetadata(ctx, &auth.TokenMetadata{
+			UserID: "some-user-id",
+			Type:   auth.EmailVerificationTokenType,
+			Token:  "some-token",
+		})
+		assert.Equal(t, terr.NewNotFoundError("token not found"), err)
+	})
+
+	t.Run("it should keep a single active token of a user and type", func(t *testing.T) {
+		securityTokenRepo := newTokenRepository(t)
+		lastUsedAt := now()
+		assert.NoError(t, securityTokenRepo.CreateOrUpdateToken(ctx, genToken("some-id", "some-user-id", "some-token", lastUsedAt)))
+		assert.NoError(t, securityTokenRepo.CreateOrUpdateToken(ctx, genToken("other-id", "some-user-id", "other-token", lastUsedAt.Add(time.Minute))))
+
+		tokens, err := securityTokenRepo.GetTokensByUserID(ctx, "some-user-id", auth.RefreshTokenType)
+		if assert.NoError(t, err) && assert.Len(t, tokens, 1) {
+			assert.Equal(t, "some-id", tokens[0].ID)
+			assert.Equal(t, "other-token", tokens[0].Token)
+			assert.True(t, lastUsedAt.Add(time.Minute).Equal(tokens[0].LastUsedAt))
+		}
+	})
+
+	t.Run("it should not replace the tokens created by CreateToken", func(t *testing.T) {
+		securityTokenRepo := newTokenRepository(t)
+		assert.NoError(t, securityTokenRepo.CreateToken(ctx, genToken("some-id", "some-user-id", "some-token", now())))
+		assert.NoError(t, securityTokenRepo.CreateOrUpdateToken(ctx, genToken("other-id", "some-user-id", "other-token", now())))
+
+		tokens, err := securityTokenRepo.GetTokensByUserID(ctx, "some-user-id", auth.RefreshTokenType)
+		if assert.NoError(t, err) {
+			assert.Len(t, tokens, 2)
+		}
+	})
+
+	t.Run("it should get the active tokens of a user and type, most recently used first", func(t *testing.T) {
+		securityTokenRepo := newTokenRepository(t)
+		lastUsedAt := now()
+		assert.NoError(t, securityTokenRepo.CreateToken(ctx, genToken("id-1", "some-user-id", "token-1", lastUsedAt)))
+		assert.NoError(t, securityTokenRepo.CreateToken(ctx, genToken("id-2", "some-user-id", "token-2", lastUsedAt.Add(time.Minute))))
+		otherType := genToken("id-3", "some-user-id", "token-3", lastUsedAt)
+		otherType.Type = auth.EmailVerificationTokenType
+		assert.NoError(t, securityTokenRepo.CreateToken(ctx, otherType))
+
+		tokens, err := securityTokenRepo.GetTokensByUserID(ctx, "some-user-id", auth.RefreshTokenType)
+		if assert.NoError(t, err) && assert.Len(t, tokens, 2) {
+			assert.Equal(t, "id-2", tokens[0].ID)
+			assert.Equal(t, "id-1", tokens[1].ID)
+		}
+	})
+
+	t.Run("it should rotate a token", func(t *testing.T) {
+		securityTokenRepo := newTokenRepository(t)
+		st := genToken("some-id", "some-user-id", "some-token", now())
+		assert.NoError(t, securityTokenRepo.CreateToken(ctx, st))
+
+		rotatedToken := *st
+		rotatedToken.ID = "rotated-id"
+		token := *st
+		token.Token = "new-token"
+		token.LastUsedAt = st.LastUsedAt.Add(time.Minute)
+		token.UpdatedAt = token.LastUsedAt
+
+		if assert.NoError(t, securityTokenRepo.RotateToken(ctx, &token, &rotatedToken)) {
+			activeToken, err := securityTokenRepo.GetTokenByMetadata(ctx, metadata("new-token"))
+			if assert.NoError(t, err) {
+				assertToken(t, &token, activeToken)
+			}
+
+			previousToken, err := securityTokenRepo.GetTokenByMetadata(ctx, metadata("some-token"))
+			if assert.NoError(t, err) {
+				assert.Equal(t, "rotated-id", previousToken.ID)
+				assert.Equal(t, "some-id", previousToken.FamilyID)
+				assert.True(t, previousToken.Rotated)
+			}
+
+			tokens, _ := securityTokenRepo.GetTokensByUserID(ctx, "some-user-id", auth.RefreshTokenType)
+			assert.Len(t, tokens, 1)
+		}
+	})
+
+	t.Run("it should not rotate a token that was already rotated", func(t *testing.T) {
+		securityTokenRepo := newTokenRepository(t)
+		st := genToken("some-id", "some-user-id", "some-token", now())
+		assert.NoError(t, securityTokenRepo.CreateToken(ctx, st))
+
+		rotatedToken := *st
+		rotatedToken.ID = "rotated-id"
+		token := *st
+		token.Token = "new-token"
+		assert.NoError(t, securityTokenRepo.RotateToken(ctx, &token, &rotatedToken))
+
+		concurrentRotatedToken := *st
+		concurrentRotatedToken.ID = "concurrent-rotated-id"
+		concurrentToken := *st
+		concurrentToken.Token = "concurrent-token"
+		err := securityTokenRepo.RotateToken(ctx, &concurrentToken, &concurrentRotatedToken)
+
+		if assert.IsType(t, &terr.UnAuthorizedError{}, err) {
+			_, err := securityTokenRepo.GetTokenByMetadata(ctx, metadata("concurrent-token"))
+			assert.Equal(t, terr.NewNotFoundError("token not found"), err)
+			activeToken, err := securityTokenRepo.GetTokenByMetadata(ctx, metadata("new-token"))
+			if assert.NoError(t, err) {
+				assert.Equal(t, "some-id", activeToken.ID)
+			}
+		}
+	})
+
+	t.Run("it should remove the tokens of a user and type", func(t *testing.T) {
+		securityTokenRepo := newTokenRepository(t)
+		assert.NoError(t, securityTokenRepo.CreateToken(ctx, genToken("id-1", "some-user-id", "token-1", now())))
+		assert.NoError(t, securityTokenRepo.CreateToken(ctx, genToken("id-2", "some-user-id", "token-2", now())))
+
+		if assert.NoError(t, securityTokenRepo.RemoveTokenByMetadata(ctx, metadata(""))) {
+			tokens, _ := securityTokenRepo.GetTokensByUserID(ctx, "some-user-id", auth.RefreshTokenType)
+			assert.Empty(t, tokens)
+		}
+	})
+
+	t.Run("it should remove a token family", func(t *testing.T) {
+		securityTokenRepo := newTokenRepository(t)
+		st := genToken("some-id", "some-user-id", "some-token", now())
+		assert.NoError(t, securityTokenRepo.CreateToken(ctx, st))
+		rotatedToken := *st
+		rotatedToken.ID = "rotated-id"
+		token := *st
+		token.Token = "new-token"
+		assert.NoError(t, securityTokenRepo.RotateToken(ctx, &token, &rotatedToken))
+		assert.NoError(t, securityTokenRepo.CreateToken(ctx, genToken("other-id", "some-user-id", "other-token", now())))
+
+		if assert.NoError(t, securityTokenRepo.RemoveTokenFamily(ctx, "some-user-id", "some-id")) {
+			_, err := securityTokenRepo.GetTokenByMetadata(ctx, metadata("some-token"))
+			assert.Equal(t, terr.NewNotFoundError("token not found"), err)
+			tokens, _ := securityTokenRepo.GetTokensByUserID(ctx, "some-user-id", auth.RefreshTokenType)
+			if assert.Len(t, tokens, 1) {
+				assert.Equal(t, "other-id", tokens[0].ID)
+			}
+			assert.Equal(t, terr.NewNotFoundError("token not found"), securityTokenRepo.RemoveTokenFamily(ctx, "some-user-id", "some-id"))
+		}
+	})
+}
+
+func runUnitOfWork(t *testing.T, newRepositories Factory) {
+	ctx := context.Background()
+
+	t.Run("it should commit the operations", func(t *testing.T) {
+		userRepo, securityTokenRepo, unitOfWork := newRepositories(t)
+
+		err := unitOfWork.Do(ctx, func(ctx context.Context) error {
+			if err := userRepo.CreateUser(ctx, genUser("some-user-id", "some@email.com", now())); err != nil {
+				return err
+			}
+			return securityTokenRepo.CreateOrUpdateToken(ctx, genToken("some-id", "some-user-id", "some-token", now()))
+		})
+
+		if assert.NoError(t, err) {
+			_, err := userRepo.GetUserByID(ctx, "some-user-id")
+			assert.NoError(t, err)
+			tokens, _ := securityTokenRepo.GetTokensByUserID(ctx, "some-user-id", auth.RefreshTokenType)
+			assert.Len(t, tokens, 1)
+		}
+	})
+
+	t.Run("it should roll back the operations on error", func(t *testing.T) {
+		userRepo, securityTokenRepo, unitOfWork := newRepositories(t)
+		assert.NoError(t, userRepo.CreateUser(ctx, genUser("some-user-id", "some@email.com", now())))
+		assert.NoError(t, securityTokenRepo.CreateToken(ctx, genToken("some-id", "some-user-id", "some-token", now())))
+
+		err := unitOfWork.Do(ctx, func(ctx context.Context) error {
+			if err := userRepo.UpdateUserPassword(ctx, "some-user-id", "new-password", now()); err != nil {
+				return err
+			}
+			if err := securityTokenRepo.RemoveTokenByMetadata(ctx, &auth.TokenMetadata{
+				UserID: "some-user-id",
+				Type:   auth.RefreshTokenType,
+			}); err != nil {
+				return err
+			}
+			return userRepo.CreateUser(ctx, genUser("other-user-id", "some@email.com", now()))
+		})
+
+		assert.Equal(t, terr.NewDuplicateEntryError("user already exist"), err)
+		user, _ := userRepo.GetUserByID(ctx, "some-user-id")
+		assert.Equal(t, "some-password", user.Password)
+		tokens, _ := securityTokenRepo.GetTokensByUserID(ctx, "some-user-id", auth.RefreshTokenType)
+		assert.Len(t, tokens, 1)
+	})
+
+	t.Run("it should see its own operations", func(t *testing.T) {
+		userRepo, _, unitOfWork := newRepositories(t)
+
+		err := unitOfWork.Do(ctx, func(ctx context.Context) error {
+			if err := userRepo.CreateUser(ctx, genUser("some-user-id", "some@email.com", now())); err != nil {
+				return err
+			}
+			_, err := userRepo.GetUserByEmail(ctx, "some@email.com")
+			return err
+		})
+
+		assert.NoError(t, err)
+	})
+
+	t.Run("it should join the transaction of the context", func(t *testing.T) {
+		userRepo, _, unitOfWork := newRepositories(t)
+		someError := terr.NewNotFoundError("some error")
+
+		err := unitOfWork.Do(ctx, func(ctx context.Context) error {
+			err := unitOfWork.Do(ctx, func(ctx context.Context) error {
+				return userRepo.CreateUser(ctx, genUser("some-user-id", "some@email.com", now()))
+			})
+			if err != nil {
+				return err
+			}
+			return someError
+		})
+
+		assert.Equal(t, someError, err)
+		_, err = userRepo.GetUserByID(ctx, "some-user-id")
+		assert.Equal(t, terr.NewNotFoundError("user not found"), err)
+	})
+}
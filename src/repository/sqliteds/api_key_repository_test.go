@@ -0,0 +1,70 @@
+package sqliteds
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	_ "sherman/src/app/testing"
+	"sherman/src/app/utils/terr"
+	"sherman/src/domain/auth"
+	"testing"
+	"time"
+)
+
+func TestCreateAPIKey(t *testing.T) {
+	t.Run("should insert", func(t *testing.T) {
+		db := genTokenDB(t)
+		defer db.Close()
+
+		now := time.Now().UTC()
+		expiresAt := now.Add(time.Hour)
+		apiKeyRepo := NewAPIKeyRepository(db, time.Second)
+		apiKey := &auth.APIKey{
+			ID:        "some-id",
+			UserID:    "some-user-id",
+			Name:      "some-key",
+			Prefix:    "some-prefix",
+			KeyHash:   "some-hash",
+			Scopes:    []string{"users:read"},
+			ExpiresAt: &expiresAt,
+			CreatedAt: now,
+		}
+		noExpiry := &auth.APIKey{
+			ID:        "other-id",
+			UserID:    "some-user-id",
+			Name:      "other-key",
+			Prefix:    "other-prefix",
+			KeyHash:   "other-hash",
+			CreatedAt: now.Add(time.Second),
+		}
+
+		assert.NoError(t, apiKeyRepo.CreateAPIKey(context.Background(), apiKey))
+		assert.NoError(t, apiKeyRepo.CreateAPIKey(context.Background(), noExpiry))
+
+		apiKeys, err := apiKeyRepo.GetAPIKeysByUserID(context.Background(), "some-user-id")
+		if assert.NoError(t, err) {
+			assert.Equal(t, []auth.APIKey{*noExpiry, *apiKey}, apiKeys)
+		}
+	})
+}
+
+func TestGetAPIKeyByHash(t *testing.T) {
+	t.Run("should return a not found error", func(t *testing.T) {
+		db := newTestDB(t)
+		defer db.Close()
+
+		_, err := NewAPIKeyRepository(db, time.Second).GetAPIKeyByHash(context.Background(), "some-hash")
+
+		assert.Equal(t, terr.NewNotFoundError("api key not found"), err)
+	})
+}
+
+func TestRemoveAPIKey(t *testing.T) {
+	t.Run("should return a not found error", func(t *testing.T) {
+		db := genTokenDB(t)
+		defer db.Close()
+
+		err := NewAPIKeyRepository(db, time.Second).RemoveAPIKey(context.Background(), "some-user-id", "some-id")
+
+		assert.Equal(t, terr.NewNotFoundError("api key not found"), err)
+	})
+}
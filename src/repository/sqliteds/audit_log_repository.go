@@ -0,0 +1,48 @@
+package sqliteds
+
+import (
+	"context"
+	"database/sql"
+	"sherman/src/app/database"
+	"sherman/src/domain/auth"
+	"time"
+)
+
+// auditLogRepository sqlite implementation of auth.AuditLogRepository
+type auditLogRepository struct {
+	DB           *sql.DB
+	QueryTimeout time.Duration
+}
+
+// NewAuditLogRepository constructor
+func NewAuditLogRepository(db *sql.DB, queryTimeout time.Duration) auth.AuditLogRepository {
+	return &auditLogRepository{
+		DB:           db,
+		QueryTimeout: queryTimeout,
+	}
+}
+
+// CreateAuditLog persist a auth.AuditLog in the datastore
+func (r *auditLogRepository) CreateAuditLog(ctx context.Context, auditLog *auth.AuditLog) error {
+	ctx, cancel := database.WithQueryTimeout(ctx, r.QueryTimeout)
+	defer cancel()
+
+	query := `
+		INSERT INTO audit_logs (
+			id,
+			user_id,
+			actor_id,
+			action,
+			created_at
+		) VALUES (?, ?, ?, ?, ?)
+	`
+
+	_, err := database.Conn(ctx, r.DB).ExecContext(ctx, query,
+		auditLog.ID,
+		auditLog.UserID,
+		auditLog.ActorID,
+		auditLog.Action,
+		auditLog.CreatedAt.UTC(),
+	)
+	return err
+}
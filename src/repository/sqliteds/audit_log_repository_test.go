@@ -0,0 +1,25 @@
+package sqliteds
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	_ "sherman/src/app/testing"
+	"sherman/src/domain/auth"
+	"testing"
+	"time"
+)
+
+func TestCreateAuditLog(t *testing.T) {
+	t.Run("should insert", func(t *testing.T) {
+		db := newTestDB(t)
+		defer db.Close()
+
+		auditLog := &auth.AuditLog{ID: "some-id", UserID: "some-user-id", ActorID: "some-actor-id", Action: "user.deleted", CreatedAt: time.Now().UTC()}
+
+		if assert.NoError(t, NewAuditLogRepository(db, time.Second).CreateAuditLog(context.Background(), auditLog)) {
+			var action string
+			assert.NoError(t, db.QueryRow(`SELECT action FROM audit_logs WHERE id = ?`, "some-id").Scan(&action))
+			assert.Equal(t, "user.deleted", action)
+		}
+	})
+}
@@ -0,0 +1,106 @@
+package sqliteds
+
+import (
+	"context"
+	"database/sql"
+	"sherman/src/app/database"
+	"sherman/src/app/utils/terr"
+	"sherman/src/domain/auth"
+	"strings"
+	"time"
+)
+
+// authorizationCodeRepository sqlite implementation of auth.AuthorizationCodeRepository
+type authorizationCodeRepository struct {
+	DB           *sql.DB
+	QueryTimeout time.Duration
+}
+
+// NewAuthorizationCodeRepository constructor
+func NewAuthorizationCodeRepository(db *sql.DB, queryTimeout time.Duration) auth.AuthorizationCodeRepository {
+	return &authorizationCodeRepository{
+		DB:           db,
+		QueryTimeout: queryTimeout,
+	}
+}
+
+// CreateAuthorizationCode persist a auth.AuthorizationCode in the datastore
+func (r *authorizationCodeRepository) CreateAuthorizationCode(ctx context.Context, code *auth.AuthorizationCode) error {
+	ctx, cancel := database.WithQueryTimeout(ctx, r.QueryTimeout)
+	defer cancel()
+
+	query := `
+		INSERT INTO authorization_codes (
+			code_hash,
+			client_id,
+			user_id,
+			redirect_uri,
+			scopes,
+			code_challenge,
+			expires_at,
+			created_at
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+	`
+	_, err := database.Conn(ctx, r.DB).ExecContext(ctx, query,
+		code.CodeHash,
+		code.ClientID,
+		code.UserID,
+		code.RedirectURI,
+		strings.Join(code.Scopes, " "),
+		code.CodeChallenge,
+		code.ExpiresAt.UTC(),
+		code.CreatedAt.UTC(),
+	)
+	return err
+}
+
+// ConsumeAuthorizationCode gets and removes a auth.AuthorizationCode from the datastore, the removal
+// is the use of the code so concurrent uses of the same code get a not found error
+func (r *authorizationCodeRepository) ConsumeAuthorizationCode(ctx context.Context, codeHash string) (auth.AuthorizationCode, error) {
+	ctx, cancel := database.WithQueryTimeout(ctx, r.QueryTimeout)
+	defer cancel()
+
+	var code auth.AuthorizationCode
+	var scopes string
+	query := `
+		SELECT
+			code_hash,
+			client_id,
+			user_id,
+			redirect_uri,
+			scopes,
+			code_challenge,
+			expires_at,
+			created_at
+		FROM authorization_codes
+		WHERE code_hash = ? LIMIT 1
+	`
+	err := database.Conn(ctx, r.DB).QueryRowContext(ctx, query, codeHash).Scan(
+		&code.CodeHash,
+		&code.ClientID,
+		&code.UserID,
+		&code.RedirectURI,
+		&scopes,
+		&code.CodeChallenge,
+		&code.ExpiresAt,
+		&code.CreatedAt)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			err = terr.NewNotFoundError("authorization code not found")
+		}
+		return auth.AuthorizationCode{}, err
+	}
+
+	result, err := database.Conn(ctx, r.DB).ExecContext(ctx, `DELETE FROM authorization_codes WHERE code_hash = ?`, codeHash)
+	if err != nil {
+		return auth.AuthorizationCode{}, err
+	}
+
+	if affected, err := result.RowsAffected(); err == nil && affected == 0 {
+		return auth.AuthorizationCode{}, terr.NewNotFoundError("authorization code not found")
+	}
+
+	code.Scopes = strings.Fields(scopes)
+	return code, nil
+}
@@ -0,0 +1,42 @@
+package sqliteds
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	_ "sherman/src/app/testing"
+	"sherman/src/app/utils/terr"
+	"sherman/src/domain/auth"
+	"testing"
+	"time"
+)
+
+func TestConsumeAuthorizationCode(t *testing.T) {
+	t.Run("should return the code once", func(t *testing.T) {
+		db := genTokenDB(t)
+		defer db.Close()
+
+		assert.NoError(t, NewOAuthClientRepository(db, time.Second).CreateClient(context.Background(), genOAuthClient()))
+
+		now := time.Now().UTC()
+		code := &auth.AuthorizationCode{
+			CodeHash:      "some-hash",
+			ClientID:      "some-client-id",
+			UserID:        "some-user-id",
+			RedirectURI:   "https://some.app/callback",
+			Scopes:        []string{"profile"},
+			CodeChallenge: "some-challenge",
+			ExpiresAt:     now.Add(time.Minute),
+			CreatedAt:     now,
+		}
+		authorizationCodeRepo := NewAuthorizationCodeRepository(db, time.Second)
+		assert.NoError(t, authorizationCodeRepo.CreateAuthorizationCode(context.Background(), code))
+
+		consumed, err := authorizationCodeRepo.ConsumeAuthorizationCode(context.Background(), "some-hash")
+		if assert.NoError(t, err) {
+			assert.Equal(t, *code, consumed)
+		}
+
+		_, err = authorizationCodeRepo.ConsumeAuthorizationCode(context.Background(), "some-hash")
+		assert.Equal(t, terr.NewNotFoundError("authorization code not found"), err)
+	})
+}
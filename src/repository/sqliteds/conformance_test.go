@@ -0,0 +1,17 @@
+package sqliteds
+
+import (
+	_ "sherman/src/app/testing"
+	"sherman/src/domain/auth"
+	"sherman/src/repository/repositorytest"
+	"testing"
+	"time"
+)
+
+func TestConformance(t *testing.T) {
+	repositorytest.Run(t, func(t *testing.T) (auth.UserRepository, auth.SecurityTokenRepository, auth.UnitOfWork) {
+		db := newTestDB(t)
+		t.Cleanup(func() { _ = db.Close() })
+		return NewUserRepository(db, time.Second), NewSecurityTokenRepository(db, time.Second), NewUnitOfWork(db)
+	})
+}
@@ -0,0 +1,141 @@
+package sqliteds
+
+import (
+	"context"
+	"database/sql"
+	"sherman/src/app/database"
+	"sherman/src/app/utils/terr"
+	"sherman/src/domain/auth"
+	"time"
+)
+
+// loginAttemptRepository sqlite implementation of auth.LoginAttemptRepository
+type loginAttemptRepository struct {
+	DB           *sql.DB
+	QueryTimeout time.Duration
+}
+
+// NewLoginAttemptRepository constructor
+func NewLoginAttemptRepository(db *sql.DB, queryTimeout time.Duration) auth.LoginAttemptRepository {
+	return &loginAttemptRepository{
+		DB:           db,
+		QueryTimeout: queryTimeout,
+	}
+}
+
+// GetLoginAttempt gets the auth.LoginAttempt of a key from the datastore
+func (r *loginAttemptRepository) GetLoginAttempt(ctx context.Context, key string) (auth.LoginAttempt, error) {
+	ctx, cancel := database.WithQueryTimeout(ctx, r.QueryTimeout)
+	defer cancel()
+
+	var loginAttempt auth.LoginAttempt
+	var lockedUntil sql.NullTime
+
+	query := `
+		SELECT
+			attempt_key,
+			failures,
+			lockouts,
+			locked_until,
+			last_failure_at
+		FROM login_attempts
+		WHERE attempt_key = ? LIMIT 1
+	`
+	err := database.Conn(ctx, r.DB).QueryRowContext(ctx, query, key).Scan(
+		&loginAttempt.Key,
+		&loginAttempt.Failures,
+		&loginAttempt.Lockouts,
+		&lockedUntil,
+		&loginAttempt.LastFailureAt)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			err = terr.NewNotFoundError("login attempt not found")
+		}
+		return auth.LoginAttempt{}, err
+	}
+
+	if lockedUntil.Valid {
+		loginAttempt.LockedUntil = lockedUntil.Time
+	}
+	return loginAttempt, nil
+}
+
+// SaveLoginAttempt persist a auth.LoginAttempt in the datastore, replacing the one of the same key
+func (r *loginAttemptRepository) SaveLoginAttempt(ctx context.Context, loginAttempt *auth.LoginAttempt) error {
+	ctx, cancel := database.WithQueryTimeout(ctx, r.QueryTimeout)
+	defer cancel()
+
+	query := `
+		INSERT INTO login_attempts (
+			attempt_key,
+			failures,
+			lockouts,
+			locked_until,
+			last_failure_at
+		) VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT (attempt_key) DO UPDATE
+		SET
+			failures=excluded.failures,
+			lockouts=excluded.lockouts,
+			locked_until=excluded.locked_until,
+			last_failure_at=excluded.last_failure_at
+	`
+
+	lockedUntil := sql.NullTime{Time: loginAttempt.LockedUntil.UTC(), Valid: !loginAttempt.LockedUntil.IsZero()}
+	_, err := database.Conn(ctx, r.DB).ExecContext(ctx, query,
+		loginAttempt.Key,
+		loginAttempt.Failures,
+		loginAttempt.Lockouts,
+		lockedUntil,
+		loginAttempt.LastFailureAt.UTC(),
+	)
+	return err
+}
+
+// UpdateLoginAttempt applies update to the auth.LoginAttempt of a key, or to a new one, and persists it
+// in a transaction, sqlite has no row lock but a single writer, a concurrent update of the key waits for the commit
+func (r *loginAttemptRepository) UpdateLoginAttempt(ctx context.Context, key string, update func(loginAttempt *auth.LoginAttempt)) error {
+	ctx, cancel := database.WithQueryTimeout(ctx, r.QueryTimeout)
+	defer cancel()
+
+	return database.RunInTx(ctx, r.DB, func(ctx context.Context) error {
+		// writing first takes the write lock of the database before the login attempt is read
+		query := `
+			INSERT INTO login_attempts (attempt_key, last_failure_at) VALUES (?, ?)
+			ON CONFLICT (attempt_key) DO NOTHING
+		`
+		if _, err := database.Conn(ctx, r.DB).ExecContext(ctx, query, key, time.Now().UTC()); err != nil {
+			return err
+		}
+
+		loginAttempt, err := r.GetLoginAttempt(ctx, key)
+		if err != nil {
+			return err
+		}
+
+		update(&loginAttempt)
+		loginAttempt.Key = key
+		return r.SaveLoginAttempt(ctx, &loginAttempt)
+	})
+}
+
+// RemoveLoginAttempt removes the auth.LoginAttempt of a key from the datastore, a missing key is a no-op
+func (r *loginAttemptRepository) RemoveLoginAttempt(ctx context.Context, key string) error {
+	ctx, cancel := database.WithQueryTimeout(ctx, r.QueryTimeout)
+	defer cancel()
+
+	query := `DELETE FROM login_attempts WHERE attempt_key = ?`
+	_, err := database.Conn(ctx, r.DB).ExecContext(ctx, query, key)
+	return err
+}
+
+// RemoveStaleLoginAttempts removes the login attempts without failure nor lock since before from the datastore
+func (r *loginAttemptRepository) RemoveStaleLoginAttempts(ctx context.Context, before time.Time) error {
+	ctx, cancel := database.WithQueryTimeout(ctx, r.QueryTimeout)
+	defer cancel()
+
+	query := `DELETE FROM login_attempts WHERE last_failure_at < ? AND (locked_until IS NULL OR locked_until < ?)`
+	_, err := database.Conn(ctx, r.DB).ExecContext(ctx, query, before.UTC(), before.UTC())
+	return err
+}
@@ -0,0 +1,111 @@
+package sqliteds
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	_ "sherman/src/app/testing"
+	"sherman/src/app/utils/terr"
+	"sherman/src/domain/auth"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestSaveLoginAttempt(t *testing.T) {
+	now := time.Now().UTC()
+
+	t.Run("should insert and update", func(t *testing.T) {
+		db := newTestDB(t)
+		defer db.Close()
+
+		loginAttemptRepo := NewLoginAttemptRepository(db, time.Second)
+		loginAttempt := &auth.LoginAttempt{Key: "some@email.com", Failures: 1, LastFailureAt: now}
+		assert.NoError(t, loginAttemptRepo.SaveLoginAttempt(context.Background(), loginAttempt))
+
+		loginAttempt.Failures = 0
+		loginAttempt.Lockouts = 1
+		loginAttempt.LockedUntil = now.Add(time.Minute)
+
+		if assert.NoError(t, loginAttemptRepo.SaveLoginAttempt(context.Background(), loginAttempt)) {
+			stored, err := loginAttemptRepo.GetLoginAttempt(context.Background(), "some@email.com")
+			if assert.NoError(t, err) {
+				assert.Equal(t, *loginAttempt, stored)
+			}
+		}
+	})
+}
+
+func TestUpdateLoginAttempt(t *testing.T) {
+	t.Run("should update a new then an existing login attempt", func(t *testing.T) {
+		db := newTestDB(t)
+		defer db.Close()
+
+		loginAttemptRepo := NewLoginAttemptRepository(db, time.Second)
+		increment := func(loginAttempt *auth.LoginAttempt) {
+			loginAttempt.Failures++
+		}
+		assert.NoError(t, loginAttemptRepo.UpdateLoginAttempt(context.Background(), "ip:10.0.0.1", increment))
+		assert.NoError(t, loginAttemptRepo.UpdateLoginAttempt(context.Background(), "ip:10.0.0.1", increment))
+
+		loginAttempt, err := loginAttemptRepo.GetLoginAttempt(context.Background(), "ip:10.0.0.1")
+		if assert.NoError(t, err) {
+			assert.Equal(t, 2, loginAttempt.Failures)
+		}
+	})
+
+	t.Run("should apply concurrent updates one after the other", func(t *testing.T) {
+		db := newTestDB(t)
+		defer db.Close()
+
+		loginAttemptRepo := NewLoginAttemptRepository(db, 5*time.Second)
+
+		var wg sync.WaitGroup
+		for i := 0; i < 20; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				assert.NoError(t, loginAttemptRepo.UpdateLoginAttempt(context.Background(), "ip:10.0.0.1", func(loginAttempt *auth.LoginAttempt) {
+					loginAttempt.Failures++
+				}))
+			}()
+		}
+		wg.Wait()
+
+		loginAttempt, err := loginAttemptRepo.GetLoginAttempt(context.Background(), "ip:10.0.0.1")
+		if assert.NoError(t, err) {
+			assert.Equal(t, 20, loginAttempt.Failures)
+		}
+	})
+}
+
+func TestGetLoginAttempt(t *testing.T) {
+	t.Run("should return a not found error", func(t *testing.T) {
+		db := newTestDB(t)
+		defer db.Close()
+
+		_, err := NewLoginAttemptRepository(db, time.Second).GetLoginAttempt(context.Background(), "some@email.com")
+
+		assert.Equal(t, terr.NewNotFoundError("login attempt not found"), err)
+	})
+}
+
+func TestRemoveStaleLoginAttempts(t *testing.T) {
+	t.Run("should remove the stale unlocked attempts only", func(t *testing.T) {
+		db := newTestDB(t)
+		defer db.Close()
+
+		now := time.Now().UTC()
+		loginAttemptRepo := NewLoginAttemptRepository(db, time.Second)
+		assert.NoError(t, loginAttemptRepo.SaveLoginAttempt(context.Background(),
+			&auth.LoginAttempt{Key: "stale", Failures: 1, LastFailureAt: now.Add(-time.Hour)}))
+		assert.NoError(t, loginAttemptRepo.SaveLoginAttempt(context.Background(),
+			&auth.LoginAttempt{Key: "locked", Lockouts: 1, LockedUntil: now.Add(time.Hour), LastFailureAt: now.Add(-time.Hour)}))
+
+		if assert.NoError(t, loginAttemptRepo.RemoveStaleLoginAttempts(context.Background(), now.Add(-time.Minute))) {
+			_, err := loginAttemptRepo.GetLoginAttempt(context.Background(), "stale")
+			assert.Error(t, err)
+			_, err = loginAttemptRepo.GetLoginAttempt(context.Background(), "locked")
+			assert.NoError(t, err)
+		}
+	})
+}
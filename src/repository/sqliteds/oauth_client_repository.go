@@ -0,0 +1,120 @@
+package sqliteds
+
+import (
+	"context"
+	"database/sql"
+	"sherman/src/app/database"
+	"sherman/src/app/utils/terr"
+	"sherman/src/domain/auth"
+	"strings"
+	"time"
+)
+
+// oauthClientRepository sqlite implementation of auth.OAuthClientRepository
+type oauthClientRepository struct {
+	DB           *sql.DB
+	QueryTimeout time.Duration
+}
+
+// NewOAuthClientRepository constructor
+func NewOAuthClientRepository(db *sql.DB, queryTimeout time.Duration) auth.OAuthClientRepository {
+	return &oauthClientRepository{
+		DB:           db,
+		QueryTimeout: queryTimeout,
+	}
+}
+
+// CreateClient persist a auth.OAuthClient in the datastore, redirect uris, grant types and scopes
+// are stored space separated
+func (r *oauthClientRepository) CreateClient(ctx context.Context, client *auth.OAuthClient) error {
+	ctx, cancel := database.WithQueryTimeout(ctx, r.QueryTimeout)
+	defer cancel()
+
+	query := `
+		INSERT INTO oauth_clients (
+			id,
+			name,
+			secret_hash,
+			redirect_uris,
+			grant_types,
+			scopes,
+			created_at,
+			updated_at
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+	`
+	_, err := database.Conn(ctx, r.DB).ExecContext(ctx, query,
+		client.ID,
+		client.Name,
+		client.SecretHash,
+		strings.Join(client.RedirectURIs, " "),
+		strings.Join(client.GrantTypes, " "),
+		strings.Join(client.Scopes, " "),
+		client.CreatedAt.UTC(),
+		client.UpdatedAt.UTC(),
+	)
+	if err != nil && isUniqueViolation(err) {
+		err = terr.NewDuplicateEntryError("oauth client already exist")
+	}
+	return err
+}
+
+// UpdateClientSecret replaces the secret hash of an auth.OAuthClient in the datastore
+func (r *oauthClientRepository) UpdateClientSecret(ctx context.Context, id, secretHash string, updatedAt time.Time) error {
+	ctx, cancel := database.WithQueryTimeout(ctx, r.QueryTimeout)
+	defer cancel()
+
+	query := `UPDATE oauth_clients SET secret_hash=?, updated_at=? WHERE id = ?`
+	result, err := database.Conn(ctx, r.DB).ExecContext(ctx, query, secretHash, updatedAt.UTC(), id)
+	if err != nil {
+		return err
+	}
+
+	if affected, err := result.RowsAffected(); err == nil && affected == 0 {
+		return terr.NewNotFoundError("oauth client not found")
+	}
+	return nil
+}
+
+// GetClientByID gets a auth.OAuthClient from the datastore, redirect uris, grant types and scopes
+// are stored space separated
+func (r *oauthClientRepository) GetClientByID(ctx context.Context, id string) (auth.OAuthClient, error) {
+	ctx, cancel := database.WithQueryTimeout(ctx, r.QueryTimeout)
+	defer cancel()
+
+	var client auth.OAuthClient
+	var redirectURIs, grantTypes, scopes string
+	query := `
+		SELECT
+			id,
+			name,
+			secret_hash,
+			redirect_uris,
+			grant_types,
+			scopes,
+			created_at,
+			updated_at
+		FROM oauth_clients
+		WHERE id = ? LIMIT 1
+	`
+	err := database.Conn(ctx, r.DB).QueryRowContext(ctx, query, id).Scan(
+		&client.ID,
+		&client.Name,
+		&client.SecretHash,
+		&redirectURIs,
+		&grantTypes,
+		&scopes,
+		&client.CreatedAt,
+		&client.UpdatedAt)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			err = terr.NewNotFoundError("oauth client not found")
+		}
+		return auth.OAuthClient{}, err
+	}
+
+	client.RedirectURIs = strings.Fields(redirectURIs)
+	client.GrantTypes = strings.Fields(grantTypes)
+	client.Scopes = strings.Fields(scopes)
+	return client, nil
+}
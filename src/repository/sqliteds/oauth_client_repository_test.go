@@ -0,0 +1,65 @@
+package sqliteds
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	_ "sherman/src/app/testing"
+	"sherman/src/app/utils/terr"
+	"sherman/src/domain/auth"
+	"testing"
+	"time"
+)
+
+func genOAuthClient() *auth.OAuthClient {
+	now := time.Now().UTC()
+	return &auth.OAuthClient{
+		ID:           "some-client-id",
+		Name:         "some-client",
+		SecretHash:   "some-hash",
+		RedirectURIs: []string{"https://some.app/callback", "https://other.app/callback"},
+		GrantTypes:   []string{"authorization_code", "refresh_token"},
+		Scopes:       []string{"profile"},
+		CreatedAt:    now,
+		UpdatedAt:    now,
+	}
+}
+
+func TestCreateClient(t *testing.T) {
+	t.Run("should insert", func(t *testing.T) {
+		db := newTestDB(t)
+		defer db.Close()
+
+		oauthClientRepo := NewOAuthClientRepository(db, time.Second)
+		client := genOAuthClient()
+
+		if assert.NoError(t, oauthClientRepo.CreateClient(context.Background(), client)) {
+			stored, err := oauthClientRepo.GetClientByID(context.Background(), "some-client-id")
+			if assert.NoError(t, err) {
+				assert.Equal(t, *client, stored)
+			}
+		}
+	})
+
+	t.Run("should return a duplicate entry error", func(t *testing.T) {
+		db := newTestDB(t)
+		defer db.Close()
+
+		oauthClientRepo := NewOAuthClientRepository(db, time.Second)
+		assert.NoError(t, oauthClientRepo.CreateClient(context.Background(), genOAuthClient()))
+
+		err := oauthClientRepo.CreateClient(context.Background(), genOAuthClient())
+
+		assert.Equal(t, terr.NewDuplicateEntryError("oauth client already exist"), err)
+	})
+}
+
+func TestUpdateClientSecret(t *testing.T) {
+	t.Run("should return a not found error", func(t *testing.T) {
+		db := newTestDB(t)
+		defer db.Close()
+
+		err := NewOAuthClientRepository(db, time.Second).UpdateClientSecret(context.Background(), "some-client-id", "some-hash", time.Now())
+
+		assert.Equal(t, terr.NewNotFoundError("oauth client not found"), err)
+	})
+}
@@ -0,0 +1,72 @@
+package sqliteds
+
+import (
+	"context"
+	"database/sql"
+	"sherman/src/app/database"
+	"sherman/src/domain/auth"
+	"time"
+)
+
+// revokedTokenRepository sqlite implementation of auth.RevokedTokenRepository
+type revokedTokenRepository struct {
+	DB           *sql.DB
+	QueryTimeout time.Duration
+}
+
+// NewRevokedTokenRepository constructor
+func NewRevokedTokenRepository(db *sql.DB, queryTimeout time.Duration) auth.RevokedTokenRepository {
+	return &revokedTokenRepository{
+		DB:           db,
+		QueryTimeout: queryTimeout,
+	}
+}
+
+// CreateRevokedToken persist a auth.RevokedToken in the datastore, revoking an already revoked token is a no-op
+func (r *revokedTokenRepository) CreateRevokedToken(ctx context.Context, revokedToken *auth.RevokedToken) error {
+	ctx, cancel := database.WithQueryTimeout(ctx, r.QueryTimeout)
+	defer cancel()
+
+	query := `
+		INSERT INTO revoked_tokens (
+			id,
+			user_id,
+			expires_at,
+			created_at
+		) VALUES (?, ?, ?, ?)
+		ON CONFLICT (id) DO NOTHING
+	`
+
+	_, err := database.Conn(ctx, r.DB).ExecContext(ctx, query,
+		revokedToken.ID,
+		revokedToken.UserID,
+		revokedToken.ExpiresAt.UTC(),
+		revokedToken.CreatedAt.UTC(),
+	)
+	return err
+}
+
+// IsTokenRevoked checks if a token id is persisted in the datastore
+func (r *revokedTokenRepository) IsTokenRevoked(ctx context.Context, tokenID string) (bool, error) {
+	ctx, cancel := database.WithQueryTimeout(ctx, r.QueryTimeout)
+	defer cancel()
+
+	var count int
+
+	query := `SELECT COUNT(*) FROM revoked_tokens WHERE id = ?`
+	if err := database.Conn(ctx, r.DB).QueryRowContext(ctx, query, tokenID).Scan(&count); err != nil {
+		return false, err
+	}
+
+	return count > 0, nil
+}
+
+// RemoveExpiredRevokedTokens removes the revoked tokens expired before now from the datastore
+func (r *revokedTokenRepository) RemoveExpiredRevokedTokens(ctx context.Context, now time.Time) error {
+	ctx, cancel := database.WithQueryTimeout(ctx, r.QueryTimeout)
+	defer cancel()
+
+	query := `DELETE FROM revoked_tokens WHERE expires_at < ?`
+	_, err := database.Conn(ctx, r.DB).ExecContext(ctx, query, now.UTC())
+	return err
+}
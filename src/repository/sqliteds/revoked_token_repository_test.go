@@ -0,0 +1,62 @@
+package sqliteds
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	_ "sherman/src/app/testing"
+	"sherman/src/domain/auth"
+	"testing"
+	"time"
+)
+
+func TestRevokeToken(t *testing.T) {
+	now := time.Now().UTC()
+
+	t.Run("should revoke the token once", func(t *testing.T) {
+		db := genTokenDB(t)
+		defer db.Close()
+
+		revokedTokenRepo := NewRevokedTokenRepository(db, time.Second)
+		revokedToken := &auth.RevokedToken{ID: "some-jti", UserID: "some-user-id", ExpiresAt: now.Add(time.Hour), CreatedAt: now}
+
+		assert.NoError(t, revokedTokenRepo.CreateRevokedToken(context.Background(), revokedToken))
+		assert.NoError(t, revokedTokenRepo.CreateRevokedToken(context.Background(), revokedToken))
+
+		revoked, err := revokedTokenRepo.IsTokenRevoked(context.Background(), "some-jti")
+		if assert.NoError(t, err) {
+			assert.True(t, revoked)
+		}
+	})
+
+	t.Run("should not report a token that was not revoked", func(t *testing.T) {
+		db := genTokenDB(t)
+		defer db.Close()
+
+		revoked, err := NewRevokedTokenRepository(db, time.Second).IsTokenRevoked(context.Background(), "some-jti")
+
+		if assert.NoError(t, err) {
+			assert.False(t, revoked)
+		}
+	})
+}
+
+func TestRemoveExpiredRevokedTokens(t *testing.T) {
+	t.Run("should remove the expired tokens only", func(t *testing.T) {
+		db := genTokenDB(t)
+		defer db.Close()
+
+		now := time.Now().UTC()
+		revokedTokenRepo := NewRevokedTokenRepository(db, time.Second)
+		assert.NoError(t, revokedTokenRepo.CreateRevokedToken(context.Background(),
+			&auth.RevokedToken{ID: "expired-jti", UserID: "some-user-id", ExpiresAt: now.Add(-time.Hour), CreatedAt: now}))
+		assert.NoError(t, revokedTokenRepo.CreateRevokedToken(context.Background(),
+			&auth.RevokedToken{ID: "live-jti", UserID: "some-user-id", ExpiresAt: now.Add(time.Hour), CreatedAt: now}))
+
+		if assert.NoError(t, revokedTokenRepo.RemoveExpiredRevokedTokens(context.Background(), now)) {
+			revoked, _ := revokedTokenRepo.IsTokenRevoked(context.Background(), "expired-jti")
+			assert.False(t, revoked)
+			revoked, _ = revokedTokenRepo.IsTokenRevoked(context.Background(), "live-jti")
+			assert.True(t, revoked)
+		}
+	})
+}
@@ -0,0 +1,89 @@
+package sqliteds
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	_ "sherman/src/app/testing"
+	"sherman/src/app/utils/terr"
+	"sherman/src/domain/auth"
+	"testing"
+	"time"
+)
+
+const adminRoleID = "7d3f5b1e-0c4a-4e8e-9a51-2f6b8c0d1a01"
+
+func TestGetRoleByName(t *testing.T) {
+	t.Run("should return the seeded admin role", func(t *testing.T) {
+		db := newTestDB(t)
+		defer db.Close()
+
+		role, err := NewRoleRepository(db, time.Second).GetRoleByName(context.Background(), "admin")
+
+		if assert.NoError(t, err) {
+			assert.Equal(t, adminRoleID, role.ID)
+		}
+	})
+
+	t.Run("should return a not found error", func(t *testing.T) {
+		db := newTestDB(t)
+		defer db.Close()
+
+		_, err := NewRoleRepository(db, time.Second).GetRoleByName(context.Background(), "unknown")
+
+		assert.Equal(t, terr.NewNotFoundError("role not found"), err)
+	})
+}
+
+func TestCreateUserRole(t *testing.T) {
+	userRole := &auth.UserRole{UserID: "some-user-id", RoleID: adminRoleID, CreatedAt: time.Now().UTC()}
+
+	t.Run("should assign the role and its permissions", func(t *testing.T) {
+		db := genTokenDB(t)
+		defer db.Close()
+
+		roleRepo := NewRoleRepository(db, time.Second)
+		if assert.NoError(t, roleRepo.CreateUserRole(context.Background(), userRole)) {
+			roleNames, err := roleRepo.GetRoleNamesByUserID(context.Background(), "some-user-id")
+			if assert.NoError(t, err) {
+				assert.Equal(t, []string{"admin"}, roleNames)
+			}
+
+			permissions, err := roleRepo.GetPermissionsByRoleNames(context.Background(), roleNames)
+			if assert.NoError(t, err) {
+				assert.Equal(t, []string{"clients:manage", "roles:manage", "users:manage", "users:read"}, permissions)
+			}
+		}
+	})
+
+	t.Run("should return a duplicate entry error", func(t *testing.T) {
+		db := genTokenDB(t)
+		defer db.Close()
+
+		roleRepo := NewRoleRepository(db, time.Second)
+		assert.NoError(t, roleRepo.CreateUserRole(context.Background(), userRole))
+
+		err := roleRepo.CreateUserRole(context.Background(), userRole)
+
+		assert.Equal(t, terr.NewDuplicateEntryError("role already assigned"), err)
+	})
+
+	t.Run("should return a not found error for an unknown user", func(t *testing.T) {
+		db := newTestDB(t)
+		defer db.Close()
+
+		err := NewRoleRepository(db, time.Second).CreateUserRole(context.Background(), userRole)
+
+		assert.Equal(t, terr.NewNotFoundError("user not found"), err)
+	})
+}
+
+func TestRemoveUserRole(t *testing.T) {
+	t.Run("should return a not found error", func(t *testing.T) {
+		db := genTokenDB(t)
+		defer db.Close()
+
+		err := NewRoleRepository(db, time.Second).RemoveUserRole(context.Background(), "some-user-id", adminRoleID)
+
+		assert.Equal(t, terr.NewNotFoundError("role not assigned"), err)
+	})
+}
@@ -0,0 +1,258 @@
+package sqliteds
+
+import (
+	"context"
+	"database/sql"
+	"sherman/src/app/database"
+	"sherman/src/app/utils/terr"
+	"sherman/src/domain/auth"
+	"time"
+)
+
+// securityTokenRepository sqlite implementation of auth.SecurityTokenRepository
+type securityTokenRepository struct {
+	DB           *sql.DB
+	QueryTimeout time.Duration
+}
+
+// NewSecurityTokenRepository constructor
+func NewSecurityTokenRepository(db *sql.DB, queryTimeout time.Duration) auth.SecurityTokenRepository {
+	return &securityTokenRepository{
+		DB:           db,
+		QueryTimeout: queryTimeout,
+	}
+}
+
+func (r *securityTokenRepository) scanTokenRow(row rowScanner) (auth.SecurityToken, error) {
+	var token auth.SecurityToken
+
+	err := row.Scan(
+		&token.ID,
+		&token.UserID,
+		&token.Token,
+		&token.Type,
+		&token.FamilyID,
+		&token.Rotated,
+		&token.UserAgent,
+		&token.IPAddress,
+		&token.LastUsedAt,
+		&token.CreatedAt,
+		&token.UpdatedAt)
+
+	if err != nil {
+		return auth.SecurityToken{}, err
+	}
+
+	return token, nil
+}
+
+// CreateToken persist a new auth.SecurityToken in the datastore
+func (r *securityTokenRepository) CreateToken(ctx context.Context, token *auth.SecurityToken) error {
+	ctx, cancel := database.WithQueryTimeout(ctx, r.QueryTimeout)
+	defer cancel()
+
+	query := `
+		INSERT INTO security_tokens (
+			id,
+			user_id,
+			token,
+			type,
+			family_id,
+			rotated,
+			user_agent,
+			ip_address,
+			last_used_at,
+			created_at,
+			updated_at
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`
+
+	_, err := database.Conn(ctx, r.DB).ExecContext(ctx, query,
+		token.ID,
+		token.UserID,
+		token.Token,
+		token.Type,
+		token.FamilyID,
+		token.Rotated,
+		token.UserAgent,
+		token.IPAddress,
+		token.LastUsedAt.UTC(),
+		token.CreatedAt.UTC(),
+		token.UpdatedAt.UTC(),
+	)
+	return err
+}
+
+// CreateOrUpdateToken persist a auth.SecurityToken in the datastore, replacing the token of the same user and type
+// persisted by a previous CreateOrUpdateToken, the insert or update is a single atomic upsert
+func (r *securityTokenRepository) CreateOrUpdateToken(ctx context.Context, token *auth.SecurityToken) error {
+	ctx, cancel := database.WithQueryTimeout(ctx, r.QueryTimeout)
+	defer cancel()
+
+	query := `
+		INSERT INTO security_tokens (
+			id,
+			user_id,
+			token,
+			type,
+			unique_type,
+			family_id,
+			rotated,
+			user_agent,
+			ip_address,
+			last_used_at,
+			created_at,
+			updated_at
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (user_id, unique_type) DO UPDATE
+		SET
+			token=excluded.token,
+			last_used_at=excluded.last_used_at,
+			updated_at=excluded.updated_at
+	`
+
+	_, err := database.Conn(ctx, r.DB).ExecContext(ctx, query,
+		token.ID,
+		token.UserID,
+		token.Token,
+		token.Type,
+		token.Type,
+		token.FamilyID,
+		token.Rotated,
+		token.UserAgent,
+		token.IPAddress,
+		token.LastUsedAt.UTC(),
+		token.CreatedAt.UTC(),
+		token.UpdatedAt.UTC(),
+	)
+	return err
+}
+
+// GetTokenByMetadata finds the exact auth.SecurityToken in the datastore, the active token of a family takes precedence
+func (r *securityTokenRepository) GetTokenByMetadata(ctx context.Context, tokenMetadata *auth.TokenMetadata) (auth.SecurityToken, error) {
+	ctx, cancel := database.WithQueryTimeout(ctx, r.QueryTimeout)
+	defer cancel()
+
+	query := `
+		SELECT
+			id,
+			user_id,
+			token,
+			type,
+			family_id,
+			rotated,
+			user_agent,
+			ip_address,
+			last_used_at,
+			created_at,
+			updated_at
+		FROM security_tokens
+		WHERE user_id = ? AND type = ? AND token = ?
+		ORDER BY rotated ASC LIMIT 1
+	`
+	row := database.Conn(ctx, r.DB).QueryRowContext(ctx, query, tokenMetadata.UserID, tokenMetadata.Type, tokenMetadata.Token)
+	token, err := r.scanTokenRow(row)
+	if err != nil {
+		return auth.SecurityToken{}, terr.NewNotFoundError("token not found")
+	}
+
+	return token, nil
+}
+
+// GetTokensByUserID gets the active auth.SecurityToken(s) of a user and type from the datastore, most recently used first
+func (r *securityTokenRepository) GetTokensByUserID(ctx context.Context, userID, tokenType string) ([]auth.SecurityToken, error) {
+	ctx, cancel := database.WithQueryTimeout(ctx, r.QueryTimeout)
+	defer cancel()
+
+	query := `
+		SELECT
+			id,
+			user_id,
+			token,
+			type,
+			family_id,
+			rotated,
+			user_agent,
+			ip_address,
+			last_used_at,
+			created_at,
+			updated_at
+		FROM security_tokens
+		WHERE user_id = ? AND type = ? AND rotated = 0
+		ORDER BY last_used_at DESC
+	`
+	rows, err := database.Conn(ctx, r.DB).QueryContext(ctx, query, userID, tokenType)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tokens := make([]auth.SecurityToken, 0)
+	for rows.Next() {
+		token, err := r.scanTokenRow(rows)
+		if err != nil {
+			return nil, err
+		}
+		tokens = append(tokens, token)
+	}
+
+	return tokens, rows.Err()
+}
+
+// RotateToken stores the new value of a token and keeps its previous value as a rotated token of the same family,
+// both or neither, a token that no longer has the previous value was rotated concurrently and returns a
+// terr.UnAuthorizedError
+func (r *securityTokenRepository) RotateToken(ctx context.Context, token, rotatedToken *auth.SecurityToken) error {
+	ctx, cancel := database.WithQueryTimeout(ctx, r.QueryTimeout)
+	defer cancel()
+
+	return database.RunInTx(ctx, r.DB, func(ctx context.Context) error {
+		query := `UPDATE security_tokens SET token=?, last_used_at=?, updated_at=? WHERE id = ? AND token = ? AND rotated = 0`
+		result, err := database.Conn(ctx, r.DB).ExecContext(ctx, query,
+			token.Token,
+			token.LastUsedAt.UTC(),
+			token.UpdatedAt.UTC(),
+			token.ID,
+			rotatedToken.Token,
+		)
+		if err != nil {
+			return err
+		}
+
+		if affected, err := result.RowsAffected(); err == nil && affected == 0 {
+			return terr.NewUnAuthorizedError("refresh token already rotated")
+		}
+
+		previousToken := *rotatedToken
+		previousToken.Rotated = true
+		return r.CreateToken(ctx, &previousToken)
+	})
+}
+
+// RemoveTokenByMetadata removes every token of the user and type from the datastore
+func (r *securityTokenRepository) RemoveTokenByMetadata(ctx context.Context, tokenMetadata *auth.TokenMetadata) error {
+	ctx, cancel := database.WithQueryTimeout(ctx, r.QueryTimeout)
+	defer cancel()
+
+	query := `DELETE FROM security_tokens WHERE user_id = ? AND type = ?`
+	_, err := database.Conn(ctx, r.DB).ExecContext(ctx, query, tokenMetadata.UserID, tokenMetadata.Type)
+	return err
+}
+
+// RemoveTokenFamily removes every token of a family from the datastore
+func (r *securityTokenRepository) RemoveTokenFamily(ctx context.Context, userID, familyID string) error {
+	ctx, cancel := database.WithQueryTimeout(ctx, r.QueryTimeout)
+	defer cancel()
+
+	query := `DELETE FROM security_tokens WHERE user_id = ? AND family_id = ?`
+	result, err := database.Conn(ctx, r.DB).ExecContext(ctx, query, userID, familyID)
+	if err != nil {
+		return err
+	}
+
+	if affected, err := result.RowsAffected(); err == nil && affected == 0 {
+		return terr.NewNotFoundError("token not found")
+	}
+
+	return nil
+}
@@ -0,0 +1,194 @@
+package sqliteds
+
+import (
+	"context"
+	"database/sql"
+	"github.com/stretchr/testify/assert"
+	_ "sherman/src/app/testing"
+	"sherman/src/app/utils/terr"
+	"sherman/src/domain/auth"
+	"testing"
+	"time"
+)
+
+func genToken(id, userID, token string) *auth.SecurityToken {
+	now := time.Now().UTC()
+	return &auth.SecurityToken{
+		ID:         id,
+		UserID:     userID,
+		Token:      token,
+		Type:       auth.RefreshTokenType,
+		FamilyID:   id,
+		UserAgent:  "some-user-agent",
+		IPAddress:  "127.0.0.1",
+		LastUsedAt: now,
+		CreatedAt:  now,
+		UpdatedAt:  now,
+	}
+}
+
+func genTokenDB(t *testing.T) *sql.DB {
+	db := newTestDB(t)
+	userRepo := NewUserRepository(db, time.Second)
+	assert.NoError(t, userRepo.CreateUser(context.Background(), genUser("some-user-id", "some@email.com", time.Now())))
+	return db
+}
+
+func TestCreateToken(t *testing.T) {
+	t.Run("should insert", func(t *testing.T) {
+		db := genTokenDB(t)
+		defer db.Close()
+
+		securityTokenRepo := NewSecurityTokenRepository(db, time.Second)
+		st := genToken("some-id", "some-user-id", "some-token")
+
+		if assert.NoError(t, securityTokenRepo.CreateToken(context.Background(), st)) {
+			token, err := securityTokenRepo.GetTokenByMetadata(context.Background(), &auth.TokenMetadata{
+				UserID: "some-user-id",
+				Type:   auth.RefreshTokenType,
+				Token:  "some-token",
+			})
+			if assert.NoError(t, err) {
+				assert.Equal(t, *st, token)
+			}
+		}
+	})
+
+	t.Run("should return an error on unknown user", func(t *testing.T) {
+		db := genTokenDB(t)
+		defer db.Close()
+
+		securityTokenRepo := NewSecurityTokenRepository(db, time.Second)
+
+		assert.Error(t, securityTokenRepo.CreateToken(context.Background(), genToken("some-id", "other-user-id", "some-token")))
+	})
+}
+
+func TestCreateOrUpdateToken(t *testing.T) {
+	t.Run("should insert then update", func(t *testing.T) {
+		db := genTokenDB(t)
+		defer db.Close()
+
+		securityTokenRepo := NewSecurityTokenRepository(db, time.Second)
+		assert.NoError(t, securityTokenRepo.CreateOrUpdateToken(context.Background(), genToken("some-id", "some-user-id", "some-token")))
+		assert.NoError(t, securityTokenRepo.CreateOrUpdateToken(context.Background(), genToken("other-id", "some-user-id", "other-token")))
+
+		tokens, err := securityTokenRepo.GetTokensByUserID(context.Background(), "some-user-id", auth.RefreshTokenType)
+		if assert.NoError(t, err) && assert.Len(t, tokens, 1) {
+			assert.Equal(t, "some-id", tokens[0].ID)
+			assert.Equal(t, "other-token", tokens[0].Token)
+		}
+	})
+}
+
+func TestGetTokenByMetadata(t *testing.T) {
+	t.Run("should return a not found error", func(t *testing.T) {
+		db := genTokenDB(t)
+		defer db.Close()
+
+		securityTokenRepo := NewSecurityTokenRepository(db, time.Second)
+
+		_, err := securityTokenRepo.GetTokenByMetadata(context.Background(), &auth.TokenMetadata{
+			UserID: "some-user-id",
+			Type:   auth.RefreshTokenType,
+			Token:  "some-token",
+		})
+
+		assert.Equal(t, terr.NewNotFoundError("token not found"), err)
+	})
+}
+
+func TestRotateToken(t *testing.T) {
+	t.Run("should rotate", func(t *testing.T) {
+		db := genTokenDB(t)
+		defer db.Close()
+
+		securityTokenRepo := NewSecurityTokenRepository(db, time.Second)
+		st := genToken("some-id", "some-user-id", "some-token")
+		assert.NoError(t, securityTokenRepo.CreateToken(context.Background(), st))
+
+		rotatedToken := *st
+		rotatedToken.ID = "rotated-id"
+		token := *st
+		token.Token = "new-token"
+
+		if assert.NoError(t, securityTokenRepo.RotateToken(context.Background(), &token, &rotatedToken)) {
+			tokens, _ := securityTokenRepo.GetTokensByUserID(context.Background(), "some-user-id", auth.RefreshTokenType)
+			if assert.Len(t, tokens, 1) {
+				assert.Equal(t, "new-token", tokens[0].Token)
+			}
+
+			previousToken, err := securityTokenRepo.GetTokenByMetadata(context.Background(), &auth.TokenMetadata{
+				UserID: "some-user-id",
+				Type:   auth.RefreshTokenType,
+				Token:  "some-token",
+			})
+			if assert.NoError(t, err) {
+				assert.True(t, previousToken.Rotated)
+				assert.Equal(t, "some-id", previousToken.FamilyID)
+			}
+		}
+	})
+
+	t.Run("should return an un-authorized error", func(t *testing.T) {
+		db := genTokenDB(t)
+		defer db.Close()
+
+		securityTokenRepo := NewSecurityTokenRepository(db, time.Second)
+		st := genToken("some-id", "some-user-id", "some-token")
+		assert.NoError(t, securityTokenRepo.CreateToken(context.Background(), st))
+
+		rotatedToken := *st
+		rotatedToken.ID = "rotated-id"
+		rotatedToken.Token = "some-stale-token"
+		token := *st
+		token.Token = "new-token"
+
+		err := securityTokenRepo.RotateToken(context.Background(), &token, &rotatedToken)
+
+		if assert.Equal(t, terr.NewUnAuthorizedError("refresh token already rotated"), err) {
+			tokens, _ := securityTokenRepo.GetTokensByUserID(context.Background(), "some-user-id", auth.RefreshTokenType)
+			if assert.Len(t, tokens, 1) {
+				assert.Equal(t, "some-token", tokens[0].Token)
+			}
+		}
+	})
+}
+
+func TestRemoveTokenByMetadata(t *testing.T) {
+	t.Run("should remove", func(t *testing.T) {
+		db := genTokenDB(t)
+		defer db.Close()
+
+		securityTokenRepo := NewSecurityTokenRepository(db, time.Second)
+		assert.NoError(t, securityTokenRepo.CreateToken(context.Background(), genToken("some-id", "some-user-id", "some-token")))
+
+		err := securityTokenRepo.RemoveTokenByMetadata(context.Background(), &auth.TokenMetadata{UserID: "some-user-id", Type: auth.RefreshTokenType})
+
+		if assert.NoError(t, err) {
+			tokens, _ := securityTokenRepo.GetTokensByUserID(context.Background(), "some-user-id", auth.RefreshTokenType)
+			assert.Empty(t, tokens)
+		}
+	})
+}
+
+func TestRemoveTokenFamily(t *testing.T) {
+	t.Run("should remove", func(t *testing.T) {
+		db := genTokenDB(t)
+		defer db.Close()
+
+		securityTokenRepo := NewSecurityTokenRepository(db, time.Second)
+		assert.NoError(t, securityTokenRepo.CreateToken(context.Background(), genToken("some-id", "some-user-id", "some-token")))
+
+		assert.NoError(t, securityTokenRepo.RemoveTokenFamily(context.Background(), "some-user-id", "some-id"))
+	})
+
+	t.Run("should return a not found error", func(t *testing.T) {
+		db := genTokenDB(t)
+		defer db.Close()
+
+		securityTokenRepo := NewSecurityTokenRepository(db, time.Second)
+
+		assert.Equal(t, terr.NewNotFoundError("token not found"), securityTokenRepo.RemoveTokenFamily(context.Background(), "some-user-id", "some-id"))
+	})
+}
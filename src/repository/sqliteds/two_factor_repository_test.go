@@ -0,0 +1,94 @@
+package sqliteds
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	_ "sherman/src/app/testing"
+	"sherman/src/app/utils/terr"
+	"sherman/src/domain/auth"
+	"testing"
+	"time"
+)
+
+func genTwoFactor(lastUsedStep int64) *auth.TwoFactor {
+	now := time.Now().UTC()
+	return &auth.TwoFactor{
+		UserID:       "some-user-id",
+		Secret:       "some-secret",
+		Enabled:      true,
+		LastUsedStep: lastUsedStep,
+		CreatedAt:    now,
+		UpdatedAt:    now,
+	}
+}
+
+func TestCreateOrUpdateTwoFactor(t *testing.T) {
+	t.Run("should insert and replace", func(t *testing.T) {
+		db := genTokenDB(t)
+		defer db.Close()
+
+		twoFactorRepo := NewTwoFactorRepository(db, time.Second)
+		created := genTwoFactor(1)
+		assert.NoError(t, twoFactorRepo.CreateOrUpdateTwoFactor(context.Background(), created))
+
+		twoFactor := genTwoFactor(2)
+		twoFactor.Secret = "other-secret"
+		twoFactor.CreatedAt = created.CreatedAt
+
+		if assert.NoError(t, twoFactorRepo.CreateOrUpdateTwoFactor(context.Background(), twoFactor)) {
+			stored, err := twoFactorRepo.GetTwoFactor(context.Background(), "some-user-id")
+			if assert.NoError(t, err) {
+				assert.Equal(t, *twoFactor, stored)
+			}
+		}
+	})
+}
+
+func TestUpdateTwoFactorLastUsedStep(t *testing.T) {
+	t.Run("should reject a replayed step", func(t *testing.T) {
+		db := genTokenDB(t)
+		defer db.Close()
+
+		twoFactorRepo := NewTwoFactorRepository(db, time.Second)
+		assert.NoError(t, twoFactorRepo.CreateOrUpdateTwoFactor(context.Background(), genTwoFactor(5)))
+
+		assert.NoError(t, twoFactorRepo.UpdateTwoFactorLastUsedStep(context.Background(), "some-user-id", 6, time.Now()))
+		err := twoFactorRepo.UpdateTwoFactorLastUsedStep(context.Background(), "some-user-id", 6, time.Now())
+
+		assert.Equal(t, terr.NewUnAuthorizedError("code already used"), err)
+	})
+}
+
+func TestRecoveryCodes(t *testing.T) {
+	t.Run("should replace the codes and use each once", func(t *testing.T) {
+		db := genTokenDB(t)
+		defer db.Close()
+
+		now := time.Now().UTC()
+		twoFactorRepo := NewTwoFactorRepository(db, time.Second)
+		assert.NoError(t, twoFactorRepo.ReplaceRecoveryCodes(context.Background(), "some-user-id", []auth.RecoveryCode{
+			{ID: "old-id", CodeHash: "old-hash", CreatedAt: now},
+		}))
+		assert.NoError(t, twoFactorRepo.ReplaceRecoveryCodes(context.Background(), "some-user-id", []auth.RecoveryCode{
+			{ID: "some-id", CodeHash: "some-hash", CreatedAt: now},
+			{ID: "other-id", CodeHash: "other-hash", CreatedAt: now},
+		}))
+
+		assert.Equal(t, terr.NewNotFoundError("recovery code not found"),
+			twoFactorRepo.RemoveRecoveryCode(context.Background(), "some-user-id", "old-hash"))
+		assert.NoError(t, twoFactorRepo.RemoveRecoveryCode(context.Background(), "some-user-id", "some-hash"))
+		assert.Equal(t, terr.NewNotFoundError("recovery code not found"),
+			twoFactorRepo.RemoveRecoveryCode(context.Background(), "some-user-id", "some-hash"))
+	})
+}
+
+func TestRemoveTwoFactor(t *testing.T) {
+	t.Run("should return a not found error", func(t *testing.T) {
+		db := genTokenDB(t)
+		defer db.Close()
+
+		err := NewTwoFactorRepository(db, time.Second).RemoveTwoFactor(context.Background(), "some-user-id")
+
+		assert.Equal(t, terr.NewNotFoundError("two factor authentication not found"), err)
+	})
+}
@@ -0,0 +1,93 @@
+package sqliteds
+
+import (
+	"context"
+	"database/sql"
+	"sherman/src/app/database"
+	"sherman/src/app/utils/terr"
+	"sherman/src/domain/auth"
+	"time"
+)
+
+// userIdentityRepository sqlite implementation of auth.UserIdentityRepository
+type userIdentityRepository struct {
+	DB           *sql.DB
+	QueryTimeout time.Duration
+}
+
+// NewUserIdentityRepository constructor
+func NewUserIdentityRepository(db *sql.DB, queryTimeout time.Duration) auth.UserIdentityRepository {
+	return &userIdentityRepository{
+		DB:           db,
+		QueryTimeout: queryTimeout,
+	}
+}
+
+// GetIdentity gets the auth.UserIdentity of a provider subject from the datastore
+func (r *userIdentityRepository) GetIdentity(ctx context.Context, provider, subject string) (auth.UserIdentity, error) {
+	ctx, cancel := database.WithQueryTimeout(ctx, r.QueryTimeout)
+	defer cancel()
+
+	var identity auth.UserIdentity
+	query := `
+		SELECT
+			id,
+			user_id,
+			provider,
+			subject,
+			email_address,
+			created_at
+		FROM user_identities
+		WHERE provider = ? AND subject = ? LIMIT 1
+	`
+	err := database.Conn(ctx, r.DB).QueryRowContext(ctx, query, provider, subject).Scan(
+		&identity.ID,
+		&identity.UserID,
+		&identity.Provider,
+		&identity.Subject,
+		&identity.EmailAddress,
+		&identity.CreatedAt)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			err = terr.NewNotFoundError("user identity not found")
+		}
+		return auth.UserIdentity{}, err
+	}
+
+	return identity, nil
+}
+
+// CreateIdentity persist a auth.UserIdentity in the datastore, a provider subject is linked to a single user
+func (r *userIdentityRepository) CreateIdentity(ctx context.Context, identity *auth.UserIdentity) error {
+	ctx, cancel := database.WithQueryTimeout(ctx, r.QueryTimeout)
+	defer cancel()
+
+	query := `
+		INSERT INTO user_identities (
+			id,
+			user_id,
+			provider,
+			subject,
+			email_address,
+			created_at
+		) VALUES (?, ?, ?, ?, ?, ?)
+	`
+	_, err := database.Conn(ctx, r.DB).ExecContext(ctx, query,
+		identity.ID,
+		identity.UserID,
+		identity.Provider,
+		identity.Subject,
+		identity.EmailAddress,
+		identity.CreatedAt.UTC(),
+	)
+
+	if err != nil {
+		if isUniqueViolation(err) {
+			err = terr.NewDuplicateEntryError("user identity already exist")
+		}
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,60 @@
+package sqliteds
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	_ "sherman/src/app/testing"
+	"sherman/src/app/utils/terr"
+	"sherman/src/domain/auth"
+	"testing"
+	"time"
+)
+
+func TestCreateIdentity(t *testing.T) {
+	identity := &auth.UserIdentity{
+		ID:           "some-id",
+		UserID:       "some-user-id",
+		Provider:     "google",
+		Subject:      "some-subject",
+		EmailAddress: "some@email.com",
+		CreatedAt:    time.Now().UTC(),
+	}
+
+	t.Run("should insert", func(t *testing.T) {
+		db := genTokenDB(t)
+		defer db.Close()
+
+		userIdentityRepo := NewUserIdentityRepository(db, time.Second)
+		if assert.NoError(t, userIdentityRepo.CreateIdentity(context.Background(), identity)) {
+			stored, err := userIdentityRepo.GetIdentity(context.Background(), "google", "some-subject")
+			if assert.NoError(t, err) {
+				assert.Equal(t, *identity, stored)
+			}
+		}
+	})
+
+	t.Run("should return a duplicate entry error", func(t *testing.T) {
+		db := genTokenDB(t)
+		defer db.Close()
+
+		userIdentityRepo := NewUserIdentityRepository(db, time.Second)
+		assert.NoError(t, userIdentityRepo.CreateIdentity(context.Background(), identity))
+
+		other := *identity
+		other.ID = "other-id"
+		err := userIdentityRepo.CreateIdentity(context.Background(), &other)
+
+		assert.Equal(t, terr.NewDuplicateEntryError("user identity already exist"), err)
+	})
+}
+
+func TestGetIdentity(t *testing.T) {
+	t.Run("should return a not found error", func(t *testing.T) {
+		db := newTestDB(t)
+		defer db.Close()
+
+		_, err := NewUserIdentityRepository(db, time.Second).GetIdentity(context.Background(), "google", "some-subject")
+
+		assert.Equal(t, terr.NewNotFoundError("user identity not found"), err)
+	})
+}
@@ -0,0 +1,269 @@
+package sqliteds
+
+import (
+	"context"
+	"database/sql"
+	"github.com/mattn/go-sqlite3"
+	"sherman/src/app/database"
+	"sherman/src/app/utils/terr"
+	"sherman/src/domain/auth"
+	"time"
+)
+
+// userRepository sqlite implementation of auth.UserRepository
+type userRepository struct {
+	DB           *sql.DB
+	QueryTimeout time.Duration
+}
+
+// NewUserRepository constructor
+func NewUserRepository(db *sql.DB, queryTimeout time.Duration) auth.UserRepository {
+	return &userRepository{
+		DB:           db,
+		QueryTimeout: queryTimeout,
+	}
+}
+
+// rowScanner a *sql.Row or *sql.Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// isUniqueViolation tells whether err is the violation of a unique or primary key constraint
+func isUniqueViolation(err error) bool {
+	sqliteErr, ok := err.(sqlite3.Error)
+	return ok && (sqliteErr.ExtendedCode == sqlite3.ErrConstraintUnique ||
+		sqliteErr.ExtendedCode == sqlite3.ErrConstraintPrimaryKey)
+}
+
+// isForeignKeyViolation tells whether err is the violation of a foreign key constraint
+func isForeignKeyViolation(err error) bool {
+	sqliteErr, ok := err.(sqlite3.Error)
+	return ok && sqliteErr.ExtendedCode == sqlite3.ErrConstraintForeignKey
+}
+
+func (r *userRepository) scanUserRow(row rowScanner) (auth.User, error) {
+	var user auth.User
+
+	err := row.Scan(
+		&user.ID,
+		&user.FirstName,
+		&user.LastName,
+		&user.EmailAddress,
+		&user.Password,
+		&user.Active,
+		&user.EmailVerified,
+		&user.CreatedAt,
+		&user.UpdatedAt)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			err = terr.NewNotFoundError("user not found")
+		}
+		return auth.User{}, err
+	}
+
+	return user, nil
+}
+
+// CreateUser persist a auth.User from the datastore
+func (r *userRepository) CreateUser(ctx context.Context, user *auth.User) error {
+	ctx, cancel := database.WithQueryTimeout(ctx, r.QueryTimeout)
+	defer cancel()
+
+	query := `
+		INSERT INTO users (
+			id,
+			first_name,
+			last_name,
+			email_address,
+			password,
+			active,
+			email_verified,
+			created_at,
+			updated_at
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`
+
+	_, err := database.Conn(ctx, r.DB).ExecContext(ctx, query,
+		user.ID,
+		user.FirstName,
+		user.LastName,
+		user.EmailAddress,
+		user.Password,
+		user.Active,
+		user.EmailVerified,
+		user.CreatedAt.UTC(),
+		user.UpdatedAt.UTC(),
+	)
+
+	if err != nil {
+		if isUniqueViolation(err) {
+			err = terr.NewDuplicateEntryError("user already exist")
+		}
+		return err
+	}
+
+	return nil
+}
+
+// UpdateUser updates a auth.User in the datastore
+func (r *userRepository) UpdateUser(ctx context.Context, user *auth.User) error {
+	ctx, cancel := database.WithQueryTimeout(ctx, r.QueryTimeout)
+	defer cancel()
+
+	query := `
+		UPDATE users
+		SET
+			first_name=?,
+			last_name=?,
+			email_address=?,
+			password=?,
+			active=?,
+			email_verified=?,
+			updated_at=?
+		WHERE id = ? AND deleted_at IS NULL
+	`
+
+	result, err := database.Conn(ctx, r.DB).ExecContext(ctx, query,
+		user.FirstName,
+		user.LastName,
+		user.EmailAddress,
+		user.Password,
+		user.Active,
+		user.EmailVerified,
+		user.UpdatedAt.UTC(),
+		user.ID,
+	)
+	if err != nil {
+		if isUniqueViolation(err) {
+			err = terr.NewDuplicateEntryError("user already exist")
+		}
+		return err
+	}
+
+	if affected, err := result.RowsAffected(); err == nil && affected == 0 {
+		return terr.NewNotFoundError("user not found")
+	}
+	return nil
+}
+
+// UpdateUserPassword updates the password hash of a auth.User in the datastore
+func (r *userRepository) UpdateUserPassword(ctx context.Context, id, password string, updatedAt time.Time) error {
+	ctx, cancel := database.WithQueryTimeout(ctx, r.QueryTimeout)
+	defer cancel()
+
+	query := `UPDATE users SET password=?, updated_at=? WHERE id = ? AND deleted_at IS NULL`
+
+	result, err := database.Conn(ctx, r.DB).ExecContext(ctx, query, password, updatedAt.UTC(), id)
+	if err != nil {
+		return err
+	}
+
+	if affected, err := result.RowsAffected(); err == nil && affected == 0 {
+		return terr.NewNotFoundError("user not found")
+	}
+	return nil
+}
+
+// UpdateUserActive activates or deactivates a auth.User in the datastore
+func (r *userRepository) UpdateUserActive(ctx context.Context, id string, active bool, updatedAt time.Time) error {
+	ctx, cancel := database.WithQueryTimeout(ctx, r.QueryTimeout)
+	defer cancel()
+
+	query := `UPDATE users SET active=?, updated_at=? WHERE id = ? AND deleted_at IS NULL`
+
+	result, err := database.Conn(ctx, r.DB).ExecContext(ctx, query, active, updatedAt.UTC(), id)
+	if err != nil {
+		return err
+	}
+
+	if affected, err := result.RowsAffected(); err == nil && affected == 0 {
+		return terr.NewNotFoundError("user not found")
+	}
+	return nil
+}
+
+// SoftDeleteUser deactivates a auth.User and flags it as deleted in the datastore, deleted users
+// are no longer found but their data is kept
+func (r *userRepository) SoftDeleteUser(ctx context.Context, id string, deletedAt time.Time) error {
+	ctx, cancel := database.WithQueryTimeout(ctx, r.QueryTimeout)
+	defer cancel()
+
+	query := `UPDATE users SET active=0, updated_at=?, deleted_at=? WHERE id = ? AND deleted_at IS NULL`
+
+	result, err := database.Conn(ctx, r.DB).ExecContext(ctx, query, deletedAt.UTC(), deletedAt.UTC(), id)
+	if err != nil {
+		return err
+	}
+
+	if affected, err := result.RowsAffected(); err == nil && affected == 0 {
+		return terr.NewNotFoundError("user not found")
+	}
+	return nil
+}
+
+// DeleteUser removes a auth.User from the datastore, soft deleted or not, its security tokens
+// and roles are removed by cascade
+func (r *userRepository) DeleteUser(ctx context.Context, id string) error {
+	ctx, cancel := database.WithQueryTimeout(ctx, r.QueryTimeout)
+	defer cancel()
+
+	query := `DELETE FROM users WHERE id = ?`
+
+	result, err := database.Conn(ctx, r.DB).ExecContext(ctx, query, id)
+	if err != nil {
+		return err
+	}
+
+	if affected, err := result.RowsAffected(); err == nil && affected == 0 {
+		return terr.NewNotFoundError("user not found")
+	}
+	return nil
+}
+
+// GetUserByID gets a non deleted auth.User by id in the datastore
+func (r *userRepository) GetUserByID(ctx context.Context, id string) (auth.User, error) {
+	ctx, cancel := database.WithQueryTimeout(ctx, r.QueryTimeout)
+	defer cancel()
+
+	query := `
+		SELECT
+			id,
+			first_name,
+			last_name,
+			email_address,
+			password,
+			active,
+			email_verified,
+			created_at,
+			updated_at
+		FROM users
+		WHERE id = ? AND deleted_at IS NULL LIMIT 1
+	`
+	row := database.Conn(ctx, r.DB).QueryRowContext(ctx, query, id)
+	return r.scanUserRow(row)
+}
+
+// GetUserByEmail gets a non deleted auth.User by email from the datastore
+func (r *userRepository) GetUserByEmail(ctx context.Context, email string) (auth.User, error) {
+	ctx, cancel := database.WithQueryTimeout(ctx, r.QueryTimeout)
+	defer cancel()
+
+	query := `
+		SELECT
+			id,
+			first_name,
+			last_name,
+			email_address,
+			password,
+			active,
+			email_verified,
+			created_at,
+			updated_at
+		FROM users
+		WHERE email_address = ? AND deleted_at IS NULL LIMIT 1
+	`
+	row := database.Conn(ctx, r.DB).QueryRowContext(ctx, query, email)
+	return r.scanUserRow(row)
+}
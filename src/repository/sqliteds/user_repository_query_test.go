@@ -0,0 +1,78 @@
+package sqliteds
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	_ "sherman/src/app/testing"
+	"sherman/src/app/utils/terr"
+	"sherman/src/domain/auth"
+	"testing"
+	"time"
+)
+
+func TestListUsers(t *testing.T) {
+	now := time.Now()
+
+	genUsers := func(t *testing.T, userRepo auth.UserRepository) {
+		assert.NoError(t, userRepo.CreateUser(context.Background(), genUser("id-1", "a_1@email.com", now)))
+		assert.NoError(t, userRepo.CreateUser(context.Background(), genUser("id-2", "ab1@email.com", now.Add(time.Second))))
+		assert.NoError(t, userRepo.CreateUser(context.Background(), genUser("id-3", "b@email.com", now.Add(2*time.Second))))
+		assert.NoError(t, userRepo.CreateUser(context.Background(), genUser("id-4", "c@email.com", now.Add(3*time.Second))))
+		assert.NoError(t, userRepo.SoftDeleteUser(context.Background(), "id-4", now))
+	}
+
+	t.Run("should return pages", func(t *testing.T) {
+		db := newTestDB(t)
+		defer db.Close()
+
+		userRepo := NewUserRepository(db, time.Second)
+		genUsers(t, userRepo)
+
+		page, err := userRepo.ListUsers(context.Background(), auth.UserQuery{SortKey: "created_at", SortDesc: true, Limit: 2})
+		if assert.NoError(t, err) {
+			assert.Equal(t, 3, page.Total)
+			assert.Equal(t, "id-3", page.Users[0].ID)
+			assert.Equal(t, "id-2", page.Users[1].ID)
+			assert.NotEmpty(t, page.NextCursor)
+		}
+
+		page, err = userRepo.ListUsers(context.Background(), auth.UserQuery{SortKey: "created_at", SortDesc: true, Limit: 2, Cursor: page.NextCursor})
+		if assert.NoError(t, err) {
+			assert.Len(t, page.Users, 1)
+			assert.Equal(t, "id-1", page.Users[0].ID)
+			assert.Empty(t, page.NextCursor)
+		}
+	})
+
+	t.Run("should filter users", func(t *testing.T) {
+		db := newTestDB(t)
+		defer db.Close()
+
+		userRepo := NewUserRepository(db, time.Second)
+		genUsers(t, userRepo)
+
+		page, err := userRepo.ListUsers(context.Background(), auth.UserQuery{
+			SortKey:     "email_address",
+			Limit:       10,
+			EmailPrefix: "a_",
+			CreatedFrom: now,
+			CreatedTo:   now.Add(time.Minute),
+		})
+
+		if assert.NoError(t, err) {
+			assert.Equal(t, 1, page.Total)
+			assert.Equal(t, "id-1", page.Users[0].ID)
+		}
+	})
+
+	t.Run("should return an invalid query error", func(t *testing.T) {
+		db := newTestDB(t)
+		defer db.Close()
+
+		userRepo := NewUserRepository(db, time.Second)
+
+		_, err := userRepo.ListUsers(context.Background(), auth.UserQuery{SortKey: "password", Limit: 10})
+
+		assert.Equal(t, terr.NewInvalidQueryError("invalid sort key"), err)
+	})
+}
@@ -0,0 +1,241 @@
+package sqliteds
+
+import (
+	"context"
+	"database/sql"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"path/filepath"
+	_ "sherman/src/app/testing"
+	"sherman/src/app/utils/terr"
+	"sherman/src/domain/auth"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newTestDB opens an in-memory sqlite database with the up sections of the sqlite migrations applied
+func newTestDB(t *testing.T) *sql.DB {
+	db, err := sql.Open("sqlite3", ":memory:?_foreign_keys=1")
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected", err)
+	}
+	// every connection to :memory: opens its own database
+	db.SetMaxOpenConns(1)
+
+	files, err := filepath.Glob("./src/app/database/migrations/sqlite3/*.sql")
+	if err != nil || len(files) == 0 {
+		t.Fatalf("sqlite migrations not found")
+	}
+	for _, file := range files {
+		migration, err := ioutil.ReadFile(file)
+		if err != nil {
+			t.Fatalf("an error '%s' was not expected", err)
+		}
+		up := strings.Split(string(migration), "-- +goose Down")[0]
+		if _, err := db.Exec(up); err != nil {
+			t.Fatalf("an error '%s' was not expected applying %s", err, file)
+		}
+	}
+
+	return db
+}
+
+func genUser(id, email string, createdAt time.Time) *auth.User {
+	return &auth.User{
+		ID:            id,
+		FirstName:     "first",
+		LastName:      "last",
+		EmailAddress:  email,
+		Password:      "some-password",
+		Active:        true,
+		EmailVerified: true,
+		CreatedAt:     createdAt,
+		UpdatedAt:     createdAt,
+	}
+}
+
+func TestCreateUser(t *testing.T) {
+	now := time.Now().UTC()
+
+	t.Run("should insert", func(t *testing.T) {
+		db := newTestDB(t)
+		defer db.Close()
+
+		userRepo := NewUserRepository(db, time.Second)
+		u := genUser("some-id", "some@email.com", now)
+
+		if assert.NoError(t, userRepo.CreateUser(context.Background(), u)) {
+			user, err := userRepo.GetUserByID(context.Background(), "some-id")
+			if assert.NoError(t, err) {
+				assert.Equal(t, *u, user)
+			}
+		}
+	})
+
+	t.Run("should return a duplicate entry error", func(t *testing.T) {
+		db := newTestDB(t)
+		defer db.Close()
+
+		userRepo := NewUserRepository(db, time.Second)
+		assert.NoError(t, userRepo.CreateUser(context.Background(), genUser("some-id", "some@email.com", now)))
+
+		err := userRepo.CreateUser(context.Background(), genUser("other-id", "some@email.com", now))
+
+		assert.Equal(t, terr.NewDuplicateEntryError("user already exist"), err)
+	})
+}
+
+func TestGetUserByEmail(t *testing.T) {
+	t.Run("should return a user", func(t *testing.T) {
+		db := newTestDB(t)
+		defer db.Close()
+
+		userRepo := NewUserRepository(db, time.Second)
+		assert.NoError(t, userRepo.CreateUser(context.Background(), genUser("some-id", "some@email.com", time.Now())))
+
+		user, err := userRepo.GetUserByEmail(context.Background(), "some@email.com")
+
+		if assert.NoError(t, err) {
+			assert.Equal(t, "some-id", user.ID)
+		}
+	})
+
+	t.Run("should return a not found error", func(t *testing.T) {
+		db := newTestDB(t)
+		defer db.Close()
+
+		userRepo := NewUserRepository(db, time.Second)
+
+		_, err := userRepo.GetUserByEmail(context.Background(), "some@email.com")
+
+		assert.Equal(t, terr.NewNotFoundError("user not found"), err)
+	})
+
+	t.Run("should return the error of a cancelled context", func(t *testing.T) {
+		db := newTestDB(t)
+		defer db.Close()
+
+		userRepo := NewUserRepository(db, time.Second)
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		_, err := userRepo.GetUserByEmail(ctx, "some@email.com")
+
+		assert.Equal(t, context.Canceled, err)
+	})
+}
+
+func TestUpdateUser(t *testing.T) {
+	t.Run("should update", func(t *testing.T) {
+		db := newTestDB(t)
+		defer db.Close()
+
+		userRepo := NewUserRepository(db, time.Second)
+		u := genUser("some-id", "some@email.com", time.Now().UTC())
+		assert.NoError(t, userRepo.CreateUser(context.Background(), u))
+
+		u.FirstName = "other"
+		u.EmailVerified = false
+		u.UpdatedAt = u.UpdatedAt.Add(time.Minute)
+
+		if assert.NoError(t, userRepo.UpdateUser(context.Background(), u)) {
+			user, _ := userRepo.GetUserByID(context.Background(), "some-id")
+			assert.Equal(t, *u, user)
+		}
+	})
+
+	t.Run("should return a not found error", func(t *testing.T) {
+		db := newTestDB(t)
+		defer db.Close()
+
+		userRepo := NewUserRepository(db, time.Second)
+
+		err := userRepo.UpdateUser(context.Background(), genUser("some-id", "some@email.com", time.Now()))
+
+		assert.Equal(t, terr.NewNotFoundError("user not found"), err)
+	})
+
+	t.Run("should return a duplicate entry error", func(t *testing.T) {
+		db := newTestDB(t)
+		defer db.Close()
+
+		userRepo := NewUserRepository(db, time.Second)
+		assert.NoError(t, userRepo.CreateUser(context.Background(), genUser("some-id", "some@email.com", time.Now())))
+		assert.NoError(t, userRepo.CreateUser(context.Background(), genUser("other-id", "other@email.com", time.Now())))
+
+		err := userRepo.UpdateUser(context.Background(), genUser("other-id", "some@email.com", time.Now()))
+
+		assert.Equal(t, terr.NewDuplicateEntryError("user already exist"), err)
+	})
+}
+
+func TestUpdateUserPasswordAndActive(t *testing.T) {
+	t.Run("should update", func(t *testing.T) {
+		db := newTestDB(t)
+		defer db.Close()
+
+		userRepo := NewUserRepository(db, time.Second)
+		assert.NoError(t, userRepo.CreateUser(context.Background(), genUser("some-id", "some@email.com", time.Now())))
+
+		assert.NoError(t, userRepo.UpdateUserPassword(context.Background(), "some-id", "other-password", time.Now()))
+		assert.NoError(t, userRepo.UpdateUserActive(context.Background(), "some-id", false, time.Now()))
+
+		user, _ := userRepo.GetUserByID(context.Background(), "some-id")
+		assert.Equal(t, "other-password", user.Password)
+		assert.False(t, user.Active)
+	})
+
+	t.Run("should return a not found error", func(t *testing.T) {
+		db := newTestDB(t)
+		defer db.Close()
+
+		userRepo := NewUserRepository(db, time.Second)
+
+		assert.Equal(t, terr.NewNotFoundError("user not found"), userRepo.UpdateUserPassword(context.Background(), "some-id", "other-password", time.Now()))
+		assert.Equal(t, terr.NewNotFoundError("user not found"), userRepo.UpdateUserActive(context.Background(), "some-id", false, time.Now()))
+	})
+}
+
+func TestSoftDeleteUser(t *testing.T) {
+	t.Run("should hide the user", func(t *testing.T) {
+		db := newTestDB(t)
+		defer db.Close()
+
+		userRepo := NewUserRepository(db, time.Second)
+		assert.NoError(t, userRepo.CreateUser(context.Background(), genUser("some-id", "some@email.com", time.Now())))
+
+		if assert.NoError(t, userRepo.SoftDeleteUser(context.Background(), "some-id", time.Now())) {
+			_, err := userRepo.GetUserByID(context.Background(), "some-id")
+			assert.Equal(t, terr.NewNotFoundError("user not found"), err)
+			assert.Equal(t, terr.NewNotFoundError("user not found"), userRepo.SoftDeleteUser(context.Background(), "some-id", time.Now()))
+		}
+	})
+}
+
+func TestDeleteUser(t *testing.T) {
+	t.Run("should delete the user and its tokens", func(t *testing.T) {
+		db := newTestDB(t)
+		defer db.Close()
+
+		userRepo := NewUserRepository(db, time.Second)
+		securityTokenRepo := NewSecurityTokenRepository(db, time.Second)
+		assert.NoError(t, userRepo.CreateUser(context.Background(), genUser("some-id", "some@email.com", time.Now())))
+		assert.NoError(t, securityTokenRepo.CreateToken(context.Background(), genToken("some-token-id", "some-id", "some-token")))
+
+		if assert.NoError(t, userRepo.DeleteUser(context.Background(), "some-id")) {
+			tokens, err := securityTokenRepo.GetTokensByUserID(context.Background(), "some-id", auth.RefreshTokenType)
+			assert.NoError(t, err)
+			assert.Empty(t, tokens)
+		}
+	})
+
+	t.Run("should return a not found error", func(t *testing.T) {
+		db := newTestDB(t)
+		defer db.Close()
+
+		userRepo := NewUserRepository(db, time.Second)
+
+		assert.Equal(t, terr.NewNotFoundError("user not found"), userRepo.DeleteUser(context.Background(), "some-id"))
+	})
+}
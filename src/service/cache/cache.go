@@ -0,0 +1,81 @@
+package cache
+
+import (
+	"sync"
+	"time"
+)
+
+// sweepInterval min interval between sweeps of expired items
+const sweepInterval = time.Minute
+
+type (
+	// Cache cache.Cache interface definition
+	Cache interface {
+		Get(key string) (interface{}, bool)
+		Set(key string, value interface{}, ttl time.Duration)
+		Delete(key string)
+	}
+
+	item struct {
+		value     interface{}
+		expiresAt time.Time
+	}
+
+	service struct {
+		mu        sync.RWMutex
+		items     map[string]item
+		lastSweep time.Time
+	}
+)
+
+// New returns an in memory instance of cache.Cache
+func New() Cache {
+	return &service{
+		items:     make(map[string]item),
+		lastSweep: time.Now(),
+	}
+}
+
+// Get gets the value of a key, expired keys are reported as missing
+func (s *service) Get(key string) (interface{}, bool) {
+	s.mu.RLock()
+	it, ok := s.items[key]
+	s.mu.RUnlock()
+
+	if !ok || !time.Now().Before(it.expiresAt) {
+		return nil, false
+	}
+	return it.value, true
+}
+
+// Set sets the value of a key for the duration of ttl, a non positive ttl is a no-op
+func (s *service) Set(key string, value interface{}, ttl time.Duration) {
+	if ttl <= 0 {
+		return
+	}
+
+	now := time.Now()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if now.Sub(s.lastSweep) >= sweepInterval {
+		for k, it := range s.items {
+			if !now.Before(it.expiresAt) {
+				delete(s.items, k)
+			}
+		}
+		s.lastSweep = now
+	}
+
+	s.items[key] = item{
+		value:     value,
+		expiresAt: now.Add(ttl),
+	}
+}
+
+// Delete removes a key
+func (s *service) Delete(key string) {
+	s.mu.Lock()
+	delete(s.items, key)
+	s.mu.Unlock()
+}
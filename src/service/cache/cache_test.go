@@ -0,0 +1,80 @@
+package cache
+
+import (
+	"github.com/stretchr/testify/assert"
+	_ "sherman/src/app/testing"
+	"testing"
+	"time"
+)
+
+func TestGet(t *testing.T) {
+	t.Run("it should succeed", func(t *testing.T) {
+		cs := New()
+		cs.Set("some-key", "some-value", time.Minute)
+
+		value, ok := cs.Get("some-key")
+		if assert.True(t, ok) {
+			assert.Equal(t, "some-value", value)
+		}
+	})
+
+	t.Run("it should not find a missing key", func(t *testing.T) {
+		cs := New()
+
+		value, ok := cs.Get("some-key")
+		assert.False(t, ok)
+		assert.Nil(t, value)
+	})
+
+	t.Run("it should not find an expired key", func(t *testing.T) {
+		cs := New()
+		cs.Set("some-key", "some-value", time.Millisecond)
+		time.Sleep(time.Millisecond * time.Duration(5))
+
+		value, ok := cs.Get("some-key")
+		assert.False(t, ok)
+		assert.Nil(t, value)
+	})
+}
+
+func TestSet(t *testing.T) {
+	t.Run("it should overwrite a key", func(t *testing.T) {
+		cs := New()
+		cs.Set("some-key", "some-value", time.Minute)
+		cs.Set("some-key", "some-other-value", time.Minute)
+
+		value, ok := cs.Get("some-key")
+		if assert.True(t, ok) {
+			assert.Equal(t, "some-other-value", value)
+		}
+	})
+
+	t.Run("it should ignore a non positive ttl", func(t *testing.T) {
+		cs := New()
+		cs.Set("some-key", "some-value", 0)
+
+		_, ok := cs.Get("some-key")
+		assert.False(t, ok)
+	})
+
+	t.Run("it should sweep expired keys", func(t *testing.T) {
+		cs := New().(*service)
+		cs.Set("some-expired-key", "some-value", time.Millisecond)
+		time.Sleep(time.Millisecond * time.Duration(5))
+		cs.lastSweep = time.Now().Add(-sweepInterval)
+		cs.Set("some-key", "some-value", time.Minute)
+
+		_, ok := cs.items["some-expired-key"]
+		assert.False(t, ok)
+		assert.Len(t, cs.items, 1)
+	})
+}
+
+func TestDelete(t *testing.T) {
+	cs := New()
+	cs.Set("some-key", "some-value", time.Minute)
+	cs.Delete("some-key")
+
+	_, ok := cs.Get("some-key")
+	assert.False(t, ok)
+}
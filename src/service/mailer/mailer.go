@@ -0,0 +1,32 @@
+package mailer
+
+import (
+	"github.com/rs/zerolog/log"
+	"sherman/src/app/config"
+)
+
+type (
+	// Mailer mailer.Mailer interface definition
+	Mailer interface {
+		Send(message *Message) error
+	}
+
+	// Message plain text email
+	Message struct {
+		To      string
+		Subject string
+		Body    string
+	}
+)
+
+// New returns the mailer.Mailer of the configured MAIL_DRIVER, unknown drivers fall back to the log mailer
+func New(cfg *config.GlobalConfig) Mailer {
+	switch cfg.Mail.Driver {
+	case "smtp":
+		return newSMTPMailer(&cfg.Mail)
+	case "log":
+	default:
+		log.Error().Msg("mailer error: MAIL_DRIVER " + cfg.Mail.Driver + " not supported, using log")
+	}
+	return newLogMailer(&cfg.Mail)
+}
@@ -0,0 +1,31 @@
+package mailer
+
+import (
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+	"sherman/src/app/config"
+)
+
+// logMailer mailer.Mailer that writes messages to the application log instead of delivering them, meant for development
+type logMailer struct {
+	from   string
+	logger zerolog.Logger
+}
+
+func newLogMailer(cfg *config.MailConfig) Mailer {
+	return &logMailer{
+		from:   cfg.From,
+		logger: log.Logger,
+	}
+}
+
+// Send logs the message
+func (m *logMailer) Send(message *Message) error {
+	m.logger.Info().
+		Str("from", m.from).
+		Str("to", message.To).
+		Str("subject", message.Subject).
+		Str("body", message.Body).
+		Msg("mail")
+	return nil
+}
@@ -0,0 +1,58 @@
+package mailer
+
+import (
+	"bytes"
+	"fmt"
+	"net"
+	"net/smtp"
+	"sherman/src/app/config"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// smtpMailer mailer.Mailer that delivers messages thru an SMTP relay, STARTTLS is used when offered
+type smtpMailer struct {
+	addr     string
+	from     string
+	auth     smtp.Auth
+	sendMail func(addr string, a smtp.Auth, from string, to []string, msg []byte) error
+}
+
+func newSMTPMailer(cfg *config.MailConfig) Mailer {
+	var auth smtp.Auth
+	if cfg.User != "" {
+		auth = smtp.PlainAuth("", cfg.User, cfg.Pass, cfg.Host)
+	}
+
+	return &smtpMailer{
+		addr:     net.JoinHostPort(cfg.Host, strconv.Itoa(cfg.Port)),
+		from:     cfg.From,
+		auth:     auth,
+		sendMail: smtp.SendMail,
+	}
+}
+
+// Send delivers the message
+func (m *smtpMailer) Send(message *Message) error {
+	if err := m.sendMail(m.addr, m.auth, m.from, []string{message.To}, m.build(message)); err != nil {
+		return fmt.Errorf("could not send mail: %s", err.Error())
+	}
+	return nil
+}
+
+// build builds the RFC 5322 message, header values are stripped of line breaks to prevent header injection
+func (m *smtpMailer) build(message *Message) []byte {
+	clean := strings.NewReplacer("\r", "", "\n", "")
+
+	var buf bytes.Buffer
+	buf.WriteString("From: " + clean.Replace(m.from) + "\r\n")
+	buf.WriteString("To: " + clean.Replace(message.To) + "\r\n")
+	buf.WriteString("Subject: " + clean.Replace(message.Subject) + "\r\n")
+	buf.WriteString("Date: " + time.Now().Format(time.RFC1123Z) + "\r\n")
+	buf.WriteString("MIME-Version: 1.0\r\n")
+	buf.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+	buf.WriteString("\r\n")
+	buf.WriteString(strings.Replace(message.Body, "\n", "\r\n", -1))
+	return buf.Bytes()
+}
@@ -0,0 +1,96 @@
+package mailer
+
+import (
+	"bytes"
+	"errors"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"net/smtp"
+	"sherman/src/app/config"
+	_ "sherman/src/app/testing"
+	"strings"
+	"testing"
+)
+
+func TestNew(t *testing.T) {
+	t.Run("it should return a log mailer", func(t *testing.T) {
+		cfg := *config.Get()
+		cfg.Mail.Driver = "log"
+
+		_, ok := New(&cfg).(*logMailer)
+		assert.True(t, ok)
+	})
+
+	t.Run("it should return a smtp mailer", func(t *testing.T) {
+		cfg := *config.Get()
+		cfg.Mail = config.MailConfig{Driver: "smtp", Host: "smtp.some-host.com", Port: 587, User: "some-user"}
+
+		ms, ok := New(&cfg).(*smtpMailer)
+		if assert.True(t, ok) {
+			assert.Equal(t, "smtp.some-host.com:587", ms.addr)
+			assert.NotNil(t, ms.auth)
+		}
+	})
+
+	t.Run("it should fall back to the log mailer", func(t *testing.T) {
+		cfg := *config.Get()
+		cfg.Mail.Driver = "some-driver"
+
+		_, ok := New(&cfg).(*logMailer)
+		assert.True(t, ok)
+	})
+}
+
+func TestLogMailerSend(t *testing.T) {
+	var buf bytes.Buffer
+	ms := &logMailer{from: "no-reply@some-host.com", logger: zerolog.New(&buf)}
+
+	err := ms.Send(&Message{To: "some@email.com", Subject: "some subject", Body: "some body"})
+
+	if assert.NoError(t, err) {
+		assert.Contains(t, buf.String(), "\"to\":\"some@email.com\"")
+		assert.Contains(t, buf.String(), "\"subject\":\"some subject\"")
+		assert.Contains(t, buf.String(), "\"body\":\"some body\"")
+	}
+}
+
+func TestSMTPMailerSend(t *testing.T) {
+	mockMessage := &Message{To: "some@email.com", Subject: "some subject\r\nBcc: other@email.com", Body: "line 1\nline 2"}
+
+	t.Run("it should succeed", func(t *testing.T) {
+		var sentTo []string
+		var sentMsg []byte
+		ms := &smtpMailer{
+			addr: "smtp.some-host.com:587",
+			from: "no-reply@some-host.com",
+			sendMail: func(addr string, a smtp.Auth, from string, to []string, msg []byte) error {
+				sentTo = to
+				sentMsg = msg
+				return nil
+			},
+		}
+
+		err := ms.Send(mockMessage)
+
+		if assert.NoError(t, err) {
+			assert.Equal(t, []string{"some@email.com"}, sentTo)
+			assert.Contains(t, string(sentMsg), "Subject: some subjectBcc: other@email.com\r\n")
+			assert.False(t, strings.Contains(string(sentMsg), "\r\nBcc:"))
+			assert.True(t, strings.HasSuffix(string(sentMsg), "\r\n\r\nline 1\r\nline 2"))
+		}
+	})
+
+	t.Run("it should return error", func(t *testing.T) {
+		ms := &smtpMailer{
+			sendMail: func(addr string, a smtp.Auth, from string, to []string, msg []byte) error {
+				return errors.New("some error")
+			},
+		}
+
+		err := ms.Send(mockMessage)
+
+		if assert.Error(t, err) {
+			assert.Equal(t, "could not send mail: some error", err.Error())
+		}
+	})
+}
@@ -0,0 +1,55 @@
+package config
+
+import (
+	"github.com/labstack/echo/v4"
+	emw "github.com/labstack/echo/v4/middleware"
+	"sherman/src/service/ratelimit"
+	"time"
+)
+
+type (
+	// RateLimitKeyExtractor extracts the key a request is counted under, requests sharing a key share a limit.
+	RateLimitKeyExtractor func(ctx echo.Context) (string, error)
+
+	// RateLimitConfig defines the config for RateLimit middleware.
+	RateLimitConfig struct {
+		// Name namespaces the counters of the limiter, limiters sharing a name share their counters.
+		Name string
+		// Limit max number of requests of a key per Window, the application config when 0.
+		Limit int
+		// Window duration of the sliding window, the application config when 0.
+		Window time.Duration
+		// KeyExtractor extracts the key of a request, KeyByIP when nil.
+		KeyExtractor RateLimitKeyExtractor
+		// Store keeps the counters, the application store when nil.
+		Store ratelimit.Store
+		// Skipper defines a function to skip middleware.
+		Skipper emw.Skipper
+	}
+)
+
+// KeyByIP counts the requests per client IP address, the one the IPExtractor of the echo instance trusts,
+// the address of the peer without IPExtractor so that a client can't change its key with a forged header.
+func KeyByIP(ctx echo.Context) (string, error) {
+	extractIP := ctx.Echo().IPExtractor
+	if extractIP == nil {
+		extractIP = echo.ExtractIPDirect()
+	}
+	return "ip:" + extractIP(ctx.Request()), nil
+}
+
+// DefaultRateLimitConfig is the default RateLimit middleware config.
+var DefaultRateLimitConfig = RateLimitConfig{
+	Name:         "default",
+	KeyExtractor: KeyByIP,
+	Skipper:      emw.DefaultSkipper,
+}
+
+// EmailRateLimitConfig is the RateLimit middleware config of the routes sending emails.
+var EmailRateLimitConfig = RateLimitConfig{
+	Name:         "email",
+	Limit:        5,
+	Window:       15 * time.Minute,
+	KeyExtractor: KeyByIP,
+	Skipper:      emw.DefaultSkipper,
+}
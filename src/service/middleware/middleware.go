@@ -3,7 +3,9 @@ package middleware
 import (
 	"github.com/labstack/echo/v4"
 	"sherman/src/app/config"
+	"sherman/src/domain/auth"
 	cmc "sherman/src/service/middleware/config"
+	"sherman/src/service/ratelimit"
 	"sherman/src/service/security"
 )
 
@@ -11,20 +13,43 @@ type (
 	// Middleware middleware.Middleware interface definition
 	Middleware interface {
 		JWT() echo.MiddlewareFunc
+		APIKey() echo.MiddlewareFunc
+		RequireRole(roles ...string) echo.MiddlewareFunc
+		RequirePermission(permission string) echo.MiddlewareFunc
+		RequireScope(scope string) echo.MiddlewareFunc
+		RateLimit(cfg *cmc.RateLimitConfig) echo.MiddlewareFunc
 		ZeroLog() echo.MiddlewareFunc
 		ZeroLogWithConfig(cfg *cmc.ZeroLogConfig) echo.MiddlewareFunc
 	}
 
 	service struct {
-		config          *config.GlobalConfig
-		securityService security.Security
+		config               *config.GlobalConfig
+		securityService      security.Security
+		securityTokenUseCase auth.SecurityTokenUseCase
+		roleUseCase          auth.RoleUseCase
+		userUseCase          auth.UserUseCase
+		apiKeyUseCase        auth.APIKeyUseCase
+		rateLimitStore       ratelimit.Store
 	}
 )
 
 // New returns an instance of middleware.Middleware
-func New(cfg *config.GlobalConfig, ss security.Security) Middleware {
+func New(
+	cfg *config.GlobalConfig,
+	ss security.Security,
+	stuc auth.SecurityTokenUseCase,
+	ruc auth.RoleUseCase,
+	uuc auth.UserUseCase,
+	akuc auth.APIKeyUseCase,
+	rls ratelimit.Store,
+) Middleware {
 	return &service{
-		config:          cfg,
-		securityService: ss,
+		config:               cfg,
+		securityService:      ss,
+		securityTokenUseCase: stuc,
+		roleUseCase:          ruc,
+		userUseCase:          uuc,
+		apiKeyUseCase:        akuc,
+		rateLimitStore:       rls,
 	}
 }
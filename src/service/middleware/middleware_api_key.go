@@ -0,0 +1,62 @@
+package middleware
+
+import (
+	"github.com/labstack/echo/v4"
+	"net/http"
+	"sherman/src/app/utils/response"
+	"sherman/src/app/utils/terr"
+	"strings"
+)
+
+// apiKeyHeader header carrying an API key
+const apiKeyHeader = "X-API-Key"
+
+// APIKey returns echo.MiddlewareFunc middleware to handle API key auth, keys are sent in the X-API-Key
+// header or as an "Authorization: ApiKey <key>" credential, the principal of accepted requests is set
+// like JWT sets it, requests without API key are left to the next middleware which must be JWT
+func (s *service) APIKey() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(ctx echo.Context) error {
+			key, ok := getAPIKey(ctx)
+			if !ok {
+				return next(ctx)
+			}
+
+			principal, err := s.apiKeyUseCase.Authenticate(ctx.Request().Context(), key)
+			if err != nil {
+				if _, ok := err.(*terr.UnAuthorizedError); ok {
+					return apiKeyUnauthorized(ctx, err.Error())
+				}
+				res := response.NewResponse()
+				res.SetInternalServerError()
+				return ctx.JSON(res.GetStatus(), res.GetBody())
+			}
+
+			if !s.userUseCase.IsUserActive(ctx.Request().Context(), principal.UserID) {
+				return apiKeyUnauthorized(ctx, "user account deactivated")
+			}
+
+			SetPrincipal(ctx, principal)
+			return next(ctx)
+		}
+	}
+}
+
+// getAPIKey gets the API key of the X-API-Key header or of the ApiKey authorization scheme
+func getAPIKey(ctx echo.Context) (string, bool) {
+	if key := ctx.Request().Header.Get(apiKeyHeader); key != "" {
+		return key, true
+	}
+
+	authorization := strings.SplitN(ctx.Request().Header.Get(echo.HeaderAuthorization), " ", 2)
+	if len(authorization) == 2 && strings.EqualFold(authorization[0], "ApiKey") && authorization[1] != "" {
+		return authorization[1], true
+	}
+	return "", false
+}
+
+// apiKeyUnauthorized responds 401 with an ApiKey WWW-Authenticate challenge
+func apiKeyUnauthorized(ctx echo.Context, message string) error {
+	ctx.Response().Header().Set(echo.HeaderWWWAuthenticate, "ApiKey")
+	return respondError(ctx, http.StatusUnauthorized, message)
+}
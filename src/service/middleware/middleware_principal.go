@@ -0,0 +1,21 @@
+package middleware
+
+import (
+	"github.com/labstack/echo/v4"
+	"sherman/src/domain/auth"
+)
+
+// principalKey echo.Context key of the authenticated principal
+const principalKey = "principal"
+
+// SetPrincipal stores the access token metadata of the authenticated user in echo.Context
+func SetPrincipal(ctx echo.Context, principal auth.TokenMetadata) {
+	ctx.Set(principalKey, principal)
+}
+
+// GetPrincipal gets the access token metadata of the authenticated user from echo.Context,
+// ok is false when the request went thru no JWT middleware
+func GetPrincipal(ctx echo.Context) (principal auth.TokenMetadata, ok bool) {
+	principal, ok = ctx.Get(principalKey).(auth.TokenMetadata)
+	return principal, ok
+}
@@ -0,0 +1,82 @@
+package middleware
+
+import (
+	"github.com/labstack/echo/v4"
+	"github.com/rs/zerolog/log"
+	"math"
+	"net/http"
+	"sherman/src/app/utils/response"
+	cmc "sherman/src/service/middleware/config"
+	"sherman/src/service/ratelimit"
+	"strconv"
+	"time"
+)
+
+// RateLimit returns a middleware that limits the requests of a key per sliding window, the limit state
+// is sent in the X-RateLimit-Limit, X-RateLimit-Remaining and X-RateLimit-Reset (seconds) headers and
+// requests over the limit are rejected with 429, a failing key extractor or store lets the request thru
+func (s *service) RateLimit(cfg *cmc.RateLimitConfig) echo.MiddlewareFunc {
+	// defaults, cfg is copied as it may be shared by several routes
+	c := *cfg
+	if c.Name == "" {
+		c.Name = cmc.DefaultRateLimitConfig.Name
+	}
+	if c.Limit <= 0 {
+		c.Limit = s.config.RateLimit.Requests
+	}
+	if c.Window <= 0 {
+		c.Window = time.Duration(s.config.RateLimit.Window) * time.Second
+	}
+	if c.KeyExtractor == nil {
+		c.KeyExtractor = cmc.DefaultRateLimitConfig.KeyExtractor
+	}
+	if c.Store == nil {
+		c.Store = s.rateLimitStore
+	}
+	if c.Skipper == nil {
+		c.Skipper = cmc.DefaultRateLimitConfig.Skipper
+	}
+
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(ctx echo.Context) error {
+			if c.Skipper(ctx) {
+				return next(ctx)
+			}
+
+			key, err := c.KeyExtractor(ctx)
+			if err != nil {
+				log.Error().Msg(err.Error())
+				return next(ctx)
+			}
+
+			result, err := ratelimit.Allow(c.Store, "rate-limit:"+c.Name+":"+key, c.Limit, c.Window, time.Now())
+			if err != nil {
+				log.Error().Msg(err.Error())
+				return next(ctx)
+			}
+
+			reset := strconv.Itoa(int(math.Ceil(result.Reset.Seconds())))
+			header := ctx.Response().Header()
+			header.Set("X-RateLimit-Limit", strconv.Itoa(result.Limit))
+			header.Set("X-RateLimit-Remaining", strconv.Itoa(result.Remaining))
+			header.Set("X-RateLimit-Reset", reset)
+
+			if !result.Allowed {
+				header.Set("Retry-After", reset)
+				res := response.NewResponse()
+				res.SetError(http.StatusTooManyRequests, "too many requests")
+				return ctx.JSON(res.GetStatus(), res.GetBody())
+			}
+			return next(ctx)
+		}
+	}
+}
+
+// KeyByUserID counts the requests per authenticated user, it must run after the JWT or APIKey middleware
+// of a route since the principal is unknown before them, requests without principal are counted per client IP address
+func KeyByUserID(ctx echo.Context) (string, error) {
+	if principal, ok := GetPrincipal(ctx); ok {
+		return "user:" + principal.UserID, nil
+	}
+	return cmc.KeyByIP(ctx)
+}
@@ -0,0 +1,82 @@
+package middleware
+
+import (
+	"fmt"
+	"github.com/labstack/echo/v4"
+	"net/http"
+	"sherman/src/app/utils/response"
+)
+
+// RequireRole returns echo.MiddlewareFunc middleware allowing requests whose principal has any of roles,
+// it must be chained after JWT
+func (s *service) RequireRole(roles ...string) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(ctx echo.Context) error {
+			principal, ok := GetPrincipal(ctx)
+			if !ok {
+				return respondError(ctx, http.StatusUnauthorized, "invalid token")
+			}
+
+			for _, role := range roles {
+				if principal.HasRole(role) {
+					return next(ctx)
+				}
+			}
+			return respondError(ctx, http.StatusForbidden, "forbidden")
+		}
+	}
+}
+
+// RequirePermission returns echo.MiddlewareFunc middleware allowing requests whose principal roles
+// grant permission, it must be chained after JWT
+func (s *service) RequirePermission(permission string) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(ctx echo.Context) error {
+			principal, ok := GetPrincipal(ctx)
+			if !ok {
+				return respondError(ctx, http.StatusUnauthorized, "invalid token")
+			}
+
+			allowed, err := s.roleUseCase.HasPermission(ctx.Request().Context(), principal.Roles, permission)
+			if err != nil {
+				res := response.NewResponse()
+				res.SetInternalServerError()
+				return ctx.JSON(res.GetStatus(), res.GetBody())
+			}
+			if !allowed {
+				return respondError(ctx, http.StatusForbidden, "forbidden")
+			}
+			return next(ctx)
+		}
+	}
+}
+
+// RequireScope returns echo.MiddlewareFunc middleware allowing requests whose access token grants scope,
+// first party tokens grant every scope, it must be chained after JWT, insufficient scopes get a RFC 6750
+// WWW-Authenticate challenge
+func (s *service) RequireScope(scope string) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(ctx echo.Context) error {
+			principal, ok := GetPrincipal(ctx)
+			if !ok {
+				return respondError(ctx, http.StatusUnauthorized, "invalid token")
+			}
+
+			if !principal.HasScope(scope) {
+				ctx.Response().Header().Set(
+					echo.HeaderWWWAuthenticate,
+					fmt.Sprintf(`Bearer error="insufficient_scope", scope="%s"`, scope),
+				)
+				return respondError(ctx, http.StatusForbidden, "insufficient scope")
+			}
+			return next(ctx)
+		}
+	}
+}
+
+// respondError responds with an error message
+func respondError(ctx echo.Context, status int, message string) error {
+	res := response.NewResponse()
+	res.SetError(status, message)
+	return ctx.JSON(res.GetStatus(), res.GetBody())
+}
@@ -14,35 +14,67 @@ import (
 	"sherman/mocks"
 	cfg "sherman/src/app/config"
 	_ "sherman/src/app/testing"
+	"sherman/src/app/utils/terr"
 	"sherman/src/domain/auth"
 	cmc "sherman/src/service/middleware/config"
+	"sherman/src/service/ratelimit"
+	"strconv"
 	"strings"
 	"testing"
+	"time"
 )
 
 type middlewareMockDeps struct {
-	config          *cfg.GlobalConfig
-	securityService *mocks.Security
+	config               *cfg.GlobalConfig
+	securityService      *mocks.Security
+	securityTokenUseCase *mocks.SecurityTokenUseCase
+	roleUseCase          *mocks.RoleUseCase
+	userUseCase          *mocks.UserUseCase
+	apiKeyUseCase        *mocks.APIKeyUseCase
+	rateLimitStore       ratelimit.Store
 }
 
 func genMockMiddleware() (Middleware, middlewareMockDeps) {
 	mDeps := middlewareMockDeps{
-		config:          cfg.Get(),
-		securityService: new(mocks.Security),
+		config:               cfg.Get(),
+		securityService:      new(mocks.Security),
+		securityTokenUseCase: new(mocks.SecurityTokenUseCase),
+		roleUseCase:          new(mocks.RoleUseCase),
+		userUseCase:          new(mocks.UserUseCase),
+		apiKeyUseCase:        new(mocks.APIKeyUseCase),
+		rateLimitStore:       ratelimit.NewMemoryStore(),
 	}
-	m := New(mDeps.config, mDeps.securityService)
+	m := New(
+		mDeps.config,
+		mDeps.securityService,
+		mDeps.securityTokenUseCase,
+		mDeps.roleUseCase,
+		mDeps.userUseCase,
+		mDeps.apiKeyUseCase,
+		mDeps.rateLimitStore,
+	)
 	return m, mDeps
 }
 
 func TestJWT(t *testing.T) {
 	t.Run("request should go thru", func(t *testing.T) {
 		m, mDeps := genMockMiddleware()
+		mockTokenMeta := auth.TokenMetadata{ID: "some-token-id", UserID: "some-user-id"}
 		mDeps.securityService.
 			On("GetAndValidateAccessToken", mock.Anything).
-			Return(auth.TokenMetadata{}, nil)
+			Return(mockTokenMeta, nil)
+		mDeps.securityTokenUseCase.
+			On("IsAccessTokenRevoked", mock.Anything, mock.Anything).
+			Return(false)
+		mDeps.userUseCase.
+			On("IsUserActive", mock.Anything, "some-user-id").
+			Return(true)
 
 		e := echo.New()
 		handler := func(c echo.Context) error {
+			principal, ok := GetPrincipal(c)
+			assert.True(t, ok)
+			assert.Equal(t, mockTokenMeta, principal)
 			return c.String(http.StatusOK, "test")
 		}
 		h := m.JWT()(handler)
@@ -71,9 +103,577 @@ func TestJWT(t *testing.T) {
 		ctx := e.NewContext(req, rec)
 		if assert.NoError(t, h(ctx)) {
 			assert.Equal(t, http.StatusUnauthorized, rec.Code)
+			assert.Equal(t, "Bearer", rec.Header().Get("WWW-Authenticate"))
 			assert.Equal(t, "{\"data\":null,\"error\":\"invalid token\"}\n", rec.Body.String())
 		}
 	})
+
+	t.Run("request with an expired token should not go thru", func(t *testing.T) {
+		m, mDeps := genMockMiddleware()
+		mDeps.securityService.
+			On("GetAndValidateAccessToken", mock.Anything).
+			Return(auth.TokenMetadata{}, terr.NewExpiredTokenError("token is expired"))
+
+		e := echo.New()
+		handler := func(c echo.Context) error {
+			return c.String(http.StatusOK, "test")
+		}
+		h := m.JWT()(handler)
+		req := httptest.NewRequest(echo.GET, "/", nil)
+		req.Header.Set("Authorization", "Bearer some-token")
+		rec := httptest.NewRecorder()
+		ctx := e.NewContext(req, rec)
+		if assert.NoError(t, h(ctx)) {
+			assert.Equal(t, http.StatusUnauthorized, rec.Code)
+			assert.Equal(
+				t,
+				"Bearer error=\"invalid_token\", error_description=\"token is expired\"",
+				rec.Header().Get("WWW-Authenticate"),
+			)
+			assert.Equal(t, "{\"data\":null,\"error\":\"token is expired\"}\n", rec.Body.String())
+		}
+	})
+
+	t.Run("request with a refresh token should not go thru", func(t *testing.T) {
+		m, mDeps := genMockMiddleware()
+		mDeps.securityService.
+			On("GetAndValidateAccessToken", mock.Anything).
+			Return(auth.TokenMetadata{}, terr.NewTokenTypeError("invalid token type"))
+
+		e := echo.New()
+		handler := func(c echo.Context) error {
+			return c.String(http.StatusOK, "test")
+		}
+		h := m.JWT()(handler)
+		req := httptest.NewRequest(echo.GET, "/", nil)
+		req.Header.Set("Authorization", "Bearer some-token")
+		rec := httptest.NewRecorder()
+		ctx := e.NewContext(req, rec)
+		if assert.NoError(t, h(ctx)) {
+			assert.Equal(t, http.StatusUnauthorized, rec.Code)
+			assert.Equal(
+				t,
+				"Bearer error=\"invalid_token\", error_description=\"invalid token type\"",
+				rec.Header().Get("WWW-Authenticate"),
+			)
+			assert.Equal(t, "{\"data\":null,\"error\":\"invalid token type\"}\n", rec.Body.String())
+		}
+	})
+
+	t.Run("request with a revoked token should not go thru", func(t *testing.T) {
+		m, mDeps := genMockMiddleware()
+		mDeps.securityService.
+			On("GetAndValidateAccessToken", mock.Anything).
+			Return(auth.TokenMetadata{ID: "some-token-id"}, nil)
+		mDeps.securityTokenUseCase.
+			On("IsAccessTokenRevoked", mock.Anything, &auth.TokenMetadata{ID: "some-token-id"}).
+			Return(true)
+
+		e := echo.New()
+		handler := func(c echo.Context) error {
+			return c.String(http.StatusOK, "test")
+		}
+		h := m.JWT()(handler)
+		req := httptest.NewRequest(echo.GET, "/", nil)
+		req.Header.Set("Authorization", "Bearer some-token")
+		rec := httptest.NewRecorder()
+		ctx := e.NewContext(req, rec)
+		if assert.NoError(t, h(ctx)) {
+			assert.Equal(t, http.StatusUnauthorized, rec.Code)
+			assert.Equal(
+				t,
+				"Bearer error=\"invalid_token\", error_description=\"token has been revoked\"",
+				rec.Header().Get("WWW-Authenticate"),
+			)
+			assert.Equal(t, "{\"data\":null,\"error\":\"token has been revoked\"}\n", rec.Body.String())
+		}
+	})
+
+	t.Run("request of a deactivated user should not go thru", func(t *testing.T) {
+		m, mDeps := genMockMiddleware()
+		mDeps.securityService.
+			On("GetAndValidateAccessToken", mock.Anything).
+			Return(auth.TokenMetadata{ID: "some-token-id", UserID: "some-user-id"}, nil)
+		mDeps.securityTokenUseCase.
+			On("IsAccessTokenRevoked", mock.Anything, mock.Anything).
+			Return(false)
+		mDeps.userUseCase.
+			On("IsUserActive", mock.Anything, "some-user-id").
+			Return(false)
+
+		e := echo.New()
+		handler := func(c echo.Context) error {
+			return c.String(http.StatusOK, "test")
+		}
+		h := m.JWT()(handler)
+		req := httptest.NewRequest(echo.GET, "/", nil)
+		req.Header.Set("Authorization", "Bearer some-token")
+		rec := httptest.NewRecorder()
+		ctx := e.NewContext(req, rec)
+		if assert.NoError(t, h(ctx)) {
+			assert.Equal(t, http.StatusUnauthorized, rec.Code)
+			assert.Equal(t, "{\"data\":null,\"error\":\"user account deactivated\"}\n", rec.Body.String())
+		}
+	})
+}
+
+func TestAPIKey(t *testing.T) {
+	handler := func(c echo.Context) error {
+		principal, _ := GetPrincipal(c)
+		return c.String(http.StatusOK, principal.UserID)
+	}
+	mockPrincipal := auth.TokenMetadata{
+		ID:     "some-key-id",
+		UserID: "some-user-id",
+		Type:   auth.APIKeyTokenType,
+		Scopes: []string{auth.ProfileScope},
+	}
+
+	for _, header := range []struct{ name, value string }{
+		{"X-API-Key", "shm_some-key"},
+		{echo.HeaderAuthorization, "ApiKey shm_some-key"},
+	} {
+		t.Run("request with "+header.name+" should go thru", func(t *testing.T) {
+			m, mDeps := genMockMiddleware()
+			mDeps.apiKeyUseCase.On("Authenticate", mock.Anything, "shm_some-key").Return(mockPrincipal, nil)
+			mDeps.userUseCase.On("IsUserActive", mock.Anything, "some-user-id").Return(true)
+
+			e := echo.New()
+			req := httptest.NewRequest(echo.GET, "/", nil)
+			req.Header.Set(header.name, header.value)
+			rec := httptest.NewRecorder()
+			ctx := e.NewContext(req, rec)
+
+			h := m.APIKey()(m.JWT()(handler))
+			if assert.NoError(t, h(ctx)) {
+				assert.Equal(t, http.StatusOK, rec.Code)
+				assert.Equal(t, "some-user-id", rec.Body.String())
+				mDeps.securityService.AssertNotCalled(t, "GetAndValidateAccessToken", mock.Anything)
+			}
+		})
+	}
+
+	t.Run("request without api key should be left to JWT", func(t *testing.T) {
+		m, mDeps := genMockMiddleware()
+		mDeps.securityService.
+			On("GetAndValidateAccessToken", mock.Anything).
+			Return(auth.TokenMetadata{}, terr.NewUnAuthorizedError("access token not found"))
+
+		e := echo.New()
+		rec := httptest.NewRecorder()
+		ctx := e.NewContext(httptest.NewRequest(echo.GET, "/", nil), rec)
+
+		h := m.APIKey()(m.JWT()(handler))
+		if assert.NoError(t, h(ctx)) {
+			assert.Equal(t, http.StatusUnauthorized, rec.Code)
+			mDeps.apiKeyUseCase.AssertNotCalled(t, "Authenticate", mock.Anything, mock.Anything)
+		}
+	})
+
+	t.Run("request with an invalid api key should not go thru", func(t *testing.T) {
+		m, mDeps := genMockMiddleware()
+		mDeps.apiKeyUseCase.
+			On("Authenticate", mock.Anything, "shm_some-key").
+			Return(auth.TokenMetadata{}, terr.NewUnAuthorizedError("invalid api key"))
+
+		e := echo.New()
+		req := httptest.NewRequest(echo.GET, "/", nil)
+		req.Header.Set("X-API-Key", "shm_some-key")
+		rec := httptest.NewRecorder()
+		ctx := e.NewContext(req, rec)
+
+		h := m.APIKey()(m.JWT()(handler))
+		if assert.NoError(t, h(ctx)) {
+			assert.Equal(t, http.StatusUnauthorized, rec.Code)
+			assert.Equal(t, "ApiKey", rec.Header().Get(echo.HeaderWWWAuthenticate))
+			assert.Equal(t, "{\"data\":null,\"error\":\"invalid api key\"}\n", rec.Body.String())
+		}
+	})
+
+	t.Run("request of a deactivated user should not go thru", func(t *testing.T) {
+		m, mDeps := genMockMiddleware()
+		mDeps.apiKeyUseCase.On("Authenticate", mock.Anything, "shm_some-key").Return(mockPrincipal, nil)
+		mDeps.userUseCase.On("IsUserActive", mock.Anything, "some-user-id").Return(false)
+
+		e := echo.New()
+		req := httptest.NewRequest(echo.GET, "/", nil)
+		req.Header.Set("X-API-Key", "shm_some-key")
+		rec := httptest.NewRecorder()
+		ctx := e.NewContext(req, rec)
+
+		h := m.APIKey()(m.JWT()(handler))
+		if assert.NoError(t, h(ctx)) {
+			assert.Equal(t, http.StatusUnauthorized, rec.Code)
+			assert.Equal(t, "{\"data\":null,\"error\":\"user account deactivated\"}\n", rec.Body.String())
+		}
+	})
+
+	t.Run("request should fail when the api key can't be checked", func(t *testing.T) {
+		m, mDeps := genMockMiddleware()
+		mDeps.apiKeyUseCase.On("Authenticate", mock.Anything, "shm_some-key").Return(auth.TokenMetadata{}, errors.New("some error"))
+
+		e := echo.New()
+		req := httptest.NewRequest(echo.GET, "/", nil)
+		req.Header.Set("X-API-Key", "shm_some-key")
+		rec := httptest.NewRecorder()
+		ctx := e.NewContext(req, rec)
+
+		h := m.APIKey()(handler)
+		if assert.NoError(t, h(ctx)) {
+			assert.Equal(t, http.StatusInternalServerError, rec.Code)
+		}
+	})
+}
+
+func TestPrincipal(t *testing.T) {
+	t.Run("it should get the stored principal", func(t *testing.T) {
+		e := echo.New()
+		ctx := e.NewContext(httptest.NewRequest(echo.GET, "/", nil), httptest.NewRecorder())
+		mockTokenMeta := auth.TokenMetadata{ID: "some-token-id", UserID: "some-user-id"}
+
+		SetPrincipal(ctx, mockTokenMeta)
+		principal, ok := GetPrincipal(ctx)
+		if assert.True(t, ok) {
+			assert.Equal(t, mockTokenMeta, principal)
+		}
+	})
+
+	t.Run("it should not get a principal", func(t *testing.T) {
+		e := echo.New()
+		ctx := e.NewContext(httptest.NewRequest(echo.GET, "/", nil), httptest.NewRecorder())
+
+		principal, ok := GetPrincipal(ctx)
+		assert.False(t, ok)
+		assert.Equal(t, auth.TokenMetadata{}, principal)
+	})
+}
+
+func TestRequireRole(t *testing.T) {
+	handler := func(c echo.Context) error {
+		return c.String(http.StatusOK, "test")
+	}
+
+	t.Run("request should go thru", func(t *testing.T) {
+		m, _ := genMockMiddleware()
+		e := echo.New()
+		rec := httptest.NewRecorder()
+		ctx := e.NewContext(httptest.NewRequest(echo.GET, "/", nil), rec)
+		SetPrincipal(ctx, auth.TokenMetadata{UserID: "some-user-id", Roles: []string{auth.AdminRole}})
+
+		h := m.RequireRole("some-role", auth.AdminRole)(handler)
+		if assert.NoError(t, h(ctx)) {
+			assert.Equal(t, http.StatusOK, rec.Code)
+			assert.Equal(t, "test", rec.Body.String())
+		}
+	})
+
+	t.Run("request should be forbidden", func(t *testing.T) {
+		m, _ := genMockMiddleware()
+		e := echo.New()
+		rec := httptest.NewRecorder()
+		ctx := e.NewContext(httptest.NewRequest(echo.GET, "/", nil), rec)
+		SetPrincipal(ctx, auth.TokenMetadata{UserID: "some-user-id", Roles: []string{"some-role"}})
+
+		h := m.RequireRole(auth.AdminRole)(handler)
+		if assert.NoError(t, h(ctx)) {
+			assert.Equal(t, http.StatusForbidden, rec.Code)
+			assert.Equal(t, "{\"data\":null,\"error\":\"forbidden\"}\n", rec.Body.String())
+		}
+	})
+
+	t.Run("request without principal should not go thru", func(t *testing.T) {
+		m, _ := genMockMiddleware()
+		e := echo.New()
+		rec := httptest.NewRecorder()
+		ctx := e.NewContext(httptest.NewRequest(echo.GET, "/", nil), rec)
+
+		h := m.RequireRole(auth.AdminRole)(handler)
+		if assert.NoError(t, h(ctx)) {
+			assert.Equal(t, http.StatusUnauthorized, rec.Code)
+			assert.Equal(t, "{\"data\":null,\"error\":\"invalid token\"}\n", rec.Body.String())
+		}
+	})
+}
+
+func TestRequirePermission(t *testing.T) {
+	handler := func(c echo.Context) error {
+		return c.String(http.StatusOK, "test")
+	}
+	mockRoles := []string{auth.AdminRole}
+
+	t.Run("request should go thru", func(t *testing.T) {
+		m, mDeps := genMockMiddleware()
+		mDeps.roleUseCase.
+			On("HasPermission", mock.Anything, mockRoles, auth.ManageRolesPermission).
+			Return(true, nil)
+		e := echo.New()
+		rec := httptest.NewRecorder()
+		ctx := e.NewContext(httptest.NewRequest(echo.GET, "/", nil), rec)
+		SetPrincipal(ctx, auth.TokenMetadata{UserID: "some-user-id", Roles: mockRoles})
+
+		h := m.RequirePermission(auth.ManageRolesPermission)(handler)
+		if assert.NoError(t, h(ctx)) {
+			assert.Equal(t, http.StatusOK, rec.Code)
+			assert.Equal(t, "test", rec.Body.String())
+		}
+	})
+
+	t.Run("request should be forbidden", func(t *testing.T) {
+		m, mDeps := genMockMiddleware()
+		mDeps.roleUseCase.
+			On("HasPermission", mock.Anything, mockRoles, auth.ManageRolesPermission).
+			Return(false, nil)
+		e := echo.New()
+		rec := httptest.NewRecorder()
+		ctx := e.NewContext(httptest.NewRequest(echo.GET, "/", nil), rec)
+		SetPrincipal(ctx, auth.TokenMetadata{UserID: "some-user-id", Roles: mockRoles})
+
+		h := m.RequirePermission(auth.ManageRolesPermission)(handler)
+		if assert.NoError(t, h(ctx)) {
+			assert.Equal(t, http.StatusForbidden, rec.Code)
+			assert.Equal(t, "{\"data\":null,\"error\":\"forbidden\"}\n", rec.Body.String())
+		}
+	})
+
+	t.Run("request should fail when permissions can't be checked", func(t *testing.T) {
+		m, mDeps := genMockMiddleware()
+		mDeps.roleUseCase.
+			On("HasPermission", mock.Anything, mockRoles, auth.ManageRolesPermission).
+			Return(false, errors.New("some error"))
+		e := echo.New()
+		rec := httptest.NewRecorder()
+		ctx := e.NewContext(httptest.NewRequest(echo.GET, "/", nil), rec)
+		SetPrincipal(ctx, auth.TokenMetadata{UserID: "some-user-id", Roles: mockRoles})
+
+		h := m.RequirePermission(auth.ManageRolesPermission)(handler)
+		if assert.NoError(t, h(ctx)) {
+			assert.Equal(t, http.StatusInternalServerError, rec.Code)
+		}
+	})
+
+	t.Run("request without principal should not go thru", func(t *testing.T) {
+		m, mDeps := genMockMiddleware()
+		e := echo.New()
+		rec := httptest.NewRecorder()
+		ctx := e.NewContext(httptest.NewRequest(echo.GET, "/", nil), rec)
+
+		h := m.RequirePermission(auth.ManageRolesPermission)(handler)
+		if assert.NoError(t, h(ctx)) {
+			assert.Equal(t, http.StatusUnauthorized, rec.Code)
+			mDeps.roleUseCase.AssertNotCalled(t, "HasPermission", mock.Anything, mock.Anything, mock.Anything)
+		}
+	})
+}
+
+func TestRequireScope(t *testing.T) {
+	handler := func(c echo.Context) error {
+		return c.String(http.StatusOK, "test")
+	}
+
+	t.Run("request should go thru", func(t *testing.T) {
+		m, _ := genMockMiddleware()
+		e := echo.New()
+		rec := httptest.NewRecorder()
+		ctx := e.NewContext(httptest.NewRequest(echo.GET, "/", nil), rec)
+		SetPrincipal(ctx, auth.TokenMetadata{
+			UserID:   "some-user-id",
+			ClientID: "some-client-id",
+			Scopes:   []string{auth.ProfileScope},
+		})
+
+		h := m.RequireScope(auth.ProfileScope)(handler)
+		if assert.NoError(t, h(ctx)) {
+			assert.Equal(t, http.StatusOK, rec.Code)
+			assert.Equal(t, "test", rec.Body.String())
+		}
+	})
+
+	t.Run("request with a scoped api key should be forbidden", func(t *testing.T) {
+		m, _ := genMockMiddleware()
+		e := echo.New()
+		rec := httptest.NewRecorder()
+		ctx := e.NewContext(httptest.NewRequest(echo.GET, "/", nil), rec)
+		SetPrincipal(ctx, auth.TokenMetadata{
+			UserID: "some-user-id",
+			Type:   auth.APIKeyTokenType,
+			Scopes: []string{auth.ProfileScope},
+		})
+
+		h := m.RequireScope(auth.AdminScope)(handler)
+		if assert.NoError(t, h(ctx)) {
+			assert.Equal(t, http.StatusForbidden, rec.Code)
+		}
+	})
+
+	t.Run("request with a first party token should go thru", func(t *testing.T) {
+		m, _ := genMockMiddleware()
+		e := echo.New()
+		rec := httptest.NewRecorder()
+		ctx := e.NewContext(httptest.NewRequest(echo.GET, "/", nil), rec)
+		SetPrincipal(ctx, auth.TokenMetadata{UserID: "some-user-id"})
+
+		h := m.RequireScope(auth.AccountScope)(handler)
+		if assert.NoError(t, h(ctx)) {
+			assert.Equal(t, http.StatusOK, rec.Code)
+		}
+	})
+
+	t.Run("request should be forbidden", func(t *testing.T) {
+		m, _ := genMockMiddleware()
+		e := echo.New()
+		rec := httptest.NewRecorder()
+		ctx := e.NewContext(httptest.NewRequest(echo.GET, "/", nil), rec)
+		SetPrincipal(ctx, auth.TokenMetadata{
+			UserID:   "some-user-id",
+			ClientID: "some-client-id",
+			Scopes:   []string{auth.ProfileScope},
+		})
+
+		h := m.RequireScope(auth.AccountScope)(handler)
+		if assert.NoError(t, h(ctx)) {
+			assert.Equal(t, http.StatusForbidden, rec.Code)
+			assert.Equal(t, "{\"data\":null,\"error\":\"insufficient scope\"}\n", rec.Body.String())
+			assert.Equal(
+				t,
+				`Bearer error="insufficient_scope", scope="account"`,
+				rec.Header().Get(echo.HeaderWWWAuthenticate),
+			)
+		}
+	})
+
+	t.Run("request without principal should not go thru", func(t *testing.T) {
+		m, _ := genMockMiddleware()
+		e := echo.New()
+		rec := httptest.NewRecorder()
+		ctx := e.NewContext(httptest.NewRequest(echo.GET, "/", nil), rec)
+
+		h := m.RequireScope(auth.ProfileScope)(handler)
+		if assert.NoError(t, h(ctx)) {
+			assert.Equal(t, http.StatusUnauthorized, rec.Code)
+		}
+	})
+}
+
+func TestRateLimit(t *testing.T) {
+	handler := func(c echo.Context) error {
+		return c.String(http.StatusOK, "test")
+	}
+
+	t.Run("request should go thru", func(t *testing.T) {
+		m, _ := genMockMiddleware()
+		e := echo.New()
+		h := m.RateLimit(&cmc.RateLimitConfig{Limit: 2, Window: time.Minute})(handler)
+
+		for i := 1; i >= 0; i-- {
+			req := httptest.NewRequest(echo.GET, "/", nil)
+			req.RemoteAddr = "10.0.0.1:1234"
+			rec := httptest.NewRecorder()
+			ctx := e.NewContext(req, rec)
+			if assert.NoError(t, h(ctx)) {
+				assert.Equal(t, http.StatusOK, rec.Code)
+				assert.Equal(t, "2", rec.Header().Get("X-RateLimit-Limit"))
+				assert.Equal(t, strconv.Itoa(i), rec.Header().Get("X-RateLimit-Remaining"))
+				assert.NotEmpty(t, rec.Header().Get("X-RateLimit-Reset"))
+			}
+		}
+	})
+
+	t.Run("request should not go thru", func(t *testing.T) {
+		m, _ := genMockMiddleware()
+		e := echo.New()
+		h := m.RateLimit(&cmc.RateLimitConfig{Limit: 1, Window: time.Minute})(handler)
+
+		var rec *httptest.ResponseRecorder
+		for i := 0; i < 2; i++ {
+			req := httptest.NewRequest(echo.GET, "/", nil)
+			req.RemoteAddr = "10.0.0.1:1234"
+			rec = httptest.NewRecorder()
+			assert.NoError(t, h(e.NewContext(req, rec)))
+		}
+
+		assert.Equal(t, http.StatusTooManyRequests, rec.Code)
+		assert.Equal(t, "0", rec.Header().Get("X-RateLimit-Remaining"))
+		assert.Equal(t, rec.Header().Get("X-RateLimit-Reset"), rec.Header().Get("Retry-After"))
+		assert.Equal(t, "{\"data\":null,\"error\":\"too many requests\"}\n", rec.Body.String())
+
+		// other clients are counted apart
+		req := httptest.NewRequest(echo.GET, "/", nil)
+		req.RemoteAddr = "10.0.0.2:1234"
+		rec = httptest.NewRecorder()
+		if assert.NoError(t, h(e.NewContext(req, rec))) {
+			assert.Equal(t, http.StatusOK, rec.Code)
+		}
+	})
+
+	t.Run("request with a forged client IP address should not go thru", func(t *testing.T) {
+		for _, extractor := range []echo.IPExtractor{nil, echo.ExtractIPDirect()} {
+			m, _ := genMockMiddleware()
+			e := echo.New()
+			e.IPExtractor = extractor
+			h := m.RateLimit(&cmc.RateLimitConfig{Limit: 1, Window: time.Minute})(handler)
+
+			var rec *httptest.ResponseRecorder
+			for _, forgedIP := range []string{"10.0.0.2", "10.0.0.3"} {
+				req := httptest.NewRequest(echo.GET, "/", nil)
+				req.RemoteAddr = "10.0.0.1:1234"
+				req.Header.Set(echo.HeaderXForwardedFor, forgedIP)
+				req.Header.Set(echo.HeaderXRealIP, forgedIP)
+				rec = httptest.NewRecorder()
+				assert.NoError(t, h(e.NewContext(req, rec)))
+			}
+
+			assert.Equal(t, http.StatusTooManyRequests, rec.Code)
+		}
+	})
+
+	t.Run("request should be counted per user", func(t *testing.T) {
+		m, _ := genMockMiddleware()
+		e := echo.New()
+		h := m.RateLimit(&cmc.RateLimitConfig{Limit: 1, KeyExtractor: KeyByUserID})(handler)
+
+		for _, userID := range []string{"some-user-id", "other-user-id"} {
+			req := httptest.NewRequest(echo.GET, "/", nil)
+			rec := httptest.NewRecorder()
+			ctx := e.NewContext(req, rec)
+			SetPrincipal(ctx, auth.TokenMetadata{UserID: userID})
+			if assert.NoError(t, h(ctx)) {
+				assert.Equal(t, http.StatusOK, rec.Code)
+				assert.Equal(t, "1", rec.Header().Get("X-RateLimit-Limit"))
+			}
+		}
+	})
+
+	t.Run("request should go thru on store error", func(t *testing.T) {
+		m, _ := genMockMiddleware()
+		e := echo.New()
+		store := new(mocks.Store)
+		store.On("Increment", mock.Anything, mock.Anything).Return(int64(0), errors.New("some error"))
+		h := m.RateLimit(&cmc.RateLimitConfig{Limit: 1, Store: store})(handler)
+
+		req := httptest.NewRequest(echo.GET, "/", nil)
+		rec := httptest.NewRecorder()
+		if assert.NoError(t, h(e.NewContext(req, rec))) {
+			assert.Equal(t, http.StatusOK, rec.Code)
+			assert.Empty(t, rec.Header().Get("X-RateLimit-Limit"))
+		}
+	})
+
+	t.Run("request should skip the limit", func(t *testing.T) {
+		m, _ := genMockMiddleware()
+		e := echo.New()
+		config := cmc.DefaultRateLimitConfig
+		config.Skipper = func(c echo.Context) bool {
+			return true
+		}
+		h := m.RateLimit(&config)(handler)
+
+		req := httptest.NewRequest(echo.GET, "/", nil)
+		rec := httptest.NewRecorder()
+		if assert.NoError(t, h(e.NewContext(req, rec))) {
+			assert.Equal(t, http.StatusOK, rec.Code)
+			assert.Empty(t, rec.Header().Get("X-RateLimit-Limit"))
+		}
+	})
 }
 
 func TestZeroLog(t *testing.T) {
@@ -1,21 +1,64 @@
 package middleware
 
 import (
+	"fmt"
 	"github.com/labstack/echo/v4"
 	"net/http"
 	"sherman/src/app/utils/response"
+	"sherman/src/app/utils/terr"
+	"sherman/src/domain/auth"
 )
 
-// UserAuthMiddleware returns echo.HandlerFunc middleware to handle user auth
+// JWT returns echo.MiddlewareFunc middleware to handle user auth, requests without a valid
+// and non revoked access token of an active user are rejected with a RFC 6750 WWW-Authenticate
+// challenge, the token metadata of accepted requests is available with GetPrincipal, requests
+// already authenticated by APIKey go thru
 func (s *service) JWT() echo.MiddlewareFunc {
 	return func(next echo.HandlerFunc) echo.HandlerFunc {
 		return func(ctx echo.Context) error {
-			if _, err := s.securityService.GetAndValidateAccessToken(ctx); err != nil {
-				res := response.NewResponse()
-				res.SetError(http.StatusUnauthorized, "invalid token")
-				return ctx.JSON(http.StatusUnauthorized, res.GetBody())
+			if principal, ok := GetPrincipal(ctx); ok && principal.Type == auth.APIKeyTokenType {
+				return next(ctx)
 			}
+
+			accessTokenMetadata, err := s.securityService.GetAndValidateAccessToken(ctx)
+			if err != nil {
+				return unauthorized(ctx, err)
+			}
+
+			if s.securityTokenUseCase.IsAccessTokenRevoked(ctx.Request().Context(), &accessTokenMetadata) {
+				return unauthorized(ctx, terr.NewUnAuthorizedError("token has been revoked"))
+			}
+
+			if !s.userUseCase.IsUserActive(ctx.Request().Context(), accessTokenMetadata.UserID) {
+				return unauthorized(ctx, terr.NewUnAuthorizedError("user account deactivated"))
+			}
+
+			SetPrincipal(ctx, accessTokenMetadata)
 			return next(ctx)
 		}
 	}
 }
+
+// unauthorized responds 401 with a WWW-Authenticate challenge, requests without credentials
+// get a challenge without error code
+func unauthorized(ctx echo.Context, err error) error {
+	message := "invalid token"
+	switch err.(type) {
+	case *terr.UnAuthorizedError,
+		*terr.ExpiredTokenError,
+		*terr.MalformedTokenError,
+		*terr.TokenTypeError,
+		*terr.TokenSignatureError:
+		message = err.Error()
+	}
+
+	challenge := "Bearer"
+	if ctx.Request().Header.Get(echo.HeaderAuthorization) != "" {
+		challenge = fmt.Sprintf(`Bearer error="invalid_token", error_description="%s"`, message)
+	}
+	ctx.Response().Header().Set(echo.HeaderWWWAuthenticate, challenge)
+
+	res := response.NewResponse()
+	res.SetError(http.StatusUnauthorized, message)
+	return ctx.JSON(res.GetStatus(), res.GetBody())
+}
@@ -0,0 +1,222 @@
+package oidc
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"sherman/src/app/config"
+	"sherman/src/app/utils/terr"
+	"sherman/src/app/utils/tokens"
+	"sherman/src/domain/auth"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// httpTimeout deadline of a request to a provider
+	httpTimeout = time.Second * time.Duration(10)
+	// maxResponseSize max size of a provider response body
+	maxResponseSize = 1 << 20
+)
+
+type (
+	// OIDC oidc.OIDC interface definition, a relying party of the configured OpenID Connect providers
+	OIDC interface {
+		NewAuthRequest(provider string) (auth.OIDCAuthRequest, error)
+		Exchange(provider, code, codeVerifier, nonce string) (auth.OIDCClaims, error)
+	}
+
+	// metadata endpoints of a provider discovery document
+	metadata struct {
+		Issuer                string `json:"issuer"`
+		AuthorizationEndpoint string `json:"authorization_endpoint"`
+		TokenEndpoint         string `json:"token_endpoint"`
+		JWKSURI               string `json:"jwks_uri"`
+	}
+
+	// tokenResponse response of a provider token endpoint
+	tokenResponse struct {
+		IDToken          string `json:"id_token"`
+		Error            string `json:"error"`
+		ErrorDescription string `json:"error_description"`
+	}
+
+	// provider a configured provider, its metadata and keys are fetched on first use
+	provider struct {
+		config        config.OIDCProviderConfig
+		mu            sync.Mutex
+		metadata      *metadata
+		keys          map[string]interface{}
+		keysFetchedAt time.Time
+	}
+
+	service struct {
+		client    *http.Client
+		leeway    int64
+		providers map[string]*provider
+	}
+)
+
+// New returns an instance of oidc.OIDC for the configured providers
+func New(cfg *config.GlobalConfig) OIDC {
+	providers := make(map[string]*provider)
+	for _, providerConfig := range cfg.OIDC.Providers {
+		providers[providerConfig.Name] = &provider{config: providerConfig}
+	}
+
+	return &service{
+		client:    &http.Client{Timeout: httpTimeout},
+		leeway:    int64(cfg.Jwt.Leeway),
+		providers: providers,
+	}
+}
+
+// NewAuthRequest generates the state, nonce and PKCE code verifier of an authorization code
+// request and the provider URL the user agent is sent to
+func (s *service) NewAuthRequest(providerName string) (auth.OIDCAuthRequest, error) {
+	p, err := s.provider(providerName)
+	if err != nil {
+		return auth.OIDCAuthRequest{}, err
+	}
+
+	md, err := s.getMetadata(p)
+	if err != nil {
+		return auth.OIDCAuthRequest{}, err
+	}
+
+	authRequest := auth.OIDCAuthRequest{Provider: providerName}
+	for _, value := range []*string{&authRequest.State, &authRequest.Nonce, &authRequest.CodeVerifier} {
+		if *value, err = tokens.Random(); err != nil {
+			return auth.OIDCAuthRequest{}, err
+		}
+	}
+
+	authURL, err := url.Parse(md.AuthorizationEndpoint)
+	if err != nil {
+		return auth.OIDCAuthRequest{}, fmt.Errorf("oidc provider %s has an invalid authorization endpoint", providerName)
+	}
+	query := authURL.Query()
+	query.Set("response_type", "code")
+	query.Set("client_id", p.config.ClientID)
+	query.Set("redirect_uri", p.config.RedirectURL)
+	query.Set("scope", strings.Join(p.config.Scopes, " "))
+	query.Set("state", authRequest.State)
+	query.Set("nonce", authRequest.Nonce)
+	query.Set("code_challenge", tokens.S256Challenge(authRequest.CodeVerifier))
+	query.Set("code_challenge_method", "S256")
+	authURL.RawQuery = query.Encode()
+
+	authRequest.URL = authURL.String()
+	return authRequest, nil
+}
+
+// Exchange exchanges an authorization code and its PKCE code verifier for an ID token, the token
+// must be signed by the provider, issued to the client and carry the nonce of the request
+func (s *service) Exchange(providerName, code, codeVerifier, nonce string) (auth.OIDCClaims, error) {
+	p, err := s.provider(providerName)
+	if err != nil {
+		return auth.OIDCClaims{}, err
+	}
+
+	md, err := s.getMetadata(p)
+	if err != nil {
+		return auth.OIDCClaims{}, err
+	}
+
+	form := url.Values{}
+	form.Set("grant_type", "authorization_code")
+	form.Set("code", code)
+	form.Set("redirect_uri", p.config.RedirectURL)
+	form.Set("code_verifier", codeVerifier)
+
+	req, err := http.NewRequest(http.MethodPost, md.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return auth.OIDCClaims{}, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	// client_secret_basic, the credentials are form encoded before being base64 encoded
+	req.SetBasicAuth(url.QueryEscape(p.config.ClientID), url.QueryEscape(p.config.ClientSecret))
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return auth.OIDCClaims{}, fmt.Errorf("oidc provider %s token request failed: %s", providerName, err.Error())
+	}
+	defer resp.Body.Close()
+
+	var token tokenResponse
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxResponseSize)).Decode(&token); err != nil {
+		return auth.OIDCClaims{}, fmt.Errorf("oidc provider %s token response is malformed", providerName)
+	}
+	if resp.StatusCode != http.StatusOK {
+		// invalid_grant is an expired, used or forged code
+		if token.Error == "invalid_grant" {
+			return auth.OIDCClaims{}, terr.NewUnAuthorizedError("invalid authorization code")
+		}
+		return auth.OIDCClaims{}, fmt.Errorf("oidc provider %s token request failed: %s %s",
+			providerName, token.Error, token.ErrorDescription)
+	}
+	if token.IDToken == "" {
+		return auth.OIDCClaims{}, fmt.Errorf("oidc provider %s returned no id token", providerName)
+	}
+
+	return s.verifyIDToken(p, md, token.IDToken, nonce)
+}
+
+// provider gets a configured provider by name
+func (s *service) provider(name string) (*provider, error) {
+	p, ok := s.providers[name]
+	if !ok {
+		return nil, terr.NewNotFoundError("oidc provider not found")
+	}
+	return p, nil
+}
+
+// getMetadata gets the discovery document of a provider, fetched once, its issuer must be the configured one
+func (s *service) getMetadata(p *provider) (*metadata, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.metadata != nil {
+		return p.metadata, nil
+	}
+
+	var md metadata
+	discoveryURL := strings.TrimSuffix(p.config.Issuer, "/") + "/.well-known/openid-configuration"
+	if err := s.getJSON(discoveryURL, &md); err != nil {
+		return nil, fmt.Errorf("oidc provider %s discovery failed: %s", p.config.Name, err.Error())
+	}
+
+	switch {
+	case md.Issuer != p.config.Issuer:
+		return nil, fmt.Errorf("oidc provider %s discovery issuer %s doesn't match", p.config.Name, md.Issuer)
+	case md.AuthorizationEndpoint == "", md.TokenEndpoint == "", md.JWKSURI == "":
+		return nil, fmt.Errorf("oidc provider %s discovery document is incomplete", p.config.Name)
+	}
+
+	p.metadata = &md
+	return p.metadata, nil
+}
+
+// getJSON gets and decodes a json document
+func (s *service) getJSON(documentURL string, v interface{}) error {
+	resp, err := s.client.Get(documentURL)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		_, _ = io.Copy(ioutil.Discard, io.LimitReader(resp.Body, maxResponseSize))
+		return fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxResponseSize)).Decode(v); err != nil {
+		return errors.New("malformed json document")
+	}
+	return nil
+}
@@ -0,0 +1,258 @@
+package oidc
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"github.com/dgrijalva/jwt-go"
+	"github.com/stretchr/testify/assert"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sherman/src/app/config"
+	_ "sherman/src/app/testing"
+	"sherman/src/app/utils/terr"
+	"sherman/src/app/utils/tokens"
+	"sherman/src/domain/auth"
+	"testing"
+	"time"
+)
+
+// fakeIdP an OpenID Connect provider issuing an ID token with claims for the code "some-code"
+type fakeIdP struct {
+	server     *httptest.Server
+	key        *rsa.PrivateKey
+	claims     jwt.MapClaims
+	tokenForms []url.Values
+}
+
+func newFakeIdP(t *testing.T) *fakeIdP {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected", err)
+	}
+
+	idp := &fakeIdP{key: key}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]string{
+			"issuer":                 idp.server.URL,
+			"authorization_endpoint": idp.server.URL + "/authorize",
+			"token_endpoint":         idp.server.URL + "/token",
+			"jwks_uri":               idp.server.URL + "/jwks",
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"keys": []map[string]string{{
+				"kty": "RSA",
+				"use": "sig",
+				"kid": "idp-key",
+				"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+				"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+			}},
+		})
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil {
+			t.Fatalf("an error '%s' was not expected", err)
+		}
+		idp.tokenForms = append(idp.tokenForms, r.PostForm)
+
+		clientID, clientSecret, _ := r.BasicAuth()
+		if clientID != "some-client" || clientSecret != "some-secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			_ = json.NewEncoder(w).Encode(map[string]string{"error": "invalid_client"})
+			return
+		}
+		if r.PostForm.Get("code") != "some-code" {
+			w.WriteHeader(http.StatusBadRequest)
+			_ = json.NewEncoder(w).Encode(map[string]string{"error": "invalid_grant"})
+			return
+		}
+
+		_ = json.NewEncoder(w).Encode(map[string]string{
+			"access_token": "some-access-token",
+			"token_type":   "Bearer",
+			"id_token":     idp.sign(t, idp.claims),
+		})
+	})
+	idp.server = httptest.NewServer(mux)
+
+	now := time.Now().Unix()
+	idp.claims = jwt.MapClaims{
+		"iss":            idp.server.URL,
+		"sub":            "some-subject",
+		"aud":            "some-client",
+		"exp":            now + 300,
+		"iat":            now,
+		"nonce":          "some-nonce",
+		"email":          "some@email.com",
+		"email_verified": true,
+		"given_name":     "first",
+		"family_name":    "last",
+		"name":           "first last",
+	}
+	return idp
+}
+
+func (idp *fakeIdP) sign(t *testing.T, claims jwt.MapClaims) string {
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = "idp-key"
+	signed, err := token.SignedString(idp.key)
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected", err)
+	}
+	return signed
+}
+
+func (idp *fakeIdP) service() OIDC {
+	return New(&config.GlobalConfig{
+		Jwt: config.JwtConfig{Leeway: 30},
+		OIDC: config.OIDCConfig{
+			Providers: []config.OIDCProviderConfig{{
+				Name:         "fake",
+				Issuer:       idp.server.URL,
+				ClientID:     "some-client",
+				ClientSecret: "some-secret",
+				RedirectURL:  "http://localhost/api/v1/auth/fake/callback",
+				Scopes:       []string{"openid", "email"},
+			}},
+		},
+	})
+}
+
+func TestNewAuthRequest(t *testing.T) {
+	idp := newFakeIdP(t)
+	defer idp.server.Close()
+
+	t.Run("it should succeed", func(t *testing.T) {
+		authRequest, err := idp.service().NewAuthRequest("fake")
+
+		if assert.NoError(t, err) {
+			assert.Equal(t, "fake", authRequest.Provider)
+			assert.NotEmpty(t, authRequest.State)
+			assert.NotEmpty(t, authRequest.Nonce)
+			assert.Len(t, authRequest.CodeVerifier, 43)
+
+			authURL, err := url.Parse(authRequest.URL)
+			if assert.NoError(t, err) {
+				query := authURL.Query()
+				assert.Equal(t, idp.server.URL+"/authorize", authURL.Scheme+"://"+authURL.Host+authURL.Path)
+				assert.Equal(t, "code", query.Get("response_type"))
+				assert.Equal(t, "some-client", query.Get("client_id"))
+				assert.Equal(t, "http://localhost/api/v1/auth/fake/callback", query.Get("redirect_uri"))
+				assert.Equal(t, "openid email", query.Get("scope"))
+				assert.Equal(t, authRequest.State, query.Get("state"))
+				assert.Equal(t, authRequest.Nonce, query.Get("nonce"))
+				assert.Equal(t, tokens.S256Challenge(authRequest.CodeVerifier), query.Get("code_challenge"))
+				assert.Equal(t, "S256", query.Get("code_challenge_method"))
+			}
+		}
+	})
+
+	t.Run("it should return not found error", func(t *testing.T) {
+		_, err := idp.service().NewAuthRequest("unknown")
+
+		assert.Equal(t, terr.NewNotFoundError("oidc provider not found"), err)
+	})
+}
+
+func TestExchange(t *testing.T) {
+	idp := newFakeIdP(t)
+	defer idp.server.Close()
+
+	t.Run("it should succeed", func(t *testing.T) {
+		claims, err := idp.service().Exchange("fake", "some-code", "some-verifier", "some-nonce")
+
+		if assert.NoError(t, err) {
+			assert.Equal(t, auth.OIDCClaims{
+				Subject:       "some-subject",
+				Email:         "some@email.com",
+				EmailVerified: true,
+				GivenName:     "first",
+				FamilyName:    "last",
+				Name:          "first last",
+			}, claims)
+
+			form := idp.tokenForms[len(idp.tokenForms)-1]
+			assert.Equal(t, "authorization_code", form.Get("grant_type"))
+			assert.Equal(t, "some-verifier", form.Get("code_verifier"))
+			assert.Equal(t, "http://localhost/api/v1/auth/fake/callback", form.Get("redirect_uri"))
+		}
+	})
+
+	t.Run("it should return error on invalid code", func(t *testing.T) {
+		_, err := idp.service().Exchange("fake", "other-code", "some-verifier", "some-nonce")
+
+		assert.Equal(t, terr.NewUnAuthorizedError("invalid authorization code"), err)
+	})
+
+	t.Run("it should return error on nonce mismatch", func(t *testing.T) {
+		_, err := idp.service().Exchange("fake", "some-code", "some-verifier", "other-nonce")
+
+		assert.Equal(t, terr.NewUnAuthorizedError("invalid id token nonce"), err)
+	})
+
+	invalidClaims := []struct {
+		name  string
+		claim string
+		value interface{}
+		err   error
+	}{
+		{"issuer", "iss", "https://other.issuer", terr.NewUnAuthorizedError("invalid id token issuer")},
+		{"audience", "aud", "other-client", terr.NewUnAuthorizedError("invalid id token audience")},
+		{"authorized party", "azp", "other-client", terr.NewUnAuthorizedError("invalid id token authorized party")},
+		{"expiration", "exp", time.Now().Unix() - 60, terr.NewUnAuthorizedError("id token is expired")},
+		{"subject", "sub", "", terr.NewUnAuthorizedError("id token has no subject")},
+	}
+	for _, invalidClaim := range invalidClaims {
+		t.Run("it should return error on invalid "+invalidClaim.name, func(t *testing.T) {
+			claims := jwt.MapClaims{}
+			for name, value := range idp.claims {
+				claims[name] = value
+			}
+			claims[invalidClaim.claim] = invalidClaim.value
+
+			validClaims := idp.claims
+			idp.claims = claims
+			defer func() { idp.claims = validClaims }()
+
+			_, err := idp.service().Exchange("fake", "some-code", "some-verifier", "some-nonce")
+
+			assert.Equal(t, invalidClaim.err, err)
+		})
+	}
+
+	t.Run("it should return error on invalid signature", func(t *testing.T) {
+		otherKey, err := rsa.GenerateKey(rand.Reader, 2048)
+		if err != nil {
+			t.Fatalf("an error '%s' was not expected", err)
+		}
+		validKey := idp.key
+		idp.key = otherKey
+		defer func() { idp.key = validKey }()
+
+		_, err = idp.service().Exchange("fake", "some-code", "some-verifier", "some-nonce")
+
+		assert.Equal(t, terr.NewUnAuthorizedError("invalid id token"), err)
+	})
+
+	t.Run("it should return error on discovery issuer mismatch", func(t *testing.T) {
+		service := New(&config.GlobalConfig{
+			OIDC: config.OIDCConfig{
+				Providers: []config.OIDCProviderConfig{{
+					Name:     "fake",
+					Issuer:   idp.server.URL + "/",
+					ClientID: "some-client",
+				}},
+			},
+		})
+
+		_, err := service.Exchange("fake", "some-code", "some-verifier", "some-nonce")
+
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,179 @@
+package oidc
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"github.com/dgrijalva/jwt-go"
+	"math/big"
+	"sherman/src/app/utils/terr"
+	"sherman/src/domain/auth"
+	"sherman/src/service/security"
+	"time"
+)
+
+// keysRefreshInterval min interval between two fetches of the keys of a provider, an unknown kid
+// triggers a fetch so rotated keys are picked up without letting forged kids flood the provider
+const keysRefreshInterval = time.Minute
+
+type (
+	// jwk a json web key of a provider key set
+	jwk struct {
+		Kty string `json:"kty"`
+		Use string `json:"use"`
+		Kid string `json:"kid"`
+		Crv string `json:"crv"`
+		N   string `json:"n"`
+		E   string `json:"e"`
+		X   string `json:"x"`
+		Y   string `json:"y"`
+	}
+	// jwks a json web key set
+	jwks struct {
+		Keys []jwk `json:"keys"`
+	}
+)
+
+// verifyIDToken verifies the signature and the claims of an ID token, errors are unauthorized errors
+func (s *service) verifyIDToken(p *provider, md *metadata, idToken, nonce string) (auth.OIDCClaims, error) {
+	// claims are validated below, jwt-go doesn't support leeway nor aud arrays
+	parser := jwt.Parser{
+		ValidMethods:         []string{jwt.SigningMethodRS256.Alg(), jwt.SigningMethodES256.Alg()},
+		SkipClaimsValidation: true,
+	}
+	token, err := parser.Parse(idToken, func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		return s.getKey(p, md, kid)
+	})
+	if err != nil {
+		return auth.OIDCClaims{}, terr.NewUnAuthorizedError("invalid id token")
+	}
+
+	claims := token.Claims.(jwt.MapClaims)
+	if err := s.validateClaims(p, md, claims, nonce); err != nil {
+		return auth.OIDCClaims{}, err
+	}
+
+	oidcClaims := auth.OIDCClaims{}
+	oidcClaims.Subject, _ = claims["sub"].(string)
+	oidcClaims.Email, _ = claims["email"].(string)
+	oidcClaims.GivenName, _ = claims["given_name"].(string)
+	oidcClaims.FamilyName, _ = claims["family_name"].(string)
+	oidcClaims.Name, _ = claims["name"].(string)
+	// some providers send email_verified as a string
+	switch emailVerified := claims["email_verified"].(type) {
+	case bool:
+		oidcClaims.EmailVerified = emailVerified
+	case string:
+		oidcClaims.EmailVerified = emailVerified == "true"
+	}
+
+	if oidcClaims.Subject == "" {
+		return auth.OIDCClaims{}, terr.NewUnAuthorizedError("id token has no subject")
+	}
+	return oidcClaims, nil
+}
+
+// validateClaims validates the claims of an ID token as required by OpenID Connect Core 3.1.3.7,
+// exp and iat are checked with the configured clock skew leeway
+func (s *service) validateClaims(p *provider, md *metadata, claims jwt.MapClaims, nonce string) error {
+	now := time.Now().Unix()
+
+	if iss, _ := claims["iss"].(string); iss != md.Issuer {
+		return terr.NewUnAuthorizedError("invalid id token issuer")
+	}
+
+	audience := security.StringsClaim(claims, "aud")
+	if !security.ContainsString(audience, p.config.ClientID) {
+		return terr.NewUnAuthorizedError("invalid id token audience")
+	}
+	if azp, ok := claims["azp"].(string); (ok || len(audience) > 1) && azp != p.config.ClientID {
+		return terr.NewUnAuthorizedError("invalid id token authorized party")
+	}
+
+	exp, ok := security.NumericClaim(claims, "exp")
+	if !ok || now > exp+s.leeway {
+		return terr.NewUnAuthorizedError("id token is expired")
+	}
+	if iat, ok := security.NumericClaim(claims, "iat"); !ok || now+s.leeway < iat {
+		return terr.NewUnAuthorizedError("id token used before issued")
+	}
+
+	if tokenNonce, _ := claims["nonce"].(string); subtle.ConstantTimeCompare([]byte(tokenNonce), []byte(nonce)) != 1 {
+		return terr.NewUnAuthorizedError("invalid id token nonce")
+	}
+	return nil
+}
+
+// getKey gets a signing key of a provider by kid, the key set is fetched again when the kid is unknown
+func (s *service) getKey(p *provider, md *metadata, kid string) (interface{}, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if key, ok := p.keys[kid]; ok {
+		return key, nil
+	}
+	if time.Since(p.keysFetchedAt) < keysRefreshInterval {
+		return nil, errors.New("signing key not found")
+	}
+
+	var keySet jwks
+	p.keysFetchedAt = time.Now()
+	if err := s.getJSON(md.JWKSURI, &keySet); err != nil {
+		return nil, fmt.Errorf("oidc provider %s keys fetch failed: %s", p.config.Name, err.Error())
+	}
+
+	p.keys = make(map[string]interface{})
+	for _, k := range keySet.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+		if key, err := k.publicKey(); err == nil {
+			p.keys[k.Kid] = key
+		}
+	}
+
+	if key, ok := p.keys[kid]; ok {
+		return key, nil
+	}
+	return nil, errors.New("signing key not found")
+}
+
+// publicKey parses the public key of a RSA or P-256 EC json web key
+func (k *jwk) publicKey() (interface{}, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := base64.RawURLEncoding.DecodeString(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := base64.RawURLEncoding.DecodeString(k.E)
+		if err != nil {
+			return nil, err
+		}
+		return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}, nil
+	case "EC":
+		if k.Crv != "P-256" {
+			return nil, fmt.Errorf("curve %s not supported", k.Crv)
+		}
+		x, err := base64.RawURLEncoding.DecodeString(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := base64.RawURLEncoding.DecodeString(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		key := &ecdsa.PublicKey{Curve: elliptic.P256(), X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}
+		if !key.Curve.IsOnCurve(key.X, key.Y) {
+			return nil, errors.New("invalid ec key")
+		}
+		return key, nil
+	default:
+		return nil, fmt.Errorf("key type %s not supported", k.Kty)
+	}
+}
@@ -8,6 +8,8 @@ type (
 	// Presenter presenter.Presenter interface definition
 	Presenter interface {
 		PresentUser(user *auth.User) auth.PresentedUser
+		PresentUsers(users []auth.User) []auth.PresentedUser
+		PresentSessions(sessions []auth.SecurityToken) []auth.PresentedSession
 	}
 
 	service struct{}
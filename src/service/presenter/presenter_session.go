@@ -0,0 +1,18 @@
+package presenter
+
+import "sherman/src/domain/auth"
+
+// PresentSessions returns a list of public session keys, values from the active refresh tokens of a user
+func (s *service) PresentSessions(sessions []auth.SecurityToken) []auth.PresentedSession {
+	presentedSessions := make([]auth.PresentedSession, 0, len(sessions))
+	for i := range sessions {
+		presentedSessions = append(presentedSessions, auth.PresentedSession{
+			ID:         sessions[i].FamilyID,
+			UserAgent:  sessions[i].UserAgent,
+			IPAddress:  sessions[i].IPAddress,
+			LastUsedAt: sessions[i].LastUsedAt,
+			CreatedAt:  sessions[i].CreatedAt,
+		})
+	}
+	return presentedSessions
+}
@@ -12,26 +12,76 @@ func TestPresentUser(t *testing.T) {
 	ps := New()
 	lo, _ := time.LoadLocation("UTC")
 	mockUser := auth.User{
-		ID:           "some-id",
-		FirstName:    "first",
-		LastName:     "last",
-		EmailAddress: "some@email.com",
-		Password:     "has a password",
-		Active:       true,
-		CreatedAt:    time.Unix(0, 0).In(lo),
-		UpdatedAt:    time.Unix(0, 0).In(lo),
+		ID:            "some-id",
+		FirstName:     "first",
+		LastName:      "last",
+		EmailAddress:  "some@email.com",
+		Password:      "has a password",
+		Active:        true,
+		EmailVerified: true,
+		CreatedAt:     time.Unix(0, 0).In(lo),
+		UpdatedAt:     time.Unix(0, 0).In(lo),
 	}
 
 	expected := auth.PresentedUser{
-		ID:           mockUser.ID,
-		FirstName:    mockUser.FirstName,
-		LastName:     mockUser.LastName,
-		EmailAddress: mockUser.EmailAddress,
-		Active:       mockUser.Active,
-		CreatedAt:    mockUser.CreatedAt,
-		UpdatedAt:    mockUser.UpdatedAt,
+		ID:            mockUser.ID,
+		FirstName:     mockUser.FirstName,
+		LastName:      mockUser.LastName,
+		EmailAddress:  mockUser.EmailAddress,
+		Active:        mockUser.Active,
+		EmailVerified: mockUser.EmailVerified,
+		CreatedAt:     mockUser.CreatedAt,
+		UpdatedAt:     mockUser.UpdatedAt,
 	}
 	actual := ps.PresentUser(&mockUser)
 
 	assert.Equal(t, expected, actual)
 }
+
+func TestPresentUsers(t *testing.T) {
+	ps := New()
+	mockUsers := []auth.User{
+		{ID: "some-id", EmailAddress: "some@email.com", Password: "has a password"},
+		{ID: "some-other-id", EmailAddress: "other@email.com", Password: "has a password"},
+	}
+
+	expected := []auth.PresentedUser{
+		{ID: "some-id", EmailAddress: "some@email.com"},
+		{ID: "some-other-id", EmailAddress: "other@email.com"},
+	}
+	assert.Equal(t, expected, ps.PresentUsers(mockUsers))
+	assert.Equal(t, []auth.PresentedUser{}, ps.PresentUsers(nil))
+}
+
+func TestPresentSessions(t *testing.T) {
+	ps := New()
+	lo, _ := time.LoadLocation("UTC")
+	mockSessions := []auth.SecurityToken{
+		{
+			ID:         "some-id",
+			UserID:     "some-user-id",
+			Token:      "some-token",
+			Type:       auth.RefreshTokenType,
+			FamilyID:   "some-family-id",
+			UserAgent:  "some-user-agent",
+			IPAddress:  "127.0.0.1",
+			LastUsedAt: time.Unix(0, 0).In(lo),
+			CreatedAt:  time.Unix(0, 0).In(lo),
+			UpdatedAt:  time.Unix(0, 0).In(lo),
+		},
+	}
+
+	expected := []auth.PresentedSession{
+		{
+			ID:         mockSessions[0].FamilyID,
+			UserAgent:  mockSessions[0].UserAgent,
+			IPAddress:  mockSessions[0].IPAddress,
+			LastUsedAt: mockSessions[0].LastUsedAt,
+			CreatedAt:  mockSessions[0].CreatedAt,
+		},
+	}
+	actual := ps.PresentSessions(mockSessions)
+
+	assert.Equal(t, expected, actual)
+	assert.Equal(t, []auth.PresentedSession{}, ps.PresentSessions(nil))
+}
@@ -5,12 +5,22 @@ import "sherman/src/domain/auth"
 // PresentUser returns a map of public auth.User keys, values
 func (s *service) PresentUser(user *auth.User) auth.PresentedUser {
 	return auth.PresentedUser{
-		ID:           user.ID,
-		FirstName:    user.FirstName,
-		LastName:     user.LastName,
-		EmailAddress: user.EmailAddress,
-		Active:       user.Active,
-		CreatedAt:    user.CreatedAt,
-		UpdatedAt:    user.UpdatedAt,
+		ID:            user.ID,
+		FirstName:     user.FirstName,
+		LastName:      user.LastName,
+		EmailAddress:  user.EmailAddress,
+		Active:        user.Active,
+		EmailVerified: user.EmailVerified,
+		CreatedAt:     user.CreatedAt,
+		UpdatedAt:     user.UpdatedAt,
 	}
 }
+
+// PresentUsers returns the public auth.User keys, values of users
+func (s *service) PresentUsers(users []auth.User) []auth.PresentedUser {
+	presentedUsers := make([]auth.PresentedUser, len(users))
+	for i := range users {
+		presentedUsers[i] = s.PresentUser(&users[i])
+	}
+	return presentedUsers
+}
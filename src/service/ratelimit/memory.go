@@ -0,0 +1,89 @@
+package ratelimit
+
+import (
+	"hash/fnv"
+	"sync"
+	"time"
+)
+
+const (
+	// shardCount number of independently locked shards of the memory store
+	shardCount = 32
+	// sweepInterval min interval between sweeps of the expired counters of a shard
+	sweepInterval = time.Minute
+)
+
+type (
+	counter struct {
+		value     int64
+		expiresAt time.Time
+	}
+
+	shard struct {
+		mu        sync.Mutex
+		counters  map[string]counter
+		lastSweep time.Time
+	}
+
+	memoryStore struct {
+		shards [shardCount]*shard
+	}
+)
+
+// NewMemoryStore returns an in process instance of ratelimit.Store, counters are sharded by key
+// to keep lock contention low and are only known by the process that incremented them
+func NewMemoryStore() Store {
+	s := &memoryStore{}
+	now := time.Now()
+	for i := range s.shards {
+		s.shards[i] = &shard{
+			counters:  make(map[string]counter),
+			lastSweep: now,
+		}
+	}
+	return s
+}
+
+// Increment increments the counter of key, created with ttl when missing or expired
+func (s *memoryStore) Increment(key string, ttl time.Duration) (int64, error) {
+	now := time.Now()
+	sh := s.shard(key)
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+
+	c, ok := sh.counters[key]
+	if !ok || !now.Before(c.expiresAt) {
+		c = counter{expiresAt: now.Add(ttl)}
+	}
+	c.value++
+	sh.counters[key] = c
+
+	if now.Sub(sh.lastSweep) >= sweepInterval {
+		for k, c := range sh.counters {
+			if !now.Before(c.expiresAt) {
+				delete(sh.counters, k)
+			}
+		}
+		sh.lastSweep = now
+	}
+	return c.value, nil
+}
+
+// Get gets the value of the counter of key, expired counters are reported as 0
+func (s *memoryStore) Get(key string) (int64, error) {
+	sh := s.shard(key)
+	sh.mu.Lock()
+	c, ok := sh.counters[key]
+	sh.mu.Unlock()
+
+	if !ok || !time.Now().Before(c.expiresAt) {
+		return 0, nil
+	}
+	return c.value, nil
+}
+
+func (s *memoryStore) shard(key string) *shard {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(key))
+	return s.shards[h.Sum32()%shardCount]
+}
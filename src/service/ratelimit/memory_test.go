@@ -0,0 +1,62 @@
+package ratelimit
+
+import (
+	"github.com/stretchr/testify/assert"
+	_ "sherman/src/app/testing"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestMemoryStore(t *testing.T) {
+	t.Run("it should increment and get a counter", func(t *testing.T) {
+		store := NewMemoryStore()
+
+		for i := int64(1); i <= 3; i++ {
+			value, err := store.Increment("some-key", time.Minute)
+			if assert.NoError(t, err) {
+				assert.Equal(t, i, value)
+			}
+		}
+
+		value, err := store.Get("some-key")
+		if assert.NoError(t, err) {
+			assert.Equal(t, int64(3), value)
+		}
+		value, err = store.Get("missing-key")
+		if assert.NoError(t, err) {
+			assert.Equal(t, int64(0), value)
+		}
+	})
+
+	t.Run("it should expire a counter", func(t *testing.T) {
+		store := NewMemoryStore()
+		_, _ = store.Increment("some-key", time.Millisecond)
+		time.Sleep(2 * time.Millisecond)
+
+		value, _ := store.Get("some-key")
+		assert.Equal(t, int64(0), value)
+		value, _ = store.Increment("some-key", time.Minute)
+		assert.Equal(t, int64(1), value)
+	})
+
+	t.Run("it should sweep the expired counters", func(t *testing.T) {
+		s := NewMemoryStore().(*memoryStore)
+		for i := 0; i < 100; i++ {
+			_, _ = s.Increment("key-"+strconv.Itoa(i), time.Nanosecond)
+		}
+		for _, sh := range s.shards {
+			sh.lastSweep = time.Now().Add(-sweepInterval)
+		}
+
+		for i := 0; i < 100; i++ {
+			_, _ = s.Increment("key-"+strconv.Itoa(i), time.Minute)
+		}
+
+		for _, sh := range s.shards {
+			for _, c := range sh.counters {
+				assert.Equal(t, int64(1), c.value)
+			}
+		}
+	})
+}
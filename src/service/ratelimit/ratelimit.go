@@ -0,0 +1,57 @@
+package ratelimit
+
+import (
+	"math"
+	"strconv"
+	"time"
+)
+
+type (
+	// Store ratelimit.Store interface definition, the counters shared by the rate limiters
+	Store interface {
+		// Increment increments the counter of key, created with ttl when missing, and returns its new value
+		Increment(key string, ttl time.Duration) (int64, error)
+		// Get gets the value of the counter of key, 0 when missing
+		Get(key string) (int64, error)
+	}
+
+	// Result outcome of a request counted by Allow
+	Result struct {
+		Allowed   bool
+		Limit     int
+		Remaining int
+		Reset     time.Duration
+	}
+)
+
+// Allow counts a request of key and checks it against a sliding window of limit requests per window,
+// the requests of the current fixed window are added to the requests of the previous fixed window
+// weighted by the part of it still covered by the sliding window, rejected requests are counted too
+// so that a client must slow down to get through again
+func Allow(store Store, key string, limit int, window time.Duration, now time.Time) (Result, error) {
+	current := now.UnixNano() / int64(window)
+	elapsed := time.Duration(now.UnixNano() - current*int64(window))
+
+	count, err := store.Increment(key+":"+strconv.FormatInt(current, 10), 2*window)
+	if err != nil {
+		return Result{}, err
+	}
+	previous, err := store.Get(key + ":" + strconv.FormatInt(current-1, 10))
+	if err != nil {
+		return Result{}, err
+	}
+
+	weight := 1 - float64(elapsed)/float64(window)
+	estimate := int(math.Ceil(float64(previous)*weight)) + int(count)
+
+	remaining := limit - estimate
+	if remaining < 0 {
+		remaining = 0
+	}
+	return Result{
+		Allowed:   estimate <= limit,
+		Limit:     limit,
+		Remaining: remaining,
+		Reset:     window - elapsed,
+	}, nil
+}
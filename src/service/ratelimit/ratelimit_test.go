@@ -0,0 +1,70 @@
+package ratelimit
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	_ "sherman/src/app/testing"
+	"testing"
+	"time"
+)
+
+// failingStore ratelimit.Store failing every call, sherman/mocks can't be used as it depends on this package
+type failingStore struct{}
+
+func (failingStore) Increment(key string, ttl time.Duration) (int64, error) {
+	return 0, errors.New("some error")
+}
+
+func (failingStore) Get(key string) (int64, error) {
+	return 0, errors.New("some error")
+}
+
+func TestAllow(t *testing.T) {
+	window := time.Minute
+	windowStart := time.Unix(0, 0).Add(1000 * window)
+
+	t.Run("it should allow the requests up to the limit", func(t *testing.T) {
+		store := NewMemoryStore()
+		now := windowStart.Add(15 * time.Second)
+
+		for i := 1; i <= 3; i++ {
+			result, err := Allow(store, "some-key", 3, window, now)
+			if assert.NoError(t, err) {
+				assert.True(t, result.Allowed)
+				assert.Equal(t, 3-i, result.Remaining)
+				assert.Equal(t, 45*time.Second, result.Reset)
+			}
+		}
+
+		result, err := Allow(store, "some-key", 3, window, now)
+		if assert.NoError(t, err) {
+			assert.False(t, result.Allowed)
+			assert.Equal(t, 0, result.Remaining)
+		}
+
+		result, err = Allow(store, "other-key", 3, window, now)
+		if assert.NoError(t, err) {
+			assert.True(t, result.Allowed)
+		}
+	})
+
+	t.Run("it should weight the previous window", func(t *testing.T) {
+		store := NewMemoryStore()
+		for i := 0; i < 8; i++ {
+			_, _ = Allow(store, "some-key", 10, window, windowStart.Add(-time.Second))
+		}
+
+		// a quarter in the window, 3/4 of the 8 previous requests are still counted
+		result, err := Allow(store, "some-key", 10, window, windowStart.Add(15*time.Second))
+		if assert.NoError(t, err) {
+			assert.True(t, result.Allowed)
+			assert.Equal(t, 3, result.Remaining)
+		}
+	})
+
+	t.Run("it should return error", func(t *testing.T) {
+		_, err := Allow(failingStore{}, "some-key", 10, window, windowStart)
+
+		assert.EqualError(t, err, "some error")
+	})
+}
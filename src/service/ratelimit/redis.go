@@ -0,0 +1,73 @@
+package ratelimit
+
+import (
+	"github.com/gomodule/redigo/redis"
+	"sherman/src/app/config"
+	"time"
+)
+
+const (
+	// redisPoolSize max number of idle connections kept by the redis store
+	redisPoolSize = 10
+	// redisTimeout deadline of a connection dial and of a command round trip
+	redisTimeout = time.Second
+)
+
+type redisStore struct {
+	pool *redis.Pool
+}
+
+// NewRedisStore returns an instance of ratelimit.Store backed by a redis server, counters are shared
+// by every process using the same server
+func NewRedisStore(cfg config.RedisConfig) Store {
+	return &redisStore{
+		pool: &redis.Pool{
+			MaxIdle: redisPoolSize,
+			Dial: func() (redis.Conn, error) {
+				return redis.Dial(
+					"tcp",
+					cfg.Addr,
+					redis.DialPassword(cfg.Password),
+					redis.DialDatabase(cfg.DB),
+					redis.DialConnectTimeout(redisTimeout),
+					redis.DialReadTimeout(redisTimeout),
+					redis.DialWriteTimeout(redisTimeout),
+				)
+			},
+		},
+	}
+}
+
+// Increment increments the counter of key, created with ttl when missing, the counter creation
+// and its increment are pipelined in a single round trip
+func (s *redisStore) Increment(key string, ttl time.Duration) (int64, error) {
+	conn := s.pool.Get()
+	defer conn.Close()
+
+	if err := conn.Send("SET", key, 0, "PX", ttl.Milliseconds(), "NX"); err != nil {
+		return 0, err
+	}
+	if err := conn.Send("INCR", key); err != nil {
+		return 0, err
+	}
+	if err := conn.Flush(); err != nil {
+		return 0, err
+	}
+
+	if _, err := conn.Receive(); err != nil {
+		return 0, err
+	}
+	return redis.Int64(conn.Receive())
+}
+
+// Get gets the value of the counter of key, 0 when missing
+func (s *redisStore) Get(key string) (int64, error) {
+	conn := s.pool.Get()
+	defer conn.Close()
+
+	value, err := redis.Int64(conn.Do("GET", key))
+	if err == redis.ErrNil {
+		return 0, nil
+	}
+	return value, err
+}
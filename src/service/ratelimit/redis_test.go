@@ -0,0 +1,217 @@
+package ratelimit
+
+import (
+	"bufio"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"net"
+	"sherman/src/app/config"
+	_ "sherman/src/app/testing"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeRedis minimal server speaking the redis protocol, it knows the commands used by the redis store
+// so the store is tested without a redis server
+type fakeRedis struct {
+	mu       sync.Mutex
+	password string
+	values   map[string]string
+	expires  map[string]time.Time
+	listener net.Listener
+}
+
+func newFakeRedis(t *testing.T, password string) *fakeRedis {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected", err)
+	}
+	f := &fakeRedis{
+		password: password,
+		values:   make(map[string]string),
+		expires:  make(map[string]time.Time),
+		listener: listener,
+	}
+	go f.serve()
+	return f
+}
+
+func (f *fakeRedis) serve() {
+	for {
+		conn, err := f.listener.Accept()
+		if err != nil {
+			return
+		}
+		go f.handle(conn)
+	}
+}
+
+func (f *fakeRedis) handle(conn net.Conn) {
+	defer conn.Close()
+	reader := bufio.NewReader(conn)
+	authenticated := f.password == ""
+
+	for {
+		args, err := readCommand(reader)
+		if err != nil {
+			return
+		}
+
+		var reply string
+		switch cmd := strings.ToUpper(args[0]); {
+		case cmd == "AUTH":
+			authenticated = args[1] == f.password
+			reply = "+OK\r\n"
+			if !authenticated {
+				reply = "-WRONGPASS invalid password\r\n"
+			}
+		case !authenticated:
+			reply = "-NOAUTH Authentication required.\r\n"
+		case cmd == "SELECT":
+			reply = "+OK\r\n"
+		default:
+			reply = f.exec(cmd, args[1:])
+		}
+
+		if _, err := conn.Write([]byte(reply)); err != nil {
+			return
+		}
+	}
+}
+
+func (f *fakeRedis) exec(cmd string, args []string) string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	key := args[0]
+	if expiresAt, ok := f.expires[key]; ok && !time.Now().Before(expiresAt) {
+		delete(f.values, key)
+		delete(f.expires, key)
+	}
+	value, exists := f.values[key]
+
+	switch cmd {
+	case "SET":
+		if exists && len(args) > 4 && strings.ToUpper(args[4]) == "NX" {
+			return "$-1\r\n"
+		}
+		f.values[key] = args[1]
+		if len(args) > 3 && strings.ToUpper(args[2]) == "PX" {
+			ms, _ := strconv.Atoi(args[3])
+			f.expires[key] = time.Now().Add(time.Duration(ms) * time.Millisecond)
+		}
+		return "+OK\r\n"
+	case "INCR":
+		n, err := strconv.ParseInt("0"+value, 10, 64)
+		if err != nil {
+			return "-ERR value is not an integer or out of range\r\n"
+		}
+		f.values[key] = strconv.FormatInt(n+1, 10)
+		return fmt.Sprintf(":%d\r\n", n+1)
+	case "GET":
+		if !exists {
+			return "$-1\r\n"
+		}
+		return fmt.Sprintf("$%d\r\n%s\r\n", len(value), value)
+	default:
+		return "-ERR unknown command\r\n"
+	}
+}
+
+func readCommand(reader *bufio.Reader) ([]string, error) {
+	line, err := reader.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	count, _ := strconv.Atoi(strings.TrimSpace(line[1:]))
+	args := make([]string, count)
+	for i := range args {
+		if _, err := reader.ReadString('\n'); err != nil {
+			return nil, err
+		}
+		arg, err := reader.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		args[i] = strings.TrimSuffix(arg, "\r\n")
+	}
+	return args, nil
+}
+
+func TestRedisStore(t *testing.T) {
+	t.Run("it should increment and get a counter", func(t *testing.T) {
+		f := newFakeRedis(t, "some-password")
+		defer f.listener.Close()
+		store := NewRedisStore(config.RedisConfig{Addr: f.listener.Addr().String(), Password: "some-password", DB: 1})
+
+		for i := int64(1); i <= 3; i++ {
+			value, err := store.Increment("some-key", time.Minute)
+			if assert.NoError(t, err) {
+				assert.Equal(t, i, value)
+			}
+		}
+
+		value, err := store.Get("some-key")
+		if assert.NoError(t, err) {
+			assert.Equal(t, int64(3), value)
+		}
+		value, err = store.Get("missing-key")
+		if assert.NoError(t, err) {
+			assert.Equal(t, int64(0), value)
+		}
+	})
+
+	t.Run("it should expire a counter", func(t *testing.T) {
+		f := newFakeRedis(t, "")
+		defer f.listener.Close()
+		store := NewRedisStore(config.RedisConfig{Addr: f.listener.Addr().String()})
+
+		_, _ = store.Increment("some-key", time.Millisecond)
+		time.Sleep(2 * time.Millisecond)
+
+		value, err := store.Increment("some-key", time.Minute)
+		if assert.NoError(t, err) {
+			assert.Equal(t, int64(1), value)
+		}
+	})
+
+	t.Run("it should return error", func(t *testing.T) {
+		f := newFakeRedis(t, "some-password")
+		defer f.listener.Close()
+		store := NewRedisStore(config.RedisConfig{Addr: f.listener.Addr().String(), Password: "wrong-password"})
+
+		_, err := store.Increment("some-key", time.Minute)
+
+		assert.EqualError(t, err, "WRONGPASS invalid password")
+	})
+
+	t.Run("it should return error", func(t *testing.T) {
+		f := newFakeRedis(t, "")
+		defer f.listener.Close()
+		f.values["some-key"] = "not-a-number"
+		store := NewRedisStore(config.RedisConfig{Addr: f.listener.Addr().String()})
+
+		_, err := store.Increment("some-key", time.Minute)
+		assert.EqualError(t, err, "ERR value is not an integer or out of range")
+
+		// the connection is still usable after a command error
+		value, err := store.Get("other-key")
+		if assert.NoError(t, err) {
+			assert.Equal(t, int64(0), value)
+		}
+	})
+
+	t.Run("it should return error", func(t *testing.T) {
+		f := newFakeRedis(t, "")
+		addr := f.listener.Addr().String()
+		f.listener.Close()
+		store := NewRedisStore(config.RedisConfig{Addr: addr})
+
+		_, err := store.Get("some-key")
+
+		assert.Error(t, err)
+	})
+}
@@ -2,8 +2,10 @@ package security
 
 import (
 	"github.com/labstack/echo/v4"
+	"github.com/rs/zerolog/log"
 	"sherman/src/app/config"
 	"sherman/src/domain/auth"
+	"time"
 )
 
 type (
@@ -13,19 +15,39 @@ type (
 		Hash(password string) ([]byte, error)
 		VerifyPassword(hashedPassword, password string) error
 		// token
-		GenToken(userID, tokenType string, iat, exp int64) (string, error)
+		GenToken(userID, tokenType string, roles []string, iat, exp int64) (string, error)
+		GenScopedToken(subject, tokenType, clientID string, scopes, roles []string, iat, exp int64) (string, error)
 		GetAndValidateAccessToken(ctx echo.Context) (auth.TokenMetadata, error)
 		GetAndValidateRefreshToken(ctx echo.Context) (auth.TokenMetadata, error)
+		ValidateToken(tokenStr, tokenType string) (auth.TokenMetadata, error)
+		GetJWKS() JWKS
+		// encryption
+		Encrypt(plaintext string) (string, error)
+		Decrypt(ciphertext string) (string, error)
+		EncryptState(plaintext string) (string, error)
+		DecryptState(ciphertext string) (string, error)
+		// totp
+		GenTOTPSecret() (string, error)
+		ValidateTOTP(secret, code string, t time.Time) (int64, bool)
 	}
 
 	service struct {
-		config *config.GlobalConfig
+		config        *config.GlobalConfig
+		signingKey    *jwtKey
+		verifyingKeys map[string]*jwtKey
 	}
 )
 
-// New returns an instance of security.Security
+// New returns an instance of security.Security, tokens can't be issued if the jwt keys fail to load
 func New(cfg *config.GlobalConfig) Security {
+	signingKey, verifyingKeys, err := loadKeys(&cfg.Jwt)
+	if err != nil {
+		log.Error().Msg("security error: " + err.Error())
+	}
+
 	return &service{
-		config: cfg,
+		config:        cfg,
+		signingKey:    signingKey,
+		verifyingKeys: verifyingKeys,
 	}
 }
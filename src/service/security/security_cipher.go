@@ -0,0 +1,79 @@
+package security
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"io"
+)
+
+// aead returns the AES-256-GCM cipher of the key derived from secret
+func aead(secret string) (cipher.AEAD, error) {
+	if secret == "" {
+		return nil, errors.New("encryption key not found")
+	}
+
+	key := sha256.Sum256([]byte(secret))
+	block, err := aes.NewCipher(key[:])
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// seal encrypts plaintext with the key derived from secret, the result is the base64 encoded random nonce
+// followed by the sealed plaintext
+func seal(secret, plaintext string) (string, error) {
+	aead, err := aead(secret)
+	if err != nil {
+		return "", err
+	}
+
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return "", err
+	}
+	sealed := aead.Seal(nonce, nonce, []byte(plaintext), nil)
+	return base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// open decrypts a ciphertext sealed by seal with the same secret
+func open(secret, ciphertext string) (string, error) {
+	aead, err := aead(secret)
+	if err != nil {
+		return "", err
+	}
+
+	sealed, err := base64.StdEncoding.DecodeString(ciphertext)
+	if err != nil || len(sealed) < aead.NonceSize() {
+		return "", errors.New("malformed ciphertext")
+	}
+	plaintext, err := aead.Open(nil, sealed[:aead.NonceSize()], sealed[aead.NonceSize():], nil)
+	if err != nil {
+		return "", errors.New("could not decrypt ciphertext")
+	}
+	return string(plaintext), nil
+}
+
+// Encrypt encrypts a secret to be stored at rest with the mfa encryption key
+func (s *service) Encrypt(plaintext string) (string, error) {
+	return seal(s.config.MFA.EncryptionKey, plaintext)
+}
+
+// Decrypt decrypts a secret encrypted by Encrypt
+func (s *service) Decrypt(ciphertext string) (string, error) {
+	return open(s.config.MFA.EncryptionKey, ciphertext)
+}
+
+// EncryptState encrypts the state of a social login kept by the user agent with the oidc state key
+func (s *service) EncryptState(plaintext string) (string, error) {
+	return seal(s.config.OIDC.StateKey, plaintext)
+}
+
+// DecryptState decrypts a state encrypted by EncryptState
+func (s *service) DecryptState(ciphertext string) (string, error) {
+	return open(s.config.OIDC.StateKey, ciphertext)
+}
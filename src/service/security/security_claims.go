@@ -0,0 +1,82 @@
+package security
+
+import (
+	"encoding/json"
+	"github.com/dgrijalva/jwt-go"
+	"sherman/src/app/utils/terr"
+	"time"
+)
+
+// validateClaims validates the registered claims of a token, exp is required and
+// exp, nbf and iat are checked with the configured clock skew leeway, errors are typed terr errors
+func (s *service) validateClaims(claims jwt.MapClaims) error {
+	now := time.Now().Unix()
+	leeway := int64(s.config.Jwt.Leeway)
+
+	exp, ok := NumericClaim(claims, "exp")
+	if !ok {
+		return terr.NewMalformedTokenError("token has no expiration")
+	}
+	if now > exp+leeway {
+		return terr.NewExpiredTokenError("token is expired")
+	}
+
+	if nbf, ok := NumericClaim(claims, "nbf"); ok && now+leeway < nbf {
+		return terr.NewUnAuthorizedError("token is not valid yet")
+	}
+
+	if iat, ok := NumericClaim(claims, "iat"); ok && now+leeway < iat {
+		return terr.NewUnAuthorizedError("token used before issued")
+	}
+
+	if iss, _ := claims["iss"].(string); iss != s.config.Jwt.Issuer {
+		return terr.NewUnAuthorizedError("invalid token issuer")
+	}
+
+	if !ContainsString(StringsClaim(claims, "aud"), s.config.Jwt.Audience) {
+		return terr.NewUnAuthorizedError("invalid token audience")
+	}
+
+	return nil
+}
+
+// NumericClaim gets a NumericDate claim as unix seconds
+func NumericClaim(claims jwt.MapClaims, name string) (int64, bool) {
+	switch value := claims[name].(type) {
+	case float64:
+		return int64(value), true
+	case json.Number:
+		v, err := value.Int64()
+		return v, err == nil
+	default:
+		return 0, false
+	}
+}
+
+// StringsClaim gets a claim that is a single string or an array of strings (e.g. aud)
+func StringsClaim(claims jwt.MapClaims, name string) []string {
+	switch value := claims[name].(type) {
+	case string:
+		return []string{value}
+	case []interface{}:
+		values := make([]string, 0, len(value))
+		for _, v := range value {
+			if str, ok := v.(string); ok {
+				values = append(values, str)
+			}
+		}
+		return values
+	default:
+		return nil
+	}
+}
+
+// ContainsString checks if values contains value
+func ContainsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
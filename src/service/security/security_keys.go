@@ -0,0 +1,205 @@
+package security
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"github.com/dgrijalva/jwt-go"
+	"io/ioutil"
+	"math/big"
+	"sherman/src/app/config"
+	"sort"
+)
+
+type (
+	// JWK public json web key
+	JWK struct {
+		Kty string `json:"kty"`
+		Use string `json:"use"`
+		Alg string `json:"alg"`
+		Kid string `json:"kid"`
+		Crv string `json:"crv,omitempty"`
+		N   string `json:"n,omitempty"`
+		E   string `json:"e,omitempty"`
+		X   string `json:"x,omitempty"`
+		Y   string `json:"y,omitempty"`
+	}
+	// JWKS json web key set
+	JWKS struct {
+		Keys []JWK `json:"keys"`
+	}
+
+	// jwtKey a key able to verify tokens, and to sign them when privateKey is set
+	jwtKey struct {
+		id         string
+		method     jwt.SigningMethod
+		privateKey interface{}
+		publicKey  interface{}
+	}
+)
+
+// loadKeys loads the signing key and the verifying keys (by kid) of a config.JwtConfig,
+// HS256 tokens are signed with the secret and carry no kid
+func loadKeys(cfg *config.JwtConfig) (*jwtKey, map[string]*jwtKey, error) {
+	if cfg.Algorithm == jwt.SigningMethodHS256.Alg() {
+		key := &jwtKey{
+			method:     jwt.SigningMethodHS256,
+			privateKey: []byte(cfg.Secret),
+			publicKey:  []byte(cfg.Secret),
+		}
+		return key, map[string]*jwtKey{"": key}, nil
+	}
+
+	switch cfg.Algorithm {
+	case jwt.SigningMethodRS256.Alg(), jwt.SigningMethodES256.Alg(), SigningMethodEdDSA.Alg():
+	default:
+		return nil, nil, fmt.Errorf("jwt algorithm %s not supported", cfg.Algorithm)
+	}
+
+	if len(cfg.Keys) == 0 {
+		return nil, nil, fmt.Errorf("jwt algorithm %s requires at least one key", cfg.Algorithm)
+	}
+
+	verifyingKeys := make(map[string]*jwtKey)
+	for _, keyConfig := range cfg.Keys {
+		data, err := ioutil.ReadFile(keyConfig.Path)
+		if err != nil {
+			return nil, nil, fmt.Errorf("could not read jwt key %s: %s", keyConfig.ID, err.Error())
+		}
+
+		key, err := parsePEMKey(keyConfig.ID, data)
+		if err != nil {
+			return nil, nil, fmt.Errorf("could not parse jwt key %s: %s", keyConfig.ID, err.Error())
+		}
+		verifyingKeys[key.id] = key
+	}
+
+	keyID := cfg.KeyID
+	if keyID == "" {
+		keyID = cfg.Keys[0].ID
+	}
+	signingKey, ok := verifyingKeys[keyID]
+	switch {
+	case !ok:
+		return nil, nil, fmt.Errorf("jwt signing key %s not found", keyID)
+	case signingKey.privateKey == nil:
+		return nil, nil, fmt.Errorf("jwt signing key %s has no private key", keyID)
+	case signingKey.method.Alg() != cfg.Algorithm:
+		return nil, nil, fmt.Errorf("jwt signing key %s is not a %s key", keyID, cfg.Algorithm)
+	}
+
+	return signingKey, verifyingKeys, nil
+}
+
+// parsePEMKey parses a PEM encoded private or public key, the signing method is given by the key type
+func parsePEMKey(id string, data []byte) (*jwtKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("no PEM data found")
+	}
+
+	var err error
+	var parsedKey interface{}
+	switch block.Type {
+	case "PRIVATE KEY":
+		parsedKey, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	case "RSA PRIVATE KEY":
+		parsedKey, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "EC PRIVATE KEY":
+		parsedKey, err = x509.ParseECPrivateKey(block.Bytes)
+	case "PUBLIC KEY":
+		parsedKey, err = x509.ParsePKIXPublicKey(block.Bytes)
+	case "RSA PUBLIC KEY":
+		parsedKey, err = x509.ParsePKCS1PublicKey(block.Bytes)
+	default:
+		err = fmt.Errorf("PEM type %s not supported", block.Type)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	key := &jwtKey{id: id}
+	if signer, ok := parsedKey.(crypto.Signer); ok {
+		key.privateKey = parsedKey
+		key.publicKey = signer.Public()
+	} else {
+		key.publicKey = parsedKey
+	}
+
+	switch publicKey := key.publicKey.(type) {
+	case *rsa.PublicKey:
+		key.method = jwt.SigningMethodRS256
+	case *ecdsa.PublicKey:
+		if publicKey.Curve != elliptic.P256() {
+			return nil, errors.New("only P-256 ecdsa keys are supported")
+		}
+		key.method = jwt.SigningMethodES256
+	case ed25519.PublicKey:
+		key.method = SigningMethodEdDSA
+	default:
+		return nil, errors.New("key type not supported")
+	}
+
+	return key, nil
+}
+
+// jwk returns the public JWK of the key
+func (k *jwtKey) jwk() JWK {
+	jwk := JWK{
+		Use: "sig",
+		Alg: k.method.Alg(),
+		Kid: k.id,
+	}
+
+	switch publicKey := k.publicKey.(type) {
+	case *rsa.PublicKey:
+		jwk.Kty = "RSA"
+		jwk.N = base64.RawURLEncoding.EncodeToString(publicKey.N.Bytes())
+		jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(publicKey.E)).Bytes())
+	case *ecdsa.PublicKey:
+		size := (publicKey.Curve.Params().BitSize + 7) / 8
+		jwk.Kty = "EC"
+		jwk.Crv = publicKey.Curve.Params().Name
+		jwk.X = base64.RawURLEncoding.EncodeToString(padBytes(publicKey.X.Bytes(), size))
+		jwk.Y = base64.RawURLEncoding.EncodeToString(padBytes(publicKey.Y.Bytes(), size))
+	case ed25519.PublicKey:
+		jwk.Kty = "OKP"
+		jwk.Crv = "Ed25519"
+		jwk.X = base64.RawURLEncoding.EncodeToString(publicKey)
+	}
+
+	return jwk
+}
+
+// padBytes left pads b with zeros up to size
+func padBytes(b []byte, size int) []byte {
+	if len(b) >= size {
+		return b
+	}
+	padded := make([]byte, size)
+	copy(padded[size-len(b):], b)
+	return padded
+}
+
+// GetJWKS gets the public keys that verify issued tokens, symmetric keys are never published
+func (s *service) GetJWKS() JWKS {
+	jwks := JWKS{Keys: []JWK{}}
+	for _, key := range s.verifyingKeys {
+		if _, ok := key.method.(*jwt.SigningMethodHMAC); ok {
+			continue
+		}
+		jwks.Keys = append(jwks.Keys, key.jwk())
+	}
+
+	sort.Slice(jwks.Keys, func(i, j int) bool {
+		return jwks.Keys[i].Kid < jwks.Keys[j].Kid
+	})
+	return jwks
+}
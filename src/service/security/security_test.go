@@ -1,18 +1,28 @@
 package security
 
 import (
+	"crypto"
 	"crypto/ecdsa"
+	"crypto/ed25519"
 	"crypto/elliptic"
 	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
 	"errors"
 	"github.com/dgrijalva/jwt-go"
 	"github.com/labstack/echo/v4"
 	"github.com/stretchr/testify/assert"
 	"golang.org/x/crypto/bcrypt"
+	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"sherman/src/app/config"
 	_ "sherman/src/app/testing"
+	"sherman/src/app/utils/terr"
 	"sherman/src/domain/auth"
 	"strings"
 	"testing"
@@ -50,11 +60,18 @@ func TestGenToken(t *testing.T) {
 	mockIat := time.Now().Unix()
 	mockExp := time.Now().Add(time.Minute * time.Duration(15)).Unix()
 
-	tokenStr, err := New(config.Get()).GenToken(mockUserID, mockTokenType, mockIat, mockExp)
+	mockRoles := []string{auth.AdminRole}
+
+	tokenStr, err := New(config.Get()).GenToken(mockUserID, mockTokenType, mockRoles, mockIat, mockExp)
 	if assert.NoError(t, err) {
 		assert.NotEmpty(t, tokenStr)
 	}
 
+	otherTokenStr, err := New(config.Get()).GenToken(mockUserID, mockTokenType, nil, mockIat, mockExp)
+	if assert.NoError(t, err) {
+		assert.NotEqual(t, tokenStr, otherTokenStr)
+	}
+
 	token, err := jwt.Parse(tokenStr, func(token *jwt.Token) (interface{}, error) {
 		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
 			return nil, errors.New("invalid refresh token")
@@ -67,16 +84,29 @@ func TestGenToken(t *testing.T) {
 	}
 	claims := token.Claims.(jwt.MapClaims)
 
+	assert.NotEmpty(t, claims["jti"])
+	assert.EqualValues(t, claims["sub"], mockUserID)
+	assert.EqualValues(t, claims["iss"], config.Get().Jwt.Issuer)
+	assert.EqualValues(t, claims["aud"], config.Get().Jwt.Audience)
+	assert.EqualValues(t, claims["nbf"], mockIat)
 	assert.EqualValues(t, claims["user_id"], mockUserID)
 	assert.EqualValues(t, claims["type"], mockTokenType)
 	assert.EqualValues(t, claims["iat"], mockIat)
 	assert.EqualValues(t, claims["exp"], mockExp)
+	assert.EqualValues(t, claims["roles"], []interface{}{auth.AdminRole})
+
+	token, _, err = new(jwt.Parser).ParseUnverified(otherTokenStr, jwt.MapClaims{})
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected", err)
+	}
+	_, ok := token.Claims.(jwt.MapClaims)["roles"]
+	assert.False(t, ok)
 }
 
 func TestGetAndValidateAccessToken(t *testing.T) {
 	ss := New(config.Get())
 	mockUserID := "some-user-id"
-	mockTokenType := "some-token-type"
+	mockTokenType := auth.AccessTokenType
 	mockIat := time.Now().Unix()
 	mockExp := time.Now().Add(time.Minute * time.Duration(15)).Unix()
 
@@ -87,14 +117,19 @@ func TestGetAndValidateAccessToken(t *testing.T) {
 			t.Fatalf("an error '%s' was not expected", err)
 		}
 
-		mockTokenStr, err := New(config.Get()).GenToken(mockUserID, mockTokenType, mockIat, mockExp)
+		mockTokenStr, err := New(config.Get()).GenToken(mockUserID, mockTokenType, nil, mockIat, mockExp)
 		if err != nil {
 			t.Fatalf("an error '%s' was not expected", err)
 		}
 		mockTokenMeta := auth.TokenMetadata{
-			UserID: mockUserID,
-			Type:   mockTokenType,
-			Token:  mockTokenStr,
+			UserID:    mockUserID,
+			Type:      mockTokenType,
+			Token:     mockTokenStr,
+			Issuer:    config.Get().Jwt.Issuer,
+			Audience:  []string{config.Get().Jwt.Audience},
+			IssuedAt:  mockIat,
+			NotBefore: mockIat,
+			ExpiresAt: mockExp,
 		}
 
 		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
@@ -104,6 +139,8 @@ func TestGetAndValidateAccessToken(t *testing.T) {
 
 		tokenMeta, err := ss.GetAndValidateAccessToken(ctx)
 		if assert.NoError(t, err) {
+			assert.NotEmpty(t, tokenMeta.ID)
+			mockTokenMeta.ID = tokenMeta.ID
 			assert.Equal(t, mockTokenMeta, tokenMeta)
 		}
 	})
@@ -156,7 +193,7 @@ func TestGetAndValidateAccessToken(t *testing.T) {
 
 		tokenMeta, err := ss.GetAndValidateAccessToken(ctx)
 		if assert.Error(t, err) {
-			assert.Equal(t, "invalid token", err.Error())
+			assert.IsType(t, &terr.TokenSignatureError{}, err)
 			assert.Equal(t, auth.TokenMetadata{}, tokenMeta)
 		}
 	})
@@ -187,7 +224,7 @@ func TestGetAndValidateAccessToken(t *testing.T) {
 
 		tokenMeta, err := ss.GetAndValidateAccessToken(ctx)
 		if assert.Error(t, err) {
-			assert.Equal(t, "invalid token", err.Error())
+			assert.IsType(t, &terr.TokenSignatureError{}, err)
 			assert.Equal(t, auth.TokenMetadata{}, tokenMeta)
 		}
 	})
@@ -201,6 +238,10 @@ func TestGetAndValidateAccessToken(t *testing.T) {
 		}
 
 		token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+			"jti":     "some-token-id",
+			"sub":     mockUserID,
+			"iss":     config.Get().Jwt.Issuer,
+			"aud":     config.Get().Jwt.Audience,
 			"user_id": mockUserID,
 			"type":    mockTokenType,
 			"iat":     time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC).Unix(),
@@ -218,7 +259,7 @@ func TestGetAndValidateAccessToken(t *testing.T) {
 
 		tokenMeta, err := ss.GetAndValidateAccessToken(ctx)
 		if assert.Error(t, err) {
-			assert.Equal(t, "invalid token", err.Error())
+			assert.IsType(t, &terr.ExpiredTokenError{}, err)
 			assert.Equal(t, auth.TokenMetadata{}, tokenMeta)
 		}
 	})
@@ -244,7 +285,7 @@ func TestGetAndValidateAccessToken(t *testing.T) {
 
 		tokenMeta, err := ss.GetAndValidateAccessToken(ctx)
 		if assert.Error(t, err) {
-			assert.Equal(t, "invalid token", err.Error())
+			assert.IsType(t, &terr.MalformedTokenError{}, err)
 			assert.Equal(t, auth.TokenMetadata{}, tokenMeta)
 		}
 
@@ -263,7 +304,7 @@ func TestGetAndValidateAccessToken(t *testing.T) {
 
 		tokenMeta, err = ss.GetAndValidateAccessToken(ctx)
 		if assert.Error(t, err) {
-			assert.Equal(t, "invalid token", err.Error())
+			assert.IsType(t, &terr.MalformedTokenError{}, err)
 			assert.Equal(t, auth.TokenMetadata{}, tokenMeta)
 		}
 	})
@@ -272,7 +313,7 @@ func TestGetAndValidateAccessToken(t *testing.T) {
 func TestGetAndValidateRefreshToken(t *testing.T) {
 	ss := New(config.Get())
 	mockUserID := "some-user-id"
-	mockTokenType := "some-token-type"
+	mockTokenType := auth.RefreshTokenType
 	mockIat := time.Now().Unix()
 	mockExp := time.Now().Add(time.Minute * time.Duration(15)).Unix()
 
@@ -283,14 +324,19 @@ func TestGetAndValidateRefreshToken(t *testing.T) {
 			t.Fatalf("an error '%s' was not expected", err)
 		}
 
-		mockTokenStr, err := New(config.Get()).GenToken(mockUserID, mockTokenType, mockIat, mockExp)
+		mockTokenStr, err := New(config.Get()).GenToken(mockUserID, mockTokenType, nil, mockIat, mockExp)
 		if err != nil {
 			t.Fatalf("an error '%s' was not expected", err)
 		}
 		mockTokenMeta := auth.TokenMetadata{
-			UserID: mockUserID,
-			Type:   mockTokenType,
-			Token:  mockTokenStr,
+			UserID:    mockUserID,
+			Type:      mockTokenType,
+			Token:     mockTokenStr,
+			Issuer:    config.Get().Jwt.Issuer,
+			Audience:  []string{config.Get().Jwt.Audience},
+			IssuedAt:  mockIat,
+			NotBefore: mockIat,
+			ExpiresAt: mockExp,
 		}
 
 		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
@@ -308,6 +354,8 @@ func TestGetAndValidateRefreshToken(t *testing.T) {
 
 		tokenMeta, err := ss.GetAndValidateRefreshToken(ctx)
 		if assert.NoError(t, err) {
+			assert.NotEmpty(t, tokenMeta.ID)
+			mockTokenMeta.ID = tokenMeta.ID
 			assert.Equal(t, mockTokenMeta, tokenMeta)
 		}
 	})
@@ -368,7 +416,7 @@ func TestGetAndValidateRefreshToken(t *testing.T) {
 
 		tokenMeta, err := ss.GetAndValidateRefreshToken(ctx)
 		if assert.Error(t, err) {
-			assert.Equal(t, "invalid refresh token", err.Error())
+			assert.IsType(t, &terr.TokenSignatureError{}, err)
 			assert.Equal(t, auth.TokenMetadata{}, tokenMeta)
 		}
 	})
@@ -407,7 +455,7 @@ func TestGetAndValidateRefreshToken(t *testing.T) {
 
 		tokenMeta, err := ss.GetAndValidateRefreshToken(ctx)
 		if assert.Error(t, err) {
-			assert.Equal(t, "invalid refresh token", err.Error())
+			assert.IsType(t, &terr.TokenSignatureError{}, err)
 			assert.Equal(t, auth.TokenMetadata{}, tokenMeta)
 		}
 	})
@@ -421,6 +469,10 @@ func TestGetAndValidateRefreshToken(t *testing.T) {
 		}
 
 		token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+			"jti":     "some-token-id",
+			"sub":     mockUserID,
+			"iss":     config.Get().Jwt.Issuer,
+			"aud":     config.Get().Jwt.Audience,
 			"user_id": mockUserID,
 			"type":    mockTokenType,
 			"iat":     time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC).Unix(),
@@ -446,7 +498,7 @@ func TestGetAndValidateRefreshToken(t *testing.T) {
 
 		tokenMeta, err := ss.GetAndValidateRefreshToken(ctx)
 		if assert.Error(t, err) {
-			assert.Equal(t, "invalid refresh token", err.Error())
+			assert.IsType(t, &terr.ExpiredTokenError{}, err)
 			assert.Equal(t, auth.TokenMetadata{}, tokenMeta)
 		}
 	})
@@ -480,8 +532,635 @@ func TestGetAndValidateRefreshToken(t *testing.T) {
 
 		tokenMeta, err := ss.GetAndValidateRefreshToken(ctx)
 		if assert.Error(t, err) {
-			assert.Equal(t, "invalid refresh token", err.Error())
+			assert.IsType(t, &terr.MalformedTokenError{}, err)
 			assert.Equal(t, auth.TokenMetadata{}, tokenMeta)
 		}
 	})
 }
+
+func TestGenScopedToken(t *testing.T) {
+	ss := New(config.Get())
+	mockIat := time.Now().Unix()
+	mockExp := time.Now().Add(time.Minute * time.Duration(15)).Unix()
+
+	t.Run("it should issue a token to a client", func(t *testing.T) {
+		tokenStr, err := ss.GenScopedToken(
+			"some-user-id",
+			auth.AccessTokenType,
+			"some-client-id",
+			[]string{auth.ProfileScope, auth.AccountScope},
+			[]string{auth.AdminRole},
+			mockIat,
+			mockExp,
+		)
+		if err != nil {
+			t.Fatalf("an error '%s' was not expected", err)
+		}
+
+		tokenMeta, err := ss.ValidateToken(tokenStr, auth.AccessTokenType)
+		if assert.NoError(t, err) {
+			assert.Equal(t, "some-user-id", tokenMeta.UserID)
+			assert.Equal(t, "some-client-id", tokenMeta.ClientID)
+			assert.Equal(t, []string{auth.ProfileScope, auth.AccountScope}, tokenMeta.Scopes)
+			assert.Equal(t, []string{auth.AdminRole}, tokenMeta.Roles)
+		}
+
+		token, _, err := new(jwt.Parser).ParseUnverified(tokenStr, jwt.MapClaims{})
+		if assert.NoError(t, err) {
+			claims := token.Claims.(jwt.MapClaims)
+			assert.EqualValues(t, "profile account", claims["scope"])
+			assert.EqualValues(t, "some-user-id", claims["user_id"])
+		}
+	})
+
+	t.Run("it should issue a client access token without user", func(t *testing.T) {
+		tokenStr, err := ss.GenScopedToken(
+			"some-client-id",
+			auth.ClientAccessTokenType,
+			"some-client-id",
+			[]string{auth.ProfileScope},
+			nil,
+			mockIat,
+			mockExp,
+		)
+		if err != nil {
+			t.Fatalf("an error '%s' was not expected", err)
+		}
+
+		token, _, err := new(jwt.Parser).ParseUnverified(tokenStr, jwt.MapClaims{})
+		if assert.NoError(t, err) {
+			claims := token.Claims.(jwt.MapClaims)
+			assert.EqualValues(t, "some-client-id", claims["sub"])
+			assert.EqualValues(t, "some-client-id", claims["client_id"])
+			assert.Nil(t, claims["user_id"])
+		}
+	})
+
+	t.Run("it should issue a first party token without client", func(t *testing.T) {
+		tokenStr, err := ss.GenScopedToken("some-user-id", auth.AccessTokenType, "", nil, nil, mockIat, mockExp)
+		if err != nil {
+			t.Fatalf("an error '%s' was not expected", err)
+		}
+
+		tokenMeta, err := ss.ValidateToken(tokenStr, auth.AccessTokenType)
+		if assert.NoError(t, err) {
+			assert.Empty(t, tokenMeta.ClientID)
+			assert.Nil(t, tokenMeta.Scopes)
+			assert.True(t, tokenMeta.HasScope(auth.AdminScope))
+		}
+	})
+}
+
+func TestValidateToken(t *testing.T) {
+	ss := New(config.Get())
+	mockIat := time.Now().Unix()
+	mockExp := time.Now().Add(time.Hour).Unix()
+
+	t.Run("it should succeed", func(t *testing.T) {
+		tokenStr, err := ss.GenToken("some-user-id", auth.EmailVerificationTokenType, nil, mockIat, mockExp)
+		if err != nil {
+			t.Fatalf("an error '%s' was not expected", err)
+		}
+
+		tokenMeta, err := ss.ValidateToken(tokenStr, auth.EmailVerificationTokenType)
+		if assert.NoError(t, err) {
+			assert.Equal(t, "some-user-id", tokenMeta.UserID)
+			assert.Equal(t, auth.EmailVerificationTokenType, tokenMeta.Type)
+			assert.Equal(t, tokenStr, tokenMeta.Token)
+		}
+	})
+
+	t.Run("it should reject a token of another type", func(t *testing.T) {
+		tokenStr, err := ss.GenToken("some-user-id", auth.AccessTokenType, nil, mockIat, mockExp)
+		if err != nil {
+			t.Fatalf("an error '%s' was not expected", err)
+		}
+
+		_, err = ss.ValidateToken(tokenStr, auth.EmailVerificationTokenType)
+		if assert.Error(t, err) {
+			assert.IsType(t, &terr.TokenTypeError{}, err)
+		}
+	})
+
+	t.Run("it should reject an empty token", func(t *testing.T) {
+		_, err := ss.ValidateToken("", auth.EmailVerificationTokenType)
+		if assert.Error(t, err) {
+			assert.Equal(t, terr.NewUnAuthorizedError("token not found"), err)
+		}
+	})
+}
+
+func TestTokenTypeEnforcement(t *testing.T) {
+	ss := New(config.Get())
+	mockIat := time.Now().Unix()
+	mockExp := time.Now().Add(time.Hour * time.Duration(48)).Unix()
+
+	refreshTokenStr, err := ss.GenToken("some-user-id", auth.RefreshTokenType, nil, mockIat, mockExp)
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected", err)
+	}
+	accessTokenStr, err := ss.GenToken("some-user-id", auth.AccessTokenType, nil, mockIat, mockExp)
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected", err)
+	}
+
+	t.Run("it should reject a refresh token as access token", func(t *testing.T) {
+		tokenMeta, err := validateAccessToken(ss, refreshTokenStr)
+		if assert.Error(t, err) {
+			assert.Equal(t, terr.NewTokenTypeError("invalid token type"), err)
+			assert.Equal(t, auth.TokenMetadata{}, tokenMeta)
+		}
+	})
+
+	t.Run("it should reject an access token as refresh token", func(t *testing.T) {
+		e := echo.New()
+		req := httptest.NewRequest(echo.PATCH, "/some-url", nil)
+		req.AddCookie(&http.Cookie{Name: "REFRESH_TOKEN", Value: accessTokenStr})
+
+		tokenMeta, err := ss.GetAndValidateRefreshToken(e.NewContext(req, httptest.NewRecorder()))
+		if assert.Error(t, err) {
+			assert.Equal(t, terr.NewTokenTypeError("invalid token type"), err)
+			assert.Equal(t, auth.TokenMetadata{}, tokenMeta)
+		}
+	})
+
+	t.Run("it should return a malformed token error", func(t *testing.T) {
+		tokenMeta, err := validateAccessToken(ss, "some.malformed.token")
+		if assert.Error(t, err) {
+			assert.Equal(t, terr.NewMalformedTokenError("malformed token"), err)
+			assert.Equal(t, auth.TokenMetadata{}, tokenMeta)
+		}
+	})
+}
+
+func TestValidateClaims(t *testing.T) {
+	ss := New(config.Get())
+	cfg := config.Get().Jwt
+	now := time.Now().Unix()
+	leeway := int64(cfg.Leeway)
+
+	genClaims := func(overrides jwt.MapClaims) jwt.MapClaims {
+		claims := jwt.MapClaims{
+			"jti":     "some-token-id",
+			"sub":     "some-user-id",
+			"iss":     cfg.Issuer,
+			"aud":     cfg.Audience,
+			"nbf":     now,
+			"iat":     now,
+			"exp":     now + 900,
+			"user_id": "some-user-id",
+			"type":    auth.AccessTokenType,
+		}
+		for k, v := range overrides {
+			if v == nil {
+				delete(claims, k)
+				continue
+			}
+			claims[k] = v
+		}
+		return claims
+	}
+
+	t.Run("it should succeed", func(t *testing.T) {
+		tests := []jwt.MapClaims{
+			genClaims(nil),
+			genClaims(jwt.MapClaims{"aud": []interface{}{"some-other-audience", cfg.Audience}}),
+			genClaims(jwt.MapClaims{"exp": now - leeway + 1}),
+			genClaims(jwt.MapClaims{"nbf": now + leeway - 1, "iat": now + leeway - 1}),
+			genClaims(jwt.MapClaims{"nbf": nil, "iat": nil}),
+		}
+
+		for _, claims := range tests {
+			token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+			tokenStr, err := token.SignedString([]byte(cfg.Secret))
+			if err != nil {
+				t.Fatalf("an error '%s' was not expected", err)
+			}
+
+			_, err = validateAccessToken(ss, tokenStr)
+			assert.NoError(t, err)
+		}
+	})
+
+	t.Run("it should return error", func(t *testing.T) {
+		tests := []struct {
+			claims      jwt.MapClaims
+			expectedErr error
+		}{
+			{genClaims(jwt.MapClaims{"exp": nil}), terr.NewMalformedTokenError("token has no expiration")},
+			{genClaims(jwt.MapClaims{"exp": now - leeway - 1}), terr.NewExpiredTokenError("token is expired")},
+			{genClaims(jwt.MapClaims{"nbf": now + leeway + 1}), terr.NewUnAuthorizedError("token is not valid yet")},
+			{genClaims(jwt.MapClaims{"iat": now + leeway + 1}), terr.NewUnAuthorizedError("token used before issued")},
+			{genClaims(jwt.MapClaims{"iss": nil}), terr.NewUnAuthorizedError("invalid token issuer")},
+			{genClaims(jwt.MapClaims{"iss": "some-other-issuer"}), terr.NewUnAuthorizedError("invalid token issuer")},
+			{genClaims(jwt.MapClaims{"aud": nil}), terr.NewUnAuthorizedError("invalid token audience")},
+			{genClaims(jwt.MapClaims{"aud": "some-other-audience"}), terr.NewUnAuthorizedError("invalid token audience")},
+			{genClaims(jwt.MapClaims{"aud": []interface{}{"some-other-audience"}}), terr.NewUnAuthorizedError("invalid token audience")},
+			{genClaims(jwt.MapClaims{"jti": nil}), terr.NewMalformedTokenError("malformed token")},
+			{genClaims(jwt.MapClaims{"sub": nil}), terr.NewMalformedTokenError("malformed token")},
+			{genClaims(jwt.MapClaims{"type": auth.RefreshTokenType}), terr.NewTokenTypeError("invalid token type")},
+		}
+
+		for _, test := range tests {
+			token := jwt.NewWithClaims(jwt.SigningMethodHS256, test.claims)
+			tokenStr, err := token.SignedString([]byte(cfg.Secret))
+			if err != nil {
+				t.Fatalf("an error '%s' was not expected", err)
+			}
+
+			tokenMeta, err := validateAccessToken(ss, tokenStr)
+			if assert.Error(t, err, test.claims) {
+				assert.Equal(t, test.expectedErr, err)
+				assert.Equal(t, auth.TokenMetadata{}, tokenMeta)
+			}
+		}
+	})
+
+	t.Run("it should extract the roles", func(t *testing.T) {
+		token := jwt.NewWithClaims(jwt.SigningMethodHS256, genClaims(jwt.MapClaims{"roles": []interface{}{auth.AdminRole}}))
+		tokenStr, err := token.SignedString([]byte(cfg.Secret))
+		if err != nil {
+			t.Fatalf("an error '%s' was not expected", err)
+		}
+
+		tokenMeta, err := validateAccessToken(ss, tokenStr)
+		if assert.NoError(t, err) {
+			assert.Equal(t, []string{auth.AdminRole}, tokenMeta.Roles)
+			assert.True(t, tokenMeta.HasRole(auth.AdminRole))
+		}
+	})
+
+	t.Run("it should reject tokens of another deployment sharing the secret", func(t *testing.T) {
+		otherCfg := *config.Get()
+		otherCfg.Jwt.Issuer = "some-other-issuer"
+		otherCfg.Jwt.Audience = "some-other-audience"
+
+		tokenStr, err := New(&otherCfg).GenToken("some-user-id", auth.AccessTokenType, nil, now, now+900)
+		if err != nil {
+			t.Fatalf("an error '%s' was not expected", err)
+		}
+
+		_, err = validateAccessToken(ss, tokenStr)
+		assert.Error(t, err)
+	})
+}
+
+// writePEMKey writes a PKCS8 private key, or a PKIX public key, to a PEM file in dir
+func writePEMKey(t *testing.T, dir, name string, key interface{}) string {
+	var err error
+	var block pem.Block
+	if _, ok := key.(crypto.Signer); ok {
+		block.Type = "PRIVATE KEY"
+		block.Bytes, err = x509.MarshalPKCS8PrivateKey(key)
+	} else {
+		block.Type = "PUBLIC KEY"
+		block.Bytes, err = x509.MarshalPKIXPublicKey(key)
+	}
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected", err)
+	}
+
+	path := filepath.Join(dir, name+".pem")
+	if err := ioutil.WriteFile(path, pem.EncodeToMemory(&block), 0600); err != nil {
+		t.Fatalf("an error '%s' was not expected", err)
+	}
+	return path
+}
+
+func genJwtConfig(algorithm, keyID string, keys ...config.JwtKeyConfig) *config.GlobalConfig {
+	cfg := *config.Get()
+	cfg.Jwt = config.JwtConfig{
+		Secret:    cfg.Jwt.Secret,
+		Algorithm: algorithm,
+		KeyID:     keyID,
+		Keys:      keys,
+	}
+	return &cfg
+}
+
+func validateAccessToken(ss Security, tokenStr string) (auth.TokenMetadata, error) {
+	e := echo.New()
+	req := httptest.NewRequest(echo.GET, "/some-url", nil)
+	req.Header.Set("Authorization", "Bearer "+tokenStr)
+	return ss.GetAndValidateAccessToken(e.NewContext(req, httptest.NewRecorder()))
+}
+
+func TestAsymmetricKeys(t *testing.T) {
+	dir, err := ioutil.TempDir("", "sherman-jwt-keys")
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected", err)
+	}
+	defer os.RemoveAll(dir)
+
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected", err)
+	}
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected", err)
+	}
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected", err)
+	}
+
+	rsaKeyConfig := config.JwtKeyConfig{ID: "rsa-key", Path: writePEMKey(t, dir, "rsa-key", rsaKey)}
+	ecKeyConfig := config.JwtKeyConfig{ID: "ec-key", Path: writePEMKey(t, dir, "ec-key", ecKey)}
+	edKeyConfig := config.JwtKeyConfig{ID: "ed-key", Path: writePEMKey(t, dir, "ed-key", edKey)}
+	mockIat := time.Now().Unix()
+	mockExp := time.Now().Add(time.Minute * time.Duration(15)).Unix()
+
+	t.Run("it should sign and verify with every algorithm", func(t *testing.T) {
+		tests := []struct {
+			algorithm string
+			keyConfig config.JwtKeyConfig
+		}{
+			{algorithm: "RS256", keyConfig: rsaKeyConfig},
+			{algorithm: "ES256", keyConfig: ecKeyConfig},
+			{algorithm: "EdDSA", keyConfig: edKeyConfig},
+		}
+
+		for _, test := range tests {
+			ss := New(genJwtConfig(test.algorithm, "", test.keyConfig))
+			tokenStr, err := ss.GenToken("some-user-id", auth.AccessTokenType, nil, mockIat, mockExp)
+			if !assert.NoError(t, err) {
+				continue
+			}
+
+			token, _, err := new(jwt.Parser).ParseUnverified(tokenStr, jwt.MapClaims{})
+			if assert.NoError(t, err) {
+				assert.Equal(t, test.algorithm, token.Method.Alg())
+				assert.Equal(t, test.keyConfig.ID, token.Header["kid"])
+			}
+
+			tokenMeta, err := validateAccessToken(ss, tokenStr)
+			if assert.NoError(t, err) {
+				assert.Equal(t, "some-user-id", tokenMeta.UserID)
+			}
+		}
+	})
+
+	t.Run("it should verify tokens of every key during rotation", func(t *testing.T) {
+		oldSS := New(genJwtConfig("RS256", "", rsaKeyConfig))
+		oldTokenStr, err := oldSS.GenToken("some-user-id", auth.AccessTokenType, nil, mockIat, mockExp)
+		if err != nil {
+			t.Fatalf("an error '%s' was not expected", err)
+		}
+
+		ss := New(genJwtConfig("ES256", "ec-key", rsaKeyConfig, ecKeyConfig))
+		tokenStr, err := ss.GenToken("some-user-id", auth.AccessTokenType, nil, mockIat, mockExp)
+		if err != nil {
+			t.Fatalf("an error '%s' was not expected", err)
+		}
+
+		_, err = validateAccessToken(ss, oldTokenStr)
+		assert.NoError(t, err)
+		_, err = validateAccessToken(ss, tokenStr)
+		assert.NoError(t, err)
+
+		// the retired key is no longer configured
+		_, err = validateAccessToken(New(genJwtConfig("ES256", "", ecKeyConfig)), oldTokenStr)
+		assert.IsType(t, &terr.TokenSignatureError{}, err)
+	})
+
+	t.Run("it should verify with a public key", func(t *testing.T) {
+		ss := New(genJwtConfig("ES256", "", ecKeyConfig))
+		tokenStr, err := ss.GenToken("some-user-id", auth.AccessTokenType, nil, mockIat, mockExp)
+		if err != nil {
+			t.Fatalf("an error '%s' was not expected", err)
+		}
+
+		publicKeyConfig := config.JwtKeyConfig{ID: "ec-key", Path: writePEMKey(t, dir, "ec-public-key", &ecKey.PublicKey)}
+		verifyingSS := New(genJwtConfig("RS256", "rsa-key", rsaKeyConfig, publicKeyConfig))
+		_, err = validateAccessToken(verifyingSS, tokenStr)
+		assert.NoError(t, err)
+	})
+
+	t.Run("it should reject HS256 tokens and alg mismatches", func(t *testing.T) {
+		ss := New(genJwtConfig("RS256", "", rsaKeyConfig))
+
+		hsTokenStr, err := New(config.Get()).GenToken("some-user-id", auth.AccessTokenType, nil, mockIat, mockExp)
+		if err != nil {
+			t.Fatalf("an error '%s' was not expected", err)
+		}
+		_, err = validateAccessToken(ss, hsTokenStr)
+		assert.Error(t, err)
+
+		// HS256 signed with the rsa public key bytes, using the kid of the rsa key
+		publicKeyBytes, err := x509.MarshalPKIXPublicKey(&rsaKey.PublicKey)
+		if err != nil {
+			t.Fatalf("an error '%s' was not expected", err)
+		}
+		token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{"user_id": "some-user-id", "type": auth.AccessTokenType})
+		token.Header["kid"] = "rsa-key"
+		forgedTokenStr, err := token.SignedString(publicKeyBytes)
+		if err != nil {
+			t.Fatalf("an error '%s' was not expected", err)
+		}
+		_, err = validateAccessToken(ss, forgedTokenStr)
+		assert.Error(t, err)
+	})
+
+	t.Run("it should not issue tokens with an invalid key config", func(t *testing.T) {
+		publicKeyConfig := config.JwtKeyConfig{ID: "ec-key", Path: writePEMKey(t, dir, "ec-public-key", &ecKey.PublicKey)}
+		tests := []*config.GlobalConfig{
+			genJwtConfig("PS512", "", rsaKeyConfig),
+			genJwtConfig("RS256", ""),
+			genJwtConfig("RS256", "", config.JwtKeyConfig{ID: "missing-key", Path: filepath.Join(dir, "missing.pem")}),
+			genJwtConfig("RS256", "other-key", rsaKeyConfig),
+			genJwtConfig("RS256", "", ecKeyConfig),
+			genJwtConfig("ES256", "", publicKeyConfig),
+		}
+
+		for _, cfg := range tests {
+			_, err := New(cfg).GenToken("some-user-id", auth.AccessTokenType, nil, mockIat, mockExp)
+			if assert.Error(t, err) {
+				assert.Equal(t, "signing key not found", err.Error())
+			}
+		}
+	})
+}
+
+func TestGetJWKS(t *testing.T) {
+	t.Run("it should not publish the HS256 secret", func(t *testing.T) {
+		assert.Equal(t, JWKS{Keys: []JWK{}}, New(config.Get()).GetJWKS())
+	})
+
+	t.Run("it should publish every public key", func(t *testing.T) {
+		dir, err := ioutil.TempDir("", "sherman-jwt-keys")
+		if err != nil {
+			t.Fatalf("an error '%s' was not expected", err)
+		}
+		defer os.RemoveAll(dir)
+
+		rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+		if err != nil {
+			t.Fatalf("an error '%s' was not expected", err)
+		}
+		ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		if err != nil {
+			t.Fatalf("an error '%s' was not expected", err)
+		}
+		edPublicKey, edKey, err := ed25519.GenerateKey(rand.Reader)
+		if err != nil {
+			t.Fatalf("an error '%s' was not expected", err)
+		}
+
+		ss := New(genJwtConfig(
+			"EdDSA",
+			"",
+			config.JwtKeyConfig{ID: "c-key", Path: writePEMKey(t, dir, "c-key", edKey)},
+			config.JwtKeyConfig{ID: "a-key", Path: writePEMKey(t, dir, "a-key", rsaKey)},
+			config.JwtKeyConfig{ID: "b-key", Path: writePEMKey(t, dir, "b-key", ecKey)},
+		))
+
+		jwks := ss.GetJWKS()
+		if !assert.Len(t, jwks.Keys, 3) {
+			return
+		}
+		assert.Equal(t, "a-key", jwks.Keys[0].Kid)
+		assert.Equal(t, "RSA", jwks.Keys[0].Kty)
+		assert.Equal(t, "RS256", jwks.Keys[0].Alg)
+		assert.Equal(t, "AQAB", jwks.Keys[0].E)
+		assert.NotEmpty(t, jwks.Keys[0].N)
+		assert.Equal(t, "b-key", jwks.Keys[1].Kid)
+		assert.Equal(t, "EC", jwks.Keys[1].Kty)
+		assert.Equal(t, "ES256", jwks.Keys[1].Alg)
+		assert.Equal(t, "P-256", jwks.Keys[1].Crv)
+		assert.Len(t, jwks.Keys[1].X, 43)
+		assert.Len(t, jwks.Keys[1].Y, 43)
+		assert.Equal(t, JWK{
+			Kty: "OKP",
+			Use: "sig",
+			Alg: "EdDSA",
+			Kid: "c-key",
+			Crv: "Ed25519",
+			X:   base64.RawURLEncoding.EncodeToString(edPublicKey),
+		}, jwks.Keys[2])
+	})
+}
+
+func TestEncrypt(t *testing.T) {
+	t.Run("it should encrypt and decrypt a secret", func(t *testing.T) {
+		ss := New(config.Get())
+
+		ciphertext, err := ss.Encrypt("some-secret")
+		if assert.NoError(t, err) {
+			assert.NotContains(t, ciphertext, "some-secret")
+
+			otherCiphertext, _ := ss.Encrypt("some-secret")
+			assert.NotEqual(t, ciphertext, otherCiphertext)
+
+			plaintext, err := ss.Decrypt(ciphertext)
+			if assert.NoError(t, err) {
+				assert.Equal(t, "some-secret", plaintext)
+			}
+		}
+	})
+
+	t.Run("it should return error", func(t *testing.T) {
+		cfg := *config.Get()
+		cfg.MFA.EncryptionKey = "other-encryption-key"
+		ciphertext, err := New(&cfg).Encrypt("some-secret")
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		ss := New(config.Get())
+		_, err = ss.Decrypt(ciphertext)
+		assert.EqualError(t, err, "could not decrypt ciphertext")
+		_, err = ss.Decrypt("not-base64")
+		assert.EqualError(t, err, "malformed ciphertext")
+
+		cfg.MFA.EncryptionKey = ""
+		_, err = New(&cfg).Encrypt("some-secret")
+		assert.EqualError(t, err, "encryption key not found")
+	})
+}
+
+func TestEncryptState(t *testing.T) {
+	t.Run("it should encrypt and decrypt a state", func(t *testing.T) {
+		ss := New(config.Get())
+
+		ciphertext, err := ss.EncryptState("some-state")
+		if assert.NoError(t, err) {
+			plaintext, err := ss.DecryptState(ciphertext)
+			if assert.NoError(t, err) {
+				assert.Equal(t, "some-state", plaintext)
+			}
+		}
+	})
+
+	t.Run("it should not decrypt with the mfa encryption key", func(t *testing.T) {
+		ss := New(config.Get())
+
+		ciphertext, err := ss.EncryptState("some-state")
+		if !assert.NoError(t, err) {
+			return
+		}
+		_, err = ss.Decrypt(ciphertext)
+		assert.EqualError(t, err, "could not decrypt ciphertext")
+
+		ciphertext, err = ss.Encrypt("some-secret")
+		if !assert.NoError(t, err) {
+			return
+		}
+		_, err = ss.DecryptState(ciphertext)
+		assert.EqualError(t, err, "could not decrypt ciphertext")
+	})
+
+	t.Run("it should return error", func(t *testing.T) {
+		cfg := *config.Get()
+		cfg.OIDC.StateKey = ""
+		_, err := New(&cfg).EncryptState("some-state")
+		assert.EqualError(t, err, "encryption key not found")
+	})
+}
+
+func TestTOTP(t *testing.T) {
+	// RFC 6238 SHA1 test secret "12345678901234567890"
+	mockSecret := "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+	ss := New(config.Get())
+
+	t.Run("it should validate the RFC 6238 codes", func(t *testing.T) {
+		vectors := map[int64]string{
+			59:          "287082",
+			1111111109:  "081804",
+			1111111111:  "050471",
+			1234567890:  "005924",
+			2000000000:  "279037",
+			20000000000: "353130",
+		}
+		for unix, code := range vectors {
+			step, ok := ss.ValidateTOTP(mockSecret, code, time.Unix(unix, 0))
+			assert.True(t, ok, code)
+			assert.Equal(t, unix/30, step)
+		}
+	})
+
+	t.Run("it should accept the adjacent periods only", func(t *testing.T) {
+		step, ok := ss.ValidateTOTP(mockSecret, "287082", time.Unix(59+30, 0))
+		assert.True(t, ok)
+		assert.Equal(t, int64(1), step)
+
+		_, ok = ss.ValidateTOTP(mockSecret, "287082", time.Unix(59+60, 0))
+		assert.False(t, ok)
+	})
+
+	t.Run("it should reject malformed codes and secrets", func(t *testing.T) {
+		_, ok := ss.ValidateTOTP(mockSecret, "28708", time.Unix(59, 0))
+		assert.False(t, ok)
+		_, ok = ss.ValidateTOTP("not base32!", "287082", time.Unix(59, 0))
+		assert.False(t, ok)
+	})
+
+	t.Run("it should generate a secret", func(t *testing.T) {
+		secret, err := ss.GenTOTPSecret()
+		if assert.NoError(t, err) {
+			assert.Len(t, secret, 32)
+			otherSecret, _ := ss.GenTOTPSecret()
+			assert.NotEqual(t, secret, otherSecret)
+		}
+	})
+}
@@ -3,7 +3,9 @@ package security
 import (
 	"errors"
 	"github.com/dgrijalva/jwt-go"
+	"github.com/google/uuid"
 	"github.com/labstack/echo/v4"
+	"sherman/src/app/utils/terr"
 	"sherman/src/domain/auth"
 	"strings"
 )
@@ -11,82 +13,174 @@ import (
 func (s *service) extractTokenMetadata(token *jwt.Token) (auth.TokenMetadata, error) {
 	claims, ok := token.Claims.(jwt.MapClaims)
 	if !ok || !token.Valid {
-		return auth.TokenMetadata{}, errors.New("invalid token data")
+		return auth.TokenMetadata{}, terr.NewMalformedTokenError("malformed token")
 	}
 
-	userID, ok := claims["user_id"].(string)
-	if !ok {
-		return auth.TokenMetadata{}, errors.New("invalid token data")
+	tokenID, ok := claims["jti"].(string)
+	if !ok || tokenID == "" {
+		return auth.TokenMetadata{}, terr.NewMalformedTokenError("malformed token")
+	}
+
+	userID, ok := claims["sub"].(string)
+	if !ok || userID == "" {
+		return auth.TokenMetadata{}, terr.NewMalformedTokenError("malformed token")
 	}
 
 	tokenType, ok := claims["type"].(string)
 	if !ok {
-		return auth.TokenMetadata{}, errors.New("invalid token data")
+		return auth.TokenMetadata{}, terr.NewMalformedTokenError("malformed token")
 	}
 
+	issuer, _ := claims["iss"].(string)
+	clientID, _ := claims["client_id"].(string)
+	var scopes []string
+	if scope, _ := claims["scope"].(string); scope != "" {
+		scopes = strings.Fields(scope)
+	}
+	roles := StringsClaim(claims, "roles")
+	iat, _ := NumericClaim(claims, "iat")
+	nbf, _ := NumericClaim(claims, "nbf")
+	exp, _ := NumericClaim(claims, "exp")
+
 	return auth.TokenMetadata{
-		UserID: userID,
-		Type:   tokenType,
-		Token:  token.Raw,
+		ID:        tokenID,
+		UserID:    userID,
+		Type:      tokenType,
+		Token:     token.Raw,
+		Roles:     roles,
+		ClientID:  clientID,
+		Scopes:    scopes,
+		Issuer:    issuer,
+		Audience:  StringsClaim(claims, "aud"),
+		IssuedAt:  iat,
+		NotBefore: nbf,
+		ExpiresAt: exp,
 	}, nil
 }
 
+// parseTokenString parses a token verifying it with the key of its kid, the token
+// signing method must be the one of the key and its registered claims must be valid
 func (s *service) parseTokenString(ts string) (*jwt.Token, error) {
-	return jwt.Parse(ts, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+	// claims are validated by validateClaims, jwt-go doesn't support leeway, iss nor aud arrays
+	parser := jwt.Parser{SkipClaimsValidation: true}
+	token, err := parser.Parse(ts, func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		key, ok := s.verifyingKeys[kid]
+		if !ok || token.Method.Alg() != key.method.Alg() {
 			return nil, errors.New("invalid token")
 		}
 
-		return []byte(s.config.Jwt.Secret), nil
+		return key.publicKey, nil
 	})
+	if err != nil {
+		return nil, parseError(err)
+	}
+
+	if err := s.validateClaims(token.Claims.(jwt.MapClaims)); err != nil {
+		return nil, err
+	}
+	return token, nil
 }
 
-// GenToken generates a jwt.token
-func (s *service) GenToken(userID, tokenType string, iat, exp int64) (string, error) {
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
-		"user_id": userID,
-		"type":    tokenType,
-		"iat":     iat,
-		"exp":     exp,
-	})
+// parseError maps a jwt-go parse error to a terr token error
+func parseError(err error) error {
+	if vErr, ok := err.(*jwt.ValidationError); ok {
+		switch {
+		case vErr.Errors&jwt.ValidationErrorMalformed != 0:
+			return terr.NewMalformedTokenError("malformed token")
+		case vErr.Errors&(jwt.ValidationErrorUnverifiable|jwt.ValidationErrorSignatureInvalid) != 0:
+			return terr.NewTokenSignatureError("invalid token signature")
+		}
+	}
+	return terr.NewMalformedTokenError("malformed token")
+}
 
-	return token.SignedString([]byte(s.config.Jwt.Secret))
+// GenToken generates a jwt.token with a unique jti, signed with the active signing key,
+// roles are only emitted when the user has any
+func (s *service) GenToken(userID, tokenType string, roles []string, iat, exp int64) (string, error) {
+	return s.GenScopedToken(userID, tokenType, "", nil, roles, iat, exp)
 }
 
-// GetAndValidateAccessToken gets the access token from echo.Context and verifies its signature
-func (s *service) GetAndValidateAccessToken(ctx echo.Context) (auth.TokenMetadata, error) {
-	tokenHeader := ctx.Request().Header.Get("Authorization")
-	tokenHeaderArr := strings.Split(tokenHeader, " ")
-	if len(tokenHeaderArr) != 2 {
-		return auth.TokenMetadata{}, errors.New("access token not found")
+// GenScopedToken generates a jwt.token like GenToken, tokens issued to an OAuth client carry its
+// client_id and their space delimited scope (RFC 9068), client access tokens have no user_id
+func (s *service) GenScopedToken(subject, tokenType, clientID string, scopes, roles []string, iat, exp int64) (string, error) {
+	if s.signingKey == nil {
+		return "", errors.New("signing key not found")
+	}
+
+	claims := jwt.MapClaims{
+		"jti":  uuid.New().String(),
+		"sub":  subject,
+		"iss":  s.config.Jwt.Issuer,
+		"aud":  s.config.Jwt.Audience,
+		"nbf":  iat,
+		"iat":  iat,
+		"exp":  exp,
+		"type": tokenType,
+	}
+	if tokenType != auth.ClientAccessTokenType {
+		claims["user_id"] = subject
+	}
+	if len(roles) > 0 {
+		claims["roles"] = roles
+	}
+	if clientID != "" {
+		claims["client_id"] = clientID
+		claims["scope"] = strings.Join(scopes, " ")
 	}
-	token, err := s.parseTokenString(tokenHeaderArr[1])
+
+	token := jwt.NewWithClaims(s.signingKey.method, claims)
+	if s.signingKey.id != "" {
+		token.Header["kid"] = s.signingKey.id
+	}
+
+	return token.SignedString(s.signingKey.privateKey)
+}
+
+// getAndValidateToken parses and validates a token string of tokenType, errors are typed terr errors
+func (s *service) getAndValidateToken(ts, tokenType string) (auth.TokenMetadata, error) {
+	token, err := s.parseTokenString(ts)
 	if err != nil {
-		return auth.TokenMetadata{}, errors.New("invalid token")
+		return auth.TokenMetadata{}, err
 	}
 
 	tokenMetadata, err := s.extractTokenMetadata(token)
 	if err != nil {
-		return auth.TokenMetadata{}, errors.New("invalid token")
+		return auth.TokenMetadata{}, err
+	}
+
+	if tokenMetadata.Type != tokenType {
+		return auth.TokenMetadata{}, terr.NewTokenTypeError("invalid token type")
 	}
 	return tokenMetadata, nil
 }
 
-// GetAndValidateRefreshToken gets the refresh token from echo.Context and verifies its signature
+// GetAndValidateAccessToken gets the access token from echo.Context and verifies its signature, claims and type
+func (s *service) GetAndValidateAccessToken(ctx echo.Context) (auth.TokenMetadata, error) {
+	tokenHeader := ctx.Request().Header.Get("Authorization")
+	tokenHeaderArr := strings.Split(tokenHeader, " ")
+	if len(tokenHeaderArr) != 2 {
+		return auth.TokenMetadata{}, terr.NewUnAuthorizedError("access token not found")
+	}
+
+	return s.getAndValidateToken(tokenHeaderArr[1], auth.AccessTokenType)
+}
+
+// GetAndValidateRefreshToken gets the refresh token from echo.Context and verifies its signature, claims and type
 func (s *service) GetAndValidateRefreshToken(ctx echo.Context) (auth.TokenMetadata, error) {
 	refreshTokenCookie, err := ctx.Request().Cookie("REFRESH_TOKEN")
 	if err != nil {
-		return auth.TokenMetadata{}, errors.New("refresh token not found")
+		return auth.TokenMetadata{}, terr.NewUnAuthorizedError("refresh token not found")
 	}
 
-	token, err := s.parseTokenString(refreshTokenCookie.Value)
-	if err != nil {
-		return auth.TokenMetadata{}, errors.New("invalid refresh token")
-	}
+	return s.getAndValidateToken(refreshTokenCookie.Value, auth.RefreshTokenType)
+}
 
-	tokenMetadata, err := s.extractTokenMetadata(token)
-	if err != nil {
-		return auth.TokenMetadata{}, errors.New("invalid refresh token")
+// ValidateToken verifies the signature, claims and type of a token string received out of band (e.g. by email)
+func (s *service) ValidateToken(tokenStr, tokenType string) (auth.TokenMetadata, error) {
+	if tokenStr == "" {
+		return auth.TokenMetadata{}, terr.NewUnAuthorizedError("token not found")
 	}
-	return tokenMetadata, nil
+
+	return s.getAndValidateToken(tokenStr, tokenType)
 }
@@ -0,0 +1,70 @@
+package security
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/binary"
+	"fmt"
+	"strings"
+	"time"
+)
+
+const (
+	// totpPeriod seconds a TOTP code is valid for
+	totpPeriod = 30
+	// totpDigits number of digits of a TOTP code
+	totpDigits = 6
+	// totpModulus 10^totpDigits, truncates a HMAC to totpDigits digits
+	totpModulus = 1000000
+	// totpSkew number of periods accepted before and after the current one to absorb clock drift
+	totpSkew = 1
+	// totpSecretSize size in bytes of a TOTP secret, the size of a HMAC-SHA1 key
+	totpSecretSize = 20
+)
+
+// totpEncoding base32 encoding of the TOTP secrets, the encoding of the otpauth URI format
+var totpEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// GenTOTPSecret generates a random base32 encoded TOTP secret
+func (s *service) GenTOTPSecret() (string, error) {
+	secret := make([]byte, totpSecretSize)
+	if _, err := rand.Read(secret); err != nil {
+		return "", err
+	}
+	return totpEncoding.EncodeToString(secret), nil
+}
+
+// ValidateTOTP validates a RFC 6238 code (HMAC-SHA1, 6 digits, 30 seconds) of a base32 secret at t,
+// the codes of the adjacent periods are accepted, the period of the matching code is returned so that
+// callers can reject a code already used
+func (s *service) ValidateTOTP(secret, code string, t time.Time) (int64, bool) {
+	key, err := totpEncoding.DecodeString(strings.ToUpper(strings.TrimRight(secret, "=")))
+	if err != nil || len(code) != totpDigits {
+		return 0, false
+	}
+
+	current := t.Unix() / totpPeriod
+	for step := current - totpSkew; step <= current+totpSkew; step++ {
+		if subtle.ConstantTimeCompare([]byte(totpCode(key, step)), []byte(code)) == 1 {
+			return step, true
+		}
+	}
+	return 0, false
+}
+
+// totpCode computes the code of a period with the dynamic truncation of RFC 4226
+func totpCode(key []byte, step int64) string {
+	counter := make([]byte, 8)
+	binary.BigEndian.PutUint64(counter, uint64(step))
+
+	mac := hmac.New(sha1.New, key)
+	_, _ = mac.Write(counter)
+	sum := mac.Sum(nil)
+
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+	return fmt.Sprintf("%0*d", totpDigits, value%totpModulus)
+}
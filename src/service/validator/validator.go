@@ -1,6 +1,7 @@
 package validator
 
 import (
+	"net/url"
 	"sherman/src/domain/auth"
 )
 
@@ -8,6 +9,15 @@ type (
 	// Validator validator.Validator interface definition
 	Validator interface {
 		ValidateUserParams(user *auth.User, action string) map[string]string
+		ValidateUserQueryParams(params url.Values) (auth.UserQuery, map[string]string)
+		ValidateTokenParams(token string) map[string]string
+		ValidatePasswordResetParams(token, password string) map[string]string
+		ValidateRoleParams(role *auth.Role) map[string]string
+		ValidateTwoFactorCodeParams(code string) map[string]string
+		ValidateTwoFactorLoginParams(mfaToken, code string) map[string]string
+		ValidateSocialLoginCallbackParams(state, code string) map[string]string
+		ValidateAPIKeyParams(apiKey *auth.APIKey) map[string]string
+		ValidateOAuthClientParams(client *auth.OAuthClient, public bool) map[string]string
 	}
 
 	service struct{}
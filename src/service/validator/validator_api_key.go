@@ -0,0 +1,48 @@
+package validator
+
+import (
+	"sherman/src/domain/auth"
+	"time"
+)
+
+// apiKeyScopes scopes an API key can be restricted to, account routes need an interactive session
+var apiKeyScopes = []string{auth.ProfileScope, auth.ProfileWriteScope, auth.AdminScope}
+
+// ValidateAPIKeyParams validates /users/me/api-keys route params, retrieves error messages for no compliant fields
+func (s *service) ValidateAPIKeyParams(apiKey *auth.APIKey) map[string]string {
+	var errorMessages = make(map[string]string)
+
+	const (
+		nameRequired     = "name is required"
+		nameTooLong      = "name must be at most 255 characters"
+		scopesInvalid    = "scopes must be any of profile, profile:write or admin"
+		expiresAtInvalid = "expires_at must be in the future"
+	)
+
+	if apiKey.Name == "" {
+		errorMessages["name_required"] = nameRequired
+	}
+	if len(apiKey.Name) > 255 {
+		errorMessages["name_too_long"] = nameTooLong
+	}
+	for _, scope := range apiKey.Scopes {
+		if !isAPIKeyScope(scope) {
+			errorMessages["scopes_invalid"] = scopesInvalid
+			break
+		}
+	}
+	if apiKey.ExpiresAt != nil && !apiKey.ExpiresAt.After(time.Now()) {
+		errorMessages["expires_at_invalid"] = expiresAtInvalid
+	}
+	return errorMessages
+}
+
+// isAPIKeyScope checks if scope is one of apiKeyScopes
+func isAPIKeyScope(scope string) bool {
+	for _, s := range apiKeyScopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
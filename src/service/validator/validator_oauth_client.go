@@ -0,0 +1,93 @@
+package validator
+
+import (
+	"net/url"
+	"sherman/src/domain/auth"
+	"strings"
+)
+
+var (
+	// oauthClientGrantTypes grant types a client can be registered with
+	oauthClientGrantTypes = []string{auth.AuthorizationCodeGrant, auth.RefreshTokenGrant, auth.ClientCredentialsGrant}
+	// oauthClientScopes scopes a client can be registered with
+	oauthClientScopes = []string{auth.ProfileScope, auth.ProfileWriteScope, auth.AccountScope, auth.AdminScope}
+)
+
+// ValidateOAuthClientParams validates /admin/oauth-clients route params, retrieves error messages for no compliant fields
+func (s *service) ValidateOAuthClientParams(client *auth.OAuthClient, public bool) map[string]string {
+	var errorMessages = make(map[string]string)
+
+	const (
+		nameRequired           = "name is required"
+		nameTooLong            = "name must be at most 255 characters"
+		redirectURIsRequired   = "redirect_uris is required with the authorization_code grant"
+		redirectURIsInvalid    = "redirect_uris must be absolute uris without fragment"
+		grantTypesRequired     = "grant_types is required"
+		grantTypesInvalid      = "grant_types must be any of authorization_code, refresh_token or client_credentials"
+		grantTypesPublicClient = "public clients can't use the client_credentials grant"
+		scopesRequired         = "scopes is required"
+		scopesInvalid          = "scopes must be any of profile, profile:write, account or admin"
+	)
+
+	if client.Name == "" {
+		errorMessages["name_required"] = nameRequired
+	}
+	if len(client.Name) > 255 {
+		errorMessages["name_too_long"] = nameTooLong
+	}
+
+	if len(client.GrantTypes) == 0 {
+		errorMessages["grant_types_required"] = grantTypesRequired
+	}
+	if !isSubset(client.GrantTypes, oauthClientGrantTypes) {
+		errorMessages["grant_types_invalid"] = grantTypesInvalid
+	}
+	if public && isSubset([]string{auth.ClientCredentialsGrant}, client.GrantTypes) {
+		errorMessages["grant_types_public_client"] = grantTypesPublicClient
+	}
+
+	if len(client.RedirectURIs) == 0 && isSubset([]string{auth.AuthorizationCodeGrant}, client.GrantTypes) {
+		errorMessages["redirect_uris_required"] = redirectURIsRequired
+	}
+	for _, redirectURI := range client.RedirectURIs {
+		if !isRedirectURI(redirectURI) {
+			errorMessages["redirect_uris_invalid"] = redirectURIsInvalid
+			break
+		}
+	}
+
+	if len(client.Scopes) == 0 {
+		errorMessages["scopes_required"] = scopesRequired
+	}
+	if !isSubset(client.Scopes, oauthClientScopes) {
+		errorMessages["scopes_invalid"] = scopesInvalid
+	}
+	return errorMessages
+}
+
+// isRedirectURI checks that a redirect uri is absolute and has no fragment (RFC 6749 3.1.2), uris
+// are stored space separated so they can't contain spaces
+func isRedirectURI(redirectURI string) bool {
+	if strings.ContainsAny(redirectURI, " \t\r\n") {
+		return false
+	}
+	u, err := url.Parse(redirectURI)
+	return err == nil && u.IsAbs() && u.Fragment == ""
+}
+
+// isSubset checks if every one of values is one of allowed
+func isSubset(values, allowed []string) bool {
+	for _, value := range values {
+		found := false
+		for _, a := range allowed {
+			if a == value {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,17 @@
+package validator
+
+import (
+	"sherman/src/domain/auth"
+)
+
+// ValidateRoleParams validates /users/:id/roles route params, retrieves error messages for no compliant fields
+func (s *service) ValidateRoleParams(role *auth.Role) map[string]string {
+	var errorMessages = make(map[string]string)
+
+	const nameRequired = "name is required"
+
+	if role.Name == "" {
+		errorMessages["name_required"] = nameRequired
+	}
+	return errorMessages
+}
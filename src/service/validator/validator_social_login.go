@@ -0,0 +1,19 @@
+package validator
+
+// ValidateSocialLoginCallbackParams validates /auth/:provider/callback route params, retrieves error messages for no compliant fields
+func (s *service) ValidateSocialLoginCallbackParams(state, code string) map[string]string {
+	var errorMessages = make(map[string]string)
+
+	const (
+		stateRequired = "state is required"
+		codeRequired  = "code is required"
+	)
+
+	if state == "" {
+		errorMessages["state_required"] = stateRequired
+	}
+	if code == "" {
+		errorMessages["code_required"] = codeRequired
+	}
+	return errorMessages
+}
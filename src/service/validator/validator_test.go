@@ -2,9 +2,12 @@ package validator
 
 import (
 	"github.com/stretchr/testify/assert"
+	"net/url"
 	_ "sherman/src/app/testing"
 	"sherman/src/domain/auth"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestValidateUserParams(t *testing.T) {
@@ -14,12 +17,25 @@ func TestValidateUserParams(t *testing.T) {
 		LastName:     "last",
 		EmailAddress: "some@email.com",
 		Password:     "has a password",
+		NewPassword:  "has a new password",
 	}
 
 	errors := vs.ValidateUserParams(&mockUser, "register")
 	assert.Equal(t, map[string]string{}, errors)
 	errors = vs.ValidateUserParams(&mockUser, "login")
 	assert.Equal(t, map[string]string{}, errors)
+	errors = vs.ValidateUserParams(&mockUser, "resend_verification")
+	assert.Equal(t, map[string]string{}, errors)
+	errors = vs.ValidateUserParams(&mockUser, "forgot_password")
+	assert.Equal(t, map[string]string{}, errors)
+	errors = vs.ValidateUserParams(&mockUser, "update")
+	assert.Equal(t, map[string]string{}, errors)
+	errors = vs.ValidateUserParams(&auth.User{LastName: "last"}, "update")
+	assert.Equal(t, map[string]string{}, errors)
+	errors = vs.ValidateUserParams(&mockUser, "confirm_password")
+	assert.Equal(t, map[string]string{}, errors)
+	errors = vs.ValidateUserParams(&mockUser, "change_password")
+	assert.Equal(t, map[string]string{}, errors)
 
 	mockUser = auth.User{
 		FirstName:    "",
@@ -41,4 +57,232 @@ func TestValidateUserParams(t *testing.T) {
 		"password_required":      "password is required",
 	}
 	assert.Equal(t, expected, errors)
+	errors = vs.ValidateUserParams(&mockUser, "resend_verification")
+	expected = map[string]string{
+		"email_address_required": "email_address is required",
+	}
+	assert.Equal(t, expected, errors)
+	errors = vs.ValidateUserParams(&mockUser, "forgot_password")
+	assert.Equal(t, expected, errors)
+	errors = vs.ValidateUserParams(&mockUser, "update")
+	expected = map[string]string{
+		"fields_required": "first_name, last_name or email_address is required",
+	}
+	assert.Equal(t, expected, errors)
+	errors = vs.ValidateUserParams(&mockUser, "confirm_password")
+	expected = map[string]string{
+		"password_required": "password is required",
+	}
+	assert.Equal(t, expected, errors)
+	errors = vs.ValidateUserParams(&mockUser, "change_password")
+	expected = map[string]string{
+		"password_required":     "password is required",
+		"new_password_required": "new_password is required",
+	}
+	assert.Equal(t, expected, errors)
+}
+
+func TestValidateUserQueryParams(t *testing.T) {
+	vs := New()
+
+	query, errors := vs.ValidateUserQueryParams(url.Values{})
+	assert.Equal(t, auth.UserQuery{}, query)
+	assert.Equal(t, map[string]string{}, errors)
+
+	query, errors = vs.ValidateUserQueryParams(url.Values{
+		"active":       {"false"},
+		"created_from": {"2020-05-05T00:00:00Z"},
+		"created_to":   {"2020-05-06T00:00:00Z"},
+		"email_prefix": {"some"},
+		"sort":         {"-email_address"},
+		"cursor":       {"some-cursor"},
+		"limit":        {"50"},
+	})
+	active := false
+	expected := auth.UserQuery{
+		Active:      &active,
+		CreatedFrom: time.Date(2020, 5, 5, 0, 0, 0, 0, time.UTC),
+		CreatedTo:   time.Date(2020, 5, 6, 0, 0, 0, 0, time.UTC),
+		EmailPrefix: "some",
+		SortKey:     "email_address",
+		SortDesc:    true,
+		Cursor:      "some-cursor",
+		Limit:       50,
+	}
+	assert.Equal(t, expected, query)
+	assert.Equal(t, map[string]string{}, errors)
+
+	_, errors = vs.ValidateUserQueryParams(url.Values{
+		"active":       {"maybe"},
+		"created_from": {"yesterday"},
+		"created_to":   {"today"},
+		"sort":         {"password"},
+		"limit":        {"1000"},
+	})
+	expectedErrors := map[string]string{
+		"active_invalid":       "active must be true or false",
+		"created_from_invalid": "created_from must be a RFC 3339 date",
+		"created_to_invalid":   "created_to must be a RFC 3339 date",
+		"sort_invalid":         "sort must be one of created_at, email_address, first_name, last_name, optionally prefixed with -",
+		"limit_invalid":        "limit must be between 1 and 100",
+	}
+	assert.Equal(t, expectedErrors, errors)
+
+	_, errors = vs.ValidateUserQueryParams(url.Values{
+		"created_from": {"2020-05-06T00:00:00Z"},
+		"created_to":   {"2020-05-05T00:00:00Z"},
+	})
+	expectedErrors = map[string]string{
+		"created_range_invalid": "created_from must be before created_to",
+	}
+	assert.Equal(t, expectedErrors, errors)
+}
+
+func TestValidateTokenParams(t *testing.T) {
+	vs := New()
+
+	errors := vs.ValidateTokenParams("some-token")
+	assert.Equal(t, map[string]string{}, errors)
+
+	errors = vs.ValidateTokenParams("")
+	expected := map[string]string{
+		"token_required": "token is required",
+	}
+	assert.Equal(t, expected, errors)
+}
+
+func TestValidatePasswordResetParams(t *testing.T) {
+	vs := New()
+
+	errors := vs.ValidatePasswordResetParams("some-token", "some-password")
+	assert.Equal(t, map[string]string{}, errors)
+
+	errors = vs.ValidatePasswordResetParams("", "")
+	expected := map[string]string{
+		"token_required":    "token is required",
+		"password_required": "password is required",
+	}
+	assert.Equal(t, expected, errors)
+}
+
+func TestValidateRoleParams(t *testing.T) {
+	vs := New()
+
+	errors := vs.ValidateRoleParams(&auth.Role{Name: auth.AdminRole})
+	assert.Equal(t, map[string]string{}, errors)
+
+	errors = vs.ValidateRoleParams(&auth.Role{})
+	expected := map[string]string{
+		"name_required": "name is required",
+	}
+	assert.Equal(t, expected, errors)
+}
+
+func TestValidateTwoFactorCodeParams(t *testing.T) {
+	vs := New()
+
+	errors := vs.ValidateTwoFactorCodeParams("123456")
+	assert.Equal(t, map[string]string{}, errors)
+
+	errors = vs.ValidateTwoFactorCodeParams("")
+	expected := map[string]string{
+		"code_required": "code is required",
+	}
+	assert.Equal(t, expected, errors)
+}
+
+func TestValidateTwoFactorLoginParams(t *testing.T) {
+	vs := New()
+
+	errors := vs.ValidateTwoFactorLoginParams("some-token", "123456")
+	assert.Equal(t, map[string]string{}, errors)
+
+	errors = vs.ValidateTwoFactorLoginParams("", "")
+	expected := map[string]string{
+		"mfa_token_required": "mfa_token is required",
+		"code_required":      "code is required",
+	}
+	assert.Equal(t, expected, errors)
+}
+
+func TestValidateSocialLoginCallbackParams(t *testing.T) {
+	vs := New()
+
+	errors := vs.ValidateSocialLoginCallbackParams("some-state", "some-code")
+	assert.Equal(t, map[string]string{}, errors)
+
+	errors = vs.ValidateSocialLoginCallbackParams("", "")
+	expected := map[string]string{
+		"state_required": "state is required",
+		"code_required":  "code is required",
+	}
+	assert.Equal(t, expected, errors)
+}
+
+func TestValidateAPIKeyParams(t *testing.T) {
+	vs := New()
+	future := time.Now().Add(time.Hour)
+	past := time.Now().Add(-time.Hour)
+
+	errors := vs.ValidateAPIKeyParams(&auth.APIKey{Name: "some key", Scopes: []string{auth.ProfileScope}, ExpiresAt: &future})
+	assert.Equal(t, map[string]string{}, errors)
+
+	errors = vs.ValidateAPIKeyParams(&auth.APIKey{Scopes: []string{"some-scope"}, ExpiresAt: &past})
+	expected := map[string]string{
+		"name_required":      "name is required",
+		"scopes_invalid":     "scopes must be any of profile, profile:write or admin",
+		"expires_at_invalid": "expires_at must be in the future",
+	}
+	assert.Equal(t, expected, errors)
+
+	errors = vs.ValidateAPIKeyParams(&auth.APIKey{Name: strings.Repeat("a", 256)})
+	assert.Equal(t, map[string]string{"name_too_long": "name must be at most 255 characters"}, errors)
+}
+
+func TestValidateOAuthClientParams(t *testing.T) {
+	vs := New()
+
+	errors := vs.ValidateOAuthClientParams(&auth.OAuthClient{
+		Name:         "some client",
+		RedirectURIs: []string{"https://app.test/callback", "com.app:/callback"},
+		GrantTypes:   []string{auth.AuthorizationCodeGrant, auth.RefreshTokenGrant},
+		Scopes:       []string{auth.ProfileScope, auth.AccountScope},
+	}, true)
+	assert.Equal(t, map[string]string{}, errors)
+
+	errors = vs.ValidateOAuthClientParams(&auth.OAuthClient{
+		GrantTypes: []string{auth.ClientCredentialsGrant},
+		Scopes:     []string{auth.AdminScope},
+	}, false)
+	assert.Equal(t, map[string]string{"name_required": "name is required"}, errors)
+
+	errors = vs.ValidateOAuthClientParams(&auth.OAuthClient{}, false)
+	expected := map[string]string{
+		"name_required":        "name is required",
+		"grant_types_required": "grant_types is required",
+		"scopes_required":      "scopes is required",
+	}
+	assert.Equal(t, expected, errors)
+
+	errors = vs.ValidateOAuthClientParams(&auth.OAuthClient{
+		Name:         strings.Repeat("a", 256),
+		RedirectURIs: []string{"/callback", "https://app.test/callback#fragment"},
+		GrantTypes:   []string{"password", auth.ClientCredentialsGrant},
+		Scopes:       []string{"some-scope"},
+	}, true)
+	expected = map[string]string{
+		"name_too_long":             "name must be at most 255 characters",
+		"redirect_uris_invalid":     "redirect_uris must be absolute uris without fragment",
+		"grant_types_invalid":       "grant_types must be any of authorization_code, refresh_token or client_credentials",
+		"grant_types_public_client": "public clients can't use the client_credentials grant",
+		"scopes_invalid":            "scopes must be any of profile, profile:write, account or admin",
+	}
+	assert.Equal(t, expected, errors)
+
+	errors = vs.ValidateOAuthClientParams(&auth.OAuthClient{
+		Name:       "some client",
+		GrantTypes: []string{auth.AuthorizationCodeGrant},
+		Scopes:     []string{auth.ProfileScope},
+	}, true)
+	assert.Equal(t, map[string]string{"redirect_uris_required": "redirect_uris is required with the authorization_code grant"}, errors)
 }
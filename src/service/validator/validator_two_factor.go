@@ -0,0 +1,25 @@
+package validator
+
+// ValidateTwoFactorCodeParams validates /users/me/2fa/[route] route params, retrieves error messages for no compliant fields
+func (s *service) ValidateTwoFactorCodeParams(code string) map[string]string {
+	var errorMessages = make(map[string]string)
+
+	const codeRequired = "code is required"
+
+	if code == "" {
+		errorMessages["code_required"] = codeRequired
+	}
+	return errorMessages
+}
+
+// ValidateTwoFactorLoginParams validates /users/login/2fa route params, retrieves error messages for no compliant fields
+func (s *service) ValidateTwoFactorLoginParams(mfaToken, code string) map[string]string {
+	errorMessages := s.ValidateTwoFactorCodeParams(code)
+
+	const mfaTokenRequired = "mfa_token is required"
+
+	if mfaToken == "" {
+		errorMessages["mfa_token_required"] = mfaTokenRequired
+	}
+	return errorMessages
+}
@@ -1,8 +1,12 @@
 package validator
 
 import (
+	"fmt"
+	"net/url"
 	"sherman/src/domain/auth"
+	"strconv"
 	"strings"
+	"time"
 )
 
 // ValidateUserParams validates /user/[route] route params, retrieves error messages for no compliant fields
@@ -10,10 +14,12 @@ func (s *service) ValidateUserParams(user *auth.User, action string) map[string]
 	var errorMessages = make(map[string]string)
 
 	const (
-		firstNameRequired = "first_name is required"
-		lastNameRequired  = "last_name is required"
-		passwordRequired  = "password is required"
-		emailRequired     = "email_address is required"
+		firstNameRequired   = "first_name is required"
+		lastNameRequired    = "last_name is required"
+		passwordRequired    = "password is required"
+		emailRequired       = "email_address is required"
+		newPasswordRequired = "new_password is required"
+		fieldsRequired      = "first_name, last_name or email_address is required"
 	)
 
 	switch strings.ToLower(action) {
@@ -37,6 +43,116 @@ func (s *service) ValidateUserParams(user *auth.User, action string) map[string]
 		if user.Password == "" {
 			errorMessages["password_required"] = passwordRequired
 		}
+	case "resend_verification", "forgot_password":
+		if user.EmailAddress == "" {
+			errorMessages["email_address_required"] = emailRequired
+		}
+	case "update":
+		if user.FirstName == "" && user.LastName == "" && user.EmailAddress == "" {
+			errorMessages["fields_required"] = fieldsRequired
+		}
+	case "confirm_password":
+		if user.Password == "" {
+			errorMessages["password_required"] = passwordRequired
+		}
+	case "change_password":
+		if user.Password == "" {
+			errorMessages["password_required"] = passwordRequired
+		}
+		if user.NewPassword == "" {
+			errorMessages["new_password_required"] = newPasswordRequired
+		}
+	}
+	return errorMessages
+}
+
+// ValidateTokenParams validates the token of /users/[route] routes consuming a token received by email
+func (s *service) ValidateTokenParams(token string) map[string]string {
+	var errorMessages = make(map[string]string)
+
+	const tokenRequired = "token is required"
+
+	if token == "" {
+		errorMessages["token_required"] = tokenRequired
+	}
+	return errorMessages
+}
+
+// ValidatePasswordResetParams validates /users/password/reset route params, retrieves error messages for no compliant fields
+func (s *service) ValidatePasswordResetParams(token, password string) map[string]string {
+	errorMessages := s.ValidateTokenParams(token)
+
+	const passwordRequired = "password is required"
+
+	if password == "" {
+		errorMessages["password_required"] = passwordRequired
 	}
 	return errorMessages
 }
+
+// ValidateUserQueryParams parses and validates /admin/users query params into a auth.UserQuery,
+// retrieves error messages for no compliant params, sort is a sort key prefixed with - for a descending sort
+func (s *service) ValidateUserQueryParams(params url.Values) (auth.UserQuery, map[string]string) {
+	var query auth.UserQuery
+	var errorMessages = make(map[string]string)
+
+	const (
+		activeInvalid       = "active must be true or false"
+		createdFromInvalid  = "created_from must be a RFC 3339 date"
+		createdToInvalid    = "created_to must be a RFC 3339 date"
+		createdRangeInvalid = "created_from must be before created_to"
+		limitInvalid        = "limit must be between 1 and %d"
+		sortInvalid         = "sort must be one of %s, optionally prefixed with -"
+	)
+
+	if active := params.Get("active"); active != "" {
+		if value, err := strconv.ParseBool(active); err == nil {
+			query.Active = &value
+		} else {
+			errorMessages["active_invalid"] = activeInvalid
+		}
+	}
+
+	if createdFrom := params.Get("created_from"); createdFrom != "" {
+		if value, err := time.Parse(time.RFC3339, createdFrom); err == nil {
+			query.CreatedFrom = value
+		} else {
+			errorMessages["created_from_invalid"] = createdFromInvalid
+		}
+	}
+	if createdTo := params.Get("created_to"); createdTo != "" {
+		if value, err := time.Parse(time.RFC3339, createdTo); err == nil {
+			query.CreatedTo = value
+		} else {
+			errorMessages["created_to_invalid"] = createdToInvalid
+		}
+	}
+	if !query.CreatedFrom.IsZero() && !query.CreatedTo.IsZero() && !query.CreatedFrom.Before(query.CreatedTo) {
+		errorMessages["created_range_invalid"] = createdRangeInvalid
+	}
+
+	if sort := params.Get("sort"); sort != "" {
+		query.SortDesc = strings.HasPrefix(sort, "-")
+		query.SortKey = strings.TrimPrefix(sort, "-")
+
+		valid := false
+		for _, sortKey := range auth.UserSortKeys {
+			valid = valid || query.SortKey == sortKey
+		}
+		if !valid {
+			errorMessages["sort_invalid"] = fmt.Sprintf(sortInvalid, strings.Join(auth.UserSortKeys, ", "))
+		}
+	}
+
+	if limit := params.Get("limit"); limit != "" {
+		value, err := strconv.Atoi(limit)
+		if err != nil || value < 1 || value > auth.MaxUserQueryLimit {
+			errorMessages["limit_invalid"] = fmt.Sprintf(limitInvalid, auth.MaxUserQueryLimit)
+		}
+		query.Limit = value
+	}
+
+	query.EmailPrefix = params.Get("email_prefix")
+	query.Cursor = params.Get("cursor")
+	return query, errorMessages
+}
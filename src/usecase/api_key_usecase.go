@@ -0,0 +1,110 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"github.com/google/uuid"
+	"sherman/src/app/utils/terr"
+	"sherman/src/app/utils/tokens"
+	"sherman/src/domain/auth"
+	"strings"
+	"time"
+)
+
+const (
+	// apiKeyPrefix prefix of every API key, it makes leaked keys easy to spot
+	apiKeyPrefix = "shm_"
+	// apiKeyDisplayLength length of the key prefix kept to tell keys apart
+	apiKeyDisplayLength = len(apiKeyPrefix) + 8
+)
+
+// apiKeyUseCase implementation of auth.APIKeyUseCase
+type apiKeyUseCase struct {
+	apiKeyRepo auth.APIKeyRepository
+	roleRepo   auth.RoleRepository
+}
+
+// NewAPIKeyUseCase constructor
+func NewAPIKeyUseCase(akr auth.APIKeyRepository, rr auth.RoleRepository) auth.APIKeyUseCase {
+	return &apiKeyUseCase{
+		apiKeyRepo: akr,
+		roleRepo:   rr,
+	}
+}
+
+// CreateAPIKey generates a new API key for the user of apiKey, the key is only returned this once
+func (uc *apiKeyUseCase) CreateAPIKey(ctx context.Context, apiKey *auth.APIKey) (auth.NewAPIKey, error) {
+	token, err := tokens.Random()
+	if err != nil {
+		return auth.NewAPIKey{}, err
+	}
+	key := apiKeyPrefix + token
+
+	newAPIKey := auth.NewAPIKey{
+		APIKey: auth.APIKey{
+			ID:        uuid.New().String(),
+			UserID:    apiKey.UserID,
+			Name:      apiKey.Name,
+			Prefix:    key[:apiKeyDisplayLength],
+			KeyHash:   hashToken(key),
+			Scopes:    apiKey.Scopes,
+			ExpiresAt: apiKey.ExpiresAt,
+			CreatedAt: time.Now(),
+		},
+		Key: key,
+	}
+	if err := uc.apiKeyRepo.CreateAPIKey(ctx, &newAPIKey.APIKey); err != nil {
+		return auth.NewAPIKey{}, err
+	}
+
+	return newAPIKey, nil
+}
+
+// GetAPIKeys gets the API keys of a user
+func (uc *apiKeyUseCase) GetAPIKeys(ctx context.Context, userID string) ([]auth.APIKey, error) {
+	return uc.apiKeyRepo.GetAPIKeysByUserID(ctx, userID)
+}
+
+// RevokeAPIKey revokes an API key of a user
+func (uc *apiKeyUseCase) RevokeAPIKey(ctx context.Context, userID, id string) error {
+	return uc.apiKeyRepo.RemoveAPIKey(ctx, userID, id)
+}
+
+// Authenticate gets the principal of an API key, it carries the current roles of the user and the
+// scopes of the key
+func (uc *apiKeyUseCase) Authenticate(ctx context.Context, key string) (auth.TokenMetadata, error) {
+	if !strings.HasPrefix(key, apiKeyPrefix) {
+		return auth.TokenMetadata{}, terr.NewUnAuthorizedError("invalid api key")
+	}
+
+	apiKey, err := uc.apiKeyRepo.GetAPIKeyByHash(ctx, hashToken(key))
+	if err != nil {
+		if _, ok := err.(*terr.NotFoundError); ok {
+			return auth.TokenMetadata{}, terr.NewUnAuthorizedError("invalid api key")
+		}
+		return auth.TokenMetadata{}, err
+	}
+
+	var expiresAt int64
+	if apiKey.ExpiresAt != nil {
+		if time.Now().After(*apiKey.ExpiresAt) {
+			return auth.TokenMetadata{}, terr.NewUnAuthorizedError("api key expired")
+		}
+		expiresAt = apiKey.ExpiresAt.Unix()
+	}
+
+	roles, err := uc.roleRepo.GetRoleNamesByUserID(ctx, apiKey.UserID)
+	if err != nil {
+		return auth.TokenMetadata{}, errors.New("could not get user roles")
+	}
+
+	return auth.TokenMetadata{
+		ID:        apiKey.ID,
+		UserID:    apiKey.UserID,
+		Type:      auth.APIKeyTokenType,
+		Roles:     roles,
+		Scopes:    apiKey.Scopes,
+		IssuedAt:  apiKey.CreatedAt.Unix(),
+		ExpiresAt: expiresAt,
+	}, nil
+}
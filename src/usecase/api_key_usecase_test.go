@@ -0,0 +1,127 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"sherman/mocks"
+	_ "sherman/src/app/testing"
+	"sherman/src/app/utils/terr"
+	"sherman/src/domain/auth"
+	"strings"
+	"testing"
+	"time"
+)
+
+type apiKeyUseCaseMockDeps struct {
+	apiKeyRepository *mocks.APIKeyRepository
+	roleRepository   *mocks.RoleRepository
+}
+
+func genAPIKeyUseCase() (auth.APIKeyUseCase, apiKeyUseCaseMockDeps) {
+	akuDeps := apiKeyUseCaseMockDeps{
+		apiKeyRepository: new(mocks.APIKeyRepository),
+		roleRepository:   new(mocks.RoleRepository),
+	}
+
+	akuc := NewAPIKeyUseCase(akuDeps.apiKeyRepository, akuDeps.roleRepository)
+
+	return akuc, akuDeps
+}
+
+func TestCreateAPIKey(t *testing.T) {
+	expiresAt := time.Now().Add(time.Hour)
+
+	t.Run("it should succeed", func(t *testing.T) {
+		akuc, akuDeps := genAPIKeyUseCase()
+		akuDeps.apiKeyRepository.On("CreateAPIKey", mock.Anything, mock.AnythingOfType("*auth.APIKey")).Return(nil)
+
+		newAPIKey, err := akuc.CreateAPIKey(context.Background(), &auth.APIKey{
+			UserID:    "some-user-id",
+			Name:      "some key",
+			Scopes:    []string{auth.ProfileScope},
+			ExpiresAt: &expiresAt,
+		})
+
+		if assert.NoError(t, err) {
+			assert.True(t, strings.HasPrefix(newAPIKey.Key, apiKeyPrefix))
+			assert.Equal(t, newAPIKey.Key[:apiKeyDisplayLength], newAPIKey.Prefix)
+			assert.Equal(t, hashToken(newAPIKey.Key), newAPIKey.KeyHash)
+			assert.Equal(t, "some-user-id", newAPIKey.UserID)
+			assert.Equal(t, []string{auth.ProfileScope}, newAPIKey.Scopes)
+			assert.Equal(t, &expiresAt, newAPIKey.ExpiresAt)
+			akuDeps.apiKeyRepository.AssertCalled(t, "CreateAPIKey", mock.Anything, &newAPIKey.APIKey)
+		}
+	})
+
+	t.Run("it should return error", func(t *testing.T) {
+		akuc, akuDeps := genAPIKeyUseCase()
+		akuDeps.apiKeyRepository.On("CreateAPIKey", mock.Anything, mock.AnythingOfType("*auth.APIKey")).Return(errors.New("some error"))
+
+		_, err := akuc.CreateAPIKey(context.Background(), &auth.APIKey{UserID: "some-user-id", Name: "some key"})
+
+		assert.EqualError(t, err, "some error")
+	})
+}
+
+func TestAuthenticate(t *testing.T) {
+	key := apiKeyPrefix + "some-key"
+
+	t.Run("it should succeed", func(t *testing.T) {
+		expiresAt := time.Now().Add(time.Hour)
+		akuc, akuDeps := genAPIKeyUseCase()
+		akuDeps.apiKeyRepository.
+			On("GetAPIKeyByHash", mock.Anything, hashToken(key)).
+			Return(auth.APIKey{
+				ID:        "some-id",
+				UserID:    "some-user-id",
+				Scopes:    []string{auth.ProfileScope},
+				ExpiresAt: &expiresAt,
+			}, nil)
+		akuDeps.roleRepository.On("GetRoleNamesByUserID", mock.Anything, "some-user-id").Return([]string{auth.AdminRole}, nil)
+
+		principal, err := akuc.Authenticate(context.Background(), key)
+
+		if assert.NoError(t, err) {
+			assert.Equal(t, "some-id", principal.ID)
+			assert.Equal(t, "some-user-id", principal.UserID)
+			assert.Equal(t, auth.APIKeyTokenType, principal.Type)
+			assert.Equal(t, []string{auth.AdminRole}, principal.Roles)
+			assert.Equal(t, []string{auth.ProfileScope}, principal.Scopes)
+			assert.Equal(t, expiresAt.Unix(), principal.ExpiresAt)
+		}
+	})
+
+	t.Run("it should return error on unknown key", func(t *testing.T) {
+		akuc, akuDeps := genAPIKeyUseCase()
+		akuDeps.apiKeyRepository.
+			On("GetAPIKeyByHash", mock.Anything, hashToken(key)).
+			Return(auth.APIKey{}, terr.NewNotFoundError("api key not found"))
+
+		_, err := akuc.Authenticate(context.Background(), key)
+
+		assert.Equal(t, terr.NewUnAuthorizedError("invalid api key"), err)
+	})
+
+	t.Run("it should return error on malformed key", func(t *testing.T) {
+		akuc, akuDeps := genAPIKeyUseCase()
+
+		_, err := akuc.Authenticate(context.Background(), "some-key")
+
+		assert.Equal(t, terr.NewUnAuthorizedError("invalid api key"), err)
+		akuDeps.apiKeyRepository.AssertNotCalled(t, "GetAPIKeyByHash", mock.Anything, mock.Anything)
+	})
+
+	t.Run("it should return error on expired key", func(t *testing.T) {
+		expiresAt := time.Now().Add(-time.Second)
+		akuc, akuDeps := genAPIKeyUseCase()
+		akuDeps.apiKeyRepository.
+			On("GetAPIKeyByHash", mock.Anything, hashToken(key)).
+			Return(auth.APIKey{ID: "some-id", UserID: "some-user-id", ExpiresAt: &expiresAt}, nil)
+
+		_, err := akuc.Authenticate(context.Background(), key)
+
+		assert.Equal(t, terr.NewUnAuthorizedError("api key expired"), err)
+	})
+}
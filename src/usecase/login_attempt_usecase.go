@@ -0,0 +1,161 @@
+package usecase
+
+import (
+	"context"
+	"github.com/rs/zerolog/log"
+	"sherman/src/app/config"
+	"sherman/src/app/utils/terr"
+	"sherman/src/domain/auth"
+	"strings"
+	"time"
+)
+
+const (
+	accountLoginAttemptPrefix = "account:"
+	ipLoginAttemptPrefix      = "ip:"
+	twoFactorAttemptPrefix    = "mfa:"
+)
+
+// loginAttemptUseCase implementation of auth.LoginAttemptUseCase
+type loginAttemptUseCase struct {
+	loginAttemptRepo auth.LoginAttemptRepository
+	cfg              config.LoginConfig
+}
+
+// NewLoginAttemptUseCase constructor
+func NewLoginAttemptUseCase(lar auth.LoginAttemptRepository, cfg *config.GlobalConfig) auth.LoginAttemptUseCase {
+	return &loginAttemptUseCase{
+		loginAttemptRepo: lar,
+		cfg:              cfg.Login,
+	}
+}
+
+// CheckLogin checks that neither the account nor the IP address is locked,
+// returns a terr.AccountLockedError or a terr.TooManyAttemptsError with the remaining lock duration otherwise
+func (uc *loginAttemptUseCase) CheckLogin(ctx context.Context, email, ipAddress string) error {
+	now := time.Now()
+
+	lockedUntil, err := uc.lockedUntil(ctx, accountLoginAttemptKey(email))
+	if err != nil {
+		return err
+	}
+	if lockedUntil.After(now) {
+		return terr.NewAccountLockedError("account locked, too many failed logins", lockedUntil.Sub(now))
+	}
+
+	lockedUntil, err = uc.lockedUntil(ctx, ipLoginAttemptPrefix+ipAddress)
+	if err != nil {
+		return err
+	}
+	if lockedUntil.After(now) {
+		return terr.NewTooManyAttemptsError("too many failed logins, try again later", lockedUntil.Sub(now))
+	}
+	return nil
+}
+
+// RecordFailedLogin counts a failed login of the account and of the IP address,
+// locks them once they reach their maximum number of failures
+func (uc *loginAttemptUseCase) RecordFailedLogin(ctx context.Context, email, ipAddress string) error {
+	now := time.Now()
+
+	if err := uc.recordFailure(ctx, accountLoginAttemptKey(email), uc.cfg.MaxAccountFailures, now); err != nil {
+		return err
+	}
+	if err := uc.recordFailure(ctx, ipLoginAttemptPrefix+ipAddress, uc.cfg.MaxIPFailures, now); err != nil {
+		return err
+	}
+
+	// forgetting old failures is best effort, it must not fail the login
+	if err := uc.loginAttemptRepo.RemoveStaleLoginAttempts(ctx, now.Add(-uc.failureWindow())); err != nil {
+		log.Error().Msg(err.Error())
+	}
+	return nil
+}
+
+// RecordSuccessfulLogin forgets the failed logins of the account, the ones of the IP address are kept
+// so that a valid account can't be used to reset the throttling of an IP address
+func (uc *loginAttemptUseCase) RecordSuccessfulLogin(ctx context.Context, email string) error {
+	return uc.loginAttemptRepo.RemoveLoginAttempt(ctx, accountLoginAttemptKey(email))
+}
+
+// CheckTwoFactor checks that the two factor codes of a user are not locked,
+// returns a terr.TooManyAttemptsError with the remaining lock duration otherwise
+func (uc *loginAttemptUseCase) CheckTwoFactor(ctx context.Context, userID string) error {
+	now := time.Now()
+
+	lockedUntil, err := uc.lockedUntil(ctx, twoFactorAttemptPrefix+userID)
+	if err != nil {
+		return err
+	}
+	if lockedUntil.After(now) {
+		return terr.NewTooManyAttemptsError("too many failed codes, try again later", lockedUntil.Sub(now))
+	}
+	return nil
+}
+
+// RecordFailedTwoFactor counts a wrong two factor code of a user,
+// locks its codes once they reach the maximum number of failures
+func (uc *loginAttemptUseCase) RecordFailedTwoFactor(ctx context.Context, userID string) error {
+	return uc.recordFailure(ctx, twoFactorAttemptPrefix+userID, uc.cfg.MaxTwoFactorFailures, time.Now())
+}
+
+// RecordSuccessfulTwoFactor forgets the wrong two factor codes of a user
+func (uc *loginAttemptUseCase) RecordSuccessfulTwoFactor(ctx context.Context, userID string) error {
+	return uc.loginAttemptRepo.RemoveLoginAttempt(ctx, twoFactorAttemptPrefix+userID)
+}
+
+func (uc *loginAttemptUseCase) lockedUntil(ctx context.Context, key string) (time.Time, error) {
+	loginAttempt, err := uc.loginAttemptRepo.GetLoginAttempt(ctx, key)
+	if err != nil {
+		if _, ok := err.(*terr.NotFoundError); ok {
+			return time.Time{}, nil
+		}
+		return time.Time{}, err
+	}
+	return loginAttempt.LockedUntil, nil
+}
+
+// recordFailure counts a failure of key, the count is read and saved in a single update of the repository
+// so that concurrent failures can't overwrite each other and grant more than maxFailures attempts
+func (uc *loginAttemptUseCase) recordFailure(ctx context.Context, key string, maxFailures int, now time.Time) error {
+	return uc.loginAttemptRepo.UpdateLoginAttempt(ctx, key, func(loginAttempt *auth.LoginAttempt) {
+		// a quiet failure window since the last failure or lock starts the count over
+		lastActivity := loginAttempt.LastFailureAt
+		if loginAttempt.LockedUntil.After(lastActivity) {
+			lastActivity = loginAttempt.LockedUntil
+		}
+		if now.Sub(lastActivity) > uc.failureWindow() {
+			loginAttempt.Failures = 0
+			loginAttempt.Lockouts = 0
+		}
+
+		loginAttempt.Failures++
+		loginAttempt.LastFailureAt = now
+		if loginAttempt.Failures >= maxFailures {
+			loginAttempt.Lockouts++
+			loginAttempt.Failures = 0
+			loginAttempt.LockedUntil = now.Add(uc.lockDuration(loginAttempt.Lockouts))
+		}
+	})
+}
+
+// lockDuration doubles the configured lock duration on every lockout, up to the configured maximum
+func (uc *loginAttemptUseCase) lockDuration(lockouts int) time.Duration {
+	duration := time.Duration(uc.cfg.LockDuration) * time.Second
+	maxDuration := time.Duration(uc.cfg.MaxLockDuration) * time.Second
+	for i := 1; i < lockouts && duration < maxDuration; i++ {
+		duration *= 2
+	}
+	if duration > maxDuration {
+		duration = maxDuration
+	}
+	return duration
+}
+
+func (uc *loginAttemptUseCase) failureWindow() time.Duration {
+	return time.Duration(uc.cfg.FailureWindow) * time.Second
+}
+
+func accountLoginAttemptKey(email string) string {
+	return accountLoginAttemptPrefix + strings.ToLower(strings.TrimSpace(email))
+}
@@ -0,0 +1,337 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"sherman/mocks"
+	"sherman/src/app/config"
+	_ "sherman/src/app/testing"
+	"sherman/src/app/utils/terr"
+	"sherman/src/domain/auth"
+	"sherman/src/repository/memds"
+	"sync"
+	"testing"
+	"time"
+)
+
+type loginAttemptUseCaseMockDeps struct {
+	loginAttemptRepository *mocks.LoginAttemptRepository
+}
+
+func genLoginAttemptUseCase() (auth.LoginAttemptUseCase, loginAttemptUseCaseMockDeps) {
+	laucDeps := loginAttemptUseCaseMockDeps{
+		loginAttemptRepository: new(mocks.LoginAttemptRepository),
+	}
+
+	cfg := config.DefaultConfig
+	cfg.Login = config.LoginConfig{
+		MaxAccountFailures:   3,
+		MaxIPFailures:        10,
+		MaxTwoFactorFailures: 5,
+		FailureWindow:        900,
+		LockDuration:         60,
+		MaxLockDuration:      300,
+	}
+	lauc := NewLoginAttemptUseCase(laucDeps.loginAttemptRepository, &cfg)
+
+	return lauc, laucDeps
+}
+
+func TestCheckLogin(t *testing.T) {
+	notFoundErr := terr.NewNotFoundError("login attempt not found")
+
+	t.Run("it should succeed", func(t *testing.T) {
+		lauc, laucDeps := genLoginAttemptUseCase()
+		laucDeps.loginAttemptRepository.
+			On("GetLoginAttempt", mock.Anything, "account:some@email.com").
+			Return(auth.LoginAttempt{}, notFoundErr)
+		laucDeps.loginAttemptRepository.
+			On("GetLoginAttempt", mock.Anything, "ip:10.0.0.1").
+			Return(auth.LoginAttempt{Failures: 2, LockedUntil: time.Now().Add(-time.Minute)}, nil)
+
+		err := lauc.CheckLogin(context.Background(), " Some@Email.com", "10.0.0.1")
+
+		assert.NoError(t, err)
+	})
+
+	t.Run("it should return an account locked error", func(t *testing.T) {
+		lauc, laucDeps := genLoginAttemptUseCase()
+		laucDeps.loginAttemptRepository.
+			On("GetLoginAttempt", mock.Anything, "account:some@email.com").
+			Return(auth.LoginAttempt{LockedUntil: time.Now().Add(time.Minute)}, nil)
+
+		err := lauc.CheckLogin(context.Background(), "some@email.com", "10.0.0.1")
+
+		if assert.IsType(t, &terr.AccountLockedError{}, err) {
+			retryAfter := err.(*terr.AccountLockedError).RetryAfter()
+			assert.True(t, retryAfter > 0 && retryAfter <= time.Minute)
+		}
+	})
+
+	t.Run("it should return a too many attempts error", func(t *testing.T) {
+		lauc, laucDeps := genLoginAttemptUseCase()
+		laucDeps.loginAttemptRepository.
+			On("GetLoginAttempt", mock.Anything, "account:some@email.com").
+			Return(auth.LoginAttempt{}, notFoundErr)
+		laucDeps.loginAttemptRepository.
+			On("GetLoginAttempt", mock.Anything, "ip:10.0.0.1").
+			Return(auth.LoginAttempt{LockedUntil: time.Now().Add(time.Minute)}, nil)
+
+		err := lauc.CheckLogin(context.Background(), "some@email.com", "10.0.0.1")
+
+		assert.IsType(t, &terr.TooManyAttemptsError{}, err)
+	})
+
+	t.Run("it should return error", func(t *testing.T) {
+		lauc, laucDeps := genLoginAttemptUseCase()
+		laucDeps.loginAttemptRepository.
+			On("GetLoginAttempt", mock.Anything, mock.Anything).
+			Return(auth.LoginAttempt{}, errors.New("some error"))
+
+		err := lauc.CheckLogin(context.Background(), "some@email.com", "10.0.0.1")
+
+		assert.EqualError(t, err, "some error")
+	})
+}
+
+func TestRecordFailedLogin(t *testing.T) {
+	t.Run("it should count the failures", func(t *testing.T) {
+		lauc, laucDeps := genLoginAttemptUseCase()
+		laucDeps.loginAttemptRepository.
+			On("UpdateLoginAttempt", mock.Anything, "account:some@email.com", updatesLoginAttempt(
+				auth.LoginAttempt{Key: "account:some@email.com"},
+				func(la *auth.LoginAttempt) bool {
+					return la.Failures == 1 && la.LockedUntil.IsZero()
+				})).
+			Return(nil)
+		laucDeps.loginAttemptRepository.
+			On("UpdateLoginAttempt", mock.Anything, "ip:10.0.0.1", updatesLoginAttempt(
+				auth.LoginAttempt{Key: "ip:10.0.0.1", Failures: 4, LastFailureAt: time.Now()},
+				func(la *auth.LoginAttempt) bool {
+					return la.Failures == 5 && la.LockedUntil.IsZero()
+				})).
+			Return(nil)
+		laucDeps.loginAttemptRepository.
+			On("RemoveStaleLoginAttempts", mock.Anything, mock.AnythingOfType("time.Time")).
+			Return(errors.New("some error"))
+
+		err := lauc.RecordFailedLogin(context.Background(), "some@email.com", "10.0.0.1")
+
+		assert.NoError(t, err)
+		laucDeps.loginAttemptRepository.AssertNumberOfCalls(t, "UpdateLoginAttempt", 2)
+	})
+
+	t.Run("it should lock the account with a doubled duration", func(t *testing.T) {
+		lauc, laucDeps := genLoginAttemptUseCase()
+		now := time.Now()
+		laucDeps.loginAttemptRepository.
+			On("UpdateLoginAttempt", mock.Anything, "account:some@email.com", updatesLoginAttempt(
+				auth.LoginAttempt{
+					Key:           "account:some@email.com",
+					Failures:      2,
+					Lockouts:      1,
+					LockedUntil:   now.Add(-time.Minute),
+					LastFailureAt: now.Add(-2 * time.Minute),
+				},
+				func(la *auth.LoginAttempt) bool {
+					lockDuration := la.LockedUntil.Sub(la.LastFailureAt)
+					return la.Failures == 0 && la.Lockouts == 2 && lockDuration == 2*time.Minute
+				})).
+			Return(nil)
+		laucDeps.loginAttemptRepository.
+			On("UpdateLoginAttempt", mock.Anything, "ip:10.0.0.1", mock.Anything).
+			Return(nil)
+		laucDeps.loginAttemptRepository.
+			On("RemoveStaleLoginAttempts", mock.Anything, mock.Anything).
+			Return(nil)
+
+		err := lauc.RecordFailedLogin(context.Background(), "some@email.com", "10.0.0.1")
+
+		assert.NoError(t, err)
+	})
+
+	t.Run("it should cap the lock duration", func(t *testing.T) {
+		lauc, laucDeps := genLoginAttemptUseCase()
+		now := time.Now()
+		laucDeps.loginAttemptRepository.
+			On("UpdateLoginAttempt", mock.Anything, "account:some@email.com", updatesLoginAttempt(
+				auth.LoginAttempt{Failures: 2, Lockouts: 6, LastFailureAt: now},
+				func(la *auth.LoginAttempt) bool {
+					return la.Lockouts == 7 && la.LockedUntil.Sub(la.LastFailureAt) == 5*time.Minute
+				})).
+			Return(nil)
+		laucDeps.loginAttemptRepository.
+			On("UpdateLoginAttempt", mock.Anything, "ip:10.0.0.1", mock.Anything).
+			Return(nil)
+		laucDeps.loginAttemptRepository.
+			On("RemoveStaleLoginAttempts", mock.Anything, mock.Anything).
+			Return(nil)
+
+		err := lauc.RecordFailedLogin(context.Background(), "some@email.com", "10.0.0.1")
+
+		assert.NoError(t, err)
+	})
+
+	t.Run("it should start over after a quiet failure window", func(t *testing.T) {
+		lauc, laucDeps := genLoginAttemptUseCase()
+		now := time.Now()
+		laucDeps.loginAttemptRepository.
+			On("UpdateLoginAttempt", mock.Anything, "account:some@email.com", updatesLoginAttempt(
+				auth.LoginAttempt{
+					Failures:      2,
+					Lockouts:      3,
+					LockedUntil:   now.Add(-20 * time.Minute),
+					LastFailureAt: now.Add(-30 * time.Minute),
+				},
+				func(la *auth.LoginAttempt) bool {
+					return la.Failures == 1 && la.Lockouts == 0
+				})).
+			Return(nil)
+		laucDeps.loginAttemptRepository.
+			On("UpdateLoginAttempt", mock.Anything, "ip:10.0.0.1", mock.Anything).
+			Return(nil)
+		laucDeps.loginAttemptRepository.
+			On("RemoveStaleLoginAttempts", mock.Anything, mock.Anything).
+			Return(nil)
+
+		err := lauc.RecordFailedLogin(context.Background(), "some@email.com", "10.0.0.1")
+
+		assert.NoError(t, err)
+	})
+
+	t.Run("it should lock the account once under concurrent failures", func(t *testing.T) {
+		cfg := config.DefaultConfig
+		cfg.Login = config.LoginConfig{
+			MaxAccountFailures: 3,
+			MaxIPFailures:      100,
+			FailureWindow:      900,
+			LockDuration:       60,
+			MaxLockDuration:    300,
+		}
+		loginAttemptRepo := memds.NewLoginAttemptRepository()
+		lauc := NewLoginAttemptUseCase(loginAttemptRepo, &cfg)
+
+		var wg sync.WaitGroup
+		for i := 0; i < 30; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				assert.NoError(t, lauc.RecordFailedLogin(context.Background(), "some@email.com", "10.0.0.1"))
+			}()
+		}
+		wg.Wait()
+
+		loginAttempt, err := loginAttemptRepo.GetLoginAttempt(context.Background(), "account:some@email.com")
+		if assert.NoError(t, err) {
+			assert.Equal(t, 10, loginAttempt.Lockouts)
+			assert.Equal(t, 0, loginAttempt.Failures)
+		}
+		loginAttempt, err = loginAttemptRepo.GetLoginAttempt(context.Background(), "ip:10.0.0.1")
+		if assert.NoError(t, err) {
+			assert.Equal(t, 30, loginAttempt.Failures)
+		}
+	})
+
+	t.Run("it should return error", func(t *testing.T) {
+		lauc, laucDeps := genLoginAttemptUseCase()
+		laucDeps.loginAttemptRepository.
+			On("UpdateLoginAttempt", mock.Anything, mock.Anything, mock.Anything).
+			Return(errors.New("some error"))
+
+		err := lauc.RecordFailedLogin(context.Background(), "some@email.com", "10.0.0.1")
+
+		assert.EqualError(t, err, "some error")
+	})
+}
+
+func TestRecordSuccessfulLogin(t *testing.T) {
+	lauc, laucDeps := genLoginAttemptUseCase()
+	laucDeps.loginAttemptRepository.
+		On("RemoveLoginAttempt", mock.Anything, "account:some@email.com").
+		Return(nil)
+
+	err := lauc.RecordSuccessfulLogin(context.Background(), "Some@Email.com")
+
+	assert.NoError(t, err)
+	laucDeps.loginAttemptRepository.AssertExpectations(t)
+}
+
+func TestCheckTwoFactor(t *testing.T) {
+	t.Run("it should succeed", func(t *testing.T) {
+		lauc, laucDeps := genLoginAttemptUseCase()
+		laucDeps.loginAttemptRepository.
+			On("GetLoginAttempt", mock.Anything, "mfa:some-user-id").
+			Return(auth.LoginAttempt{}, terr.NewNotFoundError("login attempt not found"))
+
+		err := lauc.CheckTwoFactor(context.Background(), "some-user-id")
+
+		assert.NoError(t, err)
+	})
+
+	t.Run("it should return a too many attempts error", func(t *testing.T) {
+		lauc, laucDeps := genLoginAttemptUseCase()
+		laucDeps.loginAttemptRepository.
+			On("GetLoginAttempt", mock.Anything, "mfa:some-user-id").
+			Return(auth.LoginAttempt{LockedUntil: time.Now().Add(time.Minute)}, nil)
+
+		err := lauc.CheckTwoFactor(context.Background(), "some-user-id")
+
+		if assert.IsType(t, &terr.TooManyAttemptsError{}, err) {
+			retryAfter := err.(*terr.TooManyAttemptsError).RetryAfter()
+			assert.True(t, retryAfter > 0 && retryAfter <= time.Minute)
+		}
+	})
+}
+
+func TestRecordFailedTwoFactor(t *testing.T) {
+	t.Run("it should lock the codes", func(t *testing.T) {
+		lauc, laucDeps := genLoginAttemptUseCase()
+		now := time.Now()
+		laucDeps.loginAttemptRepository.
+			On("UpdateLoginAttempt", mock.Anything, "mfa:some-user-id", updatesLoginAttempt(
+				auth.LoginAttempt{Key: "mfa:some-user-id", Failures: 4, LastFailureAt: now},
+				func(la *auth.LoginAttempt) bool {
+					return la.Failures == 0 && la.Lockouts == 1 && la.LockedUntil.After(now.Add(59*time.Second))
+				})).
+			Return(nil)
+
+		err := lauc.RecordFailedTwoFactor(context.Background(), "some-user-id")
+
+		assert.NoError(t, err)
+		laucDeps.loginAttemptRepository.AssertExpectations(t)
+	})
+
+	t.Run("it should return error", func(t *testing.T) {
+		lauc, laucDeps := genLoginAttemptUseCase()
+		laucDeps.loginAttemptRepository.
+			On("UpdateLoginAttempt", mock.Anything, "mfa:some-user-id", mock.Anything).
+			Return(errors.New("some error"))
+
+		err := lauc.RecordFailedTwoFactor(context.Background(), "some-user-id")
+
+		assert.EqualError(t, err, "some error")
+	})
+}
+
+func TestRecordSuccessfulTwoFactor(t *testing.T) {
+	lauc, laucDeps := genLoginAttemptUseCase()
+	laucDeps.loginAttemptRepository.
+		On("RemoveLoginAttempt", mock.Anything, "mfa:some-user-id").
+		Return(nil)
+
+	err := lauc.RecordSuccessfulTwoFactor(context.Background(), "some-user-id")
+
+	assert.NoError(t, err)
+	laucDeps.loginAttemptRepository.AssertExpectations(t)
+}
+
+// updatesLoginAttempt matches an update of UpdateLoginAttempt that turns loginAttempt into a login attempt passing check
+func updatesLoginAttempt(loginAttempt auth.LoginAttempt, check func(la *auth.LoginAttempt) bool) interface{} {
+	return mock.MatchedBy(func(update func(loginAttempt *auth.LoginAttempt)) bool {
+		updated := loginAttempt
+		update(&updated)
+		return check(&updated)
+	})
+}
@@ -0,0 +1,498 @@
+package usecase
+
+import (
+	"context"
+	"crypto/subtle"
+	"github.com/google/uuid"
+	"net/url"
+	"sherman/src/app/utils/terr"
+	"sherman/src/app/utils/tokens"
+	"sherman/src/domain/auth"
+	"sherman/src/service/security"
+	"strings"
+	"time"
+)
+
+const (
+	// authorizationCodeDuration validity of authorization codes, RFC 6749 recommends at most 10 minutes
+	authorizationCodeDuration = time.Minute
+	// pkceMethod the only supported PKCE code challenge method
+	pkceMethod = "S256"
+)
+
+// oauthUseCase implementation of auth.OAuthUseCase
+type oauthUseCase struct {
+	clientRepo           auth.OAuthClientRepository
+	codeRepo             auth.AuthorizationCodeRepository
+	userUseCase          auth.UserUseCase
+	securityTokenUseCase auth.SecurityTokenUseCase
+	security             security.Security
+}
+
+// NewOAuthUseCase constructor
+func NewOAuthUseCase(
+	ocr auth.OAuthClientRepository,
+	acr auth.AuthorizationCodeRepository,
+	uuc auth.UserUseCase,
+	stuc auth.SecurityTokenUseCase,
+	ss security.Security,
+) auth.OAuthUseCase {
+	return &oauthUseCase{
+		clientRepo:           ocr,
+		codeRepo:             acr,
+		userUseCase:          uuc,
+		securityTokenUseCase: stuc,
+		security:             ss,
+	}
+}
+
+// Authorize grants an authorization code to a client for the signed in user and returns the client
+// redirect uri with the code, or with the error, and the request state, clients are first party so
+// no consent is asked, an unknown client or redirect uri can't be redirected to and gets an error
+func (uc *oauthUseCase) Authorize(ctx context.Context, userID string, request *auth.AuthorizationRequest) (string, error) {
+	client, err := uc.clientRepo.GetClientByID(ctx, request.ClientID)
+	if err != nil {
+		if _, ok := err.(*terr.NotFoundError); ok {
+			return "", terr.NewOAuthError("invalid_client", "unknown client")
+		}
+		return "", err
+	}
+
+	redirectURI, ok := matchRedirectURI(&client, request.RedirectURI)
+	if !ok {
+		return "", terr.NewOAuthError("invalid_request", "invalid redirect uri")
+	}
+
+	switch {
+	case request.ResponseType != "code":
+		return authorizationRedirect(redirectURI, request.State, url.Values{
+			"error": {"unsupported_response_type"},
+		}), nil
+	case !security.ContainsString(client.GrantTypes, auth.AuthorizationCodeGrant):
+		return authorizationRedirect(redirectURI, request.State, url.Values{
+			"error": {"unauthorized_client"},
+		}), nil
+	case request.CodeChallenge == "" || request.CodeChallengeMethod != pkceMethod:
+		return authorizationRedirect(redirectURI, request.State, url.Values{
+			"error":             {"invalid_request"},
+			"error_description": {"code challenge required with method " + pkceMethod},
+		}), nil
+	}
+
+	scopes, ok := grantedScopes(client.Scopes, request.Scope)
+	if !ok {
+		return authorizationRedirect(redirectURI, request.State, url.Values{"error": {"invalid_scope"}}), nil
+	}
+
+	if userID == "" {
+		return authorizationRedirect(redirectURI, request.State, url.Values{"error": {"login_required"}}), nil
+	}
+
+	code, err := tokens.Random()
+	if err != nil {
+		return "", err
+	}
+
+	err = uc.codeRepo.CreateAuthorizationCode(ctx, &auth.AuthorizationCode{
+		CodeHash:      hashToken(code),
+		ClientID:      client.ID,
+		UserID:        userID,
+		RedirectURI:   request.RedirectURI,
+		Scopes:        scopes,
+		CodeChallenge: request.CodeChallenge,
+		ExpiresAt:     time.Now().Add(authorizationCodeDuration),
+		CreatedAt:     time.Now(),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return authorizationRedirect(redirectURI, request.State, url.Values{"code": {code}}), nil
+}
+
+// Token exchanges an authorization grant for an access token, errors are terr.OAuthError except
+// for datastore failures
+func (uc *oauthUseCase) Token(ctx context.Context, request *auth.OAuthTokenRequest) (auth.OAuthTokenResponse, error) {
+	client, err := uc.authenticateClient(ctx, request.ClientID, request.ClientSecret)
+	if err != nil {
+		return auth.OAuthTokenResponse{}, err
+	}
+
+	switch request.GrantType {
+	case auth.AuthorizationCodeGrant, auth.RefreshTokenGrant, auth.ClientCredentialsGrant:
+	case "":
+		return auth.OAuthTokenResponse{}, terr.NewOAuthError("invalid_request", "grant type required")
+	default:
+		return auth.OAuthTokenResponse{}, terr.NewOAuthError("unsupported_grant_type", "unsupported grant type")
+	}
+	if !security.ContainsString(client.GrantTypes, request.GrantType) {
+		return auth.OAuthTokenResponse{}, terr.NewOAuthError("unauthorized_client", "grant type not allowed")
+	}
+
+	switch request.GrantType {
+	case auth.AuthorizationCodeGrant:
+		return uc.exchangeAuthorizationCode(ctx, &client, request)
+	case auth.RefreshTokenGrant:
+		return uc.exchangeRefreshToken(ctx, &client, request)
+	default:
+		return uc.exchangeClientCredentials(ctx, &client, request)
+	}
+}
+
+// exchangeAuthorizationCode consumes an authorization code, the code verifier must match its PKCE
+// challenge, a refresh token is only issued to clients allowed the refresh_token grant
+func (uc *oauthUseCase) exchangeAuthorizationCode(
+	ctx context.Context,
+	client *auth.OAuthClient,
+	request *auth.OAuthTokenRequest,
+) (auth.OAuthTokenResponse, error) {
+	if request.Code == "" || request.CodeVerifier == "" {
+		return auth.OAuthTokenResponse{}, terr.NewOAuthError("invalid_request", "code and code verifier required")
+	}
+
+	code, err := uc.codeRepo.ConsumeAuthorizationCode(ctx, hashToken(request.Code))
+	if err != nil {
+		if _, ok := err.(*terr.NotFoundError); ok {
+			return auth.OAuthTokenResponse{}, terr.NewOAuthError("invalid_grant", "invalid authorization code")
+		}
+		return auth.OAuthTokenResponse{}, err
+	}
+
+	switch {
+	case code.ClientID != client.ID:
+		return auth.OAuthTokenResponse{}, terr.NewOAuthError("invalid_grant", "invalid authorization code")
+	case time.Now().After(code.ExpiresAt):
+		return auth.OAuthTokenResponse{}, terr.NewOAuthError("invalid_grant", "authorization code expired")
+	case code.RedirectURI != request.RedirectURI:
+		return auth.OAuthTokenResponse{}, terr.NewOAuthError("invalid_grant", "invalid redirect uri")
+	case subtle.ConstantTimeCompare([]byte(tokens.S256Challenge(request.CodeVerifier)), []byte(code.CodeChallenge)) != 1:
+		return auth.OAuthTokenResponse{}, terr.NewOAuthError("invalid_grant", "invalid code verifier")
+	case !uc.userUseCase.IsUserActive(ctx, code.UserID):
+		return auth.OAuthTokenResponse{}, terr.NewOAuthError("invalid_grant", "user account deactivated")
+	}
+
+	accessToken, err := uc.securityTokenUseCase.GenScopedAccessToken(ctx, code.UserID, client.ID, code.Scopes)
+	if err != nil {
+		return auth.OAuthTokenResponse{}, err
+	}
+	response := newTokenResponse(accessToken.Token, code.Scopes)
+
+	if security.ContainsString(client.GrantTypes, auth.RefreshTokenGrant) {
+		refreshToken, err := uc.securityTokenUseCase.GenScopedRefreshToken(
+			ctx,
+			code.UserID,
+			client.ID,
+			code.Scopes,
+			request.UserAgent,
+			request.IPAddress,
+		)
+		if err != nil {
+			return auth.OAuthTokenResponse{}, err
+		}
+		response.RefreshToken = refreshToken.Token
+	}
+
+	return response, nil
+}
+
+// exchangeRefreshToken rotates a refresh token of the client, the access token may be narrowed to
+// a subset of the refresh token scopes which the rotated refresh token keeps
+func (uc *oauthUseCase) exchangeRefreshToken(
+	ctx context.Context,
+	client *auth.OAuthClient,
+	request *auth.OAuthTokenRequest,
+) (auth.OAuthTokenResponse, error) {
+	refreshTokenMetadata, err := uc.security.ValidateToken(request.RefreshToken, auth.RefreshTokenType)
+	if err != nil || refreshTokenMetadata.ClientID != client.ID {
+		return auth.OAuthTokenResponse{}, terr.NewOAuthError("invalid_grant", "invalid refresh token")
+	}
+
+	scopes, ok := grantedScopes(refreshTokenMetadata.Scopes, request.Scope)
+	if !ok {
+		return auth.OAuthTokenResponse{}, terr.NewOAuthError("invalid_scope", "scope exceeds the granted scope")
+	}
+
+	if !uc.userUseCase.IsUserActive(ctx, refreshTokenMetadata.UserID) {
+		return auth.OAuthTokenResponse{}, terr.NewOAuthError("invalid_grant", "user account deactivated")
+	}
+
+	refreshToken, err := uc.securityTokenUseCase.RotateRefreshToken(ctx, &refreshTokenMetadata)
+	if err != nil {
+		if _, ok := err.(*terr.UnAuthorizedError); ok {
+			return auth.OAuthTokenResponse{}, terr.NewOAuthError("invalid_grant", err.Error())
+		}
+		return auth.OAuthTokenResponse{}, err
+	}
+
+	accessToken, err := uc.securityTokenUseCase.GenScopedAccessToken(ctx, refreshTokenMetadata.UserID, client.ID, scopes)
+	if err != nil {
+		return auth.OAuthTokenResponse{}, err
+	}
+
+	response := newTokenResponse(accessToken.Token, scopes)
+	response.RefreshToken = refreshToken.Token
+	return response, nil
+}
+
+// exchangeClientCredentials issues an access token to a confidential client acting on its own behalf
+func (uc *oauthUseCase) exchangeClientCredentials(
+	ctx context.Context,
+	client *auth.OAuthClient,
+	request *auth.OAuthTokenRequest,
+) (auth.OAuthTokenResponse, error) {
+	if client.SecretHash == "" {
+		return auth.OAuthTokenResponse{}, terr.NewOAuthError("unauthorized_client", "public clients can't use client credentials")
+	}
+
+	scopes, ok := grantedScopes(client.Scopes, request.Scope)
+	if !ok {
+		return auth.OAuthTokenResponse{}, terr.NewOAuthError("invalid_scope", "scope exceeds the client scope")
+	}
+
+	accessToken, err := uc.securityTokenUseCase.GenClientAccessToken(ctx, client.ID, scopes)
+	if err != nil {
+		return auth.OAuthTokenResponse{}, err
+	}
+	return newTokenResponse(accessToken.Token, scopes), nil
+}
+
+// Revoke revokes an access or refresh token of the client (RFC 7009), unknown tokens and tokens of
+// other clients are ignored so that the response tells nothing about them
+func (uc *oauthUseCase) Revoke(ctx context.Context, clientID, clientSecret, token string) error {
+	client, err := uc.authenticateClient(ctx, clientID, clientSecret)
+	if err != nil {
+		return err
+	}
+
+	if tokenMetadata, err := uc.security.ValidateToken(token, auth.RefreshTokenType); err == nil {
+		if tokenMetadata.ClientID != client.ID {
+			return nil
+		}
+		if err := uc.securityTokenUseCase.RemoveRefreshToken(ctx, &tokenMetadata); err != nil {
+			if _, ok := err.(*terr.NotFoundError); ok {
+				return nil
+			}
+			return err
+		}
+		return nil
+	}
+
+	for _, tokenType := range []string{auth.AccessTokenType, auth.ClientAccessTokenType} {
+		if tokenMetadata, err := uc.security.ValidateToken(token, tokenType); err == nil {
+			if tokenMetadata.ClientID != client.ID {
+				return nil
+			}
+			return uc.securityTokenUseCase.RevokeAccessToken(ctx, &tokenMetadata)
+		}
+	}
+
+	return nil
+}
+
+// Introspect describes a token to a confidential client (RFC 7662), invalid, expired, revoked or
+// rotated tokens and tokens of deactivated users are inactive
+func (uc *oauthUseCase) Introspect(ctx context.Context, clientID, clientSecret, token string) (auth.TokenIntrospection, error) {
+	client, err := uc.authenticateClient(ctx, clientID, clientSecret)
+	if err != nil {
+		return auth.TokenIntrospection{}, err
+	}
+	if client.SecretHash == "" {
+		return auth.TokenIntrospection{}, terr.NewOAuthError("unauthorized_client", "public clients can't introspect tokens")
+	}
+
+	for _, tokenType := range []string{auth.AccessTokenType, auth.ClientAccessTokenType, auth.RefreshTokenType} {
+		tokenMetadata, err := uc.security.ValidateToken(token, tokenType)
+		if err != nil {
+			continue
+		}
+
+		if !uc.isTokenActive(ctx, &tokenMetadata) {
+			break
+		}
+		return auth.TokenIntrospection{
+			Active:    true,
+			Scope:     strings.Join(tokenMetadata.Scopes, " "),
+			ClientID:  tokenMetadata.ClientID,
+			TokenType: tokenMetadata.Type,
+			Subject:   tokenMetadata.UserID,
+			Issuer:    tokenMetadata.Issuer,
+			Audience:  tokenMetadata.Audience,
+			JwtID:     tokenMetadata.ID,
+			IssuedAt:  tokenMetadata.IssuedAt,
+			NotBefore: tokenMetadata.NotBefore,
+			ExpiresAt: tokenMetadata.ExpiresAt,
+		}, nil
+	}
+
+	return auth.TokenIntrospection{Active: false}, nil
+}
+
+// CreateClient registers an OAuth client, confidential clients get a generated secret that is only
+// returned this once, public clients have none and authenticate with PKCE alone
+func (uc *oauthUseCase) CreateClient(ctx context.Context, client *auth.OAuthClient, public bool) (auth.NewOAuthClient, error) {
+	now := time.Now()
+	newClient := auth.NewOAuthClient{
+		OAuthClient: auth.OAuthClient{
+			ID:           uuid.New().String(),
+			Name:         client.Name,
+			RedirectURIs: client.RedirectURIs,
+			GrantTypes:   client.GrantTypes,
+			Scopes:       client.Scopes,
+			CreatedAt:    now,
+			UpdatedAt:    now,
+		},
+	}
+
+	if !public {
+		secret, secretHash, err := uc.genClientSecret()
+		if err != nil {
+			return auth.NewOAuthClient{}, err
+		}
+		newClient.Secret = secret
+		newClient.SecretHash = secretHash
+	}
+
+	if err := uc.clientRepo.CreateClient(ctx, &newClient.OAuthClient); err != nil {
+		return auth.NewOAuthClient{}, err
+	}
+	return newClient, nil
+}
+
+// RotateClientSecret replaces the secret of a confidential client, the previous secret stops working
+// right away and the new one is only returned this once
+func (uc *oauthUseCase) RotateClientSecret(ctx context.Context, id string) (auth.NewOAuthClient, error) {
+	client, err := uc.clientRepo.GetClientByID(ctx, id)
+	if err != nil {
+		return auth.NewOAuthClient{}, err
+	}
+	if client.SecretHash == "" {
+		return auth.NewOAuthClient{}, terr.NewOAuthError("invalid_request", "public clients have no secret")
+	}
+
+	secret, secretHash, err := uc.genClientSecret()
+	if err != nil {
+		return auth.NewOAuthClient{}, err
+	}
+	client.SecretHash = secretHash
+	client.UpdatedAt = time.Now()
+
+	if err := uc.clientRepo.UpdateClientSecret(ctx, client.ID, client.SecretHash, client.UpdatedAt); err != nil {
+		return auth.NewOAuthClient{}, err
+	}
+	return auth.NewOAuthClient{OAuthClient: client, Secret: secret}, nil
+}
+
+// genClientSecret generates a client secret and its hash, secrets are hashed like passwords
+func (uc *oauthUseCase) genClientSecret() (string, string, error) {
+	secret, err := tokens.Random()
+	if err != nil {
+		return "", "", err
+	}
+
+	secretHash, err := uc.security.Hash(secret)
+	if err != nil {
+		return "", "", err
+	}
+	return secret, string(secretHash), nil
+}
+
+// isTokenActive checks that a valid token has not been revoked nor rotated and that its user is active
+func (uc *oauthUseCase) isTokenActive(ctx context.Context, tokenMetadata *auth.TokenMetadata) bool {
+	switch tokenMetadata.Type {
+	case auth.ClientAccessTokenType:
+		return !uc.securityTokenUseCase.IsAccessTokenRevoked(ctx, tokenMetadata)
+	case auth.RefreshTokenType:
+		if !uc.securityTokenUseCase.IsRefreshTokenStored(ctx, tokenMetadata) {
+			return false
+		}
+	default:
+		if uc.securityTokenUseCase.IsAccessTokenRevoked(ctx, tokenMetadata) {
+			return false
+		}
+	}
+	return uc.userUseCase.IsUserActive(ctx, tokenMetadata.UserID)
+}
+
+// authenticateClient authenticates a client with its secret, public clients have no secret and are
+// identified by their id alone
+func (uc *oauthUseCase) authenticateClient(ctx context.Context, clientID, clientSecret string) (auth.OAuthClient, error) {
+	if clientID == "" {
+		return auth.OAuthClient{}, terr.NewOAuthError("invalid_client", "client authentication failed")
+	}
+
+	client, err := uc.clientRepo.GetClientByID(ctx, clientID)
+	if err != nil {
+		if _, ok := err.(*terr.NotFoundError); ok {
+			return auth.OAuthClient{}, terr.NewOAuthError("invalid_client", "client authentication failed")
+		}
+		return auth.OAuthClient{}, err
+	}
+
+	if client.SecretHash == "" {
+		if clientSecret != "" {
+			return auth.OAuthClient{}, terr.NewOAuthError("invalid_client", "client authentication failed")
+		}
+		return client, nil
+	}
+
+	if err := uc.security.VerifyPassword(client.SecretHash, clientSecret); err != nil {
+		return auth.OAuthClient{}, terr.NewOAuthError("invalid_client", "client authentication failed")
+	}
+	return client, nil
+}
+
+// matchRedirectURI matches a redirect uri against the registered ones of a client, it may be omitted
+// when the client registered a single one
+func matchRedirectURI(client *auth.OAuthClient, redirectURI string) (string, bool) {
+	if redirectURI == "" {
+		if len(client.RedirectURIs) == 1 {
+			return client.RedirectURIs[0], true
+		}
+		return "", false
+	}
+	return redirectURI, security.ContainsString(client.RedirectURIs, redirectURI)
+}
+
+// grantedScopes parses a space delimited scope that must be a subset of allowed, an empty scope
+// grants every allowed scope
+func grantedScopes(allowed []string, scope string) ([]string, bool) {
+	scopes := strings.Fields(scope)
+	if len(scopes) == 0 {
+		return allowed, true
+	}
+
+	for _, s := range scopes {
+		if !security.ContainsString(allowed, s) {
+			return nil, false
+		}
+	}
+	return scopes, true
+}
+
+// authorizationRedirect adds params and state to the query of a redirect uri
+func authorizationRedirect(redirectURI, state string, params url.Values) string {
+	u, err := url.Parse(redirectURI)
+	if err != nil {
+		return redirectURI
+	}
+
+	query := u.Query()
+	for name, values := range params {
+		query[name] = values
+	}
+	if state != "" {
+		query.Set("state", state)
+	}
+	u.RawQuery = query.Encode()
+	return u.String()
+}
+
+// newTokenResponse builds a bearer auth.OAuthTokenResponse
+func newTokenResponse(accessToken string, scopes []string) auth.OAuthTokenResponse {
+	return auth.OAuthTokenResponse{
+		AccessToken: accessToken,
+		TokenType:   "Bearer",
+		ExpiresIn:   int64(accessTokenDuration.Seconds()),
+		Scope:       strings.Join(scopes, " "),
+	}
+}
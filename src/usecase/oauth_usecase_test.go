@@ -0,0 +1,602 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"net/url"
+	"sherman/mocks"
+	_ "sherman/src/app/testing"
+	"sherman/src/app/utils/terr"
+	"sherman/src/app/utils/tokens"
+	"sherman/src/domain/auth"
+	"testing"
+	"time"
+)
+
+type oauthUseCaseMockDeps struct {
+	clientRepository            *mocks.OAuthClientRepository
+	authorizationCodeRepository *mocks.AuthorizationCodeRepository
+	userUseCase                 *mocks.UserUseCase
+	securityTokenUseCase        *mocks.SecurityTokenUseCase
+	securityService             *mocks.Security
+}
+
+func genOAuthUseCase() (auth.OAuthUseCase, oauthUseCaseMockDeps) {
+	ouDeps := oauthUseCaseMockDeps{
+		clientRepository:            new(mocks.OAuthClientRepository),
+		authorizationCodeRepository: new(mocks.AuthorizationCodeRepository),
+		userUseCase:                 new(mocks.UserUseCase),
+		securityTokenUseCase:        new(mocks.SecurityTokenUseCase),
+		securityService:             new(mocks.Security),
+	}
+
+	ouc := NewOAuthUseCase(
+		ouDeps.clientRepository,
+		ouDeps.authorizationCodeRepository,
+		ouDeps.userUseCase,
+		ouDeps.securityTokenUseCase,
+		ouDeps.securityService,
+	)
+
+	return ouc, ouDeps
+}
+
+func genPublicClient() auth.OAuthClient {
+	return auth.OAuthClient{
+		ID:           "some-app",
+		RedirectURIs: []string{"https://app.test/callback"},
+		GrantTypes:   []string{auth.AuthorizationCodeGrant, auth.RefreshTokenGrant},
+		Scopes:       []string{auth.ProfileScope, auth.AccountScope},
+	}
+}
+
+func genConfidentialClient() auth.OAuthClient {
+	return auth.OAuthClient{
+		ID:         "some-service",
+		SecretHash: "some-secret-hash",
+		GrantTypes: []string{auth.ClientCredentialsGrant},
+		Scopes:     []string{auth.ProfileScope},
+	}
+}
+
+func genAuthorizationRequest() *auth.AuthorizationRequest {
+	return &auth.AuthorizationRequest{
+		ResponseType:        "code",
+		ClientID:            "some-app",
+		RedirectURI:         "https://app.test/callback",
+		Scope:               "profile",
+		State:               "some-state",
+		CodeChallenge:       tokens.S256Challenge("some-verifier"),
+		CodeChallengeMethod: "S256",
+	}
+}
+
+func TestAuthorize(t *testing.T) {
+	t.Run("it should succeed", func(t *testing.T) {
+		var createdCode *auth.AuthorizationCode
+		ouc, ouDeps := genOAuthUseCase()
+		ouDeps.clientRepository.On("GetClientByID", mock.Anything, "some-app").Return(genPublicClient(), nil)
+		ouDeps.authorizationCodeRepository.
+			On("CreateAuthorizationCode", mock.Anything, mock.AnythingOfType("*auth.AuthorizationCode")).
+			Run(func(args mock.Arguments) { createdCode = args.Get(1).(*auth.AuthorizationCode) }).
+			Return(nil)
+
+		redirectURL, err := ouc.Authorize(context.Background(), "some-user-id", genAuthorizationRequest())
+
+		if assert.NoError(t, err) {
+			u, err := url.Parse(redirectURL)
+			if assert.NoError(t, err) {
+				assert.Equal(t, "app.test", u.Host)
+				assert.Equal(t, "some-state", u.Query().Get("state"))
+				assert.Equal(t, hashToken(u.Query().Get("code")), createdCode.CodeHash)
+			}
+			assert.Equal(t, "some-app", createdCode.ClientID)
+			assert.Equal(t, "some-user-id", createdCode.UserID)
+			assert.Equal(t, []string{auth.ProfileScope}, createdCode.Scopes)
+			assert.Equal(t, tokens.S256Challenge("some-verifier"), createdCode.CodeChallenge)
+			assert.WithinDuration(t, time.Now().Add(authorizationCodeDuration), createdCode.ExpiresAt, time.Second)
+		}
+	})
+
+	t.Run("it should return error on unknown client", func(t *testing.T) {
+		ouc, ouDeps := genOAuthUseCase()
+		ouDeps.clientRepository.
+			On("GetClientByID", mock.Anything, "some-app").
+			Return(auth.OAuthClient{}, terr.NewNotFoundError("oauth client not found"))
+
+		_, err := ouc.Authorize(context.Background(), "some-user-id", genAuthorizationRequest())
+
+		assert.Equal(t, terr.NewOAuthError("invalid_client", "unknown client"), err)
+	})
+
+	t.Run("it should return error on unregistered redirect uri", func(t *testing.T) {
+		ouc, ouDeps := genOAuthUseCase()
+		ouDeps.clientRepository.On("GetClientByID", mock.Anything, "some-app").Return(genPublicClient(), nil)
+		request := genAuthorizationRequest()
+		request.RedirectURI = "https://evil.test/callback"
+
+		_, err := ouc.Authorize(context.Background(), "some-user-id", request)
+
+		assert.Equal(t, terr.NewOAuthError("invalid_request", "invalid redirect uri"), err)
+	})
+
+	redirectedErrors := []struct {
+		name    string
+		userID  string
+		request func(request *auth.AuthorizationRequest)
+		error   string
+	}{
+		{"unsupported response type", "some-user-id", func(r *auth.AuthorizationRequest) { r.ResponseType = "token" }, "unsupported_response_type"},
+		{"missing code challenge", "some-user-id", func(r *auth.AuthorizationRequest) { r.CodeChallenge = "" }, "invalid_request"},
+		{"plain code challenge", "some-user-id", func(r *auth.AuthorizationRequest) { r.CodeChallengeMethod = "plain" }, "invalid_request"},
+		{"invalid scope", "some-user-id", func(r *auth.AuthorizationRequest) { r.Scope = "profile admin" }, "invalid_scope"},
+		{"missing user", "", func(r *auth.AuthorizationRequest) {}, "login_required"},
+	}
+	for _, redirectedError := range redirectedErrors {
+		t.Run("it should redirect error on "+redirectedError.name, func(t *testing.T) {
+			ouc, ouDeps := genOAuthUseCase()
+			ouDeps.clientRepository.On("GetClientByID", mock.Anything, "some-app").Return(genPublicClient(), nil)
+			request := genAuthorizationRequest()
+			redirectedError.request(request)
+
+			redirectURL, err := ouc.Authorize(context.Background(), redirectedError.userID, request)
+
+			if assert.NoError(t, err) {
+				u, _ := url.Parse(redirectURL)
+				assert.Equal(t, redirectedError.error, u.Query().Get("error"))
+				assert.Equal(t, "some-state", u.Query().Get("state"))
+				assert.Empty(t, u.Query().Get("code"))
+				ouDeps.authorizationCodeRepository.AssertNotCalled(t, "CreateAuthorizationCode", mock.Anything, mock.Anything)
+			}
+		})
+	}
+}
+
+func TestToken(t *testing.T) {
+	genCode := func() auth.AuthorizationCode {
+		return auth.AuthorizationCode{
+			CodeHash:      hashToken("some-code"),
+			ClientID:      "some-app",
+			UserID:        "some-user-id",
+			RedirectURI:   "https://app.test/callback",
+			Scopes:        []string{auth.ProfileScope},
+			CodeChallenge: tokens.S256Challenge("some-verifier"),
+			ExpiresAt:     time.Now().Add(time.Minute),
+		}
+	}
+	codeRequest := &auth.OAuthTokenRequest{
+		GrantType:    auth.AuthorizationCodeGrant,
+		ClientID:     "some-app",
+		Code:         "some-code",
+		RedirectURI:  "https://app.test/callback",
+		CodeVerifier: "some-verifier",
+		UserAgent:    "some-user-agent",
+		IPAddress:    "127.0.0.1",
+	}
+
+	t.Run("it should succeed exchanging an authorization code", func(t *testing.T) {
+		ouc, ouDeps := genOAuthUseCase()
+		ouDeps.clientRepository.On("GetClientByID", mock.Anything, "some-app").Return(genPublicClient(), nil)
+		ouDeps.authorizationCodeRepository.On("ConsumeAuthorizationCode", mock.Anything, hashToken("some-code")).Return(genCode(), nil)
+		ouDeps.userUseCase.On("IsUserActive", mock.Anything, "some-user-id").Return(true)
+		ouDeps.securityTokenUseCase.
+			On("GenScopedAccessToken", mock.Anything, "some-user-id", "some-app", []string{auth.ProfileScope}).
+			Return(auth.SecurityToken{Token: "some-access-token"}, nil)
+		ouDeps.securityTokenUseCase.
+			On("GenScopedRefreshToken", mock.Anything, "some-user-id", "some-app", []string{auth.ProfileScope}, "some-user-agent", "127.0.0.1").
+			Return(auth.SecurityToken{Token: "some-refresh-token"}, nil)
+
+		response, err := ouc.Token(context.Background(), codeRequest)
+
+		if assert.NoError(t, err) {
+			assert.Equal(t, auth.OAuthTokenResponse{
+				AccessToken:  "some-access-token",
+				TokenType:    "Bearer",
+				ExpiresIn:    int64(accessTokenDuration.Seconds()),
+				RefreshToken: "some-refresh-token",
+				Scope:        "profile",
+			}, response)
+		}
+	})
+
+	invalidCodes := []struct {
+		name    string
+		code    func(code *auth.AuthorizationCode)
+		request func(request *auth.OAuthTokenRequest)
+		err     error
+	}{
+		{
+			"code of another client",
+			func(c *auth.AuthorizationCode) { c.ClientID = "other-app" },
+			func(r *auth.OAuthTokenRequest) {},
+			terr.NewOAuthError("invalid_grant", "invalid authorization code"),
+		},
+		{
+			"expired code",
+			func(c *auth.AuthorizationCode) { c.ExpiresAt = time.Now().Add(-time.Second) },
+			func(r *auth.OAuthTokenRequest) {},
+			terr.NewOAuthError("invalid_grant", "authorization code expired"),
+		},
+		{
+			"redirect uri mismatch",
+			func(c *auth.AuthorizationCode) {},
+			func(r *auth.OAuthTokenRequest) { r.RedirectURI = "https://app.test/other" },
+			terr.NewOAuthError("invalid_grant", "invalid redirect uri"),
+		},
+		{
+			"code verifier mismatch",
+			func(c *auth.AuthorizationCode) {},
+			func(r *auth.OAuthTokenRequest) { r.CodeVerifier = "other-verifier" },
+			terr.NewOAuthError("invalid_grant", "invalid code verifier"),
+		},
+	}
+	for _, invalidCode := range invalidCodes {
+		t.Run("it should return error on "+invalidCode.name, func(t *testing.T) {
+			ouc, ouDeps := genOAuthUseCase()
+			code := genCode()
+			invalidCode.code(&code)
+			request := *codeRequest
+			invalidCode.request(&request)
+			ouDeps.clientRepository.On("GetClientByID", mock.Anything, "some-app").Return(genPublicClient(), nil)
+			ouDeps.authorizationCodeRepository.On("ConsumeAuthorizationCode", mock.Anything, hashToken("some-code")).Return(code, nil)
+
+			_, err := ouc.Token(context.Background(), &request)
+
+			assert.Equal(t, invalidCode.err, err)
+			ouDeps.securityTokenUseCase.AssertNotCalled(t, "GenScopedAccessToken", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+		})
+	}
+
+	t.Run("it should return error on consumed code", func(t *testing.T) {
+		ouc, ouDeps := genOAuthUseCase()
+		ouDeps.clientRepository.On("GetClientByID", mock.Anything, "some-app").Return(genPublicClient(), nil)
+		ouDeps.authorizationCodeRepository.
+			On("ConsumeAuthorizationCode", mock.Anything, hashToken("some-code")).
+			Return(auth.AuthorizationCode{}, terr.NewNotFoundError("authorization code not found"))
+
+		_, err := ouc.Token(context.Background(), codeRequest)
+
+		assert.Equal(t, terr.NewOAuthError("invalid_grant", "invalid authorization code"), err)
+	})
+
+	t.Run("it should succeed exchanging a refresh token", func(t *testing.T) {
+		refreshTokenMetadata := auth.TokenMetadata{
+			UserID:   "some-user-id",
+			Type:     auth.RefreshTokenType,
+			ClientID: "some-app",
+			Scopes:   []string{auth.ProfileScope, auth.AccountScope},
+		}
+		ouc, ouDeps := genOAuthUseCase()
+		ouDeps.clientRepository.On("GetClientByID", mock.Anything, "some-app").Return(genPublicClient(), nil)
+		ouDeps.securityService.
+			On("ValidateToken", "some-refresh-token", auth.RefreshTokenType).
+			Return(refreshTokenMetadata, nil)
+		ouDeps.userUseCase.On("IsUserActive", mock.Anything, "some-user-id").Return(true)
+		ouDeps.securityTokenUseCase.
+			On("RotateRefreshToken", mock.Anything, &refreshTokenMetadata).
+			Return(auth.SecurityToken{Token: "new-refresh-token"}, nil)
+		ouDeps.securityTokenUseCase.
+			On("GenScopedAccessToken", mock.Anything, "some-user-id", "some-app", []string{auth.ProfileScope}).
+			Return(auth.SecurityToken{Token: "some-access-token"}, nil)
+
+		response, err := ouc.Token(context.Background(), &auth.OAuthTokenRequest{
+			GrantType:    auth.RefreshTokenGrant,
+			ClientID:     "some-app",
+			RefreshToken: "some-refresh-token",
+			Scope:        "profile",
+		})
+
+		if assert.NoError(t, err) {
+			assert.Equal(t, "some-access-token", response.AccessToken)
+			assert.Equal(t, "new-refresh-token", response.RefreshToken)
+			assert.Equal(t, "profile", response.Scope)
+		}
+	})
+
+	t.Run("it should return error on refresh token of another client", func(t *testing.T) {
+		ouc, ouDeps := genOAuthUseCase()
+		ouDeps.clientRepository.On("GetClientByID", mock.Anything, "some-app").Return(genPublicClient(), nil)
+		ouDeps.securityService.
+			On("ValidateToken", "some-refresh-token", auth.RefreshTokenType).
+			Return(auth.TokenMetadata{UserID: "some-user-id", ClientID: "other-app"}, nil)
+
+		_, err := ouc.Token(context.Background(), &auth.OAuthTokenRequest{
+			GrantType:    auth.RefreshTokenGrant,
+			ClientID:     "some-app",
+			RefreshToken: "some-refresh-token",
+		})
+
+		assert.Equal(t, terr.NewOAuthError("invalid_grant", "invalid refresh token"), err)
+	})
+
+	t.Run("it should return error on widened scope", func(t *testing.T) {
+		ouc, ouDeps := genOAuthUseCase()
+		ouDeps.clientRepository.On("GetClientByID", mock.Anything, "some-app").Return(genPublicClient(), nil)
+		ouDeps.securityService.
+			On("ValidateToken", "some-refresh-token", auth.RefreshTokenType).
+			Return(auth.TokenMetadata{UserID: "some-user-id", ClientID: "some-app", Scopes: []string{auth.ProfileScope}}, nil)
+
+		_, err := ouc.Token(context.Background(), &auth.OAuthTokenRequest{
+			GrantType:    auth.RefreshTokenGrant,
+			ClientID:     "some-app",
+			RefreshToken: "some-refresh-token",
+			Scope:        "profile account",
+		})
+
+		assert.Equal(t, terr.NewOAuthError("invalid_scope", "scope exceeds the granted scope"), err)
+	})
+
+	t.Run("it should succeed with client credentials", func(t *testing.T) {
+		ouc, ouDeps := genOAuthUseCase()
+		ouDeps.clientRepository.On("GetClientByID", mock.Anything, "some-service").Return(genConfidentialClient(), nil)
+		ouDeps.securityService.On("VerifyPassword", "some-secret-hash", "some-secret").Return(nil)
+		ouDeps.securityTokenUseCase.
+			On("GenClientAccessToken", mock.Anything, "some-service", []string{auth.ProfileScope}).
+			Return(auth.SecurityToken{Token: "some-access-token"}, nil)
+
+		response, err := ouc.Token(context.Background(), &auth.OAuthTokenRequest{
+			GrantType:    auth.ClientCredentialsGrant,
+			ClientID:     "some-service",
+			ClientSecret: "some-secret",
+		})
+
+		if assert.NoError(t, err) {
+			assert.Equal(t, "some-access-token", response.AccessToken)
+			assert.Empty(t, response.RefreshToken)
+			assert.Equal(t, "profile", response.Scope)
+		}
+	})
+
+	t.Run("it should return error on invalid client secret", func(t *testing.T) {
+		ouc, ouDeps := genOAuthUseCase()
+		ouDeps.clientRepository.On("GetClientByID", mock.Anything, "some-service").Return(genConfidentialClient(), nil)
+		ouDeps.securityService.On("VerifyPassword", "some-secret-hash", "other-secret").Return(errors.New("mismatch"))
+
+		_, err := ouc.Token(context.Background(), &auth.OAuthTokenRequest{
+			GrantType:    auth.ClientCredentialsGrant,
+			ClientID:     "some-service",
+			ClientSecret: "other-secret",
+		})
+
+		assert.Equal(t, terr.NewOAuthError("invalid_client", "client authentication failed"), err)
+	})
+
+	t.Run("it should return error on grant not allowed", func(t *testing.T) {
+		ouc, ouDeps := genOAuthUseCase()
+		ouDeps.clientRepository.On("GetClientByID", mock.Anything, "some-app").Return(genPublicClient(), nil)
+
+		_, err := ouc.Token(context.Background(), &auth.OAuthTokenRequest{GrantType: auth.ClientCredentialsGrant, ClientID: "some-app"})
+
+		assert.Equal(t, terr.NewOAuthError("unauthorized_client", "grant type not allowed"), err)
+	})
+
+	t.Run("it should return error on unsupported grant", func(t *testing.T) {
+		ouc, ouDeps := genOAuthUseCase()
+		ouDeps.clientRepository.On("GetClientByID", mock.Anything, "some-app").Return(genPublicClient(), nil)
+
+		_, err := ouc.Token(context.Background(), &auth.OAuthTokenRequest{GrantType: "password", ClientID: "some-app"})
+
+		assert.Equal(t, terr.NewOAuthError("unsupported_grant_type", "unsupported grant type"), err)
+	})
+}
+
+func TestRevoke(t *testing.T) {
+	t.Run("it should succeed revoking a refresh token", func(t *testing.T) {
+		refreshTokenMetadata := auth.TokenMetadata{UserID: "some-user-id", ClientID: "some-app"}
+		ouc, ouDeps := genOAuthUseCase()
+		ouDeps.clientRepository.On("GetClientByID", mock.Anything, "some-app").Return(genPublicClient(), nil)
+		ouDeps.securityService.On("ValidateToken", "some-token", auth.RefreshTokenType).Return(refreshTokenMetadata, nil)
+		ouDeps.securityTokenUseCase.On("RemoveRefreshToken", mock.Anything, &refreshTokenMetadata).Return(nil)
+
+		assert.NoError(t, ouc.Revoke(context.Background(), "some-app", "", "some-token"))
+		ouDeps.securityTokenUseCase.AssertCalled(t, "RemoveRefreshToken", mock.Anything, &refreshTokenMetadata)
+	})
+
+	t.Run("it should succeed revoking an access token", func(t *testing.T) {
+		accessTokenMetadata := auth.TokenMetadata{UserID: "some-user-id", ClientID: "some-app"}
+		ouc, ouDeps := genOAuthUseCase()
+		ouDeps.clientRepository.On("GetClientByID", mock.Anything, "some-app").Return(genPublicClient(), nil)
+		ouDeps.securityService.
+			On("ValidateToken", "some-token", auth.RefreshTokenType).
+			Return(auth.TokenMetadata{}, terr.NewTokenTypeError("invalid token type"))
+		ouDeps.securityService.On("ValidateToken", "some-token", auth.AccessTokenType).Return(accessTokenMetadata, nil)
+		ouDeps.securityTokenUseCase.On("RevokeAccessToken", mock.Anything, &accessTokenMetadata).Return(nil)
+
+		assert.NoError(t, ouc.Revoke(context.Background(), "some-app", "", "some-token"))
+		ouDeps.securityTokenUseCase.AssertCalled(t, "RevokeAccessToken", mock.Anything, &accessTokenMetadata)
+	})
+
+	t.Run("it should ignore a token of another client", func(t *testing.T) {
+		ouc, ouDeps := genOAuthUseCase()
+		ouDeps.clientRepository.On("GetClientByID", mock.Anything, "some-app").Return(genPublicClient(), nil)
+		ouDeps.securityService.
+			On("ValidateToken", "some-token", auth.RefreshTokenType).
+			Return(auth.TokenMetadata{UserID: "some-user-id"}, nil)
+
+		assert.NoError(t, ouc.Revoke(context.Background(), "some-app", "", "some-token"))
+		ouDeps.securityTokenUseCase.AssertNotCalled(t, "RemoveRefreshToken", mock.Anything, mock.Anything)
+	})
+
+	t.Run("it should ignore an invalid token", func(t *testing.T) {
+		ouc, ouDeps := genOAuthUseCase()
+		ouDeps.clientRepository.On("GetClientByID", mock.Anything, "some-app").Return(genPublicClient(), nil)
+		ouDeps.securityService.
+			On("ValidateToken", "some-token", mock.Anything).
+			Return(auth.TokenMetadata{}, terr.NewMalformedTokenError("malformed token"))
+
+		assert.NoError(t, ouc.Revoke(context.Background(), "some-app", "", "some-token"))
+	})
+
+	t.Run("it should return error on unknown client", func(t *testing.T) {
+		ouc, ouDeps := genOAuthUseCase()
+		ouDeps.clientRepository.
+			On("GetClientByID", mock.Anything, "some-app").
+			Return(auth.OAuthClient{}, terr.NewNotFoundError("oauth client not found"))
+
+		err := ouc.Revoke(context.Background(), "some-app", "", "some-token")
+
+		assert.Equal(t, terr.NewOAuthError("invalid_client", "client authentication failed"), err)
+	})
+}
+
+func TestIntrospect(t *testing.T) {
+	t.Run("it should describe an active token", func(t *testing.T) {
+		accessTokenMetadata := auth.TokenMetadata{
+			ID:        "some-token-id",
+			UserID:    "some-user-id",
+			Type:      auth.AccessTokenType,
+			ClientID:  "some-app",
+			Scopes:    []string{auth.ProfileScope, auth.AccountScope},
+			Issuer:    "sherman",
+			ExpiresAt: 1600000000,
+		}
+		ouc, ouDeps := genOAuthUseCase()
+		ouDeps.clientRepository.On("GetClientByID", mock.Anything, "some-service").Return(genConfidentialClient(), nil)
+		ouDeps.securityService.On("VerifyPassword", "some-secret-hash", "some-secret").Return(nil)
+		ouDeps.securityService.On("ValidateToken", "some-token", auth.AccessTokenType).Return(accessTokenMetadata, nil)
+		ouDeps.securityTokenUseCase.On("IsAccessTokenRevoked", mock.Anything, &accessTokenMetadata).Return(false)
+		ouDeps.userUseCase.On("IsUserActive", mock.Anything, "some-user-id").Return(true)
+
+		introspection, err := ouc.Introspect(context.Background(), "some-service", "some-secret", "some-token")
+
+		if assert.NoError(t, err) {
+			assert.Equal(t, auth.TokenIntrospection{
+				Active:    true,
+				Scope:     "profile account",
+				ClientID:  "some-app",
+				TokenType: auth.AccessTokenType,
+				Subject:   "some-user-id",
+				Issuer:    "sherman",
+				JwtID:     "some-token-id",
+				ExpiresAt: 1600000000,
+			}, introspection)
+		}
+	})
+
+	t.Run("it should describe a revoked token as inactive", func(t *testing.T) {
+		accessTokenMetadata := auth.TokenMetadata{UserID: "some-user-id", Type: auth.AccessTokenType}
+		ouc, ouDeps := genOAuthUseCase()
+		ouDeps.clientRepository.On("GetClientByID", mock.Anything, "some-service").Return(genConfidentialClient(), nil)
+		ouDeps.securityService.On("VerifyPassword", "some-secret-hash", "some-secret").Return(nil)
+		ouDeps.securityService.On("ValidateToken", "some-token", auth.AccessTokenType).Return(accessTokenMetadata, nil)
+		ouDeps.securityTokenUseCase.On("IsAccessTokenRevoked", mock.Anything, &accessTokenMetadata).Return(true)
+
+		introspection, err := ouc.Introspect(context.Background(), "some-service", "some-secret", "some-token")
+
+		if assert.NoError(t, err) {
+			assert.Equal(t, auth.TokenIntrospection{Active: false}, introspection)
+		}
+	})
+
+	t.Run("it should describe an invalid token as inactive", func(t *testing.T) {
+		ouc, ouDeps := genOAuthUseCase()
+		ouDeps.clientRepository.On("GetClientByID", mock.Anything, "some-service").Return(genConfidentialClient(), nil)
+		ouDeps.securityService.On("VerifyPassword", "some-secret-hash", "some-secret").Return(nil)
+		ouDeps.securityService.
+			On("ValidateToken", "some-token", mock.Anything).
+			Return(auth.TokenMetadata{}, terr.NewExpiredTokenError("token is expired"))
+
+		introspection, err := ouc.Introspect(context.Background(), "some-service", "some-secret", "some-token")
+
+		if assert.NoError(t, err) {
+			assert.False(t, introspection.Active)
+		}
+	})
+
+	t.Run("it should return error on public client", func(t *testing.T) {
+		ouc, ouDeps := genOAuthUseCase()
+		ouDeps.clientRepository.On("GetClientByID", mock.Anything, "some-app").Return(genPublicClient(), nil)
+
+		_, err := ouc.Introspect(context.Background(), "some-app", "", "some-token")
+
+		assert.Equal(t, terr.NewOAuthError("unauthorized_client", "public clients can't introspect tokens"), err)
+	})
+}
+
+func TestCreateClient(t *testing.T) {
+	mockClient := auth.OAuthClient{
+		Name:         "some client",
+		RedirectURIs: []string{"https://app.test/callback"},
+		GrantTypes:   []string{auth.AuthorizationCodeGrant},
+		Scopes:       []string{auth.ProfileScope},
+	}
+
+	t.Run("it should create a confidential client with a hashed secret", func(t *testing.T) {
+		ouc, ouDeps := genOAuthUseCase()
+		ouDeps.securityService.On("Hash", mock.AnythingOfType("string")).Return([]byte("some-secret-hash"), nil)
+		ouDeps.clientRepository.On("CreateClient", mock.Anything, mock.Anything).Return(nil)
+
+		client, err := ouc.CreateClient(context.Background(), &mockClient, false)
+
+		if assert.NoError(t, err) {
+			assert.NotEmpty(t, client.ID)
+			assert.NotEmpty(t, client.Secret)
+			assert.Equal(t, "some-secret-hash", client.SecretHash)
+			assert.Equal(t, mockClient.RedirectURIs, client.RedirectURIs)
+			ouDeps.securityService.AssertCalled(t, "Hash", client.Secret)
+			ouDeps.clientRepository.AssertCalled(t, "CreateClient", mock.Anything, &client.OAuthClient)
+		}
+	})
+
+	t.Run("it should create a public client without secret", func(t *testing.T) {
+		ouc, ouDeps := genOAuthUseCase()
+		ouDeps.clientRepository.On("CreateClient", mock.Anything, mock.Anything).Return(nil)
+
+		client, err := ouc.CreateClient(context.Background(), &mockClient, true)
+
+		if assert.NoError(t, err) {
+			assert.Empty(t, client.Secret)
+			assert.Empty(t, client.SecretHash)
+			ouDeps.securityService.AssertNotCalled(t, "Hash", mock.Anything)
+		}
+	})
+
+	t.Run("it should return error", func(t *testing.T) {
+		ouc, ouDeps := genOAuthUseCase()
+		mockError := errors.New("some error")
+		ouDeps.clientRepository.On("CreateClient", mock.Anything, mock.Anything).Return(mockError)
+
+		_, err := ouc.CreateClient(context.Background(), &mockClient, true)
+
+		assert.Equal(t, mockError, err)
+	})
+}
+
+func TestRotateClientSecret(t *testing.T) {
+	t.Run("it should replace the secret", func(t *testing.T) {
+		ouc, ouDeps := genOAuthUseCase()
+		ouDeps.clientRepository.On("GetClientByID", mock.Anything, "some-service").Return(genConfidentialClient(), nil)
+		ouDeps.securityService.On("Hash", mock.AnythingOfType("string")).Return([]byte("other-secret-hash"), nil)
+		ouDeps.clientRepository.On("UpdateClientSecret", mock.Anything, "some-service", "other-secret-hash", mock.Anything).Return(nil)
+
+		client, err := ouc.RotateClientSecret(context.Background(), "some-service")
+
+		if assert.NoError(t, err) {
+			assert.NotEmpty(t, client.Secret)
+			assert.Equal(t, "other-secret-hash", client.SecretHash)
+			ouDeps.securityService.AssertCalled(t, "Hash", client.Secret)
+		}
+	})
+
+	t.Run("it should not give a public client a secret", func(t *testing.T) {
+		ouc, ouDeps := genOAuthUseCase()
+		ouDeps.clientRepository.On("GetClientByID", mock.Anything, "some-app").Return(genPublicClient(), nil)
+
+		_, err := ouc.RotateClientSecret(context.Background(), "some-app")
+
+		assert.Equal(t, terr.NewOAuthError("invalid_request", "public clients have no secret"), err)
+		ouDeps.clientRepository.AssertNotCalled(t, "UpdateClientSecret", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("it should return error", func(t *testing.T) {
+		ouc, ouDeps := genOAuthUseCase()
+		mockError := terr.NewNotFoundError("oauth client not found")
+		ouDeps.clientRepository.On("GetClientByID", mock.Anything, "some-service").Return(auth.OAuthClient{}, mockError)
+
+		_, err := ouc.RotateClientSecret(context.Background(), "some-service")
+
+		assert.Equal(t, mockError, err)
+	})
+}
@@ -0,0 +1,63 @@
+package usecase
+
+import (
+	"context"
+	"sherman/src/domain/auth"
+	"time"
+)
+
+// RoleUseCase implementation of auth.RoleUseCase
+type roleUseCase struct {
+	roleRepo auth.RoleRepository
+}
+
+// NewRoleUseCase constructor
+func NewRoleUseCase(rr auth.RoleRepository) auth.RoleUseCase {
+	return &roleUseCase{
+		roleRepo: rr,
+	}
+}
+
+// GetRoleNamesByUserID gets the names of the roles assigned to a user
+func (uc *roleUseCase) GetRoleNamesByUserID(ctx context.Context, userID string) ([]string, error) {
+	return uc.roleRepo.GetRoleNamesByUserID(ctx, userID)
+}
+
+// HasPermission checks if any of the roles grants a permission
+func (uc *roleUseCase) HasPermission(ctx context.Context, roleNames []string, permission string) (bool, error) {
+	permissions, err := uc.roleRepo.GetPermissionsByRoleNames(ctx, roleNames)
+	if err != nil {
+		return false, err
+	}
+
+	for _, p := range permissions {
+		if p == permission {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// AssignRole assigns a role to a user
+func (uc *roleUseCase) AssignRole(ctx context.Context, userID, roleName string) error {
+	role, err := uc.roleRepo.GetRoleByName(ctx, roleName)
+	if err != nil {
+		return err
+	}
+
+	return uc.roleRepo.CreateUserRole(ctx, &auth.UserRole{
+		UserID:    userID,
+		RoleID:    role.ID,
+		CreatedAt: time.Now(),
+	})
+}
+
+// RevokeRole revokes a role from a user
+func (uc *roleUseCase) RevokeRole(ctx context.Context, userID, roleName string) error {
+	role, err := uc.roleRepo.GetRoleByName(ctx, roleName)
+	if err != nil {
+		return err
+	}
+
+	return uc.roleRepo.RemoveUserRole(ctx, userID, role.ID)
+}
@@ -0,0 +1,145 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"sherman/mocks"
+	_ "sherman/src/app/testing"
+	"sherman/src/app/utils/terr"
+	"sherman/src/domain/auth"
+	"testing"
+)
+
+type roleUseCaseMockDeps struct {
+	roleRepository *mocks.RoleRepository
+}
+
+func genRoleUseCase() (auth.RoleUseCase, roleUseCaseMockDeps) {
+	rucDeps := roleUseCaseMockDeps{
+		roleRepository: new(mocks.RoleRepository),
+	}
+
+	ruc := NewRoleUseCase(rucDeps.roleRepository)
+
+	return ruc, rucDeps
+}
+
+func TestGetRoleNamesByUserID(t *testing.T) {
+	ruc, rucDeps := genRoleUseCase()
+	rucDeps.roleRepository.
+		On("GetRoleNamesByUserID", mock.Anything, "some-user-id").
+		Return([]string{auth.AdminRole}, nil)
+
+	roleNames, err := ruc.GetRoleNamesByUserID(context.Background(), "some-user-id")
+
+	if assert.NoError(t, err) {
+		assert.Equal(t, []string{auth.AdminRole}, roleNames)
+	}
+}
+
+func TestHasPermission(t *testing.T) {
+	t.Run("it should have the permission", func(t *testing.T) {
+		ruc, rucDeps := genRoleUseCase()
+		rucDeps.roleRepository.
+			On("GetPermissionsByRoleNames", mock.Anything, []string{auth.AdminRole}).
+			Return([]string{auth.ManageRolesPermission, auth.ReadUsersPermission}, nil)
+
+		ok, err := ruc.HasPermission(context.Background(), []string{auth.AdminRole}, auth.ReadUsersPermission)
+
+		if assert.NoError(t, err) {
+			assert.True(t, ok)
+		}
+	})
+
+	t.Run("it should not have the permission", func(t *testing.T) {
+		ruc, rucDeps := genRoleUseCase()
+		rucDeps.roleRepository.
+			On("GetPermissionsByRoleNames", mock.Anything, []string{"some-role"}).
+			Return([]string{}, nil)
+
+		ok, err := ruc.HasPermission(context.Background(), []string{"some-role"}, auth.ReadUsersPermission)
+
+		if assert.NoError(t, err) {
+			assert.False(t, ok)
+		}
+	})
+
+	t.Run("it should return error", func(t *testing.T) {
+		ruc, rucDeps := genRoleUseCase()
+		rucDeps.roleRepository.
+			On("GetPermissionsByRoleNames", mock.Anything, mock.Anything).
+			Return(nil, errors.New("some error"))
+
+		ok, err := ruc.HasPermission(context.Background(), []string{auth.AdminRole}, auth.ReadUsersPermission)
+
+		assert.Error(t, err)
+		assert.False(t, ok)
+	})
+}
+
+func TestAssignRole(t *testing.T) {
+	mockRole := auth.Role{ID: "some-role-id", Name: auth.AdminRole}
+
+	t.Run("it should succeed", func(t *testing.T) {
+		ruc, rucDeps := genRoleUseCase()
+		rucDeps.roleRepository.
+			On("GetRoleByName", mock.Anything, auth.AdminRole).
+			Return(mockRole, nil)
+		rucDeps.roleRepository.
+			On("CreateUserRole", mock.Anything, mock.MatchedBy(func(ur *auth.UserRole) bool {
+				return ur.UserID == "some-user-id" && ur.RoleID == mockRole.ID && !ur.CreatedAt.IsZero()
+			})).
+			Return(nil)
+
+		err := ruc.AssignRole(context.Background(), "some-user-id", auth.AdminRole)
+
+		assert.NoError(t, err)
+	})
+
+	t.Run("it should return error", func(t *testing.T) {
+		ruc, rucDeps := genRoleUseCase()
+		rucDeps.roleRepository.
+			On("GetRoleByName", mock.Anything, "some-role").
+			Return(auth.Role{}, terr.NewNotFoundError("role not found"))
+
+		err := ruc.AssignRole(context.Background(), "some-user-id", "some-role")
+
+		if assert.Error(t, err) {
+			assert.Equal(t, terr.NewNotFoundError("role not found"), err)
+			rucDeps.roleRepository.AssertNotCalled(t, "CreateUserRole", mock.Anything, mock.Anything)
+		}
+	})
+}
+
+func TestRevokeRole(t *testing.T) {
+	mockRole := auth.Role{ID: "some-role-id", Name: auth.AdminRole}
+
+	t.Run("it should succeed", func(t *testing.T) {
+		ruc, rucDeps := genRoleUseCase()
+		rucDeps.roleRepository.
+			On("GetRoleByName", mock.Anything, auth.AdminRole).
+			Return(mockRole, nil)
+		rucDeps.roleRepository.
+			On("RemoveUserRole", mock.Anything, "some-user-id", mockRole.ID).
+			Return(nil)
+
+		err := ruc.RevokeRole(context.Background(), "some-user-id", auth.AdminRole)
+
+		assert.NoError(t, err)
+	})
+
+	t.Run("it should return error", func(t *testing.T) {
+		ruc, rucDeps := genRoleUseCase()
+		rucDeps.roleRepository.
+			On("GetRoleByName", mock.Anything, "some-role").
+			Return(auth.Role{}, terr.NewNotFoundError("role not found"))
+
+		err := ruc.RevokeRole(context.Background(), "some-user-id", "some-role")
+
+		if assert.Error(t, err) {
+			assert.Equal(t, terr.NewNotFoundError("role not found"), err)
+		}
+	})
+}
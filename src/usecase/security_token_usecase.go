@@ -1,87 +1,452 @@
 package usecase
 
 import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
 	"github.com/google/uuid"
+	"sherman/src/app/utils/terr"
 	"sherman/src/domain/auth"
+	"sherman/src/service/cache"
 	"sherman/src/service/security"
 	"time"
 )
 
+const (
+	// maxUserAgentLength max length of a session user agent
+	maxUserAgentLength = 255
+	// accessTokenDuration validity of access tokens
+	accessTokenDuration = time.Minute * time.Duration(15)
+	// refreshTokenDuration validity of refresh tokens, a rotation issues a token of the same validity
+	refreshTokenDuration = time.Hour * time.Duration(48)
+)
+
 // SecurityTokenUseCase implementation of auth.SecurityTokenUseCase
 type securityTokenUseCase struct {
 	securityTokenRepo auth.SecurityTokenRepository
+	revokedTokenRepo  auth.RevokedTokenRepository
+	roleRepo          auth.RoleRepository
 	security          security.Security
+	cache             cache.Cache
 }
 
 // NewSecurityTokenUseCase constructor
-func NewSecurityTokenUseCase(str auth.SecurityTokenRepository, ss security.Security) auth.SecurityTokenUseCase {
+func NewSecurityTokenUseCase(
+	str auth.SecurityTokenRepository,
+	rtr auth.RevokedTokenRepository,
+	rr auth.RoleRepository,
+	ss security.Security,
+	cs cache.Cache,
+) auth.SecurityTokenUseCase {
 	return &securityTokenUseCase{
 		securityTokenRepo: str,
+		revokedTokenRepo:  rtr,
+		roleRepo:          rr,
 		security:          ss,
+		cache:             cs,
 	}
 }
 
-// GenRefreshToken generates a new refresh token and stores it
-func (uc *securityTokenUseCase) GenRefreshToken(userID string) (auth.SecurityToken, error) {
-	duration := time.Hour * time.Duration(48)
+// GenRefreshToken generates a new refresh token and stores it as a new user session
+func (uc *securityTokenUseCase) GenRefreshToken(ctx context.Context, userID, userAgent, ipAddress string) (auth.SecurityToken, error) {
 	token, err := uc.security.GenToken(
 		userID,
 		auth.RefreshTokenType,
+		nil,
 		time.Now().Unix(),
-		time.Now().Add(duration).Unix(),
+		time.Now().Add(refreshTokenDuration).Unix(),
 	)
 	if err != nil {
 		return auth.SecurityToken{}, errors.New("could not generate refresh token")
 	}
 
+	return uc.storeRefreshToken(ctx, userID, token, userAgent, ipAddress)
+}
+
+// GenScopedRefreshToken generates a new refresh token issued to an OAuth client for scopes and stores it
+// as a new user session, rotations of the token keep its client and scopes
+func (uc *securityTokenUseCase) GenScopedRefreshToken(ctx context.Context,
+	userID, clientID string,
+	scopes []string,
+	userAgent, ipAddress string,
+) (auth.SecurityToken, error) {
+	token, err := uc.security.GenScopedToken(
+		userID,
+		auth.RefreshTokenType,
+		clientID,
+		scopes,
+		nil,
+		time.Now().Unix(),
+		time.Now().Add(refreshTokenDuration).Unix(),
+	)
+	if err != nil {
+		return auth.SecurityToken{}, errors.New("could not generate refresh token")
+	}
+
+	return uc.storeRefreshToken(ctx, userID, token, userAgent, ipAddress)
+}
+
+// storeRefreshToken stores a refresh token as a new user session
+func (uc *securityTokenUseCase) storeRefreshToken(ctx context.Context, userID, token, userAgent, ipAddress string) (auth.SecurityToken, error) {
+	if len(userAgent) > maxUserAgentLength {
+		userAgent = userAgent[:maxUserAgentLength]
+	}
+
+	tokenID := uuid.New().String()
 	refreshToken := auth.SecurityToken{
-		ID:        uuid.New().String(),
-		UserID:    userID,
-		Token:     token,
-		Type:      auth.RefreshTokenType,
-		CreatedAt: time.Now(),
-		UpdatedAt: time.Now(),
+		ID:         tokenID,
+		UserID:     userID,
+		Token:      token,
+		Type:       auth.RefreshTokenType,
+		FamilyID:   tokenID,
+		UserAgent:  userAgent,
+		IPAddress:  ipAddress,
+		LastUsedAt: time.Now(),
+		CreatedAt:  time.Now(),
+		UpdatedAt:  time.Now(),
 	}
-	if err = uc.securityTokenRepo.CreateOrUpdateToken(&refreshToken); err != nil {
-		return auth.SecurityToken{}, errors.New("could not create or update refresh token")
+	// only the token hash is persisted
+	storedToken := refreshToken
+	storedToken.Token = hashToken(token)
+	if err := uc.securityTokenRepo.CreateToken(ctx, &storedToken); err != nil {
+		return auth.SecurityToken{}, errors.New("could not create refresh token")
 	}
 
 	return refreshToken, nil
 }
 
-// GenAccessToken generates a new access token
-func (uc *securityTokenUseCase) GenAccessToken(userID string) (auth.SecurityToken, error) {
-	duration := time.Minute * time.Duration(15)
+// GenAccessToken generates a new access token carrying the roles of the user
+func (uc *securityTokenUseCase) GenAccessToken(ctx context.Context, userID string) (auth.SecurityToken, error) {
+	roles, err := uc.roleRepo.GetRoleNamesByUserID(ctx, userID)
+	if err != nil {
+		return auth.SecurityToken{}, errors.New("could not get user roles")
+	}
+
 	token, err := uc.security.GenToken(
 		userID,
 		auth.AccessTokenType,
+		roles,
 		time.Now().Unix(),
-		time.Now().Add(duration).Unix(),
+		time.Now().Add(accessTokenDuration).Unix(),
+	)
+	if err != nil {
+		return auth.SecurityToken{}, errors.New("could not generate access token")
+	}
+
+	return newAccessToken(userID, token, auth.AccessTokenType), nil
+}
+
+// GenScopedAccessToken generates a new access token issued to an OAuth client for scopes, carrying the roles of the user
+func (uc *securityTokenUseCase) GenScopedAccessToken(ctx context.Context, userID, clientID string, scopes []string) (auth.SecurityToken, error) {
+	roles, err := uc.roleRepo.GetRoleNamesByUserID(ctx, userID)
+	if err != nil {
+		return auth.SecurityToken{}, errors.New("could not get user roles")
+	}
+
+	token, err := uc.security.GenScopedToken(
+		userID,
+		auth.AccessTokenType,
+		clientID,
+		scopes,
+		roles,
+		time.Now().Unix(),
+		time.Now().Add(accessTokenDuration).Unix(),
 	)
 	if err != nil {
 		return auth.SecurityToken{}, errors.New("could not generate access token")
 	}
 
-	accessToken := auth.SecurityToken{
+	return newAccessToken(userID, token, auth.AccessTokenType), nil
+}
+
+// GenClientAccessToken generates a new access token of an OAuth client acting on its own behalf, the
+// client is the subject of the token
+func (uc *securityTokenUseCase) GenClientAccessToken(ctx context.Context, clientID string, scopes []string) (auth.SecurityToken, error) {
+	token, err := uc.security.GenScopedToken(
+		clientID,
+		auth.ClientAccessTokenType,
+		clientID,
+		scopes,
+		nil,
+		time.Now().Unix(),
+		time.Now().Add(accessTokenDuration).Unix(),
+	)
+	if err != nil {
+		return auth.SecurityToken{}, errors.New("could not generate access token")
+	}
+
+	return newAccessToken(clientID, token, auth.ClientAccessTokenType), nil
+}
+
+// newAccessToken returns the auth.SecurityToken of an access token, access tokens are never persisted
+func newAccessToken(subject, token, tokenType string) auth.SecurityToken {
+	return auth.SecurityToken{
 		ID:        uuid.New().String(),
-		UserID:    userID,
+		UserID:    subject,
 		Token:     token,
-		Type:      auth.AccessTokenType,
+		Type:      tokenType,
 		CreatedAt: time.Now(),
 		UpdatedAt: time.Now(),
 	}
+}
+
+// IsRefreshTokenStored checks if a refresh token is persisted in the datastore and has not been rotated
+func (uc *securityTokenUseCase) IsRefreshTokenStored(ctx context.Context, refreshTokenMetadata *auth.TokenMetadata) bool {
+	storedToken, err := uc.securityTokenRepo.GetTokenByMetadata(ctx, hashTokenMetadata(refreshTokenMetadata))
+	return err == nil && !storedToken.Rotated
+}
+
+// RotateRefreshToken exchanges a refresh token for a new one of the same family, client and scopes,
+// presenting an already rotated refresh token, or one rotated concurrently, revokes the whole family
+func (uc *securityTokenUseCase) RotateRefreshToken(ctx context.Context, refreshTokenMetadata *auth.TokenMetadata) (auth.SecurityToken, error) {
+	storedToken, err := uc.securityTokenRepo.GetTokenByMetadata(ctx, hashTokenMetadata(refreshTokenMetadata))
+	if err != nil {
+		return auth.SecurityToken{}, terr.NewUnAuthorizedError("invalid refresh token")
+	}
+
+	if storedToken.Rotated {
+		return auth.SecurityToken{}, uc.revokeReusedFamily(ctx, &storedToken)
+	}
+
+	token, err := uc.security.GenScopedToken(
+		storedToken.UserID,
+		auth.RefreshTokenType,
+		refreshTokenMetadata.ClientID,
+		refreshTokenMetadata.Scopes,
+		nil,
+		time.Now().Unix(),
+		time.Now().Add(refreshTokenDuration).Unix(),
+	)
+	if err != nil {
+		return auth.SecurityToken{}, errors.New("could not generate refresh token")
+	}
+
+	rotatedToken := auth.SecurityToken{
+		ID:         uuid.New().String(),
+		UserID:     storedToken.UserID,
+		Token:      storedToken.Token,
+		Type:       storedToken.Type,
+		FamilyID:   storedToken.FamilyID,
+		Rotated:    true,
+		UserAgent:  storedToken.UserAgent,
+		IPAddress:  storedToken.IPAddress,
+		LastUsedAt: storedToken.LastUsedAt,
+		CreatedAt:  time.Now(),
+		UpdatedAt:  time.Now(),
+	}
+	storedToken.Token = hashToken(token)
+	storedToken.LastUsedAt = time.Now()
+	storedToken.UpdatedAt = time.Now()
+	if err := uc.securityTokenRepo.RotateToken(ctx, &storedToken, &rotatedToken); err != nil {
+		if _, ok := err.(*terr.UnAuthorizedError); ok {
+			return auth.SecurityToken{}, uc.revokeReusedFamily(ctx, &storedToken)
+		}
+		return auth.SecurityToken{}, errors.New("could not rotate refresh token")
+	}
+
+	refreshToken := storedToken
+	refreshToken.Token = token
+	return refreshToken, nil
+}
+
+// revokeReusedFamily removes the family of a reused refresh token, so that neither the legitimate client
+// nor the one that replayed it keeps the session
+func (uc *securityTokenUseCase) revokeReusedFamily(ctx context.Context, storedToken *auth.SecurityToken) error {
+	err := uc.securityTokenRepo.RemoveTokenFamily(ctx, storedToken.UserID, storedToken.FamilyID)
+	if _, ok := err.(*terr.NotFoundError); err != nil && !ok {
+		return err
+	}
+	return terr.NewUnAuthorizedError("refresh token reuse detected")
+}
+
+// RemoveRefreshToken removes the session of a refresh token from the datastore
+func (uc *securityTokenUseCase) RemoveRefreshToken(ctx context.Context, refreshTokenMetadata *auth.TokenMetadata) error {
+	storedToken, err := uc.securityTokenRepo.GetTokenByMetadata(ctx, hashTokenMetadata(refreshTokenMetadata))
+	if err != nil {
+		return err
+	}
+
+	return uc.securityTokenRepo.RemoveTokenFamily(ctx, storedToken.UserID, storedToken.FamilyID)
+}
+
+// RevokeAccessToken revokes an access token until it expires
+func (uc *securityTokenUseCase) RevokeAccessToken(ctx context.Context, accessTokenMetadata *auth.TokenMetadata) error {
+	ttl := time.Until(time.Unix(accessTokenMetadata.ExpiresAt, 0))
+	if accessTokenMetadata.ID == "" || ttl <= 0 {
+		// tokens without id can't be revoked, expired tokens don't need to
+		return nil
+	}
+
+	revokedToken := auth.RevokedToken{
+		ID:        accessTokenMetadata.ID,
+		UserID:    accessTokenMetadata.UserID,
+		ExpiresAt: time.Unix(accessTokenMetadata.ExpiresAt, 0),
+		CreatedAt: time.Now(),
+	}
+	if err := uc.revokedTokenRepo.CreateRevokedToken(ctx, &revokedToken); err != nil {
+		return errors.New("could not revoke access token")
+	}
+	uc.cache.Set(revokedTokenCacheKey(revokedToken.ID), true, ttl)
+
+	// best effort clean up, expired tokens are rejected regardless
+	_ = uc.revokedTokenRepo.RemoveExpiredRevokedTokens(ctx, time.Now())
+
+	return nil
+}
+
+// IsAccessTokenRevoked checks if an access token has been revoked, tokens without id
+// or that can't be checked are considered revoked
+func (uc *securityTokenUseCase) IsAccessTokenRevoked(ctx context.Context, accessTokenMetadata *auth.TokenMetadata) bool {
+	if accessTokenMetadata.ID == "" {
+		return true
+	}
+
+	cacheKey := revokedTokenCacheKey(accessTokenMetadata.ID)
+	if _, ok := uc.cache.Get(cacheKey); ok {
+		return true
+	}
+
+	revoked, err := uc.revokedTokenRepo.IsTokenRevoked(ctx, accessTokenMetadata.ID)
+	if err != nil {
+		return true
+	}
+	if revoked {
+		uc.cache.Set(cacheKey, true, time.Until(time.Unix(accessTokenMetadata.ExpiresAt, 0)))
+	}
+
+	return revoked
+}
+
+// GetSessions gets the active sessions of a user
+func (uc *securityTokenUseCase) GetSessions(ctx context.Context, userID string) ([]auth.SecurityToken, error) {
+	return uc.securityTokenRepo.GetTokensByUserID(ctx, userID, auth.RefreshTokenType)
+}
+
+// RemoveSession removes a session of a user
+func (uc *securityTokenUseCase) RemoveSession(ctx context.Context, userID, sessionID string) error {
+	return uc.securityTokenRepo.RemoveTokenFamily(ctx, userID, sessionID)
+}
+
+// RemoveSessions removes every session of a user
+func (uc *securityTokenUseCase) RemoveSessions(ctx context.Context, userID string) error {
+	return uc.securityTokenRepo.RemoveTokenByMetadata(ctx, &auth.TokenMetadata{
+		UserID: userID,
+		Type:   auth.RefreshTokenType,
+	})
+}
+
+// RemoveOtherSessions removes every session of a user but the one of refreshTokenMetadata,
+// every session is removed when refreshTokenMetadata is nil or doesn't match a session of the user
+func (uc *securityTokenUseCase) RemoveOtherSessions(ctx context.Context, userID string, refreshTokenMetadata *auth.TokenMetadata) error {
+	currentSessionID := ""
+	if refreshTokenMetadata != nil {
+		storedToken, err := uc.securityTokenRepo.GetTokenByMetadata(ctx, hashTokenMetadata(refreshTokenMetadata))
+		if err == nil && storedToken.UserID == userID && !storedToken.Rotated {
+			currentSessionID = storedToken.FamilyID
+		}
+	}
+
+	sessions, err := uc.securityTokenRepo.GetTokensByUserID(ctx, userID, auth.RefreshTokenType)
+	if err != nil {
+		return err
+	}
+
+	for i := range sessions {
+		if sessions[i].FamilyID == currentSessionID {
+			continue
+		}
+		err := uc.securityTokenRepo.RemoveTokenFamily(ctx, userID, sessions[i].FamilyID)
+		if _, ok := err.(*terr.NotFoundError); err != nil && !ok {
+			return err
+		}
+	}
+	return nil
+}
+
+// GenOneTimeToken generates a single use token of tokenType valid for duration, it replaces
+// the previous token of the same type of the user
+func (uc *securityTokenUseCase) GenOneTimeToken(ctx context.Context,
+	userID, tokenType string,
+	duration time.Duration,
+) (auth.SecurityToken, error) {
+	token, err := uc.security.GenToken(
+		userID,
+		tokenType,
+		nil,
+		time.Now().Unix(),
+		time.Now().Add(duration).Unix(),
+	)
+	if err != nil {
+		return auth.SecurityToken{}, errors.New("could not generate token")
+	}
+
+	tokenID := uuid.New().String()
+	oneTimeToken := auth.SecurityToken{
+		ID:         tokenID,
+		UserID:     userID,
+		Token:      token,
+		Type:       tokenType,
+		FamilyID:   tokenID,
+		LastUsedAt: time.Now(),
+		CreatedAt:  time.Now(),
+		UpdatedAt:  time.Now(),
+	}
+	// only the token hash is persisted
+	storedToken := oneTimeToken
+	storedToken.Token = hashToken(token)
+	if err = uc.securityTokenRepo.CreateOrUpdateToken(ctx, &storedToken); err != nil {
+		return auth.SecurityToken{}, errors.New("could not create token")
+	}
+
+	return oneTimeToken, nil
+}
+
+// ConsumeOneTimeToken validates a single use token of tokenType and removes it, a token can only be consumed once
+func (uc *securityTokenUseCase) ConsumeOneTimeToken(ctx context.Context, token, tokenType string) (auth.TokenMetadata, error) {
+	tokenMetadata, err := uc.security.ValidateToken(token, tokenType)
+	if err != nil {
+		return auth.TokenMetadata{}, err
+	}
+
+	storedToken, err := uc.securityTokenRepo.GetTokenByMetadata(ctx, hashTokenMetadata(&tokenMetadata))
+	if err != nil {
+		return auth.TokenMetadata{}, terr.NewUnAuthorizedError("invalid token")
+	}
+
+	// the removal is the claim of the token, concurrent consumers of the same token find nothing to remove
+	if err := uc.securityTokenRepo.RemoveTokenFamily(ctx, storedToken.UserID, storedToken.FamilyID); err != nil {
+		if _, ok := err.(*terr.NotFoundError); ok {
+			return auth.TokenMetadata{}, terr.NewUnAuthorizedError("invalid token")
+		}
+		return auth.TokenMetadata{}, err
+	}
+
+	return tokenMetadata, nil
+}
+
+// RemoveOneTimeTokens removes the pending one time tokens of a user and type, they can no longer be consumed
+func (uc *securityTokenUseCase) RemoveOneTimeTokens(ctx context.Context, userID, tokenType string) error {
+	return uc.securityTokenRepo.RemoveTokenByMetadata(ctx, &auth.TokenMetadata{
+		UserID: userID,
+		Type:   tokenType,
+	})
+}
 
-	return accessToken, nil
+// revokedTokenCacheKey returns the cache key of a revoked token id
+func revokedTokenCacheKey(tokenID string) string {
+	return "revoked-token:" + tokenID
 }
 
-// IsRefreshTokenStored checks if a refresh token is persisted in the datastore
-func (uc *securityTokenUseCase) IsRefreshTokenStored(refreshTokenMetadata *auth.TokenMetadata) bool {
-	_, err := uc.securityTokenRepo.GetTokenByMetadata(refreshTokenMetadata)
-	return err == nil
+// hashToken returns the hex encoded sha256 sum of a token, tokens are only persisted hashed
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
 }
 
-// RemoveRefreshToken removes a refresh token from the datastore
-func (uc *securityTokenUseCase) RemoveRefreshToken(refreshTokenMetadata *auth.TokenMetadata) error {
-	return uc.securityTokenRepo.RemoveTokenByMetadata(refreshTokenMetadata)
+// hashTokenMetadata returns a copy of auth.TokenMetadata with its token hashed
+func hashTokenMetadata(tokenMetadata *auth.TokenMetadata) *auth.TokenMetadata {
+	hashedTokenMetadata := *tokenMetadata
+	hashedTokenMetadata.Token = hashToken(tokenMetadata.Token)
+	return &hashedTokenMetadata
 }
@@ -1,11 +1,13 @@
 package usecase
 
 import (
+	"context"
 	"errors"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"sherman/mocks"
 	_ "sherman/src/app/testing"
+	"sherman/src/app/utils/terr"
 	"sherman/src/domain/auth"
 	"testing"
 	"time"
@@ -13,18 +15,27 @@ import (
 
 type securityTokenUseCaseMockDeps struct {
 	securityTokenRepository *mocks.SecurityTokenRepository
+	revokedTokenRepository  *mocks.RevokedTokenRepository
+	roleRepository          *mocks.RoleRepository
 	securityService         *mocks.Security
+	cacheService            *mocks.Cache
 }
 
 func genSecurityTokenUseCase() (auth.SecurityTokenUseCase, securityTokenUseCaseMockDeps) {
 	stucDeps := securityTokenUseCaseMockDeps{
 		securityTokenRepository: new(mocks.SecurityTokenRepository),
+		revokedTokenRepository:  new(mocks.RevokedTokenRepository),
+		roleRepository:          new(mocks.RoleRepository),
 		securityService:         new(mocks.Security),
+		cacheService:            new(mocks.Cache),
 	}
 
 	stuc := NewSecurityTokenUseCase(
 		stucDeps.securityTokenRepository,
+		stucDeps.revokedTokenRepository,
+		stucDeps.roleRepository,
 		stucDeps.securityService,
+		stucDeps.cacheService,
 	)
 
 	return stuc, stucDeps
@@ -33,24 +44,36 @@ func genSecurityTokenUseCase() (auth.SecurityTokenUseCase, securityTokenUseCaseM
 func TestGenRefreshToken(t *testing.T) {
 	mockUserID := "some-user-id"
 	mockToken := "some-token"
+	mockUserAgent := "some-user-agent"
+	mockIPAddress := "127.0.0.1"
 
 	t.Run("it should succeed", func(t *testing.T) {
 		stuc, stucDeps := genSecurityTokenUseCase()
-		stucDeps.securityTokenRepository.On("CreateOrUpdateToken", mock.Anything).Return(nil)
+		stucDeps.securityTokenRepository.
+			On("CreateToken", mock.Anything, mock.MatchedBy(func(st *auth.SecurityToken) bool {
+				return st.Token == hashToken(mockToken)
+			})).
+			Return(nil)
 		stucDeps.securityService.
 			On(
 				"GenToken",
 				mock.AnythingOfType("string"),
 				mock.AnythingOfType("string"),
+				mock.AnythingOfType("[]string"),
 				mock.AnythingOfType("int64"),
 				mock.AnythingOfType("int64"),
 			).
 			Return(mockToken, nil)
 
-		refreshToken, err := stuc.GenRefreshToken(mockUserID)
+		refreshToken, err := stuc.GenRefreshToken(context.Background(), mockUserID, mockUserAgent, mockIPAddress)
 
 		assert.NoError(t, err)
 		assert.NotEmpty(t, refreshToken.ID)
+		assert.EqualValues(t, refreshToken.ID, refreshToken.FamilyID)
+		assert.EqualValues(t, mockToken, refreshToken.Token)
+		assert.EqualValues(t, mockUserAgent, refreshToken.UserAgent)
+		assert.EqualValues(t, mockIPAddress, refreshToken.IPAddress)
+		assert.NotEmpty(t, refreshToken.LastUsedAt)
 		assert.EqualValues(t, mockUserID, refreshToken.UserID)
 		assert.EqualValues(t, auth.RefreshTokenType, refreshToken.Type)
 		assert.NotEmpty(t, refreshToken.CreatedAt)
@@ -59,19 +82,20 @@ func TestGenRefreshToken(t *testing.T) {
 
 	t.Run("it should return an error", func(t *testing.T) {
 		stuc, stucDeps := genSecurityTokenUseCase()
-		stucDeps.securityTokenRepository.On("CreateOrUpdateToken", mock.Anything).Return(nil)
+		stucDeps.securityTokenRepository.On("CreateToken", mock.Anything, mock.Anything).Return(nil)
 		mockError := errors.New("some error")
 		stucDeps.securityService.
 			On(
 				"GenToken",
 				mock.AnythingOfType("string"),
 				mock.AnythingOfType("string"),
+				mock.AnythingOfType("[]string"),
 				mock.AnythingOfType("int64"),
 				mock.AnythingOfType("int64"),
 			).
 			Return("", mockError)
 
-		_, err := stuc.GenRefreshToken(mockUserID)
+		_, err := stuc.GenRefreshToken(context.Background(), mockUserID, mockUserAgent, mockIPAddress)
 
 		if assert.Error(t, err) {
 			assert.Equal(t, "could not generate refresh token", err.Error())
@@ -81,21 +105,22 @@ func TestGenRefreshToken(t *testing.T) {
 	t.Run("it should return an error", func(t *testing.T) {
 		stuc, stucDeps := genSecurityTokenUseCase()
 		mockError := errors.New("some error")
-		stucDeps.securityTokenRepository.On("CreateOrUpdateToken", mock.Anything).Return(mockError)
+		stucDeps.securityTokenRepository.On("CreateToken", mock.Anything, mock.Anything).Return(mockError)
 		stucDeps.securityService.
 			On(
 				"GenToken",
 				mock.AnythingOfType("string"),
 				mock.AnythingOfType("string"),
+				mock.AnythingOfType("[]string"),
 				mock.AnythingOfType("int64"),
 				mock.AnythingOfType("int64"),
 			).
 			Return(mockToken, nil)
 
-		_, err := stuc.GenRefreshToken(mockUserID)
+		_, err := stuc.GenRefreshToken(context.Background(), mockUserID, mockUserAgent, mockIPAddress)
 
 		if assert.Error(t, err) {
-			assert.Equal(t, "could not create or update refresh token", err.Error())
+			assert.Equal(t, "could not create refresh token", err.Error())
 		}
 	})
 }
@@ -106,18 +131,19 @@ func TestGenAccessToken(t *testing.T) {
 
 	t.Run("it should succeed", func(t *testing.T) {
 		stuc, stucDeps := genSecurityTokenUseCase()
-		stucDeps.securityTokenRepository.On("CreateOrUpdateToken", mock.Anything).Return(nil)
+		stucDeps.roleRepository.On("GetRoleNamesByUserID", mock.Anything, mockUserID).Return([]string{auth.AdminRole}, nil)
 		stucDeps.securityService.
 			On(
 				"GenToken",
-				mock.AnythingOfType("string"),
-				mock.AnythingOfType("string"),
+				mockUserID,
+				auth.AccessTokenType,
+				[]string{auth.AdminRole},
 				mock.AnythingOfType("int64"),
 				mock.AnythingOfType("int64"),
 			).
 			Return(mockToken, nil)
 
-		refreshToken, err := stuc.GenAccessToken(mockUserID)
+		refreshToken, err := stuc.GenAccessToken(context.Background(), mockUserID)
 
 		assert.NoError(t, err)
 		assert.NotEmpty(t, refreshToken.ID)
@@ -130,24 +156,157 @@ func TestGenAccessToken(t *testing.T) {
 	t.Run("it should return an error", func(t *testing.T) {
 		stuc, stucDeps := genSecurityTokenUseCase()
 
-		stucDeps.securityTokenRepository.On("CreateOrUpdateToken", mock.Anything).Return(nil)
+		stucDeps.roleRepository.On("GetRoleNamesByUserID", mock.Anything, mockUserID).Return([]string{}, nil)
 		mockError := errors.New("some error")
 		stucDeps.securityService.
 			On(
 				"GenToken",
 				mock.AnythingOfType("string"),
 				mock.AnythingOfType("string"),
+				mock.AnythingOfType("[]string"),
 				mock.AnythingOfType("int64"),
 				mock.AnythingOfType("int64"),
 			).
 			Return("", mockError)
 
-		_, err := stuc.GenAccessToken(mockUserID)
+		_, err := stuc.GenAccessToken(context.Background(), mockUserID)
 
 		if assert.Error(t, err) {
 			assert.Equal(t, "could not generate access token", err.Error())
 		}
 	})
+
+	t.Run("it should return an error when the roles can't be fetched", func(t *testing.T) {
+		stuc, stucDeps := genSecurityTokenUseCase()
+		stucDeps.roleRepository.On("GetRoleNamesByUserID", mock.Anything, mockUserID).Return(nil, errors.New("some error"))
+
+		_, err := stuc.GenAccessToken(context.Background(), mockUserID)
+
+		if assert.Error(t, err) {
+			assert.Equal(t, "could not get user roles", err.Error())
+			stucDeps.securityService.AssertNotCalled(t, "GenToken", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+		}
+	})
+}
+
+func TestGenScopedRefreshToken(t *testing.T) {
+	mockScopes := []string{auth.ProfileScope}
+
+	t.Run("it should succeed", func(t *testing.T) {
+		stuc, stucDeps := genSecurityTokenUseCase()
+		stucDeps.securityTokenRepository.
+			On("CreateToken", mock.Anything, mock.MatchedBy(func(st *auth.SecurityToken) bool {
+				return st.Token == hashToken("some-token") && st.ID == st.FamilyID
+			})).
+			Return(nil)
+		stucDeps.securityService.
+			On(
+				"GenScopedToken",
+				"some-user-id",
+				auth.RefreshTokenType,
+				"some-client-id",
+				mockScopes,
+				[]string(nil),
+				mock.AnythingOfType("int64"),
+				mock.AnythingOfType("int64"),
+			).
+			Return("some-token", nil)
+
+		refreshToken, err := stuc.GenScopedRefreshToken(context.Background(), "some-user-id", "some-client-id", mockScopes, "some-user-agent", "127.0.0.1")
+
+		if assert.NoError(t, err) {
+			assert.EqualValues(t, "some-token", refreshToken.Token)
+			assert.EqualValues(t, "some-user-id", refreshToken.UserID)
+			assert.EqualValues(t, auth.RefreshTokenType, refreshToken.Type)
+		}
+	})
+
+	t.Run("it should return an error", func(t *testing.T) {
+		stuc, stucDeps := genSecurityTokenUseCase()
+		stucDeps.securityService.
+			On("GenScopedToken", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+			Return("", errors.New("some error"))
+
+		_, err := stuc.GenScopedRefreshToken(context.Background(), "some-user-id", "some-client-id", mockScopes, "some-user-agent", "127.0.0.1")
+
+		assert.EqualError(t, err, "could not generate refresh token")
+	})
+}
+
+func TestGenScopedAccessToken(t *testing.T) {
+	mockScopes := []string{auth.ProfileScope}
+
+	t.Run("it should succeed", func(t *testing.T) {
+		stuc, stucDeps := genSecurityTokenUseCase()
+		stucDeps.roleRepository.On("GetRoleNamesByUserID", mock.Anything, "some-user-id").Return([]string{auth.AdminRole}, nil)
+		stucDeps.securityService.
+			On(
+				"GenScopedToken",
+				"some-user-id",
+				auth.AccessTokenType,
+				"some-client-id",
+				mockScopes,
+				[]string{auth.AdminRole},
+				mock.AnythingOfType("int64"),
+				mock.AnythingOfType("int64"),
+			).
+			Return("some-token", nil)
+
+		accessToken, err := stuc.GenScopedAccessToken(context.Background(), "some-user-id", "some-client-id", mockScopes)
+
+		if assert.NoError(t, err) {
+			assert.EqualValues(t, "some-token", accessToken.Token)
+			assert.EqualValues(t, auth.AccessTokenType, accessToken.Type)
+		}
+	})
+
+	t.Run("it should return an error", func(t *testing.T) {
+		stuc, stucDeps := genSecurityTokenUseCase()
+		stucDeps.roleRepository.On("GetRoleNamesByUserID", mock.Anything, "some-user-id").Return(nil, errors.New("some error"))
+
+		_, err := stuc.GenScopedAccessToken(context.Background(), "some-user-id", "some-client-id", mockScopes)
+
+		assert.EqualError(t, err, "could not get user roles")
+	})
+}
+
+func TestGenClientAccessToken(t *testing.T) {
+	mockScopes := []string{auth.ProfileScope}
+
+	t.Run("it should succeed", func(t *testing.T) {
+		stuc, stucDeps := genSecurityTokenUseCase()
+		stucDeps.securityService.
+			On(
+				"GenScopedToken",
+				"some-client-id",
+				auth.ClientAccessTokenType,
+				"some-client-id",
+				mockScopes,
+				[]string(nil),
+				mock.AnythingOfType("int64"),
+				mock.AnythingOfType("int64"),
+			).
+			Return("some-token", nil)
+
+		accessToken, err := stuc.GenClientAccessToken(context.Background(), "some-client-id", mockScopes)
+
+		if assert.NoError(t, err) {
+			assert.EqualValues(t, "some-token", accessToken.Token)
+			assert.EqualValues(t, "some-client-id", accessToken.UserID)
+			assert.EqualValues(t, auth.ClientAccessTokenType, accessToken.Type)
+		}
+	})
+
+	t.Run("it should return an error", func(t *testing.T) {
+		stuc, stucDeps := genSecurityTokenUseCase()
+		stucDeps.securityService.
+			On("GenScopedToken", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+			Return("", errors.New("some error"))
+
+		_, err := stuc.GenClientAccessToken(context.Background(), "some-client-id", mockScopes)
+
+		assert.EqualError(t, err, "could not generate access token")
+	})
 }
 
 func TestIsRefreshTokenStored(t *testing.T) {
@@ -169,9 +328,9 @@ func TestIsRefreshTokenStored(t *testing.T) {
 
 	t.Run("it should succeed", func(t *testing.T) {
 		stuc, stucDeps := genSecurityTokenUseCase()
-		stucDeps.securityTokenRepository.On("GetTokenByMetadata", mock.Anything).Return(mockSecurityToken, nil)
+		stucDeps.securityTokenRepository.On("GetTokenByMetadata", mock.Anything, mock.Anything).Return(mockSecurityToken, nil)
 
-		tokenStored := stuc.IsRefreshTokenStored(mockRefreshTokenMetaData)
+		tokenStored := stuc.IsRefreshTokenStored(context.Background(), mockRefreshTokenMetaData)
 
 		assert.EqualValues(t, true, tokenStored)
 	})
@@ -179,15 +338,231 @@ func TestIsRefreshTokenStored(t *testing.T) {
 	t.Run("it should return an error", func(t *testing.T) {
 		stuc, stucDeps := genSecurityTokenUseCase()
 		stucDeps.securityTokenRepository.
-			On("GetTokenByMetadata", mock.Anything).
+			On("GetTokenByMetadata", mock.Anything, mock.Anything).
 			Return(auth.SecurityToken{}, errors.New("some error"))
 
-		tokenStored := stuc.IsRefreshTokenStored(mockRefreshTokenMetaData)
+		tokenStored := stuc.IsRefreshTokenStored(context.Background(), mockRefreshTokenMetaData)
+
+		assert.EqualValues(t, false, tokenStored)
+	})
+
+	t.Run("it should not accept a rotated token", func(t *testing.T) {
+		stuc, stucDeps := genSecurityTokenUseCase()
+		rotatedSecurityToken := mockSecurityToken
+		rotatedSecurityToken.Rotated = true
+		stucDeps.securityTokenRepository.On("GetTokenByMetadata", mock.Anything, mock.Anything).Return(rotatedSecurityToken, nil)
+
+		tokenStored := stuc.IsRefreshTokenStored(context.Background(), mockRefreshTokenMetaData)
 
 		assert.EqualValues(t, false, tokenStored)
 	})
 }
 
+func TestRotateRefreshToken(t *testing.T) {
+	mockRefreshTokenMetaData := &auth.TokenMetadata{
+		UserID: "some-user-id",
+		Type:   auth.RefreshTokenType,
+		Token:  "some-token",
+	}
+
+	now := time.Now()
+	mockSecurityToken := auth.SecurityToken{
+		ID:        "some-id",
+		UserID:    "some-user-id",
+		Token:     "some-hashed-token",
+		Type:      auth.RefreshTokenType,
+		FamilyID:  "some-id",
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	mockToken := "some-new-token"
+
+	t.Run("it should succeed", func(t *testing.T) {
+		stuc, stucDeps := genSecurityTokenUseCase()
+		stucDeps.securityTokenRepository.
+			On("GetTokenByMetadata", mock.Anything, mock.MatchedBy(func(tmd *auth.TokenMetadata) bool {
+				return tmd.Token == hashToken(mockRefreshTokenMetaData.Token)
+			})).
+			Return(mockSecurityToken, nil)
+		stucDeps.securityTokenRepository.
+			On("RotateToken", mock.Anything,
+				mock.MatchedBy(func(st *auth.SecurityToken) bool {
+					return st.ID == mockSecurityToken.ID && st.Token == hashToken(mockToken)
+				}),
+				mock.MatchedBy(func(st *auth.SecurityToken) bool {
+					return st.Rotated && st.FamilyID == mockSecurityToken.FamilyID && st.Token == mockSecurityToken.Token
+				}),
+			).
+			Return(nil)
+		stucDeps.securityService.
+			On(
+				"GenScopedToken",
+				"some-user-id",
+				auth.RefreshTokenType,
+				"",
+				[]string(nil),
+				[]string(nil),
+				mock.AnythingOfType("int64"),
+				mock.AnythingOfType("int64"),
+			).
+			Return(mockToken, nil)
+
+		refreshToken, err := stuc.RotateRefreshToken(context.Background(), mockRefreshTokenMetaData)
+
+		assert.NoError(t, err)
+		assert.EqualValues(t, mockSecurityToken.ID, refreshToken.ID)
+		assert.EqualValues(t, mockSecurityToken.FamilyID, refreshToken.FamilyID)
+		assert.EqualValues(t, mockToken, refreshToken.Token)
+		assert.EqualValues(t, auth.RefreshTokenType, refreshToken.Type)
+	})
+
+	t.Run("it should keep the client and scopes of the token", func(t *testing.T) {
+		scopedRefreshTokenMetaData := *mockRefreshTokenMetaData
+		scopedRefreshTokenMetaData.ClientID = "some-client-id"
+		scopedRefreshTokenMetaData.Scopes = []string{auth.ProfileScope}
+		stuc, stucDeps := genSecurityTokenUseCase()
+		stucDeps.securityTokenRepository.On("GetTokenByMetadata", mock.Anything, mock.Anything).Return(mockSecurityToken, nil)
+		stucDeps.securityTokenRepository.On("RotateToken", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+		stucDeps.securityService.
+			On(
+				"GenScopedToken",
+				"some-user-id",
+				auth.RefreshTokenType,
+				"some-client-id",
+				[]string{auth.ProfileScope},
+				[]string(nil),
+				mock.AnythingOfType("int64"),
+				mock.AnythingOfType("int64"),
+			).
+			Return(mockToken, nil)
+
+		refreshToken, err := stuc.RotateRefreshToken(context.Background(), &scopedRefreshTokenMetaData)
+
+		if assert.NoError(t, err) {
+			assert.EqualValues(t, mockToken, refreshToken.Token)
+		}
+	})
+
+	t.Run("it should return an un-authorized error", func(t *testing.T) {
+		stuc, stucDeps := genSecurityTokenUseCase()
+		stucDeps.securityTokenRepository.
+			On("GetTokenByMetadata", mock.Anything, mock.Anything).
+			Return(auth.SecurityToken{}, errors.New("some error"))
+
+		_, err := stuc.RotateRefreshToken(context.Background(), mockRefreshTokenMetaData)
+
+		if assert.Error(t, err) {
+			assert.Equal(t, terr.NewUnAuthorizedError("invalid refresh token"), err)
+		}
+	})
+
+	t.Run("it should revoke the token family on reuse", func(t *testing.T) {
+		stuc, stucDeps := genSecurityTokenUseCase()
+		rotatedSecurityToken := mockSecurityToken
+		rotatedSecurityToken.ID = "some-rotated-id"
+		rotatedSecurityToken.Rotated = true
+		stucDeps.securityTokenRepository.
+			On("GetTokenByMetadata", mock.Anything, mock.Anything).
+			Return(rotatedSecurityToken, nil)
+		stucDeps.securityTokenRepository.
+			On("RemoveTokenFamily", mock.Anything, rotatedSecurityToken.UserID, rotatedSecurityToken.FamilyID).
+			Return(nil)
+
+		_, err := stuc.RotateRefreshToken(context.Background(), mockRefreshTokenMetaData)
+
+		if assert.Error(t, err) {
+			assert.Equal(t, terr.NewUnAuthorizedError("refresh token reuse detected"), err)
+		}
+		stucDeps.securityTokenRepository.AssertCalled(t, "RemoveTokenFamily", mock.Anything, rotatedSecurityToken.UserID, rotatedSecurityToken.FamilyID)
+		stucDeps.securityTokenRepository.AssertNotCalled(t, "RotateToken", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("it should revoke the token family on a concurrent rotation", func(t *testing.T) {
+		stuc, stucDeps := genSecurityTokenUseCase()
+		stucDeps.securityTokenRepository.
+			On("GetTokenByMetadata", mock.Anything, mock.Anything).
+			Return(mockSecurityToken, nil)
+		stucDeps.securityService.
+			On(
+				"GenScopedToken",
+				"some-user-id",
+				auth.RefreshTokenType,
+				"",
+				[]string(nil),
+				[]string(nil),
+				mock.AnythingOfType("int64"),
+				mock.AnythingOfType("int64"),
+			).
+			Return(mockToken, nil)
+		stucDeps.securityTokenRepository.
+			On("RotateToken", mock.Anything, mock.Anything, mock.Anything).
+			Return(terr.NewUnAuthorizedError("refresh token already rotated"))
+		stucDeps.securityTokenRepository.
+			On("RemoveTokenFamily", mock.Anything, mockSecurityToken.UserID, mockSecurityToken.FamilyID).
+			Return(nil)
+
+		_, err := stuc.RotateRefreshToken(context.Background(), mockRefreshTokenMetaData)
+
+		if assert.Error(t, err) {
+			assert.Equal(t, terr.NewUnAuthorizedError("refresh token reuse detected"), err)
+		}
+		stucDeps.securityTokenRepository.AssertCalled(t, "RemoveTokenFamily", mock.Anything, mockSecurityToken.UserID, mockSecurityToken.FamilyID)
+	})
+
+	t.Run("it should return an error", func(t *testing.T) {
+		stuc, stucDeps := genSecurityTokenUseCase()
+		stucDeps.securityTokenRepository.
+			On("GetTokenByMetadata", mock.Anything, mock.Anything).
+			Return(mockSecurityToken, nil)
+		stucDeps.securityService.
+			On(
+				"GenScopedToken",
+				"some-user-id",
+				auth.RefreshTokenType,
+				"",
+				[]string(nil),
+				[]string(nil),
+				mock.AnythingOfType("int64"),
+				mock.AnythingOfType("int64"),
+			).
+			Return("", errors.New("some error"))
+
+		_, err := stuc.RotateRefreshToken(context.Background(), mockRefreshTokenMetaData)
+
+		if assert.Error(t, err) {
+			assert.Equal(t, "could not generate refresh token", err.Error())
+		}
+	})
+
+	t.Run("it should return an error", func(t *testing.T) {
+		stuc, stucDeps := genSecurityTokenUseCase()
+		stucDeps.securityTokenRepository.
+			On("GetTokenByMetadata", mock.Anything, mock.Anything).
+			Return(mockSecurityToken, nil)
+		stucDeps.securityTokenRepository.
+			On("RotateToken", mock.Anything, mock.Anything, mock.Anything).
+			Return(errors.New("some error"))
+		stucDeps.securityService.
+			On(
+				"GenScopedToken",
+				"some-user-id",
+				auth.RefreshTokenType,
+				"",
+				[]string(nil),
+				[]string(nil),
+				mock.AnythingOfType("int64"),
+				mock.AnythingOfType("int64"),
+			).
+			Return(mockToken, nil)
+
+		_, err := stuc.RotateRefreshToken(context.Background(), mockRefreshTokenMetaData)
+
+		if assert.Error(t, err) {
+			assert.Equal(t, "could not rotate refresh token", err.Error())
+		}
+	})
+}
+
 func TestRemoveRefreshToken(t *testing.T) {
 	mockRefreshTokenMetaData := &auth.TokenMetadata{
 		UserID: "some-user-id",
@@ -195,24 +570,517 @@ func TestRemoveRefreshToken(t *testing.T) {
 		Token:  "some-token",
 	}
 
+	mockSecurityToken := auth.SecurityToken{
+		ID:       "some-id",
+		UserID:   "some-user-id",
+		Token:    "some-hashed-token",
+		Type:     auth.RefreshTokenType,
+		FamilyID: "some-family-id",
+	}
+
+	t.Run("it should succeed", func(t *testing.T) {
+		stuc, stucDeps := genSecurityTokenUseCase()
+		stucDeps.securityTokenRepository.On("GetTokenByMetadata", mock.Anything, mock.Anything).Return(mockSecurityToken, nil)
+		stucDeps.securityTokenRepository.
+			On("RemoveTokenFamily", mock.Anything, mockSecurityToken.UserID, mockSecurityToken.FamilyID).
+			Return(nil)
+
+		err := stuc.RemoveRefreshToken(context.Background(), mockRefreshTokenMetaData)
+
+		assert.NoError(t, err)
+		stucDeps.securityTokenRepository.AssertNotCalled(t, "RemoveTokenByMetadata", mock.Anything, mock.Anything)
+	})
+
+	t.Run("it should return an error", func(t *testing.T) {
+		stuc, stucDeps := genSecurityTokenUseCase()
+		mockError := terr.NewNotFoundError("token not found")
+		stucDeps.securityTokenRepository.On("GetTokenByMetadata", mock.Anything, mock.Anything).Return(auth.SecurityToken{}, mockError)
+
+		err := stuc.RemoveRefreshToken(context.Background(), mockRefreshTokenMetaData)
+
+		if assert.Error(t, err) {
+			assert.EqualValues(t, mockError, err)
+		}
+	})
+
+	t.Run("it should return an error", func(t *testing.T) {
+		stuc, stucDeps := genSecurityTokenUseCase()
+		mockError := errors.New("some error")
+		stucDeps.securityTokenRepository.On("GetTokenByMetadata", mock.Anything, mock.Anything).Return(mockSecurityToken, nil)
+		stucDeps.securityTokenRepository.On("RemoveTokenFamily", mock.Anything, mock.Anything, mock.Anything).Return(mockError)
+
+		err := stuc.RemoveRefreshToken(context.Background(), mockRefreshTokenMetaData)
+
+		if assert.Error(t, err) {
+			assert.EqualValues(t, mockError, err)
+		}
+	})
+}
+
+func TestRevokeAccessToken(t *testing.T) {
+	mockTokenMeta := auth.TokenMetadata{
+		ID:        "some-token-id",
+		UserID:    "some-user-id",
+		Type:      auth.AccessTokenType,
+		Token:     "some-token",
+		ExpiresAt: time.Now().Add(time.Minute * time.Duration(15)).Unix(),
+	}
+
+	t.Run("it should succeed", func(t *testing.T) {
+		stuc, stucDeps := genSecurityTokenUseCase()
+		stucDeps.revokedTokenRepository.
+			On("CreateRevokedToken", mock.Anything, mock.MatchedBy(func(rt *auth.RevokedToken) bool {
+				return rt.ID == mockTokenMeta.ID &&
+					rt.UserID == mockTokenMeta.UserID &&
+					rt.ExpiresAt.Unix() == mockTokenMeta.ExpiresAt
+			})).
+			Return(nil)
+		stucDeps.revokedTokenRepository.
+			On("RemoveExpiredRevokedTokens", mock.Anything, mock.AnythingOfType("time.Time")).
+			Return(nil)
+		stucDeps.cacheService.
+			On("Set", "revoked-token:some-token-id", true, mock.AnythingOfType("time.Duration")).
+			Return()
+
+		err := stuc.RevokeAccessToken(context.Background(), &mockTokenMeta)
+
+		assert.NoError(t, err)
+		stucDeps.cacheService.AssertExpectations(t)
+	})
+
+	t.Run("it should ignore tokens without id or already expired", func(t *testing.T) {
+		stuc, stucDeps := genSecurityTokenUseCase()
+
+		tokenMeta := mockTokenMeta
+		tokenMeta.ID = ""
+		assert.NoError(t, stuc.RevokeAccessToken(context.Background(), &tokenMeta))
+
+		tokenMeta = mockTokenMeta
+		tokenMeta.ExpiresAt = time.Now().Add(-time.Minute).Unix()
+		assert.NoError(t, stuc.RevokeAccessToken(context.Background(), &tokenMeta))
+
+		stucDeps.revokedTokenRepository.AssertNotCalled(t, "CreateRevokedToken", mock.Anything, mock.Anything)
+	})
+
+	t.Run("it should return error", func(t *testing.T) {
+		stuc, stucDeps := genSecurityTokenUseCase()
+		stucDeps.revokedTokenRepository.
+			On("CreateRevokedToken", mock.Anything, mock.Anything).
+			Return(errors.New("some error"))
+
+		err := stuc.RevokeAccessToken(context.Background(), &mockTokenMeta)
+
+		if assert.Error(t, err) {
+			assert.Equal(t, "could not revoke access token", err.Error())
+		}
+		stucDeps.cacheService.AssertNotCalled(t, "Set", mock.Anything, mock.Anything, mock.Anything)
+	})
+}
+
+func TestIsAccessTokenRevoked(t *testing.T) {
+	mockTokenMeta := auth.TokenMetadata{
+		ID:        "some-token-id",
+		UserID:    "some-user-id",
+		Type:      auth.AccessTokenType,
+		Token:     "some-token",
+		ExpiresAt: time.Now().Add(time.Minute * time.Duration(15)).Unix(),
+	}
+
+	t.Run("it should not be revoked", func(t *testing.T) {
+		stuc, stucDeps := genSecurityTokenUseCase()
+		stucDeps.cacheService.
+			On("Get", "revoked-token:some-token-id").
+			Return(nil, false)
+		stucDeps.revokedTokenRepository.
+			On("IsTokenRevoked", mock.Anything, mockTokenMeta.ID).
+			Return(false, nil)
+
+		assert.False(t, stuc.IsAccessTokenRevoked(context.Background(), &mockTokenMeta))
+	})
+
+	t.Run("it should be revoked when cached", func(t *testing.T) {
+		stuc, stucDeps := genSecurityTokenUseCase()
+		stucDeps.cacheService.
+			On("Get", "revoked-token:some-token-id").
+			Return(true, true)
+
+		assert.True(t, stuc.IsAccessTokenRevoked(context.Background(), &mockTokenMeta))
+		stucDeps.revokedTokenRepository.AssertNotCalled(t, "IsTokenRevoked", mock.Anything, mock.Anything)
+	})
+
+	t.Run("it should be revoked and cache it", func(t *testing.T) {
+		stuc, stucDeps := genSecurityTokenUseCase()
+		stucDeps.cacheService.
+			On("Get", "revoked-token:some-token-id").
+			Return(nil, false)
+		stucDeps.cacheService.
+			On("Set", "revoked-token:some-token-id", true, mock.AnythingOfType("time.Duration")).
+			Return()
+		stucDeps.revokedTokenRepository.
+			On("IsTokenRevoked", mock.Anything, mockTokenMeta.ID).
+			Return(true, nil)
+
+		assert.True(t, stuc.IsAccessTokenRevoked(context.Background(), &mockTokenMeta))
+		stucDeps.cacheService.AssertExpectations(t)
+	})
+
+	t.Run("it should be revoked when it has no id", func(t *testing.T) {
+		stuc, _ := genSecurityTokenUseCase()
+
+		tokenMeta := mockTokenMeta
+		tokenMeta.ID = ""
+		assert.True(t, stuc.IsAccessTokenRevoked(context.Background(), &tokenMeta))
+	})
+
+	t.Run("it should be revoked when it can't be checked", func(t *testing.T) {
+		stuc, stucDeps := genSecurityTokenUseCase()
+		stucDeps.cacheService.
+			On("Get", "revoked-token:some-token-id").
+			Return(nil, false)
+		stucDeps.revokedTokenRepository.
+			On("IsTokenRevoked", mock.Anything, mockTokenMeta.ID).
+			Return(false, errors.New("some error"))
+
+		assert.True(t, stuc.IsAccessTokenRevoked(context.Background(), &mockTokenMeta))
+	})
+}
+
+func TestGetSessions(t *testing.T) {
+	mockSessions := []auth.SecurityToken{
+		{
+			ID:       "some-id",
+			UserID:   "some-user-id",
+			Type:     auth.RefreshTokenType,
+			FamilyID: "some-id",
+		},
+	}
+
 	t.Run("it should succeed", func(t *testing.T) {
 		stuc, stucDeps := genSecurityTokenUseCase()
-		stucDeps.securityTokenRepository.On("RemoveTokenByMetadata", mock.Anything).Return(nil)
+		stucDeps.securityTokenRepository.
+			On("GetTokensByUserID", mock.Anything, "some-user-id", auth.RefreshTokenType).
+			Return(mockSessions, nil)
 
-		err := stuc.RemoveRefreshToken(mockRefreshTokenMetaData)
+		sessions, err := stuc.GetSessions(context.Background(), "some-user-id")
 
 		assert.NoError(t, err)
+		assert.EqualValues(t, mockSessions, sessions)
 	})
 
 	t.Run("it should return an error", func(t *testing.T) {
 		stuc, stucDeps := genSecurityTokenUseCase()
 		mockError := errors.New("some error")
-		stucDeps.securityTokenRepository.On("RemoveTokenByMetadata", mock.Anything).Return(mockError)
+		stucDeps.securityTokenRepository.
+			On("GetTokensByUserID", mock.Anything, mock.Anything, mock.Anything).
+			Return(nil, mockError)
 
-		err := stuc.RemoveRefreshToken(mockRefreshTokenMetaData)
+		_, err := stuc.GetSessions(context.Background(), "some-user-id")
 
 		if assert.Error(t, err) {
 			assert.EqualValues(t, mockError, err)
 		}
 	})
 }
+
+func TestRemoveSession(t *testing.T) {
+	t.Run("it should succeed", func(t *testing.T) {
+		stuc, stucDeps := genSecurityTokenUseCase()
+		stucDeps.securityTokenRepository.
+			On("RemoveTokenFamily", mock.Anything, "some-user-id", "some-session-id").
+			Return(nil)
+
+		err := stuc.RemoveSession(context.Background(), "some-user-id", "some-session-id")
+
+		assert.NoError(t, err)
+	})
+
+	t.Run("it should return an error", func(t *testing.T) {
+		stuc, stucDeps := genSecurityTokenUseCase()
+		mockError := terr.NewNotFoundError("token not found")
+		stucDeps.securityTokenRepository.
+			On("RemoveTokenFamily", mock.Anything, mock.Anything, mock.Anything).
+			Return(mockError)
+
+		err := stuc.RemoveSession(context.Background(), "some-user-id", "some-session-id")
+
+		if assert.Error(t, err) {
+			assert.EqualValues(t, mockError, err)
+		}
+	})
+}
+
+func TestRemoveSessions(t *testing.T) {
+	t.Run("it should succeed", func(t *testing.T) {
+		stuc, stucDeps := genSecurityTokenUseCase()
+		stucDeps.securityTokenRepository.
+			On("RemoveTokenByMetadata", mock.Anything, &auth.TokenMetadata{UserID: "some-user-id", Type: auth.RefreshTokenType}).
+			Return(nil)
+
+		err := stuc.RemoveSessions(context.Background(), "some-user-id")
+
+		assert.NoError(t, err)
+	})
+
+	t.Run("it should return an error", func(t *testing.T) {
+		stuc, stucDeps := genSecurityTokenUseCase()
+		mockError := errors.New("some error")
+		stucDeps.securityTokenRepository.
+			On("RemoveTokenByMetadata", mock.Anything, mock.Anything).
+			Return(mockError)
+
+		err := stuc.RemoveSessions(context.Background(), "some-user-id")
+
+		if assert.Error(t, err) {
+			assert.EqualValues(t, mockError, err)
+		}
+	})
+}
+
+func TestGenOneTimeToken(t *testing.T) {
+	mockToken := "some-token"
+
+	t.Run("it should succeed", func(t *testing.T) {
+		stuc, stucDeps := genSecurityTokenUseCase()
+		stucDeps.securityService.
+			On(
+				"GenToken",
+				"some-user-id",
+				auth.EmailVerificationTokenType,
+				mock.AnythingOfType("[]string"),
+				mock.AnythingOfType("int64"),
+				mock.AnythingOfType("int64"),
+			).
+			Return(mockToken, nil)
+		stucDeps.securityTokenRepository.
+			On("CreateOrUpdateToken", mock.Anything, mock.MatchedBy(func(st *auth.SecurityToken) bool {
+				return st.Token == hashToken(mockToken) &&
+					st.Type == auth.EmailVerificationTokenType &&
+					st.FamilyID == st.ID
+			})).
+			Return(nil)
+
+		oneTimeToken, err := stuc.GenOneTimeToken(context.Background(), "some-user-id", auth.EmailVerificationTokenType, time.Hour)
+
+		if assert.NoError(t, err) {
+			assert.Equal(t, mockToken, oneTimeToken.Token)
+			assert.Equal(t, "some-user-id", oneTimeToken.UserID)
+			assert.Equal(t, auth.EmailVerificationTokenType, oneTimeToken.Type)
+		}
+	})
+
+	t.Run("it should return an error", func(t *testing.T) {
+		stuc, stucDeps := genSecurityTokenUseCase()
+		stucDeps.securityService.
+			On("GenToken", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+			Return("", errors.New("some error"))
+
+		_, err := stuc.GenOneTimeToken(context.Background(), "some-user-id", auth.EmailVerificationTokenType, time.Hour)
+
+		if assert.Error(t, err) {
+			assert.Equal(t, "could not generate token", err.Error())
+		}
+	})
+
+	t.Run("it should return an error", func(t *testing.T) {
+		stuc, stucDeps := genSecurityTokenUseCase()
+		stucDeps.securityService.
+			On("GenToken", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+			Return(mockToken, nil)
+		stucDeps.securityTokenRepository.
+			On("CreateOrUpdateToken", mock.Anything, mock.Anything).
+			Return(errors.New("some error"))
+
+		_, err := stuc.GenOneTimeToken(context.Background(), "some-user-id", auth.EmailVerificationTokenType, time.Hour)
+
+		if assert.Error(t, err) {
+			assert.Equal(t, "could not create token", err.Error())
+		}
+	})
+}
+
+func TestConsumeOneTimeToken(t *testing.T) {
+	mockTokenMeta := auth.TokenMetadata{
+		ID:     "some-token-id",
+		UserID: "some-user-id",
+		Type:   auth.EmailVerificationTokenType,
+		Token:  "some-token",
+	}
+	mockStoredToken := auth.SecurityToken{ID: "some-id", UserID: "some-user-id", FamilyID: "some-id"}
+
+	t.Run("it should succeed", func(t *testing.T) {
+		stuc, stucDeps := genSecurityTokenUseCase()
+		stucDeps.securityService.
+			On("ValidateToken", "some-token", auth.EmailVerificationTokenType).
+			Return(mockTokenMeta, nil)
+		stucDeps.securityTokenRepository.
+			On("GetTokenByMetadata", mock.Anything, hashTokenMetadata(&mockTokenMeta)).
+			Return(mockStoredToken, nil)
+		stucDeps.securityTokenRepository.
+			On("RemoveTokenFamily", mock.Anything, "some-user-id", "some-id").
+			Return(nil)
+
+		tokenMeta, err := stuc.ConsumeOneTimeToken(context.Background(), "some-token", auth.EmailVerificationTokenType)
+
+		if assert.NoError(t, err) {
+			assert.Equal(t, mockTokenMeta, tokenMeta)
+		}
+	})
+
+	t.Run("it should return the validation error", func(t *testing.T) {
+		stuc, stucDeps := genSecurityTokenUseCase()
+		stucDeps.securityService.
+			On("ValidateToken", "some-token", auth.EmailVerificationTokenType).
+			Return(auth.TokenMetadata{}, terr.NewExpiredTokenError("token is expired"))
+
+		_, err := stuc.ConsumeOneTimeToken(context.Background(), "some-token", auth.EmailVerificationTokenType)
+
+		if assert.Error(t, err) {
+			assert.Equal(t, terr.NewExpiredTokenError("token is expired"), err)
+		}
+	})
+
+	t.Run("it should reject a replaced token", func(t *testing.T) {
+		stuc, stucDeps := genSecurityTokenUseCase()
+		stucDeps.securityService.
+			On("ValidateToken", "some-token", auth.EmailVerificationTokenType).
+			Return(mockTokenMeta, nil)
+		stucDeps.securityTokenRepository.
+			On("GetTokenByMetadata", mock.Anything, mock.Anything).
+			Return(auth.SecurityToken{}, terr.NewNotFoundError("token not found"))
+
+		_, err := stuc.ConsumeOneTimeToken(context.Background(), "some-token", auth.EmailVerificationTokenType)
+
+		if assert.Error(t, err) {
+			assert.Equal(t, terr.NewUnAuthorizedError("invalid token"), err)
+		}
+	})
+
+	t.Run("it should reject a token consumed concurrently", func(t *testing.T) {
+		stuc, stucDeps := genSecurityTokenUseCase()
+		stucDeps.securityService.
+			On("ValidateToken", "some-token", auth.EmailVerificationTokenType).
+			Return(mockTokenMeta, nil)
+		stucDeps.securityTokenRepository.
+			On("GetTokenByMetadata", mock.Anything, mock.Anything).
+			Return(mockStoredToken, nil)
+		stucDeps.securityTokenRepository.
+			On("RemoveTokenFamily", mock.Anything, "some-user-id", "some-id").
+			Return(terr.NewNotFoundError("token not found"))
+
+		_, err := stuc.ConsumeOneTimeToken(context.Background(), "some-token", auth.EmailVerificationTokenType)
+
+		if assert.Error(t, err) {
+			assert.Equal(t, terr.NewUnAuthorizedError("invalid token"), err)
+		}
+	})
+}
+
+func TestRemoveOtherSessions(t *testing.T) {
+	mockRefreshTokenMeta := &auth.TokenMetadata{UserID: "some-user-id", Type: auth.RefreshTokenType, Token: "some-token"}
+	mockSessions := []auth.SecurityToken{
+		{ID: "some-id", UserID: "some-user-id", FamilyID: "some-session-id"},
+		{ID: "some-other-id", UserID: "some-user-id", FamilyID: "some-other-session-id"},
+	}
+
+	t.Run("it should keep the current session", func(t *testing.T) {
+		stuc, stucDeps := genSecurityTokenUseCase()
+		stucDeps.securityTokenRepository.
+			On("GetTokenByMetadata", mock.Anything, hashTokenMetadata(mockRefreshTokenMeta)).
+			Return(mockSessions[0], nil)
+		stucDeps.securityTokenRepository.
+			On("GetTokensByUserID", mock.Anything, "some-user-id", auth.RefreshTokenType).
+			Return(mockSessions, nil)
+		stucDeps.securityTokenRepository.
+			On("RemoveTokenFamily", mock.Anything, "some-user-id", "some-other-session-id").
+			Return(nil)
+
+		err := stuc.RemoveOtherSessions(context.Background(), "some-user-id", mockRefreshTokenMeta)
+
+		if assert.NoError(t, err) {
+			stucDeps.securityTokenRepository.AssertNotCalled(t, "RemoveTokenFamily", mock.Anything, "some-user-id", "some-session-id")
+			stucDeps.securityTokenRepository.AssertExpectations(t)
+		}
+	})
+
+	t.Run("it should remove every session without a current session", func(t *testing.T) {
+		stuc, stucDeps := genSecurityTokenUseCase()
+		stucDeps.securityTokenRepository.
+			On("GetTokensByUserID", mock.Anything, "some-user-id", auth.RefreshTokenType).
+			Return(mockSessions, nil)
+		stucDeps.securityTokenRepository.
+			On("RemoveTokenFamily", mock.Anything, "some-user-id", "some-session-id").
+			Return(nil)
+		stucDeps.securityTokenRepository.
+			On("RemoveTokenFamily", mock.Anything, "some-user-id", "some-other-session-id").
+			Return(terr.NewNotFoundError("token not found"))
+
+		err := stuc.RemoveOtherSessions(context.Background(), "some-user-id", nil)
+
+		if assert.NoError(t, err) {
+			stucDeps.securityTokenRepository.AssertExpectations(t)
+		}
+	})
+
+	t.Run("it should not keep a session of another user", func(t *testing.T) {
+		stuc, stucDeps := genSecurityTokenUseCase()
+		stucDeps.securityTokenRepository.
+			On("GetTokenByMetadata", mock.Anything, mock.Anything).
+			Return(auth.SecurityToken{UserID: "some-other-user-id", FamilyID: "some-session-id"}, nil)
+		stucDeps.securityTokenRepository.
+			On("GetTokensByUserID", mock.Anything, "some-user-id", auth.RefreshTokenType).
+			Return(mockSessions, nil)
+		stucDeps.securityTokenRepository.
+			On("RemoveTokenFamily", mock.Anything, "some-user-id", mock.AnythingOfType("string")).
+			Return(nil)
+
+		err := stuc.RemoveOtherSessions(context.Background(), "some-user-id", mockRefreshTokenMeta)
+
+		if assert.NoError(t, err) {
+			stucDeps.securityTokenRepository.AssertNumberOfCalls(t, "RemoveTokenFamily", 2)
+		}
+	})
+
+	t.Run("it should return an error", func(t *testing.T) {
+		stuc, stucDeps := genSecurityTokenUseCase()
+		mockError := errors.New("some error")
+		stucDeps.securityTokenRepository.
+			On("GetTokensByUserID", mock.Anything, "some-user-id", auth.RefreshTokenType).
+			Return(mockSessions, nil)
+		stucDeps.securityTokenRepository.
+			On("RemoveTokenFamily", mock.Anything, "some-user-id", mock.AnythingOfType("string")).
+			Return(mockError)
+
+		err := stuc.RemoveOtherSessions(context.Background(), "some-user-id", nil)
+
+		if assert.Error(t, err) {
+			assert.Equal(t, mockError, err)
+		}
+	})
+
+	t.Run("it should return an error", func(t *testing.T) {
+		stuc, stucDeps := genSecurityTokenUseCase()
+		mockError := errors.New("some error")
+		stucDeps.securityTokenRepository.
+			On("GetTokensByUserID", mock.Anything, "some-user-id", auth.RefreshTokenType).
+			Return(nil, mockError)
+
+		err := stuc.RemoveOtherSessions(context.Background(), "some-user-id", nil)
+
+		if assert.Error(t, err) {
+			assert.Equal(t, mockError, err)
+		}
+	})
+}
+
+func TestRemoveOneTimeTokens(t *testing.T) {
+	t.Run("it should succeed", func(t *testing.T) {
+		stuc, stucDeps := genSecurityTokenUseCase()
+		stucDeps.securityTokenRepository.
+			On("RemoveTokenByMetadata", mock.Anything, &auth.TokenMetadata{UserID: "some-user-id", Type: auth.EmailVerificationTokenType}).
+			Return(nil)
+
+		err := stuc.RemoveOneTimeTokens(context.Background(), "some-user-id", auth.EmailVerificationTokenType)
+
+		assert.NoError(t, err)
+	})
+}
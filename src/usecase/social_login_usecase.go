@@ -0,0 +1,170 @@
+package usecase
+
+import (
+	"context"
+	"crypto/subtle"
+	"github.com/google/uuid"
+	"sherman/src/app/utils/terr"
+	"sherman/src/app/utils/tokens"
+	"sherman/src/domain/auth"
+	"sherman/src/service/oidc"
+	"sherman/src/service/security"
+	"strings"
+	"time"
+)
+
+// oidcAuthRequestDuration time given to a user to sign in with a provider
+const oidcAuthRequestDuration = time.Minute * time.Duration(10)
+
+// SocialLoginUseCase implementation of auth.SocialLoginUseCase
+type socialLoginUseCase struct {
+	identityRepo auth.UserIdentityRepository
+	userRepo     auth.UserRepository
+	oidc         oidc.OIDC
+	security     security.Security
+}
+
+// NewSocialLoginUseCase constructor
+func NewSocialLoginUseCase(
+	uir auth.UserIdentityRepository,
+	ur auth.UserRepository,
+	os oidc.OIDC,
+	ss security.Security,
+) auth.SocialLoginUseCase {
+	return &socialLoginUseCase{
+		identityRepo: uir,
+		userRepo:     ur,
+		oidc:         os,
+		security:     ss,
+	}
+}
+
+// StartLogin starts an authorization code request to a provider
+func (uc *socialLoginUseCase) StartLogin(ctx context.Context, provider string) (auth.OIDCAuthRequest, error) {
+	authRequest, err := uc.oidc.NewAuthRequest(provider)
+	if err != nil {
+		return auth.OIDCAuthRequest{}, err
+	}
+
+	authRequest.ExpiresAt = time.Now().Add(oidcAuthRequestDuration)
+	return authRequest, nil
+}
+
+// CompleteLogin exchanges the authorization code of a started request for the ID token of the provider,
+// and returns the user linked to its subject, an unknown subject is linked to the user of its email
+// address, created when missing, only when the provider verified the address
+func (uc *socialLoginUseCase) CompleteLogin(ctx context.Context, authRequest *auth.OIDCAuthRequest, provider, state, code string) (string, error) {
+	if authRequest.Provider != provider || subtle.ConstantTimeCompare([]byte(authRequest.State), []byte(state)) != 1 {
+		return "", terr.NewUnAuthorizedError("invalid login state")
+	}
+	if time.Now().After(authRequest.ExpiresAt) {
+		return "", terr.NewUnAuthorizedError("login request expired")
+	}
+
+	claims, err := uc.oidc.Exchange(provider, code, authRequest.CodeVerifier, authRequest.Nonce)
+	if err != nil {
+		return "", err
+	}
+
+	identity, err := uc.identityRepo.GetIdentity(ctx, provider, claims.Subject)
+	if err == nil {
+		user, err := uc.userRepo.GetUserByID(ctx, identity.UserID)
+		if err != nil {
+			return "", err
+		}
+		if !user.Active {
+			return "", terr.NewInactiveUserError("user account deactivated")
+		}
+		return user.ID, nil
+	}
+	if _, ok := err.(*terr.NotFoundError); !ok {
+		return "", err
+	}
+
+	if claims.Email == "" || !claims.EmailVerified {
+		return "", terr.NewUnverifiedEmailError("provider email address not verified")
+	}
+
+	user, err := uc.linkUser(ctx, &claims)
+	if err != nil {
+		return "", err
+	}
+
+	err = uc.identityRepo.CreateIdentity(ctx, &auth.UserIdentity{
+		ID:           uuid.New().String(),
+		UserID:       user.ID,
+		Provider:     provider,
+		Subject:      claims.Subject,
+		EmailAddress: claims.Email,
+		CreatedAt:    time.Now(),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return user.ID, nil
+}
+
+// linkUser gets or creates the user of a verified provider email address, a user that never verified
+// the address is taken over: it is verified and its password, set by whoever registered it, is replaced,
+// an inactive user is never linked nor taken over, a new registration is activated by verifying its email first
+func (uc *socialLoginUseCase) linkUser(ctx context.Context, claims *auth.OIDCClaims) (auth.User, error) {
+	user, err := uc.userRepo.GetUserByEmail(ctx, claims.Email)
+	if _, ok := err.(*terr.NotFoundError); err != nil && !ok {
+		return auth.User{}, err
+	}
+
+	found := err == nil
+
+	if found && !user.Active {
+		return auth.User{}, terr.NewInactiveUserError("user account deactivated")
+	}
+	if found && user.EmailVerified {
+		return user, nil
+	}
+
+	password, err := uc.unusablePassword()
+	if err != nil {
+		return auth.User{}, err
+	}
+
+	if found {
+		user.Password = password
+		user.EmailVerified = true
+		user.UpdatedAt = time.Now()
+		return user, uc.userRepo.UpdateUser(ctx, &user)
+	}
+
+	user = auth.User{
+		ID:            uuid.New().String(),
+		FirstName:     claims.GivenName,
+		LastName:      claims.FamilyName,
+		EmailAddress:  claims.Email,
+		Password:      password,
+		Active:        true,
+		EmailVerified: true,
+		CreatedAt:     time.Now(),
+		UpdatedAt:     time.Now(),
+	}
+	if user.FirstName == "" && user.LastName == "" {
+		user.FirstName = claims.Name
+	}
+	if user.FirstName == "" {
+		user.FirstName = strings.SplitN(claims.Email, "@", 2)[0]
+	}
+	return user, uc.userRepo.CreateUser(ctx, &user)
+}
+
+// unusablePassword hashes a random password nobody knows, the user can set one with a password reset
+func (uc *socialLoginUseCase) unusablePassword() (string, error) {
+	password, err := tokens.Random()
+	if err != nil {
+		return "", err
+	}
+
+	hashPassword, err := uc.security.Hash(password)
+	if err != nil {
+		return "", err
+	}
+	return string(hashPassword), nil
+}
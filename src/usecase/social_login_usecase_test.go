@@ -0,0 +1,278 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"sherman/mocks"
+	_ "sherman/src/app/testing"
+	"sherman/src/app/utils/terr"
+	"sherman/src/domain/auth"
+	"testing"
+	"time"
+)
+
+type socialLoginUseCaseMockDeps struct {
+	userIdentityRepository *mocks.UserIdentityRepository
+	userRepository         *mocks.UserRepository
+	oidcService            *mocks.OIDC
+	securityService        *mocks.Security
+}
+
+func genSocialLoginUseCase() (auth.SocialLoginUseCase, socialLoginUseCaseMockDeps) {
+	sluDeps := socialLoginUseCaseMockDeps{
+		userIdentityRepository: new(mocks.UserIdentityRepository),
+		userRepository:         new(mocks.UserRepository),
+		oidcService:            new(mocks.OIDC),
+		securityService:        new(mocks.Security),
+	}
+
+	sluc := NewSocialLoginUseCase(
+		sluDeps.userIdentityRepository,
+		sluDeps.userRepository,
+		sluDeps.oidcService,
+		sluDeps.securityService,
+	)
+
+	return sluc, sluDeps
+}
+
+func genOIDCAuthRequest() *auth.OIDCAuthRequest {
+	return &auth.OIDCAuthRequest{
+		Provider:     "google",
+		State:        "some-state",
+		Nonce:        "some-nonce",
+		CodeVerifier: "some-verifier",
+		ExpiresAt:    time.Now().Add(time.Minute),
+	}
+}
+
+func TestStartLogin(t *testing.T) {
+	t.Run("it should succeed", func(t *testing.T) {
+		sluc, sluDeps := genSocialLoginUseCase()
+		sluDeps.oidcService.
+			On("NewAuthRequest", "google").
+			Return(auth.OIDCAuthRequest{Provider: "google", State: "some-state", URL: "https://some.url"}, nil)
+
+		authRequest, err := sluc.StartLogin(context.Background(), "google")
+
+		if assert.NoError(t, err) {
+			assert.Equal(t, "some-state", authRequest.State)
+			assert.Equal(t, "https://some.url", authRequest.URL)
+			assert.WithinDuration(t, time.Now().Add(oidcAuthRequestDuration), authRequest.ExpiresAt, time.Second)
+		}
+	})
+
+	t.Run("it should return error", func(t *testing.T) {
+		sluc, sluDeps := genSocialLoginUseCase()
+		sluDeps.oidcService.
+			On("NewAuthRequest", "unknown").
+			Return(auth.OIDCAuthRequest{}, terr.NewNotFoundError("oidc provider not found"))
+
+		_, err := sluc.StartLogin(context.Background(), "unknown")
+
+		assert.Equal(t, terr.NewNotFoundError("oidc provider not found"), err)
+	})
+}
+
+func TestCompleteLogin(t *testing.T) {
+	claims := auth.OIDCClaims{
+		Subject:       "some-subject",
+		Email:         "some@email.com",
+		EmailVerified: true,
+		GivenName:     "first",
+		FamilyName:    "last",
+	}
+	identityNotFound := terr.NewNotFoundError("user identity not found")
+	userNotFound := terr.NewNotFoundError("user not found")
+	isIdentity := mock.MatchedBy(func(identity *auth.UserIdentity) bool {
+		return identity.UserID == "some-user-id" &&
+			identity.Provider == "google" &&
+			identity.Subject == "some-subject" &&
+			identity.EmailAddress == "some@email.com"
+	})
+
+	t.Run("it should succeed with a linked identity", func(t *testing.T) {
+		sluc, sluDeps := genSocialLoginUseCase()
+		sluDeps.oidcService.
+			On("Exchange", "google", "some-code", "some-verifier", "some-nonce").
+			Return(claims, nil)
+		sluDeps.userIdentityRepository.
+			On("GetIdentity", mock.Anything, "google", "some-subject").
+			Return(auth.UserIdentity{UserID: "some-user-id"}, nil)
+		sluDeps.userRepository.
+			On("GetUserByID", mock.Anything, "some-user-id").
+			Return(auth.User{ID: "some-user-id", Active: true}, nil)
+
+		userID, err := sluc.CompleteLogin(context.Background(), genOIDCAuthRequest(), "google", "some-state", "some-code")
+
+		if assert.NoError(t, err) {
+			assert.Equal(t, "some-user-id", userID)
+		}
+	})
+
+	t.Run("it should succeed linking a verified user", func(t *testing.T) {
+		sluc, sluDeps := genSocialLoginUseCase()
+		sluDeps.oidcService.On("Exchange", "google", "some-code", "some-verifier", "some-nonce").Return(claims, nil)
+		sluDeps.userIdentityRepository.On("GetIdentity", mock.Anything, "google", "some-subject").Return(auth.UserIdentity{}, identityNotFound)
+		sluDeps.userRepository.
+			On("GetUserByEmail", mock.Anything, "some@email.com").
+			Return(auth.User{ID: "some-user-id", Active: true, EmailVerified: true}, nil)
+		sluDeps.userIdentityRepository.On("CreateIdentity", mock.Anything, isIdentity).Return(nil)
+
+		userID, err := sluc.CompleteLogin(context.Background(), genOIDCAuthRequest(), "google", "some-state", "some-code")
+
+		if assert.NoError(t, err) {
+			assert.Equal(t, "some-user-id", userID)
+			sluDeps.userRepository.AssertNotCalled(t, "UpdateUser", mock.Anything, mock.Anything)
+		}
+	})
+
+	t.Run("it should succeed taking over an unverified user", func(t *testing.T) {
+		sluc, sluDeps := genSocialLoginUseCase()
+		sluDeps.oidcService.On("Exchange", "google", "some-code", "some-verifier", "some-nonce").Return(claims, nil)
+		sluDeps.userIdentityRepository.On("GetIdentity", mock.Anything, "google", "some-subject").Return(auth.UserIdentity{}, identityNotFound)
+		sluDeps.userRepository.
+			On("GetUserByEmail", mock.Anything, "some@email.com").
+			Return(auth.User{ID: "some-user-id", Password: "registered-hash", Active: true}, nil)
+		sluDeps.securityService.On("Hash", mock.Anything).Return([]byte("unusable-hash"), nil)
+		sluDeps.userRepository.
+			On("UpdateUser", mock.Anything, mock.MatchedBy(func(user *auth.User) bool {
+				return user.ID == "some-user-id" && user.Password == "unusable-hash" && user.Active && user.EmailVerified
+			})).
+			Return(nil)
+		sluDeps.userIdentityRepository.On("CreateIdentity", mock.Anything, isIdentity).Return(nil)
+
+		userID, err := sluc.CompleteLogin(context.Background(), genOIDCAuthRequest(), "google", "some-state", "some-code")
+
+		if assert.NoError(t, err) {
+			assert.Equal(t, "some-user-id", userID)
+		}
+	})
+
+	t.Run("it should succeed creating a user", func(t *testing.T) {
+		var createdUser *auth.User
+		sluc, sluDeps := genSocialLoginUseCase()
+		sluDeps.oidcService.On("Exchange", "google", "some-code", "some-verifier", "some-nonce").Return(claims, nil)
+		sluDeps.userIdentityRepository.On("GetIdentity", mock.Anything, "google", "some-subject").Return(auth.UserIdentity{}, identityNotFound)
+		sluDeps.userRepository.On("GetUserByEmail", mock.Anything, "some@email.com").Return(auth.User{}, userNotFound)
+		sluDeps.securityService.On("Hash", mock.Anything).Return([]byte("unusable-hash"), nil)
+		sluDeps.userRepository.
+			On("CreateUser", mock.Anything, mock.AnythingOfType("*auth.User")).
+			Run(func(args mock.Arguments) { createdUser = args.Get(1).(*auth.User) }).
+			Return(nil)
+		sluDeps.userIdentityRepository.
+			On("CreateIdentity", mock.Anything, mock.MatchedBy(func(identity *auth.UserIdentity) bool {
+				return identity.UserID == createdUser.ID && identity.Subject == "some-subject"
+			})).
+			Return(nil)
+
+		userID, err := sluc.CompleteLogin(context.Background(), genOIDCAuthRequest(), "google", "some-state", "some-code")
+
+		if assert.NoError(t, err) {
+			assert.Equal(t, createdUser.ID, userID)
+			assert.Equal(t, "first", createdUser.FirstName)
+			assert.Equal(t, "last", createdUser.LastName)
+			assert.Equal(t, "some@email.com", createdUser.EmailAddress)
+			assert.Equal(t, "unusable-hash", createdUser.Password)
+			assert.True(t, createdUser.Active)
+			assert.True(t, createdUser.EmailVerified)
+		}
+	})
+
+	t.Run("it should return error on state mismatch", func(t *testing.T) {
+		sluc, sluDeps := genSocialLoginUseCase()
+
+		_, err := sluc.CompleteLogin(context.Background(), genOIDCAuthRequest(), "google", "other-state", "some-code")
+
+		assert.Equal(t, terr.NewUnAuthorizedError("invalid login state"), err)
+		sluDeps.oidcService.AssertNotCalled(t, "Exchange", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("it should return error on provider mismatch", func(t *testing.T) {
+		sluc, _ := genSocialLoginUseCase()
+
+		_, err := sluc.CompleteLogin(context.Background(), genOIDCAuthRequest(), "okta", "some-state", "some-code")
+
+		assert.Equal(t, terr.NewUnAuthorizedError("invalid login state"), err)
+	})
+
+	t.Run("it should return error on expired request", func(t *testing.T) {
+		sluc, _ := genSocialLoginUseCase()
+		authRequest := genOIDCAuthRequest()
+		authRequest.ExpiresAt = time.Now().Add(-time.Second)
+
+		_, err := sluc.CompleteLogin(context.Background(), authRequest, "google", "some-state", "some-code")
+
+		assert.Equal(t, terr.NewUnAuthorizedError("login request expired"), err)
+	})
+
+	t.Run("it should return error on exchange failure", func(t *testing.T) {
+		sluc, sluDeps := genSocialLoginUseCase()
+		sluDeps.oidcService.
+			On("Exchange", "google", "some-code", "some-verifier", "some-nonce").
+			Return(auth.OIDCClaims{}, terr.NewUnAuthorizedError("invalid id token nonce"))
+
+		_, err := sluc.CompleteLogin(context.Background(), genOIDCAuthRequest(), "google", "some-state", "some-code")
+
+		assert.Equal(t, terr.NewUnAuthorizedError("invalid id token nonce"), err)
+	})
+
+	t.Run("it should return error on unverified provider email", func(t *testing.T) {
+		unverifiedClaims := claims
+		unverifiedClaims.EmailVerified = false
+		sluc, sluDeps := genSocialLoginUseCase()
+		sluDeps.oidcService.
+			On("Exchange", "google", "some-code", "some-verifier", "some-nonce").
+			Return(unverifiedClaims, nil)
+		sluDeps.userIdentityRepository.On("GetIdentity", mock.Anything, "google", "some-subject").Return(auth.UserIdentity{}, identityNotFound)
+
+		_, err := sluc.CompleteLogin(context.Background(), genOIDCAuthRequest(), "google", "some-state", "some-code")
+
+		assert.Equal(t, terr.NewUnverifiedEmailError("provider email address not verified"), err)
+		sluDeps.userRepository.AssertNotCalled(t, "GetUserByEmail", mock.Anything, mock.Anything)
+	})
+
+	t.Run("it should return error on deactivated user", func(t *testing.T) {
+		sluc, sluDeps := genSocialLoginUseCase()
+		sluDeps.oidcService.On("Exchange", "google", "some-code", "some-verifier", "some-nonce").Return(claims, nil)
+		sluDeps.userIdentityRepository.
+			On("GetIdentity", mock.Anything, "google", "some-subject").
+			Return(auth.UserIdentity{UserID: "some-user-id"}, nil)
+		sluDeps.userRepository.
+			On("GetUserByID", mock.Anything, "some-user-id").
+			Return(auth.User{ID: "some-user-id", Active: false, EmailVerified: true}, nil)
+
+		_, err := sluc.CompleteLogin(context.Background(), genOIDCAuthRequest(), "google", "some-state", "some-code")
+
+		assert.Equal(t, terr.NewInactiveUserError("user account deactivated"), err)
+	})
+
+	t.Run("it should not take over a deactivated unverified user", func(t *testing.T) {
+		sluc, sluDeps := genSocialLoginUseCase()
+		sluDeps.oidcService.On("Exchange", "google", "some-code", "some-verifier", "some-nonce").Return(claims, nil)
+		sluDeps.userIdentityRepository.On("GetIdentity", mock.Anything, "google", "some-subject").Return(auth.UserIdentity{}, identityNotFound)
+		sluDeps.userRepository.
+			On("GetUserByEmail", mock.Anything, "some@email.com").
+			Return(auth.User{ID: "some-user-id", Password: "registered-hash", Active: false}, nil)
+
+		_, err := sluc.CompleteLogin(context.Background(), genOIDCAuthRequest(), "google", "some-state", "some-code")
+
+		assert.Equal(t, terr.NewInactiveUserError("user account deactivated"), err)
+		sluDeps.userRepository.AssertNotCalled(t, "UpdateUser", mock.Anything, mock.Anything)
+		sluDeps.userIdentityRepository.AssertNotCalled(t, "CreateIdentity", mock.Anything, mock.Anything)
+	})
+
+	t.Run("it should return error on datastore failure", func(t *testing.T) {
+		sluc, sluDeps := genSocialLoginUseCase()
+		sluDeps.oidcService.On("Exchange", "google", "some-code", "some-verifier", "some-nonce").Return(claims, nil)
+		sluDeps.userIdentityRepository.
+			On("GetIdentity", mock.Anything, "google", "some-subject").
+			Return(auth.UserIdentity{}, errors.New("some error"))
+
+		_, err := sluc.CompleteLogin(context.Background(), genOIDCAuthRequest(), "google", "some-state", "some-code")
+
+		assert.EqualError(t, err, "some error")
+	})
+}
@@ -0,0 +1,294 @@
+package usecase
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base32"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+	"net/url"
+	"sherman/src/app/config"
+	"sherman/src/app/utils/terr"
+	"sherman/src/domain/auth"
+	"sherman/src/service/security"
+	"strings"
+	"time"
+)
+
+const (
+	// mfaPendingTokenDuration validity of the tokens of logins waiting for a second factor
+	mfaPendingTokenDuration = time.Minute * time.Duration(5)
+	// recoveryCodeCount number of recovery codes generated when two factor authentication is enabled
+	recoveryCodeCount = 10
+)
+
+// TwoFactorUseCase implementation of auth.TwoFactorUseCase
+type twoFactorUseCase struct {
+	twoFactorRepo        auth.TwoFactorRepository
+	userRepo             auth.UserRepository
+	securityTokenUseCase auth.SecurityTokenUseCase
+	loginAttemptUseCase  auth.LoginAttemptUseCase
+	security             security.Security
+	issuer               string
+}
+
+// NewTwoFactorUseCase constructor
+func NewTwoFactorUseCase(
+	tfr auth.TwoFactorRepository,
+	ur auth.UserRepository,
+	stuc auth.SecurityTokenUseCase,
+	lauc auth.LoginAttemptUseCase,
+	ss security.Security,
+	cfg *config.GlobalConfig,
+) auth.TwoFactorUseCase {
+	return &twoFactorUseCase{
+		twoFactorRepo:        tfr,
+		userRepo:             ur,
+		securityTokenUseCase: stuc,
+		loginAttemptUseCase:  lauc,
+		security:             ss,
+		issuer:               cfg.MFA.Issuer,
+	}
+}
+
+// Setup generates a new TOTP secret for a user, replacing any unconfirmed one, the authenticator
+// is only enforced once confirmed
+func (uc *twoFactorUseCase) Setup(ctx context.Context, userID string) (auth.TwoFactorSetup, error) {
+	twoFactor, err := uc.twoFactorRepo.GetTwoFactor(ctx, userID)
+	if err == nil && twoFactor.Enabled {
+		return auth.TwoFactorSetup{}, terr.NewDuplicateEntryError("two factor authentication already enabled")
+	}
+	if _, ok := err.(*terr.NotFoundError); err != nil && !ok {
+		return auth.TwoFactorSetup{}, err
+	}
+
+	user, err := uc.userRepo.GetUserByID(ctx, userID)
+	if err != nil {
+		return auth.TwoFactorSetup{}, err
+	}
+
+	secret, err := uc.security.GenTOTPSecret()
+	if err != nil {
+		return auth.TwoFactorSetup{}, err
+	}
+	encryptedSecret, err := uc.security.Encrypt(secret)
+	if err != nil {
+		return auth.TwoFactorSetup{}, err
+	}
+
+	err = uc.twoFactorRepo.CreateOrUpdateTwoFactor(ctx, &auth.TwoFactor{
+		UserID:    userID,
+		Secret:    encryptedSecret,
+		Enabled:   false,
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	})
+	if err != nil {
+		return auth.TwoFactorSetup{}, err
+	}
+
+	return auth.TwoFactorSetup{
+		Secret: secret,
+		URI:    uc.otpauthURI(user.EmailAddress, secret),
+	}, nil
+}
+
+// Confirm enables the authenticator of a user with a code it generated and returns new recovery codes,
+// they are only returned once
+func (uc *twoFactorUseCase) Confirm(ctx context.Context, userID, code string) ([]string, error) {
+	twoFactor, err := uc.twoFactorRepo.GetTwoFactor(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if twoFactor.Enabled {
+		return nil, terr.NewDuplicateEntryError("two factor authentication already enabled")
+	}
+
+	var step int64
+	err = uc.limitCodeAttempts(ctx, userID, func() error {
+		var err error
+		step, err = uc.validateTOTP(&twoFactor, code)
+		if err != nil {
+			return err
+		}
+		// claims the period of the code before enabling, so that concurrent confirmations can't replay it
+		return uc.twoFactorRepo.UpdateTwoFactorLastUsedStep(ctx, userID, step, time.Now())
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	twoFactor.Enabled = true
+	twoFactor.LastUsedStep = step
+	twoFactor.UpdatedAt = time.Now()
+	if err := uc.twoFactorRepo.CreateOrUpdateTwoFactor(ctx, &twoFactor); err != nil {
+		return nil, err
+	}
+
+	return uc.genRecoveryCodes(ctx, userID)
+}
+
+// Disable removes the authenticator and the recovery codes of a user, an enabled authenticator
+// requires one of its codes or a recovery code
+func (uc *twoFactorUseCase) Disable(ctx context.Context, userID, code string) error {
+	twoFactor, err := uc.twoFactorRepo.GetTwoFactor(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	if twoFactor.Enabled {
+		err := uc.limitCodeAttempts(ctx, userID, func() error {
+			return uc.verifyCode(ctx, &twoFactor, code)
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return uc.twoFactorRepo.RemoveTwoFactor(ctx, userID)
+}
+
+// IsEnabled checks if a user has a confirmed authenticator
+func (uc *twoFactorUseCase) IsEnabled(ctx context.Context, userID string) (bool, error) {
+	twoFactor, err := uc.twoFactorRepo.GetTwoFactor(ctx, userID)
+	if err != nil {
+		if _, ok := err.(*terr.NotFoundError); ok {
+			return false, nil
+		}
+		return false, err
+	}
+	return twoFactor.Enabled, nil
+}
+
+// GenPendingToken generates the single use token of a login waiting for a second factor
+func (uc *twoFactorUseCase) GenPendingToken(ctx context.Context, userID string) (auth.SecurityToken, error) {
+	return uc.securityTokenUseCase.GenOneTimeToken(ctx, userID, auth.MFAPendingTokenType, mfaPendingTokenDuration)
+}
+
+// VerifyLogin consumes the pending token of a login and verifies its second factor, either a TOTP code
+// or a recovery code, and returns the id of the user, a wrong code consumes the pending token too so
+// that codes can only be guessed thru the password login
+func (uc *twoFactorUseCase) VerifyLogin(ctx context.Context, pendingToken, code string) (string, error) {
+	tokenMetadata, err := uc.securityTokenUseCase.ConsumeOneTimeToken(ctx, pendingToken, auth.MFAPendingTokenType)
+	if err != nil {
+		return "", err
+	}
+
+	twoFactor, err := uc.twoFactorRepo.GetTwoFactor(ctx, tokenMetadata.UserID)
+	if err != nil {
+		if _, ok := err.(*terr.NotFoundError); ok {
+			return "", terr.NewUnAuthorizedError("two factor authentication not enabled")
+		}
+		return "", err
+	}
+	if !twoFactor.Enabled {
+		return "", terr.NewUnAuthorizedError("two factor authentication not enabled")
+	}
+
+	err = uc.limitCodeAttempts(ctx, tokenMetadata.UserID, func() error {
+		return uc.verifyCode(ctx, &twoFactor, code)
+	})
+	if err != nil {
+		return "", err
+	}
+	return tokenMetadata.UserID, nil
+}
+
+// limitCodeAttempts runs the verification of a code of a user unless its codes are locked, wrong codes
+// are counted per user so that they can't be guessed across sessions, a terr.TooManyAttemptsError is
+// returned while locked
+func (uc *twoFactorUseCase) limitCodeAttempts(ctx context.Context, userID string, verify func() error) error {
+	if err := uc.loginAttemptUseCase.CheckTwoFactor(ctx, userID); err != nil {
+		return err
+	}
+
+	err := verify()
+	if _, ok := err.(*terr.UnAuthorizedError); ok {
+		if err := uc.loginAttemptUseCase.RecordFailedTwoFactor(ctx, userID); err != nil {
+			log.Error().Msg(err.Error())
+		}
+		return err
+	}
+	if err != nil {
+		return err
+	}
+
+	// forgetting the wrong codes is best effort, it must not fail a verified code
+	if err := uc.loginAttemptUseCase.RecordSuccessfulTwoFactor(ctx, userID); err != nil {
+		log.Error().Msg(err.Error())
+	}
+	return nil
+}
+
+// verifyCode verifies a TOTP code, that can't be used twice, or consumes a recovery code
+func (uc *twoFactorUseCase) verifyCode(ctx context.Context, twoFactor *auth.TwoFactor, code string) error {
+	step, err := uc.validateTOTP(twoFactor, code)
+	if err == nil {
+		return uc.twoFactorRepo.UpdateTwoFactorLastUsedStep(ctx, twoFactor.UserID, step, time.Now())
+	}
+	if _, ok := err.(*terr.UnAuthorizedError); !ok {
+		return err
+	}
+
+	err = uc.twoFactorRepo.RemoveRecoveryCode(ctx, twoFactor.UserID, hashToken(normalizeRecoveryCode(code)))
+	if _, ok := err.(*terr.NotFoundError); ok {
+		return terr.NewUnAuthorizedError("invalid code")
+	}
+	return err
+}
+
+// validateTOTP validates a TOTP code of the authenticator and returns its period
+func (uc *twoFactorUseCase) validateTOTP(twoFactor *auth.TwoFactor, code string) (int64, error) {
+	secret, err := uc.security.Decrypt(twoFactor.Secret)
+	if err != nil {
+		return 0, err
+	}
+
+	step, ok := uc.security.ValidateTOTP(secret, strings.TrimSpace(code), time.Now())
+	if !ok || step <= twoFactor.LastUsedStep {
+		return 0, terr.NewUnAuthorizedError("invalid code")
+	}
+	return step, nil
+}
+
+// genRecoveryCodes generates the recovery codes of a user, replacing the previous ones, only their hashes are persisted
+func (uc *twoFactorUseCase) genRecoveryCodes(ctx context.Context, userID string) ([]string, error) {
+	codes := make([]string, recoveryCodeCount)
+	recoveryCodes := make([]auth.RecoveryCode, recoveryCodeCount)
+	for i := range codes {
+		random := make([]byte, 7)
+		if _, err := rand.Read(random); err != nil {
+			return nil, err
+		}
+		code := strings.ToLower(base32.StdEncoding.EncodeToString(random))[:10]
+		codes[i] = code[:5] + "-" + code[5:]
+		recoveryCodes[i] = auth.RecoveryCode{
+			ID:        uuid.New().String(),
+			UserID:    userID,
+			CodeHash:  hashToken(code),
+			CreatedAt: time.Now(),
+		}
+	}
+
+	if err := uc.twoFactorRepo.ReplaceRecoveryCodes(ctx, userID, recoveryCodes); err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// otpauthURI returns the otpauth URI of a secret, authenticator apps enroll it from a QR code
+func (uc *twoFactorUseCase) otpauthURI(email, secret string) string {
+	params := url.Values{}
+	params.Set("secret", secret)
+	params.Set("issuer", uc.issuer)
+	params.Set("algorithm", "SHA1")
+	params.Set("digits", "6")
+	params.Set("period", "30")
+
+	label := url.PathEscape(uc.issuer + ":" + email)
+	return "otpauth://totp/" + label + "?" + params.Encode()
+}
+
+// normalizeRecoveryCode lowercases a recovery code and strips its separators
+func normalizeRecoveryCode(code string) string {
+	return strings.ToLower(strings.NewReplacer("-", "", " ", "").Replace(code))
+}
@@ -0,0 +1,378 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"sherman/mocks"
+	"sherman/src/app/config"
+	_ "sherman/src/app/testing"
+	"sherman/src/app/utils/terr"
+	"sherman/src/domain/auth"
+	"strings"
+	"testing"
+	"time"
+)
+
+type twoFactorUseCaseMockDeps struct {
+	twoFactorRepository  *mocks.TwoFactorRepository
+	userRepository       *mocks.UserRepository
+	securityTokenUseCase *mocks.SecurityTokenUseCase
+	loginAttemptUseCase  *mocks.LoginAttemptUseCase
+	securityService      *mocks.Security
+}
+
+func genTwoFactorUseCase() (auth.TwoFactorUseCase, twoFactorUseCaseMockDeps) {
+	tfucDeps := twoFactorUseCaseMockDeps{
+		twoFactorRepository:  new(mocks.TwoFactorRepository),
+		userRepository:       new(mocks.UserRepository),
+		securityTokenUseCase: new(mocks.SecurityTokenUseCase),
+		loginAttemptUseCase:  new(mocks.LoginAttemptUseCase),
+		securityService:      new(mocks.Security),
+	}
+
+	cfg := config.DefaultConfig
+	cfg.MFA.Issuer = "Some Issuer"
+	tfuc := NewTwoFactorUseCase(
+		tfucDeps.twoFactorRepository,
+		tfucDeps.userRepository,
+		tfucDeps.securityTokenUseCase,
+		tfucDeps.loginAttemptUseCase,
+		tfucDeps.securityService,
+		&cfg,
+	)
+
+	return tfuc, tfucDeps
+}
+
+func TestTwoFactorSetup(t *testing.T) {
+	t.Run("it should succeed", func(t *testing.T) {
+		tfuc, tfucDeps := genTwoFactorUseCase()
+		tfucDeps.twoFactorRepository.
+			On("GetTwoFactor", mock.Anything, "some-user-id").
+			Return(auth.TwoFactor{}, terr.NewNotFoundError("two factor authentication not found"))
+		tfucDeps.userRepository.
+			On("GetUserByID", mock.Anything, "some-user-id").
+			Return(auth.User{ID: "some-user-id", EmailAddress: "some@email.com"}, nil)
+		tfucDeps.securityService.On("GenTOTPSecret").Return("SOMESECRET", nil)
+		tfucDeps.securityService.On("Encrypt", "SOMESECRET").Return("encrypted-secret", nil)
+		tfucDeps.twoFactorRepository.
+			On("CreateOrUpdateTwoFactor", mock.Anything, mock.MatchedBy(func(tf *auth.TwoFactor) bool {
+				return tf.UserID == "some-user-id" && tf.Secret == "encrypted-secret" && !tf.Enabled
+			})).
+			Return(nil)
+
+		setup, err := tfuc.Setup(context.Background(), "some-user-id")
+
+		if assert.NoError(t, err) {
+			assert.Equal(t, "SOMESECRET", setup.Secret)
+			assert.Equal(
+				t,
+				"otpauth://totp/Some%20Issuer:some@email.com?algorithm=SHA1&digits=6&issuer=Some+Issuer&period=30&secret=SOMESECRET",
+				setup.URI,
+			)
+		}
+	})
+
+	t.Run("it should return error", func(t *testing.T) {
+		tfuc, tfucDeps := genTwoFactorUseCase()
+		tfucDeps.twoFactorRepository.
+			On("GetTwoFactor", mock.Anything, "some-user-id").
+			Return(auth.TwoFactor{Enabled: true}, nil)
+
+		_, err := tfuc.Setup(context.Background(), "some-user-id")
+
+		assert.IsType(t, &terr.DuplicateEntryError{}, err)
+	})
+
+	t.Run("it should return error", func(t *testing.T) {
+		tfuc, tfucDeps := genTwoFactorUseCase()
+		tfucDeps.twoFactorRepository.
+			On("GetTwoFactor", mock.Anything, "some-user-id").
+			Return(auth.TwoFactor{}, errors.New("some error"))
+
+		_, err := tfuc.Setup(context.Background(), "some-user-id")
+
+		assert.EqualError(t, err, "some error")
+	})
+}
+
+func TestTwoFactorConfirm(t *testing.T) {
+	mockTwoFactor := auth.TwoFactor{UserID: "some-user-id", Secret: "encrypted-secret", LastUsedStep: 0}
+
+	t.Run("it should succeed", func(t *testing.T) {
+		tfuc, tfucDeps := genTwoFactorUseCase()
+		tfucDeps.twoFactorRepository.On("GetTwoFactor", mock.Anything, "some-user-id").Return(mockTwoFactor, nil)
+		tfucDeps.loginAttemptUseCase.On("CheckTwoFactor", mock.Anything, "some-user-id").Return(nil)
+		tfucDeps.securityService.On("Decrypt", "encrypted-secret").Return("SOMESECRET", nil)
+		tfucDeps.securityService.
+			On("ValidateTOTP", "SOMESECRET", "123456", mock.Anything).
+			Return(int64(42), true)
+		tfucDeps.twoFactorRepository.
+			On("UpdateTwoFactorLastUsedStep", mock.Anything, "some-user-id", int64(42), mock.Anything).
+			Return(nil)
+		tfucDeps.loginAttemptUseCase.On("RecordSuccessfulTwoFactor", mock.Anything, "some-user-id").Return(nil)
+		tfucDeps.twoFactorRepository.
+			On("CreateOrUpdateTwoFactor", mock.Anything, mock.MatchedBy(func(tf *auth.TwoFactor) bool {
+				return tf.Enabled && tf.LastUsedStep == 42
+			})).
+			Return(nil)
+		tfucDeps.twoFactorRepository.
+			On("ReplaceRecoveryCodes", mock.Anything, "some-user-id", mock.MatchedBy(func(rcs []auth.RecoveryCode) bool {
+				return len(rcs) == recoveryCodeCount && len(rcs[0].CodeHash) == 64
+			})).
+			Return(nil)
+
+		codes, err := tfuc.Confirm(context.Background(), "some-user-id", " 123456 ")
+
+		if assert.NoError(t, err) {
+			assert.Len(t, codes, recoveryCodeCount)
+			assert.Regexp(t, "^[a-z2-7]{5}-[a-z2-7]{5}$", codes[0])
+			assert.NotEqual(t, codes[0], codes[1])
+		}
+	})
+
+	t.Run("it should return error", func(t *testing.T) {
+		tfuc, tfucDeps := genTwoFactorUseCase()
+		tfucDeps.twoFactorRepository.On("GetTwoFactor", mock.Anything, "some-user-id").Return(mockTwoFactor, nil)
+		tfucDeps.loginAttemptUseCase.On("CheckTwoFactor", mock.Anything, "some-user-id").Return(nil)
+		tfucDeps.securityService.On("Decrypt", "encrypted-secret").Return("SOMESECRET", nil)
+		tfucDeps.securityService.
+			On("ValidateTOTP", "SOMESECRET", "000000", mock.Anything).
+			Return(int64(0), false)
+		tfucDeps.loginAttemptUseCase.On("RecordFailedTwoFactor", mock.Anything, "some-user-id").Return(nil)
+
+		_, err := tfuc.Confirm(context.Background(), "some-user-id", "000000")
+
+		assert.Equal(t, terr.NewUnAuthorizedError("invalid code"), err)
+		tfucDeps.loginAttemptUseCase.AssertCalled(t, "RecordFailedTwoFactor", mock.Anything, "some-user-id")
+		tfucDeps.twoFactorRepository.AssertNotCalled(t, "CreateOrUpdateTwoFactor", mock.Anything, mock.Anything)
+	})
+
+	t.Run("it should reject a replayed code", func(t *testing.T) {
+		tfuc, tfucDeps := genTwoFactorUseCase()
+		tfucDeps.twoFactorRepository.On("GetTwoFactor", mock.Anything, "some-user-id").Return(mockTwoFactor, nil)
+		tfucDeps.loginAttemptUseCase.On("CheckTwoFactor", mock.Anything, "some-user-id").Return(nil)
+		tfucDeps.securityService.On("Decrypt", "encrypted-secret").Return("SOMESECRET", nil)
+		tfucDeps.securityService.
+			On("ValidateTOTP", "SOMESECRET", "123456", mock.Anything).
+			Return(int64(42), true)
+		tfucDeps.twoFactorRepository.
+			On("UpdateTwoFactorLastUsedStep", mock.Anything, "some-user-id", int64(42), mock.Anything).
+			Return(terr.NewUnAuthorizedError("code already used"))
+		tfucDeps.loginAttemptUseCase.On("RecordFailedTwoFactor", mock.Anything, "some-user-id").Return(nil)
+
+		_, err := tfuc.Confirm(context.Background(), "some-user-id", "123456")
+
+		assert.Equal(t, terr.NewUnAuthorizedError("code already used"), err)
+		tfucDeps.twoFactorRepository.AssertNotCalled(t, "CreateOrUpdateTwoFactor", mock.Anything, mock.Anything)
+	})
+
+	t.Run("it should return error", func(t *testing.T) {
+		tfuc, tfucDeps := genTwoFactorUseCase()
+		tfucDeps.twoFactorRepository.On("GetTwoFactor", mock.Anything, "some-user-id").Return(mockTwoFactor, nil)
+		tfucDeps.loginAttemptUseCase.
+			On("CheckTwoFactor", mock.Anything, "some-user-id").
+			Return(terr.NewTooManyAttemptsError("too many failed codes, try again later", time.Minute))
+
+		_, err := tfuc.Confirm(context.Background(), "some-user-id", "123456")
+
+		assert.IsType(t, &terr.TooManyAttemptsError{}, err)
+		tfucDeps.securityService.AssertNotCalled(t, "ValidateTOTP", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("it should return error", func(t *testing.T) {
+		tfuc, tfucDeps := genTwoFactorUseCase()
+		tfucDeps.twoFactorRepository.
+			On("GetTwoFactor", mock.Anything, "some-user-id").
+			Return(auth.TwoFactor{}, terr.NewNotFoundError("two factor authentication not found"))
+
+		_, err := tfuc.Confirm(context.Background(), "some-user-id", "123456")
+
+		assert.IsType(t, &terr.NotFoundError{}, err)
+	})
+}
+
+func TestTwoFactorDisable(t *testing.T) {
+	mockTwoFactor := auth.TwoFactor{UserID: "some-user-id", Secret: "encrypted-secret", Enabled: true, LastUsedStep: 10}
+
+	t.Run("it should succeed", func(t *testing.T) {
+		tfuc, tfucDeps := genTwoFactorUseCase()
+		tfucDeps.twoFactorRepository.On("GetTwoFactor", mock.Anything, "some-user-id").Return(mockTwoFactor, nil)
+		tfucDeps.loginAttemptUseCase.On("CheckTwoFactor", mock.Anything, "some-user-id").Return(nil)
+		tfucDeps.securityService.On("Decrypt", "encrypted-secret").Return("SOMESECRET", nil)
+		tfucDeps.securityService.
+			On("ValidateTOTP", "SOMESECRET", "abcde-fghij", mock.Anything).
+			Return(int64(0), false)
+		tfucDeps.twoFactorRepository.
+			On("RemoveRecoveryCode", mock.Anything, "some-user-id", hashToken("abcdefghij")).
+			Return(nil)
+		tfucDeps.loginAttemptUseCase.On("RecordSuccessfulTwoFactor", mock.Anything, "some-user-id").Return(nil)
+		tfucDeps.twoFactorRepository.On("RemoveTwoFactor", mock.Anything, "some-user-id").Return(nil)
+
+		err := tfuc.Disable(context.Background(), "some-user-id", "abcde-fghij")
+
+		assert.NoError(t, err)
+		tfucDeps.twoFactorRepository.AssertExpectations(t)
+	})
+
+	t.Run("it should return error", func(t *testing.T) {
+		tfuc, tfucDeps := genTwoFactorUseCase()
+		tfucDeps.twoFactorRepository.On("GetTwoFactor", mock.Anything, "some-user-id").Return(mockTwoFactor, nil)
+		tfucDeps.loginAttemptUseCase.On("CheckTwoFactor", mock.Anything, "some-user-id").Return(nil)
+		tfucDeps.securityService.On("Decrypt", "encrypted-secret").Return("SOMESECRET", nil)
+		tfucDeps.securityService.
+			On("ValidateTOTP", "SOMESECRET", "ABCDE-FGHIJ", mock.Anything).
+			Return(int64(0), false)
+		tfucDeps.twoFactorRepository.
+			On("RemoveRecoveryCode", mock.Anything, "some-user-id", hashToken("abcdefghij")).
+			Return(terr.NewNotFoundError("recovery code not found"))
+		tfucDeps.loginAttemptUseCase.On("RecordFailedTwoFactor", mock.Anything, "some-user-id").Return(nil)
+
+		err := tfuc.Disable(context.Background(), "some-user-id", "ABCDE-FGHIJ")
+
+		assert.Equal(t, terr.NewUnAuthorizedError("invalid code"), err)
+		tfucDeps.twoFactorRepository.AssertNotCalled(t, "RemoveTwoFactor", mock.Anything, mock.Anything)
+	})
+}
+
+func TestTwoFactorIsEnabled(t *testing.T) {
+	t.Run("it should succeed", func(t *testing.T) {
+		tfuc, tfucDeps := genTwoFactorUseCase()
+		tfucDeps.twoFactorRepository.
+			On("GetTwoFactor", mock.Anything, "some-user-id").
+			Return(auth.TwoFactor{}, terr.NewNotFoundError("two factor authentication not found"))
+		tfucDeps.twoFactorRepository.
+			On("GetTwoFactor", mock.Anything, "other-user-id").
+			Return(auth.TwoFactor{Enabled: true}, nil)
+
+		enabled, err := tfuc.IsEnabled(context.Background(), "some-user-id")
+		if assert.NoError(t, err) {
+			assert.False(t, enabled)
+		}
+		enabled, err = tfuc.IsEnabled(context.Background(), "other-user-id")
+		if assert.NoError(t, err) {
+			assert.True(t, enabled)
+		}
+	})
+
+	t.Run("it should return error", func(t *testing.T) {
+		tfuc, tfucDeps := genTwoFactorUseCase()
+		tfucDeps.twoFactorRepository.
+			On("GetTwoFactor", mock.Anything, "some-user-id").
+			Return(auth.TwoFactor{}, errors.New("some error"))
+
+		_, err := tfuc.IsEnabled(context.Background(), "some-user-id")
+
+		assert.Error(t, err)
+	})
+}
+
+func TestGenPendingToken(t *testing.T) {
+	tfuc, tfucDeps := genTwoFactorUseCase()
+	tfucDeps.securityTokenUseCase.
+		On("GenOneTimeToken", mock.Anything, "some-user-id", auth.MFAPendingTokenType, mfaPendingTokenDuration).
+		Return(auth.SecurityToken{Token: "some-token"}, nil)
+
+	token, err := tfuc.GenPendingToken(context.Background(), "some-user-id")
+
+	if assert.NoError(t, err) {
+		assert.Equal(t, "some-token", token.Token)
+	}
+}
+
+func TestVerifyLogin(t *testing.T) {
+	mockTwoFactor := auth.TwoFactor{UserID: "some-user-id", Secret: "encrypted-secret", Enabled: true, LastUsedStep: 10}
+
+	t.Run("it should succeed", func(t *testing.T) {
+		tfuc, tfucDeps := genTwoFactorUseCase()
+		tfucDeps.securityTokenUseCase.
+			On("ConsumeOneTimeToken", mock.Anything, "some-token", auth.MFAPendingTokenType).
+			Return(auth.TokenMetadata{UserID: "some-user-id"}, nil)
+		tfucDeps.twoFactorRepository.On("GetTwoFactor", mock.Anything, "some-user-id").Return(mockTwoFactor, nil)
+		tfucDeps.loginAttemptUseCase.On("CheckTwoFactor", mock.Anything, "some-user-id").Return(nil)
+		tfucDeps.securityService.On("Decrypt", "encrypted-secret").Return("SOMESECRET", nil)
+		tfucDeps.securityService.
+			On("ValidateTOTP", "SOMESECRET", "123456", mock.Anything).
+			Return(int64(11), true)
+		tfucDeps.twoFactorRepository.
+			On("UpdateTwoFactorLastUsedStep", mock.Anything, "some-user-id", int64(11), mock.Anything).
+			Return(nil)
+		tfucDeps.loginAttemptUseCase.On("RecordSuccessfulTwoFactor", mock.Anything, "some-user-id").Return(nil)
+
+		userID, err := tfuc.VerifyLogin(context.Background(), "some-token", "123456")
+
+		if assert.NoError(t, err) {
+			assert.Equal(t, "some-user-id", userID)
+		}
+	})
+
+	t.Run("it should reject a replayed code", func(t *testing.T) {
+		tfuc, tfucDeps := genTwoFactorUseCase()
+		tfucDeps.securityTokenUseCase.
+			On("ConsumeOneTimeToken", mock.Anything, "some-token", auth.MFAPendingTokenType).
+			Return(auth.TokenMetadata{UserID: "some-user-id"}, nil)
+		tfucDeps.twoFactorRepository.On("GetTwoFactor", mock.Anything, "some-user-id").Return(mockTwoFactor, nil)
+		tfucDeps.loginAttemptUseCase.On("CheckTwoFactor", mock.Anything, "some-user-id").Return(nil)
+		tfucDeps.securityService.On("Decrypt", "encrypted-secret").Return("SOMESECRET", nil)
+		tfucDeps.securityService.
+			On("ValidateTOTP", "SOMESECRET", "123456", mock.Anything).
+			Return(int64(10), true)
+		tfucDeps.twoFactorRepository.
+			On("RemoveRecoveryCode", mock.Anything, "some-user-id", mock.Anything).
+			Return(terr.NewNotFoundError("recovery code not found"))
+		tfucDeps.loginAttemptUseCase.On("RecordFailedTwoFactor", mock.Anything, "some-user-id").Return(nil)
+
+		_, err := tfuc.VerifyLogin(context.Background(), "some-token", "123456")
+
+		assert.Equal(t, terr.NewUnAuthorizedError("invalid code"), err)
+		tfucDeps.loginAttemptUseCase.AssertCalled(t, "RecordFailedTwoFactor", mock.Anything, "some-user-id")
+	})
+
+	t.Run("it should return error", func(t *testing.T) {
+		tfuc, tfucDeps := genTwoFactorUseCase()
+		tfucDeps.securityTokenUseCase.
+			On("ConsumeOneTimeToken", mock.Anything, "some-token", auth.MFAPendingTokenType).
+			Return(auth.TokenMetadata{UserID: "some-user-id"}, nil)
+		tfucDeps.twoFactorRepository.On("GetTwoFactor", mock.Anything, "some-user-id").Return(mockTwoFactor, nil)
+		tfucDeps.loginAttemptUseCase.
+			On("CheckTwoFactor", mock.Anything, "some-user-id").
+			Return(terr.NewTooManyAttemptsError("too many failed codes, try again later", time.Minute))
+
+		_, err := tfuc.VerifyLogin(context.Background(), "some-token", "123456")
+
+		assert.IsType(t, &terr.TooManyAttemptsError{}, err)
+		tfucDeps.securityService.AssertNotCalled(t, "Decrypt", mock.Anything)
+	})
+
+	t.Run("it should return error", func(t *testing.T) {
+		tfuc, tfucDeps := genTwoFactorUseCase()
+		tfucDeps.securityTokenUseCase.
+			On("ConsumeOneTimeToken", mock.Anything, "some-token", auth.MFAPendingTokenType).
+			Return(auth.TokenMetadata{}, terr.NewUnAuthorizedError("invalid token"))
+
+		_, err := tfuc.VerifyLogin(context.Background(), "some-token", "123456")
+
+		assert.IsType(t, &terr.UnAuthorizedError{}, err)
+	})
+
+	t.Run("it should return error", func(t *testing.T) {
+		tfuc, tfucDeps := genTwoFactorUseCase()
+		tfucDeps.securityTokenUseCase.
+			On("ConsumeOneTimeToken", mock.Anything, "some-token", auth.MFAPendingTokenType).
+			Return(auth.TokenMetadata{UserID: "some-user-id"}, nil)
+		tfucDeps.twoFactorRepository.
+			On("GetTwoFactor", mock.Anything, "some-user-id").
+			Return(auth.TwoFactor{UserID: "some-user-id"}, nil)
+
+		_, err := tfuc.VerifyLogin(context.Background(), "some-token", "123456")
+
+		assert.Equal(t, terr.NewUnAuthorizedError("two factor authentication not enabled"), err)
+	})
+}
+
+func TestNormalizeRecoveryCode(t *testing.T) {
+	assert.Equal(t, "abcdefghij", normalizeRecoveryCode(" ABCDE-fghij"))
+	assert.False(t, strings.Contains(normalizeRecoveryCode("ab-cd ef"), "-"))
+}
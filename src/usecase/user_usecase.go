@@ -1,31 +1,66 @@
 package usecase
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
 	"sherman/src/app/utils/terr"
 	"sherman/src/domain/auth"
+	"sherman/src/service/cache"
+	"sherman/src/service/mailer"
 	"sherman/src/service/security"
 	"time"
 )
 
+const (
+	// emailVerificationTokenDuration validity of email verification tokens
+	emailVerificationTokenDuration = time.Hour * time.Duration(24)
+	// passwordResetTokenDuration validity of password reset tokens
+	passwordResetTokenDuration = time.Minute * time.Duration(30)
+	// activeUserCacheDuration how long an active user is trusted without reading the datastore
+	activeUserCacheDuration = time.Minute
+)
+
 // UserUseCase implementation of auth.UserUseCase
 type userUseCase struct {
-	userRepo auth.UserRepository
-	security security.Security
+	userRepo             auth.UserRepository
+	auditLogRepo         auth.AuditLogRepository
+	unitOfWork           auth.UnitOfWork
+	securityTokenUseCase auth.SecurityTokenUseCase
+	security             security.Security
+	mailer               mailer.Mailer
+	cache                cache.Cache
 }
 
 // NewUserUseCase constructor
-func NewUserUseCase(ur auth.UserRepository, ss security.Security) auth.UserUseCase {
+func NewUserUseCase(
+	ur auth.UserRepository,
+	alr auth.AuditLogRepository,
+	uow auth.UnitOfWork,
+	stuc auth.SecurityTokenUseCase,
+	ss security.Security,
+	ms mailer.Mailer,
+	cs cache.Cache,
+) auth.UserUseCase {
 	return &userUseCase{
-		userRepo: ur,
-		security: ss,
+		userRepo:             ur,
+		auditLogRepo:         alr,
+		unitOfWork:           uow,
+		securityTokenUseCase: stuc,
+		security:             ss,
+		mailer:               ms,
+		cache:                cs,
 	}
 }
 
-// Register creates a user
-func (uc *userUseCase) Register(user *auth.User) error {
+// Register creates an inactive user with its email verification token, both or neither are stored,
+// and mails it the token, a failed delivery doesn't fail the registration since the token can be resent
+func (uc *userUseCase) Register(ctx context.Context, user *auth.User) error {
 	user.ID = uuid.New().String()
-	user.Active = true
+	user.Active = false
+	user.EmailVerified = false
 	user.CreatedAt = time.Now()
 	user.UpdatedAt = time.Now()
 
@@ -35,12 +70,29 @@ func (uc *userUseCase) Register(user *auth.User) error {
 	}
 	user.Password = string(hashPassword)
 
-	return uc.userRepo.CreateUser(user)
+	var verificationToken auth.SecurityToken
+	err = uc.unitOfWork.Do(ctx, func(ctx context.Context) error {
+		if err := uc.userRepo.CreateUser(ctx, user); err != nil {
+			return err
+		}
+
+		verificationToken, err = uc.genVerificationToken(ctx, user)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := uc.mailVerificationToken(user, verificationToken.Token); err != nil {
+		log.Error().Str("user_id", user.ID).Msg(err.Error())
+	}
+	return nil
 }
 
-// VerifyCredentials verifies a user credentials
-func (uc *userUseCase) VerifyCredentials(user *auth.User) (auth.User, error) {
-	userRecord, err := uc.userRepo.GetUserByEmail(user.EmailAddress)
+// VerifyCredentials verifies a user credentials, users with an unverified email address
+// and deactivated users are rejected
+func (uc *userUseCase) VerifyCredentials(ctx context.Context, user *auth.User) (auth.User, error) {
+	userRecord, err := uc.userRepo.GetUserByEmail(ctx, user.EmailAddress)
 	if err != nil {
 		return auth.User{}, err
 	}
@@ -49,10 +101,360 @@ func (uc *userUseCase) VerifyCredentials(user *auth.User) (auth.User, error) {
 		return auth.User{}, terr.NewUnAuthorizedError("password doesn't match")
 	}
 
+	if !userRecord.EmailVerified {
+		return auth.User{}, terr.NewUnverifiedEmailError("email address not verified")
+	}
+
+	if !userRecord.Active {
+		return auth.User{}, terr.NewInactiveUserError("user account deactivated")
+	}
+
 	return userRecord, nil
 }
 
 // GetUserByID creates a user by id
-func (uc *userUseCase) GetUserByID(id string) (auth.User, error) {
-	return uc.userRepo.GetUserByID(id)
+func (uc *userUseCase) GetUserByID(ctx context.Context, id string) (auth.User, error) {
+	return uc.userRepo.GetUserByID(ctx, id)
+}
+
+// ListUsers gets a page of users, newest first unless sorted otherwise, DefaultUserQueryLimit users
+// per page unless limited otherwise
+func (uc *userUseCase) ListUsers(ctx context.Context, query auth.UserQuery) (auth.UserPage, error) {
+	if query.SortKey == "" {
+		query.SortKey = "created_at"
+		query.SortDesc = true
+	}
+	if query.Limit <= 0 {
+		query.Limit = auth.DefaultUserQueryLimit
+	}
+
+	return uc.userRepo.ListUsers(ctx, query)
+}
+
+// VerifyEmail consumes an email verification token and verifies the address of its user, activating
+// a user that was never verified, the token is only consumed if the user is updated
+func (uc *userUseCase) VerifyEmail(ctx context.Context, token string) error {
+	return uc.unitOfWork.Do(ctx, func(ctx context.Context) error {
+		tokenMetadata, err := uc.securityTokenUseCase.ConsumeOneTimeToken(ctx, token, auth.EmailVerificationTokenType)
+		if err != nil {
+			return err
+		}
+
+		user, err := uc.userRepo.GetUserByID(ctx, tokenMetadata.UserID)
+		if err != nil {
+			return err
+		}
+
+		// only the first verification activates the registered user, a verified user keeps the
+		// active flag of its last activation or deactivation
+		if !user.EmailVerified {
+			user.Active = true
+		}
+		user.EmailVerified = true
+		user.UpdatedAt = time.Now()
+		return uc.userRepo.UpdateUser(ctx, &user)
+	})
+}
+
+// ResendVerificationEmail sends a new email verification token, replacing the previous one,
+// unknown and already verified addresses are ignored so registered addresses can't be probed
+func (uc *userUseCase) ResendVerificationEmail(ctx context.Context, email string) error {
+	user, err := uc.userRepo.GetUserByEmail(ctx, email)
+	if err != nil {
+		if _, ok := err.(*terr.NotFoundError); ok {
+			return nil
+		}
+		return err
+	}
+
+	if user.EmailVerified {
+		return nil
+	}
+	return uc.sendVerificationEmail(ctx, &user)
+}
+
+// ForgotPassword sends a password reset token to the user, replacing the previous one, unknown
+// addresses are ignored and delivery failures only logged so registered addresses can't be probed
+func (uc *userUseCase) ForgotPassword(ctx context.Context, email string) error {
+	user, err := uc.userRepo.GetUserByEmail(ctx, email)
+	if err != nil {
+		if _, ok := err.(*terr.NotFoundError); ok {
+			return nil
+		}
+		return err
+	}
+
+	if err := uc.sendPasswordResetEmail(ctx, &user); err != nil {
+		log.Error().Str("user_id", user.ID).Msg(err.Error())
+	}
+	return nil
+}
+
+// ResetPassword consumes a password reset token, sets the new password of its user and
+// logs out every session of the user, all at once or not at all
+func (uc *userUseCase) ResetPassword(ctx context.Context, token, password string) error {
+	return uc.unitOfWork.Do(ctx, func(ctx context.Context) error {
+		tokenMetadata, err := uc.securityTokenUseCase.ConsumeOneTimeToken(ctx, token, auth.PasswordResetTokenType)
+		if err != nil {
+			return err
+		}
+
+		hashPassword, err := uc.security.Hash(password)
+		if err != nil {
+			return err
+		}
+
+		if err := uc.userRepo.UpdateUserPassword(ctx, tokenMetadata.UserID, string(hashPassword), time.Now()); err != nil {
+			return err
+		}
+
+		return uc.securityTokenUseCase.RemoveSessions(ctx, tokenMetadata.UserID)
+	})
+}
+
+// UpdateUser updates the names and email address of a user, empty fields are left unchanged,
+// a new email address has to be verified again
+func (uc *userUseCase) UpdateUser(ctx context.Context, user *auth.User) (auth.User, error) {
+	userRecord, err := uc.userRepo.GetUserByID(ctx, user.ID)
+	if err != nil {
+		return auth.User{}, err
+	}
+
+	if user.FirstName != "" {
+		userRecord.FirstName = user.FirstName
+	}
+	if user.LastName != "" {
+		userRecord.LastName = user.LastName
+	}
+	emailChanged := user.EmailAddress != "" && user.EmailAddress != userRecord.EmailAddress
+	if emailChanged {
+		userRecord.EmailAddress = user.EmailAddress
+		userRecord.EmailVerified = false
+	}
+	userRecord.UpdatedAt = time.Now()
+
+	if err := uc.userRepo.UpdateUser(ctx, &userRecord); err != nil {
+		return auth.User{}, err
+	}
+
+	if emailChanged {
+		if err := uc.sendVerificationEmail(ctx, &userRecord); err != nil {
+			log.Error().Str("user_id", userRecord.ID).Msg(err.Error())
+		}
+	}
+	return userRecord, nil
+}
+
+// ChangePassword sets a new password after checking the current one and logs out every other session
+// of the user, the session of refreshTokenMetadata is kept, the new password and the logged out sessions
+// are stored together or not at all
+func (uc *userUseCase) ChangePassword(ctx context.Context, userID, password, newPassword string, refreshTokenMetadata *auth.TokenMetadata) error {
+	if err := uc.verifyPassword(ctx, userID, password); err != nil {
+		return err
+	}
+
+	hashPassword, err := uc.security.Hash(newPassword)
+	if err != nil {
+		return err
+	}
+
+	return uc.unitOfWork.Do(ctx, func(ctx context.Context) error {
+		if err := uc.userRepo.UpdateUserPassword(ctx, userID, string(hashPassword), time.Now()); err != nil {
+			return err
+		}
+
+		return uc.securityTokenUseCase.RemoveOtherSessions(ctx, userID, refreshTokenMetadata)
+	})
+}
+
+// IsUserActive checks if a user exists and is active, users that can't be checked are considered
+// inactive, active users are cached for activeUserCacheDuration
+func (uc *userUseCase) IsUserActive(ctx context.Context, userID string) bool {
+	cacheKey := activeUserCacheKey(userID)
+	if _, ok := uc.cache.Get(cacheKey); ok {
+		return true
+	}
+
+	user, err := uc.userRepo.GetUserByID(ctx, userID)
+	if err != nil || !user.Active {
+		return false
+	}
+
+	uc.cache.Set(cacheKey, true, activeUserCacheDuration)
+	return true
+}
+
+// ActivateUser activates a user on behalf of actorID, the activation is only kept if it is audited
+func (uc *userUseCase) ActivateUser(ctx context.Context, userID, actorID string) error {
+	return uc.unitOfWork.Do(ctx, func(ctx context.Context) error {
+		if err := uc.userRepo.UpdateUserActive(ctx, userID, true, time.Now()); err != nil {
+			return err
+		}
+
+		return uc.audit(ctx, userID, actorID, auth.UserActivatedAction)
+	})
+}
+
+// DeactivateUser deactivates a user on behalf of actorID, logs out every session of the user and
+// drops its pending email verification, all at once or not at all
+func (uc *userUseCase) DeactivateUser(ctx context.Context, userID, actorID string) error {
+	err := uc.unitOfWork.Do(ctx, func(ctx context.Context) error {
+		if err := uc.userRepo.UpdateUserActive(ctx, userID, false, time.Now()); err != nil {
+			return err
+		}
+
+		if err := uc.securityTokenUseCase.RemoveSessions(ctx, userID); err != nil {
+			return err
+		}
+
+		if err := uc.securityTokenUseCase.RemoveOneTimeTokens(ctx, userID, auth.EmailVerificationTokenType); err != nil {
+			return err
+		}
+
+		return uc.audit(ctx, userID, actorID, auth.UserDeactivatedAction)
+	})
+	if err != nil {
+		return err
+	}
+
+	uc.cache.Delete(activeUserCacheKey(userID))
+	return nil
+}
+
+// DeleteUser soft deletes a user after checking its password and logs out every session of the user,
+// all at once or not at all, the user data is kept
+func (uc *userUseCase) DeleteUser(ctx context.Context, userID, password string) error {
+	if err := uc.verifyPassword(ctx, userID, password); err != nil {
+		return err
+	}
+
+	err := uc.unitOfWork.Do(ctx, func(ctx context.Context) error {
+		if err := uc.userRepo.SoftDeleteUser(ctx, userID, time.Now()); err != nil {
+			return err
+		}
+
+		if err := uc.securityTokenUseCase.RemoveSessions(ctx, userID); err != nil {
+			return err
+		}
+
+		return uc.audit(ctx, userID, userID, auth.UserDeletedAction)
+	})
+	if err != nil {
+		return err
+	}
+
+	uc.cache.Delete(activeUserCacheKey(userID))
+	return nil
+}
+
+// EraseUser removes a user and its data after checking its password, the erasure and its audit
+// log are stored together so that no account is erased without a record
+func (uc *userUseCase) EraseUser(ctx context.Context, userID, password string) error {
+	if err := uc.verifyPassword(ctx, userID, password); err != nil {
+		return err
+	}
+
+	err := uc.unitOfWork.Do(ctx, func(ctx context.Context) error {
+		if err := uc.audit(ctx, userID, userID, auth.UserErasedAction); err != nil {
+			return err
+		}
+
+		return uc.userRepo.DeleteUser(ctx, userID)
+	})
+	if err != nil {
+		return err
+	}
+
+	uc.cache.Delete(activeUserCacheKey(userID))
+	return nil
+}
+
+// verifyPassword checks the password of a user
+func (uc *userUseCase) verifyPassword(ctx context.Context, userID, password string) error {
+	userRecord, err := uc.userRepo.GetUserByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	if err := uc.security.VerifyPassword(userRecord.Password, password); err != nil {
+		return terr.NewUnAuthorizedError("password doesn't match")
+	}
+	return nil
+}
+
+// audit persists an audit log of action
+func (uc *userUseCase) audit(ctx context.Context, userID, actorID, action string) error {
+	err := uc.auditLogRepo.CreateAuditLog(ctx, &auth.AuditLog{
+		ID:        uuid.New().String(),
+		UserID:    userID,
+		ActorID:   actorID,
+		Action:    action,
+		CreatedAt: time.Now(),
+	})
+	if err != nil {
+		return errors.New("could not write audit log")
+	}
+	return nil
+}
+
+// activeUserCacheKey returns the cache key of an active user id
+func activeUserCacheKey(userID string) string {
+	return "active-user:" + userID
+}
+
+// sendVerificationEmail generates an email verification token and mails it to the user
+func (uc *userUseCase) sendVerificationEmail(ctx context.Context, user *auth.User) error {
+	verificationToken, err := uc.genVerificationToken(ctx, user)
+	if err != nil {
+		return err
+	}
+
+	return uc.mailVerificationToken(user, verificationToken.Token)
+}
+
+// genVerificationToken generates an email verification token of the user, replacing the previous one
+func (uc *userUseCase) genVerificationToken(ctx context.Context, user *auth.User) (auth.SecurityToken, error) {
+	return uc.securityTokenUseCase.GenOneTimeToken(
+		ctx,
+		user.ID,
+		auth.EmailVerificationTokenType,
+		emailVerificationTokenDuration,
+	)
+}
+
+// mailVerificationToken mails an email verification token to the user
+func (uc *userUseCase) mailVerificationToken(user *auth.User, token string) error {
+	return uc.mailer.Send(&mailer.Message{
+		To:      user.EmailAddress,
+		Subject: "Verify your email address",
+		Body: fmt.Sprintf(
+			"Hi %s,\n\nUse the following token to verify your email address, it expires in 24 hours:\n\n%s\n",
+			user.FirstName,
+			token,
+		),
+	})
+}
+
+// sendPasswordResetEmail generates a password reset token and mails it to the user
+func (uc *userUseCase) sendPasswordResetEmail(ctx context.Context, user *auth.User) error {
+	resetToken, err := uc.securityTokenUseCase.GenOneTimeToken(
+		ctx,
+		user.ID,
+		auth.PasswordResetTokenType,
+		passwordResetTokenDuration,
+	)
+	if err != nil {
+		return err
+	}
+
+	return uc.mailer.Send(&mailer.Message{
+		To:      user.EmailAddress,
+		Subject: "Reset your password",
+		Body: fmt.Sprintf(
+			"Hi %s,\n\nUse the following token to reset your password, it expires in 30 minutes:\n\n%s\n\n"+
+				"If you didn't ask to reset your password you can ignore this email.\n",
+			user.FirstName,
+			resetToken.Token,
+		),
+	})
 }
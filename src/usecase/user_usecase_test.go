@@ -1,6 +1,7 @@
 package usecase
 
 import (
+	"context"
 	"errors"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
@@ -8,23 +9,45 @@ import (
 	_ "sherman/src/app/testing"
 	"sherman/src/app/utils/terr"
 	"sherman/src/domain/auth"
+	"sherman/src/service/mailer"
+	"strings"
 	"testing"
 )
 
 type userUseCaseMockDeps struct {
-	userRepository  *mocks.UserRepository
-	securityService *mocks.Security
+	userRepository       *mocks.UserRepository
+	auditLogRepository   *mocks.AuditLogRepository
+	unitOfWork           *mocks.UnitOfWork
+	securityTokenUseCase *mocks.SecurityTokenUseCase
+	securityService      *mocks.Security
+	mailerService        *mocks.Mailer
+	cacheService         *mocks.Cache
 }
 
 func genUserUseCase() (auth.UserUseCase, userUseCaseMockDeps) {
 	uucDeps := userUseCaseMockDeps{
-		userRepository:  new(mocks.UserRepository),
-		securityService: new(mocks.Security),
+		userRepository:       new(mocks.UserRepository),
+		auditLogRepository:   new(mocks.AuditLogRepository),
+		unitOfWork:           new(mocks.UnitOfWork),
+		securityTokenUseCase: new(mocks.SecurityTokenUseCase),
+		securityService:      new(mocks.Security),
+		mailerService:        new(mocks.Mailer),
+		cacheService:         new(mocks.Cache),
 	}
+	uucDeps.unitOfWork.
+		On("Do", mock.Anything, mock.Anything).
+		Return(func(ctx context.Context, fn func(context.Context) error) error {
+			return fn(ctx)
+		})
 
 	uuc := NewUserUseCase(
 		uucDeps.userRepository,
+		uucDeps.auditLogRepository,
+		uucDeps.unitOfWork,
+		uucDeps.securityTokenUseCase,
 		uucDeps.securityService,
+		uucDeps.mailerService,
+		uucDeps.cacheService,
 	)
 
 	return uuc, uucDeps
@@ -42,32 +65,80 @@ func TestRegister(t *testing.T) {
 	t.Run("it should succeed", func(t *testing.T) {
 		uuc, uucDeps := genUserUseCase()
 		muCopy := mockUser
-		uucDeps.userRepository.On("CreateUser", mock.Anything).Return(nil)
+		muCopy.Active = true
+		muCopy.EmailVerified = true
+		uucDeps.userRepository.On("CreateUser", mock.Anything, mock.Anything).Return(nil)
 		uucDeps.securityService.
 			On("Hash", mock.AnythingOfType("string")).
 			Return(mockHashPassword, nil)
+		uucDeps.securityTokenUseCase.
+			On("GenOneTimeToken", mock.Anything, mock.AnythingOfType("string"), auth.EmailVerificationTokenType, mock.Anything).
+			Return(auth.SecurityToken{Token: "some-verification-token"}, nil)
+		uucDeps.mailerService.
+			On("Send", mock.MatchedBy(func(m *mailer.Message) bool {
+				return m.To == mockUser.EmailAddress && strings.Contains(m.Body, "some-verification-token")
+			})).
+			Return(nil)
 
-		err := uuc.Register(&muCopy)
+		err := uuc.Register(context.Background(), &muCopy)
 
 		assert.NoError(t, err)
 		assert.NotEmpty(t, muCopy.ID)
 		assert.NotEmpty(t, muCopy.CreatedAt)
 		assert.NotEmpty(t, muCopy.UpdatedAt)
 		assert.EqualValues(t, string(mockHashPassword), muCopy.Password)
-		assert.EqualValues(t, true, muCopy.Active)
+		assert.False(t, muCopy.Active)
+		assert.False(t, muCopy.EmailVerified)
+		uucDeps.mailerService.AssertExpectations(t)
 		assert.EqualValues(t, mockUser.FirstName, muCopy.FirstName)
 		assert.EqualValues(t, mockUser.LastName, muCopy.LastName)
 		assert.EqualValues(t, mockUser.EmailAddress, muCopy.EmailAddress)
 	})
 
+	t.Run("it should succeed when the verification email can't be sent", func(t *testing.T) {
+		uuc, uucDeps := genUserUseCase()
+		muCopy := mockUser
+		uucDeps.userRepository.On("CreateUser", mock.Anything, mock.Anything).Return(nil)
+		uucDeps.securityService.
+			On("Hash", mock.AnythingOfType("string")).
+			Return(mockHashPassword, nil)
+		uucDeps.securityTokenUseCase.
+			On("GenOneTimeToken", mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+			Return(auth.SecurityToken{Token: "some-verification-token"}, nil)
+		uucDeps.mailerService.On("Send", mock.Anything).Return(errors.New("some error"))
+
+		err := uuc.Register(context.Background(), &muCopy)
+
+		assert.NoError(t, err)
+	})
+
+	t.Run("it should fail when the verification token can't be stored", func(t *testing.T) {
+		uuc, uucDeps := genUserUseCase()
+		muCopy := mockUser
+		mockError := errors.New("some error")
+		uucDeps.userRepository.On("CreateUser", mock.Anything, mock.Anything).Return(nil)
+		uucDeps.securityService.
+			On("Hash", mock.AnythingOfType("string")).
+			Return(mockHashPassword, nil)
+		uucDeps.securityTokenUseCase.
+			On("GenOneTimeToken", mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+			Return(auth.SecurityToken{}, mockError)
+
+		err := uuc.Register(context.Background(), &muCopy)
+
+		assert.Equal(t, mockError, err)
+		uucDeps.unitOfWork.AssertCalled(t, "Do", mock.Anything, mock.Anything)
+		uucDeps.mailerService.AssertNotCalled(t, "Send", mock.Anything)
+	})
+
 	t.Run("it should return an error", func(t *testing.T) {
 		uuc, uucDeps := genUserUseCase()
 		muCopy := mockUser
 		mockError := errors.New("test register error")
-		uucDeps.userRepository.On("CreateUser", mock.Anything).Return(nil)
+		uucDeps.userRepository.On("CreateUser", mock.Anything, mock.Anything).Return(nil)
 		uucDeps.securityService.On("Hash", mock.AnythingOfType("string")).Return(nil, mockError)
 
-		err := uuc.Register(&muCopy)
+		err := uuc.Register(context.Background(), &muCopy)
 		if assert.Error(t, err) {
 			assert.Equal(t, mockError, err)
 		}
@@ -81,10 +152,10 @@ func TestRegister(t *testing.T) {
 			On("Hash", mock.AnythingOfType("string")).
 			Return(mockHashPassword, nil)
 		uucDeps.userRepository.
-			On("CreateUser", mock.Anything).
+			On("CreateUser", mock.Anything, mock.Anything).
 			Return(mockError)
 
-		err := uuc.Register(&muCopy)
+		err := uuc.Register(context.Background(), &muCopy)
 		if assert.Error(t, err) {
 			assert.Equal(t, mockError, err)
 		}
@@ -95,7 +166,9 @@ func TestVerifyCredentials(t *testing.T) {
 	mockPassword := "some-password"
 	mockHashedPassword := "some-hashed-password"
 	mockUserRecord := auth.User{
-		Password: mockHashedPassword,
+		Password:      mockHashedPassword,
+		Active:        true,
+		EmailVerified: true,
 	}
 	mockUser := auth.User{
 		Password: mockPassword,
@@ -103,12 +176,12 @@ func TestVerifyCredentials(t *testing.T) {
 
 	t.Run("it should succeed", func(t *testing.T) {
 		uuc, uucDeps := genUserUseCase()
-		uucDeps.userRepository.On("GetUserByEmail", mock.Anything).Return(mockUserRecord, nil)
+		uucDeps.userRepository.On("GetUserByEmail", mock.Anything, mock.Anything).Return(mockUserRecord, nil)
 		uucDeps.securityService.
 			On("VerifyPassword", mock.AnythingOfType("string"), mock.AnythingOfType("string")).
 			Return(nil)
 
-		userRecord, err := uuc.VerifyCredentials(&mockUser)
+		userRecord, err := uuc.VerifyCredentials(context.Background(), &mockUser)
 
 		assert.NoError(t, err)
 		assert.EqualValues(t, mockUserRecord, userRecord)
@@ -117,9 +190,9 @@ func TestVerifyCredentials(t *testing.T) {
 	t.Run("it should return an error", func(t *testing.T) {
 		uuc, uucDeps := genUserUseCase()
 		mockError := errors.New("get user by email error")
-		uucDeps.userRepository.On("GetUserByEmail", mock.Anything).Return(auth.User{}, mockError)
+		uucDeps.userRepository.On("GetUserByEmail", mock.Anything, mock.Anything).Return(auth.User{}, mockError)
 
-		_, err := uuc.VerifyCredentials(&mockUser)
+		_, err := uuc.VerifyCredentials(context.Background(), &mockUser)
 
 		if assert.Error(t, err) {
 			assert.Equal(t, mockError, err)
@@ -132,14 +205,47 @@ func TestVerifyCredentials(t *testing.T) {
 		uucDeps.securityService.
 			On("VerifyPassword", mock.AnythingOfType("string"), mock.AnythingOfType("string")).
 			Return(errors.New("some-error"))
-		uucDeps.userRepository.On("GetUserByEmail", mock.Anything).Return(mockUserRecord, nil)
+		uucDeps.userRepository.On("GetUserByEmail", mock.Anything, mock.Anything).Return(mockUserRecord, nil)
 
-		_, err := uuc.VerifyCredentials(&mockUser)
+		_, err := uuc.VerifyCredentials(context.Background(), &mockUser)
 
 		if assert.Error(t, err) {
 			assert.Equal(t, mockError, err)
 		}
 	})
+
+	t.Run("it should return an unverified email error", func(t *testing.T) {
+		uuc, uucDeps := genUserUseCase()
+		unverifiedUserRecord := mockUserRecord
+		unverifiedUserRecord.Active = false
+		unverifiedUserRecord.EmailVerified = false
+		uucDeps.securityService.
+			On("VerifyPassword", mock.AnythingOfType("string"), mock.AnythingOfType("string")).
+			Return(nil)
+		uucDeps.userRepository.On("GetUserByEmail", mock.Anything, mock.Anything).Return(unverifiedUserRecord, nil)
+
+		_, err := uuc.VerifyCredentials(context.Background(), &mockUser)
+
+		if assert.Error(t, err) {
+			assert.Equal(t, terr.NewUnverifiedEmailError("email address not verified"), err)
+		}
+	})
+
+	t.Run("it should return an inactive user error", func(t *testing.T) {
+		uuc, uucDeps := genUserUseCase()
+		inactiveUserRecord := mockUserRecord
+		inactiveUserRecord.Active = false
+		uucDeps.securityService.
+			On("VerifyPassword", mock.AnythingOfType("string"), mock.AnythingOfType("string")).
+			Return(nil)
+		uucDeps.userRepository.On("GetUserByEmail", mock.Anything, mock.Anything).Return(inactiveUserRecord, nil)
+
+		_, err := uuc.VerifyCredentials(context.Background(), &mockUser)
+
+		if assert.Error(t, err) {
+			assert.Equal(t, terr.NewInactiveUserError("user account deactivated"), err)
+		}
+	})
 }
 
 func TestGetUserByID(t *testing.T) {
@@ -153,10 +259,10 @@ func TestGetUserByID(t *testing.T) {
 	t.Run("it should succeed", func(t *testing.T) {
 		uuc, uucDeps := genUserUseCase()
 		uucDeps.userRepository.
-			On("GetUserByID", mock.AnythingOfType("string")).
+			On("GetUserByID", mock.Anything, mock.AnythingOfType("string")).
 			Return(mockUser, nil)
 
-		userRecord, err := uuc.GetUserByID("some-id")
+		userRecord, err := uuc.GetUserByID(context.Background(), "some-id")
 
 		assert.NoError(t, err)
 		assert.EqualValues(t, mockUser, userRecord)
@@ -166,10 +272,782 @@ func TestGetUserByID(t *testing.T) {
 		uuc, uucDeps := genUserUseCase()
 		mockError := errors.New("get user by id error")
 		uucDeps.userRepository.
-			On("GetUserByID", mock.Anything).
+			On("GetUserByID", mock.Anything, mock.Anything).
 			Return(auth.User{}, mockError)
 
-		_, err := uuc.GetUserByID("some-id")
+		_, err := uuc.GetUserByID(context.Background(), "some-id")
+
+		if assert.Error(t, err) {
+			assert.Equal(t, mockError, err)
+		}
+	})
+}
+
+func TestListUsers(t *testing.T) {
+	mockPage := auth.UserPage{Users: []auth.User{{ID: "some-user-id"}}, NextCursor: "some-cursor", Total: 2}
+
+	t.Run("it should succeed", func(t *testing.T) {
+		uuc, uucDeps := genUserUseCase()
+		uucDeps.userRepository.
+			On("ListUsers", mock.Anything, auth.UserQuery{SortKey: "created_at", SortDesc: true, Limit: auth.DefaultUserQueryLimit}).
+			Return(mockPage, nil)
+
+		page, err := uuc.ListUsers(context.Background(), auth.UserQuery{})
+
+		assert.NoError(t, err)
+		assert.Equal(t, mockPage, page)
+	})
+
+	t.Run("it should keep the sort and limit", func(t *testing.T) {
+		uuc, uucDeps := genUserUseCase()
+		mockQuery := auth.UserQuery{SortKey: "email_address", Limit: 5, EmailPrefix: "some"}
+		uucDeps.userRepository.On("ListUsers", mock.Anything, mockQuery).Return(mockPage, nil)
+
+		_, err := uuc.ListUsers(context.Background(), mockQuery)
+
+		assert.NoError(t, err)
+		uucDeps.userRepository.AssertExpectations(t)
+	})
+
+	t.Run("it should return error", func(t *testing.T) {
+		uuc, uucDeps := genUserUseCase()
+		mockError := terr.NewInvalidQueryError("invalid cursor")
+		uucDeps.userRepository.On("ListUsers", mock.Anything, mock.Anything).Return(auth.UserPage{}, mockError)
+
+		_, err := uuc.ListUsers(context.Background(), auth.UserQuery{Cursor: "some-cursor"})
+
+		assert.Equal(t, mockError, err)
+	})
+}
+
+func TestVerifyEmail(t *testing.T) {
+	mockTokenMeta := auth.TokenMetadata{UserID: "some-user-id", Type: auth.EmailVerificationTokenType}
+	mockUser := auth.User{ID: "some-user-id", EmailAddress: "some@email.com"}
+
+	t.Run("it should succeed", func(t *testing.T) {
+		uuc, uucDeps := genUserUseCase()
+		uucDeps.securityTokenUseCase.
+			On("ConsumeOneTimeToken", mock.Anything, "some-token", auth.EmailVerificationTokenType).
+			Return(mockTokenMeta, nil)
+		uucDeps.userRepository.On("GetUserByID", mock.Anything, "some-user-id").Return(mockUser, nil)
+		uucDeps.userRepository.
+			On("UpdateUser", mock.Anything, mock.MatchedBy(func(u *auth.User) bool {
+				return u.ID == "some-user-id" && u.EmailVerified && u.Active
+			})).
+			Return(nil)
+
+		err := uuc.VerifyEmail(context.Background(), "some-token")
+
+		assert.NoError(t, err)
+	})
+
+	t.Run("it should keep a deactivated user inactive", func(t *testing.T) {
+		uuc, uucDeps := genUserUseCase()
+		deactivatedUser := mockUser
+		deactivatedUser.EmailVerified = true
+		deactivatedUser.Active = false
+		uucDeps.securityTokenUseCase.
+			On("ConsumeOneTimeToken", mock.Anything, "some-token", auth.EmailVerificationTokenType).
+			Return(mockTokenMeta, nil)
+		uucDeps.userRepository.On("GetUserByID", mock.Anything, "some-user-id").Return(deactivatedUser, nil)
+		uucDeps.userRepository.
+			On("UpdateUser", mock.Anything, mock.MatchedBy(func(u *auth.User) bool {
+				return u.ID == "some-user-id" && u.EmailVerified && !u.Active
+			})).
+			Return(nil)
+
+		err := uuc.VerifyEmail(context.Background(), "some-token")
+
+		if assert.NoError(t, err) {
+			uucDeps.userRepository.AssertExpectations(t)
+		}
+	})
+
+	t.Run("it should return an error", func(t *testing.T) {
+		uuc, uucDeps := genUserUseCase()
+		mockError := terr.NewUnAuthorizedError("invalid token")
+		uucDeps.securityTokenUseCase.
+			On("ConsumeOneTimeToken", mock.Anything, "some-token", auth.EmailVerificationTokenType).
+			Return(auth.TokenMetadata{}, mockError)
+
+		err := uuc.VerifyEmail(context.Background(), "some-token")
+
+		if assert.Error(t, err) {
+			assert.Equal(t, mockError, err)
+			uucDeps.userRepository.AssertNotCalled(t, "UpdateUser", mock.Anything, mock.Anything)
+		}
+	})
+
+	t.Run("it should return an error", func(t *testing.T) {
+		uuc, uucDeps := genUserUseCase()
+		mockError := terr.NewNotFoundError("user not found")
+		uucDeps.securityTokenUseCase.
+			On("ConsumeOneTimeToken", mock.Anything, "some-token", auth.EmailVerificationTokenType).
+			Return(mockTokenMeta, nil)
+		uucDeps.userRepository.On("GetUserByID", mock.Anything, "some-user-id").Return(auth.User{}, mockError)
+
+		err := uuc.VerifyEmail(context.Background(), "some-token")
+
+		if assert.Error(t, err) {
+			assert.Equal(t, mockError, err)
+		}
+	})
+}
+
+func TestResendVerificationEmail(t *testing.T) {
+	mockUser := auth.User{ID: "some-user-id", EmailAddress: "some@email.com"}
+
+	t.Run("it should succeed", func(t *testing.T) {
+		uuc, uucDeps := genUserUseCase()
+		uucDeps.userRepository.On("GetUserByEmail", mock.Anything, "some@email.com").Return(mockUser, nil)
+		uucDeps.securityTokenUseCase.
+			On("GenOneTimeToken", mock.Anything, "some-user-id", auth.EmailVerificationTokenType, mock.Anything).
+			Return(auth.SecurityToken{Token: "some-verification-token"}, nil)
+		uucDeps.mailerService.On("Send", mock.Anything).Return(nil)
+
+		err := uuc.ResendVerificationEmail(context.Background(), "some@email.com")
+
+		if assert.NoError(t, err) {
+			uucDeps.mailerService.AssertExpectations(t)
+		}
+	})
+
+	t.Run("it should ignore an unknown email address", func(t *testing.T) {
+		uuc, uucDeps := genUserUseCase()
+		uucDeps.userRepository.
+			On("GetUserByEmail", mock.Anything, "some@email.com").
+			Return(auth.User{}, terr.NewNotFoundError("user not found"))
+
+		err := uuc.ResendVerificationEmail(context.Background(), "some@email.com")
+
+		if assert.NoError(t, err) {
+			uucDeps.mailerService.AssertNotCalled(t, "Send", mock.Anything)
+		}
+	})
+
+	t.Run("it should ignore a verified email address", func(t *testing.T) {
+		uuc, uucDeps := genUserUseCase()
+		verifiedUser := mockUser
+		verifiedUser.EmailVerified = true
+		uucDeps.userRepository.On("GetUserByEmail", mock.Anything, "some@email.com").Return(verifiedUser, nil)
+
+		err := uuc.ResendVerificationEmail(context.Background(), "some@email.com")
+
+		if assert.NoError(t, err) {
+			uucDeps.securityTokenUseCase.AssertNotCalled(t, "GenOneTimeToken", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+		}
+	})
+
+	t.Run("it should return an error", func(t *testing.T) {
+		uuc, uucDeps := genUserUseCase()
+		mockError := errors.New("some error")
+		uucDeps.userRepository.On("GetUserByEmail", mock.Anything, "some@email.com").Return(mockUser, nil)
+		uucDeps.securityTokenUseCase.
+			On("GenOneTimeToken", mock.Anything, "some-user-id", auth.EmailVerificationTokenType, mock.Anything).
+			Return(auth.SecurityToken{}, mockError)
+
+		err := uuc.ResendVerificationEmail(context.Background(), "some@email.com")
+
+		if assert.Error(t, err) {
+			assert.Equal(t, mockError, err)
+		}
+	})
+}
+
+func TestForgotPassword(t *testing.T) {
+	mockUser := auth.User{ID: "some-user-id", FirstName: "first", EmailAddress: "some@email.com"}
+
+	t.Run("it should succeed", func(t *testing.T) {
+		uuc, uucDeps := genUserUseCase()
+		uucDeps.userRepository.On("GetUserByEmail", mock.Anything, "some@email.com").Return(mockUser, nil)
+		uucDeps.securityTokenUseCase.
+			On("GenOneTimeToken", mock.Anything, "some-user-id", auth.PasswordResetTokenType, mock.Anything).
+			Return(auth.SecurityToken{Token: "some-reset-token"}, nil)
+		uucDeps.mailerService.
+			On("Send", mock.MatchedBy(func(m *mailer.Message) bool {
+				return m.To == "some@email.com" && strings.Contains(m.Body, "some-reset-token")
+			})).
+			Return(nil)
+
+		err := uuc.ForgotPassword(context.Background(), "some@email.com")
+
+		if assert.NoError(t, err) {
+			uucDeps.mailerService.AssertExpectations(t)
+		}
+	})
+
+	t.Run("it should ignore an unknown email address", func(t *testing.T) {
+		uuc, uucDeps := genUserUseCase()
+		uucDeps.userRepository.
+			On("GetUserByEmail", mock.Anything, "some@email.com").
+			Return(auth.User{}, terr.NewNotFoundError("user not found"))
+
+		err := uuc.ForgotPassword(context.Background(), "some@email.com")
+
+		if assert.NoError(t, err) {
+			uucDeps.securityTokenUseCase.AssertNotCalled(t, "GenOneTimeToken", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+			uucDeps.mailerService.AssertNotCalled(t, "Send", mock.Anything)
+		}
+	})
+
+	t.Run("it should return an error", func(t *testing.T) {
+		uuc, uucDeps := genUserUseCase()
+		mockError := errors.New("some error")
+		uucDeps.userRepository.On("GetUserByEmail", mock.Anything, "some@email.com").Return(auth.User{}, mockError)
+
+		err := uuc.ForgotPassword(context.Background(), "some@email.com")
+
+		if assert.Error(t, err) {
+			assert.Equal(t, mockError, err)
+		}
+	})
+
+	t.Run("it should succeed when the email can't be sent", func(t *testing.T) {
+		uuc, uucDeps := genUserUseCase()
+		uucDeps.userRepository.On("GetUserByEmail", mock.Anything, "some@email.com").Return(mockUser, nil)
+		uucDeps.securityTokenUseCase.
+			On("GenOneTimeToken", mock.Anything, "some-user-id", auth.PasswordResetTokenType, mock.Anything).
+			Return(auth.SecurityToken{Token: "some-reset-token"}, nil)
+		uucDeps.mailerService.On("Send", mock.Anything).Return(errors.New("some error"))
+
+		err := uuc.ForgotPassword(context.Background(), "some@email.com")
+
+		assert.NoError(t, err)
+	})
+}
+
+func TestResetPassword(t *testing.T) {
+	mockTokenMeta := auth.TokenMetadata{UserID: "some-user-id", Type: auth.PasswordResetTokenType}
+	mockHashPassword := []byte("some-hashed-password")
+
+	t.Run("it should succeed", func(t *testing.T) {
+		uuc, uucDeps := genUserUseCase()
+		uucDeps.securityTokenUseCase.
+			On("ConsumeOneTimeToken", mock.Anything, "some-token", auth.PasswordResetTokenType).
+			Return(mockTokenMeta, nil)
+		uucDeps.securityService.On("Hash", "some-new-password").Return(mockHashPassword, nil)
+		uucDeps.userRepository.
+			On("UpdateUserPassword", mock.Anything, "some-user-id", string(mockHashPassword), mock.AnythingOfType("time.Time")).
+			Return(nil)
+		uucDeps.securityTokenUseCase.On("RemoveSessions", mock.Anything, "some-user-id").Return(nil)
+
+		err := uuc.ResetPassword(context.Background(), "some-token", "some-new-password")
+
+		if assert.NoError(t, err) {
+			uucDeps.securityTokenUseCase.AssertExpectations(t)
+		}
+	})
+
+	t.Run("it should return an error", func(t *testing.T) {
+		uuc, uucDeps := genUserUseCase()
+		mockError := terr.NewUnAuthorizedError("invalid token")
+		uucDeps.securityTokenUseCase.
+			On("ConsumeOneTimeToken", mock.Anything, "some-token", auth.PasswordResetTokenType).
+			Return(auth.TokenMetadata{}, mockError)
+
+		err := uuc.ResetPassword(context.Background(), "some-token", "some-new-password")
+
+		if assert.Error(t, err) {
+			assert.Equal(t, mockError, err)
+			uucDeps.userRepository.AssertNotCalled(t, "UpdateUserPassword", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+		}
+	})
+
+	t.Run("it should return an error", func(t *testing.T) {
+		uuc, uucDeps := genUserUseCase()
+		mockError := terr.NewNotFoundError("user not found")
+		uucDeps.securityTokenUseCase.
+			On("ConsumeOneTimeToken", mock.Anything, "some-token", auth.PasswordResetTokenType).
+			Return(mockTokenMeta, nil)
+		uucDeps.securityService.On("Hash", "some-new-password").Return(mockHashPassword, nil)
+		uucDeps.userRepository.
+			On("UpdateUserPassword", mock.Anything, "some-user-id", string(mockHashPassword), mock.AnythingOfType("time.Time")).
+			Return(mockError)
+
+		err := uuc.ResetPassword(context.Background(), "some-token", "some-new-password")
+
+		if assert.Error(t, err) {
+			assert.Equal(t, mockError, err)
+			uucDeps.securityTokenUseCase.AssertNotCalled(t, "RemoveSessions", mock.Anything, mock.Anything)
+		}
+	})
+
+	t.Run("it should return an error", func(t *testing.T) {
+		uuc, uucDeps := genUserUseCase()
+		mockError := errors.New("some error")
+		uucDeps.securityTokenUseCase.
+			On("ConsumeOneTimeToken", mock.Anything, "some-token", auth.PasswordResetTokenType).
+			Return(mockTokenMeta, nil)
+		uucDeps.securityService.On("Hash", "some-new-password").Return(nil, mockError)
+
+		err := uuc.ResetPassword(context.Background(), "some-token", "some-new-password")
+
+		if assert.Error(t, err) {
+			assert.Equal(t, mockError, err)
+		}
+	})
+}
+
+func TestUpdateUser(t *testing.T) {
+	mockUserRecord := auth.User{
+		ID:            "some-user-id",
+		FirstName:     "first",
+		LastName:      "last",
+		EmailAddress:  "some@email.com",
+		Password:      "some-hashed-password",
+		Active:        true,
+		EmailVerified: true,
+	}
+
+	t.Run("it should update the names", func(t *testing.T) {
+		uuc, uucDeps := genUserUseCase()
+		uucDeps.userRepository.On("GetUserByID", mock.Anything, "some-user-id").Return(mockUserRecord, nil)
+		uucDeps.userRepository.
+			On("UpdateUser", mock.Anything, mock.MatchedBy(func(u *auth.User) bool {
+				return u.FirstName == "other-first" &&
+					u.LastName == "last" &&
+					u.EmailAddress == "some@email.com" &&
+					u.EmailVerified &&
+					!u.UpdatedAt.IsZero()
+			})).
+			Return(nil)
+
+		user, err := uuc.UpdateUser(context.Background(), &auth.User{ID: "some-user-id", FirstName: "other-first"})
+
+		if assert.NoError(t, err) {
+			assert.Equal(t, "other-first", user.FirstName)
+			assert.Equal(t, "some-hashed-password", user.Password)
+			uucDeps.mailerService.AssertNotCalled(t, "Send", mock.Anything)
+		}
+	})
+
+	t.Run("it should require the verification of a new email address", func(t *testing.T) {
+		uuc, uucDeps := genUserUseCase()
+		uucDeps.userRepository.On("GetUserByID", mock.Anything, "some-user-id").Return(mockUserRecord, nil)
+		uucDeps.userRepository.
+			On("UpdateUser", mock.Anything, mock.MatchedBy(func(u *auth.User) bool {
+				return u.EmailAddress == "other@email.com" && !u.EmailVerified
+			})).
+			Return(nil)
+		uucDeps.securityTokenUseCase.
+			On("GenOneTimeToken", mock.Anything, "some-user-id", auth.EmailVerificationTokenType, mock.Anything).
+			Return(auth.SecurityToken{Token: "some-verification-token"}, nil)
+		uucDeps.mailerService.
+			On("Send", mock.MatchedBy(func(m *mailer.Message) bool {
+				return m.To == "other@email.com"
+			})).
+			Return(nil)
+
+		user, err := uuc.UpdateUser(context.Background(), &auth.User{ID: "some-user-id", EmailAddress: "other@email.com"})
+
+		if assert.NoError(t, err) {
+			assert.False(t, user.EmailVerified)
+			uucDeps.mailerService.AssertExpectations(t)
+		}
+	})
+
+	t.Run("it should return an error", func(t *testing.T) {
+		uuc, uucDeps := genUserUseCase()
+		mockError := terr.NewDuplicateEntryError("user already exist")
+		uucDeps.userRepository.On("GetUserByID", mock.Anything, "some-user-id").Return(mockUserRecord, nil)
+		uucDeps.userRepository.On("UpdateUser", mock.Anything, mock.Anything).Return(mockError)
+
+		_, err := uuc.UpdateUser(context.Background(), &auth.User{ID: "some-user-id", EmailAddress: "other@email.com"})
+
+		if assert.Error(t, err) {
+			assert.Equal(t, mockError, err)
+			uucDeps.mailerService.AssertNotCalled(t, "Send", mock.Anything)
+		}
+	})
+
+	t.Run("it should return an error", func(t *testing.T) {
+		uuc, uucDeps := genUserUseCase()
+		mockError := terr.NewNotFoundError("user not found")
+		uucDeps.userRepository.On("GetUserByID", mock.Anything, "some-user-id").Return(auth.User{}, mockError)
+
+		_, err := uuc.UpdateUser(context.Background(), &auth.User{ID: "some-user-id", FirstName: "other-first"})
+
+		if assert.Error(t, err) {
+			assert.Equal(t, mockError, err)
+		}
+	})
+}
+
+func TestChangePassword(t *testing.T) {
+	mockUserRecord := auth.User{ID: "some-user-id", Password: "some-hashed-password"}
+	mockHashPassword := []byte("some-new-hashed-password")
+	mockRefreshTokenMeta := auth.TokenMetadata{UserID: "some-user-id", Type: auth.RefreshTokenType, Token: "some-token"}
+
+	t.Run("it should succeed", func(t *testing.T) {
+		uuc, uucDeps := genUserUseCase()
+		uucDeps.userRepository.On("GetUserByID", mock.Anything, "some-user-id").Return(mockUserRecord, nil)
+		uucDeps.securityService.On("VerifyPassword", "some-hashed-password", "some-password").Return(nil)
+		uucDeps.securityService.On("Hash", "some-new-password").Return(mockHashPassword, nil)
+		uucDeps.userRepository.
+			On("UpdateUserPassword", mock.Anything, "some-user-id", string(mockHashPassword), mock.AnythingOfType("time.Time")).
+			Return(nil)
+		uucDeps.securityTokenUseCase.
+			On("RemoveOtherSessions", mock.Anything, "some-user-id", &mockRefreshTokenMeta).
+			Return(nil)
+
+		err := uuc.ChangePassword(context.Background(), "some-user-id", "some-password", "some-new-password", &mockRefreshTokenMeta)
+
+		assert.NoError(t, err)
+		uucDeps.securityTokenUseCase.AssertExpectations(t)
+		uucDeps.unitOfWork.AssertNumberOfCalls(t, "Do", 1)
+	})
+
+	t.Run("it should revoke the other session families", func(t *testing.T) {
+		_, uucDeps := genUserUseCase()
+		stuc, stucDeps := genSecurityTokenUseCase()
+		uuc := NewUserUseCase(
+			uucDeps.userRepository,
+			uucDeps.auditLogRepository,
+			uucDeps.unitOfWork,
+			stuc,
+			uucDeps.securityService,
+			uucDeps.mailerService,
+			uucDeps.cacheService,
+		)
+		uucDeps.userRepository.On("GetUserByID", mock.Anything, "some-user-id").Return(mockUserRecord, nil)
+		uucDeps.securityService.On("VerifyPassword", "some-hashed-password", "some-password").Return(nil)
+		uucDeps.securityService.On("Hash", "some-new-password").Return(mockHashPassword, nil)
+		uucDeps.userRepository.
+			On("UpdateUserPassword", mock.Anything, "some-user-id", string(mockHashPassword), mock.AnythingOfType("time.Time")).
+			Return(nil)
+		mockSessions := []auth.SecurityToken{
+			{ID: "some-id", UserID: "some-user-id", FamilyID: "some-session-id"},
+			{ID: "some-other-id", UserID: "some-user-id", FamilyID: "some-other-session-id"},
+			{ID: "another-id", UserID: "some-user-id", FamilyID: "another-session-id"},
+		}
+		stucDeps.securityTokenRepository.
+			On("GetTokenByMetadata", mock.Anything, hashTokenMetadata(&mockRefreshTokenMeta)).
+			Return(mockSessions[0], nil)
+		stucDeps.securityTokenRepository.
+			On("GetTokensByUserID", mock.Anything, "some-user-id", auth.RefreshTokenType).
+			Return(mockSessions, nil)
+		stucDeps.securityTokenRepository.
+			On("RemoveTokenFamily", mock.Anything, "some-user-id", "some-other-session-id").
+			Return(nil)
+		stucDeps.securityTokenRepository.
+			On("RemoveTokenFamily", mock.Anything, "some-user-id", "another-session-id").
+			Return(nil)
+
+		err := uuc.ChangePassword(context.Background(), "some-user-id", "some-password", "some-new-password", &mockRefreshTokenMeta)
+
+		if assert.NoError(t, err) {
+			stucDeps.securityTokenRepository.AssertExpectations(t)
+			stucDeps.securityTokenRepository.AssertNotCalled(t, "RemoveTokenFamily", mock.Anything, "some-user-id", "some-session-id")
+		}
+	})
+
+	t.Run("it should return an un-authorized error", func(t *testing.T) {
+		uuc, uucDeps := genUserUseCase()
+		uucDeps.userRepository.On("GetUserByID", mock.Anything, "some-user-id").Return(mockUserRecord, nil)
+		uucDeps.securityService.
+			On("VerifyPassword", "some-hashed-password", "some-wrong-password").
+			Return(errors.New("some error"))
+
+		err := uuc.ChangePassword(context.Background(), "some-user-id", "some-wrong-password", "some-new-password", nil)
+
+		if assert.Error(t, err) {
+			assert.Equal(t, terr.NewUnAuthorizedError("password doesn't match"), err)
+			uucDeps.userRepository.AssertNotCalled(t, "UpdateUserPassword", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+			uucDeps.securityTokenUseCase.AssertNotCalled(t, "RemoveOtherSessions", mock.Anything, mock.Anything, mock.Anything)
+			uucDeps.unitOfWork.AssertNotCalled(t, "Do", mock.Anything, mock.Anything)
+		}
+	})
+
+	t.Run("it should return an error", func(t *testing.T) {
+		uuc, uucDeps := genUserUseCase()
+		mockError := errors.New("some error")
+		uucDeps.userRepository.On("GetUserByID", mock.Anything, "some-user-id").Return(mockUserRecord, nil)
+		uucDeps.securityService.On("VerifyPassword", "some-hashed-password", "some-password").Return(nil)
+		uucDeps.securityService.On("Hash", "some-new-password").Return(nil, mockError)
+
+		err := uuc.ChangePassword(context.Background(), "some-user-id", "some-password", "some-new-password", nil)
+
+		if assert.Error(t, err) {
+			assert.Equal(t, mockError, err)
+		}
+	})
+
+	t.Run("it should return an error", func(t *testing.T) {
+		uuc, uucDeps := genUserUseCase()
+		mockError := errors.New("some error")
+		uucDeps.userRepository.On("GetUserByID", mock.Anything, "some-user-id").Return(mockUserRecord, nil)
+		uucDeps.securityService.On("VerifyPassword", "some-hashed-password", "some-password").Return(nil)
+		uucDeps.securityService.On("Hash", "some-new-password").Return(mockHashPassword, nil)
+		uucDeps.userRepository.
+			On("UpdateUserPassword", mock.Anything, "some-user-id", string(mockHashPassword), mock.AnythingOfType("time.Time")).
+			Return(nil)
+		uucDeps.securityTokenUseCase.
+			On("RemoveOtherSessions", mock.Anything, "some-user-id", (*auth.TokenMetadata)(nil)).
+			Return(mockError)
+
+		err := uuc.ChangePassword(context.Background(), "some-user-id", "some-password", "some-new-password", nil)
+
+		assert.Equal(t, mockError, err)
+	})
+}
+
+func TestIsUserActive(t *testing.T) {
+	t.Run("it should succeed", func(t *testing.T) {
+		uuc, uucDeps := genUserUseCase()
+		uucDeps.cacheService.On("Get", "active-user:some-user-id").Return(nil, false)
+		uucDeps.userRepository.
+			On("GetUserByID", mock.Anything, "some-user-id").
+			Return(auth.User{ID: "some-user-id", Active: true}, nil)
+		uucDeps.cacheService.On("Set", "active-user:some-user-id", true, activeUserCacheDuration).Return()
+
+		assert.True(t, uuc.IsUserActive(context.Background(), "some-user-id"))
+		uucDeps.cacheService.AssertExpectations(t)
+	})
+
+	t.Run("it should succeed from cache", func(t *testing.T) {
+		uuc, uucDeps := genUserUseCase()
+		uucDeps.cacheService.On("Get", "active-user:some-user-id").Return(true, true)
+
+		assert.True(t, uuc.IsUserActive(context.Background(), "some-user-id"))
+		uucDeps.userRepository.AssertNotCalled(t, "GetUserByID", mock.Anything, mock.Anything)
+	})
+
+	t.Run("it should return false", func(t *testing.T) {
+		uuc, uucDeps := genUserUseCase()
+		uucDeps.cacheService.On("Get", "active-user:some-user-id").Return(nil, false)
+		uucDeps.userRepository.
+			On("GetUserByID", mock.Anything, "some-user-id").
+			Return(auth.User{ID: "some-user-id", Active: false}, nil)
+
+		assert.False(t, uuc.IsUserActive(context.Background(), "some-user-id"))
+		uucDeps.cacheService.AssertNotCalled(t, "Set", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("it should return false", func(t *testing.T) {
+		uuc, uucDeps := genUserUseCase()
+		uucDeps.cacheService.On("Get", "active-user:some-user-id").Return(nil, false)
+		uucDeps.userRepository.
+			On("GetUserByID", mock.Anything, "some-user-id").
+			Return(auth.User{}, terr.NewNotFoundError("user not found"))
+
+		assert.False(t, uuc.IsUserActive(context.Background(), "some-user-id"))
+	})
+}
+
+func TestActivateUser(t *testing.T) {
+	t.Run("it should succeed", func(t *testing.T) {
+		uuc, uucDeps := genUserUseCase()
+		uucDeps.userRepository.On("UpdateUserActive", mock.Anything, "some-user-id", true, mock.Anything).Return(nil)
+		uucDeps.auditLogRepository.
+			On("CreateAuditLog", mock.Anything, mock.MatchedBy(func(al *auth.AuditLog) bool {
+				return al.UserID == "some-user-id" &&
+					al.ActorID == "some-admin-id" &&
+					al.Action == auth.UserActivatedAction
+			})).
+			Return(nil)
+
+		err := uuc.ActivateUser(context.Background(), "some-user-id", "some-admin-id")
+
+		assert.NoError(t, err)
+		uucDeps.auditLogRepository.AssertExpectations(t)
+	})
+
+	t.Run("it should return error", func(t *testing.T) {
+		uuc, uucDeps := genUserUseCase()
+		mockError := terr.NewNotFoundError("user not found")
+		uucDeps.userRepository.On("UpdateUserActive", mock.Anything, "some-user-id", true, mock.Anything).Return(mockError)
+
+		err := uuc.ActivateUser(context.Background(), "some-user-id", "some-admin-id")
+
+		if assert.Error(t, err) {
+			assert.Equal(t, mockError, err)
+			uucDeps.auditLogRepository.AssertNotCalled(t, "CreateAuditLog", mock.Anything, mock.Anything)
+		}
+	})
+
+	t.Run("it should return error", func(t *testing.T) {
+		uuc, uucDeps := genUserUseCase()
+		uucDeps.userRepository.On("UpdateUserActive", mock.Anything, "some-user-id", true, mock.Anything).Return(nil)
+		uucDeps.auditLogRepository.On("CreateAuditLog", mock.Anything, mock.Anything).Return(errors.New("some error"))
+
+		err := uuc.ActivateUser(context.Background(), "some-user-id", "some-admin-id")
+
+		if assert.Error(t, err) {
+			assert.Equal(t, "could not write audit log", err.Error())
+		}
+	})
+}
+
+func TestDeactivateUser(t *testing.T) {
+	t.Run("it should succeed", func(t *testing.T) {
+		uuc, uucDeps := genUserUseCase()
+		uucDeps.userRepository.On("UpdateUserActive", mock.Anything, "some-user-id", false, mock.Anything).Return(nil)
+		uucDeps.cacheService.On("Delete", "active-user:some-user-id").Return()
+		uucDeps.securityTokenUseCase.On("RemoveSessions", mock.Anything, "some-user-id").Return(nil)
+		uucDeps.securityTokenUseCase.
+			On("RemoveOneTimeTokens", mock.Anything, "some-user-id", auth.EmailVerificationTokenType).
+			Return(nil)
+		uucDeps.auditLogRepository.
+			On("CreateAuditLog", mock.Anything, mock.MatchedBy(func(al *auth.AuditLog) bool {
+				return al.UserID == "some-user-id" &&
+					al.ActorID == "some-user-id" &&
+					al.Action == auth.UserDeactivatedAction
+			})).
+			Return(nil)
+
+		err := uuc.DeactivateUser(context.Background(), "some-user-id", "some-user-id")
+
+		assert.NoError(t, err)
+		uucDeps.cacheService.AssertExpectations(t)
+		uucDeps.securityTokenUseCase.AssertExpectations(t)
+		uucDeps.auditLogRepository.AssertExpectations(t)
+	})
+
+	t.Run("it should return error", func(t *testing.T) {
+		uuc, uucDeps := genUserUseCase()
+		mockError := terr.NewNotFoundError("user not found")
+		uucDeps.userRepository.On("UpdateUserActive", mock.Anything, "some-user-id", false, mock.Anything).Return(mockError)
+
+		err := uuc.DeactivateUser(context.Background(), "some-user-id", "some-user-id")
+
+		if assert.Error(t, err) {
+			assert.Equal(t, mockError, err)
+			uucDeps.securityTokenUseCase.AssertNotCalled(t, "RemoveSessions", mock.Anything, mock.Anything)
+		}
+	})
+
+	t.Run("it should return error", func(t *testing.T) {
+		uuc, uucDeps := genUserUseCase()
+		mockError := errors.New("some error")
+		uucDeps.userRepository.On("UpdateUserActive", mock.Anything, "some-user-id", false, mock.Anything).Return(nil)
+		uucDeps.cacheService.On("Delete", "active-user:some-user-id").Return()
+		uucDeps.securityTokenUseCase.On("RemoveSessions", mock.Anything, "some-user-id").Return(mockError)
+
+		err := uuc.DeactivateUser(context.Background(), "some-user-id", "some-user-id")
+
+		if assert.Error(t, err) {
+			assert.Equal(t, mockError, err)
+			uucDeps.auditLogRepository.AssertNotCalled(t, "CreateAuditLog", mock.Anything, mock.Anything)
+		}
+	})
+}
+
+func TestDeleteUser(t *testing.T) {
+	mockUserRecord := auth.User{ID: "some-user-id", Password: "some-hashed-password"}
+
+	t.Run("it should succeed", func(t *testing.T) {
+		uuc, uucDeps := genUserUseCase()
+		uucDeps.userRepository.On("GetUserByID", mock.Anything, "some-user-id").Return(mockUserRecord, nil)
+		uucDeps.securityService.On("VerifyPassword", "some-hashed-password", "some-password").Return(nil)
+		uucDeps.userRepository.On("SoftDeleteUser", mock.Anything, "some-user-id", mock.Anything).Return(nil)
+		uucDeps.cacheService.On("Delete", "active-user:some-user-id").Return()
+		uucDeps.securityTokenUseCase.On("RemoveSessions", mock.Anything, "some-user-id").Return(nil)
+		uucDeps.auditLogRepository.
+			On("CreateAuditLog", mock.Anything, mock.MatchedBy(func(al *auth.AuditLog) bool {
+				return al.UserID == "some-user-id" && al.Action == auth.UserDeletedAction
+			})).
+			Return(nil)
+
+		err := uuc.DeleteUser(context.Background(), "some-user-id", "some-password")
+
+		assert.NoError(t, err)
+		uucDeps.userRepository.AssertExpectations(t)
+		uucDeps.auditLogRepository.AssertExpectations(t)
+	})
+
+	t.Run("it should return an un-authorized error", func(t *testing.T) {
+		uuc, uucDeps := genUserUseCase()
+		uucDeps.userRepository.On("GetUserByID", mock.Anything, "some-user-id").Return(mockUserRecord, nil)
+		uucDeps.securityService.
+			On("VerifyPassword", "some-hashed-password", "some-password").
+			Return(errors.New("some error"))
+
+		err := uuc.DeleteUser(context.Background(), "some-user-id", "some-password")
+
+		if assert.Error(t, err) {
+			assert.Equal(t, terr.NewUnAuthorizedError("password doesn't match"), err)
+			uucDeps.userRepository.AssertNotCalled(t, "SoftDeleteUser", mock.Anything, mock.Anything, mock.Anything)
+		}
+	})
+
+	t.Run("it should return error", func(t *testing.T) {
+		uuc, uucDeps := genUserUseCase()
+		mockError := errors.New("some error")
+		uucDeps.userRepository.On("GetUserByID", mock.Anything, "some-user-id").Return(mockUserRecord, nil)
+		uucDeps.securityService.On("VerifyPassword", "some-hashed-password", "some-password").Return(nil)
+		uucDeps.userRepository.On("SoftDeleteUser", mock.Anything, "some-user-id", mock.Anything).Return(mockError)
+
+		err := uuc.DeleteUser(context.Background(), "some-user-id", "some-password")
+
+		if assert.Error(t, err) {
+			assert.Equal(t, mockError, err)
+			uucDeps.auditLogRepository.AssertNotCalled(t, "CreateAuditLog", mock.Anything, mock.Anything)
+		}
+	})
+}
+
+func TestEraseUser(t *testing.T) {
+	mockUserRecord := auth.User{ID: "some-user-id", Password: "some-hashed-password"}
+
+	t.Run("it should succeed", func(t *testing.T) {
+		uuc, uucDeps := genUserUseCase()
+		uucDeps.userRepository.On("GetUserByID", mock.Anything, "some-user-id").Return(mockUserRecord, nil)
+		uucDeps.securityService.On("VerifyPassword", "some-hashed-password", "some-password").Return(nil)
+		uucDeps.auditLogRepository.
+			On("CreateAuditLog", mock.Anything, mock.MatchedBy(func(al *auth.AuditLog) bool {
+				return al.UserID == "some-user-id" &&
+					al.ActorID == "some-user-id" &&
+					al.Action == auth.UserErasedAction
+			})).
+			Return(nil)
+		uucDeps.userRepository.On("DeleteUser", mock.Anything, "some-user-id").Return(nil)
+		uucDeps.cacheService.On("Delete", "active-user:some-user-id").Return()
+
+		err := uuc.EraseUser(context.Background(), "some-user-id", "some-password")
+
+		assert.NoError(t, err)
+		uucDeps.userRepository.AssertExpectations(t)
+		uucDeps.auditLogRepository.AssertExpectations(t)
+	})
+
+	t.Run("it should return an un-authorized error", func(t *testing.T) {
+		uuc, uucDeps := genUserUseCase()
+		uucDeps.userRepository.On("GetUserByID", mock.Anything, "some-user-id").Return(mockUserRecord, nil)
+		uucDeps.securityService.
+			On("VerifyPassword", "some-hashed-password", "some-password").
+			Return(errors.New("some error"))
+
+		err := uuc.EraseUser(context.Background(), "some-user-id", "some-password")
+
+		if assert.Error(t, err) {
+			assert.Equal(t, terr.NewUnAuthorizedError("password doesn't match"), err)
+			uucDeps.userRepository.AssertNotCalled(t, "DeleteUser", mock.Anything, mock.Anything)
+		}
+	})
+
+	t.Run("it should not erase without an audit log", func(t *testing.T) {
+		uuc, uucDeps := genUserUseCase()
+		uucDeps.userRepository.On("GetUserByID", mock.Anything, "some-user-id").Return(mockUserRecord, nil)
+		uucDeps.securityService.On("VerifyPassword", "some-hashed-password", "some-password").Return(nil)
+		uucDeps.auditLogRepository.On("CreateAuditLog", mock.Anything, mock.Anything).Return(errors.New("some error"))
+
+		err := uuc.EraseUser(context.Background(), "some-user-id", "some-password")
+
+		if assert.Error(t, err) {
+			assert.Equal(t, "could not write audit log", err.Error())
+			uucDeps.userRepository.AssertNotCalled(t, "DeleteUser", mock.Anything, mock.Anything)
+		}
+	})
+
+	t.Run("it should return error", func(t *testing.T) {
+		uuc, uucDeps := genUserUseCase()
+		mockError := errors.New("some error")
+		uucDeps.userRepository.On("GetUserByID", mock.Anything, "some-user-id").Return(mockUserRecord, nil)
+		uucDeps.securityService.On("VerifyPassword", "some-hashed-password", "some-password").Return(nil)
+		uucDeps.auditLogRepository.On("CreateAuditLog", mock.Anything, mock.Anything).Return(nil)
+		uucDeps.userRepository.On("DeleteUser", mock.Anything, "some-user-id").Return(mockError)
+
+		err := uuc.EraseUser(context.Background(), "some-user-id", "some-password")
 
 		if assert.Error(t, err) {
 			assert.Equal(t, mockError, err)